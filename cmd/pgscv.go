@@ -2,19 +2,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 
 	"github.com/cherts/pgscv/discovery/factory"
 	sdlog "github.com/cherts/pgscv/discovery/log"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/cherts/pgscv/internal/collector"
 	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/pgscv"
+	"github.com/cherts/pgscv/plugin"
 	//_ "net/http/pprof"
 )
 
@@ -26,10 +31,42 @@ func main() {
 	var (
 		showVersion = kingpin.Flag("version", "show version and exit").Default().Bool()
 		logLevel    = kingpin.Flag("log-level", "set log level: debug, info, warn, error").Default("info").Envar("LOG_LEVEL").String()
+		logFormat   = kingpin.Flag("log-format", "set log format: json, console").Default("json").Envar("LOG_FORMAT").String()
+		logModules  = kingpin.Flag("log-module-levels", "set log levels of modules, e.g. collector=warn,store=debug").Default("").Envar("LOG_MODULE_LEVELS").String()
+		logDedup    = kingpin.Flag("log-dedup-interval", "suppress repeating collectors errors during interval, 0 disables suppression").Default("5m").Envar("LOG_DEDUP_INTERVAL").Duration()
 		configFile  = kingpin.Flag("config-file", "path to config file").Default("").Envar("PGSCV_CONFIG_FILE").String()
+
+		runCommand         = kingpin.Command("run", "run pgSCV (default)").Default()
+		once               = runCommand.Flag("once", "collect metrics once, write them and exit").Bool()
+		onceOutput         = runCommand.Flag("once-output", "file where metrics collected once are written, stdout by default").Default("-").String()
+		onceServices       = runCommand.Flag("once-services", "comma-separated IDs of services collected once, all services by default").Default("").String()
+		printQueries       = runCommand.Flag("print-queries", "print queries executed by enabled collectors of the service and exit").Bool()
+		printService       = runCommand.Flag("service", "ID of the service which queries are printed").Default("").String()
+		checkConfigCommand = kingpin.Command("check-config", "validate configuration and exit")
+		checkConnect       = checkConfigCommand.Flag("connect", "test connections to services defined in configuration").Bool()
+		listCommand        = kingpin.Command("list-collectors", "print collectors and metric families produced by them and exit")
+		grantCommand       = kingpin.Command("grant-sql", "print SQL creating role and granting privileges required by enabled collectors and exit")
+		grantService       = grantCommand.Flag("service", "ID of Postgres service which version and extensions are detected").Default("").String()
+		grantDSN           = grantCommand.Flag("dsn", "superuser connection string used for detection and applying instead of service's one").Default("").String()
+		grantRole          = grantCommand.Flag("role", "name of the role used by pgSCV").Default("pgscv").String()
+		grantPassword      = grantCommand.Flag("password", "password of the role, password is not changed by default").Default("").String()
+		grantApply         = grantCommand.Flag("apply", "execute generated SQL using connection passed in --dsn").Bool()
 	)
-	kingpin.Parse()
+	command := kingpin.Parse()
+	// Metrics collected once, printed queries and generated SQL are written to stdout, keep it clean from log messages.
+	if (*once && *onceOutput == "-") || *printQueries || command == grantCommand.FullCommand() {
+		log.SetOutput(os.Stderr)
+	}
+	if err := log.SetFormat(*logFormat); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	log.SetLevel(*logLevel)
+	if err := log.SetModuleLevels(*logModules); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	log.SetDedupInterval(*logDedup)
 	log.SetApplication(appName)
 	sdlog.Logger.Debug = log.Debug
 	sdlog.Logger.Errorf = log.Errorf
@@ -40,29 +77,34 @@ func main() {
 		os.Exit(0)
 	}
 
+	switch command {
+	case checkConfigCommand.FullCommand():
+		os.Exit(checkConfig(*configFile, *checkConnect))
+	case listCommand.FullCommand():
+		os.Exit(listCollectors())
+	case grantCommand.FullCommand():
+		os.Exit(grantSQL(*configFile, *grantService, *grantDSN, *grantRole, *grantPassword, *grantApply))
+	case runCommand.FullCommand():
+	}
+
 	log.Infoln("starting ", appName, " ", gitTag, " (", runtime.GOARCH, ") ", gitCommit, "-", gitBranch)
 
 	//go func() {
 	//	log.Infoln(http.ListenAndServe(":6060", nil))
 	//}()
 
-	config, err := pgscv.NewConfig(*configFile)
+	config, err := newConfig(*configFile)
 	if err != nil {
-		log.Errorln("create config failed: ", err)
+		log.Errorln(err)
 		os.Exit(1)
 	}
 
-	if config.DiscoveryConfig != nil {
-		config.DiscoveryServices, err = factory.Instantiate(*config.DiscoveryConfig)
-		if err != nil {
-			log.Errorln("instantiate service discovery failed: ", err)
-			os.Exit(1)
-		}
+	if *once {
+		os.Exit(collectOnce(config, *onceServices, *onceOutput))
 	}
 
-	if err := config.Validate(); err != nil {
-		log.Errorln("validate config failed: ", err)
-		os.Exit(1)
+	if *printQueries {
+		os.Exit(printServiceQueries(config, *printService))
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -81,9 +123,164 @@ func main() {
 	log.Warnf("received shutdown signal: '%s'", <-doExit)
 }
 
+// newConfig reads configuration, instantiates service discovery and validates configuration.
+func newConfig(configFile string) (*pgscv.Config, error) {
+	config, err := pgscv.NewConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("create config failed: %w", err)
+	}
+
+	// Service types implemented by plugins must be registered before validating services using them.
+	if err := plugin.Load(config.Plugins); err != nil {
+		return nil, fmt.Errorf("load plugins failed: %w", err)
+	}
+
+	if config.DiscoveryConfig != nil {
+		config.DiscoveryServices, err = factory.Instantiate(*config.DiscoveryConfig)
+		if err != nil {
+			return nil, fmt.Errorf("instantiate service discovery failed: %w", err)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("validate config failed: %w", err)
+	}
+
+	return config, nil
+}
+
+// checkConfig validates configuration and optionally tests connections to services, returns exit code.
+func checkConfig(configFile string, connect bool) int {
+	config, err := newConfig(configFile)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if connect {
+		if err := config.CheckConnections(); err != nil {
+			fmt.Printf("check connections failed:\n%s\n", err)
+			return 1
+		}
+	}
+
+	fmt.Println("configuration is valid")
+	return 0
+}
+
+// listCollectors prints collectors and metric families produced by them, returns exit code.
+func listCollectors() int {
+	for _, info := range collector.ListCollectors() {
+		fmt.Println(info.Name)
+		for _, m := range info.Metrics {
+			fmt.Println("  " + m.Name)
+		}
+	}
+	return 0
+}
+
+// grantSQL prints statements creating role and granting privileges required by enabled Postgres collectors, optionally
+// applies them using superuser connection. Returns exit code.
+func grantSQL(configFile, serviceID, dsn, role, password string, apply bool) int {
+	if apply && dsn == "" {
+		fmt.Println("superuser connection string is required for applying, use --dsn flag")
+		return 1
+	}
+
+	config, err := newConfig(configFile)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	connString := dsn
+	if connString == "" {
+		s, ok := config.ServicesConnsSettings[serviceID]
+		if !ok || s.ServiceType != model.ServiceTypePostgresql {
+			fmt.Printf("postgres service '%s' is not defined in configuration, use --service or --dsn flags\n", serviceID)
+			return 1
+		}
+		connString = s.Conninfo
+	}
+
+	statements, err := collector.PrivilegesSQL(connString, config.ConnTimeout, role, password, config.DisableCollectors)
+	if err != nil {
+		fmt.Printf("detect Postgres settings failed: %s\n", err)
+		return 1
+	}
+
+	for _, s := range statements {
+		if strings.HasPrefix(s, "--") {
+			fmt.Println(s)
+		} else {
+			fmt.Println(s + ";")
+		}
+	}
+
+	if apply {
+		if err := collector.ApplyPrivileges(dsn, config.ConnTimeout, statements); err != nil {
+			fmt.Printf("apply privileges failed: %s\n", err)
+			return 1
+		}
+		fmt.Println("-- privileges have been applied")
+	}
+
+	return 0
+}
+
+// collectOnce collects metrics of services once and writes them into output file, returns exit code. File is replaced
+// atomically, so it could be read by textfile collector of node_exporter at any time.
+func collectOnce(config *pgscv.Config, services string, output string) int {
+	var serviceIDs []string
+	for id := range strings.SplitSeq(services, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			serviceIDs = append(serviceIDs, id)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pgscv.CollectOnce(config, serviceIDs, &buf); err != nil {
+		log.Errorln("collect metrics failed: ", err)
+		return 1
+	}
+
+	if output == "-" {
+		_, _ = os.Stdout.Write(buf.Bytes())
+		return 0
+	}
+
+	tmp := output + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil { // #nosec G306
+		log.Errorln("write metrics failed: ", err)
+		return 1
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		_ = os.Remove(tmp)
+		log.Errorln("write metrics failed: ", err)
+		return 1
+	}
+
+	return 0
+}
+
 func listenSignals() error {
 	c := make(chan os.Signal, 1)
 	defer signal.Stop(c)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 	return fmt.Errorf("%s", <-c)
 }
+
+// printServiceQueries prints queries executed by enabled collectors of the service, returns exit code.
+func printServiceQueries(config *pgscv.Config, serviceID string) int {
+	if serviceID == "" {
+		log.Errorln("service is not specified, use --service flag")
+		return 1
+	}
+
+	if err := pgscv.PrintQueries(config, serviceID, os.Stdout); err != nil {
+		log.Errorln("print queries failed: ", err)
+		return 1
+	}
+
+	return 0
+}
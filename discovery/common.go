@@ -8,8 +8,10 @@ import (
 // Config abstract configuration SdConfig.config
 type Config any
 
-// Service abstract service definition
+// Service abstract service definition. ServiceType is empty for Postgres services, DSN of HTTP services (e.g. Patroni)
+// is the base URL.
 type Service struct {
+	ServiceType  string
 	DSN          string
 	ConstLabels  map[string]string
 	TargetLabels map[string]string
@@ -36,6 +38,16 @@ type Discovery interface {
 const (
 	// YandexMDB constant SdConfig.type
 	YandexMDB = "yandex-mdb"
+	// Replicas constant SdConfig.type
+	Replicas = "replicas"
+	// Local constant SdConfig.type
+	Local = "local"
+	// PostgresOperator constant SdConfig.type
+	PostgresOperator = "postgres-operator"
+	// AzureFlexibleServer constant SdConfig.type
+	AzureFlexibleServer = "azure-flexible-server"
+	// GCPCloudSQL constant SdConfig.type
+	GCPCloudSQL = "gcp-cloudsql"
 )
 
 // SdConfig top level of configuration tree
@@ -27,6 +27,16 @@ func Instantiate(discoveryConfig discovery.Config) (*map[string]discovery.Discov
 		switch srv.Type {
 		case discovery.YandexMDB:
 			services[id] = service.NewYandexDiscovery()
+		case discovery.Replicas:
+			services[id] = service.NewReplicasDiscovery()
+		case discovery.Local:
+			services[id] = service.NewLocalDiscovery()
+		case discovery.PostgresOperator:
+			services[id] = service.NewOperatorDiscovery()
+		case discovery.AzureFlexibleServer:
+			services[id] = service.NewAzureDiscovery()
+		case discovery.GCPCloudSQL:
+			services[id] = service.NewGCPDiscovery()
 		default:
 			err := fmt.Errorf("[SD] Unknown service discovery type '%s'", srv.Type)
 			log.Debug(err.Error())
@@ -38,6 +38,51 @@ func TestInstantiate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "succeed replicas service",
+			cfg: map[string]discovery.SdConfig{
+				"replicas1": {
+					Type: discovery.Replicas,
+					Config: []service.ReplicasConfig{
+						{
+							PrimaryDSN:  "postgres://pgscv@127.0.0.1:5432/postgres",
+							DSNTemplate: "postgres://pgscv@{{ .Host }}:{{ .Port }}/postgres",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid replicas service",
+			cfg: map[string]discovery.SdConfig{
+				"replicas1": {
+					Type:   discovery.Replicas,
+					Config: []service.ReplicasConfig{{PrimaryDSN: "postgres://pgscv@127.0.0.1:5432/postgres"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "succeed local service",
+			cfg: map[string]discovery.SdConfig{
+				"local1": {
+					Type:   discovery.Local,
+					Config: service.LocalConfig{ServiceTypes: []string{"postgres", "pgbouncer"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid local service",
+			cfg: map[string]discovery.SdConfig{
+				"local1": {
+					Type:   discovery.Local,
+					Config: service.LocalConfig{ServiceTypes: []string{"mysql"}},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "wrong single service",
 			cfg: map[string]discovery.SdConfig{
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// snapshotCollector is implemented by collectors which cached stats could be persisted across restarts of pgSCV.
+type snapshotCollector interface {
+	// snapshot returns stats cached by collector, false is returned if there are no stats.
+	snapshot() (CachedStats, bool)
+	// restore replaces stats cached by collector with stats taken from snapshot.
+	restore(s CachedStats) error
+}
+
+// CachedStats defines stats cached by collector and time when the stats have been collected.
+type CachedStats struct {
+	Updated time.Time       `json:"updated"`
+	Stats   json.RawMessage `json:"stats"`
+}
+
+// CacheSnapshot defines stats cached by collectors of the service, by names of collectors.
+type CacheSnapshot map[string]CachedStats
+
+// CacheSnapshot returns stats cached by collectors of the service.
+func (n *PgscvCollector) CacheSnapshot() CacheSnapshot {
+	snapshot := CacheSnapshot{}
+	for name, c := range n.Collectors {
+		sc, ok := c.(snapshotCollector)
+		if !ok {
+			continue
+		}
+		if s, ok := sc.snapshot(); ok {
+			snapshot[name] = s
+		}
+	}
+
+	return snapshot
+}
+
+// RestoreCache restores stats cached by collectors of the service. Restored stats are treated as collected at the
+// time of the snapshot, so collectors don't repeat heavy work until their intervals are passed.
+func (n *PgscvCollector) RestoreCache(snapshot CacheSnapshot) {
+	for name, s := range snapshot {
+		sc, ok := n.Collectors[name].(snapshotCollector)
+		if !ok {
+			continue
+		}
+		if err := sc.restore(s); err != nil {
+			collectorLog.Warnf("restore cached stats of %s collector failed: %s; skip", name, err)
+		}
+	}
+}
+
+// marshalCachedStats returns snapshot of passed stats collected at passed time.
+func marshalCachedStats(updated time.Time, stats any) (CachedStats, bool) {
+	if updated.IsZero() {
+		return CachedStats{}, false
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		collectorLog.Warnf("marshal cached stats failed: %s; skip", err)
+		return CachedStats{}, false
+	}
+
+	return CachedStats{Updated: updated, Stats: data}, true
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgscvCollector_CacheSnapshot(t *testing.T) {
+	updated := time.Now().Add(-time.Hour).Round(time.Second)
+
+	checksums, err := NewPostgresChecksumsCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	checksums.(*postgresChecksumsCollector).verifyStats = map[string]checksumsVerifyStat{"db1": {pages: 100, failures: 1}}
+	checksums.(*postgresChecksumsCollector).verifyLastTime = updated
+	checksums.(*postgresChecksumsCollector).verifyDuration = 12.5
+
+	buffercache, err := NewPostgresBuffercacheCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	buffercache.(*postgresBuffercacheCollector).stats = postgresBuffercacheStats{
+		databases:   map[string]postgresBuffercacheUsage{"db1": {buffers: 10, dirty: 1}},
+		usagecounts: map[string]float64{"1": 5, "5": 5},
+		unused:      100,
+		relations:   []postgresBuffercacheRelation{{database: "db1", schema: "public", relation: "t1", postgresBuffercacheUsage: postgresBuffercacheUsage{buffers: 8, dirty: 1}}},
+	}
+	buffercache.(*postgresBuffercacheCollector).lastUpdated = updated
+
+	objects, err := NewPostgresObjectsCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	objects.(*postgresObjectsCollector).stats = []postgresObjectsStat{{database: "db1", largeObjects: 2, largeObjectsBytes: 8192, toastTables: 3, toastBytes: 16384}}
+	objects.(*postgresObjectsCollector).lastUpdated = updated
+
+	pgvector, err := NewPostgresPgvectorCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	pgvector.(*postgresPgvectorCollector).stats = []postgresPgvectorIndexStat{{database: "db1", schema: "public", table: "t1", index: "t1_idx", method: "ivfflat", sizebytes: 8192, lists: 100}}
+	pgvector.(*postgresPgvectorCollector).lastUpdated = updated
+
+	// Collectors without cached stats are not included into snapshot.
+	empty, err := NewPostgresChecksumsCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+
+	c := &PgscvCollector{Collectors: map[string]Collector{
+		"postgres/checksums": checksums, "postgres/buffercache": buffercache, "postgres/objects": objects,
+		"postgres/pgvector": pgvector, "postgres/empty": empty,
+	}}
+	snapshot := c.CacheSnapshot()
+	assert.Len(t, snapshot, 4)
+	assert.True(t, updated.Equal(snapshot["postgres/checksums"].Updated))
+
+	restoredChecksums, err := NewPostgresChecksumsCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+
+	restoredBuffercache, err := NewPostgresBuffercacheCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+
+	restoredObjects, err := NewPostgresObjectsCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+
+	restoredPgvector, err := NewPostgresPgvectorCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+
+	restored := &PgscvCollector{Collectors: map[string]Collector{
+		"postgres/checksums": restoredChecksums, "postgres/buffercache": restoredBuffercache,
+		"postgres/objects": restoredObjects, "postgres/pgvector": restoredPgvector,
+	}}
+	restored.RestoreCache(snapshot)
+
+	got := restoredChecksums.(*postgresChecksumsCollector)
+	assert.Equal(t, map[string]checksumsVerifyStat{"db1": {pages: 100, failures: 1}}, got.verifyStats)
+	assert.True(t, updated.Equal(got.verifyLastTime))
+	assert.Equal(t, 12.5, got.verifyDuration)
+	assert.Equal(t, buffercache.(*postgresBuffercacheCollector).stats, restoredBuffercache.(*postgresBuffercacheCollector).stats)
+	assert.True(t, updated.Equal(restoredBuffercache.(*postgresBuffercacheCollector).lastUpdated))
+	assert.Equal(t, objects.(*postgresObjectsCollector).stats, restoredObjects.(*postgresObjectsCollector).stats)
+	assert.True(t, updated.Equal(restoredObjects.(*postgresObjectsCollector).lastUpdated))
+	assert.Equal(t, pgvector.(*postgresPgvectorCollector).stats, restoredPgvector.(*postgresPgvectorCollector).stats)
+	assert.True(t, updated.Equal(restoredPgvector.(*postgresPgvectorCollector).lastUpdated))
+
+	// Invalid stats are skipped.
+	restored.RestoreCache(CacheSnapshot{"postgres/checksums": {Updated: updated, Stats: []byte(`{`)}})
+	assert.Equal(t, map[string]checksumsVerifyStat{"db1": {pages: 100, failures: 1}}, got.verifyStats)
+}
@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricInfo defines metric family produced by collector.
+type MetricInfo struct {
+	Name   string
+	Help   string
+	Type   prometheus.ValueType
+	Labels []string // variable labels, service labels are not listed
+}
+
+// CollectorInfo defines collector and metric families produced by the collector.
+type CollectorInfo struct {
+	Name    string
+	Metrics []MetricInfo
+}
+
+// ListCollectors returns all collectors and metric families produced by them, sorted by names. Catalog is static and
+// kept in sync with collectors by tests, hence collectors are not created and metrics defined by user in collectors
+// settings are not listed.
+func ListCollectors() []CollectorInfo {
+	infos := make([]CollectorInfo, 0, len(collectorsCatalog))
+	for _, info := range collectorsCatalog {
+		metrics := slices.Clone(info.Metrics)
+		for i := range metrics {
+			metrics[i].Labels = slices.Clone(metrics[i].Labels)
+		}
+		infos = append(infos, CollectorInfo{Name: info.Name, Metrics: metrics})
+	}
+	return infos
+}
+
+// IsVariableLabel returns true if the label is used as variable label by metrics of builtin collectors. Service labels
+// with such names would clash with labels of these metrics.
+func IsVariableLabel(name string) bool {
+	for _, info := range collectorsCatalog {
+		for _, m := range info.Metrics {
+			if slices.Contains(m.Labels, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsBuiltinMetric returns true if the metric family with passed name is produced by builtin collectors.
+func IsBuiltinMetric(name string) bool {
+	for _, info := range collectorsCatalog {
+		if slices.ContainsFunc(info.Metrics, func(m MetricInfo) bool { return m.Name == name }) {
+			return true
+		}
+	}
+	return false
+}
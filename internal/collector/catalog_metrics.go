@@ -0,0 +1,534 @@
+// Code generated by Test_collectorsCatalog using -update-catalog flag. DO NOT EDIT.
+
+package collector
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// collectorsCatalog defines collectors and metric families produced by them, sorted by names.
+var collectorsCatalog = []CollectorInfo{
+	{Name: "http/custom", Metrics: []MetricInfo{
+		{Name: "pgscv_http_request_success", Help: "Whether the last request of the URL succeeded (1) or failed (0).", Type: prometheus.GaugeValue, Labels: []string{"subsystem"}},
+	}},
+	{Name: "patroni/common", Metrics: []MetricInfo{
+		{Name: "patroni_cluster_unlocked", Help: "Value is 1 if the cluster is unlocked, 0 if locked.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_config_drift", Help: "Value is 1 if DCS configuration cached by the node differs from the one cached by the leader, e.g. the node misses DCS updates, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_dcs_last_seen", Help: "Epoch timestamp when DCS was last contacted successfully by Patroni.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_failsafe_mode_is_active", Help: "Value is 1 if failsafe mode is active, 0 if inactive.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_is_paused", Help: "Value is 1 if auto failover is disabled, 0 otherwise.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_last_timeline_change_seconds", Help: "Epoch seconds since latest timeline switched.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_loop_wait", Help: "Current loop_wait setting of the Patroni configuration.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_master", Help: "Value is 1 if this node is the leader, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_maximum_lag_on_failover", Help: "Current maximum_lag_on_failover setting of the Patroni configuration.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_node_name", Help: "Node name.", Type: prometheus.GaugeValue, Labels: []string{"scope", "node_name"}},
+		{Name: "patroni_node_tag_info", Help: "Labeled info about tags of the node, e.g. nofailover, clonefrom, replicatefrom.", Type: prometheus.GaugeValue, Labels: []string{"scope", "tag", "value"}},
+		{Name: "patroni_pending_restart", Help: "Value is 1 if the node needs a restart, 0 otherwise.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_postgres_in_archive_recovery", Help: "Value is 1 if Postgres is replicating from archive, 0 otherwise.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_postgres_running", Help: "Value is 1 if Postgres is running, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_postgres_server_version", Help: "Version of Postgres (if running), 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_postgres_streaming", Help: "Value is 1 if Postgres is streaming, 0 otherwise.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_postgres_timeline", Help: "Postgres timeline of this node (if running), 0 otherwise.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_postmaster_start_time", Help: "Epoch seconds since Postgres started.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_replica", Help: "Value is 1 if this node is a replica, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_retry_timeout", Help: "Current retry_timeout setting of the Patroni configuration.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_standby_leader", Help: "Value is 1 if this node is the standby_leader, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_sync_standby", Help: "Value is 1 if synchronous mode is active, 0 if inactive.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_ttl", Help: "Current ttl setting of the Patroni configuration.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_up", Help: "State of Patroni service: 1 is up, 0 otherwise.", Type: prometheus.GaugeValue},
+		{Name: "patroni_version", Help: "Numeric representation of Patroni version.", Type: prometheus.GaugeValue, Labels: []string{"scope", "version"}},
+		{Name: "patroni_xlog_location", Help: "Current location of the Postgres transaction log, 0 if this node is a replica.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_xlog_paused", Help: "Value is 1 if the replaying of Postgres transaction log is paused, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+		{Name: "patroni_xlog_received_location", Help: "Current location of the received Postgres transaction log, 0 if this node is the leader.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_xlog_replayed_location", Help: "Current location of the replayed Postgres transaction log, 0 if this node is the leader.", Type: prometheus.CounterValue, Labels: []string{"scope"}},
+		{Name: "patroni_xlog_replayed_timestamp", Help: "Current timestamp of the replayed Postgres transaction log, 0 if null.", Type: prometheus.GaugeValue, Labels: []string{"scope"}},
+	}},
+	{Name: "patroni/custom"},
+	{Name: "patroni/pgscv", Metrics: []MetricInfo{
+		{Name: "pgscv_services_registered_total", Help: "Total number of services registered by pgSCV.", Type: prometheus.GaugeValue, Labels: []string{"service"}},
+	}},
+	{Name: "patroni/probe", Metrics: []MetricInfo{
+		{Name: "pgscv_probe_duration_seconds", Help: "Duration of the last successful probe of service endpoint (TCP connect time or ICMP round-trip time), in seconds.", Type: prometheus.GaugeValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_failures_total", Help: "Total number of failed probes of service endpoint.", Type: prometheus.CounterValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_success", Help: "Whether the last probe of service endpoint succeeded (1) or failed (0).", Type: prometheus.GaugeValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_total", Help: "Total number of probes of service endpoint.", Type: prometheus.CounterValue, Labels: []string{"address", "probe"}},
+	}},
+	{Name: "pgbouncer/custom"},
+	{Name: "pgbouncer/pgscv", Metrics: []MetricInfo{
+		{Name: "pgscv_services_registered_total", Help: "Total number of services registered by pgSCV.", Type: prometheus.GaugeValue, Labels: []string{"service"}},
+	}},
+	{Name: "pgbouncer/pools", Metrics: []MetricInfo{
+		{Name: "pgbouncer_client_connections_in_flight", Help: "The total number of client connections established by source address.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "address"}},
+		{Name: "pgbouncer_pool_connections_in_flight", Help: "The total number of connections established by each state.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "pool_mode", "state", "backend_service_id"}},
+		{Name: "pgbouncer_pool_max_wait_seconds", Help: "Total time the first (oldest) client in the queue has waited, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "pool_mode", "backend_service_id"}},
+	}},
+	{Name: "pgbouncer/probe", Metrics: []MetricInfo{
+		{Name: "pgscv_probe_duration_seconds", Help: "Duration of the last successful probe of service endpoint (TCP connect time or ICMP round-trip time), in seconds.", Type: prometheus.GaugeValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_failures_total", Help: "Total number of failed probes of service endpoint.", Type: prometheus.CounterValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_success", Help: "Whether the last probe of service endpoint succeeded (1) or failed (0).", Type: prometheus.GaugeValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_total", Help: "Total number of probes of service endpoint.", Type: prometheus.CounterValue, Labels: []string{"address", "probe"}},
+	}},
+	{Name: "pgbouncer/settings", Metrics: []MetricInfo{
+		{Name: "pgbouncer_service_config_reloads_total", Help: "Total number of Pgbouncer configuration reloads detected by changes of effective configuration.", Type: prometheus.CounterValue},
+		{Name: "pgbouncer_service_database_pool_size", Help: "Maximum size of pools for the database.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "pgbouncer_service_database_settings_info", Help: "Labeled information about Pgbouncer's per-database configuration settings.", Type: prometheus.GaugeValue, Labels: []string{"database", "mode", "size"}},
+		{Name: "pgbouncer_service_non_default_settings_info", Help: "Labeled information about Pgbouncer configuration settings which differ from defaults.", Type: prometheus.GaugeValue, Labels: []string{"name", "setting", "default"}},
+		{Name: "pgbouncer_service_settings_hash_info", Help: "Labeled information about hash of Pgbouncer effective configuration.", Type: prometheus.GaugeValue, Labels: []string{"hash"}},
+		{Name: "pgbouncer_service_settings_info", Help: "Labeled information about Pgbouncer configuration settings.", Type: prometheus.GaugeValue, Labels: []string{"name", "setting"}},
+		{Name: "pgbouncer_version", Help: "Numeric representation of Pgbouncer version.", Type: prometheus.GaugeValue, Labels: []string{"version"}},
+	}},
+	{Name: "pgbouncer/stats", Metrics: []MetricInfo{
+		{Name: "pgbouncer_bytes_total", Help: "Total volume of network traffic processed by pgbouncer in each direction, in bytes.", Type: prometheus.CounterValue, Labels: []string{"database", "type"}},
+		{Name: "pgbouncer_prepared_statements_binds_total", Help: "Total number of prepared statements bind requests sent by clients.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "pgbouncer_prepared_statements_cache_hits_total", Help: "Estimated total number of clients parse requests served by statements already prepared on servers.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "pgbouncer_prepared_statements_cached", Help: "Number of prepared statements cached on server connections, for each pool.", Type: prometheus.GaugeValue, Labels: []string{"user", "database"}},
+		{Name: "pgbouncer_prepared_statements_cached_max", Help: "Max number of prepared statements cached on single server connection, for each pool. Compare with max_prepared_statements.", Type: prometheus.GaugeValue, Labels: []string{"user", "database"}},
+		{Name: "pgbouncer_prepared_statements_parses_total", Help: "Total number of prepared statements parse requests, sent by clients or sent to servers by pgbouncer.", Type: prometheus.CounterValue, Labels: []string{"database", "side"}},
+		{Name: "pgbouncer_queries_total", Help: "Total number of SQL queries processed, for each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "pgbouncer_spent_seconds_total", Help: "Total number of time spent by pgbouncer when connected to PostgreSQL executing queries or processing transactions, in seconds.", Type: prometheus.CounterValue, Labels: []string{"database", "type", "mode"}},
+		{Name: "pgbouncer_transactions_total", Help: "Total number of SQL transactions processed, for each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "pgbouncer_up", Help: "State of Pgbouncer service: 0 is down, 1 is up.", Type: prometheus.CounterValue},
+	}},
+	{Name: "postgres/activity", Metrics: []MetricInfo{
+		{Name: "postgres_activity_application_connections_in_flight", Help: "Number of connections in-flight of each application in each state, top applications only.", Type: prometheus.GaugeValue, Labels: []string{"application", "state"}},
+		{Name: "postgres_activity_connections_all_in_flight", Help: "Number of all connections in-flight.", Type: prometheus.GaugeValue},
+		{Name: "postgres_activity_connections_in_flight", Help: "Number of connections in-flight in each state.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "state"}},
+		{Name: "postgres_activity_max_seconds", Help: "Longest activity for each user, database and activity type.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "state", "type"}},
+		{Name: "postgres_activity_prepared_transactions_in_flight", Help: "Number of transactions that are currently prepared for two-phase commit.", Type: prometheus.GaugeValue},
+		{Name: "postgres_activity_queries_in_flight", Help: "Number of queries running in-flight of each type.", Type: prometheus.GaugeValue, Labels: []string{"type"}},
+		{Name: "postgres_activity_vacuums_in_flight", Help: "Number of vacuum operations running in-flight of each type.", Type: prometheus.GaugeValue, Labels: []string{"type"}},
+		{Name: "postgres_activity_wait_events_in_flight", Help: "Number of wait events in-flight in each state.", Type: prometheus.GaugeValue, Labels: []string{"type", "event"}},
+		{Name: "postgres_start_time_seconds", Help: "Postgres start time, in unixtime.", Type: prometheus.GaugeValue},
+		{Name: "postgres_up", Help: "State of PostgreSQL service: 0 is down, 1 is up.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "postgres/archiver", Metrics: []MetricInfo{
+		{Name: "postgres_archiver_archived_total", Help: "Total number of WAL segments had been successfully archived.", Type: prometheus.CounterValue},
+		{Name: "postgres_archiver_failed_total", Help: "Total number of attempts when WAL segments had been failed to archive.", Type: prometheus.CounterValue},
+		{Name: "postgres_archiver_lag_bytes", Help: "Amount of WAL segments ready, but not archived, in bytes.", Type: prometheus.GaugeValue},
+		{Name: "postgres_archiver_since_last_archive_seconds", Help: "Number of seconds since last WAL segment had been successfully archived.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "postgres/auth_config", Metrics: []MetricInfo{
+		{Name: "postgres_auth_config_errors", Help: "Number of rules with errors in authentication configuration file.", Type: prometheus.GaugeValue, Labels: []string{"file"}},
+		{Name: "postgres_auth_config_info", Help: "Labeled information about authentication configuration file contents.", Type: prometheus.GaugeValue, Labels: []string{"file", "hash"}},
+		{Name: "postgres_auth_config_rules", Help: "Number of rules in authentication configuration file.", Type: prometheus.GaugeValue, Labels: []string{"file"}},
+	}},
+	{Name: "postgres/bgwriter", Metrics: []MetricInfo{
+		{Name: "postgres_backends_allocated_bytes_total", Help: "Total number of bytes allocated by backends.", Type: prometheus.CounterValue},
+		{Name: "postgres_backends_fsync_total", Help: "Total number of times a backends had to execute its own fsync() call.", Type: prometheus.CounterValue},
+		{Name: "postgres_bgwriter_maxwritten_clean_total", Help: "Total number of times the background writer stopped a cleaning scan because it had written too many buffers.", Type: prometheus.CounterValue},
+		{Name: "postgres_bgwriter_stats_age_seconds_total", Help: "The age of the background writer activity statistics, in seconds.", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_all_total", Help: "Total number of checkpoints that have been performed.", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_restartpoints_done", Help: "Number of restartpoints that have been performed (since v17).", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_restartpoints_req", Help: "Number of requested restartpoints (since v17).", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_restartpoints_timed", Help: "Number of scheduled restartpoints due to timeout or after a failed attempt to perform it (since v17).", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_seconds_all_total", Help: "Total amount of time that has been spent processing data during checkpoint, in seconds.", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_seconds_total", Help: "Total amount of time that has been spent processing data during checkpoint in each stage, in seconds.", Type: prometheus.CounterValue, Labels: []string{"stage"}},
+		{Name: "postgres_checkpoints_stats_age_seconds_total", Help: "The age of the checkpointer activity statistics, in seconds (since v17).", Type: prometheus.CounterValue},
+		{Name: "postgres_checkpoints_total", Help: "Total number of checkpoints that have been performed of each type.", Type: prometheus.CounterValue, Labels: []string{"checkpoint"}},
+		{Name: "postgres_written_bytes_total", Help: "Total number of bytes written by each subsystem, in bytes.", Type: prometheus.CounterValue, Labels: []string{"process"}},
+	}},
+	{Name: "postgres/buffercache", Metrics: []MetricInfo{
+		{Name: "postgres_buffercache_buffers", Help: "Number of shared buffers used by relations of the database.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_buffercache_dirty_buffers", Help: "Number of dirty shared buffers used by relations of the database.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_buffercache_relation_buffers", Help: "Number of shared buffers used by the relation, top relations only.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "relation"}},
+		{Name: "postgres_buffercache_relation_dirty_buffers", Help: "Number of dirty shared buffers used by the relation, top relations only.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "relation"}},
+		{Name: "postgres_buffercache_unused_buffers", Help: "Number of unused shared buffers.", Type: prometheus.GaugeValue},
+		{Name: "postgres_buffercache_usagecount_buffers", Help: "Number of used shared buffers by clock-sweep usage count.", Type: prometheus.GaugeValue, Labels: []string{"usagecount"}},
+	}},
+	{Name: "postgres/checksums", Metrics: []MetricInfo{
+		{Name: "postgres_checksums_enabled", Help: "Value is 1 if data checksums are enabled, 0 otherwise.", Type: prometheus.GaugeValue},
+		{Name: "postgres_checksums_last_verify_duration_seconds", Help: "Duration of the last data files verification, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "postgres_checksums_last_verify_seconds", Help: "Time when the last data files verification has been finished, in unixtime.", Type: prometheus.GaugeValue},
+		{Name: "postgres_checksums_verified_pages_total", Help: "Total number of data pages verified by pgSCV.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_checksums_verify_failures_total", Help: "Total number of data pages with invalid checksum found by pgSCV.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+	}},
+	{Name: "postgres/conflicts", Metrics: []MetricInfo{
+		{Name: "postgres_recovery_conflict_blocking_backends", Help: "Number of backends blocking the startup process by recovery conflict, by conflict type.", Type: prometheus.GaugeValue, Labels: []string{"conflict"}},
+		{Name: "postgres_recovery_conflict_waiting_seconds", Help: "Time the startup process is waiting on recovery conflict, by conflict type, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"conflict"}},
+		{Name: "postgres_recovery_conflicts_total", Help: "Total number of recovery conflicts occurred by each conflict type.", Type: prometheus.CounterValue, Labels: []string{"database", "conflict"}},
+		{Name: "postgres_recovery_pause_state", Help: "Current WAL replay pause state of the standby, 1 for the current state.", Type: prometheus.GaugeValue, Labels: []string{"state"}},
+	}},
+	{Name: "postgres/custom"},
+	{Name: "postgres/databases", Metrics: []MetricInfo{
+		{Name: "postgres_database_blk_time_seconds_total", Help: "Total time spent accessing data blocks by backends in this database in each access type, in seconds.", Type: prometheus.CounterValue, Labels: []string{"database", "type"}},
+		{Name: "postgres_database_blocks_total", Help: "Total number of disk blocks had been accessed by each type of access.", Type: prometheus.CounterValue, Labels: []string{"database", "access"}},
+		{Name: "postgres_database_checksum_failures_total", Help: "Total number of checksum failures occurred.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_conflicts_total", Help: "Total number of recovery conflicts occurred.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_deadlocks_total", Help: "Total number of deadlocks occurred.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_last_checksum_failure_seconds", Help: "Time of the last checksum failure occurred, in unixtime.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_parallel_workers", Help: "Total number of parallel workers to launch and launched in this database.", Type: prometheus.CounterValue, Labels: []string{"database", "state"}},
+		{Name: "postgres_database_session_time_seconds_all_total", Help: "Total time spent by database sessions in this database in all states, in seconds", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_session_time_seconds_total", Help: "Total time spent by database sessions in this database in each state, in seconds", Type: prometheus.CounterValue, Labels: []string{"database", "state"}},
+		{Name: "postgres_database_sessions_all_total", Help: "Total number of sessions established to this database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_sessions_total", Help: "Total number of sessions established to this database and closed by each reason.", Type: prometheus.CounterValue, Labels: []string{"database", "reason"}},
+		{Name: "postgres_database_size_bytes", Help: "Total size of the database, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_database_size_growth_bytes_per_second", Help: "Growth rate of the database size since the previous snapshot taken by pgSCV, in bytes per second.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_database_stats_age_seconds_total", Help: "The age of the databases activity statistics, in seconds.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_tablespace_size_bytes", Help: "Size of the database data stored in each tablespace, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database", "tablespace"}},
+		{Name: "postgres_database_temp_bytes_total", Help: "Total amount of data written to temporary files by queries.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_temp_files_total", Help: "Total number of temporary files created by queries.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_tuples_deleted_total", Help: "Total number of rows deleted per each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_tuples_fetched_total", Help: "Total number of rows fetched per each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_tuples_inserted_total", Help: "Total number of rows inserted per each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_tuples_returned_total", Help: "Total number of rows returned per each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_tuples_updated_total", Help: "Total number of rows updated per each database.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_xact_commits_total", Help: "Total number of transactions had been committed.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_database_xact_rollbacks_total", Help: "Total number of transactions had been rolled back.", Type: prometheus.CounterValue, Labels: []string{"database"}},
+		{Name: "postgres_xacts_left_before_wraparound", Help: "The number of transactions left before force shutdown due to XID wraparound.", Type: prometheus.CounterValue, Labels: []string{"xid_from"}},
+	}},
+	{Name: "postgres/ddl", Metrics: []MetricInfo{
+		{Name: "postgres_ddl_created_total", Help: "Total number of objects created in the schema since pgSCV start, by kind.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "kind"}},
+		{Name: "postgres_ddl_dropped_total", Help: "Total number of objects dropped from the schema since pgSCV start, by kind.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "kind"}},
+		{Name: "postgres_ddl_objects", Help: "Number of user-defined objects in the schema, by kind.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "kind"}},
+	}},
+	{Name: "postgres/extensions", Metrics: []MetricInfo{
+		{Name: "postgres_extension_info", Help: "Labeled information about installed extension.", Type: prometheus.GaugeValue, Labels: []string{"database", "name", "version", "schema"}},
+		{Name: "postgres_extension_update_available", Help: "Value is 1 if installed version of extension differs from default version available, 0 otherwise.", Type: prometheus.GaugeValue, Labels: []string{"database", "name", "version", "default_version"}},
+	}},
+	{Name: "postgres/functions", Metrics: []MetricInfo{
+		{Name: "postgres_function_calls_total", Help: "Total number of times functions had been called.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "function"}},
+		{Name: "postgres_function_self_time_seconds_total", Help: "Total time spent in function itself, not including other functions called by it, in seconds.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "function"}},
+		{Name: "postgres_function_total_time_seconds_total", Help: "Total time spent in function and all other functions called by it, in seconds.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "function"}},
+	}},
+	{Name: "postgres/indexes", Metrics: []MetricInfo{
+		{Name: "postgres_index_brin_ranges", Help: "Total number of block ranges of the table covered by BRIN index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_index_brin_summarized_ranges", Help: "Number of block ranges summarized in BRIN index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_index_gin_pending_pages", Help: "Number of pages in the pending list of GIN index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_index_gin_pending_tuples", Help: "Number of tuples in the pending list of GIN index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_index_io_blocks_total", Help: "Total number of indexes blocks processed.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table", "index", "access"}},
+		{Name: "postgres_index_scans_total", Help: "Total number of index scans initiated.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table", "index", "key", "isvalid"}},
+		{Name: "postgres_index_size_bytes", Help: "Total size of the index, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_index_tuples_total", Help: "Total number of index entries processed by scans.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table", "index", "tuples"}},
+		{Name: "postgres_index_unused_bytes", Help: "Number of bytes occupied by valid non-key index which has not been scanned since statistics reset.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_index_unused_since_reset_seconds", Help: "Time since statistics reset (or Postgres start, if statistics have never been reset) during which valid non-key index has not been scanned, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+	}},
+	{Name: "postgres/locks", Metrics: []MetricInfo{
+		{Name: "postgres_locks_all_in_flight", Help: "Total number of all in-flight locks held by active processes.", Type: prometheus.GaugeValue},
+		{Name: "postgres_locks_in_flight", Help: "Number of in-flight locks held by active processes in each mode.", Type: prometheus.GaugeValue, Labels: []string{"mode"}},
+		{Name: "postgres_locks_not_granted_in_flight", Help: "Number of in-flight not granted locks held by active processes.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "postgres/logs", Metrics: []MetricInfo{
+		{Name: "postgres_log_audit_events_total", Help: "Total number of pgaudit events logged by audit type and class.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "type", "class"}},
+		{Name: "postgres_log_config_reloads_total", Help: "Total number of configuration reloads logged on receiving SIGHUP.", Type: prometheus.CounterValue},
+		{Name: "postgres_log_connections_total", Help: "Total number of logged connection events (received, authorized, disconnected) by user and database.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "event"}},
+		{Name: "postgres_log_deadlocks_total", Help: "Total number of deadlocks logged by each pair of involved relations.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "relation1", "relation2"}},
+		{Name: "postgres_log_error_messages_total", Help: "Total number of ERROR log messages written.", Type: prometheus.CounterValue, Labels: []string{"msg"}},
+		{Name: "postgres_log_fatal_messages_total", Help: "Total number of FATAL log messages written.", Type: prometheus.CounterValue, Labels: []string{"msg"}},
+		{Name: "postgres_log_last_parameter_change_info", Help: "Labeled information about the last changed configuration parameter, value is the time of change in unix seconds.", Type: prometheus.GaugeValue, Labels: []string{"parameter"}},
+		{Name: "postgres_log_messages_total", Help: "Total number of log messages written by each level.", Type: prometheus.CounterValue, Labels: []string{"level"}},
+		{Name: "postgres_log_panic_messages_total", Help: "Total number of PANIC log messages written.", Type: prometheus.CounterValue, Labels: []string{"msg"}},
+		{Name: "postgres_log_parameter_changes_total", Help: "Total number of logged changes of each configuration parameter.", Type: prometheus.CounterValue, Labels: []string{"parameter"}},
+		{Name: "postgres_log_slow_plan_nodes_total", Help: "Total number of slow plans logged by auto_explain which contain the plan node, for each normalized query.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "query_hash", "node"}},
+		{Name: "postgres_log_slow_plans_duration_seconds_total", Help: "Total duration of statements with slow plans logged by auto_explain for each normalized query, in seconds.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "query_hash"}},
+		{Name: "postgres_log_slow_plans_query_info", Help: "Labeled info about normalized queries which slow plans are logged by auto_explain.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "query_hash", "query"}},
+		{Name: "postgres_log_slow_plans_total", Help: "Total number of slow plans logged by auto_explain for each normalized query.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "query_hash"}},
+		{Name: "postgres_log_temp_bytes_total", Help: "Total number of bytes written to temporary files logged by each normalized query.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "query_hash"}},
+		{Name: "postgres_log_temp_files_query_info", Help: "Labeled info about normalized queries which temporary files are logged.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "query_hash", "query"}},
+		{Name: "postgres_log_temp_files_total", Help: "Total number of temporary files logged by each normalized query.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "query_hash"}},
+		{Name: "postgres_log_warning_messages_total", Help: "Total number of WARNING log messages written.", Type: prometheus.CounterValue, Labels: []string{"msg"}},
+	}},
+	{Name: "postgres/objects", Metrics: []MetricInfo{
+		{Name: "postgres_objects_large_objects", Help: "Number of large objects in the database.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_objects_large_objects_bytes", Help: "Total size of large objects storage of the database, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_objects_toast_bytes", Help: "Total size of TOAST tables of the database, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_objects_toast_tables", Help: "Number of tables and materialized views which have TOAST table in the database.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+	}},
+	{Name: "postgres/pgscv", Metrics: []MetricInfo{
+		{Name: "pgscv_services_registered_total", Help: "Total number of services registered by pgSCV.", Type: prometheus.GaugeValue, Labels: []string{"service"}},
+	}},
+	{Name: "postgres/pgvector", Metrics: []MetricInfo{
+		{Name: "postgres_pgvector_index_lists", Help: "Number of inverted lists of the ivfflat index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_pgvector_index_size_bytes", Help: "Total size of the pgvector index, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index", "method"}},
+	}},
+	{Name: "postgres/probe", Metrics: []MetricInfo{
+		{Name: "pgscv_probe_duration_seconds", Help: "Duration of the last successful probe of service endpoint (TCP connect time or ICMP round-trip time), in seconds.", Type: prometheus.GaugeValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_failures_total", Help: "Total number of failed probes of service endpoint.", Type: prometheus.CounterValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_success", Help: "Whether the last probe of service endpoint succeeded (1) or failed (0).", Type: prometheus.GaugeValue, Labels: []string{"address", "probe"}},
+		{Name: "pgscv_probe_total", Help: "Total number of probes of service endpoint.", Type: prometheus.CounterValue, Labels: []string{"address", "probe"}},
+	}},
+	{Name: "postgres/publications", Metrics: []MetricInfo{
+		{Name: "postgres_publication_replica_identity_issues", Help: "Labeled information about published tables which replica identity does not allow to replicate updates and deletes.", Type: prometheus.GaugeValue, Labels: []string{"database", "publication", "schema", "table", "replica_identity"}},
+		{Name: "postgres_publication_tables", Help: "Number of tables published by the publication.", Type: prometheus.GaugeValue, Labels: []string{"database", "publication"}},
+	}},
+	{Name: "postgres/recovery_prefetch", Metrics: []MetricInfo{
+		{Name: "postgres_recovery_prefetch_block_distance", Help: "How many blocks ahead the prefetcher is looking.", Type: prometheus.GaugeValue},
+		{Name: "postgres_recovery_prefetch_blocks_total", Help: "Total number of blocks referenced in WAL during recovery, by prefetch result.", Type: prometheus.CounterValue, Labels: []string{"result"}},
+		{Name: "postgres_recovery_prefetch_io_depth", Help: "How many prefetches have been initiated but are not yet known to have completed.", Type: prometheus.GaugeValue},
+		{Name: "postgres_recovery_prefetch_wal_distance_bytes", Help: "How far ahead the prefetcher is looking, in bytes.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "postgres/replication", Metrics: []MetricInfo{
+		{Name: "postgres_replication_lag_all_bytes", Help: "Number of bytes standby is behind than primary including all phases.", Type: prometheus.GaugeValue, Labels: []string{"client_addr", "client_port", "user", "application_name", "state"}},
+		{Name: "postgres_replication_lag_all_seconds", Help: "Number of seconds standby is behind than primary including all phases.", Type: prometheus.GaugeValue, Labels: []string{"client_addr", "client_port", "user", "application_name", "state"}},
+		{Name: "postgres_replication_lag_bytes", Help: "Number of bytes standby is behind than primary in each WAL processing phase.", Type: prometheus.GaugeValue, Labels: []string{"client_addr", "client_port", "user", "application_name", "state", "lag"}},
+		{Name: "postgres_replication_lag_seconds", Help: "Number of seconds standby is behind than primary in each WAL processing phase.", Type: prometheus.GaugeValue, Labels: []string{"client_addr", "client_port", "user", "application_name", "state", "lag"}},
+	}},
+	{Name: "postgres/replication_slots", Metrics: []MetricInfo{
+		{Name: "postgres_replication_slot_wal_retain_bytes", Help: "Number of WAL retained and required by consumers, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database", "slot_name", "slot_type", "active", "application_name", "client_addr"}},
+	}},
+	{Name: "postgres/roles", Metrics: []MetricInfo{
+		{Name: "postgres_role_connections_in_flight", Help: "Number of connections currently established by role.", Type: prometheus.GaugeValue, Labels: []string{"role"}},
+		{Name: "postgres_role_connections_limit", Help: "Maximum number of concurrent connections allowed for role.", Type: prometheus.GaugeValue, Labels: []string{"role"}},
+		{Name: "postgres_role_valid_until_seconds", Help: "Time after which role's password is no longer valid, in unixtime.", Type: prometheus.GaugeValue, Labels: []string{"role"}},
+		{Name: "postgres_roles_count", Help: "Number of roles having attribute.", Type: prometheus.GaugeValue, Labels: []string{"attribute"}},
+	}},
+	{Name: "postgres/schemas", Metrics: []MetricInfo{
+		{Name: "postgres_index_redundant_bytes", Help: "Number of bytes occupied by index which is a duplicate or a prefix of another index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_schema_invalid_indexes_bytes", Help: "Number of bytes occupied by invalid index.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index"}},
+		{Name: "postgres_schema_mistyped_fkeys", Help: "Number of foreign key constraints with different data type.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "column", "refschema", "reftable", "refcolumn"}},
+		{Name: "postgres_schema_non_indexed_fkeys", Help: "Number of non-indexed FOREIGN key constraints.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "columns", "constraint", "referenced"}},
+		{Name: "postgres_schema_non_pk_tables", Help: "Labeled information about tables with no primary or unique key constraints.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_schema_redundant_indexes_bytes", Help: "Number of bytes occupied by redundant indexes.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "index", "indexdef", "redundantdef"}},
+		{Name: "postgres_schema_sequence_exhaustion_ratio", Help: "Sequences usage percentage accordingly to attached column, in percent.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "sequence"}},
+		{Name: "postgres_schema_system_catalog_bytes", Help: "Number of bytes occupied by system catalog.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+	}},
+	{Name: "postgres/settings", Metrics: []MetricInfo{
+		{Name: "postgres_service_files_info", Help: "Labeled information about Postgres system files.", Type: prometheus.GaugeValue, Labels: []string{"guc", "mode", "path"}},
+		{Name: "postgres_service_settings_info", Help: "Labeled information about Postgres configuration settings.", Type: prometheus.GaugeValue, Labels: []string{"name", "setting", "unit", "vartype", "source"}},
+	}},
+	{Name: "postgres/stat_io", Metrics: []MetricInfo{
+		{Name: "postgres_stat_io_evictions", Help: "Number of times a block has been written out from a shared or local buffer in order to make it available for another use.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_extend_bytes", Help: "Number of relation extend, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_extend_time", Help: "Time spent in extend operations in milliseconds (if track_io_timing is enabled, otherwise zero)", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_extends", Help: "Number of relation extend operations, each of the size specified in op_bytes.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_fsync_time", Help: "Time spent in fsync operations in milliseconds (if track_io_timing is enabled, otherwise zero)", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_fsyncs", Help: "Number of fsync calls. These are only tracked in context normal.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_hits", Help: "The number of times a desired block was found in a shared buffer.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_read_bytes", Help: "Number of read, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_read_time", Help: "Time spent in read operations in milliseconds (if track_io_timing is enabled, otherwise zero)", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_reads", Help: "Number of read operations, each of the size specified in op_bytes.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_reuses", Help: "The number of times an existing buffer in a size-limited ring buffer outside of shared buffers was reused as part of an I/O operation in the bulkread, bulkwrite, or vacuum contexts.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_write_bytes", Help: "Number of write, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_write_time", Help: "Time spent in write operations in milliseconds (if track_io_timing is enabled, otherwise zero)", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_writeback_time", Help: "Time spent in writeback operations in milliseconds (if track_io_timing is enabled, otherwise zero). ", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_writebacks", Help: "Number of units of size op_bytes which the process requested the kernel write out to permanent storage.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+		{Name: "postgres_stat_io_writes", Help: "Number of write operations, each of the size specified in op_bytes.", Type: prometheus.GaugeValue, Labels: []string{"backend_type", "object", "context"}},
+	}},
+	{Name: "postgres/stat_slru", Metrics: []MetricInfo{
+		{Name: "postgres_stat_slru_blks_exists", Help: "Number of blocks checked for existence for this SLRU.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_blks_hit", Help: "Number of times disk blocks were found already in the SLRU, so that a read was not necessary (this only includes hits in the SLRU, not the operating system's file system cache).", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_blks_read", Help: "Number of disk blocks read for this SLRU.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_blks_written", Help: "Number of disk blocks written for this SLRU.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_blks_zeroed", Help: "Number of blocks zeroed during initializations.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_buffers_bytes", Help: "Configured size of this SLRU cache, in bytes (since v17, auto-tuned sizes are not reported).", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_flushes", Help: "Number of flushes of dirty data for this SLRU.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_hit_ratio", Help: "Ratio of blocks found in this SLRU to all blocks requested, since stats reset.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+		{Name: "postgres_stat_slru_truncates", Help: "Number of truncates for this SLRU.", Type: prometheus.GaugeValue, Labels: []string{"name"}},
+	}},
+	{Name: "postgres/stat_ssl", Metrics: []MetricInfo{
+		{Name: "postgres_stat_ssl_client_conn_number", Help: "Number of client connections by encryption: ssl, gssapi, none (unencrypted network connection) or local (Unix socket).", Type: prometheus.GaugeValue, Labels: []string{"database", "encryption"}},
+		{Name: "postgres_stat_ssl_conn_number", Help: "Number of SSL connections.", Type: prometheus.GaugeValue, Labels: []string{"database", "username"}},
+		{Name: "postgres_stat_ssl_tls_conn_number", Help: "Number of SSL connections by TLS version and cipher.", Type: prometheus.GaugeValue, Labels: []string{"version", "cipher"}},
+	}},
+	{Name: "postgres/stat_subscription", Metrics: []MetricInfo{
+		{Name: "postgres_stat_subscription_confl_count", Help: "Number of times an additional conflict error occurred.", Type: prometheus.CounterValue, Labels: []string{"subid", "subname", "worker_type", "type"}},
+		{Name: "postgres_stat_subscription_error_count", Help: "Number of times an error occurred (applying changes OR initial table synchronization).", Type: prometheus.CounterValue, Labels: []string{"subid", "subname", "worker_type", "type"}},
+		{Name: "postgres_stat_subscription_msg_recv_time", Help: "Receipt time of last message received from origin WAL sender.", Type: prometheus.GaugeValue, Labels: []string{"subid", "subname", "worker_type"}},
+		{Name: "postgres_stat_subscription_msg_send_time", Help: "Send time of last message received from origin WAL sender.", Type: prometheus.GaugeValue, Labels: []string{"subid", "subname", "worker_type"}},
+		{Name: "postgres_stat_subscription_received_lsn", Help: "Last write-ahead log location received.", Type: prometheus.GaugeValue, Labels: []string{"subid", "subname", "worker_type"}},
+		{Name: "postgres_stat_subscription_reported_lsn", Help: "Last write-ahead log location reported to origin WAL sender.", Type: prometheus.GaugeValue, Labels: []string{"subid", "subname", "worker_type"}},
+		{Name: "postgres_stat_subscription_reported_time", Help: "Time of last write-ahead log location reported to origin WAL sender.", Type: prometheus.GaugeValue, Labels: []string{"subid", "subname", "worker_type"}},
+	}},
+	{Name: "postgres/statements", Metrics: []MetricInfo{
+		{Name: "postgres_statements_calls_total", Help: "Total number of times statement has been executed.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_database_calls_total", Help: "Total number of times statements have been executed in the database, by top-level flag.", Type: prometheus.CounterValue, Labels: []string{"database", "toplevel"}},
+		{Name: "postgres_statements_database_plans_calls_ratio", Help: "Ratio of statements plans to calls in the database, values close to 1 indicate prepared statements are not reused.", Type: prometheus.GaugeValue, Labels: []string{"database"}},
+		{Name: "postgres_statements_database_plans_total", Help: "Total number of times statements have been planned in the database, by top-level flag.", Type: prometheus.CounterValue, Labels: []string{"database", "toplevel"}},
+		{Name: "postgres_statements_dealloc_total", Help: "Total number of times least-executed statements have been deallocated because more distinct statements than max were observed.", Type: prometheus.CounterValue},
+		{Name: "postgres_statements_entries", Help: "Number of statements tracked by pg_stat_statements.", Type: prometheus.GaugeValue},
+		{Name: "postgres_statements_local_buffers_dirtied_total", Help: "Total number of blocks have been dirtied in local buffers by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_local_buffers_hit_total", Help: "Total number of blocks have been found in local buffers by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_local_buffers_read_bytes_total", Help: "Total number of bytes read from disk or OS page cache by the statement when block not found in local buffers.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_local_buffers_written_bytes_total", Help: "Total number of bytes written from local buffers to disk by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_max_entries", Help: "Max number of statements tracked by pg_stat_statements.", Type: prometheus.GaugeValue},
+		{Name: "postgres_statements_parallel_workers_total", Help: "Total number of parallel workers planned to be launched and actually launched by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid", "workers"}},
+		{Name: "postgres_statements_retention_seconds", Help: "Estimated time statements are kept before eviction, averaged since stats reset, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "postgres_statements_rollup_calls_total", Help: "Total number of times all statements have been executed, by database and user.", Type: prometheus.CounterValue, Labels: []string{"user", "database"}},
+		{Name: "postgres_statements_rollup_exec_time_seconds_total", Help: "Total time spent executing all statements, by database and user, in seconds.", Type: prometheus.CounterValue, Labels: []string{"user", "database"}},
+		{Name: "postgres_statements_rollup_temp_bytes_total", Help: "Total number of bytes read from and written to temporary files by all statements, by database and user.", Type: prometheus.CounterValue, Labels: []string{"user", "database"}},
+		{Name: "postgres_statements_rollup_wal_bytes_total", Help: "Total number of WAL bytes generated by all statements, by database and user.", Type: prometheus.CounterValue, Labels: []string{"user", "database"}},
+		{Name: "postgres_statements_rows_total", Help: "Total number of rows retrieved or affected by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_shared_buffers_dirtied_total", Help: "Total number of blocks have been dirtied in shared buffers by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_shared_buffers_hit_total", Help: "Total number of blocks have been found in shared buffers by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_shared_buffers_read_bytes_total", Help: "Total number of bytes read from disk or OS page cache by the statement when block not found in shared buffers.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_shared_buffers_written_bytes_total", Help: "Total number of bytes written from shared buffers to disk by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_temp_read_bytes_total", Help: "Total number of bytes read from temporary files by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_temp_written_bytes_total", Help: "Total number of bytes written to temporary files by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_time_seconds_all_total", Help: "Total time spent by the statement, in seconds.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_time_seconds_total", Help: "Time spent by the statement in each mode, in seconds.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid", "mode"}},
+		{Name: "postgres_statements_toplevel_calls_total", Help: "Total number of times statement has been executed as top-level statement, calls of nested statements are not included.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_wal_buffers_full", Help: "Total number of times the WAL buffers became full generated by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_wal_bytes_all_total", Help: "Total number of WAL generated by the statement, in bytes.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_wal_bytes_total", Help: "Total number of WAL bytes generated by the statement, by type.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid", "wal"}},
+		{Name: "postgres_statements_wal_records_total", Help: "Total number of WAL records generated by the statement.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+	}},
+	{Name: "postgres/statements_plans", Metrics: []MetricInfo{
+		{Name: "postgres_statements_plan_changes_total", Help: "Total number of plan shape changes of the top statements noticed by pgSCV.", Type: prometheus.CounterValue, Labels: []string{"user", "database", "queryid"}},
+		{Name: "postgres_statements_plan_info", Help: "Labeled info about fingerprint of plan shape of the top statements.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "queryid", "fingerprint"}},
+		{Name: "postgres_statements_plan_last_explain_seconds", Help: "Time when plans of the top statements have been explained, in unixtime.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "postgres/statements_query", Metrics: []MetricInfo{
+		{Name: "postgres_statements_query_info", Help: "Labeled info about statements has been executed.", Type: prometheus.GaugeValue, Labels: []string{"user", "database", "queryid", "query"}},
+	}},
+	{Name: "postgres/storage", Metrics: []MetricInfo{
+		{Name: "postgres_data_directory_bytes", Help: "The size of Postgres server data directory, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+		{Name: "postgres_log_directory_bytes", Help: "The size of Postgres server LOG directory, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+		{Name: "postgres_log_directory_files", Help: "The number of files in Postgres server LOG directory.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+		{Name: "postgres_tablespace_directory_bytes", Help: "The size of Postgres tablespace directory, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"tablespace", "device", "mountpoint", "path"}},
+		{Name: "postgres_temp_bytes_database_in_flight", Help: "Number of bytes occupied by temporary files processed in flight, by database.", Type: prometheus.GaugeValue, Labels: []string{"tablespace", "database"}},
+		{Name: "postgres_temp_bytes_in_flight", Help: "Number of bytes occupied by temporary files processed in flight.", Type: prometheus.GaugeValue, Labels: []string{"tablespace"}},
+		{Name: "postgres_temp_files_all_bytes", Help: "The size of all Postgres temp directories, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+		{Name: "postgres_temp_files_database_in_flight", Help: "Number of temporary files processed in flight, by database.", Type: prometheus.GaugeValue, Labels: []string{"tablespace", "database"}},
+		{Name: "postgres_temp_files_in_flight", Help: "Number of temporary files processed in flight.", Type: prometheus.GaugeValue, Labels: []string{"tablespace"}},
+		{Name: "postgres_temp_files_max_age_seconds", Help: "The age of the oldest temporary file, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"tablespace"}},
+		{Name: "postgres_wal_directory_bytes", Help: "The size of Postgres server WAL directory, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+		{Name: "postgres_wal_directory_files", Help: "The number of files in Postgres server WAL directory.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+		{Name: "postgres_wal_directory_free_bytes", Help: "Free space available on filesystem of Postgres server WAL directory, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "path"}},
+	}},
+	{Name: "postgres/subscription_rel", Metrics: []MetricInfo{
+		{Name: "postgres_subscription_rel_count", Help: "Count tables in replication state", Type: prometheus.GaugeValue, Labels: []string{"datname", "subname", "state"}},
+	}},
+	{Name: "postgres/table_bloat", Metrics: []MetricInfo{
+		{Name: "postgres_table_bloat_dead_tuple_percent", Help: "Percentage of the table occupied by dead tuples, sampled periodically.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_bloat_free_percent", Help: "Percentage of free space in the table, sampled periodically.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+	}},
+	{Name: "postgres/tables", Metrics: []MetricInfo{
+		{Name: "postgres_table_hot_update_ratio", Help: "Ratio of HOT updates to all updates of tuples (rows) in the table.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_idx_scan_total", Help: "Total number of index scans initiated on this table.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_idx_tup_fetch_total", Help: "Total number of live rows fetched by index scans.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_info", Help: "Labeled information about table's storage parameters.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table", "fillfactor"}},
+		{Name: "postgres_table_io_blocks_total", Help: "Total number of table's blocks processed.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table", "type", "access"}},
+		{Name: "postgres_table_last_analyze_time", Help: "Time of last analyze or autoanalyze has been done, in unixtime.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_last_vacuum_time", Help: "Time of last vacuum or autovacuum has been done (not counting VACUUM FULL), in unixtime.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_maintenance_total", Help: "Total number of times this table has been maintained by each type of maintenance operation.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table", "type"}},
+		{Name: "postgres_table_seq_scan_ratio", Help: "Ratio of sequential scans to all scans (sequential and index) initiated on the table.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_seq_scan_total", Help: "The total number of sequential scans have been done.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_seq_tup_read_total", Help: "The total number of tuples have been read by sequential scans.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_since_last_analyze_seconds_total", Help: "Total time since table was analyzed manually or automatically, in seconds. DEPRECATED.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_since_last_vacuum_seconds_total", Help: "Total time since table was vacuumed manually or automatically (not counting VACUUM FULL), in seconds. DEPRECATED.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_size_bytes", Help: "Total size of the table (including all forks and TOASTed data), in bytes.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_dead_total", Help: "Estimated total number of dead tuples in the table.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_deleted_total", Help: "Total number of tuples (rows) have been deleted in the table.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_hot_updated_total", Help: "Total number of tuples (rows) have been updated in the table (HOT only).", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_inserted_total", Help: "Total number of tuples (rows) have been inserted in the table.", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_live_total", Help: "Estimated total number of live tuples in the table.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_modified_total", Help: "Estimated total number of modified tuples in the table since last vacuum.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_total", Help: "Number of rows in the table based on pg_class.reltuples value.", Type: prometheus.GaugeValue, Labels: []string{"database", "schema", "table"}},
+		{Name: "postgres_table_tuples_updated_total", Help: "Total number of tuples (rows) have been updated in the table (including HOT).", Type: prometheus.CounterValue, Labels: []string{"database", "schema", "table"}},
+	}},
+	{Name: "postgres/vacuum", Metrics: []MetricInfo{
+		{Name: "postgres_vacuum_cost_settings_info", Help: "Labeled information about effective vacuum cost-based delay settings.", Type: prometheus.GaugeValue, Labels: []string{"name", "setting"}},
+		{Name: "postgres_vacuum_worker_dead_tuples", Help: "Number of dead tuples collected by running vacuum since the last index vacuum cycle.", Type: prometheus.GaugeValue, Labels: []string{"database", "relation", "phase", "type"}},
+		{Name: "postgres_vacuum_worker_duration_seconds", Help: "Duration of running vacuum, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"database", "relation", "phase", "type"}},
+		{Name: "postgres_vacuum_worker_heap_blks_scanned", Help: "Number of heap blocks scanned by running vacuum.", Type: prometheus.GaugeValue, Labels: []string{"database", "relation", "phase", "type"}},
+		{Name: "postgres_vacuum_worker_heap_blks_total", Help: "Total number of heap blocks in the relation being vacuumed.", Type: prometheus.GaugeValue, Labels: []string{"database", "relation", "phase", "type"}},
+		{Name: "postgres_vacuum_worker_heap_blks_vacuumed", Help: "Number of heap blocks vacuumed by running vacuum.", Type: prometheus.GaugeValue, Labels: []string{"database", "relation", "phase", "type"}},
+		{Name: "postgres_vacuum_worker_index_vacuum_count", Help: "Number of completed index vacuum cycles by running vacuum.", Type: prometheus.GaugeValue, Labels: []string{"database", "relation", "phase", "type"}},
+	}},
+	{Name: "postgres/wal", Metrics: []MetricInfo{
+		{Name: "postgres_recovery_info", Help: "Current recovery state, 0 - not in recovery; 1 - in recovery.", Type: prometheus.GaugeValue},
+		{Name: "postgres_recovery_min_apply_delay_seconds", Help: "Delay of applying WAL on standby configured by recovery_min_apply_delay, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "postgres_recovery_pause", Help: "Current recovery pause state, 0 - recovery pause is not requested; 1 - recovery pause is requested.", Type: prometheus.GaugeValue},
+		{Name: "postgres_recovery_since_last_replay_seconds", Help: "Time elapsed since last transaction replayed by standby, grows when primary is idle, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "postgres_wal_buffers_full_total", Help: "Total number of times WAL data was written to disk because WAL buffers became full (zero in case of standby).", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_bytes_total", Help: "Total amount of WAL generated (zero in case of standby) since last stats reset, in bytes.", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_fpi_total", Help: "Total number of WAL full page images generated (zero in case of standby).", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_receiver_info", Help: "Labeled information about WAL receiver connection to upstream server.", Type: prometheus.GaugeValue, Labels: []string{"status", "slot_name", "sender_host", "sender_port", "sslmode"}},
+		{Name: "postgres_wal_receiver_last_msg_age_seconds", Help: "Time elapsed since last message received from upstream server, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "postgres_wal_receiver_received_bytes_total", Help: "Total amount of WAL received and flushed to disk by WAL receiver since cluster init, in bytes.", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_receiver_replay_lag_bytes", Help: "Amount of WAL received by WAL receiver but not replayed yet, in bytes.", Type: prometheus.GaugeValue},
+		{Name: "postgres_wal_records_total", Help: "Total number of WAL records generated (zero in case of standby).", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_seconds_all_total", Help: "Total amount of time spent processing WAL buffers (zero in case of standby), in seconds.", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_seconds_total", Help: "Total amount of time spent processing WAL buffers by each operation (zero in case of standby), in seconds.", Type: prometheus.CounterValue, Labels: []string{"op"}},
+		{Name: "postgres_wal_stats_reset_time", Help: "Time at which WAL statistics were last reset, in unixtime.", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_sync_total", Help: "Total number of times WAL files were synced to disk via issue_xlog_fsync request (zero in case of standby).", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_write_total", Help: "Total number of times WAL buffers were written out to disk via XLogWrite request (zero in case of standby).", Type: prometheus.CounterValue},
+		{Name: "postgres_wal_written_bytes_total", Help: "Total amount of WAL written (or received in case of standby) since cluster init, in bytes.", Type: prometheus.CounterValue},
+	}},
+	{Name: "postgres/watchdog", Metrics: []MetricInfo{
+		{Name: "pgscv_watchdog_cancelled_queries_total", Help: "Total number of pgSCV queries cancelled due to exceeded max query age.", Type: prometheus.CounterValue},
+	}},
+	{Name: "system/cgroup", Metrics: []MetricInfo{
+		{Name: "node_cgroup_cpu_period_seconds", Help: "Length of the period of CPU quota of the cgroup, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"process", "cgroup"}},
+		{Name: "node_cgroup_cpu_quota_seconds", Help: "CPU time available to the cgroup during each period, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"process", "cgroup"}},
+		{Name: "node_cgroup_cpu_throttled_periods_total", Help: "Total number of periods when the cgroup has been throttled.", Type: prometheus.CounterValue, Labels: []string{"process", "cgroup"}},
+		{Name: "node_cgroup_cpu_throttled_seconds_total", Help: "Total time processes of the cgroup have been throttled, in seconds.", Type: prometheus.CounterValue, Labels: []string{"process", "cgroup"}},
+		{Name: "node_cgroup_memory_limit_bytes", Help: "Memory limit of the cgroup, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"process", "cgroup"}},
+		{Name: "node_cgroup_memory_usage_bytes", Help: "Memory used by processes of the cgroup, in bytes.", Type: prometheus.GaugeValue, Labels: []string{"process", "cgroup"}},
+		{Name: "node_cgroup_oom_kills_total", Help: "Total number of processes of the cgroup killed by OOM killer.", Type: prometheus.CounterValue, Labels: []string{"process", "cgroup"}},
+	}},
+	{Name: "system/clock", Metrics: []MetricInfo{
+		{Name: "node_clock_estimated_error_seconds", Help: "Estimated error of the clock, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "node_clock_max_error_seconds", Help: "Maximum error of the clock, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "node_clock_offset_seconds", Help: "Estimated offset of the clock from the reference time, in seconds.", Type: prometheus.GaugeValue},
+		{Name: "node_clock_synchronized", Help: "Whether the clock is synchronized by NTP daemon (1) or not (0).", Type: prometheus.GaugeValue},
+	}},
+	{Name: "system/cpu", Metrics: []MetricInfo{
+		{Name: "node_cpu_guest_seconds_total", Help: "Seconds the CPUs spent in guests (VMs) for each mode.", Type: prometheus.CounterValue, Labels: []string{"mode"}},
+		{Name: "node_cpu_seconds_all_total", Help: "Seconds the CPUs spent in all modes.", Type: prometheus.CounterValue},
+		{Name: "node_cpu_seconds_total", Help: "Seconds the CPUs spent in each mode.", Type: prometheus.CounterValue, Labels: []string{"mode"}},
+		{Name: "node_uptime_idle_seconds_total", Help: "Total number of seconds all cores have spent idle, accordingly to /proc/uptime.", Type: prometheus.CounterValue},
+		{Name: "node_uptime_up_seconds_total", Help: "Total number of seconds the system has been up, accordingly to /proc/uptime.", Type: prometheus.CounterValue},
+	}},
+	{Name: "system/diskstats", Metrics: []MetricInfo{
+		{Name: "node_disk_bytes_all_total", Help: "The total number of bytes processed by IO requests.", Type: prometheus.CounterValue, Labels: []string{"device"}},
+		{Name: "node_disk_bytes_total", Help: "The total number of bytes processed by IO requests of each type.", Type: prometheus.CounterValue, Labels: []string{"device", "type"}},
+		{Name: "node_disk_completed_all_total", Help: "The total number of IO requests completed successfully.", Type: prometheus.CounterValue, Labels: []string{"device"}},
+		{Name: "node_disk_completed_total", Help: "The total number of IO requests completed successfully of each type.", Type: prometheus.CounterValue, Labels: []string{"device", "type"}},
+		{Name: "node_disk_io_now", Help: "The number of I/Os currently in progress.", Type: prometheus.GaugeValue, Labels: []string{"device"}},
+		{Name: "node_disk_io_time_seconds_total", Help: "Total seconds spent doing I/Os.", Type: prometheus.CounterValue, Labels: []string{"device"}},
+		{Name: "node_disk_io_time_weighted_seconds_total", Help: "The weighted number of seconds spent doing I/Os.", Type: prometheus.CounterValue, Labels: []string{"device"}},
+		{Name: "node_disk_merged_all_total", Help: "The total number of merged IO requests.", Type: prometheus.CounterValue, Labels: []string{"device"}},
+		{Name: "node_disk_merged_total", Help: "The total number of merged IO requests of each type.", Type: prometheus.CounterValue, Labels: []string{"device", "type"}},
+		{Name: "node_disk_time_seconds_all_total", Help: "The total number of seconds spent on all requests.", Type: prometheus.CounterValue, Labels: []string{"device"}},
+		{Name: "node_disk_time_seconds_total", Help: "The total number of seconds spent on all requests of each type.", Type: prometheus.CounterValue, Labels: []string{"device", "type"}},
+		{Name: "node_system_storage_info", Help: "Labeled information about storage devices present in the system. DEPRECATED: consider using node_system_storage_size_bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "rotational", "scheduler"}},
+		{Name: "node_system_storage_size_bytes", Help: "Total size of storage device in bytes.", Type: prometheus.GaugeValue, Labels: []string{"device", "rotational", "scheduler", "virtual", "model", "ro"}},
+	}},
+	{Name: "system/exec", Metrics: []MetricInfo{
+		{Name: "pgscv_exec_command_duration_seconds", Help: "Duration of the last execution of the command, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"command"}},
+		{Name: "pgscv_exec_command_success", Help: "Whether the last execution of the command succeeded (1) or failed (0).", Type: prometheus.GaugeValue, Labels: []string{"command"}},
+	}},
+	{Name: "system/filesystems", Metrics: []MetricInfo{
+		{Name: "node_filesystem_bytes", Help: "Number of bytes of filesystem by usage.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "fstype", "usage"}},
+		{Name: "node_filesystem_bytes_total", Help: "Total number of bytes of filesystem capacity.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "fstype"}},
+		{Name: "node_filesystem_files", Help: "Number of files (inodes) of filesystem by usage.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "fstype", "usage"}},
+		{Name: "node_filesystem_files_total", Help: "Total number of files (inodes) of filesystem capacity.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "fstype"}},
+		{Name: "node_filesystem_full_remaining_seconds", Help: "Predicted time until filesystem hosting Postgres data or WAL is full, based on recent usage growth, in seconds.", Type: prometheus.GaugeValue, Labels: []string{"device", "mountpoint", "fstype"}},
+	}},
+	{Name: "system/loadaverage", Metrics: []MetricInfo{
+		{Name: "node_load1", Help: "1m load average.", Type: prometheus.GaugeValue},
+		{Name: "node_load15", Help: "15m load average.", Type: prometheus.GaugeValue},
+		{Name: "node_load5", Help: "5m load average.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "system/memory", Metrics: []MetricInfo{
+		{Name: "node_memory_MemUsed", Help: "Memory information composite field MemUsed.", Type: prometheus.GaugeValue},
+		{Name: "node_memory_SwapUsed", Help: "Memory information composite field SwapUsed.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "system/netdev", Metrics: []MetricInfo{
+		{Name: "node_network_bytes_total", Help: "Total number of bytes processed by network device, by each direction.", Type: prometheus.CounterValue, Labels: []string{"device", "type"}},
+		{Name: "node_network_events_total", Help: "Total number of events occurred on network device, by each type and direction.", Type: prometheus.CounterValue, Labels: []string{"device", "type", "event"}},
+		{Name: "node_network_packets_total", Help: "Total number of packets processed by network device, by each direction.", Type: prometheus.CounterValue, Labels: []string{"device", "type"}},
+	}},
+	{Name: "system/network", Metrics: []MetricInfo{
+		{Name: "node_network_private_addresses", Help: "Number of private network addresses present on the system, by type.", Type: prometheus.GaugeValue},
+		{Name: "node_network_public_addresses", Help: "Number of public network addresses present on the system, by type.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "system/pgscv", Metrics: []MetricInfo{
+		{Name: "pgscv_services_registered_total", Help: "Total number of services registered by pgSCV.", Type: prometheus.GaugeValue, Labels: []string{"service"}},
+	}},
+	{Name: "system/sysconfig", Metrics: []MetricInfo{
+		{Name: "node_boot_time_seconds", Help: "Node boot time, in unixtime.", Type: prometheus.GaugeValue},
+		{Name: "node_context_switches_total", Help: "Total number of context switches.", Type: prometheus.CounterValue},
+		{Name: "node_forks_total", Help: "Total number of forks.", Type: prometheus.CounterValue},
+		{Name: "node_system_cpu_cores_total", Help: "Total number of CPU cores in each state.", Type: prometheus.GaugeValue, Labels: []string{"state"}},
+		{Name: "node_system_numa_nodes_total", Help: "Total number of NUMA nodes in the system.", Type: prometheus.GaugeValue},
+		{Name: "node_system_scaling_governors_total", Help: "Total number of CPU scaling governors used of each type.", Type: prometheus.GaugeValue, Labels: []string{"governor"}},
+		{Name: "node_system_sysctl", Help: "Node sysctl system settings.", Type: prometheus.GaugeValue, Labels: []string{"sysctl"}},
+	}},
+	{Name: "system/sysinfo", Metrics: []MetricInfo{
+		{Name: "node_os_info", Help: "Labeled operating system information.", Type: prometheus.GaugeValue, Labels: []string{"kernel", "type", "name", "version"}},
+		{Name: "node_platform_info", Help: "Labeled system platform information", Type: prometheus.GaugeValue, Labels: []string{"vendor", "product_name"}},
+	}},
+	{Name: "system/systemd", Metrics: []MetricInfo{
+		{Name: "node_systemd_unit_restarts_total", Help: "Total number of automatic restarts of the systemd service unit.", Type: prometheus.CounterValue, Labels: []string{"unit"}},
+		{Name: "node_systemd_unit_state", Help: "Current state of the systemd unit, 1 for the current state and 0 for others.", Type: prometheus.GaugeValue, Labels: []string{"unit", "state"}},
+	}},
+}
@@ -0,0 +1,201 @@
+package collector
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateCatalog defines static catalog of collectors should be regenerated.
+var updateCatalog = flag.Bool("update-catalog", false, "regenerate catalog_metrics.go using registered collectors")
+
+func TestListCollectors(t *testing.T) {
+	infos := ListCollectors()
+	assert.NotEmpty(t, infos)
+
+	got := map[string][]string{}
+	for _, info := range infos {
+		for _, m := range info.Metrics {
+			got[info.Name] = append(got[info.Name], m.Name)
+		}
+	}
+
+	assert.Contains(t, got, "system/loadaverage")
+	assert.Contains(t, got["postgres/logs"], "postgres_log_messages_total")
+	assert.Contains(t, got["pgbouncer/settings"], "pgbouncer_service_settings_info")
+	assert.Contains(t, got["patroni/common"], "patroni_up")
+
+	// Types and descriptions of metrics are listed.
+	for _, info := range infos {
+		if info.Name != "postgres/logs" {
+			continue
+		}
+		for _, m := range info.Metrics {
+			if m.Name == "postgres_log_messages_total" {
+				assert.Equal(t, prometheus.CounterValue, m.Type)
+				assert.NotEmpty(t, m.Help)
+			}
+		}
+	}
+
+	assert.True(t, IsVariableLabel("database"))
+	assert.True(t, IsVariableLabel("user"))
+	assert.False(t, IsVariableLabel("environment"))
+
+	// Static catalog is not modified by callers.
+	infos[0].Metrics[0].Name = "modified"
+	assert.NotEqual(t, "modified", ListCollectors()[0].Metrics[0].Name)
+}
+
+// Test_collectorsCatalog checks static catalog matches descriptors of registered collectors. Catalog could be
+// regenerated using 'go test ./internal/collector -run Test_collectorsCatalog -update-catalog'.
+func Test_collectorsCatalog(t *testing.T) {
+	factories := Factories{}
+	factories.RegisterSystemCollectors(nil)
+	factories.RegisterPostgresCollectors(nil)
+	factories.RegisterPgbouncerCollectors(nil)
+	factories.RegisterPatroniCollectors(nil)
+
+	var infos []CollectorInfo
+	for _, name := range slices.Sorted(maps.Keys(factories)) {
+		c, err := factories[name](labels{}, model.CollectorSettings{})
+		require.NoError(t, err, name)
+
+		var metrics []MetricInfo
+		for _, m := range collectorDescs(reflect.ValueOf(c), map[uintptr]bool{}) {
+			if !slices.ContainsFunc(metrics, func(v MetricInfo) bool { return v.Name == m.Name }) {
+				metrics = append(metrics, m)
+			}
+		}
+		slices.SortFunc(metrics, func(a, b MetricInfo) int { return strings.Compare(a.Name, b.Name) })
+		infos = append(infos, CollectorInfo{Name: name, Metrics: metrics})
+	}
+
+	if *updateCatalog {
+		src, err := formatCatalog(infos)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile("catalog_metrics.go", src, 0644))
+		return
+	}
+
+	assert.Equal(t, infos, collectorsCatalog, "catalog is outdated, regenerate it using -update-catalog flag")
+}
+
+// Test_collectorsCatalog_units checks units of metrics are reflected in names by suffixes, OpenMetrics consumers
+// derive units from names because units metadata is not exposed.
+func Test_collectorsCatalog_units(t *testing.T) {
+	for _, info := range collectorsCatalog {
+		for _, m := range info.Metrics {
+			// Unit could be followed by other suffixes, e.g. _seconds_all_total or _bytes_per_second.
+			name := m.Name + "_"
+			if strings.Contains(m.Help, "in seconds") {
+				assert.Contains(t, name, "_seconds_", m.Name)
+			}
+			if strings.Contains(m.Help, "in bytes") {
+				assert.Contains(t, name, "_bytes_", m.Name)
+			}
+		}
+	}
+}
+
+// collectorDescs returns builtin metric descriptors found in fields of the collector.
+func collectorDescs(v reflect.Value, seen map[uintptr]bool) []MetricInfo {
+	var metrics []MetricInfo
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return nil
+		}
+		seen[v.Pointer()] = true
+		return collectorDescs(v.Elem(), seen)
+	case reflect.Interface:
+		if !v.IsNil() {
+			return collectorDescs(v.Elem(), seen)
+		}
+	case reflect.Struct:
+		if !v.CanAddr() {
+			addressable := reflect.New(v.Type()).Elem()
+			addressable.Set(v)
+			v = addressable
+		}
+
+		// Unexported fields are read using their addresses.
+		if d, ok := reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Interface().(*typedDesc); ok {
+			if d.desc != nil {
+				metrics = append(metrics, describeTypedDesc(d))
+			}
+			return metrics
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			metrics = append(metrics, collectorDescs(reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem(), seen)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			metrics = append(metrics, collectorDescs(v.Index(i), seen)...)
+		}
+	case reflect.Map:
+		for it := v.MapRange(); it.Next(); {
+			metrics = append(metrics, collectorDescs(it.Value(), seen)...)
+		}
+	}
+
+	return metrics
+}
+
+// describeTypedDesc returns name, description and type of the metric descriptor.
+func describeTypedDesc(d *typedDesc) MetricInfo {
+	s := d.desc.String()
+	help, _ := strconv.QuotedPrefix(s[strings.Index(s, "help: ")+len("help: "):])
+	help, _ = strconv.Unquote(help)
+
+	return MetricInfo{Name: reDescFqName.FindStringSubmatch(s)[1], Help: help, Type: d.valueType, Labels: d.labelNames}
+}
+
+// formatCatalog returns source code of static catalog of collectors.
+func formatCatalog(infos []CollectorInfo) ([]byte, error) {
+	types := map[prometheus.ValueType]string{
+		prometheus.CounterValue: "prometheus.CounterValue",
+		prometheus.GaugeValue:   "prometheus.GaugeValue",
+		prometheus.UntypedValue: "prometheus.UntypedValue",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by Test_collectorsCatalog using -update-catalog flag. DO NOT EDIT.\n\n")
+	b.WriteString("package collector\n\nimport \"github.com/prometheus/client_golang/prometheus\"\n\n")
+	b.WriteString("// collectorsCatalog defines collectors and metric families produced by them, sorted by names.\n")
+	b.WriteString("var collectorsCatalog = []CollectorInfo{\n")
+	for _, info := range infos {
+		if len(info.Metrics) == 0 {
+			fmt.Fprintf(&b, "{Name: %q},\n", info.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "{Name: %q, Metrics: []MetricInfo{\n", info.Name)
+		for _, m := range info.Metrics {
+			if len(m.Labels) == 0 {
+				fmt.Fprintf(&b, "{Name: %q, Help: %q, Type: %s},\n", m.Name, m.Help, types[m.Type])
+				continue
+			}
+			fmt.Fprintf(&b, "{Name: %q, Help: %q, Type: %s, Labels: %#v},\n", m.Name, m.Help, types[m.Type], m.Labels)
+		}
+		b.WriteString("}},\n")
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,218 @@
+package collector
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// clusterIdentityInterval defines how often value of cluster_name is requested from the service.
+	clusterIdentityInterval = time.Minute
+
+	// patroniScopeTTL defines how long scope reported by Patroni is used for identity of Postgres on the same host.
+	patroniScopeTTL = 10 * time.Minute
+)
+
+// patroniScope defines scope of Patroni cluster and time when it has been reported by Patroni.
+type patroniScope struct {
+	host    string
+	scope   string
+	updated time.Time
+}
+
+// syncPatroniScopes contains scopes of Patroni clusters by URLs of Patroni REST API.
+type syncPatroniScopes struct {
+	store map[string]patroniScope
+	mu    sync.RWMutex
+}
+
+// patroniScopes defines scopes reported to Patroni collectors, shared by all services.
+var patroniScopes = &syncPatroniScopes{store: map[string]patroniScope{}}
+
+// set remembers scope reported by Patroni REST API at passed URL. Scopes which are not reported anymore are forgotten.
+func (s *syncPatroniScopes) set(baseURL, scope string, now time.Time) {
+	u, err := url.Parse(baseURL)
+	if err != nil || scope == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, v := range s.store {
+		if now.Sub(v.updated) > patroniScopeTTL {
+			delete(s.store, k)
+		}
+	}
+
+	s.store[baseURL] = patroniScope{host: identityHost(u.Hostname()), scope: scope, updated: now}
+}
+
+// get returns scope of Patroni cluster running on the host. Empty string is returned if scope has not been reported
+// recently, or many Patroni clusters with different scopes are running on the host.
+func (s *syncPatroniScopes) get(host string, now time.Time) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var scope string
+	for _, v := range s.store {
+		if v.host != host || now.Sub(v.updated) > patroniScopeTTL {
+			continue
+		}
+		if scope != "" && scope != v.scope {
+			return ""
+		}
+		scope = v.scope
+	}
+
+	return scope
+}
+
+// identityHost returns host used for matching Postgres and Patroni running on the same host. Unix sockets and
+// loopback addresses are considered as the same local host.
+func identityHost(host string) string {
+	if host == "" || host == "localhost" || strings.HasPrefix(host, "/") {
+		return "localhost"
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return "localhost"
+	}
+	return host
+}
+
+// clusterIdentity defines stable identity of Postgres cluster reflected in service labels, so series are not broken
+// when service address is changed, e.g. when VIP moves to another host after failover. Identity is resolved on every
+// scrape, hence labels follow changes of the identity without restart.
+type clusterIdentity struct {
+	mode        string // see ClusterIdentity* constants
+	host        string // host of the service used for looking up scope of Patroni running on the same host
+	mu          sync.Mutex
+	clusterName string    // the last known value of cluster_name
+	checked     time.Time // time when cluster_name has been requested last time
+}
+
+// newClusterIdentity creates a new clusterIdentity of the service, nil is returned if identity is not used.
+func newClusterIdentity(config Config) *clusterIdentity {
+	if config.ClusterIdentity == "" || config.ServiceType != model.ServiceTypePostgresql {
+		return nil
+	}
+
+	var host string
+	if pgconfig, err := pgx.ParseConfig(config.ConnString); err == nil {
+		host = pgconfig.Host
+	}
+
+	return &clusterIdentity{mode: config.ClusterIdentity, host: identityHost(host)}
+}
+
+// refresh requests value of cluster_name from the service, not more often than clusterIdentityInterval. Value
+// requested during configuration of the service is used at first.
+func (c *clusterIdentity) refresh(config Config, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checked.IsZero() && config.clusterName != "" {
+		c.clusterName, c.checked = config.clusterName, now
+		return
+	}
+
+	if now.Sub(c.checked) < clusterIdentityInterval {
+		return
+	}
+
+	c.checked = now
+	name, err := queryClusterName(config)
+	if err != nil {
+		collectorLog.WarnfLimited("identity"+config.ConnString, "request cluster_name failed: %s; keep the last known cluster identity", err)
+		return
+	}
+	c.clusterName = name
+}
+
+// current returns identity of the cluster: scope of Patroni running on the same host (when Patroni is monitored), or
+// the last known value of cluster_name. Empty string is returned when identity is unknown.
+func (c *clusterIdentity) current(now time.Time) string {
+	if scope := patroniScopes.get(c.host, now); scope != "" {
+		return scope
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.clusterName == "" {
+		collectorLog.WarnfLimited("identity"+c.host, "cluster identity is unknown (Patroni scope is not reported and cluster_name is not set), keep host and port labels")
+	}
+
+	return c.clusterName
+}
+
+// queryClusterName returns value of cluster_name of the service.
+func queryClusterName(config Config) (string, error) {
+	conn, err := config.connect()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var name string
+	err = conn.QueryRow(postgresSettingQuery, "cluster_name").Scan(&name)
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// relabel returns metric with 'cluster' label set to identity, 'host' and 'port' labels are removed in replace mode.
+// Metric is returned as is when identity is unknown.
+func (c *clusterIdentity) relabel(m prometheus.Metric, identity string) prometheus.Metric {
+	if identity == "" {
+		return m
+	}
+	return identityMetric{Metric: m, identity: identity, replace: c.mode == ClusterIdentityReplace}
+}
+
+// identityMetric defines metric which labels are rewritten accordingly to cluster identity. Descriptor of the
+// original metric is kept, it is used only for metric name and help by registries.
+type identityMetric struct {
+	prometheus.Metric
+	identity string
+	replace  bool
+}
+
+// Write implements prometheus.Metric interface.
+func (m identityMetric) Write(out *dto.Metric) error {
+	err := m.Metric.Write(out)
+	if err != nil {
+		return err
+	}
+
+	name, value := "cluster", m.identity
+	pairs := []*dto.LabelPair{{Name: &name, Value: &value}}
+	for _, lp := range out.GetLabel() {
+		switch lp.GetName() {
+		case "cluster":
+			continue
+		case "host", "port":
+			if m.replace {
+				continue
+			}
+		}
+		pairs = append(pairs, lp)
+	}
+
+	// Registries expect label pairs sorted by names.
+	slices.SortFunc(pairs, func(a, b *dto.LabelPair) int { return strings.Compare(a.GetName(), b.GetName()) })
+	out.Label = pairs
+
+	return nil
+}
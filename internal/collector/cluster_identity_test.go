@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_identityHost(t *testing.T) {
+	for host, want := range map[string]string{
+		"":                    "localhost",
+		"/var/run/postgresql": "localhost",
+		"localhost":           "localhost",
+		"127.0.0.1":           "localhost",
+		"::1":                 "localhost",
+		"10.0.0.1":            "10.0.0.1",
+		"db.example.org":      "db.example.org",
+	} {
+		assert.Equal(t, want, identityHost(host), host)
+	}
+}
+
+func Test_syncPatroniScopes(t *testing.T) {
+	s := &syncPatroniScopes{store: map[string]patroniScope{}}
+	now := time.Now()
+
+	s.set("http://127.0.0.1:8008", "main", now)
+	s.set("http://10.0.0.1:8008", "first", now)
+	s.set("http://10.0.0.1:8009", "second", now)
+	s.set("http://10.0.0.2:8008", "", now)
+
+	assert.Equal(t, "main", s.get("localhost", now))
+	assert.Equal(t, "", s.get("10.0.0.1", now)) // ambiguous
+	assert.Equal(t, "", s.get("10.0.0.2", now))
+
+	// Scopes which are not reported anymore are forgotten.
+	later := now.Add(patroniScopeTTL + time.Second)
+	assert.Equal(t, "", s.get("localhost", later))
+	s.set("http://10.0.0.3:8008", "other", later)
+	assert.Len(t, s.store, 1)
+}
+
+func Test_clusterIdentity(t *testing.T) {
+	assert.Nil(t, newClusterIdentity(Config{ServiceType: model.ServiceTypePostgresql}))
+	assert.Nil(t, newClusterIdentity(Config{ServiceType: model.ServiceTypePgbouncer, ClusterIdentity: ClusterIdentityLabel}))
+
+	c := newClusterIdentity(Config{ServiceType: model.ServiceTypePostgresql, ClusterIdentity: ClusterIdentityLabel, ConnString: "host=192.0.2.1 port=5432"})
+	require.NotNil(t, c)
+	assert.Equal(t, "192.0.2.1", c.host)
+
+	now := time.Now()
+	assert.Equal(t, "", c.current(now))
+
+	// Value requested during service configuration is used at first, it's not requested again until interval passed.
+	c.refresh(Config{ConnString: "host=192.0.2.1 port=5432", postgresServiceConfig: postgresServiceConfig{clusterName: "main"}}, now)
+	c.refresh(Config{ConnString: "host=192.0.2.1 port=5432"}, now.Add(time.Second))
+	assert.Equal(t, "main", c.current(now))
+
+	// Scope of Patroni running on the same host has priority.
+	patroniScopes.set("http://192.0.2.1:8008", "patroni", now)
+	defer func() {
+		patroniScopes.mu.Lock()
+		delete(patroniScopes.store, "http://192.0.2.1:8008")
+		patroniScopes.mu.Unlock()
+	}()
+	assert.Equal(t, "patroni", c.current(now))
+}
+
+func Test_clusterIdentity_relabel(t *testing.T) {
+	desc := prometheus.NewDesc("test_metric", "Test.", []string{"database"}, prometheus.Labels{"service_id": "svc", "host": "10.0.0.1", "port": "5432"})
+	m := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "shop")
+
+	testcases := []struct {
+		mode     string
+		identity string
+		want     map[string]string
+	}{
+		{mode: ClusterIdentityLabel, identity: "main", want: map[string]string{"cluster": "main", "database": "shop", "host": "10.0.0.1", "port": "5432", "service_id": "svc"}},
+		{mode: ClusterIdentityReplace, identity: "main", want: map[string]string{"cluster": "main", "database": "shop", "service_id": "svc"}},
+		{mode: ClusterIdentityReplace, identity: "", want: map[string]string{"database": "shop", "host": "10.0.0.1", "port": "5432", "service_id": "svc"}},
+	}
+
+	for _, tc := range testcases {
+		c := &clusterIdentity{mode: tc.mode}
+		got := c.relabel(m, tc.identity)
+		assert.Equal(t, desc, got.Desc())
+
+		pb := &dto.Metric{}
+		require.NoError(t, got.Write(pb))
+
+		labels := map[string]string{}
+		var names []string
+		for _, lp := range pb.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+			names = append(names, lp.GetName())
+		}
+		assert.Equal(t, tc.want, labels)
+		assert.IsIncreasing(t, names)
+		assert.Equal(t, float64(1), pb.GetGauge().GetValue())
+	}
+}
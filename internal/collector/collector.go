@@ -2,17 +2,41 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
 	"maps"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/cherts/pgscv/plugin"
 	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// collectorLog is the logger of collectors, repeating errors of collectors are rate-limited.
+var collectorLog = log.Module("collector")
+
+const (
+	// ClusterIdentityLabel defines mode when 'cluster' label with stable cluster identity is added to service labels.
+	ClusterIdentityLabel = "label"
+	// ClusterIdentityReplace defines mode when 'host' and 'port' service labels are replaced with 'cluster' label.
+	ClusterIdentityReplace = "replace"
+)
+
+// dbPoolIdleTimeout defines how long unused per-database connection pools and their connections are kept open.
+const dbPoolIdleTimeout = 5 * time.Minute
+
+// queryRetryTimeout defines time since scrape start, during which queries failed with transient errors are retried. It
+// matches default scrape timeout of Prometheus, so retries don't delay scrape response beyond it.
+const queryRetryTimeout = 10 * time.Second
+
 // Factories defines collector functions which used for collecting metrics.
 type Factories map[string]func(labels, model.CollectorSettings) (Collector, error)
 
@@ -28,12 +52,17 @@ func (f Factories) RegisterSystemCollectors(disabled []string) {
 		"system/sysinfo":     NewSysInfoCollector,
 		"system/loadaverage": NewLoadAverageCollector,
 		"system/cpu":         NewCPUCollector,
+		"system/cgroup":      NewCgroupCollector,
+		"system/clock":       NewClockCollector,
 		"system/diskstats":   NewDiskstatsCollector,
+		"system/exec":        NewExecCollector,
 		"system/filesystems": NewFilesystemCollector,
 		"system/netdev":      NewNetdevCollector,
 		"system/network":     NewNetworkCollector,
 		"system/memory":      NewMeminfoCollector,
 		"system/sysconfig":   NewSysconfigCollector,
+		"system/systemd":     NewSystemdCollector,
+		"http/custom":        NewHTTPCustomCollector,
 	}
 
 	for name, fn := range funcs {
@@ -58,16 +87,29 @@ func (f Factories) RegisterPostgresCollectors(disabled []string) {
 		"postgres/pgscv":             NewPgscvServicesCollector,
 		"postgres/activity":          NewPostgresActivityCollector,
 		"postgres/archiver":          NewPostgresWalArchivingCollector,
+		"postgres/auth_config":       NewPostgresAuthConfigCollector,
 		"postgres/bgwriter":          NewPostgresBgwriterCollector,
+		"postgres/buffercache":       NewPostgresBuffercacheCollector,
+		"postgres/checksums":         NewPostgresChecksumsCollector,
 		"postgres/conflicts":         NewPostgresConflictsCollector,
 		"postgres/databases":         NewPostgresDatabasesCollector,
+		"postgres/ddl":               NewPostgresDDLCollector,
+		"postgres/extensions":        NewPostgresExtensionsCollector,
 		"postgres/indexes":           NewPostgresIndexesCollector,
 		"postgres/functions":         NewPostgresFunctionsCollector,
 		"postgres/locks":             NewPostgresLocksCollector,
 		"postgres/logs":              NewPostgresLogsCollector,
+		"postgres/objects":           NewPostgresObjectsCollector,
+		"postgres/pgvector":          NewPostgresPgvectorCollector,
+		"postgres/probe":             NewProbeCollector,
+		"postgres/publications":      NewPostgresPublicationsCollector,
+		"postgres/recovery_prefetch": NewPostgresRecoveryPrefetchCollector,
 		"postgres/replication":       NewPostgresReplicationCollector,
 		"postgres/replication_slots": NewPostgresReplicationSlotsCollector,
+		"postgres/roles":             NewPostgresRolesCollector,
 		"postgres/statements":        NewPostgresStatementsCollector,
+		"postgres/statements_query":  NewPostgresStatementsQueryCollector,
+		"postgres/statements_plans":  NewPostgresStatementsPlansCollector,
 		"postgres/schemas":           NewPostgresSchemasCollector,
 		"postgres/settings":          NewPostgresSettingsCollector,
 		"postgres/storage":           NewPostgresStorageCollector,
@@ -77,7 +119,10 @@ func (f Factories) RegisterPostgresCollectors(disabled []string) {
 		"postgres/stat_subscription": NewPostgresStatSubscriptionCollector,
 		"postgres/stat_ssl":          NewPostgresStatSslCollector,
 		"postgres/tables":            NewPostgresTablesCollector,
+		"postgres/table_bloat":       NewPostgresTableBloatCollector,
+		"postgres/vacuum":            NewPostgresVacuumCollector,
 		"postgres/wal":               NewPostgresWalCollector,
+		"postgres/watchdog":          NewPostgresWatchdogCollector,
 		"postgres/custom":            NewPostgresCustomCollector,
 	}
 
@@ -101,8 +146,10 @@ func (f Factories) RegisterPgbouncerCollectors(disabled []string) {
 	funcs := map[string]func(labels, model.CollectorSettings) (Collector, error){
 		"pgbouncer/pgscv":    NewPgscvServicesCollector,
 		"pgbouncer/pools":    NewPgbouncerPoolsCollector,
+		"pgbouncer/probe":    NewProbeCollector,
 		"pgbouncer/stats":    NewPgbouncerStatsCollector,
 		"pgbouncer/settings": NewPgbouncerSettingsCollector,
+		"pgbouncer/custom":   NewPgbouncerCustomCollector,
 	}
 
 	for name, fn := range funcs {
@@ -126,6 +173,8 @@ func (f Factories) RegisterPatroniCollectors(disabled []string) {
 	funcs := map[string]func(labels, model.CollectorSettings) (Collector, error){
 		"patroni/pgscv":  NewPgscvServicesCollector,
 		"patroni/common": NewPatroniCommonCollector,
+		"patroni/probe":  NewProbeCollector,
+		"patroni/custom": NewPatroniCustomCollector,
 	}
 
 	for name, fn := range funcs {
@@ -152,20 +201,63 @@ type Collector interface {
 
 // PgscvCollector implements the prometheus.Collector interface.
 type PgscvCollector struct {
-	Config     Config
+	Config Config
+	// configMu protects Config which is updated when service configuration is refilled.
+	configMu   sync.RWMutex
 	Collectors map[string]Collector
 	// anchorDesc is a metric descriptor used for distinguishing collectors when unregister is required.
 	anchorDesc typedDesc
+	// dropped accumulates number of series dropped due to exceeded scrape limits.
+	dropped *droppedSeries
+	// silences keeps collectors which should not be executed during scrapes.
+	silences *silences
+	// derived defines collectors which compute metrics using stats of other collectors.
+	derived []derivedCollector
+	// pools defines metrics of connection pools used by collectors.
+	pools *poolsStats
+	// sampler defines background sampler of active sessions, nil if sampling is disabled.
+	sampler *sessionSampler
+	// queries defines metrics of durations of queries executed by collectors.
+	queries *queryStats
+	// errors defines metrics of errors of queries executed by collectors.
+	errors *queryErrors
+	// freshness defines metrics of age of values produced by collectors.
+	freshness *dataFreshness
+	// capabilities defines metrics of capabilities available for collecting metrics of the service.
+	capabilities *serviceCapabilities
+	// warmUp defines staggered first execution of heavy collectors, nil if warm-up is disabled.
+	warmUp *warmUp
+	// rules defines recording rules evaluated over metrics of the service, nil if there are no rules.
+	rules *recordingRules
+	// identity defines stable cluster identity reflected in labels of metrics, nil if identity is not used.
+	identity *clusterIdentity
 }
 
 // NewPgscvCollector accepts Factories and creates per-service instance of Collector.
 func NewPgscvCollector(serviceID string, factories Factories, config Config) (*PgscvCollector, error) {
 	collectors := make(map[string]Collector)
-	pgConfig, err := pgx.ParseConfig(config.ConnString)
-	if err != nil {
-		return nil, err
+	var constLabels labels
+	if _, ok := plugin.Lookup(config.ServiceType); ok {
+		// Connection strings of plugin service types have service-specific format, use address of the service.
+		host, port, err := probeEndpoint(config)
+		if err != nil {
+			return nil, err
+		}
+		constLabels = labels{"service_id": serviceID, "host": host, "port": port}
+	} else {
+		pgConfig, err := pgx.ParseConfig(config.ConnString)
+		if err != nil {
+			return nil, err
+		}
+		constLabels = labels{"service_id": serviceID, "host": pgConfig.Host, "port": strconv.FormatUint(uint64(pgConfig.Port), 10)}
+	}
+	if config.ClusterNameLabel && config.ServiceType == model.ServiceTypePostgresql {
+		applyClusterName(constLabels, config.clusterName)
 	}
-	constLabels := labels{"service_id": serviceID, "host": pgConfig.Host, "port": strconv.FormatUint(uint64(pgConfig.Port), 10)}
+	if config.TargetLabels != nil {
+		addServiceLabels(constLabels, *config.TargetLabels)
+	}
+	addServiceLabels(constLabels, config.ExtraLabels)
 	if config.ConstLabels != nil {
 		maps.Copy(constLabels, *config.ConstLabels)
 	}
@@ -179,6 +271,23 @@ func NewPgscvCollector(serviceID string, factories Factories, config Config) (*P
 		collectors[key] = collector
 	}
 
+	// Query texts of statements are cached by statements_query collector and reused by statements collector.
+	config.statementsTexts = linkStatementsTexts(collectors)
+
+	// Per-database connection pools are shared by all collectors of Postgres service, pool size is limited by
+	// concurrency limit, so connection limits are respected across all databases.
+	if config.ServiceType == model.ServiceTypePostgresql {
+		maxConns := len(collectors)
+		if config.ConcurrencyLimit != nil && *config.ConcurrencyLimit > 0 {
+			maxConns = *config.ConcurrencyLimit
+		}
+		var err error
+		config.dbPools, err = store.NewPools(config.ConnString, config.ConnTimeout, config.SessionSettings, maxConns, dbPoolIdleTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// anchorDesc is a metric descriptor used for distinguish collectors. Creating many collectors with uniq anchorDesc makes
 	// possible to unregister collectors if they or their associated services become unnecessary or unavailable.
 	desc := newBuiltinTypedDesc(
@@ -188,52 +297,250 @@ func NewPgscvCollector(serviceID string, factories Factories, config Config) (*P
 		filter.New(),
 	)
 
-	return &PgscvCollector{Config: config, Collectors: collectors, anchorDesc: desc}, nil
+	// Sampling is paused while whole service is silenced, or instance is standby as sampling runs on the leader only.
+	silences := newSilences(constLabels)
+	sampler := newSessionSampler(config, constLabels, func() bool {
+		return silences.serviceSilenced() || !config.LeaderElector.IsLeader()
+	})
+	sampler.start()
+
+	return &PgscvCollector{
+		Config:       config,
+		Collectors:   collectors,
+		anchorDesc:   desc,
+		dropped:      newDroppedSeries(constLabels),
+		silences:     silences,
+		derived:      newDerivedCollectors(collectors, constLabels, config.Settings),
+		pools:        newPoolsStats(constLabels),
+		sampler:      sampler,
+		queries:      newQueryStats(constLabels),
+		errors:       newQueryErrors(constLabels),
+		freshness:    newDataFreshness(constLabels),
+		capabilities: newServiceCapabilities(constLabels),
+		warmUp:       newWarmUp(serviceID, collectors, config.WarmUpWindow, time.Now()),
+		rules:        newRecordingRules(config.RecordingRules, constLabels),
+		identity:     newClusterIdentity(config),
+	}, nil
+}
+
+// applyClusterName adds 'cluster' label with value of cluster_name requested during configuration of the service.
+// Label is not added when cluster_name is not set or service is unavailable.
+func applyClusterName(constLabels labels, clusterName string) {
+	if clusterName == "" {
+		log.Warnf("cluster_name is not set or service is unavailable, skip 'cluster' label")
+		return
+	}
+
+	constLabels["cluster"] = clusterName
+}
+
+// addServiceLabels adds target or extra labels to service labels. Labels already defined for the service are not
+// overridden, Prometheus meta labels (e.g. __scrape_timeout__) are not valid for series and skipped. Labels used by
+// metrics of collectors are skipped too, otherwise such metrics would be rejected by registry.
+func addServiceLabels(constLabels labels, extra map[string]string) {
+	for name, value := range extra {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		if IsVariableLabel(name) {
+			log.Warnf("service label '%s' is used by metrics of collectors; skip", name)
+			continue
+		}
+		if _, ok := constLabels[name]; ok {
+			continue
+		}
+		constLabels[name] = value
+	}
 }
 
 // Describe implements the prometheus.Collector interface.
-func (n PgscvCollector) Describe(ch chan<- *prometheus.Desc) {
+func (n *PgscvCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- n.anchorDesc.desc
 }
 
+// Close stops sampling of active sessions and closes connection pools of the collector.
+func (n *PgscvCollector) Close() {
+	n.sampler.stop()
+
+	if n.Config.dbPools != nil {
+		n.Config.dbPools.Close()
+	}
+}
+
+// Silence mutes passed collectors for specified duration, when no collectors passed, whole service is muted.
+func (n *PgscvCollector) Silence(collectors []string, d time.Duration) error {
+	for _, c := range collectors {
+		if _, ok := n.Collectors[c]; !ok {
+			return fmt.Errorf("unknown collector '%s'", c)
+		}
+	}
+
+	n.silences.add(collectors, d)
+	return nil
+}
+
+// TopQueries returns snapshot of top statements collected by statements collector during the last scrape.
+func (n *PgscvCollector) TopQueries() (TopQueriesSnapshot, error) {
+	c, ok := n.Collectors["postgres/statements"].(*postgresStatementsCollector)
+	if !ok {
+		return TopQueriesSnapshot{}, fmt.Errorf("statements collector is not enabled")
+	}
+
+	snapshot := c.topQueries()
+	if snapshot.Updated.IsZero() {
+		return TopQueriesSnapshot{}, fmt.Errorf("statements have not been collected yet")
+	}
+	return snapshot, nil
+}
+
+// CollectOne runs passed collector bypassing stats cached during previous scrapes, and sends produced metrics into
+// channel. Silences, scrape limits and metrics allowlist are not applied, it is intended for debugging slow or misbehaving collectors.
+func (n *PgscvCollector) CollectOne(name string, ch chan<- prometheus.Metric) error {
+	c, ok := n.Collectors[name]
+	if !ok {
+		return fmt.Errorf("collector %s is not enabled", name)
+	}
+
+	config := n.config()
+	if config.ServiceType == "postgres" && config.blockSize == 0 {
+		if err := n.FillServiceConfig(); err != nil {
+			return fmt.Errorf("update service config failed: %w", err)
+		}
+		config = n.config()
+	}
+
+	config.queryObserver = n.queries.observer(name)
+	config.errorObserver = n.errors.observer(name)
+	config.scrapeDeadline = time.Now().Add(queryRetryTimeout)
+	config.bypassCache = true
+
+	return c.Update(config, ch)
+}
+
+// EnabledCollectors returns sorted names of collectors enabled for the service.
+func (n *PgscvCollector) EnabledCollectors() []string {
+	return slices.Sorted(maps.Keys(n.Collectors))
+}
+
 // FlushServiceConfig postgresql service config
-func (n PgscvCollector) FlushServiceConfig() {
-	n.Config.FlushServiceConfig()
+func (n *PgscvCollector) FlushServiceConfig() {
+	config := n.config()
+	config.FlushServiceConfig()
+	n.setPostgresServiceConfig(config.postgresServiceConfig)
+}
+
+// FillServiceConfig requests service-specific settings from the service and keeps them in collector's configuration.
+func (n *PgscvCollector) FillServiceConfig() error {
+	config := n.config()
+	if config.ServiceType != model.ServiceTypePostgresql {
+		return nil
+	}
+
+	err := config.FillPostgresServiceConfig(config.ConnTimeout)
+	if err != nil {
+		return err
+	}
+
+	n.setPostgresServiceConfig(config.postgresServiceConfig)
+	return nil
+}
+
+// ServiceConfigured returns true if service-specific settings have been successfully requested from the service.
+func (n *PgscvCollector) ServiceConfigured() bool {
+	config := n.config()
+	return config.ServiceType != model.ServiceTypePostgresql || config.blockSize != 0
+}
+
+// config returns copy of collector's configuration.
+func (n *PgscvCollector) config() Config {
+	n.configMu.RLock()
+	defer n.configMu.RUnlock()
+	return n.Config
+}
+
+// setPostgresServiceConfig updates Postgres-specific settings in collector's configuration.
+func (n *PgscvCollector) setPostgresServiceConfig(c postgresServiceConfig) {
+	n.configMu.Lock()
+	n.Config.postgresServiceConfig = c
+	n.configMu.Unlock()
 }
 
 // Collect implements the prometheus.Collector interface.
-func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
+func (n *PgscvCollector) Collect(out chan<- prometheus.Metric) {
+	silenced := n.silences.active()
+	_, silencedAll := silenced[silenceAllCollectors]
+
+	// Labels of all metrics of the service are rewritten accordingly to the current cluster identity.
+	if n.identity != nil {
+		if !silencedAll {
+			n.identity.refresh(n.config(), time.Now())
+		}
+		identity := n.identity.current(time.Now())
+
+		relabeled := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		go func(out chan<- prometheus.Metric) {
+			for m := range relabeled {
+				out <- n.identity.relabel(m, identity)
+			}
+			close(done)
+		}(out)
+		defer func() {
+			close(relabeled)
+			<-done
+		}()
+		out = relabeled
+	}
+
+	// Don't touch the service at all when it is silenced, e.g. during planned maintenance.
+	if silencedAll {
+		log.Debugf("service is silenced, skip collecting")
+		n.silences.send(silenced, out)
+		return
+	}
+
 	// Update settings of Postgres collectors if service was unavailabled when register
 	var concurrencyLimit int
 
-	if n.Config.ServiceType == "postgres" {
-		if n.Config.postgresServiceConfig.blockSize == 0 {
+	config := n.config()
+
+	// Wait for a turn when number of concurrently collected services is limited.
+	if config.ScrapeScheduler != nil {
+		config.ScrapeScheduler.acquire()
+		defer config.ScrapeScheduler.release()
+	}
+
+	if config.ServiceType == "postgres" {
+		if config.blockSize == 0 {
 			log.Debug("updating service configuration...")
-			err := n.Config.FillPostgresServiceConfig(n.Config.ConnTimeout)
+			err := n.FillServiceConfig()
 			if err != nil {
 				log.Errorf("update service config failed: %s", err.Error())
 
+				n.capabilities.send(config, out)
+
 				activityCollector, ok := n.Collectors["postgres/activity"]
 				if !ok {
 					return
 				}
 
 				// ping connection, send postgres_up 0
-				collect("postgres/activity", n.Config, activityCollector, out)
+				collect("postgres/activity", config, activityCollector, out)
 
 				return
 			}
+			config = n.config()
 		}
-		if n.Config.ConcurrencyLimit != nil {
-			log.Debugf("user rolConnLimit: %d", n.Config.rolConnLimit)
-			log.Debugf("current ConcurrencyLimit: %d connection limit set for DB", *n.Config.ConcurrencyLimit)
-			if n.Config.rolConnLimit > -1 {
-				concurrencyLimit = n.Config.rolConnLimit
+		if config.ConcurrencyLimit != nil {
+			log.Debugf("user rolConnLimit: %d", config.rolConnLimit)
+			log.Debugf("current ConcurrencyLimit: %d connection limit set for DB", *config.ConcurrencyLimit)
+			if config.rolConnLimit > -1 {
+				concurrencyLimit = config.rolConnLimit
 			} else {
 				concurrencyLimit = len(n.Collectors)
 			}
-			if *n.Config.ConcurrencyLimit < concurrencyLimit {
-				concurrencyLimit = *n.Config.ConcurrencyLimit
+			if *config.ConcurrencyLimit < concurrencyLimit {
+				concurrencyLimit = *config.ConcurrencyLimit
 			}
 		} else {
 			concurrencyLimit = len(n.Collectors)
@@ -252,10 +559,44 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 	// Create pipe channel used transmitting metrics from collectors to sender.
 	pipelineIn := make(chan prometheus.Metric)
 
+	// When scrape limits are enabled, series are buffered per collector and sent after all collectors finished.
+	limited := config.MaxSeriesPerCollector > 0 || config.MaxPayloadBytes > 0
+	results := &limitedSeries{}
+
+	// Values published by collectors are joined after all collectors finished.
+	config.facts = newScrapeFacts()
+
+	// Failed queries are retried only until scrape is expected to be finished.
+	config.scrapeDeadline = time.Now().Add(queryRetryTimeout)
+
+	// Heavy collectors are executed by the leader only.
+	standby := !config.LeaderElector.IsLeader()
+
+	// Only collectors which don't need SQL are executed when SQL access to the local service is rejected.
+	sqlUnavailable := config.ServiceType == model.ServiceTypePostgresql && config.sqlUnavailable
+
 	// Run collectors.
+	now := time.Now()
 	sem := make(chan struct{}, concurrencyLimit)
-	wgCollector.Add(len(n.Collectors))
 	for name, c := range n.Collectors {
+		if _, ok := silenced[name]; ok {
+			log.Debugf("%s: collector is silenced, skip", name)
+			continue
+		}
+		if standby && stringsContains(standbyDisabledCollectors, name) {
+			log.Debugf("%s: instance is standby, skip", name)
+			continue
+		}
+		if sqlUnavailable && !stringsContains(sqlUnavailableCollectors, name) {
+			log.Debugf("%s: SQL access is not available, skip", name)
+			continue
+		}
+		if n.warmUp.pending(name, now) {
+			log.Debugf("%s: collector is warming up, skip", name)
+			continue
+		}
+
+		wgCollector.Add(1)
 		go func(name string, c Collector) {
 			if concurrencyLimit > 0 {
 				sem <- struct{}{}
@@ -267,19 +608,49 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 
 				wgCollector.Done()
 			}()
-			collect(name, n.Config, c, pipelineIn)
+
+			// Durations and errors of queries are accounted by each collector separately.
+			config := config
+			config.queryObserver = n.queries.observer(name)
+			config.errorObserver = n.errors.observer(name)
+
+			if limited {
+				results.collectLimited(name, config, c, n.dropped)
+			} else {
+				collect(name, config, c, pipelineIn)
+			}
+
+			n.freshness.observe(name, c)
 		}(name, c)
 	}
 
 	// Run sender.
 
 	wgSender.Go(func() {
-		send(pipelineIn, out)
+		send(pipelineIn, out, newMetricsAllowlist(config.MetricsAllowlist), n.rules.newScrape())
 	})
 
 	// Wait until all collectors have been finished. Close the channel and allow to sender to send metrics.
 	wgCollector.Wait()
 	close(sem)
+
+	for _, d := range n.derived {
+		d.derive(config.facts, pipelineIn)
+	}
+
+	if limited {
+		results.send(config.MaxPayloadBytes, n.dropped, pipelineIn)
+		n.dropped.send(pipelineIn)
+	}
+
+	n.silences.send(silenced, pipelineIn)
+	n.pools.send(config.dbPools, pipelineIn)
+	n.sampler.send(pipelineIn)
+	n.queries.send(pipelineIn)
+	n.errors.send(pipelineIn)
+	n.freshness.send(pipelineIn)
+	n.capabilities.send(config, pipelineIn)
+
 	close(pipelineIn)
 
 	// Wait until metrics have been sent.
@@ -287,23 +658,72 @@ func (n PgscvCollector) Collect(out chan<- prometheus.Metric) {
 }
 
 // send acts like a middleware between metric collector functions which produces metrics and Prometheus who accepts metrics.
-func send(in <-chan prometheus.Metric, out chan<- prometheus.Metric) {
+func send(in <-chan prometheus.Metric, out chan<- prometheus.Metric, allowlist *metricsAllowlist, rules *ruleSeries) {
 	for m := range in {
 		// Skip received nil values
 		if m == nil {
 			continue
 		}
 
+		// Remember series used by recording rules, including series of not allowed families.
+		rules.observe(m)
+
+		// Skip metrics which families are not allowed.
+		if !allowlist.allowed(m) {
+			continue
+		}
+
 		// implement other middlewares here.
 
 		out <- m
 	}
+
+	// Recording rules are evaluated when all metrics have been received.
+	if rules != nil {
+		computed := make(chan prometheus.Metric)
+		go func() {
+			rules.send(computed)
+			close(computed)
+		}()
+		for m := range computed {
+			if allowlist.allowed(m) {
+				out <- m
+			}
+		}
+	}
 }
 
 // collect runs metric collection function and wraps it into instrumenting logic.
 func collect(name string, config Config, c Collector, ch chan<- prometheus.Metric) {
 	err := c.Update(config, ch)
 	if err != nil {
-		log.Errorf("%s collector failed; %s", name, err)
+		// The same errors are usually repeated every scrape (e.g. lack of permissions), don't flood logs.
+		var qerr *store.QueryError
+		if errors.As(err, &qerr) {
+			collectorLog.ErrorfLimited(name+config.ConnString+config.BaseURL, "%s collector failed with %s error; %s", name, qerr.Class, err)
+			return
+		}
+		collectorLog.ErrorfLimited(name+config.ConnString+config.BaseURL, "%s collector failed; %s", name, err)
+	}
+}
+
+// ReleaseServiceCaches removes data cached by collectors for the service with passed connection string. Returns
+// number of removed cache entries.
+func ReleaseServiceCaches(connString string) int {
+	if storageMountpoints.remove(connString) {
+		return 1
+	}
+	return 0
+}
+
+// ReapServiceCaches removes data cached by collectors for services which are not active anymore. Returns number of
+// removed cache entries.
+func ReapServiceCaches(active func(connString string) bool) int {
+	var n int
+	for _, owner := range storageMountpoints.owners() {
+		if !active(owner) {
+			n += ReleaseServiceCaches(owner)
+		}
 	}
+	return n
 }
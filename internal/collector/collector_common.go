@@ -7,12 +7,12 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
-	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -86,6 +86,13 @@ func newCustomTypedDesc(opts descOpts, dtype prometheus.ValueType, valueSource s
 
 // newConstMetric is the wrapper on prometheus.NewConstMetric
 func (d *typedDesc) newConstMetric(value float64, labelValues ...string) prometheus.Metric {
+	return d.newConstMetricWithCreated(value, time.Time{}, labelValues...)
+}
+
+// newConstMetricWithCreated is the wrapper on prometheus.NewConstMetricWithCreatedTimestamp. Created timestamp is set
+// to counters only, zero timestamp is not set. Created timestamps are exposed as '_created' samples only when
+// OpenMetrics format is negotiated.
+func (d *typedDesc) newConstMetricWithCreated(value float64, created time.Time, labelValues ...string) prometheus.Metric {
 	if d.factor != 0 {
 		value *= d.factor
 	}
@@ -100,7 +107,13 @@ func (d *typedDesc) newConstMetric(value float64, labelValues ...string) prometh
 		return nil
 	}
 
-	m, err := prometheus.NewConstMetric(d.desc, d.valueType, value, labelValues...)
+	var m prometheus.Metric
+	var err error
+	if created.IsZero() || d.valueType != prometheus.CounterValue {
+		m, err = prometheus.NewConstMetric(d.desc, d.valueType, value, labelValues...)
+	} else {
+		m, err = prometheus.NewConstMetricWithCreatedTimestamp(d.desc, d.valueType, value, created, labelValues...)
+	}
 	if err != nil {
 		log.Errorf("create const metric failed: %s; skip. Failed metric descriptor: '%s'", err, d.desc.String())
 	}
@@ -108,6 +121,27 @@ func (d *typedDesc) newConstMetric(value float64, labelValues ...string) prometh
 	return m
 }
 
+// newConstMetricWithExemplar is the wrapper on newConstMetric which attaches exemplar with passed labels to the metric.
+// Exemplars are exposed only when OpenMetrics format is negotiated and ignored in classic text format.
+func (d *typedDesc) newConstMetricWithExemplar(value float64, exemplarLabels prometheus.Labels, labelValues ...string) prometheus.Metric {
+	m := d.newConstMetric(value, labelValues...)
+	if m == nil || len(exemplarLabels) == 0 {
+		return m
+	}
+
+	if d.factor != 0 {
+		value *= d.factor
+	}
+
+	me, err := prometheus.NewMetricWithExemplars(m, prometheus.Exemplar{Value: value, Labels: exemplarLabels})
+	if err != nil {
+		log.Warnf("create metric exemplar failed: %s; skip exemplar. Failed metric descriptor: '%s'", err, d.desc.String())
+		return m
+	}
+
+	return me
+}
+
 // hasFilter checks label values against configured filters. Returns true if metric has to be filtered and false otherwise.
 func (d *typedDesc) hasFilter(labelValues []string) bool {
 	for i, key := range d.labelNames {
@@ -128,6 +162,8 @@ type typedDescSet struct {
 	subsystem   string         // subsystem to which all nested metrics are belong
 	databasesRE *regexp.Regexp // compiled regexp.Regexp object with databases from which metrics should be collected
 	query       string         // query used for requesting stats
+	endpoint    string         // REST API endpoint used for requesting stats, Patroni only
+	path        string         // JSONPath expression selecting objects of endpoint response, Patroni only
 	descs       []typedDesc    // metrics descriptors
 }
 
@@ -210,6 +246,8 @@ func newDescSet(namespace string, subsystemName string, subsystem model.MetricsS
 		subsystem:   subsystemName,
 		databasesRE: databasesRE,
 		query:       subsystem.Query,
+		endpoint:    subsystem.Endpoint,
+		path:        subsystem.Path,
 		descs:       descs,
 	}, nil
 }
@@ -235,18 +273,13 @@ func updateAllDescSets(config Config, descSets []typedDescSet, ch chan<- prometh
 
 // updateFromMultipleDatabases method visits all requested databases and collects necessary metrics.
 func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	realDatabases, err := listDatabases(conn)
-	if err != nil {
-		return err
-	}
-
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	realDatabases, err := listDatabases(conn, config.ExcludeDatabasesRE)
 	if err != nil {
 		return err
 	}
@@ -260,14 +293,13 @@ func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan
 			}
 
 			// Connect to the database and update metrics.
-			pgconfig.Database = dbname
-			conn, err := store.NewWithConfig(pgconfig)
+			conn, err := config.acquireDatabaseConn(dbname)
 			if err != nil {
 				return err
 			}
-			defer conn.Close()
 
 			err = updateSingleDescSet(conn, s, ch, true)
+			conn.Close()
 			if err != nil {
 				log.Errorf("collect failed: %s; skip", err)
 			}
@@ -279,7 +311,7 @@ func updateFromMultipleDatabases(config Config, descSets []typedDescSet, ch chan
 
 // updateFromSingleDatabase method visit only one database and collect necessary metrics.
 func updateFromSingleDatabase(config Config, descSets []typedDescSet, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
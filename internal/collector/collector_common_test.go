@@ -7,11 +7,13 @@ import (
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"regexp"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func Test_newConstMetric(t *testing.T) {
@@ -28,6 +30,70 @@ func Test_newConstMetric(t *testing.T) {
 	assert.Nil(t, m)
 }
 
+func Test_newConstMetricWithExemplar(t *testing.T) {
+	d := newBuiltinTypedDesc(
+		descOpts{"postgres", "statements", "calls_total", "Test description.", 0},
+		prometheus.CounterValue,
+		[]string{"L1", "L2"}, nil,
+		filter.New(),
+	)
+	m := d.newConstMetricWithExemplar(10, prometheus.Labels{"queryid": "123"}, "L1", "L2")
+	assert.NotNil(t, m)
+
+	pb := &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.NotNil(t, pb.GetCounter().GetExemplar())
+	assert.Equal(t, float64(10), pb.GetCounter().GetExemplar().GetValue())
+	assert.Equal(t, "queryid", pb.GetCounter().GetExemplar().GetLabel()[0].GetName())
+
+	// No exemplar labels, plain metric expected.
+	m = d.newConstMetricWithExemplar(10, nil, "L1", "L2")
+	assert.NotNil(t, m)
+	pb = &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.Nil(t, pb.GetCounter().GetExemplar())
+
+	m = d.newConstMetricWithExemplar(1, prometheus.Labels{"queryid": "123"}, "L1", "L2", "L3")
+	assert.Nil(t, m)
+}
+
+func Test_newConstMetricWithCreated(t *testing.T) {
+	counter := newBuiltinTypedDesc(
+		descOpts{"postgres", "database", "xact_commits_total", "Test description.", 0},
+		prometheus.CounterValue,
+		[]string{"L1"}, nil,
+		filter.New(),
+	)
+	created := time.Unix(1700000000, 0)
+
+	m := counter.newConstMetricWithCreated(10, created, "L1")
+	assert.NotNil(t, m)
+	pb := &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.Equal(t, created.Unix(), pb.GetCounter().GetCreatedTimestamp().GetSeconds())
+
+	// Zero time, created timestamp is not set.
+	m = counter.newConstMetricWithCreated(10, time.Time{}, "L1")
+	pb = &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.Nil(t, pb.GetCounter().GetCreatedTimestamp())
+
+	// Gauges have no created timestamps.
+	gauge := newBuiltinTypedDesc(
+		descOpts{"postgres", "database", "size_bytes", "Test description.", 0},
+		prometheus.GaugeValue,
+		[]string{"L1"}, nil,
+		filter.New(),
+	)
+	m = gauge.newConstMetricWithCreated(10, created, "L1")
+	pb = &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+	assert.Equal(t, float64(10), pb.GetGauge().GetValue())
+
+	m = counter.newConstMetricWithCreated(1, created, "L1", "L2")
+	assert.Nil(t, m)
+}
+
 func Test_typedDesc_hasFilter(t *testing.T) {
 	f := filter.New()
 	f.Add("target", filter.Filter{Exclude: "unwanted"})
@@ -36,3 +36,60 @@ func TestPgscvCollector_Collect(t *testing.T) {
 	assert.NotNil(t, metrics)
 	assert.Greater(t, len(metrics), 0)
 }
+
+func TestPgscvCollector_CollectOne(t *testing.T) {
+	f := Factories{}
+	f.RegisterSystemCollectors([]string{})
+	c, err := NewPgscvCollector("test:0", f, Config{})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric)
+	var metrics []prometheus.Metric
+	done := make(chan struct{})
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	assert.NoError(t, c.CollectOne("system/loadaverage", ch))
+	assert.Error(t, c.CollectOne("postgres/unknown", ch))
+	close(ch)
+	<-done
+
+	assert.Greater(t, len(metrics), 0)
+}
+
+func Test_applyClusterName(t *testing.T) {
+	constLabels := labels{"service_id": "test", "host": "10.0.0.1", "port": "5432"}
+	applyClusterName(constLabels, "")
+	assert.Equal(t, labels{"service_id": "test", "host": "10.0.0.1", "port": "5432"}, constLabels)
+
+	applyClusterName(constLabels, "main")
+	assert.Equal(t, labels{"service_id": "test", "host": "10.0.0.1", "port": "5432", "cluster": "main"}, constLabels)
+}
+
+func Test_addServiceLabels(t *testing.T) {
+	constLabels := labels{"service_id": "test", "host": "10.0.0.1", "port": "5432"}
+	addServiceLabels(constLabels, map[string]string{"env": "prod", "host": "example", "__scrape_timeout__": "10s", "database": "db"})
+	addServiceLabels(constLabels, map[string]string{"env": "dev", "region": "eu"})
+	addServiceLabels(constLabels, nil)
+
+	assert.Equal(t, labels{"service_id": "test", "host": "10.0.0.1", "port": "5432", "env": "prod", "region": "eu"}, constLabels)
+}
+
+func TestReapServiceCaches(t *testing.T) {
+	storageMountpoints.add("service1", "/reap/data1")
+	storageMountpoints.add("service2", "/reap/data2")
+	defer storageMountpoints.remove("service1")
+	defer storageMountpoints.remove("service2")
+
+	assert.Equal(t, 1, ReapServiceCaches(func(connString string) bool { return connString != "service1" }))
+	assert.False(t, storageMountpoints.contains("/reap/data1"))
+	assert.True(t, storageMountpoints.contains("/reap/data2"))
+
+	assert.Equal(t, 1, ReleaseServiceCaches("service2"))
+	assert.Equal(t, 0, ReleaseServiceCaches("service2"))
+	assert.False(t, storageMountpoints.contains("/reap/data2"))
+}
@@ -10,12 +10,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/jackc/pgx/v4"
 )
 
+const (
+	// postgresRolConnLimitQuery defines query for connection limit of the role used by pgSCV.
+	postgresRolConnLimitQuery = "SELECT rolconnlimit FROM pg_roles WHERE rolname = USER"
+	// postgresSettingQuery defines query for value of the setting passed in parameter.
+	postgresSettingQuery = "SELECT setting FROM pg_catalog.pg_settings WHERE name = $1"
+	// postgresFullVersionQuery defines query for full version string of Postgres.
+	postgresFullVersionQuery = "SELECT pg_catalog.version()"
+	// postgresAwsAuroraQuery defines query for checking the service is Amazon Aurora.
+	postgresAwsAuroraQuery = "SELECT pg_catalog.count(1) = 1 FROM pg_catalog.pg_settings WHERE name = 'rds.extensions' AND setting LIKE '%aurora_stat_utils%'"
+	// postgresCitusQuery defines query for checking Citus extension is installed.
+	postgresCitusQuery = "SELECT pg_catalog.count(1) = 1 FROM pg_catalog.pg_extension WHERE extname = 'citus'"
+	// postgresExtensionSchemaQuery defines query for schema where extension passed in parameter is installed.
+	postgresExtensionSchemaQuery = "SELECT extnamespace::regnamespace FROM pg_extension WHERE extname = $1"
+)
+
 // Config defines collector's global configuration.
 type Config struct {
 	// ServiceType defines the type of discovered service. Depending on the type there should be different settings or
@@ -25,12 +41,16 @@ type Config struct {
 	ConnString string
 	// BaseURL defines a URL string for connecting to HTTP service
 	BaseURL string
+	// BaseURLAuth defines credentials and TLS settings used for connecting to HTTP service.
+	BaseURLAuth http.ClientAuthConfig
 	// NoTrackMode controls collector to gather and send sensitive information, such as queries texts.
 	NoTrackMode bool
 	// postgresServiceConfig defines collector's options specific for Postgres service
 	postgresServiceConfig
 	// DatabasesRE defines regexp with databases from which builtin metrics should be collected.
 	DatabasesRE *regexp.Regexp
+	// ExcludeDatabasesRE defines regexp with databases excluded from metrics collection.
+	ExcludeDatabasesRE *regexp.Regexp
 	// Settings defines collectors settings propagated from main YAML configuration.
 	Settings         model.CollectorsSettings
 	CollectTopTable  int
@@ -40,8 +60,104 @@ type Config struct {
 	TargetLabels     *map[string]string
 	ConnTimeout      int // in seconds
 	ConcurrencyLimit *int
+	// CollectTopApplication defines number of applications exposed in activity breakdown, 0 means default number.
+	CollectTopApplication int
+	// MaxSeriesPerCollector defines max number of series exposed by single collector, 0 means no limit.
+	MaxSeriesPerCollector int
+	// MaxPayloadBytes defines max estimated size of series exposed by service during single scrape, 0 means no limit.
+	MaxPayloadBytes int
+	// MetricsAllowlist defines metric families exposed by service, all other metrics are dropped. Empty list means all
+	// metrics are exposed.
+	MetricsAllowlist []string
+	// RecordingRules defines metrics computed at scrape time using expressions over metrics of the service.
+	RecordingRules model.RecordingRules
+	// ChecksumsVerifyInterval defines interval of data files checksums verification, 0 means verification disabled.
+	ChecksumsVerifyInterval time.Duration
+	// ChecksumsVerifyRate defines max rate of reading data files during checksums verification, bytes per second.
+	ChecksumsVerifyRate int
+	// ClusterIdentity defines how stable cluster identity is reflected in service labels, see ClusterIdentity* constants.
+	ClusterIdentity string
+	// ClusterNameLabel defines adding 'cluster' label with value of cluster_name to labels of Postgres service.
+	ClusterNameLabel bool
+	// ExtraLabels defines labels added to metrics of all services.
+	ExtraLabels map[string]string
+	// SessionSettings defines session settings (GUCs) applied to connections used by collectors.
+	SessionSettings map[string]string
+	// BuffercacheTTL defines interval during which pg_buffercache stats are reused, 0 means default interval.
+	BuffercacheTTL time.Duration
+	// TableBloatTables defines tables sampled using pgstattuple_approx(), in 'database.schema.table' format.
+	TableBloatTables []string
+	// TableBloatTop defines number of the largest tables of each database sampled using pgstattuple_approx().
+	TableBloatTop int
+	// TableBloatTTL defines interval during which sampled bloat of tables is reused, 0 means default interval.
+	TableBloatTTL time.Duration
+	// IndexProbesTop defines number of the largest GIN and BRIN indexes of each database probed using pgstattuple and
+	// pageinspect functions, 0 means probes disabled.
+	IndexProbesTop int
+	// IndexProbesTTL defines interval during which probed stats of indexes are reused, 0 means default interval.
+	IndexProbesTTL time.Duration
+	// DDLTracking defines whether catalog snapshots are compared between scrapes for tracking DDL activity.
+	DDLTracking bool
+	// ProbeICMP defines whether service endpoints are probed using ICMP echo in addition to TCP connect.
+	ProbeICMP bool
+	// WatchdogMaxQueryAge defines max age of pgSCV own queries, older queries are cancelled. 0 means watchdog disabled.
+	WatchdogMaxQueryAge time.Duration
+	// SchemaLabelMaxLength defines max length of label values of schema metrics. 0 means unlimited.
+	SchemaLabelMaxLength int
+	// SchemaLabelHash defines truncated label values of schema metrics should be suffixed with hash of the full value.
+	SchemaLabelHash bool
+	// SchemaMaxSeries defines max number of series of each schema metric. 0 means unlimited.
+	SchemaMaxSeries int
+	// DirWalkRate defines max number of files visited per second when calculating directories sizes. 0 means unlimited.
+	DirWalkRate int
+	// DirWalkTimeout defines max duration of waiting for calculating directory size, the last calculated size is used when
+	// calculating is not finished in time. 0 means unlimited.
+	DirWalkTimeout time.Duration
+	// DirWalkCacheTTL defines how long calculated directories sizes are reused. 0 means no caching.
+	DirWalkCacheTTL time.Duration
+	// SessionSamplingInterval defines interval of sampling active sessions for session history metrics. 0 means disabled.
+	SessionSamplingInterval time.Duration
+	// SessionHistoryMaxQueries defines max number of queries exposed by session history metrics. 0 means unlimited.
+	SessionHistoryMaxQueries int
+	// StatementsQueryTTL defines interval during which query texts of statements are reused, 0 means default interval.
+	StatementsQueryTTL time.Duration
+	// StatementsQueryAll defines query texts are exposed for all statements, not only for statements in top-k.
+	StatementsQueryAll bool
+	// StatementsExplainInterval defines interval of explaining plans of the top statements, 0 means explaining disabled.
+	StatementsExplainInterval time.Duration
+	// WarmUpWindow defines window over which first execution of heavy collectors is staggered, 0 means disabled.
+	WarmUpWindow time.Duration
+	// ScrapeScheduler defines scheduler shared by all services which limits number of services collected concurrently.
+	ScrapeScheduler *ScrapeScheduler
+	// LeaderElector defines elector of the leader among paired instances, nil means election is disabled.
+	LeaderElector *LeaderElector
+	// ServiceLookup defines function for looking up other registered services, could be nil.
+	ServiceLookup ServiceLookup
+	// facts keeps values published by collectors during scrape, used for computing derived metrics.
+	facts *scrapeFacts
+	// statementsTexts defines query texts of statements shared by statements collectors, nil if texts are not cached.
+	statementsTexts *statementsTexts
+	// extensionsSchemas defines schemas of extensions installed in the database of the connection string, it is filled
+	// only when queries of collectors are listed.
+	extensionsSchemas map[string]string
+	// pgbouncerVersion defines version of Pgbouncer, it is filled only when queries of collectors are listed.
+	pgbouncerVersion int
+	// dbPools defines per-database connection pools shared by collectors which need per-database access.
+	dbPools *store.Pools
+	// queryObserver defines observer of queries executed by collector, it is set separately for each collector.
+	queryObserver store.QueryObserver
+	// errorObserver defines observer of errors of queries executed by collector, it is set separately for each collector.
+	errorObserver store.ErrorObserver
+	// scrapeDeadline defines time after which failed queries are not retried.
+	scrapeDeadline time.Time
+	// bypassCache defines stats cached by collectors must not be reused, e.g. during on-demand run of collector.
+	bypassCache bool
 }
 
+// ServiceLookup returns ID of registered service of passed type which address matches passed function. Empty string
+// is returned when there is no such service.
+type ServiceLookup func(serviceType string, match func(host string, port uint16) bool) string
+
 // postgresServiceConfig defines Postgres-specific stuff required during collecting Postgres metrics.
 type postgresServiceConfig struct {
 	// localService defines service is running on the local host.
@@ -66,6 +182,11 @@ type postgresServiceConfig struct {
 	pgStatStatementsSchema string
 	// rolConnLimit defines connection limit for the role used by the collector.
 	rolConnLimit int
+	// clusterName defines value of 'cluster_name' GUC.
+	clusterName string
+	// sqlUnavailable defines SQL access to the local service is rejected (e.g. by peer authentication), settings are
+	// taken from postmaster files and only collectors which don't need SQL are executed.
+	sqlUnavailable bool
 }
 
 // PostgresVersion - Identifying information about the PostgreSQL server version and build details
@@ -110,7 +231,7 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	var setting string
 
 	// Get role connection limit.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT rolconnlimit FROM pg_roles WHERE rolname = USER").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresRolConnLimitQuery).Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get rolconnlimit setting from pg_roles, %s, please check user grants", err)
 	}
@@ -121,7 +242,7 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	config.rolConnLimit = int(rolConnLimit)
 
 	// Get Postgres block size.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_catalog.pg_settings WHERE name = 'block_size'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "block_size").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get block_size setting from pg_catalog.pg_settings, %s, please check user grants", err)
 	}
@@ -133,7 +254,7 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	config.blockSize = bsize
 
 	// Get Postgres WAL segment size.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_catalog.pg_settings WHERE name = 'wal_segment_size'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "wal_segment_size").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get wal_segment_size setting from pg_catalog.pg_settings, %s, please check user grants", err)
 	}
@@ -145,7 +266,7 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	config.walSegmentSize = walSegSize
 
 	// Get Postgres server version
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_catalog.pg_settings WHERE name = 'server_version_num'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "server_version_num").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get server_version_num setting from pg_catalog.pg_settings, %s, please check user grants", err)
 	}
@@ -160,13 +281,13 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 
 	config.pgVersion.Numeric = version
 
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_catalog.pg_settings WHERE name = 'server_version'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "server_version").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get server_version setting from pg_catalog.pg_settings, %s, please check user grants", err)
 	}
 	config.pgVersion.Short = setting
 
-	err = conn.Conn().QueryRow(context.Background(), "SELECT pg_catalog.version()").Scan(&config.pgVersion.Full)
+	err = conn.Conn().QueryRow(context.Background(), postgresFullVersionQuery).Scan(&config.pgVersion.Full)
 	if err != nil {
 		return config, fmt.Errorf("failed to get pg_catalog.version(), %s, please check user grants", err)
 	}
@@ -186,7 +307,7 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	config.pgVersion.IsCitus = isCitus
 
 	// Get Postgres data directory
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'data_directory'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "data_directory").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get data_directory setting from pg_settings, %s, please check user grants", err)
 	}
@@ -194,7 +315,7 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	config.dataDirectory = setting
 
 	// Get setting of 'logging_collector' GUC.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'logging_collector'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "logging_collector").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get logging_collector setting from pg_settings, %s, please check user grants", err)
 	}
@@ -204,13 +325,21 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	}
 
 	// Get setting of 'log_destination' GUC.
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'log_destination'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "log_destination").Scan(&setting)
 	if err != nil {
 		return config, fmt.Errorf("failed to get log_destination setting from pg_settings, %s, please check user grants", err)
 	}
 
 	config.logDestination = setting
 
+	// Get setting of 'cluster_name' GUC, Patroni sets it to the cluster scope by default.
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "cluster_name").Scan(&setting)
+	if err != nil {
+		return config, fmt.Errorf("failed to get cluster_name setting from pg_settings, %s, please check user grants", err)
+	}
+
+	config.clusterName = setting
+
 	// Discover pg_stat_statements.
 	exists, database, schema, err := discoverPgStatStatements(connStr)
 	if err != nil {
@@ -227,16 +356,95 @@ func newPostgresServiceConfig(connStr string, connTimeout int) (postgresServiceC
 	return config, nil
 }
 
-// FillPostgresServiceConfig defines new config for Postgres-based collectors.
+// FillPostgresServiceConfig defines new config for Postgres-based collectors. When local service connected via Unix
+// socket rejects connection due to peer authentication, config is filled using postmaster files, hence collectors
+// which don't need SQL could be executed.
 func (cfg *Config) FillPostgresServiceConfig(connTimeout int) error {
 	var err error
 	cfg.postgresServiceConfig, err = newPostgresServiceConfig(cfg.ConnString, connTimeout)
-	return err
+	if err == nil || !isPeerAuthError(err) {
+		return err
+	}
+
+	local, lerr := newLocalPostgresServiceConfig(cfg.ConnString, defaultProcRoot)
+	if lerr != nil {
+		collectorLog.WarnfLimited("local"+cfg.ConnString, "SQL access to local service rejected, read postmaster files failed: %s; skip", lerr)
+		return err
+	}
+
+	// Service config is requested on every scrape until SQL access is granted, don't flood logs.
+	collectorLog.WarnfLimited("local"+cfg.ConnString, "SQL access to local service rejected: %s; only collectors not requiring SQL are executed", err)
+	cfg.postgresServiceConfig = local
+	return nil
 }
 
 // FlushServiceConfig postgresql service config
 func (cfg *Config) FlushServiceConfig() {
-	cfg.postgresServiceConfig, _ = newPostgresServiceConfig(cfg.ConnString, cfg.ConnTimeout)
+	_ = cfg.FillPostgresServiceConfig(cfg.ConnTimeout)
+}
+
+// acquireDatabaseConn returns connection to the passed database of the service. Connection is acquired from the
+// per-database pool if pools are configured, waiting for connection is limited by deadline of the scrape. Otherwise,
+// new connection is established. In both cases connection must be closed by caller.
+func (cfg Config) acquireDatabaseConn(database string) (*store.DB, error) {
+	if cfg.dbPools != nil {
+		ctx := context.Background()
+		if !cfg.scrapeDeadline.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, cfg.scrapeDeadline)
+			defer cancel()
+		}
+
+		conn, err := cfg.dbPools.Acquire(ctx, database)
+		if err != nil {
+			return nil, err
+		}
+		cfg.instrument(conn)
+		return conn, nil
+	}
+
+	pgconfig, err := pgx.ParseConfig(cfg.ConnString)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ConnTimeout > 0 {
+		pgconfig.ConnectTimeout = time.Duration(cfg.ConnTimeout) * time.Second
+	}
+
+	pgconfig.Database = database
+	store.SetSessionSettings(pgconfig, cfg.SessionSettings)
+
+	conn, err := store.NewWithConfig(pgconfig)
+	if err != nil {
+		return nil, err
+	}
+	cfg.instrument(conn)
+
+	return conn, nil
+}
+
+// connect establishes new connection to the service. Queries executed using the connection are accounted by
+// collector's query observer. Connection must be closed by caller.
+func (cfg Config) connect() (*store.DB, error) {
+	conn, err := store.NewWithSettings(cfg.ConnString, cfg.ConnTimeout, cfg.SessionSettings)
+	if err != nil {
+		return nil, err
+	}
+	cfg.instrument(conn)
+
+	return conn, nil
+}
+
+// instrument sets observers of queries executed by collector and deadline of queries retries to the connection.
+func (cfg Config) instrument(conn *store.DB) {
+	conn.SetQueryObserver(cfg.queryObserver)
+	conn.SetErrorObserver(cfg.errorObserver)
+	conn.SetDeadline(cfg.scrapeDeadline)
+}
+
+// isAddressLoopback returns true if passed address is the loopback address or UNIX socket directory.
+func isAddressLoopback(addr string) bool {
+	return strings.HasPrefix(addr, "/") || addr == "localhost" || strings.HasPrefix(addr, "127.") || addr == "::1"
 }
 
 // isAddressLocal return true if passed address is local, and return false otherwise.
@@ -245,11 +453,7 @@ func isAddressLocal(addr string) bool {
 		return false
 	}
 
-	if strings.HasPrefix(addr, "/") {
-		return true
-	}
-
-	if addr == "localhost" || strings.HasPrefix(addr, "127.") || addr == "::1" {
+	if isAddressLoopback(addr) {
 		return true
 	}
 
@@ -283,7 +487,7 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 	defer conn.Close()
 
 	var setting string
-	err = conn.Conn().QueryRow(context.Background(), "SELECT setting FROM pg_settings WHERE name = 'shared_preload_libraries'").Scan(&setting)
+	err = conn.Conn().QueryRow(context.Background(), postgresSettingQuery, "shared_preload_libraries").Scan(&setting)
 	if err != nil {
 		return false, "", "", err
 	}
@@ -303,7 +507,7 @@ func discoverPgStatStatements(connStr string) (bool, string, string, error) {
 	// and we have to walk through all database and looking for it.
 
 	// Get databases list from current connection.
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(conn, nil)
 	if err != nil {
 		return false, "", "", err
 	}
@@ -336,7 +540,7 @@ func extensionInstalledSchema(db *store.DB, name string) string {
 
 	var schema string
 	err := db.Conn().
-		QueryRow(context.Background(), "SELECT extnamespace::regnamespace FROM pg_extension WHERE extname = $1", name).
+		QueryRow(context.Background(), postgresExtensionSchemaQuery, name).
 		Scan(&schema)
 	if err != nil && err != pgx.ErrNoRows {
 		log.Errorf("failed to check extensions '%s' in pg_extension: %s", name, err)
@@ -350,7 +554,7 @@ func extensionInstalledSchema(db *store.DB, name string) string {
 func GetIsAwsAurora(db *store.DB) (bool, error) {
 	var isAurora bool
 	err := db.Conn().
-		QueryRow(context.Background(), "SELECT pg_catalog.count(1) = 1 FROM pg_catalog.pg_settings WHERE name = 'rds.extensions' AND setting LIKE '%aurora_stat_utils%'").
+		QueryRow(context.Background(), postgresAwsAuroraQuery).
 		Scan(&isAurora)
 	return isAurora, err
 }
@@ -359,7 +563,7 @@ func GetIsAwsAurora(db *store.DB) (bool, error) {
 func GetIsCitus(db *store.DB) (bool, error) {
 	var isCitus bool
 	err := db.Conn().
-		QueryRow(context.Background(), "SELECT pg_catalog.count(1) = 1 FROM pg_catalog.pg_extension WHERE extname = 'citus'").
+		QueryRow(context.Background(), postgresCitusQuery).
 		Scan(&isCitus)
 	return isCitus, err
 }
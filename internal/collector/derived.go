@@ -0,0 +1,165 @@
+package collector
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// factWalWrittenBytes defines total amount of WAL written (or received in case of standby), in bytes.
+	factWalWrittenBytes = "wal_written_bytes"
+	// factWalDirectoryFreeBytes defines free space on filesystem where WAL directory is located, in bytes.
+	factWalDirectoryFreeBytes = "wal_directory_free_bytes"
+	// factMaxSlotWalKeepSizeBytes defines value of max_slot_wal_keep_size setting, in bytes.
+	factMaxSlotWalKeepSizeBytes = "max_slot_wal_keep_size_bytes"
+	// factSlotRetainedBytes defines amount of WAL retained by replication slot, in bytes.
+	factSlotRetainedBytes = "replication_slot_retained_bytes"
+)
+
+// fact is a value published by collector during scrape.
+type fact struct {
+	labels labels
+	value  float64
+}
+
+// scrapeFacts keeps values published by collectors during single scrape. When all collectors are finished, the values
+// are joined for computing derived metrics which depend on stats of several collectors.
+type scrapeFacts struct {
+	mu    sync.Mutex
+	store map[string][]fact
+}
+
+// newScrapeFacts creates new scrapeFacts.
+func newScrapeFacts() *scrapeFacts {
+	return &scrapeFacts{store: map[string][]fact{}}
+}
+
+// publish adds value with specified name and labels. Values are ignored when facts are not collected.
+func (f *scrapeFacts) publish(name string, value float64, l labels) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	f.store[name] = append(f.store[name], fact{labels: l, value: value})
+	f.mu.Unlock()
+}
+
+// get returns all values with specified name.
+func (f *scrapeFacts) get(name string) []fact {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.store[name]
+}
+
+// first returns first value with specified name.
+func (f *scrapeFacts) first(name string) (fact, bool) {
+	values := f.get(name)
+	if len(values) == 0 {
+		return fact{}, false
+	}
+	return values[0], true
+}
+
+// derivedCollector computes metrics using values published by other collectors during scrape.
+type derivedCollector interface {
+	derive(facts *scrapeFacts, ch chan<- prometheus.Metric)
+}
+
+// newDerivedCollectors returns derived collectors which could be computed using stats of enabled collectors.
+func newDerivedCollectors(collectors map[string]Collector, constLabels labels, settings model.CollectorsSettings) []derivedCollector {
+	var derived []derivedCollector
+
+	if _, ok := collectors["postgres/wal"]; ok {
+		derived = append(derived, newWalRetentionCollector(constLabels, settings["postgres/wal"]))
+	}
+
+	return derived
+}
+
+// walRetentionCollector computes how long WAL could grow at current rate until it exhausts free disk space or
+// limits of replication slots.
+type walRetentionCollector struct {
+	slotKeepRatio   typedDesc
+	slotKeepSeconds typedDesc
+	diskFullSeconds typedDesc
+
+	mu          sync.Mutex
+	lastWritten float64
+	lastTime    time.Time
+}
+
+// newWalRetentionCollector creates new walRetentionCollector.
+func newWalRetentionCollector(constLabels labels, settings model.CollectorSettings) *walRetentionCollector {
+	return &walRetentionCollector{
+		slotKeepRatio: newBuiltinTypedDesc(
+			descOpts{"postgres", "replication_slot", "wal_keep_ratio", "Ratio of WAL retained by slot to max_slot_wal_keep_size, slot is invalidated when ratio exceeds 1.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "slot_name", "slot_type"}, constLabels,
+			settings.Filters,
+		),
+		slotKeepSeconds: newBuiltinTypedDesc(
+			descOpts{"postgres", "replication_slot", "wal_keep_remaining_seconds", "Estimated time until WAL retained by slot exceeds max_slot_wal_keep_size at current WAL rate, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "slot_name", "slot_type"}, constLabels,
+			settings.Filters,
+		),
+		diskFullSeconds: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_directory", "full_remaining_seconds", "Estimated time until filesystem of WAL directory is full at current WAL rate, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "path"}, constLabels,
+			settings.Filters,
+		),
+	}
+}
+
+// derive implements derivedCollector interface.
+func (c *walRetentionCollector) derive(facts *scrapeFacts, ch chan<- prometheus.Metric) {
+	rate := c.walRate(facts)
+
+	if keepSize, ok := facts.first(factMaxSlotWalKeepSizeBytes); ok && keepSize.value > 0 {
+		for _, slot := range facts.get(factSlotRetainedBytes) {
+			values := []string{slot.labels["database"], slot.labels["slot_name"], slot.labels["slot_type"]}
+
+			ch <- c.slotKeepRatio.newConstMetric(slot.value/keepSize.value, values...)
+			if rate > 0 {
+				ch <- c.slotKeepSeconds.newConstMetric(math.Max(keepSize.value-slot.value, 0)/rate, values...)
+			}
+		}
+	}
+
+	if free, ok := facts.first(factWalDirectoryFreeBytes); ok && rate > 0 {
+		ch <- c.diskFullSeconds.newConstMetric(free.value/rate, free.labels["device"], free.labels["mountpoint"], free.labels["path"])
+	}
+}
+
+// walRate returns rate of WAL written since previous scrape, in bytes per second. Zero is returned when rate is
+// unknown, e.g. during first scrape.
+func (c *walRetentionCollector) walRate(facts *scrapeFacts) float64 {
+	written, ok := facts.first(factWalWrittenBytes)
+	if !ok {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	lastWritten, lastTime := c.lastWritten, c.lastTime
+	c.lastWritten, c.lastTime = written.value, now
+
+	elapsed := now.Sub(lastTime).Seconds()
+	if lastTime.IsZero() || elapsed <= 0 || written.value < lastWritten {
+		return 0
+	}
+
+	return (written.value - lastWritten) / elapsed
+}
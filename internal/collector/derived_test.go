@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_scrapeFacts(t *testing.T) {
+	// Publishing into nil facts is allowed, e.g. when collector is used outside of scrape.
+	var nilFacts *scrapeFacts
+	nilFacts.publish("example", 1, nil)
+	assert.Nil(t, nilFacts.get("example"))
+
+	f := newScrapeFacts()
+	f.publish("example", 1, labels{"name": "a"})
+	f.publish("example", 2, labels{"name": "b"})
+
+	assert.Len(t, f.get("example"), 2)
+	v, ok := f.first("example")
+	assert.True(t, ok)
+	assert.Equal(t, fact{labels: labels{"name": "a"}, value: 1}, v)
+
+	_, ok = f.first("unknown")
+	assert.False(t, ok)
+}
+
+func Test_newDerivedCollectors(t *testing.T) {
+	assert.Len(t, newDerivedCollectors(map[string]Collector{"postgres/activity": nil}, labels{}, nil), 0)
+	assert.Len(t, newDerivedCollectors(map[string]Collector{"postgres/wal": nil}, labels{}, nil), 1)
+}
+
+func Test_walRetentionCollector_derive(t *testing.T) {
+	c := newWalRetentionCollector(labels{}, model.CollectorSettings{})
+
+	newFacts := func(written float64) *scrapeFacts {
+		f := newScrapeFacts()
+		f.publish(factWalWrittenBytes, written, nil)
+		f.publish(factMaxSlotWalKeepSizeBytes, 1000, nil)
+		f.publish(factSlotRetainedBytes, 250, labels{"database": "", "slot_name": "standby1", "slot_type": "physical"})
+		f.publish(factWalDirectoryFreeBytes, 5000, labels{"device": "sda1", "mountpoint": "/", "path": "/data/pg_wal"})
+		return f
+	}
+
+	// First scrape, WAL rate is unknown, only ratio is computed.
+	got := deriveMetrics(c, newFacts(10000))
+	assert.Equal(t, map[string]float64{"postgres_replication_slot_wal_keep_ratio": 0.25}, got)
+
+	// Second scrape, WAL is written at 100 bytes per second.
+	c.lastTime = time.Now().Add(-10 * time.Second)
+	got = deriveMetrics(c, newFacts(11000))
+	require.Len(t, got, 3)
+	assert.Equal(t, 0.25, got["postgres_replication_slot_wal_keep_ratio"])
+	assert.InDelta(t, 7.5, got["postgres_replication_slot_wal_keep_remaining_seconds"], 0.1)
+	assert.InDelta(t, 50, got["postgres_wal_directory_full_remaining_seconds"], 1)
+
+	// WAL counter has been reset, rate is unknown.
+	got = deriveMetrics(c, newFacts(100))
+	assert.Len(t, got, 1)
+}
+
+// deriveMetrics runs derived collector and returns values of produced metrics by their names.
+func deriveMetrics(c derivedCollector, facts *scrapeFacts) map[string]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.derive(facts, ch)
+		close(ch)
+	}()
+
+	got := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		_ = m.Write(pb)
+		got[reDescFqName.FindStringSubmatch(m.Desc().String())[1]] = pb.GetGauge().GetValue()
+	}
+	return got
+}
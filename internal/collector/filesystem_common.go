@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 )
@@ -80,3 +82,130 @@ func truncateDeviceName(path string) string {
 	// Return default (or dereferenced) name.
 	return name
 }
+
+// storageMountpoints keeps mountpoints hosting Postgres data and WAL directories discovered by storage collector.
+// Filesystem collector predicts exhaustion only for these mountpoints.
+var storageMountpoints = newMountpointsSet()
+
+// mountpointsSet is a concurrency-safe set of mountpoints. Mountpoints are kept per owner (service connection string),
+// so mountpoints of removed services could be forgotten.
+type mountpointsSet struct {
+	mu    sync.RWMutex
+	items map[string]map[string]struct{}
+}
+
+// newMountpointsSet creates new empty mountpointsSet.
+func newMountpointsSet() *mountpointsSet {
+	return &mountpointsSet{items: map[string]map[string]struct{}{}}
+}
+
+// add adds passed mountpoints of the owner to the set, empty values are ignored.
+func (s *mountpointsSet) add(owner string, mountpoints ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range mountpoints {
+		if m == "" {
+			continue
+		}
+		if s.items[owner] == nil {
+			s.items[owner] = map[string]struct{}{}
+		}
+		s.items[owner][m] = struct{}{}
+	}
+}
+
+// contains returns true if mountpoint is in the set.
+func (s *mountpointsSet) contains(mountpoint string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, items := range s.items {
+		if _, ok := items[mountpoint]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// owners returns owners of mountpoints in the set.
+func (s *mountpointsSet) owners() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	owners := make([]string, 0, len(s.items))
+	for owner := range s.items {
+		owners = append(owners, owner)
+	}
+	return owners
+}
+
+// remove removes mountpoints of the owner from the set, returns true if the owner has been known.
+func (s *mountpointsSet) remove(owner string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.items[owner]
+	delete(s.items, owner)
+	return ok
+}
+
+// usageSample is a filesystem used bytes observed at specific time.
+type usageSample struct {
+	time time.Time
+	used float64
+}
+
+// usageHistory keeps recent samples of filesystem usage within the window.
+type usageHistory struct {
+	window  time.Duration
+	samples []usageSample
+}
+
+// add appends new sample and removes samples which are out of window.
+func (h *usageHistory) add(ts time.Time, used float64) {
+	h.samples = append(h.samples, usageSample{time: ts, used: used})
+
+	var i int
+	for i < len(h.samples) && ts.Sub(h.samples[i].time) > h.window {
+		i++
+	}
+	h.samples = h.samples[i:]
+}
+
+// growthRate returns usage growth rate in bytes per second, calculated using least squares linear regression over
+// recent samples. Returns false if there are not enough samples.
+func (h *usageHistory) growthRate() (float64, bool) {
+	n := float64(len(h.samples))
+	if n < 3 {
+		return 0, false
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	start := h.samples[0].time
+	for _, s := range h.samples {
+		x := s.time.Sub(start).Seconds()
+		sumX += x
+		sumY += s.used
+		sumXY += x * s.used
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// secondsUntilFull returns predicted number of seconds until available space is exhausted at current growth rate.
+// Returns false if prediction is not possible, e.g. usage is not growing.
+func (h *usageHistory) secondsUntilFull(avail float64) (float64, bool) {
+	rate, ok := h.growthRate()
+	if !ok || rate <= 0 {
+		return 0, false
+	}
+
+	return avail / rate, true
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func Test_parseProcMounts(t *testing.T) {
@@ -50,3 +51,55 @@ func Test_truncateDeviceName(t *testing.T) {
 		assert.Equal(t, tc.want, truncateDeviceName(tc.path))
 	}
 }
+
+func Test_mountpointsSet(t *testing.T) {
+	s := newMountpointsSet()
+	s.add("service1", "/data", "", "/wal", "/data")
+	s.add("service2", "/data2")
+	s.add("service3", "")
+
+	assert.True(t, s.contains("/data"))
+	assert.True(t, s.contains("/wal"))
+	assert.True(t, s.contains("/data2"))
+	assert.False(t, s.contains(""))
+	assert.False(t, s.contains("/"))
+	assert.ElementsMatch(t, []string{"service1", "service2"}, s.owners())
+
+	assert.True(t, s.remove("service1"))
+	assert.False(t, s.remove("service1"))
+	assert.False(t, s.contains("/data"))
+	assert.True(t, s.contains("/data2"))
+}
+
+func Test_usageHistory(t *testing.T) {
+	start := time.Now()
+	h := &usageHistory{window: time.Hour}
+
+	// not enough samples
+	h.add(start, 1000)
+	h.add(start.Add(time.Minute), 1060)
+	_, ok := h.secondsUntilFull(3600)
+	assert.False(t, ok)
+
+	// usage grows 1 byte per second
+	h.add(start.Add(2*time.Minute), 1120)
+	rate, ok := h.growthRate()
+	assert.True(t, ok)
+	assert.InDelta(t, 1, rate, 0.0001)
+
+	seconds, ok := h.secondsUntilFull(7200)
+	assert.True(t, ok)
+	assert.InDelta(t, 7200, seconds, 0.0001)
+
+	// samples out of window are removed
+	h.add(start.Add(61*time.Minute), 1180)
+	assert.Len(t, h.samples, 3)
+
+	// usage is not growing
+	h = &usageHistory{window: time.Hour}
+	for i := 0; i < 5; i++ {
+		h.add(start.Add(time.Duration(i)*time.Minute), float64(1000-i))
+	}
+	_, ok = h.secondsUntilFull(1000)
+	assert.False(t, ok)
+}
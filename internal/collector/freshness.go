@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cachedCollector is implemented by collectors which could reuse stats collected during previous scrapes.
+type cachedCollector interface {
+	// dataAge returns age of the values produced during the last update and true if values have been taken from cache.
+	dataAge() (time.Duration, bool)
+}
+
+// dataAge defines freshness of values produced by collector.
+type dataAge struct {
+	source string
+	age    float64
+}
+
+// dataFreshness accounts freshness of values produced by collectors during the scrape.
+type dataFreshness struct {
+	mu   sync.Mutex
+	ages map[string]dataAge
+	age  typedDesc
+}
+
+// newDataFreshness creates new dataFreshness.
+func newDataFreshness(constLabels labels) *dataFreshness {
+	return &dataFreshness{
+		ages: map[string]dataAge{},
+		age: newBuiltinTypedDesc(
+			descOpts{"pgscv", "collector", "data_age_seconds", "Age of values produced by collector during the scrape, in seconds, by source of values.", 0},
+			prometheus.GaugeValue,
+			[]string{"collector", "source"}, constLabels,
+			filter.New(),
+		),
+	}
+}
+
+// observe accounts freshness of values produced by passed collector. Values of collectors which don't cache stats
+// are always taken from live queries.
+func (f *dataFreshness) observe(name string, c Collector) {
+	if f == nil {
+		return
+	}
+
+	v := dataAge{source: "live"}
+	if cc, ok := c.(cachedCollector); ok {
+		if age, cached := cc.dataAge(); cached {
+			v = dataAge{source: "cache", age: age.Seconds()}
+		}
+	}
+
+	f.mu.Lock()
+	f.ages[name] = v
+	f.mu.Unlock()
+}
+
+// send sends freshness of values produced by collectors into channel and resets accounted values, so only collectors
+// executed during the scrape are reported.
+func (f *dataFreshness) send(ch chan<- prometheus.Metric) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, v := range f.ages {
+		ch <- f.age.newConstMetric(v.age, name, v.source)
+	}
+
+	f.ages = map[string]dataAge{}
+}
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCachedCollector is a collector which always reuses stats collected a minute ago.
+type testCachedCollector struct{}
+
+func (testCachedCollector) Update(_ Config, _ chan<- prometheus.Metric) error { return nil }
+
+func (testCachedCollector) dataAge() (time.Duration, bool) { return time.Minute, true }
+
+func Test_dataFreshness(t *testing.T) {
+	f := newDataFreshness(labels{"service_id": "test"})
+
+	f.observe("postgres/activity", &postgresActivityCollector{})
+	f.observe("postgres/buffercache", &postgresBuffercacheCollector{})
+	f.observe("postgres/storage", testCachedCollector{})
+
+	ch := make(chan prometheus.Metric, 10)
+	f.send(ch)
+	close(ch)
+	assert.Len(t, ch, 3)
+
+	for m := range ch {
+		var metric dto.Metric
+		assert.NoError(t, m.Write(&metric))
+
+		got := map[string]string{}
+		for _, l := range metric.GetLabel() {
+			got[l.GetName()] = l.GetValue()
+		}
+
+		switch got["collector"] {
+		case "postgres/activity", "postgres/buffercache":
+			assert.Equal(t, "live", got["source"])
+			assert.Equal(t, 0.0, metric.GetGauge().GetValue())
+		case "postgres/storage":
+			assert.Equal(t, "cache", got["source"])
+			assert.Equal(t, 60.0, metric.GetGauge().GetValue())
+		default:
+			t.Errorf("unexpected collector %s", got["collector"])
+		}
+	}
+
+	// Accounted values are reset after sending.
+	ch = make(chan prometheus.Metric, 10)
+	f.send(ch)
+	assert.Len(t, ch, 0)
+}
+
+func TestPgscvCollector_Collect_noFreshness(t *testing.T) {
+	// Collector is created without freshness of values, like in tests of other pipeline stages.
+	c := PgscvCollector{
+		Collectors: map[string]Collector{
+			"postgres/activity": newSeriesCollector("activity", 5),
+		},
+		dropped:  newDroppedSeries(labels{"service_id": "test"}),
+		silences: newSilences(labels{"service_id": "test"}),
+	}
+
+	ch := make(chan prometheus.Metric, 20)
+	assert.NotPanics(t, func() { c.Collect(ch) })
+	close(ch)
+	assert.Len(t, ch, 5)
+}
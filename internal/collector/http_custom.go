@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/cherts/pgscv/internal/http"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultHTTPCustomTimeout defines default max duration of URL request.
+const defaultHTTPCustomTimeout = 5 * time.Second
+
+// httpCustomCollector defines metric descriptors and requested URLs.
+type httpCustomCollector struct {
+	success typedDesc
+	targets []httpCustomTarget
+}
+
+// httpCustomTarget defines URL requested for user-defined metrics of the subsystem.
+type httpCustomTarget struct {
+	descs  typedDescSet
+	url    string
+	path   string
+	regex  *regexp.Regexp
+	client *http.Client
+}
+
+// NewHTTPCustomCollector returns a new Collector that expose user-defined metrics extracted from responses of HTTP
+// endpoints, e.g. HAProxy stats or sidecars of cloud providers colocated with Postgres. URLs are requested on each
+// scrape, values are extracted using JSONPath expression or regular expression with named groups.
+func NewHTTPCustomCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	targets := make([]httpCustomTarget, 0, len(settings.Subsystems))
+	for _, name := range slices.Sorted(maps.Keys(settings.Subsystems)) {
+		subsystem := settings.Subsystems[name]
+
+		descs, err := newDescSet("http", name, subsystem, constLabels)
+		if err != nil {
+			return nil, err
+		}
+
+		timeout := subsystem.Timeout
+		if timeout <= 0 {
+			timeout = defaultHTTPCustomTimeout
+		}
+
+		client, err := http.NewClientWithAuth(http.ClientConfig{Timeout: timeout}, subsystem.URL, subsystem.Auth)
+		if err != nil {
+			return nil, err
+		}
+
+		t := httpCustomTarget{descs: descs, url: subsystem.URL, path: subsystem.Path, client: client}
+		if subsystem.Regex != "" {
+			t.regex, err = regexp.Compile(subsystem.Regex)
+			if err != nil {
+				return nil, err
+			}
+		}
+		targets = append(targets, t)
+	}
+
+	return &httpCustomCollector{
+		success: newBuiltinTypedDesc(
+			descOpts{"pgscv", "http", "request_success", "Whether the last request of the URL succeeded (1) or failed (0).", 0},
+			prometheus.GaugeValue,
+			[]string{"subsystem"}, constLabels,
+			settings.Filters,
+		),
+		targets: targets,
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *httpCustomCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	for _, t := range c.targets {
+		err := t.update(ch)
+		if err != nil {
+			log.Errorf("request %s failed: %s; skip", t.url, err)
+			ch <- c.success.newConstMetric(0, t.descs.subsystem)
+			continue
+		}
+		ch <- c.success.newConstMetric(1, t.descs.subsystem)
+	}
+
+	return nil
+}
+
+// update requests URL of the target and produces metrics from response.
+func (t httpCustomTarget) update(ch chan<- prometheus.Metric) error {
+	resp, err := t.client.Get(t.url)
+	if err != nil {
+		return err
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response: %s", resp.Status)
+	}
+
+	if t.regex == nil {
+		return updateDescSetFromJSON(content, t.path, t.descs, ch)
+	}
+
+	colnames, rows := parseRegexRows(t.regex, string(content))
+	for _, row := range rows {
+		for _, d := range t.descs.descs {
+			updateMetrics(row, d, colnames, ch, "")
+		}
+	}
+
+	return nil
+}
+
+// parseRegexRows applies regular expression to the text and returns names of named groups and rows of their values,
+// one row per match. Groups not participating in the match have NULL values.
+func parseRegexRows(re *regexp.Regexp, text string) ([]string, [][]sql.NullString) {
+	var colnames []string
+	var indexes []int
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			colnames = append(colnames, name)
+			indexes = append(indexes, i)
+		}
+	}
+
+	var rows [][]sql.NullString
+	for _, match := range re.FindAllStringSubmatchIndex(text, -1) {
+		row := make([]sql.NullString, len(indexes))
+		for i, idx := range indexes {
+			if match[2*idx] >= 0 {
+				row[i] = sql.NullString{String: text[match[2*idx]:match[2*idx+1]], Valid: true}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return colnames, rows
+}
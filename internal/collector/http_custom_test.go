@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/http"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPCustomCollector_Update(t *testing.T) {
+	ts := http.TestServer(t, http.StatusOK, `{"instances": [{"name": "db1", "connections": 10, "healthy": true}, {"name": "db2", "connections": 5, "healthy": false}]}`)
+	defer ts.Close()
+
+	c, err := NewHTTPCustomCollector(labels{}, model.CollectorSettings{
+		Subsystems: map[string]model.MetricsSubsystem{
+			"sidecar": {
+				URL:  ts.URL,
+				Path: "$.instances[*]",
+				Metrics: model.Metrics{
+					{ShortName: "connections", Usage: "GAUGE", Value: "connections", Labels: []string{"name"}, Description: "description"},
+					{ShortName: "healthy", Usage: "GAUGE", Value: "healthy", Labels: []string{"name"}, Description: "description"},
+				},
+			},
+			"unavailable": {
+				URL:     "http://127.0.0.1:1/stats",
+				Metrics: model.Metrics{{ShortName: "v1", Usage: "GAUGE", Value: "value", Description: "description"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, c.Update(Config{}, ch))
+	close(ch)
+
+	// 4 metrics from sidecar and 2 request_success metrics.
+	assert.Len(t, ch, 6)
+}
+
+func Test_parseRegexRows(t *testing.T) {
+	text := "# pxname,svname,qcur,qmax,scur\nfrontend,FRONTEND,,,12\nbackend,pg1,0,0,3\n"
+	re := regexp.MustCompile(`(?m)^(?P<proxy>[^#,]+),(?P<server>[^,]+),(?P<queue>\d*),\d*,(?P<current>\d+)$`)
+
+	colnames, rows := parseRegexRows(re, text)
+	assert.Equal(t, []string{"proxy", "server", "queue", "current"}, colnames)
+	assert.Equal(t, [][]sql.NullString{
+		{{String: "frontend", Valid: true}, {String: "FRONTEND", Valid: true}, {String: "", Valid: true}, {String: "12", Valid: true}},
+		{{String: "backend", Valid: true}, {String: "pg1", Valid: true}, {String: "0", Valid: true}, {String: "3", Valid: true}},
+	}, rows)
+
+	colnames, rows = parseRegexRows(regexp.MustCompile(`(?P<v>\d+)|(?P<w>x)`), "x")
+	assert.Equal(t, []string{"v", "w"}, colnames)
+	assert.Equal(t, [][]sql.NullString{{{}, {String: "x", Valid: true}}}, rows)
+}
@@ -0,0 +1,201 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLeaderLockID defines default key of advisory lock used for electing the leader.
+const DefaultLeaderLockID int64 = 0x70677363 // 'pgsc'
+
+const (
+	// leaderAliveQuery defines query for checking connection holding the lock is alive.
+	leaderAliveQuery = "SELECT 1"
+	// leaderLockQuery defines query for acquiring the lock.
+	leaderLockQuery = "SELECT pg_try_advisory_lock($1)"
+)
+
+// standbyDisabledCollectors defines heavy collectors which are executed by the leader only. Standby executes lightweight
+// collectors only, hence paired instances don't produce duplicate load on monitored services.
+var standbyDisabledCollectors = []string{
+	"postgres/tables",
+	"postgres/indexes",
+	"postgres/functions",
+	"postgres/schemas",
+	"postgres/statements",
+	"postgres/statements_query",
+	"postgres/statements_plans",
+	"postgres/storage",
+	"postgres/buffercache",
+	"postgres/checksums",
+	"postgres/objects",
+	"postgres/pgvector",
+	"postgres/table_bloat",
+}
+
+// LeaderElector elects the leader among pgSCV instances monitoring the same services. The leader is the instance which
+// holds session-level advisory lock in the Postgres used for election.
+type LeaderElector struct {
+	mu          sync.Mutex
+	connString  string
+	lockID      int64
+	db          *store.DB // connection holding the lock, nil when not connected; used by Run only
+	leader      bool
+	transitions float64
+
+	status           typedDesc
+	transitionsTotal typedDesc
+}
+
+// NewLeaderElector creates new elector which acquires advisory lock with passed ID in Postgres with passed connection string.
+func NewLeaderElector(connString string, lockID int64) *LeaderElector {
+	return &LeaderElector{
+		connString: connString,
+		lockID:     lockID,
+		status: newBuiltinTypedDesc(
+			descOpts{"pgscv", "leader", "status", "Leadership status of the instance: 1 is leader, 0 is standby.", 0},
+			prometheus.GaugeValue,
+			nil, nil,
+			filter.New(),
+		),
+		transitionsTotal: newBuiltinTypedDesc(
+			descOpts{"pgscv", "leader", "transitions_total", "Total number of leadership changes of the instance.", 0},
+			prometheus.CounterValue,
+			nil, nil,
+			filter.New(),
+		),
+	}
+}
+
+// IsLeader returns true if the instance is the leader. Without election, all instances are leaders.
+func (e *LeaderElector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leader
+}
+
+// Run periodically attempts to acquire leadership and checks it is kept, until context is done. Leadership is released
+// on exit. Each attempt is limited by half of the interval, hence the leader which lost connection steps down before
+// standby could take over leadership.
+func (e *LeaderElector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.elect(ctx, interval/2)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// elect attempts to acquire the lock if the instance is standby, or checks the lock is kept if the instance is leader.
+// Connecting and querying are limited by timeout, the instance steps down when they fail.
+func (e *LeaderElector) elect(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if e.db == nil {
+		config, err := pgx.ParseConfig(e.connString)
+		if err != nil {
+			log.Errorf("leader election: parse conninfo failed: %s; standby", err)
+			e.setLeader(false)
+			return
+		}
+		if config.ConnectTimeout == 0 || config.ConnectTimeout > timeout {
+			config.ConnectTimeout = timeout
+		}
+
+		db, err := store.NewWithConfig(config)
+		if err != nil {
+			log.Errorf("leader election: connect failed: %s; standby", err)
+			e.setLeader(false)
+			return
+		}
+		e.db = db
+	}
+
+	// Session-level lock is held until the connection is closed, so the leader just checks connection is alive.
+	// The lock is not reentered, otherwise it should be released as many times as it was acquired.
+	var acquired bool
+	var err error
+	if e.IsLeader() {
+		_, err = e.db.Conn().Exec(ctx, leaderAliveQuery)
+		acquired = err == nil
+	} else {
+		err = e.db.Conn().QueryRow(ctx, leaderLockQuery, e.lockID).Scan(&acquired)
+	}
+
+	if err != nil {
+		log.Errorf("leader election: query failed: %s; standby", err)
+		e.db.Close()
+		e.db = nil
+	}
+
+	e.setLeader(acquired)
+}
+
+// release closes connection holding the lock, hence the lock is released and other instance could become leader.
+func (e *LeaderElector) release() {
+	if e.db != nil {
+		e.db.Close()
+		e.db = nil
+	}
+
+	e.setLeader(false)
+}
+
+// setLeader updates leadership status and accounts its changes.
+func (e *LeaderElector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.leader == leader {
+		return
+	}
+
+	if leader {
+		log.Info("leader election: instance became leader")
+	} else {
+		log.Info("leader election: instance became standby")
+	}
+
+	e.leader = leader
+	e.transitions++
+}
+
+// Describe implements the prometheus.Collector interface.
+func (e *LeaderElector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.status.desc
+	ch <- e.transitionsTotal.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (e *LeaderElector) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var status float64
+	if e.leader {
+		status = 1
+	}
+
+	ch <- e.status.newConstMetric(status)
+	ch <- e.transitionsTotal.newConstMetric(e.transitions)
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderElector_IsLeader(t *testing.T) {
+	// Without election all instances are leaders.
+	var e *LeaderElector
+	assert.True(t, e.IsLeader())
+
+	e = NewLeaderElector(store.TestPostgresConnStr, DefaultLeaderLockID)
+	assert.False(t, e.IsLeader())
+
+	e.setLeader(true)
+	assert.True(t, e.IsLeader())
+	e.setLeader(true)
+	e.setLeader(false)
+	assert.False(t, e.IsLeader())
+
+	assert.Equal(t, float64(0), gaugeValue(t, e, "pgscv_leader_status"))
+	assert.Equal(t, float64(2), gaugeValue(t, e, "pgscv_leader_transitions_total"))
+}
+
+func TestLeaderElector_elect(t *testing.T) {
+	ctx := context.Background()
+	leader := NewLeaderElector(store.TestPostgresConnStr, DefaultLeaderLockID)
+	standby := NewLeaderElector(store.TestPostgresConnStr, DefaultLeaderLockID)
+
+	leader.elect(ctx, time.Second)
+	standby.elect(ctx, time.Second)
+	assert.True(t, leader.IsLeader())
+	assert.False(t, standby.IsLeader())
+
+	// Leadership is kept by the leader.
+	leader.elect(ctx, time.Second)
+	standby.elect(ctx, time.Second)
+	assert.True(t, leader.IsLeader())
+	assert.False(t, standby.IsLeader())
+
+	// Standby takes over leadership when the leader releases the lock.
+	leader.release()
+	standby.elect(ctx, time.Second)
+	assert.False(t, leader.IsLeader())
+	assert.True(t, standby.IsLeader())
+
+	standby.release()
+}
+
+func TestLeaderElector_elect_unavailable(t *testing.T) {
+	e := NewLeaderElector("host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures connect_timeout=1", DefaultLeaderLockID)
+	e.setLeader(true)
+
+	e.elect(context.Background(), time.Second)
+	assert.False(t, e.IsLeader())
+	assert.Nil(t, e.db)
+}
+
+func TestLeaderElector_elect_timeout(t *testing.T) {
+	// Server accepts connections but never responds.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	e := NewLeaderElector(fmt.Sprintf("host=127.0.0.1 port=%d user=pgscv dbname=pgscv_fixtures sslmode=disable", port), DefaultLeaderLockID)
+	e.setLeader(true)
+
+	start := time.Now()
+	e.elect(context.Background(), 100*time.Millisecond)
+	assert.False(t, e.IsLeader())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
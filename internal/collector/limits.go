@@ -0,0 +1,255 @@
+package collector
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// defaultCollectorPriority defines priority of collectors which are not listed in collectorsPriority.
+	defaultCollectorPriority = 2
+
+	// droppedReasonSeriesLimit defines reason of dropping series exceeded max_series_per_collector limit.
+	droppedReasonSeriesLimit = "series_limit"
+	// droppedReasonPayloadLimit defines reason of dropping series exceeded max_payload_bytes limit.
+	droppedReasonPayloadLimit = "payload_limit"
+
+	// seriesValueSizeEstimate defines estimated size of formatted sample value with delimiters.
+	seriesValueSizeEstimate = 24
+)
+
+// collectorsPriority defines order in which collectors' series are kept when payload limit is exceeded. Series of
+// collectors with lower value are sent first, series of high-cardinality collectors are sent last and truncated first.
+var collectorsPriority = map[string]int{
+	"system/pgscv":         0,
+	"postgres/pgscv":       0,
+	"pgbouncer/pgscv":      0,
+	"patroni/pgscv":        0,
+	"postgres/activity":    1,
+	"postgres/databases":   1,
+	"postgres/replication": 1,
+	"postgres/wal":         1,
+	"pgbouncer/pools":      1,
+	"patroni/common":       1,
+	"postgres/statements":  3,
+	"postgres/tables":      3,
+	"postgres/indexes":     3,
+	"postgres/functions":   3,
+	"postgres/custom":      3,
+}
+
+// reDescFqName defines regexp for extracting fully-qualified metric name from descriptor's string representation.
+var reDescFqName = regexp.MustCompile(`^Desc{fqName: "([^"]*)"`)
+
+// droppedSeries accumulates number of dropped series per collector and reason across scrapes.
+type droppedSeries struct {
+	store map[[2]string]float64
+	mu    sync.Mutex
+	desc  typedDesc
+}
+
+// newDroppedSeries creates new droppedSeries.
+func newDroppedSeries(constLabels labels) *droppedSeries {
+	return &droppedSeries{
+		store: map[[2]string]float64{},
+		desc: newBuiltinTypedDesc(
+			descOpts{"pgscv", "", "series_dropped_total", "Total number of series dropped due to exceeded scrape limits, by collector and reason.", 0},
+			prometheus.CounterValue,
+			[]string{"collector", "reason"}, constLabels,
+			filter.New(),
+		),
+	}
+}
+
+// add increments number of series dropped by collector due to reason. Warning is logged only when series are dropped
+// first time, the following drops are reflected by pgscv_series_dropped_total only.
+func (d *droppedSeries) add(collector, reason string, n int) {
+	if n <= 0 {
+		return
+	}
+
+	key := [2]string{collector, reason}
+
+	d.mu.Lock()
+	_, seen := d.store[key]
+	d.store[key] += float64(n)
+	d.mu.Unlock()
+
+	if !seen {
+		log.Warnf("%s: %d series dropped due to %s, the following drops are counted by pgscv_series_dropped_total", collector, n, reason)
+	}
+}
+
+// send sends accumulated numbers of dropped series into channel.
+func (d *droppedSeries) send(ch chan<- prometheus.Metric) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, v := range d.store {
+		ch <- d.desc.newConstMetric(v, k[0], k[1])
+	}
+}
+
+// limitedSeries holds series collected by collectors when scrape limits are enabled.
+type limitedSeries struct {
+	store map[string][]prometheus.Metric
+	mu    sync.Mutex
+}
+
+// collectLimited runs collector and buffers produced series, series exceeded maxSeries limit are dropped.
+func (s *limitedSeries) collectLimited(name string, config Config, c Collector, dropped *droppedSeries) {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	var (
+		series []prometheus.Metric
+		n      int
+	)
+
+	go func() {
+		for m := range ch {
+			if m == nil {
+				continue
+			}
+			if config.MaxSeriesPerCollector > 0 && len(series) >= config.MaxSeriesPerCollector {
+				n++
+				continue
+			}
+			series = append(series, m)
+		}
+		close(done)
+	}()
+
+	collect(name, config, c, ch)
+	close(ch)
+	<-done
+
+	dropped.add(name, droppedReasonSeriesLimit, n)
+
+	s.mu.Lock()
+	if s.store == nil {
+		s.store = map[string][]prometheus.Metric{}
+	}
+	s.store[name] = series
+	s.mu.Unlock()
+}
+
+// send sends buffered series into channel in order of collectors priority. When maxPayloadBytes limit is exceeded,
+// all remaining series are dropped.
+func (s *limitedSeries) send(maxPayloadBytes int, dropped *droppedSeries, ch chan<- prometheus.Metric) {
+	names := make([]string, 0, len(s.store))
+	for name := range s.store {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := collectorPriority(names[i]), collectorPriority(names[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return names[i] < names[j]
+	})
+
+	var (
+		size     int
+		exceeded bool
+	)
+	fqNames := map[*prometheus.Desc]string{}
+
+	for _, name := range names {
+		series := s.store[name]
+
+		for i, m := range series {
+			if !exceeded && maxPayloadBytes > 0 {
+				size += estimateSeriesSize(m, fqNames)
+				exceeded = size > maxPayloadBytes
+			}
+
+			if exceeded {
+				dropped.add(name, droppedReasonPayloadLimit, len(series)-i)
+				break
+			}
+
+			ch <- m
+		}
+	}
+}
+
+// collectorPriority returns priority of collector.
+func collectorPriority(name string) int {
+	if p, ok := collectorsPriority[name]; ok {
+		return p
+	}
+	return defaultCollectorPriority
+}
+
+// estimateSeriesSize returns estimated size of series in text exposition format. Names of metrics are cached in
+// passed map to avoid formatting descriptors for every series.
+func estimateSeriesSize(m prometheus.Metric, fqNames map[*prometheus.Desc]string) int {
+	desc := m.Desc()
+	fqName, ok := fqNames[desc]
+	if !ok {
+		if parts := reDescFqName.FindStringSubmatch(desc.String()); len(parts) == 2 {
+			fqName = parts[1]
+		}
+		fqNames[desc] = fqName
+	}
+
+	size := len(fqName) + seriesValueSizeEstimate
+
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return size
+	}
+
+	for _, l := range pb.GetLabel() {
+		// name="value",
+		size += len(l.GetName()) + len(l.GetValue()) + 4
+	}
+
+	return size
+}
+
+// metricsAllowlist defines metric families allowed to be exposed, all other metrics are dropped. Decisions are cached
+// per descriptor to avoid formatting descriptors for every series.
+type metricsAllowlist struct {
+	names map[string]struct{}
+	cache map[*prometheus.Desc]bool
+}
+
+// newMetricsAllowlist creates allowlist of passed metric families, nil is returned when list is empty and all metrics
+// are allowed.
+func newMetricsAllowlist(names []string) *metricsAllowlist {
+	if len(names) == 0 {
+		return nil
+	}
+
+	a := &metricsAllowlist{names: make(map[string]struct{}, len(names)), cache: map[*prometheus.Desc]bool{}}
+	for _, name := range names {
+		a.names[name] = struct{}{}
+	}
+	return a
+}
+
+// allowed returns true if family of passed metric is in allowlist, or allowlist is not defined.
+func (a *metricsAllowlist) allowed(m prometheus.Metric) bool {
+	if a == nil {
+		return true
+	}
+
+	desc := m.Desc()
+	ok, found := a.cache[desc]
+	if !found {
+		if parts := reDescFqName.FindStringSubmatch(desc.String()); len(parts) == 2 {
+			_, ok = a.names[parts[1]]
+		}
+		a.cache[desc] = ok
+	}
+	return ok
+}
@@ -0,0 +1,107 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// seriesCollector is a fake collector which produces specified number of series.
+type seriesCollector struct {
+	desc typedDesc
+	n    int
+}
+
+func (c seriesCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	for i := 0; i < c.n; i++ {
+		ch <- c.desc.newConstMetric(float64(i), "example")
+	}
+	return nil
+}
+
+func newSeriesCollector(name string, n int) seriesCollector {
+	return seriesCollector{
+		desc: newBuiltinTypedDesc(
+			descOpts{"test", "", name, "Test description.", 0},
+			prometheus.GaugeValue,
+			[]string{"label"}, labels{"service_id": "test"},
+			filter.New(),
+		),
+		n: n,
+	}
+}
+
+func Test_limitedSeries(t *testing.T) {
+	dropped := newDroppedSeries(labels{"service_id": "test"})
+	results := &limitedSeries{}
+
+	config := Config{MaxSeriesPerCollector: 10}
+	results.collectLimited("postgres/tables", config, newSeriesCollector("tables", 15), dropped)
+	results.collectLimited("postgres/activity", config, newSeriesCollector("activity", 5), dropped)
+
+	assert.Len(t, results.store["postgres/tables"], 10)
+	assert.Len(t, results.store["postgres/activity"], 5)
+	assert.Equal(t, float64(5), dropped.store[[2]string{"postgres/tables", droppedReasonSeriesLimit}])
+
+	// Estimate size of single series and allow payload fitting 7 series.
+	size := estimateSeriesSize(results.store["postgres/activity"][0], map[*prometheus.Desc]string{})
+	assert.Greater(t, size, len("test_activity"))
+
+	ch := make(chan prometheus.Metric, 20)
+	results.send(size*7, dropped, ch)
+	close(ch)
+
+	// All series of high-priority collector are sent first.
+	var got []string
+	fqNames := map[*prometheus.Desc]string{}
+	for m := range ch {
+		estimateSeriesSize(m, fqNames)
+		got = append(got, fqNames[m.Desc()])
+	}
+	assert.Equal(t, []string{
+		"test_activity", "test_activity", "test_activity", "test_activity", "test_activity", "test_tables", "test_tables",
+	}, got)
+	assert.Equal(t, float64(8), dropped.store[[2]string{"postgres/tables", droppedReasonPayloadLimit}])
+
+	// Dropped series counters.
+	ch = make(chan prometheus.Metric, 10)
+	dropped.send(ch)
+	close(ch)
+	assert.Len(t, ch, 2)
+
+	// Drops of the following scrapes are accumulated.
+	results.collectLimited("postgres/tables", config, newSeriesCollector("tables", 15), dropped)
+	assert.Equal(t, float64(10), dropped.store[[2]string{"postgres/tables", droppedReasonSeriesLimit}])
+}
+
+func Test_collectorPriority(t *testing.T) {
+	assert.Equal(t, 0, collectorPriority("postgres/pgscv"))
+	assert.Equal(t, 3, collectorPriority("postgres/indexes"))
+	assert.Equal(t, defaultCollectorPriority, collectorPriority("postgres/settings"))
+}
+
+func Test_metricsAllowlist(t *testing.T) {
+	var a *metricsAllowlist
+	c := newSeriesCollector("tables", 1)
+	m := c.desc.newConstMetric(1, "example")
+	assert.True(t, a.allowed(m))
+
+	assert.Nil(t, newMetricsAllowlist(nil))
+
+	a = newMetricsAllowlist([]string{"test_activity"})
+	in := make(chan prometheus.Metric, 10)
+	out := make(chan prometheus.Metric, 10)
+	for _, c := range []seriesCollector{c, newSeriesCollector("activity", 2)} {
+		assert.NoError(t, c.Update(Config{}, in))
+	}
+	close(in)
+	send(in, out, a, nil)
+	close(out)
+
+	assert.Len(t, out, 2)
+	for m := range out {
+		assert.Equal(t, "test_activity", reDescFqName.FindStringSubmatch(m.Desc().String())[1])
+	}
+}
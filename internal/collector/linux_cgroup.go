@@ -0,0 +1,331 @@
+//go:build linux
+
+// Package collector is a pgSCV collectors
+package collector
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// defaultProcRoot defines default mountpoint of procfs.
+	defaultProcRoot = "/proc"
+	// defaultCgroupRoot defines default mountpoint of cgroup filesystems.
+	defaultCgroupRoot = "/sys/fs/cgroup"
+	// cgroupV1Unlimited defines threshold above which cgroup v1 memory limit is considered as not set. Unlimited value
+	// is the max int64 rounded down to the page size.
+	cgroupV1Unlimited = 1 << 62
+)
+
+type cgroupCollector struct {
+	procRoot         string
+	cgroupRoot       string
+	memoryLimit      typedDesc
+	memoryUsage      typedDesc
+	cpuQuota         typedDesc
+	cpuPeriod        typedDesc
+	throttledPeriods typedDesc
+	throttledTime    typedDesc
+	oomKills         typedDesc
+}
+
+// NewCgroupCollector returns a new Collector exposing resource limits and usage of cgroups of pgSCV and local Postgres
+// processes. In containers node-level stats are misleading, because processes are limited by their cgroups.
+// For details see https://docs.kernel.org/admin-guide/cgroup-v2.html
+func NewCgroupCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labelNames = []string{"process", "cgroup"}
+
+	return &cgroupCollector{
+		procRoot:   defaultProcRoot,
+		cgroupRoot: defaultCgroupRoot,
+		memoryLimit: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "memory_limit_bytes", "Memory limit of the cgroup, in bytes.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		memoryUsage: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "memory_usage_bytes", "Memory used by processes of the cgroup, in bytes.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		cpuQuota: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "cpu_quota_seconds", "CPU time available to the cgroup during each period, in seconds.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		cpuPeriod: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "cpu_period_seconds", "Length of the period of CPU quota of the cgroup, in seconds.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		throttledPeriods: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "cpu_throttled_periods_total", "Total number of periods when the cgroup has been throttled.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		throttledTime: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "cpu_throttled_seconds_total", "Total time processes of the cgroup have been throttled, in seconds.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		oomKills: newBuiltinTypedDesc(
+			descOpts{"node", "cgroup", "oom_kills_total", "Total number of processes of the cgroup killed by OOM killer.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects cgroups stats and sends metrics to Prometheus.
+func (c *cgroupCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	pids := map[string][]int{"pgscv": {os.Getpid()}}
+	postmasters, err := findPostmasterPIDs(c.procRoot)
+	if err != nil {
+		log.Warnf("[system/cgroup collector]: find postmaster processes failed: %s; skip", err)
+	}
+	pids["postgres"] = postmasters
+
+	for process, list := range pids {
+		// Many Postgres could run in the same cgroup, don't report it more than once.
+		seen := map[string]bool{}
+
+		for _, pid := range list {
+			cgroups, err := readProcessCgroups(c.procRoot, pid)
+			if err != nil {
+				log.Warnf("[system/cgroup collector]: read cgroup of process %d failed: %s; skip", pid, err)
+				continue
+			}
+
+			path, stats := readCgroupStats(c.cgroupRoot, cgroups)
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			if stats.hasMemoryLimit {
+				ch <- c.memoryLimit.newConstMetric(stats.memoryLimit, process, path)
+			}
+			ch <- c.memoryUsage.newConstMetric(stats.memoryUsage, process, path)
+			if stats.hasCPUQuota {
+				ch <- c.cpuQuota.newConstMetric(stats.cpuQuota, process, path)
+			}
+			if stats.cpuPeriod > 0 {
+				ch <- c.cpuPeriod.newConstMetric(stats.cpuPeriod, process, path)
+			}
+			ch <- c.throttledPeriods.newConstMetric(stats.throttledPeriods, process, path)
+			ch <- c.throttledTime.newConstMetric(stats.throttledTime, process, path)
+			ch <- c.oomKills.newConstMetric(stats.oomKills, process, path)
+		}
+	}
+
+	return nil
+}
+
+// cgroupStats defines resource limits and usage of cgroup.
+type cgroupStats struct {
+	memoryLimit      float64
+	hasMemoryLimit   bool
+	memoryUsage      float64
+	cpuQuota         float64
+	hasCPUQuota      bool
+	cpuPeriod        float64
+	throttledPeriods float64
+	throttledTime    float64
+	oomKills         float64
+}
+
+// findPostmasterPIDs scans procfs and returns PIDs of Postgres postmaster processes. Backends and auxiliary processes
+// change their command line, hence only postmaster has 'postgres' or 'postmaster' executable in the first argument.
+func findPostmasterPIDs(procRoot string) ([]int, error) {
+	dirs, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes might finish during scan, skip them.
+		cmdline, err := os.ReadFile(filepath.Join(procRoot, d.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		name, _, _ := strings.Cut(string(cmdline), "\x00")
+		if base := filepath.Base(name); base == "postgres" || base == "postmaster" {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids, nil
+}
+
+// readProcessCgroups returns cgroups of the process.
+func readProcessCgroups(procRoot string, pid int) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return parseProcCgroup(f)
+}
+
+// parseProcCgroup parses content of /proc/<pid>/cgroup and returns cgroup paths by controller. Path of cgroup v2 is
+// returned with empty controller name.
+func parseProcCgroup(r io.Reader) (map[string]string, error) {
+	cgroups := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Line format is 'hierarchy-ID:controller-list:cgroup-path'.
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		if parts[1] == "" {
+			cgroups[""] = parts[2]
+			continue
+		}
+
+		for _, controller := range strings.Split(parts[1], ",") {
+			cgroups[controller] = parts[2]
+		}
+	}
+
+	return cgroups, scanner.Err()
+}
+
+// readCgroupStats reads stats of cgroups and returns path of cgroup and its stats. Cgroup v1 is used when memory
+// controller is mounted in v1 hierarchy, otherwise unified cgroup v2 hierarchy is used.
+func readCgroupStats(root string, cgroups map[string]string) (string, cgroupStats) {
+	if path, ok := cgroups["memory"]; ok {
+		return path, readCgroupV1Stats(root, cgroups)
+	}
+
+	path := cgroups[""]
+	return path, readCgroupV2Stats(filepath.Join(root, path))
+}
+
+// readCgroupV2Stats reads stats of cgroup v2 located in passed directory. Missing files are skipped, because not all
+// controllers could be enabled for the cgroup.
+func readCgroupV2Stats(dir string) cgroupStats {
+	var stats cgroupStats
+
+	if v, ok := readCgroupValue(filepath.Join(dir, "memory.max")); ok {
+		stats.memoryLimit, stats.hasMemoryLimit = v, true
+	}
+	stats.memoryUsage, _ = readCgroupValue(filepath.Join(dir, "memory.current"))
+
+	// cpu.max has format '$MAX $PERIOD', where $MAX could be 'max' which means no limit.
+	if data, err := os.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 {
+			if period, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				stats.cpuPeriod = period / 1e6
+			}
+			if quota, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				stats.cpuQuota, stats.hasCPUQuota = quota/1e6, true
+			}
+		}
+	}
+
+	cpu := readCgroupKeyValues(filepath.Join(dir, "cpu.stat"))
+	stats.throttledPeriods = cpu["nr_throttled"]
+	stats.throttledTime = cpu["throttled_usec"] / 1e6
+
+	stats.oomKills = readCgroupKeyValues(filepath.Join(dir, "memory.events"))["oom_kill"]
+
+	return stats
+}
+
+// readCgroupV1Stats reads stats of cgroup v1 from hierarchies of memory and cpu controllers.
+func readCgroupV1Stats(root string, cgroups map[string]string) cgroupStats {
+	var stats cgroupStats
+
+	memory := filepath.Join(root, "memory", cgroups["memory"])
+	if v, ok := readCgroupValue(filepath.Join(memory, "memory.limit_in_bytes")); ok && v < cgroupV1Unlimited {
+		stats.memoryLimit, stats.hasMemoryLimit = v, true
+	}
+	stats.memoryUsage, _ = readCgroupValue(filepath.Join(memory, "memory.usage_in_bytes"))
+
+	// oom_kill is available since Linux 4.13.
+	stats.oomKills = readCgroupKeyValues(filepath.Join(memory, "memory.oom_control"))["oom_kill"]
+
+	cpu := filepath.Join(root, "cpu", cgroups["cpu"])
+	if v, ok := readCgroupValue(filepath.Join(cpu, "cpu.cfs_period_us")); ok {
+		stats.cpuPeriod = v / 1e6
+	}
+	if v, ok := readCgroupValue(filepath.Join(cpu, "cpu.cfs_quota_us")); ok && v > 0 {
+		stats.cpuQuota, stats.hasCPUQuota = v/1e6, true
+	}
+
+	values := readCgroupKeyValues(filepath.Join(cpu, "cpu.stat"))
+	stats.throttledPeriods = values["nr_throttled"]
+	stats.throttledTime = values["throttled_time"] / 1e9
+
+	return stats
+}
+
+// readCgroupValue reads file with single numeric value. False is returned when file is not available or value is not
+// a number (e.g. 'max').
+func readCgroupValue(path string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// readCgroupKeyValues reads file with 'key value' lines, e.g. cpu.stat or memory.events.
+func readCgroupKeyValues(path string) map[string]float64 {
+	values := map[string]float64{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return values
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			log.Errorf("invalid input, parse '%s' failed: %s; skip", fields[1], err)
+			continue
+		}
+		values[fields[0]] = v
+	}
+
+	return values
+}
@@ -0,0 +1,100 @@
+//go:build linux
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_cgroup_memory_limit_bytes",
+			"node_cgroup_memory_usage_bytes",
+			"node_cgroup_cpu_quota_seconds",
+			"node_cgroup_cpu_period_seconds",
+			"node_cgroup_cpu_throttled_periods_total",
+			"node_cgroup_cpu_throttled_seconds_total",
+			"node_cgroup_oom_kills_total",
+		},
+		collector: NewCgroupCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_findPostmasterPIDs(t *testing.T) {
+	root := t.TempDir()
+	for pid, cmdline := range map[string]string{
+		"100":  "/usr/lib/postgresql/16/bin/postgres\x00-D\x00/var/lib/postgresql/16/main\x00",
+		"101":  "postgres: 16/main: checkpointer \x00",
+		"200":  "postmaster\x00",
+		"300":  "/usr/sbin/pgbouncer\x00/etc/pgbouncer/pgbouncer.ini\x00",
+		"self": "/usr/bin/pgscv\x00",
+	} {
+		assert.NoError(t, os.Mkdir(filepath.Join(root, pid), 0750))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, pid, "cmdline"), []byte(cmdline), 0600))
+	}
+
+	pids, err := findPostmasterPIDs(root)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{100, 200}, pids)
+
+	_, err = findPostmasterPIDs(filepath.Join(root, "unknown"))
+	assert.Error(t, err)
+}
+
+func Test_parseProcCgroup(t *testing.T) {
+	f, err := os.Open("testdata/proc/cgroup.v1.golden")
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	cgroups, err := parseProcCgroup(f)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"":             "/docker/abc",
+		"pids":         "/docker/abc",
+		"memory":       "/docker/abc",
+		"cpu":          "/docker/abc",
+		"cpuacct":      "/docker/abc",
+		"name=systemd": "/docker/abc",
+	}, cgroups)
+}
+
+func Test_readCgroupStats(t *testing.T) {
+	// cgroup v2
+	f, err := os.Open("testdata/proc/cgroup.v2.golden")
+	assert.NoError(t, err)
+	cgroups, err := parseProcCgroup(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	path, stats := readCgroupStats("testdata/sys/fs/cgroup.v2", cgroups)
+	assert.Equal(t, "/system.slice/postgresql.service", path)
+	assert.Equal(t, cgroupStats{
+		memoryLimit: 1073741824, hasMemoryLimit: true, memoryUsage: 536870912,
+		cpuQuota: 0.2, hasCPUQuota: true, cpuPeriod: 0.1,
+		throttledPeriods: 10, throttledTime: 2.5, oomKills: 1,
+	}, stats)
+
+	// cgroup v1, memory and CPU are not limited.
+	f, err = os.Open("testdata/proc/cgroup.v1.golden")
+	assert.NoError(t, err)
+	cgroups, err = parseProcCgroup(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	path, stats = readCgroupStats("testdata/sys/fs/cgroup.v1", cgroups)
+	assert.Equal(t, "/docker/abc", path)
+	assert.Equal(t, cgroupStats{
+		memoryUsage: 268435456, cpuPeriod: 0.1, throttledPeriods: 5, throttledTime: 1.5, oomKills: 3,
+	}, stats)
+
+	// Unknown cgroup, nothing is available.
+	_, stats = readCgroupStats("testdata/sys/fs/cgroup.v2", map[string]string{"": "/unknown"})
+	assert.Equal(t, cgroupStats{}, stats)
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+// Package collector is a pgSCV collectors
+package collector
+
+import (
+	"syscall"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// timeError defines clock state returned by adjtimex when clock is not synchronized.
+	timeError = 5
+	// timexStatusUnsync defines STA_UNSYNC status flag, it is set when clock is not synchronized.
+	timexStatusUnsync = 0x0040
+	// timexStatusNano defines STA_NANO status flag, it is set when offset is in nanoseconds instead of microseconds.
+	timexStatusNano = 0x2000
+)
+
+type clockCollector struct {
+	synchronized   typedDesc
+	offset         typedDesc
+	maxError       typedDesc
+	estimatedError typedDesc
+}
+
+// NewClockCollector returns a new Collector exposing clock synchronization status and offset reported by kernel.
+// Replication lag and log timestamps analysis break silently when host clock drifts.
+// For details see https://man7.org/linux/man-pages/man2/adjtimex.2.html
+func NewClockCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &clockCollector{
+		synchronized: newBuiltinTypedDesc(
+			descOpts{"node", "clock", "synchronized", "Whether the clock is synchronized by NTP daemon (1) or not (0).", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		offset: newBuiltinTypedDesc(
+			descOpts{"node", "clock", "offset_seconds", "Estimated offset of the clock from the reference time, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		maxError: newBuiltinTypedDesc(
+			descOpts{"node", "clock", "max_error_seconds", "Maximum error of the clock, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		estimatedError: newBuiltinTypedDesc(
+			descOpts{"node", "clock", "estimated_error_seconds", "Estimated error of the clock, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects clock synchronization stats and sends metrics to Prometheus.
+func (c *clockCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	// Zero modes means read-only request, clock is not adjusted.
+	var tx syscall.Timex
+	state, err := syscall.Adjtimex(&tx)
+	if err != nil {
+		return err
+	}
+
+	stats := parseTimex(state, tx)
+
+	ch <- c.synchronized.newConstMetric(stats.synchronized)
+	ch <- c.offset.newConstMetric(stats.offset)
+	ch <- c.maxError.newConstMetric(stats.maxError)
+	ch <- c.estimatedError.newConstMetric(stats.estimatedError)
+
+	return nil
+}
+
+// clockStats defines clock synchronization stats.
+type clockStats struct {
+	synchronized   float64
+	offset         float64 // in seconds
+	maxError       float64 // in seconds
+	estimatedError float64 // in seconds
+}
+
+// parseTimex converts clock state and timex structure returned by adjtimex into clock stats.
+func parseTimex(state int, tx syscall.Timex) clockStats {
+	var stats clockStats
+
+	if state != timeError && tx.Status&timexStatusUnsync == 0 {
+		stats.synchronized = 1
+	}
+
+	divisor := 1e6
+	if tx.Status&timexStatusNano != 0 {
+		divisor = 1e9
+	}
+
+	stats.offset = float64(tx.Offset) / divisor
+	stats.maxError = float64(tx.Maxerror) / 1e6
+	stats.estimatedError = float64(tx.Esterror) / 1e6
+
+	return stats
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package collector
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"node_clock_synchronized",
+			"node_clock_offset_seconds",
+			"node_clock_max_error_seconds",
+			"node_clock_estimated_error_seconds",
+		},
+		collector: NewClockCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parseTimex(t *testing.T) {
+	testcases := []struct {
+		name  string
+		state int
+		tx    syscall.Timex
+		want  clockStats
+	}{
+		{
+			name: "synchronized", state: 0,
+			tx:   syscall.Timex{Status: 0x2001, Offset: -2500000, Maxerror: 150000, Esterror: 500},
+			want: clockStats{synchronized: 1, offset: -0.0025, maxError: 0.15, estimatedError: 0.0005},
+		},
+		{
+			name: "microseconds offset", state: 0,
+			tx:   syscall.Timex{Status: 0x0001, Offset: 1500},
+			want: clockStats{synchronized: 1, offset: 0.0015},
+		},
+		{
+			name: "unsynchronized", state: timeError,
+			tx:   syscall.Timex{Status: timexStatusUnsync, Maxerror: 16000000},
+			want: clockStats{synchronized: 0, maxError: 16},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, parseTimex(tc.state, tc.tx))
+		})
+	}
+}
@@ -1,3 +1,5 @@
+//go:build linux
+
 // Package collector is a pgSCV collectors
 package collector
 
@@ -6,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,13 +22,18 @@ var (
 	errFilesystemTimedOut             = errors.New("filesystem timed out")
 	mountpointStatTimeout             = 3 * time.Second
 	readMountpointStatWithTimeoutFunc = readMountpointStatWithTimeout
+	// filesystemPredictWindow defines period of usage samples used for predicting filesystem exhaustion.
+	filesystemPredictWindow = time.Hour
 )
 
 type filesystemCollector struct {
-	bytes      typedDesc
-	bytesTotal typedDesc
-	files      typedDesc
-	filesTotal typedDesc
+	bytes       typedDesc
+	bytesTotal  typedDesc
+	files       typedDesc
+	filesTotal  typedDesc
+	fullSeconds typedDesc
+	history     map[string]*usageHistory
+	mu          sync.Mutex
 }
 
 // NewFilesystemCollector returns a new Collector exposing filesystem stats.
@@ -69,6 +77,13 @@ func NewFilesystemCollector(constLabels labels, settings model.CollectorSettings
 			[]string{"device", "mountpoint", "fstype"}, constLabels,
 			settings.Filters,
 		),
+		fullSeconds: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "full_remaining_seconds", "Predicted time until filesystem hosting Postgres data or WAL is full, based on recent usage growth, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
+		history: map[string]*usageHistory{},
 	}, nil
 }
 
@@ -92,11 +107,36 @@ func (c *filesystemCollector) Update(_ Config, ch chan<- prometheus.Metric) erro
 		ch <- c.filesTotal.newConstMetric(s.files, device, s.mount.mountpoint, s.mount.fstype)
 		ch <- c.files.newConstMetric(s.filesfree, device, s.mount.mountpoint, s.mount.fstype, "free")
 		ch <- c.files.newConstMetric(s.files-s.filesfree, device, s.mount.mountpoint, s.mount.fstype, "used")
+
+		// exhaustion prediction, only for filesystems hosting Postgres data or WAL
+		if seconds, ok := c.predict(s, time.Now()); ok {
+			ch <- c.fullSeconds.newConstMetric(seconds, device, s.mount.mountpoint, s.mount.fstype)
+		}
 	}
 
 	return nil
 }
 
+// predict tracks usage of filesystem and returns predicted number of seconds until it is full.
+func (c *filesystemCollector) predict(s filesystemStat, ts time.Time) (float64, bool) {
+	if !storageMountpoints.contains(s.mount.mountpoint) {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.history[s.mount.mountpoint]
+	if !ok {
+		h = &usageHistory{window: filesystemPredictWindow}
+		c.history[s.mount.mountpoint] = h
+	}
+
+	h.add(ts, s.size-s.free)
+
+	return h.secondsUntilFull(s.avail)
+}
+
 // filesystemStat describes various stats related to filesystem usage.
 type filesystemStat struct {
 	mount     mount
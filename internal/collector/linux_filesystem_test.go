@@ -1,3 +1,5 @@
+//go:build linux
+
 package collector
 
 import (
@@ -108,3 +110,30 @@ func Test_readMountpointStat_timeout(t *testing.T) {
 	// stat should carry the error as well
 	assert.Equal(t, errFilesystemTimedOut, stat.err)
 }
+
+func Test_filesystemCollector_predict(t *testing.T) {
+	c, err := NewFilesystemCollector(labels{}, model.CollectorSettings{Filters: filter.New()})
+	assert.NoError(t, err)
+	fc := c.(*filesystemCollector)
+
+	start := time.Now()
+	stat := filesystemStat{mount: mount{mountpoint: "/predict"}, size: 10000, free: 9000, avail: 7200}
+
+	// mountpoint doesn't host Postgres directories
+	for i := 0; i < 3; i++ {
+		_, ok := fc.predict(stat, start.Add(time.Duration(i)*time.Minute))
+		assert.False(t, ok)
+	}
+
+	storageMountpoints.add("test", "/predict")
+	defer storageMountpoints.remove("test")
+
+	var seconds float64
+	var ok bool
+	for i := 0; i < 3; i++ {
+		stat.free -= 60
+		seconds, ok = fc.predict(stat, start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.True(t, ok)
+	assert.InDelta(t, 7200, seconds, 0.0001)
+}
@@ -1,3 +1,5 @@
+//go:build linux
+
 // Package collector is a pgSCV collectors
 package collector
 
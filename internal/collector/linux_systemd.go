@@ -0,0 +1,198 @@
+//go:build linux
+
+// Package collector is a pgSCV collectors
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// systemctlTimeout defines max duration of systemctl execution.
+	systemctlTimeout = 5 * time.Second
+)
+
+// defaultSystemdUnits defines patterns of units monitored when no units are configured in collector settings.
+var defaultSystemdUnits = []string{"postgresql*.service", "pgbouncer*.service", "patroni*.service"}
+
+// systemdUnitStates defines active states of systemd units.
+var systemdUnitStates = []string{"active", "activating", "deactivating", "inactive", "failed"}
+
+type systemdCollector struct {
+	units    []string
+	state    typedDesc
+	restarts typedDesc
+}
+
+// NewSystemdCollector returns a new Collector exposing state of systemd units of Postgres-related services. Units are
+// configured with 'units' collector setting, by default Postgres, Pgbouncer and Patroni units are monitored.
+func NewSystemdCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	units := settings.Units
+	if len(units) == 0 {
+		units = defaultSystemdUnits
+	}
+
+	return &systemdCollector{
+		units: units,
+		state: newBuiltinTypedDesc(
+			descOpts{"node", "systemd", "unit_state", "Current state of the systemd unit, 1 for the current state and 0 for others.", 0},
+			prometheus.GaugeValue,
+			[]string{"unit", "state"}, constLabels,
+			settings.Filters,
+		),
+		restarts: newBuiltinTypedDesc(
+			descOpts{"node", "systemd", "unit_restarts_total", "Total number of automatic restarts of the systemd service unit.", 0},
+			prometheus.CounterValue,
+			[]string{"unit"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects state of systemd units and sends metrics to Prometheus.
+func (c *systemdCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	// Units are matched by patterns in each update, because units could be added or removed (e.g. new Postgres cluster).
+	out, err := runSystemctl(append([]string{"list-units", "--all", "--plain", "--no-legend", "--no-pager"}, c.units...)...)
+	if err != nil {
+		return err
+	}
+
+	names := parseSystemctlListUnits(out)
+	if len(names) == 0 {
+		log.Debugln("[system/systemd collector]: no units matched, skip")
+		return nil
+	}
+
+	out, err = runSystemctl(append([]string{"show", "--property=Id,ActiveState,NRestarts", "--no-pager"}, names...)...)
+	if err != nil {
+		return err
+	}
+
+	for _, unit := range parseSystemctlShow(out) {
+		for _, state := range systemdUnitStates {
+			var v float64
+			if unit.activeState == state {
+				v = 1
+			}
+			ch <- c.state.newConstMetric(v, unit.name, state)
+		}
+
+		// NRestarts is available only for service units.
+		if unit.hasRestarts {
+			ch <- c.restarts.newConstMetric(unit.restarts, unit.name)
+		}
+	}
+
+	return nil
+}
+
+// systemdUnit defines state of systemd unit.
+type systemdUnit struct {
+	name        string
+	activeState string
+	restarts    float64
+	hasRestarts bool
+}
+
+// runSystemctl executes systemctl with passed arguments and returns its output.
+func runSystemctl(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), systemctlTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "systemctl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("systemctl timeout %s exceeded", systemctlTimeout)
+		}
+		return nil, fmt.Errorf("systemctl failed: %s: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// parseSystemctlListUnits parses output of 'systemctl list-units --plain --no-legend' and returns names of units.
+func parseSystemctlListUnits(out []byte) []string {
+	var names []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Failed units could be prefixed with status mark.
+		name := fields[0]
+		if name == "●" || name == "*" {
+			if len(fields) < 2 {
+				continue
+			}
+			name = fields[1]
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// parseSystemctlShow parses output of 'systemctl show' with properties of units separated by empty lines.
+func parseSystemctlShow(out []byte) []systemdUnit {
+	var (
+		units []systemdUnit
+		unit  systemdUnit
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if unit.name != "" {
+				units = append(units, unit)
+			}
+			unit = systemdUnit{}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "Id":
+			unit.name = value
+		case "ActiveState":
+			unit.activeState = value
+		case "NRestarts":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", value, err)
+				continue
+			}
+			unit.restarts, unit.hasRestarts = v, true
+		}
+	}
+
+	if unit.name != "" {
+		units = append(units, unit)
+	}
+
+	return units
+}
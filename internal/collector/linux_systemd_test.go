@@ -0,0 +1,40 @@
+//go:build linux
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseSystemctlListUnits(t *testing.T) {
+	out := []byte(`postgresql@16-main.service loaded active   running PostgreSQL Cluster 16-main
+● patroni.service           loaded failed   failed  Runners to orchestrate a high-availability PostgreSQL
+pgbouncer.service          loaded inactive dead    connection pooler for PostgreSQL
+
+`)
+
+	assert.Equal(t, []string{"postgresql@16-main.service", "patroni.service", "pgbouncer.service"}, parseSystemctlListUnits(out))
+	assert.Nil(t, parseSystemctlListUnits(nil))
+}
+
+func Test_parseSystemctlShow(t *testing.T) {
+	out := []byte(`Id=postgresql@16-main.service
+ActiveState=active
+NRestarts=2
+
+Id=patroni.service
+ActiveState=failed
+NRestarts=invalid
+
+Id=postgresql.target
+ActiveState=inactive
+`)
+
+	assert.Equal(t, []systemdUnit{
+		{name: "postgresql@16-main.service", activeState: "active", restarts: 2, hasRestarts: true},
+		{name: "patroni.service", activeState: "failed"},
+		{name: "postgresql.target", activeState: "inactive"},
+	}, parseSystemctlShow(out))
+}
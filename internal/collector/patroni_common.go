@@ -5,16 +5,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cherts/pgscv/internal/http"
+	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// patroniLeaderConfigTTL defines how long DCS configuration seen by the leader is cached, hence the leader is not
+// requested on every scrape of every node.
+const patroniLeaderConfigTTL = time.Minute
+
 type patroniCommonCollector struct {
 	client               *http.Client
+	clientMu             sync.Mutex
+	leader               patroniLeaderConfig
+	leaderMu             sync.Mutex
 	up                   typedDesc
 	name                 typedDesc
 	version              typedDesc
@@ -43,6 +53,8 @@ type patroniCommonCollector struct {
 	retryTimeout         typedDesc
 	ttl                  typedDesc
 	syncStandby          typedDesc
+	tag                  typedDesc
+	configDrift          typedDesc
 }
 
 // NewPatroniCommonCollector returns a new Collector exposing Patroni common info.
@@ -51,7 +63,6 @@ func NewPatroniCommonCollector(constLabels labels, settings model.CollectorSetti
 	varLabels := []string{"scope"}
 
 	return &patroniCommonCollector{
-		client: http.NewClient(http.ClientConfig{Timeout: time.Second}),
 		up: newBuiltinTypedDesc(
 			descOpts{"patroni", "", "up", "State of Patroni service: 1 is up, 0 otherwise.", 0},
 			prometheus.GaugeValue,
@@ -220,17 +231,46 @@ func NewPatroniCommonCollector(constLabels labels, settings model.CollectorSetti
 			varLabels, constLabels,
 			settings.Filters,
 		),
+		tag: newBuiltinTypedDesc(
+			descOpts{"patroni", "node", "tag_info", "Labeled info about tags of the node, e.g. nofailover, clonefrom, replicatefrom.", 0},
+			prometheus.GaugeValue,
+			[]string{"scope", "tag", "value"}, constLabels,
+			settings.Filters,
+		),
+		configDrift: newBuiltinTypedDesc(
+			descOpts{"patroni", "config", "drift", "Value is 1 if DCS configuration cached by the node differs from the one cached by the leader, e.g. the node misses DCS updates, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			varLabels, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
+// httpClient returns HTTP client for connecting to Patroni API, the client is created on first use.
+func (c *patroniCommonCollector) httpClient(config Config) (*http.Client, error) {
+	c.clientMu.Lock()
+	defer c.clientMu.Unlock()
+
+	if c.client == nil {
+		client, err := http.NewClientWithAuth(http.ClientConfig{Timeout: time.Second}, config.BaseURL, config.BaseURLAuth)
+		if err != nil {
+			return nil, err
+		}
+		c.client = client
+	}
+
+	return c.client, nil
+}
+
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *patroniCommonCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	if strings.HasPrefix(config.BaseURL, "https://") {
-		c.client.EnableTLSInsecure()
+	client, err := c.httpClient(config)
+	if err != nil {
+		return err
 	}
 
 	// Check liveness.
-	err := requestAPILiveness(c.client, config.BaseURL)
+	err = requestAPILiveness(client, config.BaseURL)
 	if err != nil {
 		ch <- c.up.newConstMetric(0)
 		return err
@@ -239,7 +279,7 @@ func (c *patroniCommonCollector) Update(config Config, ch chan<- prometheus.Metr
 	ch <- c.up.newConstMetric(1)
 
 	// Request general info.
-	respInfo, err := requestAPIPatroni(c.client, config.BaseURL)
+	respInfo, err := requestAPIPatroni(client, config.BaseURL)
 	if err != nil {
 		return err
 	}
@@ -249,6 +289,9 @@ func (c *patroniCommonCollector) Update(config Config, ch chan<- prometheus.Metr
 		return err
 	}
 
+	// Scope is used as cluster identity of Postgres running on the same host.
+	patroniScopes.set(config.BaseURL, info.scope, time.Now())
+
 	ch <- c.name.newConstMetric(0, info.scope, info.name)
 	ch <- c.version.newConstMetric(info.version, info.scope, info.versionStr)
 	ch <- c.pgup.newConstMetric(info.running, info.scope)
@@ -274,8 +317,12 @@ func (c *patroniCommonCollector) Update(config Config, ch chan<- prometheus.Metr
 	ch <- c.inArchiveRecovery.newConstMetric(info.inArchiveRecovery, info.scope)
 	ch <- c.syncStandby.newConstMetric(info.syncStandby, info.scope)
 
+	for tag, value := range info.tags {
+		ch <- c.tag.newConstMetric(1, info.scope, tag, value)
+	}
+
 	// Request and parse config.
-	respConfig, err := requestAPIPatroniConfig(c.client, config.BaseURL)
+	respConfig, err := requestAPIPatroniConfig(client, config.BaseURL)
 	if err != nil {
 		return err
 	}
@@ -289,8 +336,16 @@ func (c *patroniCommonCollector) Update(config Config, ch chan<- prometheus.Metr
 		ch <- c.ttl.newConstMetric(patroniConfig.ttl, info.scope)
 	}
 
+	// Compare configuration with the one seen by the leader, the leader could be not available during failover.
+	leader, err := c.leaderConfig(client, config.BaseURL, info.name, time.Now())
+	if err != nil {
+		log.Debugf("[patroni common collector]: check configuration drift failed: %s; skip", err)
+	} else {
+		ch <- c.configDrift.newConstMetric(patroniConfigDrift(info.name, respConfig, leader), info.scope)
+	}
+
 	// Request and parse history.
-	respHist, err := requestAPIHistory(c.client, config.BaseURL)
+	respHist, err := requestAPIHistory(client, config.BaseURL)
 	if err != nil {
 		return err
 	}
@@ -344,6 +399,7 @@ type apiPatroniResponse struct {
 	PendingRestart   bool            `json:"pending_restart"`
 	Pause            bool            `json:"pause"`
 	SyncStandby      bool            `json:"sync_standby"`
+	Tags             map[string]any  `json:"tags"`
 }
 
 // patroniInfo implements metrics values extracted from the response of '/patroni' endpoint.
@@ -371,6 +427,7 @@ type patroniInfo struct {
 	pause             float64
 	inArchiveRecovery float64
 	syncStandby       float64
+	tags              map[string]string
 }
 
 // apiPatroniConfigResponse implements API response returned by '/config' endpoint.
@@ -380,6 +437,8 @@ type apiPatroniConfigResponse struct {
 	MaxLagOnFailover int  `json:"maximum_lag_on_failover"`
 	RetryTimeout     int  `json:"retry_timeout"`
 	TTL              int  `json:"ttl"`
+	// raw defines complete configuration used for detecting configuration drift.
+	raw map[string]any
 }
 
 // patroniConfigInfo implements metrics values extracted from the response of '/config' endpoint.
@@ -416,6 +475,11 @@ func requestAPIPatroniConfig(c *http.Client, baseurl string) (*apiPatroniConfigR
 		return nil, err
 	}
 
+	err = json.Unmarshal(content, &r.raw)
+	if err != nil {
+		return nil, err
+	}
+
 	return r, nil
 }
 
@@ -542,6 +606,14 @@ func parsePatroniResponse(resp *apiPatroniResponse) (*patroniInfo, error) {
 		syncStandby = 1
 	}
 
+	var tags map[string]string
+	if len(resp.Tags) > 0 {
+		tags = make(map[string]string, len(resp.Tags))
+		for k, v := range resp.Tags {
+			tags[k] = fmt.Sprint(v)
+		}
+	}
+
 	return &patroniInfo{
 		name:              resp.Patroni.Name,
 		scope:             resp.Patroni.Scope,
@@ -566,9 +638,116 @@ func parsePatroniResponse(resp *apiPatroniResponse) (*patroniInfo, error) {
 		pause:             pause,
 		inArchiveRecovery: inArchiveRecovery,
 		syncStandby:       syncStandby,
+		tags:              tags,
 	}, nil
 }
 
+// apiClusterMember implements member object of API response returned by '/cluster' endpoint.
+type apiClusterMember struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	APIURL string `json:"api_url"`
+}
+
+// apiClusterResponse implements API response returned by '/cluster' endpoint.
+type apiClusterResponse struct {
+	Members []apiClusterMember `json:"members"`
+}
+
+// requestAPICluster requests to /cluster endpoint of API and returns parsed response.
+func requestAPICluster(c *http.Client, baseurl string) (*apiClusterResponse, error) {
+	resp, err := c.Get(baseurl + "/cluster")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response: %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = resp.Body.Close()
+
+	r := &apiClusterResponse{}
+
+	err = json.Unmarshal(content, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// patroniLeaderConfig defines DCS configuration seen by the leader of the cluster.
+type patroniLeaderConfig struct {
+	name    string         // name of the leader
+	raw     map[string]any // configuration returned by '/config' endpoint of the leader, nil if the node is the leader
+	updated time.Time      // time when configuration has been requested
+}
+
+// leaderConfig returns DCS configuration seen by the leader, configuration is requested not more often than
+// patroniLeaderConfigTTL.
+func (c *patroniCommonCollector) leaderConfig(client *http.Client, baseurl, name string, now time.Time) (patroniLeaderConfig, error) {
+	c.leaderMu.Lock()
+	defer c.leaderMu.Unlock()
+
+	if !c.leader.updated.IsZero() && now.Sub(c.leader.updated) < patroniLeaderConfigTTL {
+		return c.leader, nil
+	}
+
+	leader, err := requestPatroniLeaderConfig(client, baseurl, name)
+	if err != nil {
+		return patroniLeaderConfig{}, err
+	}
+
+	leader.updated = now
+	c.leader = leader
+
+	return leader, nil
+}
+
+// requestPatroniLeaderConfig finds the leader using '/cluster' endpoint and requests configuration using API URL of
+// the leader published in the cluster. Configuration is not requested if the node with passed name is the leader.
+func requestPatroniLeaderConfig(c *http.Client, baseurl, name string) (patroniLeaderConfig, error) {
+	cluster, err := requestAPICluster(c, baseurl)
+	if err != nil {
+		return patroniLeaderConfig{}, err
+	}
+
+	for _, m := range cluster.Members {
+		if m.Role != "leader" && m.Role != "standby_leader" {
+			continue
+		}
+
+		if m.Name == name {
+			return patroniLeaderConfig{name: m.Name}, nil
+		}
+
+		resp, err := requestAPIPatroniConfig(c, strings.TrimSuffix(m.APIURL, "/patroni"))
+		if err != nil {
+			return patroniLeaderConfig{}, err
+		}
+
+		return patroniLeaderConfig{name: m.Name, raw: resp.raw}, nil
+	}
+
+	return patroniLeaderConfig{}, fmt.Errorf("leader not found")
+}
+
+// patroniConfigDrift compares DCS configuration seen by the node with the one seen by the leader and returns 1 if
+// configurations differ. Both nodes return configuration from their caches of DCS, hence drift means the node doesn't
+// receive updates of DCS, e.g. due to connectivity issues. Configuration of the leader is never drifted.
+func patroniConfigDrift(name string, local *apiPatroniConfigResponse, leader patroniLeaderConfig) float64 {
+	if leader.name == name || reflect.DeepEqual(local.raw, leader.raw) {
+		return 0
+	}
+	return 1
+}
+
 // patroniHistoryUnit defines single item of Patroni history in the API response.
 // Basically this is array like [ int, int, string, string ].
 type patroniHistoryUnit []any
@@ -3,6 +3,7 @@ package collector
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/stretchr/testify/assert"
@@ -199,3 +200,73 @@ func Test_parseHistoryResponse(t *testing.T) {
 		}
 	}
 }
+
+func Test_parsePatroniResponse_tags(t *testing.T) {
+	got, err := parsePatroniResponse(&apiPatroniResponse{
+		Patroni: patroni{Version: "3.3.0", Scope: "demo", Name: "patroni2"},
+		Tags:    map[string]any{"nofailover": true, "clonefrom": false, "replicatefrom": "patroni1", "failover_priority": float64(0)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"nofailover": "true", "clonefrom": "false", "replicatefrom": "patroni1", "failover_priority": "0"}, got.tags)
+}
+
+func Test_patroniConfigDrift(t *testing.T) {
+	leader := http.TestServer(t, http.StatusOK, `{"loop_wait": 10, "ttl": 30, "postgresql": {"parameters": {"max_connections": 100}}}`)
+	defer leader.Close()
+
+	cluster := http.TestServer(t, http.StatusOK, fmt.Sprintf(
+		`{"members": [{"name": "patroni1", "role": "leader", "api_url": "%s/patroni"}, {"name": "patroni2", "role": "replica", "api_url": "http://127.0.0.1:30080/patroni"}]}`,
+		leader.URL,
+	))
+	defer cluster.Close()
+
+	c := http.NewClient(http.ClientConfig{})
+
+	local, err := requestAPIPatroniConfig(c, leader.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, local.LoopWait)
+
+	// Configuration of the leader is not requested by the leader itself.
+	got, err := requestPatroniLeaderConfig(c, cluster.URL, "patroni1")
+	assert.NoError(t, err)
+	assert.Equal(t, patroniLeaderConfig{name: "patroni1"}, got)
+	assert.Equal(t, float64(0), patroniConfigDrift("patroni1", &apiPatroniConfigResponse{}, got))
+
+	got, err = requestPatroniLeaderConfig(c, cluster.URL, "patroni2")
+	assert.NoError(t, err)
+	assert.Equal(t, "patroni1", got.name)
+	assert.Equal(t, float64(0), patroniConfigDrift("patroni2", local, got))
+
+	local.raw["loop_wait"] = float64(5)
+	assert.Equal(t, float64(1), patroniConfigDrift("patroni2", local, got))
+
+	// Test errors
+	noLeader := http.TestServer(t, http.StatusOK, `{"members": [{"name": "patroni2", "role": "replica"}]}`)
+	defer noLeader.Close()
+
+	_, err = requestPatroniLeaderConfig(c, noLeader.URL, "patroni2")
+	assert.Error(t, err)
+
+	_, err = requestPatroniLeaderConfig(c, "http://127.0.0.1:30080/invalid", "patroni2")
+	assert.Error(t, err)
+}
+
+func Test_patroniCommonCollector_leaderConfig(t *testing.T) {
+	cluster := http.TestServer(t, http.StatusOK, `{"members": [{"name": "patroni1", "role": "leader"}]}`)
+	c := &patroniCommonCollector{}
+	client := http.NewClient(http.ClientConfig{})
+
+	now := time.Now()
+	got, err := c.leaderConfig(client, cluster.URL, "patroni1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "patroni1", got.name)
+
+	// Cached configuration is used until TTL expired.
+	cluster.Close()
+	got, err = c.leaderConfig(client, cluster.URL, "patroni1", now.Add(patroniLeaderConfigTTL/2))
+	assert.NoError(t, err)
+	assert.Equal(t, "patroni1", got.name)
+
+	_, err = c.leaderConfig(client, cluster.URL, "patroni1", now.Add(patroniLeaderConfigTTL))
+	assert.Error(t, err)
+}
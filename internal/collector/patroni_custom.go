@@ -0,0 +1,261 @@
+package collector
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/http"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type patroniCustomCollector struct {
+	client   *http.Client
+	clientMu sync.Mutex
+	custom   []typedDescSet
+}
+
+// NewPatroniCustomCollector returns a new Collector that expose user-defined patroni metrics. Metrics are requested
+// from REST API endpoints, objects of responses selected using JSONPath expression are used as rows of label/values.
+// For details see https://patroni.readthedocs.io/en/latest/rest_api.html
+func NewPatroniCustomCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &patroniCustomCollector{
+		custom: newDeskSetsFromSubsystems("patroni", settings.Subsystems, constLabels),
+	}, nil
+}
+
+// httpClient returns HTTP client for connecting to Patroni API, the client is created on first use.
+func (c *patroniCustomCollector) httpClient(config Config) (*http.Client, error) {
+	c.clientMu.Lock()
+	defer c.clientMu.Unlock()
+
+	if c.client == nil {
+		client, err := http.NewClientWithAuth(http.ClientConfig{Timeout: time.Second}, config.BaseURL, config.BaseURLAuth)
+		if err != nil {
+			return nil, err
+		}
+		c.client = client
+	}
+
+	return c.client, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *patroniCustomCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if len(c.custom) == 0 {
+		return nil
+	}
+
+	client, err := c.httpClient(config)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range c.custom {
+		err := updatePatroniDescSet(client, config.BaseURL, s, ch)
+		if err != nil {
+			log.Errorf("collect %s failed: %s; skip", s.subsystem, err)
+		}
+	}
+
+	return nil
+}
+
+// updatePatroniDescSet requests REST API endpoint of descs set and produces metrics from objects of response
+// selected by JSONPath expression.
+func updatePatroniDescSet(c *http.Client, baseurl string, descs typedDescSet, ch chan<- prometheus.Metric) error {
+	resp, err := c.Get(baseurl + descs.endpoint)
+	if err != nil {
+		return err
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response: %s", resp.Status)
+	}
+
+	return updateDescSetFromJSON(content, descs.path, descs, ch)
+}
+
+// updateDescSetFromJSON produces metrics from objects of JSON document selected by JSONPath expression.
+func updateDescSetFromJSON(content []byte, path string, descs typedDescSet, ch chan<- prometheus.Metric) error {
+	var doc any
+	err := json.Unmarshal(content, &doc)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := evalJSONPath(doc, path)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		colnames, row := flattenJSONRow(node)
+		for _, d := range descs.descs {
+			updateMetrics(row, d, colnames, ch, "")
+		}
+	}
+
+	return nil
+}
+
+// ValidateJSONPath checks JSONPath expression is supported by patroni/custom and http/custom collectors.
+func ValidateJSONPath(path string) error {
+	_, err := parseJSONPath(path)
+	return err
+}
+
+// jsonPathStep defines single step of JSONPath expression: object key, array index or wildcard.
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses subset of JSONPath syntax: root '$', object keys '.key' and array elements '[N]' or '[*]'.
+// Empty path is equal to '$'.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if path == "" || path == "$" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid path '%s': must start with '$'", path)
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid path '%s': empty key", path)
+			}
+			if key == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else {
+				steps = append(steps, jsonPathStep{key: key})
+			}
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': unclosed bracket", path)
+			}
+			sel := rest[1:end]
+			if sel == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else {
+				idx, err := strconv.Atoi(sel)
+				if err != nil || idx < 0 {
+					return nil, fmt.Errorf("invalid path '%s': invalid index '%s'", path, sel)
+				}
+				steps = append(steps, jsonPathStep{index: idx, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path '%s': unexpected '%c'", path, rest[0])
+		}
+	}
+
+	return steps, nil
+}
+
+// evalJSONPath returns nodes of the decoded JSON document selected by JSONPath expression.
+func evalJSONPath(doc any, path string) ([]any, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []any{doc}
+	for _, step := range steps {
+		var next []any
+		for _, node := range nodes {
+			switch v := node.(type) {
+			case map[string]any:
+				if step.wildcard {
+					for _, k := range slices.Sorted(maps.Keys(v)) {
+						next = append(next, v[k])
+					}
+				} else if child, ok := v[step.key]; ok && !step.isIndex {
+					next = append(next, child)
+				}
+			case []any:
+				if step.wildcard {
+					next = append(next, v...)
+				} else if step.isIndex && step.index < len(v) {
+					next = append(next, v[step.index])
+				}
+			}
+		}
+		nodes = next
+	}
+
+	return nodes, nil
+}
+
+// flattenJSONRow converts JSON node into column names and row of values. Nested objects are flattened using
+// underscore, booleans are converted to 1/0, arrays are skipped. Scalar node is returned as 'value' column.
+func flattenJSONRow(node any) ([]string, []sql.NullString) {
+	values := map[string]sql.NullString{}
+	flattenJSONValue(node, "", values)
+
+	colnames := slices.Sorted(maps.Keys(values))
+	row := make([]sql.NullString, len(colnames))
+	for i, name := range colnames {
+		row[i] = values[name]
+	}
+
+	return colnames, row
+}
+
+// flattenJSONValue puts values of JSON node into values map keyed by flattened names.
+func flattenJSONValue(node any, prefix string, values map[string]sql.NullString) {
+	name := prefix
+	if name == "" {
+		name = "value"
+	}
+
+	switch v := node.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if prefix != "" {
+				k = prefix + "_" + k
+			}
+			flattenJSONValue(child, k, values)
+		}
+	case string:
+		values[name] = sql.NullString{String: v, Valid: true}
+	case float64:
+		values[name] = sql.NullString{String: strconv.FormatFloat(v, 'f', -1, 64), Valid: true}
+	case bool:
+		if v {
+			values[name] = sql.NullString{String: "1", Valid: true}
+		} else {
+			values[name] = sql.NullString{String: "0", Valid: true}
+		}
+	case nil:
+		values[name] = sql.NullString{}
+	}
+}
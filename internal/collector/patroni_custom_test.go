@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/http"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+const patroniClusterResponse = `{"members": [{"name": "patroni1", "role": "leader", "state": "running", "timeline": 5, "tags": {"nofailover": false}}, {"name": "patroni2", "role": "replica", "state": "streaming", "timeline": 5, "lag": 0, "tags": {"nofailover": true}}], "scope": "demo"}`
+
+func Test_evalJSONPath(t *testing.T) {
+	var doc any
+	assert.NoError(t, json.Unmarshal([]byte(patroniClusterResponse), &doc))
+
+	testcases := []struct {
+		path string
+		want []any
+	}{
+		{path: "$.scope", want: []any{"demo"}},
+		{path: "$.members[1].name", want: []any{"patroni2"}},
+		{path: "$.members[*].role", want: []any{"leader", "replica"}},
+		{path: "$.members[*].tags.nofailover", want: []any{false, true}},
+		{path: "$.members[5]", want: nil},
+		{path: "$.unknown.key", want: nil},
+	}
+
+	for _, tc := range testcases {
+		got, err := evalJSONPath(doc, tc.path)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got, tc.path)
+	}
+
+	got, err := evalJSONPath(doc, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{doc}, got)
+
+	// Test errors
+	for _, path := range []string{"members", "$.", "$.members[", "$.members[x]", "$.members[-1]", "$members"} {
+		_, err = evalJSONPath(doc, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func Test_flattenJSONRow(t *testing.T) {
+	var doc any
+	assert.NoError(t, json.Unmarshal([]byte(`{"name": "patroni1", "timeline": 5, "lag": null, "tags": {"nofailover": true}, "list": [1]}`), &doc))
+
+	colnames, row := flattenJSONRow(doc)
+	assert.Equal(t, []string{"lag", "name", "tags_nofailover", "timeline"}, colnames)
+	assert.Equal(t, []sql.NullString{
+		{}, {String: "patroni1", Valid: true}, {String: "1", Valid: true}, {String: "5", Valid: true},
+	}, row)
+
+	colnames, row = flattenJSONRow(12.5)
+	assert.Equal(t, []string{"value"}, colnames)
+	assert.Equal(t, []sql.NullString{{String: "12.5", Valid: true}}, row)
+}
+
+func Test_updatePatroniDescSet(t *testing.T) {
+	ts := http.TestServer(t, http.StatusOK, patroniClusterResponse)
+	defer ts.Close()
+
+	descs, err := newDescSet("patroni", "member", model.MetricsSubsystem{
+		Endpoint: "/cluster",
+		Path:     "$.members[*]",
+		Metrics: model.Metrics{
+			{ShortName: "timeline", Usage: "GAUGE", Value: "timeline", Labels: []string{"name", "role"}, Description: "description"},
+			{ShortName: "nofailover", Usage: "GAUGE", Value: "tags_nofailover", Labels: []string{"name"}, Description: "description"},
+		},
+	}, labels{})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric, 10)
+	err = updatePatroniDescSet(http.NewClient(http.ClientConfig{}), ts.URL, descs, ch)
+	assert.NoError(t, err)
+	close(ch)
+	assert.Len(t, ch, 4)
+
+	// Test errors
+	ts = http.TestServer(t, http.StatusNotFound, "")
+	defer ts.Close()
+
+	err = updatePatroniDescSet(http.NewClient(http.ClientConfig{}), ts.URL, descs, make(chan prometheus.Metric, 10))
+	assert.Error(t, err)
+}
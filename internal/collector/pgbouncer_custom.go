@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type pgbouncerCustomCollector struct {
+	custom []typedDescSet
+}
+
+// NewPgbouncerCustomCollector returns a new Collector that expose user-defined pgbouncer metrics. Queries are executed
+// against pgbouncer admin console, hence SHOW commands could be used.
+func NewPgbouncerCustomCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &pgbouncerCustomCollector{
+		custom: newDeskSetsFromSubsystems("pgbouncer", settings.Subsystems, constLabels),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *pgbouncerCustomCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	return updateFromSingleDatabase(config, c.custom, ch)
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *pgbouncerCustomCollector) executedQueries(_ Config) []string {
+	// Sets with multiple databases are skipped during update.
+	var sets []typedDescSet
+	for _, s := range c.custom {
+		if s.databasesRE == nil {
+			sets = append(sets, s)
+		}
+	}
+	return descSetsQueries(sets)
+}
@@ -7,14 +7,15 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
 	// admin console queries used for retrieving stats.
-	poolsQuery   = "SHOW POOLS"
-	clientsQuery = "SHOW CLIENTS"
+	poolsQuery     = "SHOW POOLS"
+	clientsQuery   = "SHOW CLIENTS"
+	databasesQuery = "SHOW DATABASES"
 )
 
 type pgbouncerPoolsCollector struct {
@@ -26,6 +27,8 @@ type pgbouncerPoolsCollector struct {
 
 // NewPgbouncerPoolsCollector returns a new Collector exposing pgbouncer pools connections usage stats.
 // For details see https://www.pgbouncer.org/usage.html#show-pools.
+// Pools metrics have 'backend_service_id' label with ID of Postgres service the pool's database is served by, if the
+// service is registered in pgSCV.
 func NewPgbouncerPoolsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	var poolsLabelNames = []string{"user", "database", "pool_mode", "state"}
 
@@ -33,13 +36,13 @@ func NewPgbouncerPoolsCollector(constLabels labels, settings model.CollectorSett
 		conns: newBuiltinTypedDesc(
 			descOpts{"pgbouncer", "pool", "connections_in_flight", "The total number of connections established by each state.", 0},
 			prometheus.GaugeValue,
-			poolsLabelNames, constLabels,
+			append(poolsLabelNames, "backend_service_id"), constLabels,
 			settings.Filters,
 		),
 		maxwait: newBuiltinTypedDesc(
 			descOpts{"pgbouncer", "pool", "max_wait_seconds", "Total time the first (oldest) client in the queue has waited, in seconds.", 0},
 			prometheus.GaugeValue,
-			[]string{"user", "database", "pool_mode"}, constLabels,
+			[]string{"user", "database", "pool_mode", "backend_service_id"}, constLabels,
 			settings.Filters,
 		),
 		clients: newBuiltinTypedDesc(
@@ -54,7 +57,7 @@ func NewPgbouncerPoolsCollector(constLabels labels, settings model.CollectorSett
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *pgbouncerPoolsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -74,21 +77,34 @@ func (c *pgbouncerPoolsCollector) Update(config Config, ch chan<- prometheus.Met
 
 	clientsStats := parsePgbouncerClientsStats(res)
 
+	// Map pools databases to backend Postgres services registered in pgSCV.
+	backendServices := map[string]string{}
+	if config.ServiceLookup != nil {
+		res, err = conn.Query(databasesQuery)
+		if err != nil {
+			log.Warnf("get pgbouncer databases failed: %s; skip", err)
+		} else {
+			backendServices = lookupPgbouncerBackendServices(config, parsePgbouncerDatabases(res))
+		}
+	}
+
 	// Process pools stats.
 	for _, stat := range poolsStats {
-		ch <- c.conns.newConstMetric(stat.clActive, stat.user, stat.database, stat.mode, "cl_active")
-		ch <- c.conns.newConstMetric(stat.clWaiting, stat.user, stat.database, stat.mode, "cl_waiting")
-		ch <- c.conns.newConstMetric(stat.clCancelReq, stat.user, stat.database, stat.mode, "cl_cancel_req")
-		ch <- c.conns.newConstMetric(stat.clActiveCancelReq, stat.user, stat.database, stat.mode, "cl_active_cancel_req")
-		ch <- c.conns.newConstMetric(stat.clWaitingCancelReq, stat.user, stat.database, stat.mode, "cl_waiting_cancel_req")
-		ch <- c.conns.newConstMetric(stat.svActive, stat.user, stat.database, stat.mode, "sv_active")
-		ch <- c.conns.newConstMetric(stat.svActiveCancel, stat.user, stat.database, stat.mode, "sv_active_cancel")
-		ch <- c.conns.newConstMetric(stat.svBeingCanceled, stat.user, stat.database, stat.mode, "sv_being_canceled")
-		ch <- c.conns.newConstMetric(stat.svIdle, stat.user, stat.database, stat.mode, "sv_idle")
-		ch <- c.conns.newConstMetric(stat.svUsed, stat.user, stat.database, stat.mode, "sv_used")
-		ch <- c.conns.newConstMetric(stat.svTested, stat.user, stat.database, stat.mode, "sv_tested")
-		ch <- c.conns.newConstMetric(stat.svLogin, stat.user, stat.database, stat.mode, "sv_login")
-		ch <- c.maxwait.newConstMetric(stat.maxWait, stat.user, stat.database, stat.mode)
+		backend := backendServices[stat.database]
+
+		ch <- c.conns.newConstMetric(stat.clActive, stat.user, stat.database, stat.mode, "cl_active", backend)
+		ch <- c.conns.newConstMetric(stat.clWaiting, stat.user, stat.database, stat.mode, "cl_waiting", backend)
+		ch <- c.conns.newConstMetric(stat.clCancelReq, stat.user, stat.database, stat.mode, "cl_cancel_req", backend)
+		ch <- c.conns.newConstMetric(stat.clActiveCancelReq, stat.user, stat.database, stat.mode, "cl_active_cancel_req", backend)
+		ch <- c.conns.newConstMetric(stat.clWaitingCancelReq, stat.user, stat.database, stat.mode, "cl_waiting_cancel_req", backend)
+		ch <- c.conns.newConstMetric(stat.svActive, stat.user, stat.database, stat.mode, "sv_active", backend)
+		ch <- c.conns.newConstMetric(stat.svActiveCancel, stat.user, stat.database, stat.mode, "sv_active_cancel", backend)
+		ch <- c.conns.newConstMetric(stat.svBeingCanceled, stat.user, stat.database, stat.mode, "sv_being_canceled", backend)
+		ch <- c.conns.newConstMetric(stat.svIdle, stat.user, stat.database, stat.mode, "sv_idle", backend)
+		ch <- c.conns.newConstMetric(stat.svUsed, stat.user, stat.database, stat.mode, "sv_used", backend)
+		ch <- c.conns.newConstMetric(stat.svTested, stat.user, stat.database, stat.mode, "sv_tested", backend)
+		ch <- c.conns.newConstMetric(stat.svLogin, stat.user, stat.database, stat.mode, "sv_login", backend)
+		ch <- c.maxwait.newConstMetric(stat.maxWait, stat.user, stat.database, stat.mode, backend)
 	}
 
 	// Process client connections stats.
@@ -107,6 +123,14 @@ func (c *pgbouncerPoolsCollector) Update(config Config, ch chan<- prometheus.Met
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *pgbouncerPoolsCollector) executedQueries(config Config) []string {
+	if config.ServiceLookup == nil {
+		return []string{poolsQuery, clientsQuery}
+	}
+	return []string{poolsQuery, clientsQuery, databasesQuery}
+}
+
 // pgbouncerPoolStat is a per-pool store for connections metrics.
 type pgbouncerPoolStat struct {
 	database           string
@@ -241,3 +265,72 @@ func parsePgbouncerClientsStats(r *model.PGResult) map[string]float64 {
 
 	return stats
 }
+
+// pgbouncerBackend defines address of the Postgres service which serves pgbouncer database.
+type pgbouncerBackend struct {
+	host string
+	port uint16
+}
+
+// parsePgbouncerDatabases parses query result and returns backend addresses of pgbouncer databases.
+func parsePgbouncerDatabases(r *model.PGResult) map[string]pgbouncerBackend {
+	log.Debug("parse pgbouncer databases")
+
+	var databases = map[string]pgbouncerBackend{}
+
+	for _, row := range r.Rows {
+		var name string
+		var backend = pgbouncerBackend{port: 5432}
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "name":
+				name = row[i].String
+			case "host":
+				backend.host = row[i].String
+			case "port":
+				port, err := strconv.ParseUint(row[i].String, 10, 16)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+				backend.port = uint16(port)
+			}
+		}
+
+		databases[name] = backend
+	}
+
+	return databases
+}
+
+// lookupPgbouncerBackendServices returns IDs of registered Postgres services which serve pgbouncer databases. Local
+// backend addresses are relative to pgbouncer host, hence they are replaced with pgbouncer host.
+func lookupPgbouncerBackendServices(config Config, databases map[string]pgbouncerBackend) map[string]string {
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		log.Warnf("parse pgbouncer connection string failed: %s; skip", err)
+		return map[string]string{}
+	}
+
+	var services = map[string]string{}
+
+	for name, backend := range databases {
+		host := backend.host
+		if host == "" || isAddressLoopback(host) {
+			host = pgconfig.Host
+		}
+
+		id := config.ServiceLookup(model.ServiceTypePostgresql, func(h string, p uint16) bool {
+			if p != backend.port {
+				return false
+			}
+			return h == host || (isAddressLocal(h) && isAddressLocal(host))
+		})
+		if id != "" {
+			services[name] = id
+		}
+	}
+
+	return services
+}
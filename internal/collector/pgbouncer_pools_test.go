@@ -122,3 +122,55 @@ func Test_parsePgbouncerClientsStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePgbouncerDatabases(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("name")}, {Name: []byte("host")}, {Name: []byte("port")}, {Name: []byte("database")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "pgbouncer", Valid: true}, {}, {String: "6432", Valid: true}, {String: "pgbouncer", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "10.0.0.1", Valid: true}, {String: "5433", Valid: true}, {String: "testdb", Valid: true}},
+			{{String: "local", Valid: true}, {String: "", Valid: false}, {String: "5432", Valid: true}, {String: "local", Valid: true}},
+		},
+	}
+
+	want := map[string]pgbouncerBackend{
+		"pgbouncer": {host: "", port: 6432},
+		"testdb":    {host: "10.0.0.1", port: 5433},
+		"local":     {host: "", port: 5432},
+	}
+
+	assert.Equal(t, want, parsePgbouncerDatabases(res))
+}
+
+func Test_lookupPgbouncerBackendServices(t *testing.T) {
+	lookup := func(serviceType string, match func(string, uint16) bool) string {
+		assert.Equal(t, model.ServiceTypePostgresql, serviceType)
+		if match("10.0.0.1", 5433) {
+			return "postgres:10.0.0.1:5433"
+		}
+		if match("10.0.0.2", 5432) {
+			return "postgres:10.0.0.2:5432"
+		}
+		return ""
+	}
+
+	config := Config{ConnString: "host=10.0.0.2 port=6432 user=pgscv dbname=pgbouncer", ServiceLookup: lookup}
+	databases := map[string]pgbouncerBackend{
+		"testdb":  {host: "10.0.0.1", port: 5433},
+		"local":   {host: "", port: 5432},
+		"socket":  {host: "/var/run/postgresql", port: 5432},
+		"unknown": {host: "10.0.0.3", port: 5432},
+	}
+
+	want := map[string]string{
+		"testdb": "postgres:10.0.0.1:5433",
+		"local":  "postgres:10.0.0.2:5432",
+		"socket": "postgres:10.0.0.2:5432",
+	}
+
+	assert.Equal(t, want, lookupPgbouncerBackendServices(config, databases))
+}
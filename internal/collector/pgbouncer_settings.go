@@ -3,17 +3,20 @@ package collector
 
 import (
 	"bufio"
-	"context"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -23,11 +26,18 @@ const (
 	versionQuery  = "SHOW VERSION"
 )
 
+// PgbouncerV121 defines numeric representation of Pgbouncer 1.21, which introduced prepared statements support.
+const PgbouncerV121 = 12100
+
 type pgbouncerSettingsCollector struct {
-	version    typedDesc
-	settings   typedDesc
-	dbSettings typedDesc
-	poolSize   typedDesc
+	version     typedDesc
+	settings    typedDesc
+	dbSettings  typedDesc
+	poolSize    typedDesc
+	hash        typedDesc
+	nonDefaults typedDesc
+	reloads     typedDesc
+	state       pgbouncerSettingsState
 }
 
 // NewPgbouncerSettingsCollector returns a new Collector exposing pgbouncer configuration.
@@ -58,6 +68,24 @@ func NewPgbouncerSettingsCollector(constLabels labels, settings model.CollectorS
 			[]string{"database"}, constLabels,
 			settings.Filters,
 		),
+		hash: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "service", "settings_hash_info", "Labeled information about hash of Pgbouncer effective configuration.", 0},
+			prometheus.GaugeValue,
+			[]string{"hash"}, constLabels,
+			settings.Filters,
+		),
+		nonDefaults: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "service", "non_default_settings_info", "Labeled information about Pgbouncer configuration settings which differ from defaults.", 0},
+			prometheus.GaugeValue,
+			[]string{"name", "setting", "default"}, constLabels,
+			settings.Filters,
+		),
+		reloads: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "service", "config_reloads_total", "Total number of Pgbouncer configuration reloads detected by changes of effective configuration.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
@@ -106,6 +134,29 @@ func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.
 		ch <- c.settings.newConstMetric(f, k, v)
 	}
 
+	// Defaults are available since Pgbouncer 1.20, settings differ from defaults are not reported for older versions.
+	for k, d := range parsePgbouncerSettingsDefaults(res) {
+		if v, ok := settings[k]; ok && v != d {
+			ch <- c.nonDefaults.newConstMetric(1, k, v, d)
+		}
+	}
+
+	hash := pgbouncerSettingsHash(settings)
+	ch <- c.hash.newConstMetric(1, hash)
+
+	// Stats counters are used for distinguishing reloads from restarts.
+	res, err = conn.Query(pgbouncerStatsQuery)
+	if err != nil {
+		return err
+	}
+
+	var queries float64
+	for _, stat := range parsePgbouncerStatsStats(res, []string{"database"}) {
+		queries += stat.queries
+	}
+
+	ch <- c.reloads.newConstMetric(c.state.observe(hash, queries))
+
 	// Determine is service running locally.
 	if !isAddressLocal(pgbconfig.Host) {
 		log.Debugln("[pgbouncer collector]: skip collecting per database settings metrics from remote services")
@@ -139,10 +190,15 @@ func (c *pgbouncerSettingsCollector) Update(config Config, ch chan<- prometheus.
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *pgbouncerSettingsCollector) executedQueries(_ Config) []string {
+	return []string{versionQuery, settingsQuery, pgbouncerStatsQuery}
+}
+
 // queryPgbouncerVersion queries version info from Pgbouncer and return numeric and string version representation.
 func queryPgbouncerVersion(conn *store.DB) (int, string, error) {
 	var versionStr string
-	err := conn.Conn().QueryRow(context.Background(), versionQuery).Scan(&versionStr)
+	err := conn.QueryRow(versionQuery).Scan(&versionStr)
 	if err != nil {
 		// Pgbouncer before 1.12 returns version string as a NOTICE, and it seems there is no way to extract
 		// message text from the NOTICE. Return zero value and nil as error.
@@ -185,6 +241,68 @@ func parsePgbouncerSettings(r *model.PGResult) map[string]string {
 	return settings
 }
 
+// parsePgbouncerSettingsDefaults parses content of 'SHOW CONFIG' and return map with default values of settings. Nil
+// is returned when defaults are not provided by Pgbouncer.
+func parsePgbouncerSettingsDefaults(r *model.PGResult) map[string]string {
+	idx := slices.IndexFunc(r.Colnames, func(c pgproto3.FieldDescription) bool { return string(c.Name) == "default" })
+	if idx < 0 {
+		return nil
+	}
+
+	defaults := make(map[string]string)
+
+	for _, row := range r.Rows {
+		if len(row) <= idx {
+			log.Warnln("invalid input: too few values; skip")
+			continue
+		}
+
+		defaults[row[0].String] = row[idx].String
+	}
+
+	return defaults
+}
+
+// pgbouncerSettingsHash returns hash of settings used for detecting configuration drift across Pgbouncers.
+func pgbouncerSettingsHash(settings map[string]string) string {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	h := fnv.New64a()
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", k, settings[k])
+	}
+
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// pgbouncerSettingsState keeps settings hash and stats counters observed during previous scrape.
+type pgbouncerSettingsState struct {
+	mu      sync.Mutex
+	hash    string
+	queries float64
+	reloads float64
+}
+
+// observe accounts settings hash and total number of queries observed during scrape, and returns total number of
+// detected reloads. Reload is detected when settings hash has changed while stats counters keep growing, decreased
+// counters mean Pgbouncer has been restarted. Reloads which don't change configuration are not detected.
+func (s *pgbouncerSettingsState) observe(hash string, queries float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hash != "" && s.hash != hash && queries >= s.queries {
+		s.reloads++
+	}
+
+	s.hash, s.queries = hash, queries
+
+	return s.reloads
+}
+
 // dbSettings describes per-database settings specified inside [database] section of pgbouncer config file.
 type dbSettings struct {
 	name string
@@ -16,6 +16,11 @@ func TestPgbouncerSettingsCollector_Update(t *testing.T) {
 			"pgbouncer_service_settings_info",
 			"pgbouncer_service_database_settings_info",
 			"pgbouncer_service_database_pool_size",
+			"pgbouncer_service_settings_hash_info",
+			"pgbouncer_service_config_reloads_total",
+		},
+		optional: []string{
+			"pgbouncer_service_non_default_settings_info",
 		},
 		collector: NewPgbouncerSettingsCollector,
 		service:   model.ServiceTypePgbouncer,
@@ -67,6 +72,53 @@ func Test_parsePgbouncerSettings(t *testing.T) {
 	}
 }
 
+func Test_parsePgbouncerSettingsDefaults(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("key")}, {Name: []byte("value")}, {Name: []byte("default")}, {Name: []byte("changeable")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "listen_addr", Valid: true}, {String: "127.0.0.1", Valid: true}, {String: "", Valid: true}, {String: "no", Valid: true}},
+			{{String: "max_client_conn", Valid: true}, {String: "1000", Valid: true}, {String: "100", Valid: true}, {String: "yes", Valid: true}},
+		},
+	}
+	assert.Equal(t, map[string]string{"listen_addr": "", "max_client_conn": "100"}, parsePgbouncerSettingsDefaults(res))
+
+	// Defaults are not provided by older Pgbouncers.
+	res = &model.PGResult{
+		Nrows: 1,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("key")}, {Name: []byte("value")}, {Name: []byte("changeable")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "max_client_conn", Valid: true}, {String: "1000", Valid: true}, {String: "yes", Valid: true}},
+		},
+	}
+	assert.Nil(t, parsePgbouncerSettingsDefaults(res))
+}
+
+func Test_pgbouncerSettingsHash(t *testing.T) {
+	settings := map[string]string{"listen_addr": "127.0.0.1", "max_client_conn": "1000"}
+
+	hash := pgbouncerSettingsHash(settings)
+	assert.Len(t, hash, 16)
+	assert.Equal(t, hash, pgbouncerSettingsHash(map[string]string{"max_client_conn": "1000", "listen_addr": "127.0.0.1"}))
+	assert.NotEqual(t, hash, pgbouncerSettingsHash(map[string]string{"listen_addr": "127.0.0.1", "max_client_conn": "2000"}))
+}
+
+func Test_pgbouncerSettingsState_observe(t *testing.T) {
+	var s pgbouncerSettingsState
+
+	assert.Equal(t, float64(0), s.observe("a", 10)) // first scrape
+	assert.Equal(t, float64(0), s.observe("a", 20)) // configuration not changed
+	assert.Equal(t, float64(1), s.observe("b", 30)) // reload
+	assert.Equal(t, float64(1), s.observe("c", 5))  // restart
+	assert.Equal(t, float64(2), s.observe("d", 5))  // reload without queries
+}
+
 func Test_getPerDatabaseSettings(t *testing.T) {
 	defaults := map[string]string{
 		"pool_mode":         "transaction",
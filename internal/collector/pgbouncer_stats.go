@@ -6,11 +6,13 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const pgbouncerStatsQuery = "SHOW STATS"
+const (
+	pgbouncerStatsQuery   = "SHOW STATS"
+	pgbouncerServersQuery = "SHOW SERVERS"
+)
 
 type pgbouncerStatsCollector struct {
 	up         typedDesc
@@ -18,6 +20,11 @@ type pgbouncerStatsCollector struct {
 	queries    typedDesc
 	bytes      typedDesc
 	time       typedDesc
+	parses     typedDesc
+	binds      typedDesc
+	cacheHits  typedDesc
+	cached     typedDesc
+	cachedMax  typedDesc
 	labelNames []string
 }
 
@@ -62,12 +69,42 @@ func NewPgbouncerStatsCollector(constLabels labels, settings model.CollectorSett
 			[]string{"database", "type", "mode"}, constLabels,
 			settings.Filters,
 		),
+		parses: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "prepared_statements", "parses_total", "Total number of prepared statements parse requests, sent by clients or sent to servers by pgbouncer.", 0},
+			prometheus.CounterValue,
+			[]string{"database", "side"}, constLabels,
+			settings.Filters,
+		),
+		binds: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "prepared_statements", "binds_total", "Total number of prepared statements bind requests sent by clients.", 0},
+			prometheus.CounterValue,
+			pgbouncerLabelNames, constLabels,
+			settings.Filters,
+		),
+		cacheHits: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "prepared_statements", "cache_hits_total", "Estimated total number of clients parse requests served by statements already prepared on servers.", 0},
+			prometheus.CounterValue,
+			pgbouncerLabelNames, constLabels,
+			settings.Filters,
+		),
+		cached: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "prepared_statements", "cached", "Number of prepared statements cached on server connections, for each pool.", 0},
+			prometheus.GaugeValue,
+			[]string{"user", "database"}, constLabels,
+			settings.Filters,
+		),
+		cachedMax: newBuiltinTypedDesc(
+			descOpts{"pgbouncer", "prepared_statements", "cached_max", "Max number of prepared statements cached on single server connection, for each pool. Compare with max_prepared_statements.", 0},
+			prometheus.GaugeValue,
+			[]string{"user", "database"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *pgbouncerStatsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		ch <- c.up.newConstMetric(0)
 		return err
@@ -91,23 +128,58 @@ func (c *pgbouncerStatsCollector) Update(config Config, ch chan<- prometheus.Met
 		ch <- c.time.newConstMetric(stat.waittime, stat.database, "waiting", "none")
 	}
 
+	// Prepared statements are supported since Pgbouncer 1.21. Failed queries of prepared statements stats don't affect
+	// the rest of stats, hence they are skipped.
+	version, _, err := queryPgbouncerVersion(conn)
+	if err != nil {
+		log.Warnf("get pgbouncer version failed: %s; skip prepared statements stats", err)
+	} else if version >= PgbouncerV121 {
+		for _, stat := range stats {
+			ch <- c.parses.newConstMetric(stat.clientParses, stat.database, "client")
+			ch <- c.parses.newConstMetric(stat.serverParses, stat.database, "server")
+			ch <- c.binds.newConstMetric(stat.binds, stat.database)
+			ch <- c.cacheHits.newConstMetric(max(stat.clientParses-stat.serverParses, 0), stat.database)
+		}
+
+		res, err = conn.Query(pgbouncerServersQuery)
+		if err != nil {
+			log.Warnf("get pgbouncer servers failed: %s; skip", err)
+		} else {
+			for _, stat := range parsePgbouncerPreparedStatements(res) {
+				ch <- c.cached.newConstMetric(stat.cached, stat.user, stat.database)
+				ch <- c.cachedMax.newConstMetric(stat.cachedMax, stat.user, stat.database)
+			}
+		}
+	}
+
 	// All is ok, collect up metric.
 	ch <- c.up.newConstMetric(1)
 
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *pgbouncerStatsCollector) executedQueries(config Config) []string {
+	if config.pgbouncerVersion < PgbouncerV121 {
+		return []string{pgbouncerStatsQuery, versionQuery}
+	}
+	return []string{pgbouncerStatsQuery, versionQuery, pgbouncerServersQuery}
+}
+
 // pgbouncerStatsStat represents general stats provided by 'SHOW STATS' command.
 // See https://www.pgbouncer.org/usage.html for details.
 type pgbouncerStatsStat struct {
-	database  string
-	xacts     float64
-	queries   float64
-	received  float64
-	sent      float64
-	xacttime  float64
-	querytime float64
-	waittime  float64
+	database     string
+	xacts        float64
+	queries      float64
+	received     float64
+	sent         float64
+	xacttime     float64
+	querytime    float64
+	waittime     float64
+	clientParses float64
+	serverParses float64
+	binds        float64
 }
 
 // parsePgbouncerStatsStats parses passed PGResult and result struct with data values extracted from PGResult
@@ -171,6 +243,12 @@ func parsePgbouncerStatsStats(r *model.PGResult, labelNames []string) map[string
 				s.querytime = v
 			case "total_wait_time":
 				s.waittime = v
+			case "total_client_parse_count":
+				s.clientParses = v
+			case "total_server_parse_count":
+				s.serverParses = v
+			case "total_bind_count":
+				s.binds = v
 			default:
 				continue
 			}
@@ -181,3 +259,52 @@ func parsePgbouncerStatsStats(r *model.PGResult, labelNames []string) map[string
 
 	return stats
 }
+
+// pgbouncerPreparedStatementsStat represents number of prepared statements cached on server connections of the pool.
+type pgbouncerPreparedStatementsStat struct {
+	user      string
+	database  string
+	cached    float64 // total number of statements cached on all server connections
+	cachedMax float64 // max number of statements cached on single server connection
+}
+
+// parsePgbouncerPreparedStatements parses 'SHOW SERVERS' output and returns number of prepared statements cached on
+// server connections, for each pool.
+func parsePgbouncerPreparedStatements(r *model.PGResult) map[string]pgbouncerPreparedStatementsStat {
+	log.Debug("parse pgbouncer servers prepared statements")
+
+	var stats = map[string]pgbouncerPreparedStatementsStat{}
+
+	for _, row := range r.Rows {
+		var user, database string
+		var cached float64
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "user":
+				user = row[i].String
+			case "database":
+				database = row[i].String
+			case "prepared_statements":
+				if !row[i].Valid {
+					continue
+				}
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err.Error())
+					continue
+				}
+				cached = v
+			}
+		}
+
+		pool := user + "/" + database
+		s := stats[pool]
+		s.user, s.database = user, database
+		s.cached += cached
+		s.cachedMax = max(s.cachedMax, cached)
+		stats[pool] = s
+	}
+
+	return stats
+}
@@ -18,6 +18,13 @@ func TestPgbouncerStatsCollector_Update(t *testing.T) {
 			"pgbouncer_bytes_total",
 			"pgbouncer_spent_seconds_total",
 		},
+		optional: []string{
+			"pgbouncer_prepared_statements_parses_total",
+			"pgbouncer_prepared_statements_binds_total",
+			"pgbouncer_prepared_statements_cache_hits_total",
+			"pgbouncer_prepared_statements_cached",
+			"pgbouncer_prepared_statements_cached_max",
+		},
 		collector: NewPgbouncerStatsCollector,
 		service:   model.ServiceTypePgbouncer,
 	}
@@ -63,6 +70,26 @@ func Test_parsePgbouncerStatsStats(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "pgbouncer 1.21 output",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 5,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("database")}, {Name: []byte("total_xact_count")},
+					{Name: []byte("total_server_parse_count")}, {Name: []byte("total_client_parse_count")}, {Name: []byte("total_bind_count")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "testdb1", Valid: true}, {String: "452789541", Valid: true},
+						{String: "1250", Valid: true}, {String: "48754", Valid: true}, {String: "985412", Valid: true},
+					},
+				},
+			},
+			want: map[string]pgbouncerStatsStat{
+				"testdb1": {database: "testdb1", xacts: 452789541, serverParses: 1250, clientParses: 48754, binds: 985412},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -72,3 +99,39 @@ func Test_parsePgbouncerStatsStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePgbouncerPreparedStatements(t *testing.T) {
+	var testCases = []struct {
+		name string
+		res  *model.PGResult
+		want map[string]pgbouncerPreparedStatementsStat
+	}{
+		{
+			name: "normal output",
+			res: &model.PGResult{
+				Nrows: 4,
+				Ncols: 4,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("type")}, {Name: []byte("user")}, {Name: []byte("database")}, {Name: []byte("prepared_statements")},
+				},
+				Rows: [][]sql.NullString{
+					{{String: "S", Valid: true}, {String: "app", Valid: true}, {String: "testdb1", Valid: true}, {String: "12", Valid: true}},
+					{{String: "S", Valid: true}, {String: "app", Valid: true}, {String: "testdb1", Valid: true}, {String: "30", Valid: true}},
+					{{String: "S", Valid: true}, {String: "app", Valid: true}, {String: "testdb2", Valid: true}, {String: "0", Valid: true}},
+					{{String: "S", Valid: true}, {String: "admin", Valid: true}, {String: "testdb1", Valid: true}, {String: "", Valid: false}},
+				},
+			},
+			want: map[string]pgbouncerPreparedStatementsStat{
+				"app/testdb1":   {user: "app", database: "testdb1", cached: 42, cachedMax: 30},
+				"app/testdb2":   {user: "app", database: "testdb2", cached: 0, cachedMax: 0},
+				"admin/testdb1": {user: "admin", database: "testdb1", cached: 0, cachedMax: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualValues(t, tc.want, parsePgbouncerPreparedStatements(tc.res))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// defaultProbeTimeout defines timeout of network probes when conn_timeout is not set.
+	defaultProbeTimeout = 5 * time.Second
+
+	// Protocol numbers used for parsing ICMP messages.
+	protocolICMP   = 1
+	protocolICMPv6 = 58
+)
+
+// probeCollector defines metric descriptors and probes counters.
+type probeCollector struct {
+	success  typedDesc
+	duration typedDesc
+	total    typedDesc
+	failures typedDesc
+	// counters keeps total number of probes and failed probes per probe type.
+	counters map[string][2]float64
+	mu       sync.Mutex
+}
+
+// NewProbeCollector returns a new Collector which probes network reachability of service endpoint from pgSCV host
+// using TCP connect and optionally ICMP echo. Probes are independent of SQL queries, hence network problems could be
+// distinguished from database problems.
+func NewProbeCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labelNames = []string{"address", "probe"}
+
+	return &probeCollector{
+		success: newBuiltinTypedDesc(
+			descOpts{"pgscv", "probe", "success", "Whether the last probe of service endpoint succeeded (1) or failed (0).", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		duration: newBuiltinTypedDesc(
+			descOpts{"pgscv", "probe", "duration_seconds", "Duration of the last successful probe of service endpoint (TCP connect time or ICMP round-trip time), in seconds.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		total: newBuiltinTypedDesc(
+			descOpts{"pgscv", "probe", "total", "Total number of probes of service endpoint.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		failures: newBuiltinTypedDesc(
+			descOpts{"pgscv", "probe", "failures_total", "Total number of failed probes of service endpoint.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		counters: map[string][2]float64{},
+	}, nil
+}
+
+// Update method probes service endpoint and sends metrics to Prometheus.
+func (c *probeCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	host, port, err := probeEndpoint(config)
+	if err != nil {
+		return err
+	}
+
+	// Unix sockets are not probed, service is running on the same host.
+	if host == "" || strings.HasPrefix(host, "/") {
+		return nil
+	}
+
+	timeout := defaultProbeTimeout
+	if config.ConnTimeout > 0 {
+		timeout = time.Duration(config.ConnTimeout) * time.Second
+	}
+
+	address := net.JoinHostPort(host, port)
+
+	probes := map[string]func() (time.Duration, error){
+		"tcp": func() (time.Duration, error) { return probeTCP(address, timeout) },
+	}
+	if config.ProbeICMP {
+		probes["icmp"] = func() (time.Duration, error) { return probeICMP(host, timeout) }
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for probe, fn := range probes {
+		counters := c.counters[probe]
+		counters[0]++
+
+		d, err := fn()
+		if err != nil {
+			log.Warnf("[probe collector]: %s probe of %s failed: %s", probe, address, err)
+			counters[1]++
+			ch <- c.success.newConstMetric(0, address, probe)
+		} else {
+			ch <- c.success.newConstMetric(1, address, probe)
+			ch <- c.duration.newConstMetric(d.Seconds(), address, probe)
+		}
+
+		c.counters[probe] = counters
+		ch <- c.total.newConstMetric(counters[0], address, probe)
+		ch <- c.failures.newConstMetric(counters[1], address, probe)
+	}
+
+	return nil
+}
+
+// probeEndpoint returns host and port of service endpoint. Endpoints of HTTP services and services of plugin service
+// types are defined by base URL.
+func probeEndpoint(config Config) (string, string, error) {
+	if config.ServiceType == model.ServiceTypePatroni || config.BaseURL != "" {
+		u, err := url.Parse(config.BaseURL)
+		if err != nil {
+			return "", "", err
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		return u.Hostname(), port, nil
+	}
+
+	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	if err != nil {
+		return "", "", err
+	}
+
+	return pgconfig.Host, strconv.FormatUint(uint64(pgconfig.Port), 10), nil
+}
+
+// probeTCP establishes TCP connection to the address and returns time spent on connect.
+func probeTCP(address string, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return 0, err
+	}
+	d := time.Since(start)
+
+	_ = conn.Close()
+
+	return d, nil
+}
+
+// probeICMP sends ICMP echo request to the host and returns round-trip time. Unprivileged ICMP sockets are used, on
+// Linux this requires pgSCV group to be allowed in net.ipv4.ping_group_range sysctl.
+func probeICMP(host string, timeout time.Duration) (time.Duration, error) {
+	addr, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		return 0, err
+	}
+
+	network, listen, protocol := "udp4", "0.0.0.0", protocolICMP
+	var request, reply icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if addr.IP.To4() == nil {
+		network, listen, protocol = "udp6", "::", protocolICMPv6
+		request, reply = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+
+	conn, err := icmp.ListenPacket(network, listen)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	msg := icmp.Message{
+		Type: request,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: 1, Data: []byte("pgscv")},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := conn.SetDeadline(start.Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	if _, err := conn.WriteTo(b, &net.UDPAddr{IP: addr.IP, Zone: addr.Zone}); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+
+		m, err := icmp.ParseMessage(protocol, buf[:n])
+		if err != nil {
+			return 0, fmt.Errorf("parse ICMP message failed: %w", err)
+		}
+
+		if m.Type == reply {
+			return time.Since(start), nil
+		}
+	}
+}
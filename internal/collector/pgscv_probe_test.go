@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeCollector_Update(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	c, err := NewProbeCollector(labels{"service_id": "test"}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	config := Config{ServiceType: model.ServiceTypePostgresql, ConnString: "postgres://pgscv@" + ln.Addr().String() + "/pgscv"}
+
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, c.Update(config, ch))
+	close(ch)
+
+	// success, duration, total and failures metrics of TCP probe.
+	assert.Len(t, ch, 4)
+	assert.Equal(t, [2]float64{1, 0}, c.(*probeCollector).counters["tcp"])
+
+	// Close listener, the next probe should fail.
+	assert.NoError(t, ln.Close())
+
+	ch = make(chan prometheus.Metric, 10)
+	assert.NoError(t, c.Update(config, ch))
+	close(ch)
+
+	assert.Len(t, ch, 3)
+	assert.Equal(t, [2]float64{2, 1}, c.(*probeCollector).counters["tcp"])
+}
+
+func Test_probeEndpoint(t *testing.T) {
+	testcases := []struct {
+		config Config
+		host   string
+		port   string
+	}{
+		{config: Config{ServiceType: model.ServiceTypePostgresql, ConnString: "host=10.0.0.1 port=6432 user=pgscv"}, host: "10.0.0.1", port: "6432"},
+		{config: Config{ServiceType: model.ServiceTypePostgresql, ConnString: "host=/var/run/postgresql user=pgscv"}, host: "/var/run/postgresql", port: "5432"},
+		{config: Config{ServiceType: model.ServiceTypePatroni, BaseURL: "http://10.0.0.1:8008"}, host: "10.0.0.1", port: "8008"},
+		{config: Config{ServiceType: model.ServiceTypePatroni, BaseURL: "https://patroni.example.com"}, host: "patroni.example.com", port: "443"},
+		{config: Config{ServiceType: "etcd", BaseURL: "http://10.0.0.2:2379", ConnString: "opaque"}, host: "10.0.0.2", port: "2379"},
+	}
+
+	for _, tc := range testcases {
+		host, port, err := probeEndpoint(tc.config)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.host, host)
+		assert.Equal(t, tc.port, port)
+	}
+}
+
+func Test_probeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	d, err := probeTCP(ln.Addr().String(), time.Second)
+	assert.NoError(t, err)
+	assert.Greater(t, d, time.Duration(0))
+
+	assert.NoError(t, ln.Close())
+
+	_, err = probeTCP(ln.Addr().String(), time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterPluginCollectors registers collectors of service type implemented by plugin, in addition to them builtin
+// '<type>/pgscv' and '<type>/probe' collectors are registered.
+func (f Factories) RegisterPluginCollectors(t plugin.ServiceType, disabled []string) {
+	if stringsContains(disabled, t.Name) {
+		log.Debugln("disable all ", t.Name, " collectors")
+		return
+	}
+
+	funcs := map[string]func(labels, model.CollectorSettings) (Collector, error){
+		t.Name + "/pgscv": NewPgscvServicesCollector,
+		t.Name + "/probe": NewProbeCollector,
+	}
+	for name, factory := range t.Collectors {
+		funcs[t.Name+"/"+name] = newPluginCollectorFactory(factory)
+	}
+
+	for name, fn := range funcs {
+		if stringsContains(disabled, name) {
+			log.Debugln("disable ", name)
+			continue
+		}
+
+		log.Debugln("enable ", name)
+		f.register(name, fn)
+	}
+}
+
+// pluginCollector adapts collector implemented by plugin to Collector interface.
+type pluginCollector struct {
+	collector plugin.Collector
+}
+
+// newPluginCollectorFactory returns factory of collectors which adapts collectors created by plugin factory.
+func newPluginCollectorFactory(factory plugin.CollectorFactory) func(labels, model.CollectorSettings) (Collector, error) {
+	return func(constLabels labels, _ model.CollectorSettings) (Collector, error) {
+		c, err := factory(prometheus.Labels(constLabels))
+		if err != nil {
+			return nil, err
+		}
+		return &pluginCollector{collector: c}, nil
+	}
+}
+
+// Update method calls plugin collector, collector is cancelled when scrape deadline is exceeded.
+func (c *pluginCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	ctx := context.Background()
+	if !config.scrapeDeadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, config.scrapeDeadline)
+		defer cancel()
+	}
+
+	return c.collector.Update(ctx, plugin.Service{BaseURL: config.BaseURL, Conninfo: config.ConnString}, ch)
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/cherts/pgscv/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPluginCollector is the collector of plugin service type which exposes single metric.
+type testPluginCollector struct {
+	desc *prometheus.Desc
+}
+
+func (c testPluginCollector) Update(ctx context.Context, service plugin.Service, ch chan<- prometheus.Metric) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return context.DeadlineExceeded
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, service.BaseURL)
+	return nil
+}
+
+func newTestPluginCollector(constLabels prometheus.Labels) (plugin.Collector, error) {
+	return testPluginCollector{
+		desc: prometheus.NewDesc("test_plugin_up", "State of the service.", []string{"url"}, constLabels),
+	}, nil
+}
+
+func TestFactories_RegisterPluginCollectors(t *testing.T) {
+	st := plugin.ServiceType{Name: "test_plugin", Collectors: map[string]plugin.CollectorFactory{
+		"health": newTestPluginCollector,
+		"stats":  newTestPluginCollector,
+	}}
+
+	f := Factories{}
+	f.RegisterPluginCollectors(st, []string{"test_plugin/stats"})
+	assert.Equal(t, []string{"test_plugin/health", "test_plugin/pgscv", "test_plugin/probe"}, slices.Sorted(maps.Keys(f)))
+
+	f = Factories{}
+	f.RegisterPluginCollectors(st, []string{"test_plugin"})
+	assert.Empty(t, f)
+}
+
+func TestNewPgscvCollector_plugin(t *testing.T) {
+	assert.NoError(t, plugin.Register(plugin.ServiceType{Name: "test_plugin_svc", Collectors: map[string]plugin.CollectorFactory{
+		"health": newTestPluginCollector,
+	}}))
+
+	st, _ := plugin.Lookup("test_plugin_svc")
+	f := Factories{}
+	f.RegisterPluginCollectors(st, []string{"test_plugin_svc/probe"})
+
+	c, err := NewPgscvCollector("etcd:2379", f, Config{ServiceType: "test_plugin_svc", BaseURL: "http://10.0.0.2:2379", ConnString: "opaque"})
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric, 10)
+	c.Collect(ch)
+	close(ch)
+
+	var found bool
+	for m := range ch {
+		if m.Desc().String() == newTestPluginDesc().String() {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func newTestPluginDesc() *prometheus.Desc {
+	return prometheus.NewDesc("test_plugin_up", "State of the service.", []string{"url"},
+		prometheus.Labels{"service_id": "etcd:2379", "host": "10.0.0.2", "port": "2379"})
+}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolsStats defines metric descriptors of per-database connection pools used by collectors of the service.
+type poolsStats struct {
+	databases       typedDesc
+	maxConns        typedDesc
+	totalConns      typedDesc
+	idleConns       typedDesc
+	acquiredConns   typedDesc
+	acquires        typedDesc
+	acquireWait     typedDesc
+	acquireCanceled typedDesc
+	newConns        typedDesc
+}
+
+// newPoolsStats creates new poolsStats.
+func newPoolsStats(constLabels labels) *poolsStats {
+	newDesc := func(name, help string, valueType prometheus.ValueType) typedDesc {
+		return newBuiltinTypedDesc(descOpts{"pgscv", "pool", name, help, 0}, valueType, nil, constLabels, filter.New())
+	}
+
+	return &poolsStats{
+		databases:       newDesc("databases", "Number of open per-database connection pools.", prometheus.GaugeValue),
+		maxConns:        newDesc("max_connections", "Max number of connections acquired across all pools.", prometheus.GaugeValue),
+		totalConns:      newDesc("total_connections", "Number of connections currently open in pools.", prometheus.GaugeValue),
+		idleConns:       newDesc("idle_connections", "Number of idle connections in pools.", prometheus.GaugeValue),
+		acquiredConns:   newDesc("acquired_connections", "Number of connections currently acquired from pools.", prometheus.GaugeValue),
+		acquires:        newDesc("acquires_total", "Total number of connections successfully acquired from pools.", prometheus.CounterValue),
+		acquireWait:     newDesc("acquire_wait_seconds_total", "Total time spent waiting for connections from pools, in seconds.", prometheus.CounterValue),
+		acquireCanceled: newDesc("acquire_cancels_total", "Total number of failed or timed out attempts to acquire connections from pools.", prometheus.CounterValue),
+		newConns:        newDesc("new_connections_total", "Total number of connections created by pools.", prometheus.CounterValue),
+	}
+}
+
+// send sends statistics of passed pools into channel.
+func (s *poolsStats) send(pools *store.Pools, ch chan<- prometheus.Metric) {
+	if s == nil || pools == nil {
+		return
+	}
+
+	stat := pools.Stat()
+
+	ch <- s.databases.newConstMetric(float64(stat.Pools))
+	ch <- s.maxConns.newConstMetric(float64(stat.MaxConns))
+	ch <- s.totalConns.newConstMetric(float64(stat.TotalConns))
+	ch <- s.idleConns.newConstMetric(float64(stat.IdleConns))
+	ch <- s.acquiredConns.newConstMetric(float64(stat.AcquiredConns))
+	ch <- s.acquires.newConstMetric(float64(stat.AcquireCount))
+	ch <- s.acquireWait.newConstMetric(stat.AcquireDuration.Seconds())
+	ch <- s.acquireCanceled.newConstMetric(float64(stat.CanceledAcquireCount))
+	ch <- s.newConns.newConstMetric(float64(stat.NewConnsCount))
+}
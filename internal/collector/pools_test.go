@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_poolsStats_send(t *testing.T) {
+	pools, err := store.NewPools("host=127.0.0.1 port=1 user=pgscv dbname=postgres", 1, nil, 2, dbPoolIdleTimeout)
+	assert.NoError(t, err)
+	defer pools.Close()
+
+	s := newPoolsStats(labels{"service_id": "test"})
+
+	ch := make(chan prometheus.Metric, 20)
+	s.send(pools, ch)
+	close(ch)
+	assert.Len(t, ch, 9)
+
+	// Nothing is sent for services without pools.
+	ch = make(chan prometheus.Metric, 20)
+	s.send(nil, ch)
+	var empty *poolsStats
+	empty.send(pools, ch)
+	close(ch)
+	assert.Len(t, ch, 0)
+}
@@ -2,14 +2,13 @@
 package collector
 
 import (
-	"context"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -17,7 +16,7 @@ const (
 	// postgresActivityQuery95 defines activity query for 9.5 and older.
 	// Postgres 9.5 doesn't have 'wait_event_type', 'wait_event' and 'backend_type'  attributes.
 	postgresActivityQuery95 = "SELECT " +
-		"COALESCE(usename, 'system') AS user, datname AS database, state, waiting, " +
+		"COALESCE(usename, 'system') AS user, datname AS database, application_name AS application, state, waiting, " +
 		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN waiting = 't' THEN EXTRACT(EPOCH FROM clock_timestamp() - state_change) ELSE 0 END AS waiting_seconds, " +
 		"LEFT(query, 32) AS query " +
@@ -26,7 +25,7 @@ const (
 	// postgresActivityQuery96 defines activity query for 9.6.
 	// Postgres 9.6 doesn't have 'backend_type' attribute.
 	postgresActivityQuery96 = "SELECT " +
-		"COALESCE(usename, 'system') AS user, datname AS database, state, wait_event_type, wait_event, " +
+		"COALESCE(usename, 'system') AS user, datname AS database, application_name AS application, state, wait_event_type, wait_event, " +
 		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN wait_event_type = 'Lock' THEN EXTRACT(EPOCH FROM clock_timestamp() - state_change) ELSE 0 END AS waiting_seconds, " +
 		"LEFT(query, 32) AS query " +
@@ -34,7 +33,7 @@ const (
 
 	// postgresActivityQuery13 defines activity query for versions from 10 to 13.
 	postgresActivityQuery13 = "SELECT " +
-		"COALESCE(usename, backend_type) AS user, datname AS database, state, wait_event_type, wait_event, " +
+		"COALESCE(usename, backend_type) AS user, datname AS database, application_name AS application, state, wait_event_type, wait_event, " +
 		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN wait_event_type = 'Lock' THEN EXTRACT(EPOCH FROM clock_timestamp() - state_change) ELSE 0 END AS waiting_seconds, " +
 		"LEFT(query, 32) AS query " +
@@ -43,7 +42,7 @@ const (
 	// postgresActivityQueryLatest defines activity query for recent versions.
 	// Postgres 14 has pg_locks.waitstart which is better for taking sessions waiting time.
 	postgresActivityQueryLatest = "SELECT " +
-		"COALESCE(usename, backend_type) AS user, datname AS database, state, wait_event_type, wait_event, " +
+		"COALESCE(usename, backend_type) AS user, datname AS database, application_name AS application, state, wait_event_type, wait_event, " +
 		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - xact_start), 0) AS active_seconds, " +
 		"CASE WHEN wait_event_type = 'Lock' " +
 		"THEN (SELECT EXTRACT(EPOCH FROM clock_timestamp() - MAX(waitstart)) FROM pg_locks l WHERE l.pid = a.pid) " +
@@ -79,9 +78,15 @@ type postgresActivityCollector struct {
 	prepared   typedDesc
 	inflight   typedDesc
 	vacuums    typedDesc
+	apps       typedDesc
 	re         queryRegexp // regexps for queries classification
+	query      string      // query overriding built-in activity query
 }
 
+// defaultTopApplications defines default number of applications exposed in activity breakdown, connections of other
+// applications are accounted as 'other' application.
+const defaultTopApplications = 10
+
 // NewPostgresActivityCollector returns a new Collector exposing postgres activity stats.
 // For details see:
 //  1. https://www.postgresql.org/docs/current/monitoring-stats.html#PG-STAT-ACTIVITY-VIEW
@@ -142,13 +147,20 @@ func NewPostgresActivityCollector(constLabels labels, settings model.CollectorSe
 			[]string{"type"}, constLabels,
 			settings.Filters,
 		),
-		re: newQueryRegexp(),
+		apps: newBuiltinTypedDesc(
+			descOpts{"postgres", "activity", "application_connections_in_flight", "Number of connections in-flight of each application in each state, top applications only.", 0},
+			prometheus.GaugeValue,
+			[]string{"application", "state"}, constLabels,
+			settings.Filters,
+		),
+		re:    newQueryRegexp(),
+		query: settings.Query,
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		ch <- c.up.newConstMetric(0)
 		return err
@@ -156,7 +168,7 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 	defer conn.Close()
 
 	// get pg_stat_activity stats
-	res, err := conn.Query(selectActivityQuery(config.pgVersion.Numeric))
+	res, err := queryWithOverride(conn, "postgres/activity", c.query, selectActivityQuery(config.pgVersion.Numeric))
 	if err != nil {
 		return err
 	}
@@ -166,7 +178,7 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 
 	// get pg_prepared_xacts stats
 	var count int
-	err = conn.Conn().QueryRow(context.Background(), postgresPreparedXactQuery).Scan(&count)
+	err = conn.QueryRow(postgresPreparedXactQuery).Scan(&count)
 	if err != nil {
 		log.Warnf("query pg_prepared_xacts failed: %s; skip", err)
 	} else {
@@ -175,7 +187,7 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 
 	// get postmaster start time
 	var startTime float64
-	err = conn.Conn().QueryRow(context.Background(), postgresStartTimeQuery).Scan(&startTime)
+	err = conn.QueryRow(postgresStartTimeQuery).Scan(&startTime)
 	if err != nil {
 		log.Warnf("query postmaster start time failed: %s; skip", err)
 	} else {
@@ -221,6 +233,18 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 
 	ch <- c.statesAll.newConstMetric(total)
 
+	// connection states by application
+	topApps := config.CollectTopApplication
+	if topApps <= 0 {
+		topApps = defaultTopApplications
+	}
+
+	for app, states := range selectTopApplications(stats.applications, topApps) {
+		for state, v := range states {
+			ch <- c.apps.newConstMetric(v, app, state)
+		}
+	}
+
 	// prepared transactions
 	ch <- c.prepared.newConstMetric(stats.prepared)
 
@@ -267,6 +291,15 @@ func (c *postgresActivityCollector) Update(config Config, ch chan<- prometheus.M
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresActivityCollector) executedQueries(config Config) []string {
+	return []string{
+		queryOrOverride(c.query, selectActivityQuery(config.pgVersion.Numeric)),
+		postgresPreparedXactQuery,
+		postgresStartTimeQuery,
+	}
+}
+
 // queryRegexp used for keeping regexps for query classification.
 // It's created (compiled) at startup and used during program lifetime.
 type queryRegexp struct {
@@ -298,28 +331,29 @@ func newQueryRegexp() queryRegexp {
 
 // postgresActivityStat describes current activity
 type postgresActivityStat struct {
-	idle           map[string]float64 // state = 'idle'
-	idlexact       map[string]float64 // state IN ('idle in transaction', 'idle in transaction (aborted)'))
-	active         map[string]float64 // state = 'active'
-	other          map[string]float64 // state IN ('fastpath function call','disabled')
-	waiting        map[string]float64 // wait_event_type = 'Lock' (or waiting = 't')
-	waitEvents     map[string]float64 // wait_event_type/wait_event counters
-	prepared       float64            // FROM pg_prepared_xacts
-	maxIdleUser    map[string]float64 // longest duration among idle transactions opened by user/database
-	maxIdleMaint   map[string]float64 // longest duration among idle transactions initiated by maintenance operations (autovacuum, vacuum. analyze)
-	maxActiveUser  map[string]float64 // longest duration among client queries
-	maxActiveMaint map[string]float64 // longest duration among maintenance operations (autovacuum, vacuum. analyze)
-	maxWaitUser    map[string]float64 // longest duration being in waiting state (all activity)
-	maxWaitMaint   map[string]float64 // longest duration being in waiting state (all activity)
-	querySelect    float64            // number of select queries: SELECT, TABLE
-	queryMod       float64            // number of DML: INSERT, UPDATE, DELETE, TRUNCATE
-	queryDdl       float64            // number of DDL queries: CREATE, ALTER, DROP
-	queryMaint     float64            // number of maintenance queries: VACUUM, ANALYZE, CLUSTER, REINDEX, REFRESH, CHECKPOINT
-	queryWith      float64            // number of CTE queries
-	queryCopy      float64            // number of COPY queries
-	queryOther     float64            // number of queries of other types: BEGIN, END, COMMIT, ABORT, SET, etc...
-	vacuumOps      map[string]float64 // vacuum operations by type
-	startTime      float64            // unix time when postmaster has been started
+	idle           map[string]float64            // state = 'idle'
+	idlexact       map[string]float64            // state IN ('idle in transaction', 'idle in transaction (aborted)'))
+	active         map[string]float64            // state = 'active'
+	other          map[string]float64            // state IN ('fastpath function call','disabled')
+	waiting        map[string]float64            // wait_event_type = 'Lock' (or waiting = 't')
+	waitEvents     map[string]float64            // wait_event_type/wait_event counters
+	prepared       float64                       // FROM pg_prepared_xacts
+	maxIdleUser    map[string]float64            // longest duration among idle transactions opened by user/database
+	maxIdleMaint   map[string]float64            // longest duration among idle transactions initiated by maintenance operations (autovacuum, vacuum. analyze)
+	maxActiveUser  map[string]float64            // longest duration among client queries
+	maxActiveMaint map[string]float64            // longest duration among maintenance operations (autovacuum, vacuum. analyze)
+	maxWaitUser    map[string]float64            // longest duration being in waiting state (all activity)
+	maxWaitMaint   map[string]float64            // longest duration being in waiting state (all activity)
+	querySelect    float64                       // number of select queries: SELECT, TABLE
+	queryMod       float64                       // number of DML: INSERT, UPDATE, DELETE, TRUNCATE
+	queryDdl       float64                       // number of DDL queries: CREATE, ALTER, DROP
+	queryMaint     float64                       // number of maintenance queries: VACUUM, ANALYZE, CLUSTER, REINDEX, REFRESH, CHECKPOINT
+	queryWith      float64                       // number of CTE queries
+	queryCopy      float64                       // number of COPY queries
+	queryOther     float64                       // number of queries of other types: BEGIN, END, COMMIT, ABORT, SET, etc...
+	vacuumOps      map[string]float64            // vacuum operations by type
+	applications   map[string]map[string]float64 // number of client connections by application and state
+	startTime      float64                       // unix time when postmaster has been started
 
 	re queryRegexp // regexps used for query classification, it comes from postgresActivityCollector.
 }
@@ -370,6 +404,14 @@ func parsePostgresActivityStats(r *model.PGResult, re queryRegexp) postgresActiv
 	}
 
 	for _, row := range r.Rows {
+		// Account client connections by application, background daemons don't have database.
+		if appIdx, ok := colindexes["application"]; ok {
+			databaseIdx, stateIdx := colindexes["database"], colindexes["state"]
+			if row[databaseIdx].Valid && row[stateIdx].Valid {
+				stats.updateApplication(row[appIdx].String, row[stateIdx].String)
+			}
+		}
+
 		for i, colname := range r.Colnames {
 			// Skip empty (NULL) values.
 			if !row[i].Valid {
@@ -485,6 +527,80 @@ func (s *postgresActivityStat) updateState(usename, datname, state string) {
 	}
 }
 
+// updateApplication increments counter of application connections depending on passed state of the backend.
+func (s *postgresActivityStat) updateApplication(application, state string) {
+	if application == "" {
+		application = "unknown"
+	}
+
+	var tag string
+	switch state {
+	case stActive:
+		tag = "active"
+	case stIdle:
+		tag = "idle"
+	case stIdleXact, stIdleXactAborted:
+		tag = "idlexact"
+	default:
+		tag = "other"
+	}
+
+	if s.applications == nil {
+		s.applications = map[string]map[string]float64{}
+	}
+	if _, ok := s.applications[application]; !ok {
+		s.applications[application] = map[string]float64{}
+	}
+	s.applications[application][tag]++
+}
+
+// selectTopApplications returns applications with the most connections, connections of other applications are summed
+// into 'other' application.
+func selectTopApplications(applications map[string]map[string]float64, topN int) map[string]map[string]float64 {
+	if len(applications) <= topN {
+		return applications
+	}
+
+	type appTotal struct {
+		name  string
+		total float64
+	}
+
+	totals := make([]appTotal, 0, len(applications))
+	for name, states := range applications {
+		var total float64
+		for _, v := range states {
+			total += v
+		}
+		totals = append(totals, appTotal{name: name, total: total})
+	}
+
+	// Sort by name on equal totals, so selected applications are the same across scrapes.
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].total != totals[j].total {
+			return totals[i].total > totals[j].total
+		}
+		return totals[i].name < totals[j].name
+	})
+
+	top := make(map[string]map[string]float64, topN+1)
+	for i, t := range totals {
+		if i < topN {
+			top[t.name] = applications[t.name]
+			continue
+		}
+
+		if _, ok := top["other"]; !ok {
+			top["other"] = map[string]float64{}
+		}
+		for state, v := range applications[t.name] {
+			top["other"][state] += v
+		}
+	}
+
+	return top
+}
+
 // updateMaxIdletimeDuration updates max duration of idle transactions activity.
 func (s *postgresActivityStat) updateMaxIdletimeDuration(value, usename, datname, state, query string) {
 	// necessary values should not be empty (except wait_event_type)
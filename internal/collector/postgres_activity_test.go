@@ -20,6 +20,7 @@ func TestPostgresActivityCollector_Update(t *testing.T) {
 			"postgres_activity_prepared_transactions_in_flight",
 			"postgres_activity_queries_in_flight",
 			"postgres_activity_vacuums_in_flight",
+			"postgres_activity_application_connections_in_flight",
 		},
 		collector: NewPostgresActivityCollector,
 		service:   model.ServiceTypePostgresql,
@@ -216,6 +217,46 @@ func Test_parsePostgresActivityStats(t *testing.T) {
 				re:          testRE,
 			},
 		},
+		{
+			name: "application names",
+			res: &model.PGResult{
+				Nrows: 4,
+				Ncols: 7,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("user")},
+					{Name: []byte("database")},
+					{Name: []byte("application")},
+					{Name: []byte("state")},
+					{Name: []byte("active_seconds")},
+					{Name: []byte("waiting_seconds")},
+					{Name: []byte("query")},
+				},
+				Rows: [][]sql.NullString{
+					{{String: "testuser", Valid: true}, {String: "testdb", Valid: true}, {String: "app1", Valid: true}, {String: "active", Valid: true}, {String: "1", Valid: true}, {String: "0", Valid: true}, {String: "SELECT test", Valid: true}},
+					{{String: "testuser", Valid: true}, {String: "testdb", Valid: true}, {String: "app1", Valid: true}, {String: "idle", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "SELECT test", Valid: true}},
+					{{String: "testuser", Valid: true}, {String: "testdb", Valid: true}, {String: "", Valid: true}, {String: "idle in transaction", Valid: true}, {String: "1", Valid: true}, {String: "0", Valid: true}, {String: "SELECT test", Valid: true}},
+					{{String: "postgres", Valid: true}, {}, {String: "", Valid: true}, {String: "active", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "START_REPLICATION", Valid: true}},
+				},
+			},
+			want: postgresActivityStat{
+				waitEvents:  map[string]float64{},
+				maxIdleUser: map[string]float64{"testuser/testdb": 1}, maxIdleMaint: map[string]float64{},
+				maxActiveUser: map[string]float64{"testuser/testdb": 1}, maxActiveMaint: map[string]float64{},
+				maxWaitUser: map[string]float64{}, maxWaitMaint: map[string]float64{},
+				active:      map[string]float64{"testuser/testdb": 1},
+				idle:        map[string]float64{"testuser/testdb": 1},
+				idlexact:    map[string]float64{"testuser/testdb": 1},
+				other:       map[string]float64{},
+				waiting:     map[string]float64{},
+				querySelect: 1, queryOther: 1,
+				vacuumOps: map[string]float64{"regular": 0, "user": 0, "wraparound": 0},
+				applications: map[string]map[string]float64{
+					"app1":    {"active": 1, "idle": 1},
+					"unknown": {"idlexact": 1},
+				},
+				re: testRE,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -226,6 +267,24 @@ func Test_parsePostgresActivityStats(t *testing.T) {
 	}
 }
 
+func Test_selectTopApplications(t *testing.T) {
+	apps := map[string]map[string]float64{
+		"app1": {"active": 5, "idle": 5},
+		"app2": {"active": 1},
+		"app3": {"idle": 2},
+		"app4": {"idle": 2, "idlexact": 1},
+	}
+
+	// Number of applications doesn't exceed the limit.
+	assert.Equal(t, apps, selectTopApplications(apps, 10))
+
+	assert.Equal(t, map[string]map[string]float64{
+		"app1":  {"active": 5, "idle": 5},
+		"app4":  {"idle": 2, "idlexact": 1},
+		"other": {"active": 1, "idle": 2},
+	}, selectTopApplications(apps, 2))
+}
+
 func Test_selectActivityQuery(t *testing.T) {
 	testcases := []struct {
 		version int
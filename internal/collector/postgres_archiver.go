@@ -6,7 +6,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -20,6 +19,7 @@ type postgresWalArchivingCollector struct {
 	failed               typedDesc
 	sinceArchivedSeconds typedDesc
 	archivingLag         typedDesc
+	query                string
 }
 
 // NewPostgresWalArchivingCollector returns a new Collector exposing postgres WAL archiving stats.
@@ -50,12 +50,13 @@ func NewPostgresWalArchivingCollector(constLabels labels, settings model.Collect
 			nil, constLabels,
 			settings.Filters,
 		),
+		query: settings.Query,
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -66,7 +67,7 @@ func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- promethe
 		return nil
 	}
 
-	res, err := conn.Query(walArchivingQuery)
+	res, err := queryWithOverride(conn, "postgres/archiver", c.query, walArchivingQuery)
 	if err != nil {
 		return err
 	}
@@ -86,6 +87,14 @@ func (c *postgresWalArchivingCollector) Update(config Config, ch chan<- promethe
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresWalArchivingCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV12 {
+		return nil
+	}
+	return []string{queryOrOverride(c.query, walArchivingQuery)}
+}
+
 // postgresWalArchivingStat describes stats about WAL archiving.
 type postgresWalArchivingStat struct {
 	archived             float64
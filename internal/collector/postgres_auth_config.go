@@ -0,0 +1,143 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// postgresHbaRulesQuery defines query for summarizing pg_hba.conf rules. The hash is calculated over all rules
+	// as they are parsed by Postgres, hence it changes after every meaningful edit of the file.
+	postgresHbaRulesQuery = "SELECT 'pg_hba' AS file, count(*) AS rules, count(*) FILTER (WHERE error IS NOT NULL) AS errors, " +
+		"COALESCE(md5(string_agg(r::text, E'\\n' ORDER BY line_number)), '') AS hash FROM pg_hba_file_rules r"
+
+	// postgresIdentMappingsQuery defines query for summarizing pg_ident.conf mappings (since Postgres 15).
+	postgresIdentMappingsQuery = "SELECT 'pg_ident' AS file, count(*) AS rules, count(*) FILTER (WHERE error IS NOT NULL) AS errors, " +
+		"COALESCE(md5(string_agg(m::text, E'\\n' ORDER BY line_number)), '') AS hash FROM pg_ident_file_mappings m"
+)
+
+// postgresAuthConfigCollector defines metric descriptors.
+type postgresAuthConfigCollector struct {
+	rules  typedDesc
+	errors typedDesc
+	info   typedDesc
+}
+
+// NewPostgresAuthConfigCollector returns a new Collector exposing summary of authentication configuration files
+// (pg_hba.conf and pg_ident.conf) as they are currently read from disk. Errors in files are detected before reload.
+// For details see https://www.postgresql.org/docs/current/view-pg-hba-file-rules.html
+func NewPostgresAuthConfigCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresAuthConfigCollector{
+		rules: newBuiltinTypedDesc(
+			descOpts{"postgres", "auth_config", "rules", "Number of rules in authentication configuration file.", 0},
+			prometheus.GaugeValue,
+			[]string{"file"}, constLabels,
+			settings.Filters,
+		),
+		errors: newBuiltinTypedDesc(
+			descOpts{"postgres", "auth_config", "errors", "Number of rules with errors in authentication configuration file.", 0},
+			prometheus.GaugeValue,
+			[]string{"file"}, constLabels,
+			settings.Filters,
+		),
+		info: newBuiltinTypedDesc(
+			descOpts{"postgres", "auth_config", "info", "Labeled information about authentication configuration file contents.", 0},
+			prometheus.GaugeValue,
+			[]string{"file", "hash"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresAuthConfigCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.pgVersion.Numeric < PostgresV10 {
+		log.Debugln("[postgres auth_config collector]: pg_hba_file_rules view is not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	queries := []string{postgresHbaRulesQuery}
+	if config.pgVersion.Numeric >= PostgresV15 {
+		queries = append(queries, postgresIdentMappingsQuery)
+	}
+
+	for _, query := range queries {
+		res, err := conn.Query(query)
+		if err != nil {
+			log.Warnf("get authentication config summary failed: %s; skip", err)
+			continue
+		}
+
+		for _, stat := range parsePostgresAuthConfigStats(res) {
+			ch <- c.rules.newConstMetric(stat.rules, stat.file)
+			ch <- c.errors.newConstMetric(stat.errors, stat.file)
+			ch <- c.info.newConstMetric(1, stat.file, stat.hash)
+		}
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresAuthConfigCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV10 {
+		return nil
+	}
+	if config.pgVersion.Numeric < PostgresV15 {
+		return []string{postgresHbaRulesQuery}
+	}
+	return []string{postgresHbaRulesQuery, postgresIdentMappingsQuery}
+}
+
+// postgresAuthConfigStat represents summary of authentication configuration file.
+type postgresAuthConfigStat struct {
+	file   string
+	hash   string
+	rules  float64
+	errors float64
+}
+
+// parsePostgresAuthConfigStats parses PGResult and returns structs with stats values.
+func parsePostgresAuthConfigStats(r *model.PGResult) []postgresAuthConfigStat {
+	log.Debug("parse postgres auth config stats")
+
+	var stats []postgresAuthConfigStat
+
+	for _, row := range r.Rows {
+		var stat postgresAuthConfigStat
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "file":
+				stat.file = row[i].String
+			case "hash":
+				stat.hash = row[i].String
+			case "rules", "errors":
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				if string(colname.Name) == "rules" {
+					stat.rules = v
+				} else {
+					stat.errors = v
+				}
+			}
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresAuthConfigCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_auth_config_rules",
+			"postgres_auth_config_errors",
+			"postgres_auth_config_info",
+		},
+		collector: NewPostgresAuthConfigCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresAuthConfigStats(t *testing.T) {
+	var testCases = []struct {
+		name string
+		res  *model.PGResult
+		want []postgresAuthConfigStat
+	}{
+		{
+			name: "normal output",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 4,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("file")}, {Name: []byte("rules")}, {Name: []byte("errors")}, {Name: []byte("hash")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "pg_hba", Valid: true}, {String: "12", Valid: true}, {String: "1", Valid: true},
+						{String: "0cc175b9c0f1b6a831c399e269772661", Valid: true},
+					},
+				},
+			},
+			want: []postgresAuthConfigStat{
+				{file: "pg_hba", rules: 12, errors: 1, hash: "0cc175b9c0f1b6a831c399e269772661"},
+			},
+		},
+		{
+			name: "empty file",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 4,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("file")}, {Name: []byte("rules")}, {Name: []byte("errors")}, {Name: []byte("hash")},
+				},
+				Rows: [][]sql.NullString{
+					{{String: "pg_ident", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "", Valid: true}},
+				},
+			},
+			want: []postgresAuthConfigStat{{file: "pg_ident"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, parsePostgresAuthConfigStats(tc.res))
+		})
+	}
+}
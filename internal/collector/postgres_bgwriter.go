@@ -6,7 +6,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -135,7 +134,7 @@ func NewPostgresBgwriterCollector(constLabels labels, settings model.CollectorSe
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresBgwriterCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -196,6 +195,11 @@ func (c *postgresBgwriterCollector) Update(config Config, ch chan<- prometheus.M
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresBgwriterCollector) executedQueries(config Config) []string {
+	return []string{selectBgwriterQuery(config.pgVersion.Numeric)}
+}
+
 // postgresBgwriterStat describes stats related to Postgres background writes.
 type postgresBgwriterStat struct {
 	ckptTimed              float64
@@ -0,0 +1,392 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// defaultBuffercacheTTL defines default interval during which pg_buffercache stats are reused between scrapes.
+	// Inspecting pg_buffercache requires scanning all shared buffers, hence it should not be done on every scrape.
+	defaultBuffercacheTTL = 5 * time.Minute
+
+	// buffercacheTopRelations defines number of relations with the most buffers exposed by collector.
+	buffercacheTopRelations = 20
+
+	// postgresBuffercacheQuery defines query for summarizing shared buffers by database and usage count. Unused buffers
+	// have no database and usage count, buffers of shared catalogs are reported as 'global' database.
+	postgresBuffercacheQuery = "SELECT CASE WHEN b.reldatabase = 0 THEN 'global' ELSE d.datname END AS database, " +
+		"b.usagecount, count(*) AS buffers, count(*) FILTER (WHERE b.isdirty) AS dirty " +
+		"FROM %s.pg_buffercache b LEFT JOIN pg_database d ON d.oid = b.reldatabase GROUP BY 1, 2"
+
+	// postgresBuffercacheRelationsQuery defines query for selecting relations of current database with the most buffers.
+	postgresBuffercacheRelationsQuery = "SELECT current_database() AS database, n.nspname AS schema, c.relname AS relation, " +
+		"count(*) AS buffers, count(*) FILTER (WHERE b.isdirty) AS dirty " +
+		"FROM %s.pg_buffercache b JOIN pg_class c ON b.relfilenode = pg_relation_filenode(c.oid) " +
+		"JOIN pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE b.reldatabase IN (0, (SELECT oid FROM pg_database WHERE datname = current_database())) " +
+		"GROUP BY 1, 2, 3 ORDER BY 4 DESC LIMIT %d"
+)
+
+// postgresBuffercacheCollector defines metric descriptors and stats store.
+type postgresBuffercacheCollector struct {
+	buffers         typedDesc
+	dirty           typedDesc
+	unused          typedDesc
+	usagecount      typedDesc
+	relationBuffers typedDesc
+	relationDirty   typedDesc
+	// stats keeps stats collected during the last inspection of pg_buffercache.
+	stats       postgresBuffercacheStats
+	lastUpdated time.Time
+	// cached is true when stats have been reused during the last update.
+	cached bool
+	mu     sync.Mutex
+}
+
+// NewPostgresBuffercacheCollector returns a new Collector exposing shared buffers content using pg_buffercache extension.
+// Collector is enabled only if extension is installed in the database used for connection. Collected stats are reused
+// during buffercache_ttl because inspecting shared buffers is expensive.
+// For details see https://www.postgresql.org/docs/current/pgbuffercache.html
+func NewPostgresBuffercacheCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresBuffercacheCollector{
+		buffers: newBuiltinTypedDesc(
+			descOpts{"postgres", "buffercache", "buffers", "Number of shared buffers used by relations of the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		dirty: newBuiltinTypedDesc(
+			descOpts{"postgres", "buffercache", "dirty_buffers", "Number of dirty shared buffers used by relations of the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		unused: newBuiltinTypedDesc(
+			descOpts{"postgres", "buffercache", "unused_buffers", "Number of unused shared buffers.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		usagecount: newBuiltinTypedDesc(
+			descOpts{"postgres", "buffercache", "usagecount_buffers", "Number of used shared buffers by clock-sweep usage count.", 0},
+			prometheus.GaugeValue,
+			[]string{"usagecount"}, constLabels,
+			settings.Filters,
+		),
+		relationBuffers: newBuiltinTypedDesc(
+			descOpts{"postgres", "buffercache", "relation_buffers", "Number of shared buffers used by the relation, top relations only.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "relation"}, constLabels,
+			settings.Filters,
+		),
+		relationDirty: newBuiltinTypedDesc(
+			descOpts{"postgres", "buffercache", "relation_dirty_buffers", "Number of dirty shared buffers used by the relation, top relations only.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "relation"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresBuffercacheCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	ttl := config.BuffercacheTTL
+	if ttl <= 0 {
+		ttl = defaultBuffercacheTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cached = true
+	if config.bypassCache || c.lastUpdated.IsZero() || time.Since(c.lastUpdated) >= ttl {
+		c.cached = false
+		stats, ok, err := queryPostgresBuffercacheStats(config)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		c.stats = stats
+		c.lastUpdated = time.Now()
+	}
+
+	for database, v := range c.stats.databases {
+		ch <- c.buffers.newConstMetric(v.buffers, database)
+		ch <- c.dirty.newConstMetric(v.dirty, database)
+	}
+
+	ch <- c.unused.newConstMetric(c.stats.unused)
+
+	for usagecount, v := range c.stats.usagecounts {
+		ch <- c.usagecount.newConstMetric(v, usagecount)
+	}
+
+	for _, rel := range c.stats.relations {
+		ch <- c.relationBuffers.newConstMetric(rel.buffers, rel.database, rel.schema, rel.relation)
+		ch <- c.relationDirty.newConstMetric(rel.dirty, rel.database, rel.schema, rel.relation)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresBuffercacheCollector) executedQueries(config Config) []string {
+	schema, ok := config.extensionsSchemas["pg_buffercache"]
+	if !ok {
+		return []string{postgresExtensionSchemaQuery}
+	}
+	return []string{
+		postgresExtensionSchemaQuery,
+		fmt.Sprintf(postgresBuffercacheQuery, schema),
+		fmt.Sprintf(postgresBuffercacheRelationsQuery, schema, buffercacheTopRelations),
+	}
+}
+
+// snapshot implements snapshotCollector interface.
+func (c *postgresBuffercacheCollector) snapshot() (CachedStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := postgresBuffercacheSnapshot{
+		Databases:   map[string]postgresBuffercacheUsageSnapshot{},
+		Usagecounts: c.stats.usagecounts,
+		Unused:      c.stats.unused,
+	}
+	for database, v := range c.stats.databases {
+		stats.Databases[database] = postgresBuffercacheUsageSnapshot{Buffers: v.buffers, Dirty: v.dirty}
+	}
+	for _, rel := range c.stats.relations {
+		stats.Relations = append(stats.Relations, postgresBuffercacheRelationSnapshot{
+			Database: rel.database, Schema: rel.schema, Relation: rel.relation,
+			Buffers: rel.buffers, Dirty: rel.dirty,
+		})
+	}
+
+	return marshalCachedStats(c.lastUpdated, stats)
+}
+
+// restore implements snapshotCollector interface.
+func (c *postgresBuffercacheCollector) restore(s CachedStats) error {
+	var stats postgresBuffercacheSnapshot
+	err := json.Unmarshal(s.Stats, &stats)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = postgresBuffercacheStats{
+		databases:   map[string]postgresBuffercacheUsage{},
+		usagecounts: stats.Usagecounts,
+		unused:      stats.Unused,
+	}
+	for database, v := range stats.Databases {
+		c.stats.databases[database] = postgresBuffercacheUsage{buffers: v.Buffers, dirty: v.Dirty}
+	}
+	for _, rel := range stats.Relations {
+		c.stats.relations = append(c.stats.relations, postgresBuffercacheRelation{
+			database: rel.Database, schema: rel.Schema, relation: rel.Relation,
+			postgresBuffercacheUsage: postgresBuffercacheUsage{buffers: rel.Buffers, dirty: rel.Dirty},
+		})
+	}
+	c.lastUpdated = s.Updated
+
+	return nil
+}
+
+// dataAge implements cachedCollector interface.
+func (c *postgresBuffercacheCollector) dataAge() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.cached || c.lastUpdated.IsZero() {
+		return 0, false
+	}
+	return time.Since(c.lastUpdated), true
+}
+
+// queryPostgresBuffercacheStats inspects pg_buffercache and returns collected stats. False is returned when extension
+// is not installed.
+func queryPostgresBuffercacheStats(config Config) (postgresBuffercacheStats, bool, error) {
+	var stats postgresBuffercacheStats
+
+	conn, err := config.connect()
+	if err != nil {
+		return stats, false, err
+	}
+	defer conn.Close()
+
+	schema := extensionInstalledSchema(conn, "pg_buffercache")
+	if schema == "" {
+		log.Debugln("[postgres buffercache collector]: pg_buffercache extension is not installed, skip")
+		return stats, false, nil
+	}
+
+	res, err := conn.Query(fmt.Sprintf(postgresBuffercacheQuery, schema))
+	if err != nil {
+		return stats, false, err
+	}
+
+	stats = parsePostgresBuffercacheStats(res)
+
+	res, err = conn.Query(fmt.Sprintf(postgresBuffercacheRelationsQuery, schema, buffercacheTopRelations))
+	if err != nil {
+		log.Warnf("get pg_buffercache relations stats failed: %s; skip", err)
+	} else {
+		stats.relations = parsePostgresBuffercacheRelationsStats(res)
+	}
+
+	return stats, true, nil
+}
+
+// postgresBuffercacheStats represents summary of shared buffers content.
+type postgresBuffercacheStats struct {
+	databases   map[string]postgresBuffercacheUsage
+	usagecounts map[string]float64
+	unused      float64
+	relations   []postgresBuffercacheRelation
+}
+
+// postgresBuffercacheUsage represents number of all and dirty buffers.
+type postgresBuffercacheUsage struct {
+	buffers float64
+	dirty   float64
+}
+
+// postgresBuffercacheRelation represents number of buffers used by relation.
+type postgresBuffercacheRelation struct {
+	database string
+	schema   string
+	relation string
+	postgresBuffercacheUsage
+}
+
+// postgresBuffercacheSnapshot represents postgresBuffercacheStats persisted in snapshot of cached stats.
+type postgresBuffercacheSnapshot struct {
+	Databases   map[string]postgresBuffercacheUsageSnapshot `json:"databases"`
+	Usagecounts map[string]float64                          `json:"usagecounts"`
+	Unused      float64                                     `json:"unused"`
+	Relations   []postgresBuffercacheRelationSnapshot       `json:"relations"`
+}
+
+// postgresBuffercacheUsageSnapshot represents postgresBuffercacheUsage persisted in snapshot of cached stats.
+type postgresBuffercacheUsageSnapshot struct {
+	Buffers float64 `json:"buffers"`
+	Dirty   float64 `json:"dirty"`
+}
+
+// postgresBuffercacheRelationSnapshot represents postgresBuffercacheRelation persisted in snapshot of cached stats.
+type postgresBuffercacheRelationSnapshot struct {
+	Database string  `json:"database"`
+	Schema   string  `json:"schema"`
+	Relation string  `json:"relation"`
+	Buffers  float64 `json:"buffers"`
+	Dirty    float64 `json:"dirty"`
+}
+
+// parsePostgresBuffercacheStats parses PGResult and returns summary of shared buffers content.
+func parsePostgresBuffercacheStats(r *model.PGResult) postgresBuffercacheStats {
+	log.Debug("parse postgres buffercache stats")
+
+	stats := postgresBuffercacheStats{
+		databases:   map[string]postgresBuffercacheUsage{},
+		usagecounts: map[string]float64{},
+	}
+
+	for _, row := range r.Rows {
+		var (
+			database, usagecount string
+			usage                postgresBuffercacheUsage
+		)
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				database = row[i].String
+			case "usagecount":
+				usagecount = row[i].String
+			case "buffers", "dirty":
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				if string(colname.Name) == "buffers" {
+					usage.buffers = v
+				} else {
+					usage.dirty = v
+				}
+			}
+		}
+
+		// Buffers without usage count are not used by any relation.
+		if usagecount == "" {
+			stats.unused += usage.buffers
+			continue
+		}
+
+		stats.usagecounts[usagecount] += usage.buffers
+
+		// Buffers of relations from dropped databases are not attributed to any database.
+		if database == "" {
+			continue
+		}
+
+		s := stats.databases[database]
+		s.buffers += usage.buffers
+		s.dirty += usage.dirty
+		stats.databases[database] = s
+	}
+
+	return stats
+}
+
+// parsePostgresBuffercacheRelationsStats parses PGResult and returns number of buffers used by relations.
+func parsePostgresBuffercacheRelationsStats(r *model.PGResult) []postgresBuffercacheRelation {
+	log.Debug("parse postgres buffercache relations stats")
+
+	var stats []postgresBuffercacheRelation
+
+	for _, row := range r.Rows {
+		var stat postgresBuffercacheRelation
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				stat.database = row[i].String
+			case "schema":
+				stat.schema = row[i].String
+			case "relation":
+				stat.relation = row[i].String
+			case "buffers", "dirty":
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				if string(colname.Name) == "buffers" {
+					stat.buffers = v
+				} else {
+					stat.dirty = v
+				}
+			}
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresBuffercacheCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_buffercache_buffers",
+			"postgres_buffercache_dirty_buffers",
+			"postgres_buffercache_unused_buffers",
+			"postgres_buffercache_usagecount_buffers",
+			"postgres_buffercache_relation_buffers",
+			"postgres_buffercache_relation_dirty_buffers",
+		},
+		collector: NewPostgresBuffercacheCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresBuffercacheStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 5,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("usagecount")}, {Name: []byte("buffers")}, {Name: []byte("dirty")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "postgres", Valid: true}, {String: "1", Valid: true}, {String: "100", Valid: true}, {String: "10", Valid: true}},
+			{{String: "postgres", Valid: true}, {String: "5", Valid: true}, {String: "50", Valid: true}, {String: "0", Valid: true}},
+			{{String: "global", Valid: true}, {String: "5", Valid: true}, {String: "20", Valid: true}, {String: "1", Valid: true}},
+			{{String: "", Valid: false}, {String: "1", Valid: true}, {String: "3", Valid: true}, {String: "0", Valid: true}},
+			{{String: "", Valid: false}, {String: "", Valid: false}, {String: "16000", Valid: true}, {String: "0", Valid: true}},
+		},
+	}
+
+	want := postgresBuffercacheStats{
+		databases: map[string]postgresBuffercacheUsage{
+			"postgres": {buffers: 150, dirty: 10},
+			"global":   {buffers: 20, dirty: 1},
+		},
+		usagecounts: map[string]float64{"1": 103, "5": 70},
+		unused:      16000,
+	}
+
+	assert.Equal(t, want, parsePostgresBuffercacheStats(res))
+}
+
+func Test_parsePostgresBuffercacheRelationsStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 5,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("relation")}, {Name: []byte("buffers")}, {Name: []byte("dirty")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "pgscv_fixtures", Valid: true}, {String: "public", Valid: true}, {String: "orders", Valid: true}, {String: "1200", Valid: true}, {String: "15", Valid: true}},
+			{{String: "pgscv_fixtures", Valid: true}, {String: "pg_catalog", Valid: true}, {String: "pg_class", Valid: true}, {String: "30", Valid: true}, {String: "0", Valid: true}},
+		},
+	}
+
+	want := []postgresBuffercacheRelation{
+		{database: "pgscv_fixtures", schema: "public", relation: "orders", postgresBuffercacheUsage: postgresBuffercacheUsage{buffers: 1200, dirty: 15}},
+		{database: "pgscv_fixtures", schema: "pg_catalog", relation: "pg_class", postgresBuffercacheUsage: postgresBuffercacheUsage{buffers: 30, dirty: 0}},
+	}
+
+	assert.Equal(t, want, parsePostgresBuffercacheRelationsStats(res))
+}
+
+func Test_postgresBuffercacheCollector_dataAge(t *testing.T) {
+	c := &postgresBuffercacheCollector{}
+	_, cached := c.dataAge()
+	assert.False(t, cached)
+
+	c.lastUpdated = time.Now().Add(-time.Minute)
+	c.cached = true
+	age, cached := c.dataAge()
+	assert.True(t, cached)
+	assert.GreaterOrEqual(t, age, time.Minute)
+}
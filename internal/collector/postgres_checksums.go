@@ -0,0 +1,441 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// postgresChecksumsQuery defines query for getting data checksums status and current WAL position. WAL position
+	// is used for skipping pages modified during verification.
+	postgresChecksumsQuery = "SELECT current_setting('data_checksums') = 'on' AS enabled, " +
+		"COALESCE(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END - '0/0', 0)::text AS lsn"
+
+	// postgresChecksumsDatabasesQuery defines query for getting databases OIDs.
+	postgresChecksumsDatabasesQuery = "SELECT oid, datname FROM pg_database WHERE datallowconn"
+
+	// relsegSize defines size of relation segment file in bytes.
+	relsegSize = 1024 * 1024 * 1024
+
+	// checksumNSums defines number of checksums calculated in parallel by Postgres checksum algorithm.
+	checksumNSums = 32
+	// checksumFNVPrime defines prime used by Postgres checksum algorithm.
+	checksumFNVPrime = 16777619
+)
+
+// checksumBaseOffsets defines base offsets for initializing checksum sums, see src/include/storage/checksum_impl.h.
+var checksumBaseOffsets = [checksumNSums]uint32{
+	0x5B1F36E9, 0xB8525960, 0x02AB50AA, 0x1DE66D2A, 0x79FF467A, 0x9BB9F8A3, 0x217E7CD2, 0x83E13D2C,
+	0xF8D4474F, 0xE39EB970, 0x42C6AE16, 0x993216FA, 0x7B093B5D, 0x98DAFF3C, 0xF718902A, 0x0B1C9CDB,
+	0xE58F764B, 0x187636BC, 0x5D7B3BB1, 0xE73DE7DE, 0x92BEC979, 0xCCA6C0B2, 0x304A0979, 0x85AA43D4,
+	0x783125BB, 0x6CA8EAA2, 0xE407EAC6, 0x4B5CFC3E, 0x9FBF8C76, 0x15CA20BE, 0xF2CA9FFF, 0x3ED7A3D1,
+}
+
+// reRelationFile defines regexp for relation data files (including forks and segments) in database directory.
+var reRelationFile = regexp.MustCompile(`^(\d+)(_fsm|_vm|_init)?(\.(\d+))?$`)
+
+// postgresChecksumsCollector defines metric descriptors and state of data files verification.
+type postgresChecksumsCollector struct {
+	enabled        typedDesc
+	pages          typedDesc
+	failures       typedDesc
+	lastRun        typedDesc
+	duration       typedDesc
+	verifyMu       sync.Mutex
+	verifyRunning  bool
+	verifyLastTime time.Time
+	verifyStats    map[string]checksumsVerifyStat
+	verifyDuration float64
+}
+
+// checksumsVerifyStat defines per-database results of data files verification.
+type checksumsVerifyStat struct {
+	pages    float64
+	failures float64
+}
+
+// NewPostgresChecksumsCollector returns a new Collector exposing data checksums status and results of data files
+// verification.
+func NewPostgresChecksumsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresChecksumsCollector{
+		verifyStats: map[string]checksumsVerifyStat{},
+		enabled: newBuiltinTypedDesc(
+			descOpts{"postgres", "checksums", "enabled", "Value is 1 if data checksums are enabled, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		pages: newBuiltinTypedDesc(
+			descOpts{"postgres", "checksums", "verified_pages_total", "Total number of data pages verified by pgSCV.", 0},
+			prometheus.CounterValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		failures: newBuiltinTypedDesc(
+			descOpts{"postgres", "checksums", "verify_failures_total", "Total number of data pages with invalid checksum found by pgSCV.", 0},
+			prometheus.CounterValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		lastRun: newBuiltinTypedDesc(
+			descOpts{"postgres", "checksums", "last_verify_seconds", "Time when the last data files verification has been finished, in unixtime.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		duration: newBuiltinTypedDesc(
+			descOpts{"postgres", "checksums", "last_verify_duration_seconds", "Duration of the last data files verification, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresChecksumsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.pgVersion.Numeric < PostgresV10 {
+		log.Debugln("[postgres checksums collector]: some system functions are not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var (
+		enabled bool
+		lsn     string
+	)
+	err = conn.QueryRow(postgresChecksumsQuery).Scan(&enabled, &lsn)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		ch <- c.enabled.newConstMetric(1)
+	} else {
+		ch <- c.enabled.newConstMetric(0)
+	}
+
+	// Data files verification is optional and possible only for local services with enabled checksums.
+	if !enabled || config.ChecksumsVerifyInterval <= 0 || !config.localService || config.blockSize == 0 {
+		return nil
+	}
+
+	startLSN, err := strconv.ParseUint(lsn, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	c.verifyMu.Lock()
+	if !c.verifyRunning && time.Since(c.verifyLastTime) >= config.ChecksumsVerifyInterval {
+		databases, err := listDatabasesOIDs(conn)
+		if err != nil {
+			c.verifyMu.Unlock()
+			return err
+		}
+
+		c.verifyRunning = true
+		go c.verify(config, databases, startLSN)
+	}
+
+	for database, stat := range c.verifyStats {
+		ch <- c.pages.newConstMetric(stat.pages, database)
+		ch <- c.failures.newConstMetric(stat.failures, database)
+	}
+	if !c.verifyLastTime.IsZero() {
+		ch <- c.lastRun.newConstMetric(float64(c.verifyLastTime.Unix()))
+		ch <- c.duration.newConstMetric(c.verifyDuration)
+	}
+	c.verifyMu.Unlock()
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresChecksumsCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV10 {
+		return nil
+	}
+	if config.ChecksumsVerifyInterval <= 0 || !config.localService {
+		return []string{postgresChecksumsQuery}
+	}
+	return []string{postgresChecksumsQuery, postgresChecksumsDatabasesQuery}
+}
+
+// verify walks through data files of all databases and verifies checksums of data pages. Reading is throttled
+// according to ChecksumsVerifyRate setting.
+func (c *postgresChecksumsCollector) verify(config Config, databases map[string]string, startLSN uint64) {
+	start := time.Now()
+	log.Infoln("[postgres checksums collector]: data files verification started")
+
+	blockSize := int(config.blockSize) // #nosec G115
+
+	limiter := rate.NewLimiter(rate.Inf, blockSize)
+	if config.ChecksumsVerifyRate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.ChecksumsVerifyRate), max(config.ChecksumsVerifyRate, blockSize))
+	}
+
+	stats := map[string]checksumsVerifyStat{}
+	for oid, datname := range databases {
+		stat, err := verifyDatabaseChecksums(filepath.Join(config.dataDirectory, "base", oid), blockSize, startLSN, limiter)
+		if err != nil {
+			log.Warnf("[postgres checksums collector]: verify database %s failed: %s; skip", datname, err)
+		}
+		stats[datname] = stat
+	}
+
+	c.verifyMu.Lock()
+	for datname, stat := range stats {
+		s := c.verifyStats[datname]
+		s.pages += stat.pages
+		s.failures += stat.failures
+		c.verifyStats[datname] = s
+	}
+	c.verifyRunning = false
+	c.verifyLastTime = time.Now()
+	c.verifyDuration = time.Since(start).Seconds()
+	c.verifyMu.Unlock()
+
+	log.Infof("[postgres checksums collector]: data files verification finished in %s", time.Since(start))
+}
+
+// postgresChecksumsSnapshot defines results of data files verification persisted across restarts.
+type postgresChecksumsSnapshot struct {
+	Duration  float64                            `json:"duration"`
+	Databases map[string]checksumsVerifySnapshot `json:"databases"`
+}
+
+// checksumsVerifySnapshot defines per-database results of data files verification persisted across restarts.
+type checksumsVerifySnapshot struct {
+	Pages    float64 `json:"pages"`
+	Failures float64 `json:"failures"`
+}
+
+// snapshot implements snapshotCollector interface.
+func (c *postgresChecksumsCollector) snapshot() (CachedStats, bool) {
+	c.verifyMu.Lock()
+	defer c.verifyMu.Unlock()
+
+	s := postgresChecksumsSnapshot{Duration: c.verifyDuration, Databases: map[string]checksumsVerifySnapshot{}}
+	for datname, stat := range c.verifyStats {
+		s.Databases[datname] = checksumsVerifySnapshot{Pages: stat.pages, Failures: stat.failures}
+	}
+
+	return marshalCachedStats(c.verifyLastTime, s)
+}
+
+// restore implements snapshotCollector interface. Verification is not started again until verification interval is
+// passed since the restored verification.
+func (c *postgresChecksumsCollector) restore(cs CachedStats) error {
+	var s postgresChecksumsSnapshot
+	err := json.Unmarshal(cs.Stats, &s)
+	if err != nil {
+		return err
+	}
+
+	c.verifyMu.Lock()
+	defer c.verifyMu.Unlock()
+
+	c.verifyStats = map[string]checksumsVerifyStat{}
+	for datname, stat := range s.Databases {
+		c.verifyStats[datname] = checksumsVerifyStat{pages: stat.Pages, failures: stat.Failures}
+	}
+	c.verifyLastTime = cs.Updated
+	c.verifyDuration = s.Duration
+
+	return nil
+}
+
+// verifyDatabaseChecksums verifies checksums of pages in data files of the database directory.
+func verifyDatabaseChecksums(dir string, blockSize int, startLSN uint64, limiter *rate.Limiter) (checksumsVerifyStat, error) {
+	var stat checksumsVerifyStat
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return stat, err
+	}
+
+	for _, e := range entries {
+		parts := reRelationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || parts == nil {
+			continue
+		}
+
+		var segno uint64
+		if parts[4] != "" {
+			segno, err = strconv.ParseUint(parts[4], 10, 32)
+			if err != nil {
+				continue
+			}
+		}
+
+		pages, failures, err := verifyFileChecksums(filepath.Join(dir, e.Name()), blockSize, segno, startLSN, limiter)
+		if err != nil {
+			// Relations could be dropped during verification.
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Warnf("[postgres checksums collector]: verify file %s failed: %s; skip", e.Name(), err)
+			}
+			continue
+		}
+
+		stat.pages += pages
+		stat.failures += failures
+	}
+
+	return stat, nil
+}
+
+// verifyFileChecksums verifies checksums of pages in the single data file. Pages modified after verification has been
+// started (concurrently written pages) are skipped.
+func verifyFileChecksums(path string, blockSize int, segno uint64, startLSN uint64, limiter *rate.Limiter) (float64, float64, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var (
+		pages, failures float64
+		page            = make([]byte, blockSize)
+		firstBlock      = segno * uint64(relsegSize/blockSize) // #nosec G115
+	)
+
+	for blkno := firstBlock; ; blkno++ {
+		err = limiter.WaitN(context.Background(), blockSize)
+		if err != nil {
+			return pages, failures, err
+		}
+
+		_, err = io.ReadFull(f, page)
+		if err != nil {
+			// Partially written last page is skipped, it is going to be written completely.
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return pages, failures, nil
+			}
+			return pages, failures, err
+		}
+
+		// Skip new (uninitialized) pages and pages modified since verification has been started.
+		if pageIsNew(page) || pageLSN(page) >= startLSN {
+			continue
+		}
+
+		pages++
+
+		if pageChecksum(page, uint32(blkno)) == pageStoredChecksum(page) { // #nosec G115
+			continue
+		}
+
+		// Page might be torn by concurrent write, re-read it and check again.
+		_, err = f.ReadAt(page, int64((blkno-firstBlock)*uint64(blockSize))) // #nosec G115
+		if err != nil {
+			return pages, failures, err
+		}
+
+		if pageIsNew(page) || pageLSN(page) >= startLSN || pageChecksum(page, uint32(blkno)) == pageStoredChecksum(page) { // #nosec G115
+			continue
+		}
+
+		log.Warnf("[postgres checksums collector]: checksum verification failed in file %s, block %d", path, blkno)
+		failures++
+	}
+}
+
+// listDatabasesOIDs returns OIDs and names of databases which allow connections.
+func listDatabasesOIDs(db *store.DB) (map[string]string, error) {
+	res, err := db.Query(postgresChecksumsDatabasesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make(map[string]string, res.Nrows)
+	for _, row := range res.Rows {
+		if len(row) != 2 {
+			return nil, fmt.Errorf("invalid input: wrong number of columns")
+		}
+		databases[row[0].String] = row[1].String
+	}
+
+	return databases, nil
+}
+
+// pageIsNew returns true if page is not initialized yet (pd_upper is zero).
+func pageIsNew(page []byte) bool {
+	return binary.NativeEndian.Uint16(page[14:16]) == 0
+}
+
+// pageLSN returns LSN of the last change of the page (pd_lsn).
+func pageLSN(page []byte) uint64 {
+	return uint64(binary.NativeEndian.Uint32(page[0:4]))<<32 | uint64(binary.NativeEndian.Uint32(page[4:8]))
+}
+
+// pageStoredChecksum returns checksum stored in page header (pd_checksum).
+func pageStoredChecksum(page []byte) uint16 {
+	return binary.NativeEndian.Uint16(page[8:10])
+}
+
+// pageChecksum calculates checksum of the page, it is the Go implementation of pg_checksum_page() function,
+// see src/include/storage/checksum_impl.h.
+func pageChecksum(page []byte, blkno uint32) uint16 {
+	var sums [checksumNSums]uint32
+	copy(sums[:], checksumBaseOffsets[:])
+
+	comp := func(sum, value uint32) uint32 {
+		tmp := sum ^ value
+		return tmp*checksumFNVPrime ^ (tmp >> 17)
+	}
+
+	for i := 0; i < len(page); i += 4 * checksumNSums {
+		for j := 0; j < checksumNSums; j++ {
+			offset := i + j*4
+			value := binary.NativeEndian.Uint32(page[offset : offset+4])
+
+			// Checksum is calculated with zeroed pd_checksum field (lower half of the third word).
+			if offset == 8 {
+				var b [4]byte
+				binary.NativeEndian.PutUint32(b[:], value)
+				b[0], b[1] = 0, 0
+				value = binary.NativeEndian.Uint32(b[:])
+			}
+
+			sums[j] = comp(sums[j], value)
+		}
+	}
+
+	// Finally add in two rounds of zeroes for additional mixing.
+	for i := 0; i < 2; i++ {
+		for j := 0; j < checksumNSums; j++ {
+			sums[j] = comp(sums[j], 0)
+		}
+	}
+
+	var checksum uint32
+	for j := 0; j < checksumNSums; j++ {
+		checksum ^= sums[j]
+	}
+
+	// Mix in the block number to detect transposed pages.
+	checksum ^= blkno
+
+	return uint16((checksum % 65535) + 1) // #nosec G115
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestPostgresChecksumsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_checksums_enabled",
+		},
+		optional: []string{
+			"postgres_checksums_verified_pages_total",
+			"postgres_checksums_verify_failures_total",
+			"postgres_checksums_last_verify_seconds",
+			"postgres_checksums_last_verify_duration_seconds",
+		},
+		collector: NewPostgresChecksumsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+// newTestPage creates initialized page with valid checksum.
+func newTestPage(blockSize int, blkno uint32, lsn uint64) []byte {
+	page := make([]byte, blockSize)
+	binary.NativeEndian.PutUint32(page[0:4], uint32(lsn>>32))
+	binary.NativeEndian.PutUint32(page[4:8], uint32(lsn))
+	binary.NativeEndian.PutUint16(page[12:14], 24)                // pd_lower
+	binary.NativeEndian.PutUint16(page[14:16], uint16(blockSize)) // pd_upper
+	for i := 24; i < blockSize; i++ {
+		page[i] = byte(i * 7)
+	}
+	binary.NativeEndian.PutUint16(page[8:10], pageChecksum(page, blkno))
+	return page
+}
+
+func Test_pageChecksum(t *testing.T) {
+	page := newTestPage(8192, 10, 100)
+	assert.Equal(t, pageStoredChecksum(page), pageChecksum(page, 10))
+	assert.Equal(t, uint64(100), pageLSN(page))
+	assert.False(t, pageIsNew(page))
+
+	// Checksum depends on block number.
+	assert.NotEqual(t, pageStoredChecksum(page), pageChecksum(page, 11))
+
+	// Checksum depends on page content.
+	page[4000]++
+	assert.NotEqual(t, pageStoredChecksum(page), pageChecksum(page, 10))
+
+	assert.True(t, pageIsNew(make([]byte, 8192)))
+}
+
+func Test_verifyDatabaseChecksums(t *testing.T) {
+	const blockSize = 8192
+	dir := t.TempDir()
+
+	// Relation with 4 pages: valid, corrupted, new (uninitialized) and modified after verification start.
+	var data []byte
+	data = append(data, newTestPage(blockSize, 0, 100)...)
+	corrupted := newTestPage(blockSize, 1, 100)
+	corrupted[5000]++
+	data = append(data, corrupted...)
+	data = append(data, make([]byte, blockSize)...)
+	modified := newTestPage(blockSize, 3, 2000)
+	modified[5000]++
+	data = append(data, modified...)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "16384"), data, 0600))
+
+	// Second segment of relation, block numbers continue from the first segment.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "16385.1"), newTestPage(blockSize, relsegSize/blockSize, 100), 0600))
+
+	// Files which are not relations are skipped.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "PG_VERSION"), []byte("17\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pg_filenode.map"), make([]byte, 512), 0600))
+
+	stat, err := verifyDatabaseChecksums(dir, blockSize, 1000, rate.NewLimiter(rate.Inf, blockSize))
+	assert.NoError(t, err)
+	assert.Equal(t, checksumsVerifyStat{pages: 3, failures: 1}, stat)
+
+	_, err = verifyDatabaseChecksums(filepath.Join(dir, "invalid"), blockSize, 1000, rate.NewLimiter(rate.Inf, blockSize))
+	assert.Error(t, err)
+}
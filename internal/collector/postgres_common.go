@@ -2,9 +2,10 @@
 package collector
 
 import (
-	"context"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -31,6 +32,10 @@ const (
 	PostgresVMinStr = "9.5"
 )
 
+// postgresDatabasesListQuery defines query for databases allowed for connection.
+const postgresDatabasesListQuery = "SELECT datname FROM pg_database WHERE NOT datistemplate AND datallowconn " +
+	"AND has_database_privilege(datname, 'CONNECT') AND NOT (version() LIKE '%yandex%' AND datname = 'postgres')"
+
 // postgresGenericStat represent generic stat suitable for all kind of stats
 type postgresGenericStat struct {
 	labels map[string]string
@@ -149,15 +154,20 @@ func parsePostgresCustomStats(r *model.PGResult, labelNames []string) postgresCu
 	return stats
 }
 
-// listDatabases returns slice with databases names
-func listDatabases(db *store.DB) ([]string, error) {
+// statsCreatedTime returns time since statistics counters are accumulated, passed in unixtime. Counters are accumulated
+// since the last reset of statistics, or since Postgres start if they were never reset. Zero time is returned when the
+// time is unknown.
+func statsCreatedTime(unixtime float64) time.Time {
+	if unixtime <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(unixtime * 1000))
+}
+
+// listDatabases returns slice with databases names, databases matching exclude regexp are skipped.
+func listDatabases(db *store.DB, exclude *regexp.Regexp) ([]string, error) {
 	// getDBList returns the list of databases that allowed for connection
-	rows, err := db.Conn().Query(context.Background(),
-		`SELECT datname FROM pg_database
-			 WHERE NOT datistemplate AND datallowconn
-			  AND has_database_privilege(datname, 'CONNECT')
-			  AND NOT (version() LIKE '%yandex%' AND datname = 'postgres');`,
-	)
+	rows, err := db.QueryRows(postgresDatabasesListQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +179,9 @@ func listDatabases(db *store.DB) ([]string, error) {
 		if err := rows.Scan(&dbname); err != nil {
 			return nil, err
 		}
+		if exclude != nil && exclude.MatchString(dbname) {
+			continue
+		}
 		list = append(list, dbname)
 	}
 	return list, nil
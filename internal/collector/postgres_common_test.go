@@ -7,6 +7,7 @@ import (
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func Test_parsePostgresGenericStats(t *testing.T) {
@@ -102,10 +103,15 @@ func Test_parsePostgresCustomStats(t *testing.T) {
 	}
 }
 
+func Test_statsCreatedTime(t *testing.T) {
+	assert.Equal(t, time.UnixMilli(1700000000500), statsCreatedTime(1700000000.5))
+	assert.True(t, statsCreatedTime(0).IsZero())
+}
+
 func Test_listDatabases(t *testing.T) {
 	conn := store.NewTest(t)
 
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(conn, nil)
 	assert.NoError(t, err)
 	assert.Greater(t, len(databases), 0)
 	conn.Close()
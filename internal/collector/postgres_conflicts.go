@@ -2,11 +2,12 @@
 package collector
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -16,10 +17,33 @@ const (
 
 	postgresDatabaseConflictsQueryLatest = "SELECT datname AS database, confl_tablespace, confl_lock, confl_snapshot, confl_bufferpin, confl_deadlock, confl_active_logicalslot " +
 		"FROM pg_stat_database_conflicts WHERE pg_is_in_recovery() = 't'"
+
+	// postgresRecoveryPauseStateQuery defines query for WAL replay pause state of the standby.
+	postgresRecoveryPauseStateQuery = "SELECT pg_get_wal_replay_pause_state() AS state WHERE pg_is_in_recovery()"
+
+	// postgresRecoveryConflictWaitsQuery defines query for sampling waits of the startup process caused by recovery
+	// conflicts. Waiting time is taken from pg_locks.waitstart for lock conflicts, and approximated by replay delay
+	// for other conflicts.
+	postgresRecoveryConflictWaitsQuery = "SELECT CASE WHEN a.wait_event_type = 'Lock' THEN 'lock' " +
+		"WHEN a.wait_event_type = 'BufferPin' THEN 'bufferpin' " +
+		"WHEN a.wait_event = 'RecoveryConflictSnapshot' THEN 'snapshot' " +
+		"WHEN a.wait_event = 'RecoveryConflictTablespace' THEN 'tablespace' END AS conflict, " +
+		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - (SELECT max(l.waitstart) FROM pg_locks l WHERE l.pid = a.pid)), " +
+		"EXTRACT(EPOCH FROM clock_timestamp() - pg_last_xact_replay_timestamp()), 0) AS waiting_seconds, " +
+		"cardinality(pg_blocking_pids(a.pid)) AS blocking_backends " +
+		"FROM pg_stat_activity a WHERE a.backend_type = 'startup' " +
+		"AND (a.wait_event_type IN ('Lock', 'BufferPin') OR a.wait_event IN ('RecoveryConflictSnapshot', 'RecoveryConflictTablespace'))"
 )
 
+// recoveryPauseStates defines possible states returned by pg_get_wal_replay_pause_state().
+var recoveryPauseStates = []string{"not paused", "pause requested", "paused"}
+
 type postgresConflictsCollector struct {
 	conflicts typedDesc
+	pause     typedDesc
+	waiting   typedDesc
+	blocking  typedDesc
+	query     string
 }
 
 // NewPostgresConflictsCollector returns a new Collector exposing postgres databases recovery conflicts stats.
@@ -32,18 +56,37 @@ func NewPostgresConflictsCollector(constLabels labels, settings model.CollectorS
 			[]string{"database", "conflict"}, constLabels,
 			settings.Filters,
 		),
+		pause: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery", "pause_state", "Current WAL replay pause state of the standby, 1 for the current state.", 0},
+			prometheus.GaugeValue,
+			[]string{"state"}, constLabels,
+			settings.Filters,
+		),
+		waiting: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery", "conflict_waiting_seconds", "Time the startup process is waiting on recovery conflict, by conflict type, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"conflict"}, constLabels,
+			settings.Filters,
+		),
+		blocking: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery", "conflict_blocking_backends", "Number of backends blocking the startup process by recovery conflict, by conflict type.", 0},
+			prometheus.GaugeValue,
+			[]string{"conflict"}, constLabels,
+			settings.Filters,
+		),
+		query: settings.Query,
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresConflictsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	res, err := conn.Query(selectDatabaseConflictsQuery(config.pgVersion.Numeric))
+	res, err := queryWithOverride(conn, "postgres/conflicts", c.query, selectDatabaseConflictsQuery(config.pgVersion.Numeric))
 	if err != nil {
 		return err
 	}
@@ -59,9 +102,102 @@ func (c *postgresConflictsCollector) Update(config Config, ch chan<- prometheus.
 		ch <- c.conflicts.newConstMetric(stat.activeLogicalslot, stat.database, "active_logicalslot")
 	}
 
+	// Replay pause state and waiting times of the startup process use functions and views introduced in Postgres 14.
+	if config.pgVersion.Numeric < PostgresV14 {
+		return nil
+	}
+
+	var state string
+	err = conn.QueryRow(postgresRecoveryPauseStateQuery).Scan(&state)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Not a standby, nothing to do.
+		return nil
+	case err != nil:
+		log.Warnf("get recovery pause state failed: %s; skip", err)
+	default:
+		for _, s := range recoveryPauseStates {
+			var v float64
+			if s == state {
+				v = 1
+			}
+			ch <- c.pause.newConstMetric(v, s)
+		}
+	}
+
+	res, err = conn.Query(postgresRecoveryConflictWaitsQuery)
+	if err != nil {
+		log.Warnf("get recovery conflict waits failed: %s; skip", err)
+		return nil
+	}
+
+	for _, stat := range parsePostgresRecoveryConflictWaits(res) {
+		ch <- c.waiting.newConstMetric(stat.waitingSeconds, stat.conflict)
+		ch <- c.blocking.newConstMetric(stat.blockingBackends, stat.conflict)
+	}
+
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresConflictsCollector) executedQueries(config Config) []string {
+	query := queryOrOverride(c.query, selectDatabaseConflictsQuery(config.pgVersion.Numeric))
+	if config.pgVersion.Numeric < PostgresV14 {
+		return []string{query}
+	}
+	return []string{query, postgresRecoveryPauseStateQuery, postgresRecoveryConflictWaitsQuery}
+}
+
+// postgresRecoveryConflictWait represents wait of the startup process caused by recovery conflict.
+type postgresRecoveryConflictWait struct {
+	conflict         string
+	waitingSeconds   float64
+	blockingBackends float64
+}
+
+// parsePostgresRecoveryConflictWaits parses PGResult and returns structs with startup process waits.
+func parsePostgresRecoveryConflictWaits(r *model.PGResult) []postgresRecoveryConflictWait {
+	log.Debug("parse postgres recovery conflict waits")
+
+	var stats []postgresRecoveryConflictWait
+
+	for _, row := range r.Rows {
+		var stat postgresRecoveryConflictWait
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "conflict":
+				stat.conflict = row[i].String
+			case "waiting_seconds", "blocking_backends":
+				// Skip empty (NULL) values.
+				if !row[i].Valid {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				if string(colname.Name) == "waiting_seconds" {
+					stat.waitingSeconds = v
+				} else {
+					stat.blockingBackends = v
+				}
+			}
+		}
+
+		if stat.conflict == "" {
+			continue
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
 // postgresConflictStat represents per-database recovery conflicts stats based on pg_stat_database_conflicts.
 type postgresConflictStat struct {
 	database          string
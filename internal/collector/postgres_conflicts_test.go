@@ -12,6 +12,9 @@ func TestPostgresConflictsCollector_Update(t *testing.T) {
 	var input = pipelineInput{
 		optional: []string{
 			"postgres_recovery_conflicts_total",
+			"postgres_recovery_pause_state",
+			"postgres_recovery_conflict_waiting_seconds",
+			"postgres_recovery_conflict_blocking_backends",
 		},
 		collector: NewPostgresConflictsCollector,
 		service:   model.ServiceTypePostgresql,
@@ -59,3 +62,29 @@ func Test_parsePostgresConflictsStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePostgresRecoveryConflictWaits(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("conflict")}, {Name: []byte("waiting_seconds")}, {Name: []byte("blocking_backends")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "lock", Valid: true}, {String: "12.5", Valid: true}, {String: "2", Valid: true}},
+			{{String: "snapshot", Valid: true}, {String: "3", Valid: true}, {}},
+		},
+	}
+
+	want := []postgresRecoveryConflictWait{
+		{conflict: "lock", waitingSeconds: 12.5, blockingBackends: 2},
+		{conflict: "snapshot", waitingSeconds: 3},
+	}
+
+	assert.Equal(t, want, parsePostgresRecoveryConflictWaits(res))
+}
+
+func Test_selectDatabaseConflictsQuery(t *testing.T) {
+	assert.Equal(t, postgresDatabaseConflictsQuery15, selectDatabaseConflictsQuery(PostgresV15))
+	assert.Equal(t, postgresDatabaseConflictsQueryLatest, selectDatabaseConflictsQuery(PostgresV16))
+}
@@ -21,3 +21,8 @@ func NewPostgresCustomCollector(constLabels labels, settings model.CollectorSett
 func (c *postgresCustomCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	return updateAllDescSets(config, c.custom, ch)
 }
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresCustomCollector) executedQueries(_ Config) []string {
+	return descSetsQueries(c.custom)
+}
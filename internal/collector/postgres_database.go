@@ -2,7 +2,10 @@
 package collector
 
 import (
+	"maps"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -15,7 +18,8 @@ const (
 		"COALESCE(datname, 'global') AS database, " +
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
 		"conflicts, temp_files, temp_bytes, deadlocks, blk_read_time, blk_write_time, pg_database_size(datname) as size_bytes, " +
-		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds " +
+		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds, " +
+		"EXTRACT(EPOCH FROM COALESCE(stats_reset, pg_postmaster_start_time())) AS stats_reset_unixtime " +
 		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
 		"OR datname IS NULL"
 
@@ -24,7 +28,8 @@ const (
 		"xact_commit, xact_rollback, blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, " +
 		"conflicts, temp_files, temp_bytes, deadlocks, checksum_failures, COALESCE(EXTRACT(EPOCH FROM checksum_last_failure), 0) AS last_checksum_failure_unixtime, " +
 		"blk_read_time, blk_write_time, pg_database_size(datname) as size_bytes, " +
-		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds " +
+		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds, " +
+		"EXTRACT(EPOCH FROM COALESCE(stats_reset, pg_postmaster_start_time())) AS stats_reset_unixtime " +
 		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
 		"OR datname IS NULL"
 
@@ -35,7 +40,8 @@ const (
 		"blk_read_time, blk_write_time, " +
 		"session_time, active_time, idle_in_transaction_time, sessions, sessions_abandoned, sessions_fatal, sessions_killed, " +
 		"pg_database_size(datname) as size_bytes, " +
-		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds " +
+		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds, " +
+		"EXTRACT(EPOCH FROM COALESCE(stats_reset, pg_postmaster_start_time())) AS stats_reset_unixtime " +
 		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
 		"OR datname IS NULL"
 
@@ -47,13 +53,31 @@ const (
 		"session_time, active_time, idle_in_transaction_time, sessions, sessions_abandoned, sessions_fatal, sessions_killed, " +
 		"parallel_workers_to_launch, parallel_workers_launched, " +
 		"pg_database_size(datname) as size_bytes, " +
-		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds " +
+		"COALESCE(EXTRACT(EPOCH FROM AGE(now(), stats_reset)), 0) as stats_age_seconds, " +
+		"EXTRACT(EPOCH FROM COALESCE(stats_reset, pg_postmaster_start_time())) AS stats_reset_unixtime " +
 		"FROM pg_stat_database WHERE datname IN (SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate) " +
 		"OR datname IS NULL"
 
 	xidLimitQuery = "SELECT 'database' AS src, 2147483647 - GREATEST(MAX(AGE(datfrozenxid)), COALESCE(MAX(MXID_AGE(NULLIF(datminmxid, 1))), MAX(AGE(datfrozenxid)))) AS to_limit FROM pg_database " +
 		"UNION SELECT 'prepared_xacts' AS src, 2147483647 - COALESCE(MAX(AGE(transaction)), 0) AS to_limit FROM pg_prepared_xacts " +
 		"UNION SELECT 'replication_slots' AS src, 2147483647 - GREATEST(COALESCE(MIN(AGE(xmin)), 0), COALESCE(MIN(AGE(catalog_xmin)), 0)) AS to_limit FROM pg_replication_slots"
+
+	// databasesTablespacesQuery defines query for selecting default tablespaces of databases and number of user-defined
+	// tablespaces, when there are no user-defined tablespaces all data of databases is stored in default tablespaces.
+	databasesTablespacesQuery = "SELECT d.datname AS database, t.spcname AS tablespace, " +
+		"(SELECT count(*) FROM pg_tablespace WHERE spcname NOT IN ('pg_default', 'pg_global')) AS user_tablespaces " +
+		"FROM pg_database d JOIN pg_tablespace t ON t.oid = d.dattablespace WHERE d.datallowconn AND NOT d.datistemplate"
+
+	// databaseTablespaceSizesQuery defines query for calculating size of relations of the current database stored in
+	// each tablespace, relations with zero reltablespace are stored in default tablespace of the database.
+	databaseTablespaceSizesQuery = "SELECT COALESCE(t.spcname, (SELECT dt.spcname FROM pg_database d JOIN pg_tablespace dt ON dt.oid = d.dattablespace WHERE d.datname = current_database())) AS tablespace, " +
+		"sum(pg_relation_size(c.oid, 'main') + pg_relation_size(c.oid, 'fsm') + pg_relation_size(c.oid, 'vm') + pg_relation_size(c.oid, 'init')) AS size_bytes " +
+		"FROM pg_class c LEFT JOIN pg_tablespace t ON t.oid = c.reltablespace " +
+		"WHERE c.relkind IN ('r', 'i', 't', 'm', 'S') GROUP BY 1"
+
+	// databaseSizeGrowthInterval defines min interval between snapshots of databases sizes used for calculating growth
+	// rate, rate is not recalculated on more frequent scrapes to avoid noise.
+	databaseSizeGrowthInterval = time.Minute
 )
 
 type postgresDatabasesCollector struct {
@@ -80,7 +104,21 @@ type postgresDatabasesCollector struct {
 	sizes              typedDesc
 	statsage           typedDesc
 	xidlimit           typedDesc
+	sizeGrowth         typedDesc
+	tablespaceSizes    typedDesc
 	labelNames         []string
+	// sizeSnapshots keeps previous sizes of databases used for calculating growth rate, keyed by database name.
+	sizeSnapshots map[string]databaseSizeSnapshot
+	mu            sync.Mutex
+}
+
+// databaseSizeSnapshot represents size of the database observed at the time, and growth rate calculated against
+// previous snapshot.
+type databaseSizeSnapshot struct {
+	size    float64
+	ts      time.Time
+	rate    float64
+	hasRate bool
 }
 
 // NewPostgresDatabasesCollector returns a new Collector exposing postgres databases stats.
@@ -228,12 +266,25 @@ func NewPostgresDatabasesCollector(constLabels labels, settings model.CollectorS
 			[]string{"xid_from"}, constLabels,
 			settings.Filters,
 		),
+		sizeGrowth: newBuiltinTypedDesc(
+			descOpts{"postgres", "database", "size_growth_bytes_per_second", "Growth rate of the database size since the previous snapshot taken by pgSCV, in bytes per second.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		tablespaceSizes: newBuiltinTypedDesc(
+			descOpts{"postgres", "database", "tablespace_size_bytes", "Size of the database data stored in each tablespace, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "tablespace"}, constLabels,
+			settings.Filters,
+		),
+		sizeSnapshots: map[string]databaseSizeSnapshot{},
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -246,6 +297,13 @@ func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.
 
 	stats := parsePostgresDatabasesStats(res, c.labelNames)
 
+	// Skip excluded databases, shared objects are never excluded.
+	if config.ExcludeDatabasesRE != nil {
+		maps.DeleteFunc(stats, func(database string, _ postgresDatabaseStat) bool {
+			return database != "global" && config.ExcludeDatabasesRE.MatchString(database)
+		})
+	}
+
 	res, err = conn.Query(xidLimitQuery)
 	if err != nil {
 		return err
@@ -254,46 +312,48 @@ func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.
 	xidStats := parsePostgresXidLimitStats(res)
 
 	for _, stat := range stats {
-		ch <- c.commits.newConstMetric(stat.xactcommit, stat.database)
-		ch <- c.rollbacks.newConstMetric(stat.xactrollback, stat.database)
-		ch <- c.blocks.newConstMetric(stat.blksread, stat.database, "read")
-		ch <- c.blocks.newConstMetric(stat.blkshit, stat.database, "hit")
-		ch <- c.tuplesReturned.newConstMetric(stat.tupreturned, stat.database)
-		ch <- c.tuplesFetched.newConstMetric(stat.tupfetched, stat.database)
-		ch <- c.tuplesInserted.newConstMetric(stat.tupinserted, stat.database)
-		ch <- c.tuplesUpdated.newConstMetric(stat.tupupdated, stat.database)
-		ch <- c.tuplesDeleted.newConstMetric(stat.tupdeleted, stat.database)
-
-		ch <- c.tempbytes.newConstMetric(stat.tempbytes, stat.database)
-		ch <- c.tempfiles.newConstMetric(stat.tempfiles, stat.database)
-		ch <- c.conflicts.newConstMetric(stat.conflicts, stat.database)
-		ch <- c.deadlocks.newConstMetric(stat.deadlocks, stat.database)
-
-		ch <- c.blockstime.newConstMetric(stat.blkreadtime, stat.database, "read")
-		ch <- c.blockstime.newConstMetric(stat.blkwritetime, stat.database, "write")
+		created := statsCreatedTime(stat.statsreset)
+
+		ch <- c.commits.newConstMetricWithCreated(stat.xactcommit, created, stat.database)
+		ch <- c.rollbacks.newConstMetricWithCreated(stat.xactrollback, created, stat.database)
+		ch <- c.blocks.newConstMetricWithCreated(stat.blksread, created, stat.database, "read")
+		ch <- c.blocks.newConstMetricWithCreated(stat.blkshit, created, stat.database, "hit")
+		ch <- c.tuplesReturned.newConstMetricWithCreated(stat.tupreturned, created, stat.database)
+		ch <- c.tuplesFetched.newConstMetricWithCreated(stat.tupfetched, created, stat.database)
+		ch <- c.tuplesInserted.newConstMetricWithCreated(stat.tupinserted, created, stat.database)
+		ch <- c.tuplesUpdated.newConstMetricWithCreated(stat.tupupdated, created, stat.database)
+		ch <- c.tuplesDeleted.newConstMetricWithCreated(stat.tupdeleted, created, stat.database)
+
+		ch <- c.tempbytes.newConstMetricWithCreated(stat.tempbytes, created, stat.database)
+		ch <- c.tempfiles.newConstMetricWithCreated(stat.tempfiles, created, stat.database)
+		ch <- c.conflicts.newConstMetricWithCreated(stat.conflicts, created, stat.database)
+		ch <- c.deadlocks.newConstMetricWithCreated(stat.deadlocks, created, stat.database)
+
+		ch <- c.blockstime.newConstMetricWithCreated(stat.blkreadtime, created, stat.database, "read")
+		ch <- c.blockstime.newConstMetricWithCreated(stat.blkwritetime, created, stat.database, "write")
 		ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database)
 		ch <- c.statsage.newConstMetric(stat.statsage, stat.database)
 
 		if config.pgVersion.Numeric >= PostgresV12 {
-			ch <- c.csumfails.newConstMetric(stat.csumfails, stat.database)
+			ch <- c.csumfails.newConstMetricWithCreated(stat.csumfails, created, stat.database)
 			ch <- c.csumlastfailunixts.newConstMetric(stat.csumlastfailunixts, stat.database)
 		}
 
 		if config.pgVersion.Numeric >= PostgresV14 {
-			ch <- c.sessionalltime.newConstMetric(stat.sessiontime, stat.database)
-			ch <- c.sessiontime.newConstMetric(stat.activetime, stat.database, "active")
-			ch <- c.sessiontime.newConstMetric(stat.idletxtime, stat.database, "idle_in_transaction")
-			ch <- c.sessiontime.newConstMetric(stat.sessiontime-(stat.activetime+stat.idletxtime), stat.database, "idle")
-			ch <- c.sessionsall.newConstMetric(stat.sessions, stat.database)
-			ch <- c.sessions.newConstMetric(stat.sessabandoned, stat.database, "abandoned")
-			ch <- c.sessions.newConstMetric(stat.sessfatal, stat.database, "fatal")
-			ch <- c.sessions.newConstMetric(stat.sesskilled, stat.database, "killed")
-			ch <- c.sessions.newConstMetric(stat.sessions-(stat.sessabandoned+stat.sessfatal+stat.sesskilled), stat.database, "normal")
+			ch <- c.sessionalltime.newConstMetricWithCreated(stat.sessiontime, created, stat.database)
+			ch <- c.sessiontime.newConstMetricWithCreated(stat.activetime, created, stat.database, "active")
+			ch <- c.sessiontime.newConstMetricWithCreated(stat.idletxtime, created, stat.database, "idle_in_transaction")
+			ch <- c.sessiontime.newConstMetricWithCreated(stat.sessiontime-(stat.activetime+stat.idletxtime), created, stat.database, "idle")
+			ch <- c.sessionsall.newConstMetricWithCreated(stat.sessions, created, stat.database)
+			ch <- c.sessions.newConstMetricWithCreated(stat.sessabandoned, created, stat.database, "abandoned")
+			ch <- c.sessions.newConstMetricWithCreated(stat.sessfatal, created, stat.database, "fatal")
+			ch <- c.sessions.newConstMetricWithCreated(stat.sesskilled, created, stat.database, "killed")
+			ch <- c.sessions.newConstMetricWithCreated(stat.sessions-(stat.sessabandoned+stat.sessfatal+stat.sesskilled), created, stat.database, "normal")
 		}
 
 		if config.pgVersion.Numeric >= PostgresV18 {
-			ch <- c.parallelWorkers.newConstMetric(stat.prlworkplan, stat.database, "planned")
-			ch <- c.parallelWorkers.newConstMetric(stat.prlworkfact, stat.database, "fact")
+			ch <- c.parallelWorkers.newConstMetricWithCreated(stat.prlworkplan, created, stat.database, "planned")
+			ch <- c.parallelWorkers.newConstMetricWithCreated(stat.prlworkfact, created, stat.database, "fact")
 		}
 	}
 
@@ -301,9 +361,120 @@ func (c *postgresDatabasesCollector) Update(config Config, ch chan<- prometheus.
 	ch <- c.xidlimit.newConstMetric(xidStats.prepared, "pg_prepared_xacts")
 	ch <- c.xidlimit.newConstMetric(xidStats.replSlot, "pg_replication_slots")
 
+	c.mu.Lock()
+	rates := updateDatabaseSizeSnapshots(c.sizeSnapshots, stats, time.Now())
+	c.mu.Unlock()
+
+	for database, rate := range rates {
+		ch <- c.sizeGrowth.newConstMetric(rate, database)
+	}
+
+	c.updateTablespaceSizes(conn, config, stats, ch)
+
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresDatabasesCollector) executedQueries(config Config) []string {
+	return []string{selectDatabasesQuery(config.pgVersion.Numeric), xidLimitQuery, databasesTablespacesQuery, databaseTablespaceSizesQuery}
+}
+
+// updateTablespaceSizes produces metrics of databases sizes broken down by tablespaces. When there are user-defined
+// tablespaces, sizes are calculated in databases which are walked through (matched by 'databases' setting, or the
+// current one). Otherwise, total size of database is attributed to its default tablespace.
+func (c *postgresDatabasesCollector) updateTablespaceSizes(conn *store.DB, config Config, stats map[string]postgresDatabaseStat, ch chan<- prometheus.Metric) {
+	res, err := conn.Query(databasesTablespacesQuery)
+	if err != nil {
+		log.Warnf("get databases tablespaces failed: %s; skip", err)
+		return
+	}
+
+	current := conn.Conn().Config().Database
+	for _, row := range res.Rows {
+		database, tablespace := row[0].String, row[1].String
+		if config.ExcludeDatabasesRE != nil && config.ExcludeDatabasesRE.MatchString(database) {
+			continue
+		}
+
+		// Walk through databases which are allowed to connect, only the current one in service discovery case.
+		skip := (config.DatabasesRE == nil && database != current) || (config.DatabasesRE != nil && !config.DatabasesRE.MatchString(database))
+		if row[2].String == "0" || skip {
+			if stat, ok := stats[database]; ok {
+				ch <- c.tablespaceSizes.newConstMetric(stat.sizebytes, database, tablespace)
+			}
+			continue
+		}
+
+		dbconn := conn
+		if database != current {
+			dbconn, err = config.acquireDatabaseConn(database)
+			if err != nil {
+				log.Warnf("connect to database %s failed: %s; skip", database, err)
+				continue
+			}
+		}
+
+		sizes, err := dbconn.Query(databaseTablespaceSizesQuery)
+		if dbconn != conn {
+			dbconn.Close()
+		}
+		if err != nil {
+			log.Warnf("get tablespaces sizes of database %s failed: %s; skip", database, err)
+			continue
+		}
+
+		for _, r := range sizes.Rows {
+			v, err := strconv.ParseFloat(r[1].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", r[1].String, err)
+				continue
+			}
+			ch <- c.tablespaceSizes.newConstMetric(v, database, r[0].String)
+		}
+	}
+}
+
+// updateDatabaseSizeSnapshots updates snapshots of databases sizes and returns growth rates of databases. Rates are
+// recalculated when snapshots are older than databaseSizeGrowthInterval, snapshots of dropped databases are forgotten.
+func updateDatabaseSizeSnapshots(snapshots map[string]databaseSizeSnapshot, stats map[string]postgresDatabaseStat, now time.Time) map[string]float64 {
+	rates := map[string]float64{}
+
+	for database := range snapshots {
+		if _, ok := stats[database]; !ok {
+			delete(snapshots, database)
+		}
+	}
+
+	for database, stat := range stats {
+		// Skip shared objects, they have no size.
+		if database == "global" {
+			continue
+		}
+
+		prev, ok := snapshots[database]
+		if !ok {
+			snapshots[database] = databaseSizeSnapshot{size: stat.sizebytes, ts: now}
+			continue
+		}
+
+		if elapsed := now.Sub(prev.ts); elapsed >= databaseSizeGrowthInterval {
+			prev = databaseSizeSnapshot{
+				size:    stat.sizebytes,
+				ts:      now,
+				rate:    (stat.sizebytes - prev.size) / elapsed.Seconds(),
+				hasRate: true,
+			}
+			snapshots[database] = prev
+		}
+
+		if prev.hasRate {
+			rates[database] = prev.rate
+		}
+	}
+
+	return rates
+}
+
 // postgresDatabaseStat represents per-database stats based on pg_stat_database.
 type postgresDatabaseStat struct {
 	database           string
@@ -335,6 +506,7 @@ type postgresDatabaseStat struct {
 	prlworkfact        float64
 	sizebytes          float64
 	statsage           float64
+	statsreset         float64
 }
 
 // parsePostgresDatabasesStats parses PGResult, extract data and return struct with stats values.
@@ -439,6 +611,8 @@ func parsePostgresDatabasesStats(r *model.PGResult, labelNames []string) map[str
 				s.sizebytes = v
 			case "stats_age_seconds":
 				s.statsage = v
+			case "stats_reset_unixtime":
+				s.statsreset = v
 			default:
 				continue
 			}
@@ -3,6 +3,7 @@ package collector
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/jackc/pgproto3/v2"
@@ -37,6 +38,8 @@ func TestPostgresDatabasesCollector_Update(t *testing.T) {
 		},
 		optional: []string{
 			"postgres_database_parallel_workers",
+			"postgres_database_size_growth_bytes_per_second",
+			"postgres_database_tablespace_size_bytes",
 		},
 		collector: NewPostgresDatabasesCollector,
 		service:   model.ServiceTypePostgresql,
@@ -172,3 +175,42 @@ func Test_selectDatabasesQuery(t *testing.T) {
 		assert.Equal(t, tc.want, selectDatabasesQuery(tc.version))
 	}
 }
+
+func Test_updateDatabaseSizeSnapshots(t *testing.T) {
+	snapshots := map[string]databaseSizeSnapshot{}
+	now := time.Now()
+
+	// First snapshot, no rates.
+	rates := updateDatabaseSizeSnapshots(snapshots, map[string]postgresDatabaseStat{
+		"global": {database: "global"},
+		"db1":    {database: "db1", sizebytes: 1000},
+		"db2":    {database: "db2", sizebytes: 5000},
+	}, now)
+	assert.Empty(t, rates)
+	assert.Len(t, snapshots, 2)
+
+	// Too frequent update, rates are not calculated yet.
+	rates = updateDatabaseSizeSnapshots(snapshots, map[string]postgresDatabaseStat{
+		"db1": {database: "db1", sizebytes: 2000},
+		"db2": {database: "db2", sizebytes: 5000},
+	}, now.Add(10*time.Second))
+	assert.Empty(t, rates)
+
+	// Rates are calculated against the first snapshot, dropped database is forgotten.
+	rates = updateDatabaseSizeSnapshots(snapshots, map[string]postgresDatabaseStat{
+		"db1": {database: "db1", sizebytes: 7000},
+	}, now.Add(time.Minute))
+	assert.Equal(t, map[string]float64{"db1": 100}, rates)
+	assert.Len(t, snapshots, 1)
+
+	// Rate is reused until the next snapshot.
+	rates = updateDatabaseSizeSnapshots(snapshots, map[string]postgresDatabaseStat{
+		"db1": {database: "db1", sizebytes: 1000},
+	}, now.Add(90*time.Second))
+	assert.Equal(t, map[string]float64{"db1": 100}, rates)
+
+	rates = updateDatabaseSizeSnapshots(snapshots, map[string]postgresDatabaseStat{
+		"db1": {database: "db1", sizebytes: 1000},
+	}, now.Add(2*time.Minute))
+	assert.Equal(t, map[string]float64{"db1": -100}, rates)
+}
@@ -0,0 +1,232 @@
+package collector
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresDDLQuery defines query for snapshotting user-defined tables, indexes and functions of the database. Objects
+// are identified by OIDs, hence objects dropped and created again between scrapes are accounted too. System and
+// temporary schemas are skipped.
+const postgresDDLQuery = "SELECT current_database() AS database, c.oid, n.nspname AS schema, " +
+	"CASE WHEN c.relkind IN ('r', 'p') THEN 'table' ELSE 'index' END AS kind " +
+	"FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace " +
+	"WHERE c.relkind IN ('r', 'p', 'i', 'I') " +
+	"AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast') AND n.nspname !~ '^pg_(toast_)?temp_' " +
+	"UNION ALL " +
+	"SELECT current_database(), p.oid, n.nspname, 'function' " +
+	"FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace " +
+	"WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')"
+
+// postgresDDLKey defines a set of labels which catalog objects are accounted by.
+type postgresDDLKey struct {
+	database string
+	schema   string
+	kind     string
+}
+
+// postgresDDLCollector defines metric descriptors and catalog snapshots taken during previous scrape.
+type postgresDDLCollector struct {
+	mu           sync.Mutex
+	snapshots    map[string]map[uint32]postgresDDLKey // objects of each database, by OID
+	created      map[postgresDDLKey]float64
+	dropped      map[postgresDDLKey]float64
+	objects      typedDesc
+	createdTotal typedDesc
+	droppedTotal typedDesc
+}
+
+// NewPostgresDDLCollector returns a new Collector exposing number of tables, indexes and functions per schema, and
+// number of objects created and dropped between scrapes. Catalog snapshots are compared, hence DDL churn is tracked
+// without parsing logs or installing event triggers. Collector does nothing unless ddl_tracking is enabled.
+func NewPostgresDDLCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labelNames = []string{"database", "schema", "kind"}
+
+	return &postgresDDLCollector{
+		snapshots: map[string]map[uint32]postgresDDLKey{},
+		created:   map[postgresDDLKey]float64{},
+		dropped:   map[postgresDDLKey]float64{},
+		objects: newBuiltinTypedDesc(
+			descOpts{"postgres", "ddl", "objects", "Number of user-defined objects in the schema, by kind.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		createdTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "ddl", "created_total", "Total number of objects created in the schema since pgSCV start, by kind.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		droppedTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "ddl", "dropped_total", "Total number of objects dropped from the schema since pgSCV start, by kind.", 0},
+			prometheus.CounterValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresDDLCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	// Snapshotting catalogs is optional, snapshots of large catalogs occupy noticeable amount of memory.
+	if !config.DDLTracking {
+		return nil
+	}
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Snapshots of databases which are not seen during scrape are released.
+	snapshots := map[string]map[uint32]postgresDDLKey{}
+
+	collect := func(conn *store.DB) error {
+		res, err := conn.Query(postgresDDLQuery)
+		if err != nil {
+			return err
+		}
+
+		for database, snapshot := range parsePostgresDDLSnapshots(res) {
+			snapshots[database] = snapshot
+		}
+		return nil
+	}
+
+	if config.DatabasesRE == nil {
+		// service discovery case
+		err = collect(conn)
+		if err != nil {
+			return err
+		}
+	} else {
+		databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range databases {
+			// Skip database if not matched to allowed.
+			if !config.DatabasesRE.MatchString(d) {
+				continue
+			}
+
+			conn, err := config.acquireDatabaseConn(d)
+			if err != nil {
+				log.Warnf("connect to database %s failed: %s; skip", d, err)
+				continue
+			}
+			err = collect(conn)
+			conn.Close()
+			if err != nil {
+				log.Warnf("get catalog snapshot of database %s failed: %s; skip", d, err)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for database, snapshot := range snapshots {
+		// Objects of databases seen for the first time are not accounted as created.
+		if prev, ok := c.snapshots[database]; ok {
+			diffPostgresDDLSnapshots(prev, snapshot, c.created, c.dropped)
+		}
+	}
+	c.snapshots = snapshots
+
+	for key, v := range countPostgresDDLObjects(snapshots) {
+		ch <- c.objects.newConstMetric(v, key.database, key.schema, key.kind)
+	}
+	for key, v := range c.created {
+		ch <- c.createdTotal.newConstMetric(v, key.database, key.schema, key.kind)
+	}
+	for key, v := range c.dropped {
+		ch <- c.droppedTotal.newConstMetric(v, key.database, key.schema, key.kind)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresDDLCollector) executedQueries(config Config) []string {
+	if !config.DDLTracking {
+		return nil
+	}
+	return perDatabaseQueries(config, postgresDDLQuery)
+}
+
+// parsePostgresDDLSnapshots parses PGResult and returns catalog objects of each database, by OID.
+func parsePostgresDDLSnapshots(r *model.PGResult) map[string]map[uint32]postgresDDLKey {
+	log.Debug("parse postgres ddl snapshots")
+
+	var snapshots = map[string]map[uint32]postgresDDLKey{}
+
+	for _, row := range r.Rows {
+		var key postgresDDLKey
+		var oid uint32
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				key.database = row[i].String
+			case "schema":
+				key.schema = row[i].String
+			case "kind":
+				key.kind = row[i].String
+			case "oid":
+				v, err := strconv.ParseUint(row[i].String, 10, 32)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+				oid = uint32(v)
+			}
+		}
+
+		if oid == 0 {
+			continue
+		}
+
+		if _, ok := snapshots[key.database]; !ok {
+			snapshots[key.database] = map[uint32]postgresDDLKey{}
+		}
+		snapshots[key.database][oid] = key
+	}
+
+	return snapshots
+}
+
+// diffPostgresDDLSnapshots compares previous and current snapshots of the database and accounts created and dropped objects.
+func diffPostgresDDLSnapshots(prev, curr map[uint32]postgresDDLKey, created, dropped map[postgresDDLKey]float64) {
+	for oid, key := range curr {
+		if _, ok := prev[oid]; !ok {
+			created[key]++
+		}
+	}
+	for oid, key := range prev {
+		if _, ok := curr[oid]; !ok {
+			dropped[key]++
+		}
+	}
+}
+
+// countPostgresDDLObjects returns number of objects in snapshots, by database, schema and kind.
+func countPostgresDDLObjects(snapshots map[string]map[uint32]postgresDDLKey) map[postgresDDLKey]float64 {
+	var counts = map[postgresDDLKey]float64{}
+
+	for _, snapshot := range snapshots {
+		for _, key := range snapshot {
+			counts[key]++
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresDDLCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_ddl_objects",
+			"postgres_ddl_created_total",
+			"postgres_ddl_dropped_total",
+		},
+		collector: NewPostgresDDLCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func TestPostgresDDLCollector_Update_disabled(t *testing.T) {
+	c, err := NewPostgresDDLCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	// Collector doesn't connect to the service unless DDL tracking is enabled.
+	ch := make(chan prometheus.Metric, 1)
+	assert.NoError(t, c.Update(Config{}, ch))
+	assert.Empty(t, ch)
+}
+
+func Test_parsePostgresDDLSnapshots(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 4,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("oid")}, {Name: []byte("schema")}, {Name: []byte("kind")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "testdb", Valid: true}, {String: "16384", Valid: true}, {String: "public", Valid: true}, {String: "table", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "16390", Valid: true}, {String: "public", Valid: true}, {String: "index", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "16400", Valid: true}, {String: "billing", Valid: true}, {String: "function", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "invalid", Valid: true}, {String: "public", Valid: true}, {String: "table", Valid: true}},
+		},
+	}
+
+	want := map[string]map[uint32]postgresDDLKey{
+		"testdb": {
+			16384: {database: "testdb", schema: "public", kind: "table"},
+			16390: {database: "testdb", schema: "public", kind: "index"},
+			16400: {database: "testdb", schema: "billing", kind: "function"},
+		},
+	}
+
+	assert.Equal(t, want, parsePostgresDDLSnapshots(res))
+}
+
+func Test_diffPostgresDDLSnapshots(t *testing.T) {
+	table := postgresDDLKey{database: "testdb", schema: "public", kind: "table"}
+	index := postgresDDLKey{database: "testdb", schema: "public", kind: "index"}
+
+	prev := map[uint32]postgresDDLKey{16384: table, 16385: table, 16390: index}
+	// One table is dropped and another one is created, new index is created.
+	curr := map[uint32]postgresDDLKey{16384: table, 16400: table, 16390: index, 16401: index}
+
+	created, dropped := map[postgresDDLKey]float64{}, map[postgresDDLKey]float64{}
+	diffPostgresDDLSnapshots(prev, curr, created, dropped)
+
+	assert.Equal(t, map[postgresDDLKey]float64{table: 1, index: 1}, created)
+	assert.Equal(t, map[postgresDDLKey]float64{table: 1}, dropped)
+
+	assert.Equal(t, map[postgresDDLKey]float64{table: 2, index: 2}, countPostgresDDLObjects(map[string]map[uint32]postgresDDLKey{"testdb": curr}))
+}
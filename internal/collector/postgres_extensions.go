@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const postgresExtensionsQuery = "SELECT current_database() AS database, e.extname AS name, e.extversion AS version, " +
+	"e.extnamespace::regnamespace::text AS schema, COALESCE(a.default_version, '') AS default_version " +
+	"FROM pg_extension e LEFT JOIN pg_available_extensions a ON a.name = e.extname"
+
+type postgresExtensionsCollector struct {
+	info            typedDesc
+	updateAvailable typedDesc
+}
+
+// NewPostgresExtensionsCollector returns a new Collector exposing installed extensions.
+// For details see https://www.postgresql.org/docs/current/catalog-pg-extension.html
+func NewPostgresExtensionsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresExtensionsCollector{
+		info: newBuiltinTypedDesc(
+			descOpts{"postgres", "extension", "info", "Labeled information about installed extension.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "name", "version", "schema"}, constLabels,
+			settings.Filters,
+		),
+		updateAvailable: newBuiltinTypedDesc(
+			descOpts{"postgres", "extension", "update_available", "Value is 1 if installed version of extension differs from default version available, 0 otherwise.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "name", "version", "default_version"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresExtensionsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	collect := func(conn *store.DB) error {
+		res, err := conn.Query(postgresExtensionsQuery)
+		if err != nil {
+			log.Warnf("get extensions failed: %s", err)
+			return err
+		}
+
+		for _, ext := range parsePostgresExtensions(res) {
+			ch <- c.info.newConstMetric(1, ext.database, ext.name, ext.version, ext.schema)
+
+			// Extension's control file might be removed after package upgrade, nothing to compare with.
+			if ext.defaultVersion == "" {
+				continue
+			}
+
+			if ext.version != ext.defaultVersion {
+				ch <- c.updateAvailable.newConstMetric(1, ext.database, ext.name, ext.version, ext.defaultVersion)
+			} else {
+				ch <- c.updateAvailable.newConstMetric(0, ext.database, ext.name, ext.version, ext.defaultVersion)
+			}
+		}
+		return nil
+	}
+
+	if config.DatabasesRE == nil {
+		// service discovery case
+		return collect(conn)
+	}
+
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		conn, err := config.acquireDatabaseConn(d)
+		if err != nil {
+			return err
+		}
+		err = collect(conn)
+		conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresExtensionsCollector) executedQueries(config Config) []string {
+	return perDatabaseQueries(config, postgresExtensionsQuery)
+}
+
+// postgresExtension represents extension installed in database.
+type postgresExtension struct {
+	database       string
+	name           string
+	version        string
+	schema         string
+	defaultVersion string
+}
+
+// parsePostgresExtensions parses PGResult and returns installed extensions.
+func parsePostgresExtensions(r *model.PGResult) []postgresExtension {
+	log.Debug("parse postgres extensions")
+
+	var extensions = make([]postgresExtension, 0, r.Nrows)
+
+	for _, row := range r.Rows {
+		ext := postgresExtension{}
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				ext.database = row[i].String
+			case "name":
+				ext.name = row[i].String
+			case "version":
+				ext.version = row[i].String
+			case "schema":
+				ext.schema = row[i].String
+			case "default_version":
+				ext.defaultVersion = row[i].String
+			}
+		}
+
+		extensions = append(extensions, ext)
+	}
+
+	return extensions
+}
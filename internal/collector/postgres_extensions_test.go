@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresExtensionsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_extension_info",
+			"postgres_extension_update_available",
+		},
+		collector: NewPostgresExtensionsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresExtensions(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 5,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("name")}, {Name: []byte("version")},
+			{Name: []byte("schema")}, {Name: []byte("default_version")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "plpgsql", Valid: true}, {String: "1.0", Valid: true},
+				{String: "pg_catalog", Valid: true}, {String: "1.0", Valid: true},
+			},
+			{
+				{String: "testdb", Valid: true}, {String: "pg_stat_statements", Valid: true}, {String: "1.10", Valid: true},
+				{String: "public", Valid: true}, {String: "1.11", Valid: true},
+			},
+		},
+	}
+
+	want := []postgresExtension{
+		{database: "testdb", name: "plpgsql", version: "1.0", schema: "pg_catalog", defaultVersion: "1.0"},
+		{database: "testdb", name: "pg_stat_statements", version: "1.10", schema: "public", defaultVersion: "1.11"},
+	}
+
+	assert.Equal(t, want, parsePostgresExtensions(res))
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
-	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -51,7 +50,7 @@ func NewPostgresFunctionsCollector(constLabels labels, settings model.CollectorS
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -81,12 +80,7 @@ func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.
 		return err
 	}
 
-	databases, err := listDatabases(conn)
-	if err != nil {
-		return err
-	}
-
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
 	if err != nil {
 		return err
 	}
@@ -97,13 +91,12 @@ func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.
 			continue
 		}
 
-		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := config.acquireDatabaseConn(d)
 		if err != nil {
 			return err
 		}
-		defer conn.Close()
 		err = collect(conn)
+		conn.Close()
 		if err != nil {
 			return err
 		}
@@ -112,6 +105,11 @@ func (c *postgresFunctionsCollector) Update(config Config, ch chan<- prometheus.
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresFunctionsCollector) executedQueries(config Config) []string {
+	return perDatabaseQueries(config, postgresFunctionsQuery)
+}
+
 // postgresFunctionStat represents Postgres function stats based pg_stat_user_functions.
 type postgresFunctionStat struct {
 	database  string
@@ -2,8 +2,14 @@
 package collector
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -13,8 +19,16 @@ import (
 )
 
 const (
+	// indexStatsAgeColumn defines expression for getting age of database statistics, i.e. how long indexes usage
+	// statistics have been accumulated since the last reset. The stats_reset is NULL when statistics have never been
+	// reset, in this case Postgres start time is used (statistics could be kept across restarts since Postgres 15, hence
+	// the age is underestimated).
+	indexStatsAgeColumn = "EXTRACT(EPOCH FROM now() - COALESCE((SELECT stats_reset FROM pg_stat_database WHERE datname = current_database()), " +
+		"pg_postmaster_start_time())) AS stats_age_seconds"
+
 	userIndexesQuery = "SELECT current_database() AS database, schemaname AS schema, relname AS table, indexrelname AS index, (i.indisprimary OR i.indisunique) AS key," +
-		"i.indisvalid AS isvalid, idx_scan, idx_tup_read, idx_tup_fetch, idx_blks_read, idx_blks_hit, pg_relation_size(s1.indexrelid) AS size_bytes " +
+		"i.indisvalid AS isvalid, idx_scan, idx_tup_read, idx_tup_fetch, idx_blks_read, idx_blks_hit, pg_relation_size(s1.indexrelid) AS size_bytes, " +
+		indexStatsAgeColumn + " " +
 		"FROM pg_stat_user_indexes s1 " +
 		"JOIN pg_statio_user_indexes s2 USING (schemaname, relname, indexrelname) " +
 		"JOIN pg_index i ON (s1.indexrelid = i.indexrelid) " +
@@ -22,7 +36,7 @@ const (
 
 	userIndexesQueryTopK = "WITH stat AS (SELECT schemaname AS schema, relname AS table, indexrelname AS index, (i.indisprimary OR i.indisunique) AS key, " +
 		"i.indisvalid AS isvalid, idx_scan, idx_tup_read, idx_tup_fetch, idx_blks_read, idx_blks_hit, pg_relation_size(s1.indexrelid) AS size_bytes, " +
-		"NOT i.indisvalid OR /* unused and size > 50mb */ (idx_scan = 0 AND pg_relation_size(s1.indexrelid) > 50*1024*1024) OR " +
+		indexStatsAgeColumn + ", NOT i.indisvalid OR /* unused and size > 50mb */ (idx_scan = 0 AND pg_relation_size(s1.indexrelid) > 50*1024*1024) OR " +
 		"(row_number() OVER (ORDER BY idx_scan DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY idx_tup_read DESC NULLS LAST) < $1) OR " +
 		"(row_number() OVER (ORDER BY idx_tup_fetch DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY idx_blks_read DESC NULLS LAST) < $1) OR " +
 		"(row_number() OVER (ORDER BY idx_blks_hit DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY pg_relation_size(s1.indexrelid) DESC NULLS LAST) < $1) AS visible " +
@@ -31,19 +45,76 @@ const (
 		"JOIN pg_index i ON (s1.indexrelid = i.indexrelid) " +
 		"WHERE NOT EXISTS ( SELECT 1 FROM pg_locks WHERE relation = s1.indexrelid AND mode = 'AccessExclusiveLock')) " +
 		"SELECT current_database() AS database, \"schema\", \"table\", \"index\", \"key\", isvalid, idx_scan, idx_tup_read, idx_tup_fetch, " +
-		"idx_blks_read, idx_blks_hit, size_bytes FROM stat WHERE visible " +
+		"idx_blks_read, idx_blks_hit, size_bytes, stats_age_seconds FROM stat WHERE visible " +
 		"UNION ALL SELECT current_database() AS database, 'all_shemas', 'all_other_tables', 'all_other_indexes', true, null, " +
 		"NULLIF(SUM(COALESCE(idx_scan,0)),0), NULLIF(SUM(COALESCE(idx_tup_fetch,0)),0), NULLIF(SUM(COALESCE(idx_tup_read,0)),0), " +
 		"NULLIF(SUM(COALESCE(idx_blks_read,0)),0), NULLIF(SUM(COALESCE(idx_blks_hit,0)),0), " +
-		"NULLIF(SUM(COALESCE(size_bytes,0)),0) FROM stat WHERE NOT visible HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible)"
+		"NULLIF(SUM(COALESCE(size_bytes,0)),0), NULL FROM stat WHERE NOT visible HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible)"
+
+	// defaultIndexProbesTTL defines default interval during which probed stats of an index are reused.
+	defaultIndexProbesTTL = time.Hour
+
+	// indexProbesBatch defines max number of indexes probed during single update. Probes read pages of indexes, hence
+	// indexes are probed gradually across scrapes to avoid IO storms.
+	indexProbesBatch = 5
+
+	// indexProbesMaxTop defines max number of the largest indexes of each database probed when index_probes_top is set.
+	indexProbesMaxTop = 50
+
+	// indexProbeTimeout defines max time of probing a single index.
+	indexProbeTimeout = 10 * time.Second
+
+	// indexProbeLockTimeout defines max time of waiting for lock of the probed index, e.g. held by concurrent DDL.
+	indexProbeLockTimeout = time.Second
+
+	// indexProbesTopQuery defines query for selecting the largest valid indexes of access methods passed as array.
+	indexProbesTopQuery = "SELECT n.nspname AS schema, t.relname AS table, c.relname AS index, am.amname " +
+		"FROM pg_class c JOIN pg_index i ON i.indexrelid = c.oid JOIN pg_class t ON t.oid = i.indrelid " +
+		"JOIN pg_namespace n ON n.oid = c.relnamespace JOIN pg_am am ON am.oid = c.relam " +
+		"WHERE am.amname = ANY($1) AND i.indisvalid AND c.relpersistence = 'p' " +
+		"AND n.nspname NOT IN ('pg_catalog', 'information_schema') " +
+		"ORDER BY pg_relation_size(c.oid) DESC LIMIT $2"
+
+	// ginIndexProbeQuery defines query for pending list of GIN index using pgstatginindex() from pgstattuple extension.
+	ginIndexProbeQuery = "SELECT pending_pages::float8, pending_tuples::float8 " +
+		"FROM %s.pgstatginindex(format('%%I.%%I', $1::text, $2::text)::regclass)"
+
+	// brinIndexProbeQuery defines query for total and summarized ranges of BRIN index using pageinspect extension.
+	// Summarized ranges are the ranges having an entry in the index revmap, total number of ranges is derived from the
+	// size of the table.
+	brinIndexProbeQuery = "SELECT ceil(pg_relation_size(i.indrelid) / current_setting('block_size')::numeric / m.pagesperrange)::float8, " +
+		"(SELECT count(*) FROM generate_series(1, m.lastrevmappage) b, " +
+		"LATERAL %[1]s.brin_revmap_data(%[1]s.get_raw_page(format('%%I.%%I', $1::text, $2::text), b::int)) r(pages) WHERE r.pages <> '(0,0)')::float8 " +
+		"FROM pg_index i, LATERAL %[1]s.brin_metapage_info(%[1]s.get_raw_page(format('%%I.%%I', $1::text, $2::text), 0)) m " +
+		"WHERE i.indexrelid = format('%%I.%%I', $1::text, $2::text)::regclass"
 )
 
 // postgresIndexesCollector defines metric descriptors and stats store.
 type postgresIndexesCollector struct {
-	indexes typedDesc
-	tuples  typedDesc
-	io      typedDesc
-	sizes   typedDesc
+	indexes     typedDesc
+	tuples      typedDesc
+	io          typedDesc
+	sizes       typedDesc
+	unused      typedDesc
+	unusedSince typedDesc
+	ginPending  typedDesc
+	ginTuples   typedDesc
+	brinRanges  typedDesc
+	brinSummary typedDesc
+	// probes keeps probed GIN and BRIN indexes, keyed by database/schema/table/index.
+	probes map[string]indexProbeSample
+	mu     sync.Mutex
+}
+
+// indexProbeSample represents stats of GIN or BRIN index probed using inspection functions of extensions.
+type indexProbeSample struct {
+	database string
+	schema   string
+	table    string
+	index    string
+	amname   string
+	values   [2]float64
+	sampled  time.Time
 }
 
 // NewPostgresIndexesCollector returns a new Collector exposing postgres indexes stats.
@@ -76,19 +147,70 @@ func NewPostgresIndexesCollector(constLabels labels, settings model.CollectorSet
 			[]string{"database", "schema", "table", "index"}, constLabels,
 			settings.Filters,
 		),
+		unused: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "unused_bytes", "Number of bytes occupied by valid non-key index which has not been scanned since statistics reset.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		unusedSince: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "unused_since_reset_seconds", "Time since statistics reset (or Postgres start, if statistics have never been reset) during which valid non-key index has not been scanned, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		ginPending: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "gin_pending_pages", "Number of pages in the pending list of GIN index.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		ginTuples: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "gin_pending_tuples", "Number of tuples in the pending list of GIN index.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		brinRanges: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "brin_ranges", "Total number of block ranges of the table covered by BRIN index.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		brinSummary: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "brin_summarized_ranges", "Number of block ranges summarized in BRIN index.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+		probes: map[string]indexProbeSample{},
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	var err error
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	collect := func(conn *store.DB) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := config.IndexProbesTTL
+	if ttl <= 0 {
+		ttl = defaultIndexProbesTTL
+	}
+
+	budget := indexProbesBatch
+	if config.bypassCache {
+		budget = indexProbesMaxTop
+	}
+	candidates := map[string]bool{}
+
+	collect := func(conn *store.DB, database string) error {
 		var res *model.PGResult
 		if config.CollectTopIndex > 0 {
 			res, err = conn.Query(userIndexesQueryTopK, config.CollectTopIndex)
@@ -120,24 +242,40 @@ func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Me
 			if stat.idxhit > 0 {
 				ch <- c.io.newConstMetric(stat.idxhit, stat.database, stat.schema, stat.table, stat.index, "hit")
 			}
+
+			// Unique and primary key indexes enforce constraints and can't be dropped even if never scanned, invalid
+			// indexes are reported by schemas collector. Aggregated 'all_other_indexes' row has no validity flag.
+			if stat.idxscan == 0 && stat.key == "false" && stat.isvalid == "true" {
+				ch <- c.unused.newConstMetric(stat.sizebytes, stat.database, stat.schema, stat.table, stat.index)
+				if stat.statsage > 0 {
+					ch <- c.unusedSince.newConstMetric(stat.statsage, stat.database, stat.schema, stat.table, stat.index)
+				}
+			}
+		}
+
+		if config.IndexProbesTop > 0 {
+			budget = c.probeDatabase(conn, database, config, ttl, budget, candidates)
 		}
 		return nil
 	}
 
 	if config.DatabasesRE == nil {
 		// service discovery case
-		err = collect(conn)
-		return err
+		err = collect(conn, conn.Conn().Config().Database)
+		if err != nil {
+			return err
+		}
+		c.sendIndexProbes(candidates, ch)
+		return nil
 	}
 
-	databases, err := listDatabases(conn)
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
 	if err != nil {
 		return err
 	}
 
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
-	if err != nil {
-		return err
+	if config.bypassCache {
+		budget = indexProbesMaxTop * len(databases)
 	}
 
 	for _, d := range databases {
@@ -146,21 +284,153 @@ func (c *postgresIndexesCollector) Update(config Config, ch chan<- prometheus.Me
 			continue
 		}
 
-		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := config.acquireDatabaseConn(d)
 		if err != nil {
 			return err
 		}
-		defer conn.Close()
-		err = collect(conn)
+		err = collect(conn, d)
+		conn.Close()
 		if err != nil {
 			return err
 		}
 	}
 
+	c.sendIndexProbes(candidates, ch)
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresIndexesCollector) executedQueries(config Config) []string {
+	query := userIndexesQuery
+	if config.CollectTopIndex > 0 {
+		query = userIndexesQueryTopK
+	}
+	if config.IndexProbesTop <= 0 {
+		return perDatabaseQueries(config, query)
+	}
+
+	queries := []string{query, postgresExtensionSchemaQuery, indexProbesTopQuery}
+	queries = append(queries, sortedQueries(selectIndexProbeQueries(config.extensionsSchemas))...)
+
+	return perDatabaseQueries(config, queries...)
+}
+
+// probeDatabase probes the largest GIN and BRIN indexes of the database which samples are older than ttl, at most
+// budget indexes are probed. Indexes are probed only when extensions providing inspection functions are installed in
+// the database: pgstattuple for GIN pending lists, pageinspect for BRIN ranges. Keys of selected indexes are put into
+// candidates. Remaining budget is returned.
+func (c *postgresIndexesCollector) probeDatabase(conn *store.DB, database string, config Config, ttl time.Duration, budget int, candidates map[string]bool) int {
+	queries := selectIndexProbeQueries(map[string]string{
+		"pgstattuple": extensionInstalledSchema(conn, "pgstattuple"),
+		"pageinspect": extensionInstalledSchema(conn, "pageinspect"),
+	})
+	if len(queries) == 0 {
+		log.Debugf("[postgres indexes collector]: pgstattuple and pageinspect extensions are not installed in database %s, skip probes", database)
+		return budget
+	}
+
+	res, err := conn.Query(indexProbesTopQuery, slices.Collect(maps.Keys(queries)), min(config.IndexProbesTop, indexProbesMaxTop))
+	if err != nil {
+		log.Warnf("get largest GIN and BRIN indexes of database %s failed: %s; skip", database, err)
+		return budget
+	}
+
+	indexes := make([]indexProbeSample, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		indexes = append(indexes, indexProbeSample{database: database, schema: row[0].String, table: row[1].String, index: row[2].String, amname: row[3].String})
+	}
+
+	// Probe indexes with the oldest samples first, never probed indexes go first.
+	slices.SortStableFunc(indexes, func(a, b indexProbeSample) int {
+		return c.probes[a.key()].sampled.Compare(c.probes[b.key()].sampled)
+	})
+
+	for _, idx := range indexes {
+		key := idx.key()
+		candidates[key] = true
+
+		if s, ok := c.probes[key]; budget <= 0 || (ok && time.Since(s.sampled) < ttl && !config.bypassCache) {
+			continue
+		}
+		budget--
+
+		idx.values, err = probeIndex(conn, queries[idx.amname], idx.schema, idx.index)
+		if err != nil {
+			log.Warnf("probe %s index %s.%s.%s failed: %s; skip", idx.amname, database, idx.schema, idx.index, err)
+			continue
+		}
+		idx.sampled = time.Now()
+		c.probes[key] = idx
+	}
+
+	return budget
+}
+
+// selectIndexProbeQueries returns queries probing indexes by access methods, depending on schemas where pgstattuple and
+// pageinspect extensions are installed. Access methods which extensions are not installed are omitted.
+func selectIndexProbeQueries(schemas map[string]string) map[string]string {
+	queries := map[string]string{}
+	if schema := schemas["pgstattuple"]; schema != "" {
+		queries["gin"] = fmt.Sprintf(ginIndexProbeQuery, schema)
+	}
+	if schema := schemas["pageinspect"]; schema != "" {
+		queries["brin"] = fmt.Sprintf(brinIndexProbeQuery, schema)
+	}
+	return queries
+}
+
+// sendIndexProbes forgets indexes which are not selected anymore and sends stats of probed indexes.
+func (c *postgresIndexesCollector) sendIndexProbes(candidates map[string]bool, ch chan<- prometheus.Metric) {
+	for key := range c.probes {
+		if !candidates[key] {
+			delete(c.probes, key)
+		}
+	}
+
+	for _, s := range c.probes {
+		switch s.amname {
+		case "gin":
+			ch <- c.ginPending.newConstMetric(s.values[0], s.database, s.schema, s.table, s.index)
+			ch <- c.ginTuples.newConstMetric(s.values[1], s.database, s.schema, s.table, s.index)
+		case "brin":
+			ch <- c.brinRanges.newConstMetric(s.values[0], s.database, s.schema, s.table, s.index)
+			ch <- c.brinSummary.newConstMetric(s.values[1], s.database, s.schema, s.table, s.index)
+		}
+	}
+}
+
+// key returns key of the probed index.
+func (s indexProbeSample) key() string {
+	return strings.Join([]string{s.database, s.schema, s.table, s.index}, "/")
+}
+
+// probeIndex returns two values produced by the probe query. The index is probed within read-only transaction with
+// limited lock_timeout, hence the probe fails instead of waiting when the index is locked by concurrent DDL.
+func probeIndex(conn *store.DB, query, schema, index string) ([2]float64, error) {
+	var values [2]float64
+
+	ctx, cancel := context.WithTimeout(context.Background(), indexProbeTimeout)
+	defer cancel()
+
+	tx, err := conn.Conn().BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return values, err
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	_, err = tx.Exec(ctx, "SET LOCAL lock_timeout = "+strconv.Itoa(int(indexProbeLockTimeout.Milliseconds())))
+	if err != nil {
+		return values, err
+	}
+
+	err = tx.QueryRow(ctx, query, schema, index).Scan(&values[0], &values[1])
+	if err != nil {
+		return values, err
+	}
+
+	return values, nil
+}
+
 // postgresIndexStat is per-index store for metrics related to how indexes are accessed.
 type postgresIndexStat struct {
 	database    string
@@ -175,6 +445,7 @@ type postgresIndexStat struct {
 	idxread     float64
 	idxhit      float64
 	sizebytes   float64
+	statsage    float64
 }
 
 // parsePostgresIndexStats parses PGResult and returns structs with stats values.
@@ -242,6 +513,8 @@ func parsePostgresIndexStats(r *model.PGResult, labelNames []string) map[string]
 				s.idxhit = v
 			case "size_bytes":
 				s.sizebytes = v
+			case "stats_age_seconds":
+				s.statsage = v
 			default:
 				continue
 			}
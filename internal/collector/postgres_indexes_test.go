@@ -2,9 +2,12 @@ package collector
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -15,6 +18,12 @@ func TestPostgresIndexesCollector_Update(t *testing.T) {
 			"postgres_index_tuples_total",
 			"postgres_index_io_blocks_total",
 			"postgres_index_size_bytes",
+			"postgres_index_unused_bytes",
+			"postgres_index_unused_since_reset_seconds",
+			"postgres_index_gin_pending_pages",
+			"postgres_index_gin_pending_tuples",
+			"postgres_index_brin_ranges",
+			"postgres_index_brin_summarized_ranges",
 		},
 		collector: NewPostgresIndexesCollector,
 		service:   model.ServiceTypePostgresql,
@@ -53,6 +62,31 @@ func Test_parsePostgresIndexStats(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "unused index",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 9,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("table")}, {Name: []byte("index")},
+					{Name: []byte("key")}, {Name: []byte("isvalid")}, {Name: []byte("idx_scan")}, {Name: []byte("size_bytes")},
+					{Name: []byte("stats_age_seconds")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "testdb", Valid: true}, {String: "testschema", Valid: true}, {String: "testrelname", Valid: true}, {String: "testindex", Valid: true},
+						{String: "false", Valid: true}, {String: "true", Valid: true}, {String: "0", Valid: true}, {String: "16384", Valid: true},
+						{String: "86400.5", Valid: true},
+					},
+				},
+			},
+			want: map[string]postgresIndexStat{
+				"testdb/testschema/testrelname/testindex": {
+					database: "testdb", schema: "testschema", table: "testrelname", index: "testindex", key: "false", isvalid: "true",
+					sizebytes: 16384, statsage: 86400.5,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -62,3 +96,33 @@ func Test_parsePostgresIndexStats(t *testing.T) {
 		})
 	}
 }
+
+func Test_indexProbeQueries(t *testing.T) {
+	gin := fmt.Sprintf(ginIndexProbeQuery, "public")
+	assert.NotContains(t, gin, "%!")
+	assert.Contains(t, gin, "FROM public.pgstatginindex(format('%I.%I', $1::text, $2::text)::regclass)")
+
+	brin := fmt.Sprintf(brinIndexProbeQuery, "ext")
+	assert.NotContains(t, brin, "%!")
+	assert.Contains(t, brin, "ext.brin_metapage_info(ext.get_raw_page(format('%I.%I', $1::text, $2::text), 0)) m")
+	assert.NotContains(t, brin, "pg_locks")
+}
+
+func Test_postgresIndexesCollector_sendIndexProbes(t *testing.T) {
+	c, err := NewPostgresIndexesCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	ic := c.(*postgresIndexesCollector)
+
+	gin := indexProbeSample{database: "testdb", schema: "public", table: "docs", index: "docs_body_gin", amname: "gin", values: [2]float64{4, 120}}
+	brin := indexProbeSample{database: "testdb", schema: "public", table: "events", index: "events_ts_brin", amname: "brin", values: [2]float64{120, 117}}
+	ic.probes[gin.key()] = gin
+	ic.probes[brin.key()] = brin
+
+	// BRIN index is not the largest one anymore and should be forgotten.
+	ch := make(chan prometheus.Metric, 10)
+	ic.sendIndexProbes(map[string]bool{"testdb/public/docs/docs_body_gin": true}, ch)
+	close(ch)
+
+	assert.Len(t, ch, 2)
+	assert.Equal(t, map[string]indexProbeSample{gin.key(): gin}, ic.probes)
+}
@@ -0,0 +1,323 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sqlUnavailableCollectors defines collectors executed when SQL access to the local service is rejected. Storage and
+// logs collectors read data directory directly, activity collector reports the service is not accessible.
+var sqlUnavailableCollectors = []string{
+	"postgres/activity",
+	"postgres/storage",
+	"postgres/logs",
+}
+
+// postmasterInfo defines properties of the local postmaster taken from its postmaster.pid file.
+type postmasterInfo struct {
+	pid             int
+	dataDirectory   string
+	port            uint16
+	socketDirectory string
+}
+
+// parsePostmasterPid parses content of postmaster.pid file. Lines of the file are: PID, data directory, start time,
+// port, first Unix socket directory, listen address, shared memory key and status.
+func parsePostmasterPid(r io.Reader) (postmasterInfo, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return postmasterInfo{}, err
+	}
+
+	if len(lines) < 4 {
+		return postmasterInfo{}, fmt.Errorf("invalid input, too few lines in postmaster.pid: %d", len(lines))
+	}
+
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil {
+		return postmasterInfo{}, fmt.Errorf("invalid input, parse pid '%s' failed: %s", lines[0], err)
+	}
+
+	port, err := strconv.ParseUint(lines[3], 10, 16)
+	if err != nil {
+		return postmasterInfo{}, fmt.Errorf("invalid input, parse port '%s' failed: %s", lines[3], err)
+	}
+
+	info := postmasterInfo{pid: pid, dataDirectory: lines[1], port: uint16(port)}
+	if len(lines) > 4 {
+		info.socketDirectory = lines[4]
+	}
+
+	return info, nil
+}
+
+// findLocalPostmasters returns postmasters running on the local host. Postmaster changes its working directory to
+// the data directory, hence postmaster.pid is found there. Postmasters which files are not accessible are skipped.
+func findLocalPostmasters(procRoot string) []postmasterInfo {
+	pids, err := findPostmasterPIDs(procRoot)
+	if err != nil {
+		log.Warnf("find postmaster processes failed: %s; skip", err)
+		return nil
+	}
+
+	var postmasters []postmasterInfo
+	for _, pid := range pids {
+		datadir, err := os.Readlink(filepath.Join(procRoot, strconv.Itoa(pid), "cwd"))
+		if err != nil {
+			log.Debugf("read working directory of postmaster (pid %d) failed: %s; skip", pid, err)
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(datadir, "postmaster.pid"))
+		if err != nil {
+			log.Debugf("open postmaster.pid of postmaster (pid %d) failed: %s; skip", pid, err)
+			continue
+		}
+
+		info, err := parsePostmasterPid(f)
+		_ = f.Close()
+		if err != nil {
+			log.Warnf("parse postmaster.pid of postmaster (pid %d) failed: %s; skip", pid, err)
+			continue
+		}
+
+		postmasters = append(postmasters, info)
+	}
+
+	return postmasters
+}
+
+// ResolveSocketDirectory returns connection string with Unix socket directory of the local postmaster, when passed
+// connection string points to the socket directory where socket of the port doesn't exist (e.g. socket directory is
+// not specified and default one differs from unix_socket_directories). Otherwise connection string is returned as is.
+func ResolveSocketDirectory(connStr string) string {
+	return resolveSocketDirectory(connStr, defaultProcRoot)
+}
+
+// resolveSocketDirectory returns connection string with Unix socket directory of postmaster found in procRoot.
+func resolveSocketDirectory(connStr string, procRoot string) string {
+	pgconfig, err := pgx.ParseConfig(connStr)
+	if err != nil || !strings.HasPrefix(pgconfig.Host, "/") {
+		return connStr
+	}
+
+	if _, err := os.Stat(filepath.Join(pgconfig.Host, fmt.Sprintf(".s.PGSQL.%d", pgconfig.Port))); err == nil {
+		return connStr
+	}
+
+	for _, p := range findLocalPostmasters(procRoot) {
+		if p.port != pgconfig.Port || p.socketDirectory == "" || p.socketDirectory == pgconfig.Host {
+			continue
+		}
+
+		log.Infof("socket of port %d not found in %s, use socket directory %s of postmaster (pid %d)", pgconfig.Port, pgconfig.Host, p.socketDirectory, p.pid)
+		return withConnHost(connStr, p.socketDirectory)
+	}
+
+	return connStr
+}
+
+// withConnHost returns connection string with host replaced by passed one. Both URL and keyword/value formats are
+// supported, host specified in query parameters or the last keyword takes precedence.
+func withConnHost(connStr string, host string) string {
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return connStr
+		}
+		q := u.Query()
+		q.Set("host", host)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	host = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(host)
+	return strings.TrimSpace(connStr + " host='" + host + "'")
+}
+
+// isPeerAuthError returns true if connection has been rejected by peer or ident authentication, i.e. operating
+// system user of pgSCV doesn't match the database user.
+func isPeerAuthError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "28000" {
+		return false
+	}
+
+	return strings.HasPrefix(pgErr.Message, "Peer authentication failed") || strings.HasPrefix(pgErr.Message, "Ident authentication failed")
+}
+
+// newLocalPostgresServiceConfig returns config of the local service connected via Unix socket, when SQL access is
+// not available. Settings are taken from files of postmaster listening the port of the service.
+func newLocalPostgresServiceConfig(connStr string, procRoot string) (postgresServiceConfig, error) {
+	var config = postgresServiceConfig{}
+
+	pgconfig, err := pgx.ParseConfig(connStr)
+	if err != nil {
+		return config, err
+	}
+
+	if !strings.HasPrefix(pgconfig.Host, "/") {
+		return config, fmt.Errorf("service is not connected via Unix socket")
+	}
+
+	postmasters := findLocalPostmasters(procRoot)
+	idx := slices.IndexFunc(postmasters, func(p postmasterInfo) bool { return p.port == pgconfig.Port })
+	if idx < 0 {
+		return config, fmt.Errorf("postmaster listening port %d not found", pgconfig.Port)
+	}
+	postmaster := postmasters[idx]
+
+	version, err := os.ReadFile(filepath.Join(postmaster.dataDirectory, "PG_VERSION"))
+	if err != nil {
+		return config, err
+	}
+
+	config.pgVersion.Short = strings.TrimSpace(string(version))
+	config.pgVersion.Numeric, err = parsePostgresMajorVersion(config.pgVersion.Short)
+	if err != nil {
+		return config, err
+	}
+
+	config.localService = true
+	config.sqlUnavailable = true
+	config.rolConnLimit = -1
+	config.dataDirectory = postmaster.dataDirectory
+
+	// current_logfiles is written only when logging_collector is enabled.
+	logfiles, err := readCurrentLogfiles(postmaster.dataDirectory)
+	if err == nil && len(logfiles) > 0 {
+		destinations := make([]string, 0, len(logfiles))
+		for d := range logfiles {
+			destinations = append(destinations, d)
+		}
+		slices.Sort(destinations)
+
+		config.loggingCollector = true
+		config.logDestination = strings.Join(destinations, ",")
+	}
+
+	return config, nil
+}
+
+// parsePostgresMajorVersion parses content of PG_VERSION file and returns numeric version, e.g. 160000 for '16' and
+// 90600 for '9.6'.
+func parsePostgresMajorVersion(s string) (int, error) {
+	major, minor, found := strings.Cut(s, ".")
+
+	v, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("invalid input, parse version '%s' failed: %s", s, err)
+	}
+
+	if !found {
+		return v * 10000, nil
+	}
+
+	m, err := strconv.Atoi(minor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid input, parse version '%s' failed: %s", s, err)
+	}
+
+	return v*10000 + m*100, nil
+}
+
+// readCurrentLogfiles reads current_logfiles file in data directory and returns absolute paths of log files currently
+// written by logging collector, by log destination.
+func readCurrentLogfiles(datadir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(datadir, "current_logfiles"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	logfiles := map[string]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		destination, logfile, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+
+		if !strings.HasPrefix(logfile, "/") {
+			logfile = filepath.Join(datadir, logfile)
+		}
+		logfiles[destination] = logfile
+	}
+
+	return logfiles, scanner.Err()
+}
+
+// readCurrentLogfile returns path to stderr logfile currently written by logging collector.
+func readCurrentLogfile(datadir string) (string, error) {
+	logfiles, err := readCurrentLogfiles(datadir)
+	if err != nil {
+		return "", err
+	}
+
+	logfile, ok := logfiles["stderr"]
+	if !ok {
+		return "", fmt.Errorf("stderr logfile not found in current_logfiles")
+	}
+
+	return logfile, nil
+}
+
+// serviceCapabilities defines metrics of capabilities available for collecting metrics of Postgres service.
+type serviceCapabilities struct {
+	capability typedDesc
+}
+
+// newServiceCapabilities creates new serviceCapabilities.
+func newServiceCapabilities(constLabels labels) *serviceCapabilities {
+	return &serviceCapabilities{
+		capability: newBuiltinTypedDesc(
+			descOpts{"pgscv", "service", "capability", "Capability available for collecting metrics of the service: sql, filesystem or logs.", 0},
+			prometheus.GaugeValue,
+			[]string{"capability"}, constLabels,
+			filter.New(),
+		),
+	}
+}
+
+// send sends capabilities of Postgres service into channel. SQL is available when service configuration has been
+// requested using SQL, filesystem and logs are available for local services only.
+func (c *serviceCapabilities) send(config Config, ch chan<- prometheus.Metric) {
+	if config.ServiceType != model.ServiceTypePostgresql {
+		return
+	}
+
+	capabilities := map[string]bool{
+		"sql":        config.blockSize != 0,
+		"filesystem": config.localService && config.dataDirectory != "",
+		"logs":       config.localService && config.loggingCollector && logDestinationStderr(config.logDestination),
+	}
+
+	for name, available := range capabilities {
+		var v float64
+		if available {
+			v = 1
+		}
+		ch <- c.capability.newConstMetric(v, name)
+	}
+}
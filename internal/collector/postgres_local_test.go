@@ -0,0 +1,192 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestPostmaster creates procfs and data directory of postmaster listening passed port, and returns procfs root
+// and data directory.
+func newTestPostmaster(t *testing.T, port int, socketDir string) (string, string) {
+	procRoot, datadir := t.TempDir(), t.TempDir()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(procRoot, "4242"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(procRoot, "4242", "cmdline"), []byte("/usr/lib/postgresql/16/bin/postgres\x00-D\x00"+datadir+"\x00"), 0644))
+	assert.NoError(t, os.Symlink(datadir, filepath.Join(procRoot, "4242", "cwd")))
+
+	pidfile := fmt.Sprintf("4242\n%s\n1760000000\n%d\n%s\n*\n  5433001   1048576\nready   \n", datadir, port, socketDir)
+	assert.NoError(t, os.WriteFile(filepath.Join(datadir, "postmaster.pid"), []byte(pidfile), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(datadir, "PG_VERSION"), []byte("16\n"), 0600))
+
+	return procRoot, datadir
+}
+
+func Test_parsePostmasterPid(t *testing.T) {
+	f, err := os.Open("testdata/datadir/postmaster.pid.golden")
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	info, err := parsePostmasterPid(f)
+	assert.NoError(t, err)
+	assert.Equal(t, postmasterInfo{pid: 4242, dataDirectory: "/var/lib/postgresql/16/main", port: 5433, socketDirectory: "/run/postgresql"}, info)
+
+	// Invalid input.
+	for _, in := range []string{"", "4242\n/data\n", "invalid\n/data\n1760000000\n5432\n", "4242\n/data\n1760000000\ninvalid\n"} {
+		_, err = parsePostmasterPid(strings.NewReader(in))
+		assert.Error(t, err)
+	}
+}
+
+func Test_resolveSocketDirectory(t *testing.T) {
+	procRoot, _ := newTestPostmaster(t, 5433, "/run/postgresql-custom")
+
+	// Socket not found in specified directory, directory of postmaster is used.
+	assert.Equal(t,
+		"host=/nonexistent port=5433 user=postgres host='/run/postgresql-custom'",
+		resolveSocketDirectory("host=/nonexistent port=5433 user=postgres", procRoot),
+	)
+
+	// Another port.
+	assert.Equal(t, "host=/nonexistent port=5434 user=postgres", resolveSocketDirectory("host=/nonexistent port=5434 user=postgres", procRoot))
+
+	// Not a Unix socket.
+	assert.Equal(t, "host=127.0.0.1 port=5433 user=postgres", resolveSocketDirectory("host=127.0.0.1 port=5433 user=postgres", procRoot))
+
+	// Socket exists.
+	socketDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(socketDir, ".s.PGSQL.5433"), nil, 0600))
+	connStr := "host=" + socketDir + " port=5433 user=postgres"
+	assert.Equal(t, connStr, resolveSocketDirectory(connStr, procRoot))
+}
+
+func Test_withConnHost(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want string
+	}{
+		{in: "port=5432 user=postgres", want: "port=5432 user=postgres host='/run/postgresql'"},
+		{in: "", want: "host='/run/postgresql'"},
+		{in: "postgres://postgres@/postgres", want: "postgres://postgres@/postgres?host=%2Frun%2Fpostgresql"},
+		{in: "postgresql://postgres@/postgres?host=/tmp", want: "postgresql://postgres@/postgres?host=%2Frun%2Fpostgresql"},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, withConnHost(tc.in, "/run/postgresql"))
+	}
+}
+
+func Test_isPeerAuthError(t *testing.T) {
+	assert.True(t, isPeerAuthError(&pgconn.PgError{Code: "28000", Message: `Peer authentication failed for user "postgres"`}))
+	assert.True(t, isPeerAuthError(fmt.Errorf("connect failed: %w", &pgconn.PgError{Code: "28000", Message: `Ident authentication failed for user "postgres"`})))
+	assert.False(t, isPeerAuthError(&pgconn.PgError{Code: "28000", Message: `no pg_hba.conf entry for host "[local]", user "postgres"`}))
+	assert.False(t, isPeerAuthError(&pgconn.PgError{Code: "28P01", Message: `password authentication failed for user "postgres"`}))
+	assert.False(t, isPeerAuthError(fmt.Errorf("connection refused")))
+}
+
+func Test_newLocalPostgresServiceConfig(t *testing.T) {
+	procRoot, datadir := newTestPostmaster(t, 5433, "/run/postgresql")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(datadir, "log"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(datadir, "current_logfiles"), []byte("stderr log/postgresql.log\ncsvlog /var/log/postgresql/postgresql.csv\n"), 0600))
+
+	config, err := newLocalPostgresServiceConfig("host=/run/postgresql port=5433 user=postgres", procRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, postgresServiceConfig{
+		localService:     true,
+		sqlUnavailable:   true,
+		rolConnLimit:     -1,
+		dataDirectory:    datadir,
+		pgVersion:        PostgresVersion{Short: "16", Numeric: 160000},
+		loggingCollector: true,
+		logDestination:   "csvlog,stderr",
+	}, config)
+
+	// Postmaster listening the port not found.
+	_, err = newLocalPostgresServiceConfig("host=/run/postgresql port=5434 user=postgres", procRoot)
+	assert.Error(t, err)
+
+	// Not a Unix socket.
+	_, err = newLocalPostgresServiceConfig("host=127.0.0.1 port=5433 user=postgres", procRoot)
+	assert.Error(t, err)
+}
+
+func Test_parsePostgresMajorVersion(t *testing.T) {
+	testcases := []struct {
+		in    string
+		valid bool
+		want  int
+	}{
+		{in: "16", valid: true, want: 160000},
+		{in: "9.6", valid: true, want: 90600},
+		{in: "invalid", valid: false},
+		{in: "9.invalid", valid: false},
+	}
+
+	for _, tc := range testcases {
+		got, err := parsePostgresMajorVersion(tc.in)
+		if tc.valid {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_readCurrentLogfile(t *testing.T) {
+	datadir := t.TempDir()
+
+	_, err := readCurrentLogfile(datadir)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(datadir, "current_logfiles"), []byte("csvlog log/postgresql.csv\n"), 0600))
+	_, err = readCurrentLogfile(datadir)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(datadir, "current_logfiles"), []byte("stderr log/postgresql.log\n"), 0600))
+	logfile, err := readCurrentLogfile(datadir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(datadir, "log/postgresql.log"), logfile)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(datadir, "current_logfiles"), []byte("stderr /var/log/postgresql/postgresql.log\n"), 0600))
+	logfile, err = readCurrentLogfile(datadir)
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/log/postgresql/postgresql.log", logfile)
+}
+
+func Test_serviceCapabilities(t *testing.T) {
+	c := newServiceCapabilities(labels{"service_id": "test"})
+
+	// Capabilities are reported for Postgres services only.
+	ch := make(chan prometheus.Metric, 10)
+	c.send(Config{ServiceType: model.ServiceTypePgbouncer}, ch)
+	assert.Len(t, ch, 0)
+
+	config := Config{ServiceType: model.ServiceTypePostgresql}
+	config.localService = true
+	config.sqlUnavailable = true
+	config.dataDirectory = "/data"
+	config.loggingCollector = true
+	config.logDestination = "csvlog,stderr"
+
+	c.send(config, ch)
+	close(ch)
+	assert.Len(t, ch, 3)
+
+	got := map[string]float64{}
+	for m := range ch {
+		var metric dto.Metric
+		assert.NoError(t, m.Write(&metric))
+		got[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"sql": 0, "filesystem": 1, "logs": 1}, got)
+}
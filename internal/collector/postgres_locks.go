@@ -6,7 +6,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -30,6 +29,7 @@ type postgresLocksCollector struct {
 	locks      typedDesc
 	locksAll   typedDesc
 	notgranted typedDesc
+	query      string
 }
 
 // NewPostgresLocksCollector creates new postgresLocksCollector.
@@ -53,19 +53,20 @@ func NewPostgresLocksCollector(constLabels labels, settings model.CollectorSetti
 			nil, constLabels,
 			settings.Filters,
 		),
+		query: settings.Query,
 	}, nil
 }
 
 // Update method collects locks metrics.
 func (c *postgresLocksCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
 	// get pg_stat_activity stats
-	res, err := conn.Query(locksQuery)
+	res, err := queryWithOverride(conn, "postgres/locks", c.query, locksQuery)
 	if err != nil {
 		return err
 	}
@@ -87,6 +88,11 @@ func (c *postgresLocksCollector) Update(config Config, ch chan<- prometheus.Metr
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresLocksCollector) executedQueries(_ Config) []string {
+	return []string{queryOrOverride(c.query, locksQuery)}
+}
+
 // locksStat describes locks statistics.
 type locksStat struct {
 	accessShareLock          float64
@@ -2,11 +2,14 @@
 package collector
 
 import (
+	"container/list"
 	"context"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -15,6 +18,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// postgresCurrentLogfileQuery defines query for data directory and current logfile of logging collector.
+	postgresCurrentLogfileQuery = "SELECT current_setting('data_directory'), pg_current_logfile()"
+
+	// logQueriesLimit defines max number of distinct queries accounted by stats parsed from logs, stats of the least
+	// recently logged queries are evicted when the limit is exceeded.
+	logQueriesLimit = 1000
+)
+
 // Current implementation has an issue described here: https://github.com/nxadm/tail/issues/18.
 // When attempting to tail previously tailed logfiles, new messages are not coming from the Lines channel.
 // At the same time, test Test_runTailLoop works as intended and doesn't show the problem.
@@ -24,19 +36,166 @@ type syncKV struct {
 	mu    sync.RWMutex
 }
 
+// recentKeys tracks the least recently used keys and limits number of tracked keys.
+type recentKeys[K comparable] struct {
+	limit int
+	order *list.List // keys ordered from the most to the least recently used
+	elems map[K]*list.Element
+}
+
+// newRecentKeys creates a new recentKeys with specified limit.
+func newRecentKeys[K comparable](limit int) *recentKeys[K] {
+	return &recentKeys[K]{limit: limit, order: list.New(), elems: map[K]*list.Element{}}
+}
+
+// touch marks the key as the most recently used and returns the least recently used key when limit is exceeded.
+// Returned key is not tracked anymore.
+func (r *recentKeys[K]) touch(key K) (K, bool) {
+	var evicted K
+
+	if e, ok := r.elems[key]; ok {
+		r.order.MoveToFront(e)
+		return evicted, false
+	}
+
+	r.elems[key] = r.order.PushFront(key)
+	if r.order.Len() <= r.limit {
+		return evicted, false
+	}
+
+	e := r.order.Back()
+	evicted = r.order.Remove(e).(K)
+	delete(r.elems, evicted)
+
+	return evicted, true
+}
+
+// tempFileKey defines a set of labels which temp files usage is accounted by.
+type tempFileKey struct {
+	user      string
+	database  string
+	queryHash string
+}
+
+// tempFileStat defines stats about temp files written by particular query.
+type tempFileStat struct {
+	query string // normalized query text
+	files float64
+	bytes float64
+}
+
+// syncTempFiles contains collected stats about temp files usage.
+type syncTempFiles struct {
+	store  map[tempFileKey]tempFileStat
+	recent *recentKeys[tempFileKey]
+	mu     sync.RWMutex
+}
+
+// auditKey defines a set of labels which pgaudit events are accounted by.
+type auditKey struct {
+	user      string
+	database  string
+	auditType string
+	class     string
+}
+
+// syncAuditEvents contains collected stats about pgaudit events.
+type syncAuditEvents struct {
+	store map[auditKey]float64
+	mu    sync.RWMutex
+}
+
+// slowPlanKey defines a set of labels which slow plans logged by auto_explain are accounted by.
+type slowPlanKey struct {
+	user      string
+	database  string
+	queryHash string
+}
+
+// slowPlanStat defines stats about slow plans of particular query.
+type slowPlanStat struct {
+	query    string // normalized query text
+	plans    float64
+	duration float64            // in seconds
+	nodes    map[string]float64 // number of plans which contain the plan node, by node name
+}
+
+// syncSlowPlans contains collected stats about slow plans logged by auto_explain.
+type syncSlowPlans struct {
+	store  map[slowPlanKey]slowPlanStat
+	recent *recentKeys[slowPlanKey]
+	mu     sync.RWMutex
+}
+
+// deadlockKey defines a set of labels which deadlocks are accounted by. Relations are ordered, so the same pair of
+// relations is accounted by the same key regardless of processes order.
+type deadlockKey struct {
+	user      string
+	database  string
+	relation1 string
+	relation2 string
+}
+
+// syncDeadlocks contains collected stats about deadlocks.
+type syncDeadlocks struct {
+	store map[deadlockKey]float64
+	mu    sync.RWMutex
+}
+
+// connectionKey defines a set of labels which connection events are accounted by.
+type connectionKey struct {
+	user     string
+	database string
+	event    string
+}
+
+// syncConnections contains collected stats about connection events logged with log_connections and log_disconnections.
+type syncConnections struct {
+	store map[connectionKey]float64
+	mu    sync.RWMutex
+}
+
+// syncConfigChanges contains collected stats about configuration reloads and changes of parameters.
+type syncConfigChanges struct {
+	reloads       float64
+	changes       map[string]float64 // number of changes by parameter name
+	lastParameter string             // name of the last changed parameter
+	lastChange    float64            // time when the last change has been logged, in unix seconds
+	mu            sync.RWMutex
+}
+
 type postgresLogsCollector struct {
-	updateLogfile   chan string // updateLogfile used for notify tail/collect goroutine when logfile has been changed.
-	currentLogfile  string      // currentLogfile contains logfile name currently tailed and used for collecting stat.
-	totals          syncKV      // totals contains collected stats about total number of log messages.
-	panics          syncKV      // panics contains all collected messages with PANIC severity.
-	fatals          syncKV      // fatals contains all collected messages with FATAL severity.
-	errors          syncKV      // errors contains all collected messages with ERROR severity.
-	warnings        syncKV      // warnings contains all collected messages with WARNING severity.
+	updateLogfile   chan string       // updateLogfile used for notify tail/collect goroutine when logfile has been changed.
+	currentLogfile  string            // currentLogfile contains logfile name currently tailed and used for collecting stat.
+	totals          syncKV            // totals contains collected stats about total number of log messages.
+	panics          syncKV            // panics contains all collected messages with PANIC severity.
+	fatals          syncKV            // fatals contains all collected messages with FATAL severity.
+	errors          syncKV            // errors contains all collected messages with ERROR severity.
+	warnings        syncKV            // warnings contains all collected messages with WARNING severity.
+	tempFiles       syncTempFiles     // tempFiles contains collected stats about temp files written by queries.
+	auditEvents     syncAuditEvents   // auditEvents contains collected stats about pgaudit events.
+	slowPlans       syncSlowPlans     // slowPlans contains collected stats about slow plans logged by auto_explain.
+	deadlocks       syncDeadlocks     // deadlocks contains collected stats about deadlocks by involved relations.
+	configChanges   syncConfigChanges // configChanges contains collected stats about configuration reloads and parameters changes.
+	connections     syncConnections   // connections contains collected stats about connection events.
 	messagesTotal   typedDesc
 	panicMessages   typedDesc
 	fatalMessages   typedDesc
 	errorMessages   typedDesc
 	warningMessages typedDesc
+	tempFilesTotal  typedDesc
+	tempBytesTotal  typedDesc
+	tempFilesQuery  typedDesc
+	auditTotal      typedDesc
+	slowPlansTotal  typedDesc
+	slowPlansTime   typedDesc
+	slowPlanNodes   typedDesc
+	slowPlansQuery  typedDesc
+	deadlocksTotal  typedDesc
+	configReloads   typedDesc
+	paramChanges    typedDesc
+	lastParamChange typedDesc
+	connsTotal      typedDesc
 }
 
 // NewPostgresLogsCollector creates new collector for Postgres log messages.
@@ -69,6 +228,32 @@ func NewPostgresLogsCollector(constLabels labels, settings model.CollectorSettin
 			store: map[string]float64{},
 			mu:    sync.RWMutex{},
 		},
+		tempFiles: syncTempFiles{
+			store:  map[tempFileKey]tempFileStat{},
+			recent: newRecentKeys[tempFileKey](logQueriesLimit),
+			mu:     sync.RWMutex{},
+		},
+		auditEvents: syncAuditEvents{
+			store: map[auditKey]float64{},
+			mu:    sync.RWMutex{},
+		},
+		slowPlans: syncSlowPlans{
+			store:  map[slowPlanKey]slowPlanStat{},
+			recent: newRecentKeys[slowPlanKey](logQueriesLimit),
+			mu:     sync.RWMutex{},
+		},
+		deadlocks: syncDeadlocks{
+			store: map[deadlockKey]float64{},
+			mu:    sync.RWMutex{},
+		},
+		configChanges: syncConfigChanges{
+			changes: map[string]float64{},
+			mu:      sync.RWMutex{},
+		},
+		connections: syncConnections{
+			store: map[connectionKey]float64{},
+			mu:    sync.RWMutex{},
+		},
 		messagesTotal: newBuiltinTypedDesc(
 			descOpts{"postgres", "log", "messages_total", "Total number of log messages written by each level.", 0},
 			prometheus.CounterValue,
@@ -99,6 +284,84 @@ func NewPostgresLogsCollector(constLabels labels, settings model.CollectorSettin
 			[]string{"msg"}, constLabels,
 			settings.Filters,
 		),
+		tempFilesTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "temp_files_total", "Total number of temporary files logged by each normalized query.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "query_hash"}, constLabels,
+			settings.Filters,
+		),
+		tempBytesTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "temp_bytes_total", "Total number of bytes written to temporary files logged by each normalized query.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "query_hash"}, constLabels,
+			settings.Filters,
+		),
+		tempFilesQuery: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "temp_files_query_info", "Labeled info about normalized queries which temporary files are logged.", 0},
+			prometheus.GaugeValue,
+			[]string{"user", "database", "query_hash", "query"}, constLabels,
+			settings.Filters,
+		),
+		auditTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "audit_events_total", "Total number of pgaudit events logged by audit type and class.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "type", "class"}, constLabels,
+			settings.Filters,
+		),
+		slowPlansTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "slow_plans_total", "Total number of slow plans logged by auto_explain for each normalized query.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "query_hash"}, constLabels,
+			settings.Filters,
+		),
+		slowPlansTime: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "slow_plans_duration_seconds_total", "Total duration of statements with slow plans logged by auto_explain for each normalized query, in seconds.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "query_hash"}, constLabels,
+			settings.Filters,
+		),
+		slowPlanNodes: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "slow_plan_nodes_total", "Total number of slow plans logged by auto_explain which contain the plan node, for each normalized query.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "query_hash", "node"}, constLabels,
+			settings.Filters,
+		),
+		slowPlansQuery: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "slow_plans_query_info", "Labeled info about normalized queries which slow plans are logged by auto_explain.", 0},
+			prometheus.GaugeValue,
+			[]string{"user", "database", "query_hash", "query"}, constLabels,
+			settings.Filters,
+		),
+		deadlocksTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "deadlocks_total", "Total number of deadlocks logged by each pair of involved relations.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "relation1", "relation2"}, constLabels,
+			settings.Filters,
+		),
+		configReloads: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "config_reloads_total", "Total number of configuration reloads logged on receiving SIGHUP.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		paramChanges: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "parameter_changes_total", "Total number of logged changes of each configuration parameter.", 0},
+			prometheus.CounterValue,
+			[]string{"parameter"}, constLabels,
+			settings.Filters,
+		),
+		lastParamChange: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "last_parameter_change_info", "Labeled information about the last changed configuration parameter, value is the time of change in unix seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"parameter"}, constLabels,
+			settings.Filters,
+		),
+		connsTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "log", "connections_total", "Total number of logged connection events (received, authorized, disconnected) by user and database.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "event"}, constLabels,
+			settings.Filters,
+		),
 	}
 
 	go runTailLoop(collector)
@@ -122,13 +385,19 @@ func (c *postgresLogsCollector) Update(config Config, ch chan<- prometheus.Metri
 		return nil
 	}
 
-	if config.logDestination != "stderr" {
-		log.Debugln("[postgres log collector]: PostgreSQL parameter log_destination not set to stderr, log collector disabled")
+	if !logDestinationStderr(config.logDestination) {
+		log.Debugln("[postgres log collector]: PostgreSQL parameter log_destination doesn't include stderr, log collector disabled")
 		return nil
 	}
 
-	// Notify log collector goroutine if logfile has been changed.
-	logfile, err := queryCurrentLogfile(config.ConnString, config.ConnTimeout)
+	// Notify log collector goroutine if logfile has been changed. Without SQL access logfile is taken from data directory.
+	var logfile string
+	var err error
+	if config.sqlUnavailable {
+		logfile, err = readCurrentLogfile(config.dataDirectory)
+	} else {
+		logfile, err = queryCurrentLogfile(config.ConnString, config.ConnTimeout)
+	}
 	if err != nil {
 		return err
 	}
@@ -175,9 +444,84 @@ func (c *postgresLogsCollector) Update(config Config, ch chan<- prometheus.Metri
 	}
 	c.warnings.mu.RUnlock()
 
+	// Temporary files.
+	c.tempFiles.mu.RLock()
+	for key, stat := range c.tempFiles.store {
+		ch <- c.tempFilesTotal.newConstMetric(stat.files, key.user, key.database, key.queryHash)
+		ch <- c.tempBytesTotal.newConstMetric(stat.bytes, key.user, key.database, key.queryHash)
+		ch <- c.tempFilesQuery.newConstMetric(1, key.user, key.database, key.queryHash, stat.query)
+	}
+	c.tempFiles.mu.RUnlock()
+
+	// pgaudit events.
+	c.auditEvents.mu.RLock()
+	for key, value := range c.auditEvents.store {
+		ch <- c.auditTotal.newConstMetric(value, key.user, key.database, key.auditType, key.class)
+	}
+	c.auditEvents.mu.RUnlock()
+
+	// auto_explain slow plans.
+	c.slowPlans.mu.RLock()
+	for key, stat := range c.slowPlans.store {
+		ch <- c.slowPlansTotal.newConstMetric(stat.plans, key.user, key.database, key.queryHash)
+		ch <- c.slowPlansTime.newConstMetric(stat.duration, key.user, key.database, key.queryHash)
+		ch <- c.slowPlansQuery.newConstMetric(1, key.user, key.database, key.queryHash, stat.query)
+		for node, value := range stat.nodes {
+			ch <- c.slowPlanNodes.newConstMetric(value, key.user, key.database, key.queryHash, node)
+		}
+	}
+	c.slowPlans.mu.RUnlock()
+
+	// Deadlocks.
+	c.deadlocks.mu.RLock()
+	for key, value := range c.deadlocks.store {
+		ch <- c.deadlocksTotal.newConstMetric(value, key.user, key.database, key.relation1, key.relation2)
+	}
+	c.deadlocks.mu.RUnlock()
+
+	// Configuration reloads and parameters changes.
+	c.configChanges.mu.RLock()
+	ch <- c.configReloads.newConstMetric(c.configChanges.reloads)
+	for param, value := range c.configChanges.changes {
+		ch <- c.paramChanges.newConstMetric(value, param)
+	}
+	if c.configChanges.lastParameter != "" {
+		ch <- c.lastParamChange.newConstMetric(c.configChanges.lastChange, c.configChanges.lastParameter)
+	}
+	c.configChanges.mu.RUnlock()
+
+	// Connection events.
+	c.connections.mu.RLock()
+	for key, value := range c.connections.store {
+		ch <- c.connsTotal.newConstMetric(value, key.user, key.database, key.event)
+	}
+	c.connections.mu.RUnlock()
+
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresLogsCollector) executedQueries(config Config) []string {
+	if !config.localService || !config.loggingCollector || config.pgVersion.Numeric < PostgresV10 {
+		return nil
+	}
+	if !logDestinationStderr(config.logDestination) || config.sqlUnavailable {
+		return nil
+	}
+	return []string{postgresCurrentLogfileQuery}
+}
+
+// logDestinationStderr returns true if passed value of 'log_destination' includes stderr. Multiple destinations are
+// separated by commas, e.g. 'stderr,csvlog'.
+func logDestinationStderr(destination string) bool {
+	for _, d := range strings.Split(destination, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), "stderr") {
+			return true
+		}
+	}
+	return false
+}
+
 // runTailLoop accepts logfile names over channel and run tail/collect functions.
 func runTailLoop(c *postgresLogsCollector) {
 	var ctx context.Context
@@ -244,6 +588,12 @@ func tailCollect(ctx context.Context, logfile string, init bool, wg *sync.WaitGr
 				return
 			}
 			parser.updateMessagesStats(line.Text, c)
+			parser.updateTempFilesStats(line.Text, c)
+			parser.updateAuditStats(line.Text, c)
+			parser.updateSlowPlansStats(line.Text, c)
+			parser.updateDeadlocksStats(line.Text, c)
+			parser.updateConfigChangesStats(line.Text, c)
+			parser.updateConnectionsStats(line.Text, c)
 		}
 	}
 }
@@ -257,7 +607,7 @@ func queryCurrentLogfile(conninfo string, connTimeout int) (string, error) {
 	defer conn.Close()
 
 	var datadir, logfile string
-	err = conn.Conn().QueryRow(context.TODO(), "SELECT current_setting('data_directory'),pg_current_logfile()").Scan(&datadir, &logfile)
+	err = conn.QueryRow(postgresCurrentLogfileQuery).Scan(&datadir, &logfile)
 	if err != nil {
 		return "", err
 	}
@@ -271,9 +621,57 @@ func queryCurrentLogfile(conninfo string, connTimeout int) (string, error) {
 
 // logParser contains set or regexp patterns used for parse log messages.
 type logParser struct {
-	reSeverity  map[string]*regexp.Regexp // regexp to determine messages severity.
-	reExtract   *regexp.Regexp            // regexp for extracting exact messages from the whole line (drop log_line_prefix stuff).
-	reNormalize []*regexp.Regexp          // regexp for normalizing log message.
+	reSeverity       map[string]*regexp.Regexp // regexp to determine messages severity.
+	reExtract        *regexp.Regexp            // regexp for extracting exact messages from the whole line (drop log_line_prefix stuff).
+	reNormalize      []*regexp.Regexp          // regexp for normalizing log message.
+	reTempFile       *regexp.Regexp            // regexp for extracting size of temporary file.
+	reStatement      *regexp.Regexp            // regexp for extracting query text from STATEMENT line.
+	reAudit          *regexp.Regexp            // regexp for extracting audit type and class from text of pgaudit messages.
+	rePrefix         *regexp.Regexp            // regexp for extracting log_line_prefix from the whole line.
+	reUser           *regexp.Regexp            // regexp for extracting user name from log_line_prefix.
+	reDatabase       *regexp.Regexp            // regexp for extracting database name from log_line_prefix.
+	reQueryNormalize []*regexp.Regexp          // regexp for normalizing query text.
+	reSlowPlan       *regexp.Regexp            // regexp for extracting duration from auto_explain messages.
+	rePlanNode       *regexp.Regexp            // regexp for extracting node name from auto_explain plan lines.
+	reDeadlock       *regexp.Regexp            // regexp for matching deadlock messages.
+	reMessagePart    *regexp.Regexp            // regexp for extracting supplementary parts (DETAIL, HINT, etc.) of messages.
+	reProcessQuery   *regexp.Regexp            // regexp for extracting query of process from deadlock details.
+	reRelation       *regexp.Regexp            // regexp for extracting relation name from query text.
+	reConfigReload   *regexp.Regexp            // regexp for matching configuration reload messages.
+	reParamChange    *regexp.Regexp            // regexp for extracting parameter name from parameter change messages.
+	reConnection     *regexp.Regexp            // regexp for extracting event and details from connection messages.
+	reConnUser       *regexp.Regexp            // regexp for extracting user name from details of connection messages.
+	reConnDatabase   *regexp.Regexp            // regexp for extracting database name from details of connection messages.
+	pendingTempFile  *pendingTempFile          // pendingTempFile holds temp file waiting for its STATEMENT line.
+	pendingPlan      *pendingPlan              // pendingPlan holds slow plan which lines are not received completely.
+	pendingDeadlock  *pendingDeadlock          // pendingDeadlock holds deadlock which details are not received completely.
+}
+
+// pendingTempFile defines temp file which has been logged but its query is not known yet.
+type pendingTempFile struct {
+	user     string
+	database string
+	size     float64
+}
+
+// pendingPlan defines slow plan logged by auto_explain which continuation lines are being received.
+type pendingPlan struct {
+	user      string
+	database  string
+	duration  float64 // in seconds
+	query     []string
+	queryDone bool // true when all lines of query text have been received
+	nodes     map[string]struct{}
+}
+
+// pendingDeadlock defines deadlock which details are being received.
+type pendingDeadlock struct {
+	user      string
+	database  string
+	inDetail  bool              // true when DETAIL part of message is being received
+	process   string            // process which query is being received
+	processes []string          // processes in order of appearance in details
+	relations map[string]string // first relation used in query of each process
 }
 
 // newLogParser creates a new logParser with necessary compiled regexp objects.
@@ -291,9 +689,16 @@ func newLogParser() *logParser {
 		`(\s+".+?"\s?)`,
 	}
 
+	queryNormalizePatterns := []string{
+		`'(?:[^']|'')*'`,
+		`\b\d+(?:\.\d+)?\b`,
+		`\s+`,
+	}
+
 	p := &logParser{
-		reSeverity:  map[string]*regexp.Regexp{},
-		reNormalize: make([]*regexp.Regexp, len(normalizePatterns)),
+		reSeverity:       map[string]*regexp.Regexp{},
+		reNormalize:      make([]*regexp.Regexp, len(normalizePatterns)),
+		reQueryNormalize: make([]*regexp.Regexp, len(queryNormalizePatterns)),
 	}
 
 	for name, pattern := range severityPatterns {
@@ -306,6 +711,28 @@ func newLogParser() *logParser {
 		p.reNormalize[i] = regexp.MustCompile(pattern)
 	}
 
+	p.reTempFile = regexp.MustCompile(`LOG:\s+temporary file: path ".+?", size (\d+)`)
+	p.reStatement = regexp.MustCompile(`\s?STATEMENT:\s+(.+)`)
+	p.reAudit = regexp.MustCompile(`^AUDIT:\s+(SESSION|OBJECT),\d+,\d+,([A-Z_]+),`)
+	p.rePrefix = regexp.MustCompile(`^(.*?)\s?(DEBUG[1-5]?|LOG|INFO|NOTICE|WARNING|ERROR|FATAL|PANIC|DETAIL|HINT|CONTEXT|STATEMENT):\s+`)
+	p.reUser = regexp.MustCompile(`\b(?:user|usr)=([^,\s\]]*)`)
+	p.reDatabase = regexp.MustCompile(`\b(?:db|database)=([^,\s\]]*)`)
+	p.reSlowPlan = regexp.MustCompile(`LOG:\s+duration: ([\d.]+) ms\s+plan:`)
+	p.rePlanNode = regexp.MustCompile(`^\s*(?:->\s+)?(?:Parallel\s+)?([A-Z][A-Za-z ]*?)(?:\s+on\s|\s+using\s|\s*\()`)
+	p.reDeadlock = regexp.MustCompile(`ERROR:\s+deadlock detected`)
+	p.reMessagePart = regexp.MustCompile(`\s?(DETAIL|HINT|CONTEXT|STATEMENT):\s+(.*)`)
+	p.reProcessQuery = regexp.MustCompile(`^\s*Process (\d+): (.*)`)
+	p.reConfigReload = regexp.MustCompile(`LOG:\s+received SIGHUP, reloading configuration files`)
+	p.reParamChange = regexp.MustCompile(`LOG:\s+parameter "([^"]+)" (?:changed to "|removed from configuration file)`)
+	p.reConnection = regexp.MustCompile(`LOG:\s+(?:(?:replication )?connection (received|authorized)|(disconnection)):\s+(.*)`)
+	p.reConnUser = regexp.MustCompile(`\buser=(\S+)`)
+	p.reConnDatabase = regexp.MustCompile(`\bdatabase=(\S+)`)
+	p.reRelation = regexp.MustCompile(`(?i)\b(?:update|into|from|join|table)\s+(?:only\s+)?((?:"[^"]+"|[a-z_][\w$]*)(?:\.(?:"[^"]+"|[a-z_][\w$]*))?)`)
+
+	for i, pattern := range queryNormalizePatterns {
+		p.reQueryNormalize[i] = regexp.MustCompile(pattern)
+	}
+
 	return p
 }
 
@@ -347,6 +774,321 @@ func (p *logParser) updateMessagesStats(line string, c *postgresLogsCollector) {
 	}
 }
 
+// updateTempFilesStats process the message string and update stats about temporary files. Temporary file message is
+// followed by STATEMENT line with query text, hence temp file is remembered and accounted when STATEMENT line is received.
+func (p *logParser) updateTempFilesStats(line string, c *postgresLogsCollector) {
+	if p.pendingTempFile != nil {
+		var query string
+		if parts := p.reStatement.FindStringSubmatch(line); len(parts) == 2 {
+			query = p.normalizeQuery(parts[1])
+		}
+
+		key := tempFileKey{user: p.pendingTempFile.user, database: p.pendingTempFile.database, queryHash: queryHash(query)}
+
+		c.tempFiles.mu.Lock()
+		stat := c.tempFiles.store[key]
+		stat.query = query
+		stat.files++
+		stat.bytes += p.pendingTempFile.size
+		c.tempFiles.store[key] = stat
+		if evicted, ok := c.tempFiles.recent.touch(key); ok {
+			delete(c.tempFiles.store, evicted)
+		}
+		c.tempFiles.mu.Unlock()
+
+		p.pendingTempFile = nil
+	}
+
+	parts := p.reTempFile.FindStringSubmatch(line)
+	if len(parts) != 2 {
+		return
+	}
+
+	size, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		log.Errorf("invalid input, parse '%s' failed: %s; skip", parts[1], err)
+		return
+	}
+
+	pending := &pendingTempFile{size: size}
+	pending.user, pending.database = p.prefixUserDatabase(line)
+
+	p.pendingTempFile = pending
+}
+
+// updateAuditStats process the message string and update stats about pgaudit events. Audit messages have format
+// 'AUDIT: audit_type,statement_id,substatement_id,class,command,object_type,object_name,statement,parameter'.
+// For details see https://github.com/pgaudit/pgaudit#format
+func (p *logParser) updateAuditStats(line string, c *postgresLogsCollector) {
+	_, severity, message, ok := p.splitMessage(line)
+	if !ok || severity != "LOG" {
+		return
+	}
+
+	parts := p.reAudit.FindStringSubmatch(message)
+	if len(parts) != 3 {
+		return
+	}
+
+	key := auditKey{auditType: strings.ToLower(parts[1]), class: strings.ToLower(parts[2])}
+	key.user, key.database = p.prefixUserDatabase(line)
+
+	c.auditEvents.mu.Lock()
+	c.auditEvents.store[key]++
+	c.auditEvents.mu.Unlock()
+}
+
+// updateSlowPlansStats process the message string and update stats about slow plans logged by auto_explain in text
+// format. Plan message is followed by continuation lines (prefixed with tab) with query text and plan nodes, hence
+// plan is accounted when the next log message is received.
+// For details see https://www.postgresql.org/docs/current/auto-explain.html
+func (p *logParser) updateSlowPlansStats(line string, c *postgresLogsCollector) {
+	if p.pendingPlan != nil {
+		if strings.HasPrefix(line, "\t") {
+			p.parsePlanLine(strings.TrimPrefix(line, "\t"))
+			return
+		}
+
+		p.flushPendingPlan(c)
+	}
+
+	parts := p.reSlowPlan.FindStringSubmatch(line)
+	if len(parts) != 2 {
+		return
+	}
+
+	duration, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		log.Errorf("invalid input, parse '%s' failed: %s; skip", parts[1], err)
+		return
+	}
+
+	pending := &pendingPlan{duration: duration / 1000, nodes: map[string]struct{}{}}
+	pending.user, pending.database = p.prefixUserDatabase(line)
+
+	p.pendingPlan = pending
+}
+
+// parsePlanLine parses continuation line of auto_explain message. Query text could span many lines and is followed
+// by optional query parameters and plan nodes.
+func (p *logParser) parsePlanLine(line string) {
+	plan := p.pendingPlan
+	isNode := strings.Contains(line, "(cost=") || strings.Contains(line, "(actual")
+
+	switch {
+	case strings.HasPrefix(line, "Query Text: "):
+		plan.query = append(plan.query, strings.TrimPrefix(line, "Query Text: "))
+	case strings.HasPrefix(line, "Query Parameters: "):
+		plan.queryDone = true
+	case !isNode:
+		// Lines of multi-line query text, other lines like filters or buffers usage are skipped.
+		if len(plan.query) > 0 && !plan.queryDone {
+			plan.query = append(plan.query, line)
+		}
+	default:
+		plan.queryDone = true
+		if m := p.rePlanNode.FindStringSubmatch(line); len(m) == 2 {
+			plan.nodes[strings.ToLower(strings.ReplaceAll(m[1], " ", "_"))] = struct{}{}
+		}
+	}
+}
+
+// flushPendingPlan accounts pending slow plan and resets it.
+func (p *logParser) flushPendingPlan(c *postgresLogsCollector) {
+	plan := p.pendingPlan
+	p.pendingPlan = nil
+
+	query := p.normalizeQuery(strings.Join(plan.query, " "))
+	key := slowPlanKey{user: plan.user, database: plan.database, queryHash: queryHash(query)}
+
+	c.slowPlans.mu.Lock()
+	defer c.slowPlans.mu.Unlock()
+
+	stat, ok := c.slowPlans.store[key]
+	if !ok {
+		stat.nodes = map[string]float64{}
+	}
+	stat.query = query
+	stat.plans++
+	stat.duration += plan.duration
+	for node := range plan.nodes {
+		stat.nodes[node]++
+	}
+	c.slowPlans.store[key] = stat
+
+	if evicted, ok := c.slowPlans.recent.touch(key); ok {
+		delete(c.slowPlans.store, evicted)
+	}
+}
+
+// updateDeadlocksStats process the message string and update stats about deadlocks. Deadlock message is followed by
+// DETAIL part with processes and their queries, relations used in queries are accounted when the next log message is
+// received. Relations are taken from queries, because DETAIL part contains OIDs of relations instead of names.
+func (p *logParser) updateDeadlocksStats(line string, c *postgresLogsCollector) {
+	if p.pendingDeadlock != nil {
+		if strings.HasPrefix(line, "\t") {
+			p.parseDeadlockLine(strings.TrimPrefix(line, "\t"))
+			return
+		}
+
+		if parts := p.reMessagePart.FindStringSubmatch(line); len(parts) == 3 {
+			p.pendingDeadlock.inDetail = parts[1] == "DETAIL"
+			p.parseDeadlockLine(parts[2])
+			return
+		}
+
+		p.flushPendingDeadlock(c)
+	}
+
+	if !p.reDeadlock.MatchString(line) {
+		return
+	}
+
+	pending := &pendingDeadlock{relations: map[string]string{}}
+	pending.user, pending.database = p.prefixUserDatabase(line)
+
+	p.pendingDeadlock = pending
+}
+
+// parseDeadlockLine parses line of DETAIL part of deadlock message. Lines with waits are skipped, lines with queries
+// of processes have 'Process PID: query' format, query text could span many lines.
+func (p *logParser) parseDeadlockLine(line string) {
+	deadlock := p.pendingDeadlock
+	if !deadlock.inDetail {
+		return
+	}
+
+	if m := p.reProcessQuery.FindStringSubmatch(line); len(m) == 3 {
+		deadlock.process = m[1]
+		deadlock.processes = append(deadlock.processes, deadlock.process)
+		line = m[2]
+	} else if strings.HasPrefix(strings.TrimSpace(line), "Process ") {
+		deadlock.process = ""
+		return
+	}
+
+	if deadlock.process == "" || deadlock.relations[deadlock.process] != "" {
+		return
+	}
+
+	if m := p.reRelation.FindStringSubmatch(line); len(m) == 2 {
+		deadlock.relations[deadlock.process] = strings.ToLower(strings.ReplaceAll(m[1], `"`, ""))
+	}
+}
+
+// flushPendingDeadlock accounts pending deadlock and resets it. Deadlock is accounted by relations of the first two
+// processes, relation is empty when it's not found in the query.
+func (p *logParser) flushPendingDeadlock(c *postgresLogsCollector) {
+	deadlock := p.pendingDeadlock
+	p.pendingDeadlock = nil
+
+	relations := make([]string, 2)
+	for i, process := range deadlock.processes {
+		if i == len(relations) {
+			break
+		}
+		relations[i] = deadlock.relations[process]
+	}
+
+	if relations[0] > relations[1] {
+		relations[0], relations[1] = relations[1], relations[0]
+	}
+
+	key := deadlockKey{user: deadlock.user, database: deadlock.database, relation1: relations[0], relation2: relations[1]}
+
+	c.deadlocks.mu.Lock()
+	c.deadlocks.store[key]++
+	c.deadlocks.mu.Unlock()
+}
+
+// updateConfigChangesStats process the message string and update stats about configuration reloads and changes of
+// parameters. Parameters changed or removed from configuration files are logged by postmaster on configuration reload.
+func (p *logParser) updateConfigChangesStats(line string, c *postgresLogsCollector) {
+	if p.reConfigReload.MatchString(line) {
+		c.configChanges.mu.Lock()
+		c.configChanges.reloads++
+		c.configChanges.mu.Unlock()
+		return
+	}
+
+	m := p.reParamChange.FindStringSubmatch(line)
+	if len(m) != 2 {
+		return
+	}
+
+	c.configChanges.mu.Lock()
+	c.configChanges.changes[m[1]]++
+	c.configChanges.lastParameter = m[1]
+	c.configChanges.lastChange = float64(time.Now().Unix())
+	c.configChanges.mu.Unlock()
+}
+
+// updateConnectionsStats process the message string and update stats about connection events logged when
+// log_connections and log_disconnections are enabled. User and database are taken from message details and from
+// log_line_prefix when details don't contain them (e.g. 'connection received' messages).
+func (p *logParser) updateConnectionsStats(line string, c *postgresLogsCollector) {
+	m := p.reConnection.FindStringSubmatch(line)
+	if len(m) != 4 {
+		return
+	}
+
+	key := connectionKey{event: m[1]}
+	if m[2] != "" {
+		key.event = "disconnected"
+	}
+
+	key.user, key.database = p.prefixUserDatabase(line)
+	if u := p.reConnUser.FindStringSubmatch(m[3]); len(u) == 2 {
+		key.user = u[1]
+	}
+	if d := p.reConnDatabase.FindStringSubmatch(m[3]); len(d) == 2 {
+		key.database = d[1]
+	}
+
+	c.connections.mu.Lock()
+	c.connections.store[key]++
+	c.connections.mu.Unlock()
+}
+
+// prefixUserDatabase returns user and database names found in log_line_prefix of the line. Message text is not
+// searched, hence names mentioned in messages (e.g. in query texts) are not taken.
+func (p *logParser) prefixUserDatabase(line string) (string, string) {
+	prefix, _, _, ok := p.splitMessage(line)
+	if !ok {
+		return "", ""
+	}
+
+	var user, database string
+	if m := p.reUser.FindStringSubmatch(prefix); len(m) == 2 {
+		user = m[1]
+	}
+	if m := p.reDatabase.FindStringSubmatch(prefix); len(m) == 2 {
+		database = m[1]
+	}
+
+	return user, database
+}
+
+// splitMessage splits the line into log_line_prefix, severity and message text. The first severity marker of the line
+// is taken, hence markers mentioned in message text (e.g. in query texts) are not confused with the message severity.
+func (p *logParser) splitMessage(line string) (string, string, string, bool) {
+	m := p.rePrefix.FindStringSubmatch(line)
+	if len(m) != 3 {
+		return "", "", "", false
+	}
+
+	return m[1], m[2], line[len(m[0]):], true
+}
+
+// normalizeQuery used for normalizing query text and replacing literals with placeholders.
+func (p *logParser) normalizeQuery(query string) string {
+	query = p.reQueryNormalize[0].ReplaceAllString(query, "?")
+	query = p.reQueryNormalize[1].ReplaceAllString(query, "?")
+	query = p.reQueryNormalize[2].ReplaceAllString(query, " ")
+
+	return strings.TrimSpace(query)
+}
+
 // parseMessageSeverity accepts lines and parse it using patterns from logParser.
 func (p *logParser) parseMessageSeverity(line string) (string, bool) {
 	if line == "" {
@@ -149,6 +149,15 @@ func Test_queryCurrentLogfile(t *testing.T) {
 	assert.Equal(t, got, "")
 }
 
+func Test_logDestinationStderr(t *testing.T) {
+	for _, d := range []string{"stderr", "csvlog,stderr", "jsonlog, stderr", "STDERR"} {
+		assert.True(t, logDestinationStderr(d), d)
+	}
+	for _, d := range []string{"", "csvlog", "syslog,jsonlog", "stderrlog"} {
+		assert.False(t, logDestinationStderr(d), d)
+	}
+}
+
 func Test_newLogParser(t *testing.T) {
 	p := newLogParser()
 	assert.NotNil(t, p)
@@ -255,3 +264,293 @@ func Test_logParser_normalizeMessage(t *testing.T) {
 		assert.Equal(t, tc.want, parser.normalizeMessage(tc.in))
 	}
 }
+
+func Test_logParser_updateTempFilesStats(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop LOG:  temporary file: path "base/pgsql_tmp/pgsql_tmp1402271.0", size 1048576`,
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop STATEMENT:  SELECT * FROM orders WHERE id > 100 AND status = 'new' ORDER BY created_at`,
+		`2020-10-01 08:37:59.208 +05 1402272 user=app,db=shop LOG:  temporary file: path "base/pgsql_tmp/pgsql_tmp1402272.0", size 2097152`,
+		`2020-10-01 08:37:59.208 +05 1402272 user=app,db=shop STATEMENT:  SELECT * FROM orders WHERE id > 200 AND status = 'done' ORDER BY created_at`,
+		`2020-10-01 08:38:00.208 +05 1402273 user=etl,db=dwh LOG:  temporary file: path "base/pgsql_tmp/pgsql_tmp1402273.0", size 4096`,
+		`2020-10-01 08:38:00.208 +05 1402273 user=etl,db=dwh LOG:  duration: 1.000 ms`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateTempFilesStats(line, lc)
+	}
+
+	lc.tempFiles.mu.RLock()
+	defer lc.tempFiles.mu.RUnlock()
+
+	query := "SELECT * FROM orders WHERE id > ? AND status = ? ORDER BY created_at"
+	assert.Len(t, lc.tempFiles.store, 2)
+	assert.Equal(t,
+		tempFileStat{query: query, files: 2, bytes: 3145728},
+		lc.tempFiles.store[tempFileKey{user: "app", database: "shop", queryHash: queryHash(query)}],
+	)
+	assert.Equal(t, tempFileStat{files: 1, bytes: 4096}, lc.tempFiles.store[tempFileKey{user: "etl", database: "dwh", queryHash: queryHash("")}])
+}
+
+func Test_logParser_updateTempFilesStats_limit(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	lc := c.(*postgresLogsCollector)
+	lc.tempFiles.recent = newRecentKeys[tempFileKey](2)
+
+	p := newLogParser()
+	for _, table := range []string{"a", "b", "a", "c"} {
+		p.updateTempFilesStats(`2020-10-01 08:37:58.208 +05 1 user=app,db=shop LOG:  temporary file: path "base/pgsql_tmp/pgsql_tmp1.0", size 1024`, lc)
+		p.updateTempFilesStats(`2020-10-01 08:37:58.208 +05 1 user=app,db=shop STATEMENT:  SELECT * FROM `+table, lc)
+	}
+
+	// Stats of the least recently logged query are evicted.
+	assert.Len(t, lc.tempFiles.store, 2)
+	assert.Equal(t, float64(2), lc.tempFiles.store[tempFileKey{user: "app", database: "shop", queryHash: queryHash("SELECT * FROM a")}].files)
+	assert.Contains(t, lc.tempFiles.store, tempFileKey{user: "app", database: "shop", queryHash: queryHash("SELECT * FROM c")})
+}
+
+func Test_recentKeys(t *testing.T) {
+	r := newRecentKeys[string](2)
+
+	_, ok := r.touch("a")
+	assert.False(t, ok)
+	_, ok = r.touch("b")
+	assert.False(t, ok)
+	_, ok = r.touch("a")
+	assert.False(t, ok)
+
+	evicted, ok := r.touch("c")
+	assert.True(t, ok)
+	assert.Equal(t, "b", evicted)
+
+	evicted, ok = r.touch("b")
+	assert.True(t, ok)
+	assert.Equal(t, "a", evicted)
+	assert.Len(t, r.elems, 2)
+}
+
+func Test_logParser_prefixUserDatabase(t *testing.T) {
+	testcases := []struct {
+		line     string
+		user     string
+		database string
+	}{
+		{line: `2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop LOG:  temporary file: path "base/pgsql_tmp/pgsql_tmp1.0", size 1024`, user: "app", database: "shop"},
+		{line: `2020-10-01 08:37:58.208 +05 [1402271] usr=app database=shop ERROR:  deadlock detected`, user: "app", database: "shop"},
+		{line: `2020-10-01 08:37:58.208 +05 1402271 LOG:  duration: 1.000 ms  statement: SELECT * FROM t WHERE user=1 AND db=2`},
+		{line: `2020-10-01 08:37:58.208 +05 1402271 user=app LOG:  statement: SELECT 'db=other'`, user: "app"},
+		{line: `2020-10-01 08:37:58.208 +05 1402271 appuser=app,mydb=shop LOG:  statement: SELECT 1`},
+		{line: `invalid`},
+	}
+
+	p := newLogParser()
+	for _, tc := range testcases {
+		user, database := p.prefixUserDatabase(tc.line)
+		assert.Equal(t, tc.user, user, tc.line)
+		assert.Equal(t, tc.database, database, tc.line)
+	}
+}
+
+func Test_logParser_updateAuditStats(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop LOG:  AUDIT: SESSION,1,1,READ,SELECT,,,"SELECT * FROM orders",<not logged>`,
+		`2020-10-01 08:37:59.208 +05 1402271 user=app,db=shop LOG:  AUDIT: SESSION,2,1,WRITE,INSERT,,,"INSERT INTO orders VALUES (1)",<not logged>`,
+		`2020-10-01 08:38:00.208 +05 1402272 user=app,db=shop LOG:  AUDIT: OBJECT,3,1,READ,SELECT,TABLE,public.orders,"SELECT * FROM orders",<not logged>`,
+		`2020-10-01 08:38:01.208 +05 1402273 user=admin,db=shop LOG:  AUDIT: SESSION,1,1,DDL,CREATE TABLE,TABLE,public.items,"CREATE TABLE items (id int)",<not logged>`,
+		`2020-10-01 08:38:02.208 +05 1402273 user=admin,db=shop LOG:  AUDIT: SESSION,2,1,ROLE,GRANT,TABLE,,"GRANT SELECT ON items TO app",<not logged>`,
+		`2020-10-01 08:38:03.208 +05 1402274 user=app,db=shop LOG:  AUDIT: SESSION,3,1,READ,SELECT,,,"SELECT 1",<not logged>`,
+		`2020-10-01 08:38:04.208 +05 1402274 user=app,db=shop LOG:  duration: 1.000 ms`,
+		`2020-10-01 08:38:05.208 +05 1402274 user=app,db=shop LOG:  statement: SELECT 'LOG:  AUDIT: SESSION,1,1,WRITE,INSERT,,,'`,
+		`2020-10-01 08:38:06.208 +05 1402274 user=app,db=shop ERROR:  syntax error at or near "LOG:  AUDIT: SESSION,1,1,WRITE,INSERT,,,"`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateAuditStats(line, lc)
+	}
+
+	lc.auditEvents.mu.RLock()
+	defer lc.auditEvents.mu.RUnlock()
+
+	assert.Equal(t, map[auditKey]float64{
+		{user: "app", database: "shop", auditType: "session", class: "read"}:   2,
+		{user: "app", database: "shop", auditType: "session", class: "write"}:  1,
+		{user: "app", database: "shop", auditType: "object", class: "read"}:    1,
+		{user: "admin", database: "shop", auditType: "session", class: "ddl"}:  1,
+		{user: "admin", database: "shop", auditType: "session", class: "role"}: 1,
+	}, lc.auditEvents.store)
+}
+
+func Test_logParser_updateSlowPlansStats(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop LOG:  duration: 1500.000 ms  plan:`,
+		"\tQuery Text: SELECT * FROM orders o",
+		"\t  JOIN items i ON i.order_id = o.id WHERE o.id > 100",
+		"\tNested Loop  (cost=0.29..16.34 rows=1 width=72) (actual time=0.020..1500.021 rows=1 loops=1)",
+		"\t  ->  Seq Scan on orders o  (cost=0.00..8.01 rows=1 width=36) (actual time=0.010..0.011 rows=1 loops=1)",
+		"\t        Filter: (id > 100)",
+		"\t  ->  Index Scan using items_order_id_idx on items i  (cost=0.29..8.31 rows=1 width=36) (actual time=0.005..0.006 rows=1 loops=1)",
+		`2020-10-01 08:37:59.208 +05 1402272 user=app,db=shop LOG:  duration: 500.000 ms  plan:`,
+		"\tQuery Text: SELECT * FROM orders o JOIN items i ON i.order_id = o.id WHERE o.id > 200",
+		"\tQuery Parameters: $1 = '200'",
+		"\tHash Join  (cost=8.02..16.34 rows=1 width=72)",
+		"\t  ->  Parallel Seq Scan on orders o  (cost=0.00..8.01 rows=1 width=36)",
+		`2020-10-01 08:38:00.208 +05 1402273 user=app,db=shop LOG:  duration: 1.000 ms`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateSlowPlansStats(line, lc)
+	}
+
+	assert.Nil(t, p.pendingPlan)
+
+	lc.slowPlans.mu.RLock()
+	defer lc.slowPlans.mu.RUnlock()
+
+	query := "SELECT * FROM orders o JOIN items i ON i.order_id = o.id WHERE o.id > ?"
+	key := slowPlanKey{user: "app", database: "shop", queryHash: queryHash(query)}
+	assert.Equal(t, map[slowPlanKey]slowPlanStat{key: {
+		query: query, plans: 2, duration: 2,
+		nodes: map[string]float64{"nested_loop": 1, "seq_scan": 2, "index_scan": 1, "hash_join": 1},
+	}}, lc.slowPlans.store)
+}
+
+func Test_logParser_updateSlowPlansStats_limit(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	lc := c.(*postgresLogsCollector)
+	lc.slowPlans.recent = newRecentKeys[slowPlanKey](2)
+
+	p := newLogParser()
+	for _, table := range []string{"a", "b", "a", "c"} {
+		p.updateSlowPlansStats(`2020-10-01 08:37:58.208 +05 1 user=app,db=shop LOG:  duration: 1000.000 ms  plan:`, lc)
+		p.updateSlowPlansStats("\tQuery Text: SELECT * FROM "+table, lc)
+		p.updateSlowPlansStats("\tSeq Scan on "+table+"  (cost=0.00..8.01 rows=1 width=36)", lc)
+	}
+	p.updateSlowPlansStats(`2020-10-01 08:37:59.208 +05 1 user=app,db=shop LOG:  duration: 1.000 ms`, lc)
+
+	// Stats of the least recently logged query are evicted along with its plan nodes.
+	assert.Len(t, lc.slowPlans.store, 2)
+	assert.Equal(t, float64(2), lc.slowPlans.store[slowPlanKey{user: "app", database: "shop", queryHash: queryHash("SELECT * FROM a")}].nodes["seq_scan"])
+	assert.Contains(t, lc.slowPlans.store, slowPlanKey{user: "app", database: "shop", queryHash: queryHash("SELECT * FROM c")})
+}
+
+func Test_logParser_updateDeadlocksStats(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop ERROR:  deadlock detected`,
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop DETAIL:  Process 1402271 waits for ShareLock on transaction 1001; blocked by process 1402275.`,
+		"\tProcess 1402275 waits for ShareLock on transaction 1000; blocked by process 1402271.",
+		"\tProcess 1402271: UPDATE public.orders SET status = 'paid'",
+		"\t  WHERE id = 1",
+		"\tProcess 1402275: UPDATE \"Items\" SET qty = qty - 1 WHERE id = 1",
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop HINT:  See server log for query details.`,
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop CONTEXT:  while updating tuple (0,1) in relation "orders"`,
+		`2020-10-01 08:37:58.208 +05 1402271 user=app,db=shop STATEMENT:  UPDATE public.orders SET status = 'paid'`,
+		"\t  WHERE id = 1",
+		`2020-10-01 08:37:59.208 +05 1402280 user=app,db=shop ERROR:  deadlock detected`,
+		`2020-10-01 08:37:59.208 +05 1402280 user=app,db=shop DETAIL:  Process 1402280 waits for ExclusiveLock on relation 16384 of database 16385; blocked by process 1402281.`,
+		"\tProcess 1402281 waits for ShareLock on transaction 1002; blocked by process 1402280.",
+		"\tProcess 1402280: delete from items where id = 2",
+		"\tProcess 1402281: SELECT pg_advisory_lock(1)",
+		`2020-10-01 08:38:00.208 +05 1402273 user=app,db=shop LOG:  duration: 1.000 ms`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateDeadlocksStats(line, lc)
+	}
+
+	assert.Nil(t, p.pendingDeadlock)
+
+	lc.deadlocks.mu.RLock()
+	defer lc.deadlocks.mu.RUnlock()
+
+	assert.Equal(t, map[deadlockKey]float64{
+		{user: "app", database: "shop", relation1: "items", relation2: "public.orders"}: 1,
+		{user: "app", database: "shop", relation1: "", relation2: "items"}:              1,
+	}, lc.deadlocks.store)
+}
+
+func Test_logParser_updateConfigChangesStats(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2020-10-01 08:37:58.208 +05 1402271 LOG:  received SIGHUP, reloading configuration files`,
+		`2020-10-01 08:37:58.209 +05 1402271 LOG:  parameter "work_mem" changed to "8MB"`,
+		`2020-10-01 08:37:58.209 +05 1402271 LOG:  parameter "log_min_duration_statement" changed to "100ms"`,
+		`2020-10-01 08:38:58.208 +05 1402271 LOG:  received SIGHUP, reloading configuration files`,
+		`2020-10-01 08:38:58.209 +05 1402271 LOG:  parameter "work_mem" removed from configuration file, reset to default`,
+		`2020-10-01 08:38:58.209 +05 1402271 LOG:  parameter "shared_buffers" cannot be changed without restarting the server`,
+		`2020-10-01 08:38:59.208 +05 1402273 user=app,db=shop LOG:  duration: 1.000 ms`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateConfigChangesStats(line, lc)
+	}
+
+	lc.configChanges.mu.RLock()
+	defer lc.configChanges.mu.RUnlock()
+
+	assert.Equal(t, float64(2), lc.configChanges.reloads)
+	assert.Equal(t, map[string]float64{"work_mem": 2, "log_min_duration_statement": 1}, lc.configChanges.changes)
+	assert.Equal(t, "work_mem", lc.configChanges.lastParameter)
+	assert.Greater(t, lc.configChanges.lastChange, float64(0))
+}
+
+func Test_logParser_updateConnectionsStats(t *testing.T) {
+	c, err := NewPostgresLogsCollector(nil, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	lc := c.(*postgresLogsCollector)
+
+	lines := []string{
+		`2020-10-01 08:37:58.208 +05 1402271 user=,db= LOG:  connection received: host=10.0.0.1 port=51234`,
+		`2020-10-01 08:37:58.209 +05 1402271 user=app,db=shop LOG:  connection authorized: user=app database=shop application_name=psql`,
+		`2020-10-01 08:37:58.210 +05 1402272 user=,db= LOG:  connection received: host=[local]`,
+		`2020-10-01 08:37:58.211 +05 1402272 user=repl,db= LOG:  replication connection authorized: user=repl application_name=walreceiver`,
+		`2020-10-01 08:37:59.208 +05 1402271 user=app,db=shop LOG:  disconnection: session time: 0:00:01.000 user=app database=shop host=10.0.0.1 port=51234`,
+		`2020-10-01 08:37:59.209 +05 1402273 LOG:  disconnection: session time: 0:00:00.005 user=app database=shop host=[local]`,
+		`2020-10-01 08:38:59.208 +05 1402273 user=app,db=shop FATAL:  password authentication failed for user "app"`,
+	}
+
+	p := newLogParser()
+	for _, line := range lines {
+		p.updateConnectionsStats(line, lc)
+	}
+
+	lc.connections.mu.RLock()
+	defer lc.connections.mu.RUnlock()
+
+	assert.Equal(t, map[connectionKey]float64{
+		{user: "", database: "", event: "received"}:            2,
+		{user: "app", database: "shop", event: "authorized"}:   1,
+		{user: "repl", database: "", event: "authorized"}:      1,
+		{user: "app", database: "shop", event: "disconnected"}: 2,
+	}, lc.connections.store)
+}
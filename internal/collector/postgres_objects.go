@@ -0,0 +1,258 @@
+package collector
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresObjectsTTL defines interval during which large objects and TOAST stats are reused between scrapes. Sizes of
+// TOAST relations of all tables are calculated, hence stats are not collected on every scrape.
+const postgresObjectsTTL = 10 * time.Minute
+
+// postgresObjectsQuery defines query for summarizing large objects and TOAST storage of the database. Large objects are
+// stored in pg_largeobject catalog and TOAST data in separate relations, so they are not visible in tables stats.
+const postgresObjectsQuery = "SELECT current_database() AS database, " +
+	"(SELECT count(*) FROM pg_largeobject_metadata) AS large_objects, " +
+	"pg_total_relation_size('pg_largeobject') AS large_objects_bytes, " +
+	"count(*) AS toast_tables, COALESCE(sum(pg_total_relation_size(c.reltoastrelid)), 0) AS toast_bytes " +
+	"FROM pg_class c WHERE c.reltoastrelid <> 0 AND c.relkind IN ('r', 'm')"
+
+// postgresObjectsCollector defines metric descriptors and stats store.
+type postgresObjectsCollector struct {
+	largeObjects      typedDesc
+	largeObjectsBytes typedDesc
+	toastTables       typedDesc
+	toastBytes        typedDesc
+	// stats keeps stats collected during the last update of the cache.
+	stats       []postgresObjectsStat
+	lastUpdated time.Time
+	// cached is true when stats have been reused during the last update.
+	cached bool
+	mu     sync.Mutex
+}
+
+// NewPostgresObjectsCollector returns a new Collector exposing size of large objects and TOAST storage of databases.
+// Collected stats are reused during postgresObjectsTTL.
+// For details see https://www.postgresql.org/docs/current/largeobjects.html and
+// https://www.postgresql.org/docs/current/storage-toast.html
+func NewPostgresObjectsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresObjectsCollector{
+		largeObjects: newBuiltinTypedDesc(
+			descOpts{"postgres", "objects", "large_objects", "Number of large objects in the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		largeObjectsBytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "objects", "large_objects_bytes", "Total size of large objects storage of the database, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		toastTables: newBuiltinTypedDesc(
+			descOpts{"postgres", "objects", "toast_tables", "Number of tables and materialized views which have TOAST table in the database.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		toastBytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "objects", "toast_bytes", "Total size of TOAST tables of the database, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresObjectsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cached = true
+	if config.bypassCache || c.lastUpdated.IsZero() || time.Since(c.lastUpdated) >= postgresObjectsTTL {
+		c.cached = false
+		stats, err := queryPostgresObjectsStats(config)
+		if err != nil {
+			return err
+		}
+		c.stats = stats
+		c.lastUpdated = time.Now()
+	}
+
+	for _, stat := range c.stats {
+		ch <- c.largeObjects.newConstMetric(stat.largeObjects, stat.database)
+		ch <- c.largeObjectsBytes.newConstMetric(stat.largeObjectsBytes, stat.database)
+		ch <- c.toastTables.newConstMetric(stat.toastTables, stat.database)
+		ch <- c.toastBytes.newConstMetric(stat.toastBytes, stat.database)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresObjectsCollector) executedQueries(config Config) []string {
+	return perDatabaseQueries(config, postgresObjectsQuery)
+}
+
+// dataAge implements cachedCollector interface.
+func (c *postgresObjectsCollector) dataAge() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.cached || c.lastUpdated.IsZero() {
+		return 0, false
+	}
+	return time.Since(c.lastUpdated), true
+}
+
+// snapshot implements snapshotCollector interface.
+func (c *postgresObjectsCollector) snapshot() (CachedStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]postgresObjectsSnapshot, 0, len(c.stats))
+	for _, stat := range c.stats {
+		stats = append(stats, postgresObjectsSnapshot{
+			Database: stat.database, LargeObjects: stat.largeObjects, LargeObjectsBytes: stat.largeObjectsBytes,
+			ToastTables: stat.toastTables, ToastBytes: stat.toastBytes,
+		})
+	}
+
+	return marshalCachedStats(c.lastUpdated, stats)
+}
+
+// restore implements snapshotCollector interface.
+func (c *postgresObjectsCollector) restore(s CachedStats) error {
+	var stats []postgresObjectsSnapshot
+	err := json.Unmarshal(s.Stats, &stats)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = make([]postgresObjectsStat, 0, len(stats))
+	for _, stat := range stats {
+		c.stats = append(c.stats, postgresObjectsStat{
+			database: stat.Database, largeObjects: stat.LargeObjects, largeObjectsBytes: stat.LargeObjectsBytes,
+			toastTables: stat.ToastTables, toastBytes: stat.ToastBytes,
+		})
+	}
+	c.lastUpdated = s.Updated
+
+	return nil
+}
+
+// queryPostgresObjectsStats returns large objects and TOAST storage stats of databases.
+func queryPostgresObjectsStats(config Config) ([]postgresObjectsStat, error) {
+	conn, err := config.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var stats []postgresObjectsStat
+
+	collect := func(conn *store.DB) {
+		res, err := conn.Query(postgresObjectsQuery)
+		if err != nil {
+			log.Warnf("get large objects and TOAST stats failed: %s; skip", err)
+			return
+		}
+
+		stats = append(stats, parsePostgresObjectsStats(res)...)
+	}
+
+	if config.DatabasesRE == nil {
+		// service discovery case
+		collect(conn)
+		return stats, nil
+	}
+
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		conn, err := config.acquireDatabaseConn(d)
+		if err != nil {
+			return nil, err
+		}
+		collect(conn)
+		conn.Close()
+	}
+
+	return stats, nil
+}
+
+// postgresObjectsStat represents large objects and TOAST storage summary of the database.
+type postgresObjectsStat struct {
+	database          string
+	largeObjects      float64
+	largeObjectsBytes float64
+	toastTables       float64
+	toastBytes        float64
+}
+
+// postgresObjectsSnapshot represents postgresObjectsStat persisted in snapshot of cached stats.
+type postgresObjectsSnapshot struct {
+	Database          string  `json:"database"`
+	LargeObjects      float64 `json:"large_objects"`
+	LargeObjectsBytes float64 `json:"large_objects_bytes"`
+	ToastTables       float64 `json:"toast_tables"`
+	ToastBytes        float64 `json:"toast_bytes"`
+}
+
+// parsePostgresObjectsStats parses PGResult and returns structs with stats values.
+func parsePostgresObjectsStats(r *model.PGResult) []postgresObjectsStat {
+	log.Debug("parse postgres objects stats")
+
+	var stats []postgresObjectsStat
+
+	for _, row := range r.Rows {
+		var stat postgresObjectsStat
+
+		for i, colname := range r.Colnames {
+			if string(colname.Name) == "database" {
+				stat.database = row[i].String
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+				continue
+			}
+
+			switch string(colname.Name) {
+			case "large_objects":
+				stat.largeObjects = v
+			case "large_objects_bytes":
+				stat.largeObjectsBytes = v
+			case "toast_tables":
+				stat.toastTables = v
+			case "toast_bytes":
+				stat.toastBytes = v
+			}
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresObjectsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_objects_large_objects",
+			"postgres_objects_large_objects_bytes",
+			"postgres_objects_toast_tables",
+			"postgres_objects_toast_bytes",
+		},
+		collector: NewPostgresObjectsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresObjectsStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 5,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("large_objects")}, {Name: []byte("large_objects_bytes")},
+			{Name: []byte("toast_tables")}, {Name: []byte("toast_bytes")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "pgscv_fixtures", Valid: true}, {String: "12", Valid: true}, {String: "49152", Valid: true}, {String: "34", Valid: true}, {String: "278528", Valid: true}},
+		},
+	}
+
+	want := []postgresObjectsStat{
+		{database: "pgscv_fixtures", largeObjects: 12, largeObjectsBytes: 49152, toastTables: 34, toastBytes: 278528},
+	}
+
+	assert.Equal(t, want, parsePostgresObjectsStats(res))
+}
+
+func Test_postgresObjectsCollector_cache(t *testing.T) {
+	c, err := NewPostgresObjectsCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	pc := c.(*postgresObjectsCollector)
+
+	_, cached := pc.dataAge()
+	assert.False(t, cached)
+
+	// Fresh stats are reused without connecting to the service.
+	pc.stats = []postgresObjectsStat{{database: "testdb", largeObjects: 12, largeObjectsBytes: 49152, toastTables: 34, toastBytes: 278528}}
+	pc.lastUpdated = time.Now().Add(-time.Minute)
+
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, c.Update(Config{}, ch))
+	assert.Len(t, ch, 4)
+
+	age, cached := pc.dataAge()
+	assert.True(t, cached)
+	assert.GreaterOrEqual(t, age, time.Minute)
+}
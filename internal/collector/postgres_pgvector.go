@@ -0,0 +1,257 @@
+package collector
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresPgvectorTTL defines interval during which stats of pgvector indexes are reused between scrapes. Vector
+// indexes are rebuilt rarely, hence their stats are not collected on every scrape.
+const postgresPgvectorTTL = 10 * time.Minute
+
+// postgresPgvectorIndexesQuery defines query for selecting indexes created using pgvector access methods. Number of
+// lists is defined only for ivfflat indexes, when not specified explicitly pgvector uses 100 lists.
+const postgresPgvectorIndexesQuery = "SELECT current_database() AS database, n.nspname AS schema, t.relname AS table, " +
+	"i.relname AS index, am.amname AS method, pg_relation_size(i.oid) AS size_bytes, " +
+	"CASE WHEN am.amname = 'ivfflat' THEN COALESCE((SELECT option_value FROM pg_options_to_table(i.reloptions) " +
+	"WHERE option_name = 'lists'), '100') END AS lists " +
+	"FROM pg_index x JOIN pg_class i ON i.oid = x.indexrelid JOIN pg_class t ON t.oid = x.indrelid " +
+	"JOIN pg_namespace n ON n.oid = i.relnamespace JOIN pg_am am ON am.oid = i.relam " +
+	"WHERE am.amname IN ('ivfflat', 'hnsw')"
+
+// postgresPgvectorCollector defines metric descriptors and stats store.
+type postgresPgvectorCollector struct {
+	size  typedDesc
+	lists typedDesc
+	// stats keeps stats collected during the last update of the cache.
+	stats       []postgresPgvectorIndexStat
+	lastUpdated time.Time
+	// cached is true when stats have been reused during the last update.
+	cached bool
+	mu     sync.Mutex
+}
+
+// NewPostgresPgvectorCollector returns a new Collector exposing stats of indexes created by pgvector extension.
+// Collected stats are reused during postgresPgvectorTTL.
+// For details see https://github.com/pgvector/pgvector
+func NewPostgresPgvectorCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresPgvectorCollector{
+		size: newBuiltinTypedDesc(
+			descOpts{"postgres", "pgvector", "index_size_bytes", "Total size of the pgvector index, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index", "method"}, constLabels,
+			settings.Filters,
+		),
+		lists: newBuiltinTypedDesc(
+			descOpts{"postgres", "pgvector", "index_lists", "Number of inverted lists of the ivfflat index.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresPgvectorCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cached = true
+	if config.bypassCache || c.lastUpdated.IsZero() || time.Since(c.lastUpdated) >= postgresPgvectorTTL {
+		c.cached = false
+		stats, err := queryPostgresPgvectorIndexesStats(config)
+		if err != nil {
+			return err
+		}
+		c.stats = stats
+		c.lastUpdated = time.Now()
+	}
+
+	for _, stat := range c.stats {
+		ch <- c.size.newConstMetric(stat.sizebytes, stat.database, stat.schema, stat.table, stat.index, stat.method)
+		if stat.lists > 0 {
+			ch <- c.lists.newConstMetric(stat.lists, stat.database, stat.schema, stat.table, stat.index)
+		}
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresPgvectorCollector) executedQueries(config Config) []string {
+	return perDatabaseQueries(config, postgresPgvectorIndexesQuery)
+}
+
+// dataAge implements cachedCollector interface.
+func (c *postgresPgvectorCollector) dataAge() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.cached || c.lastUpdated.IsZero() {
+		return 0, false
+	}
+	return time.Since(c.lastUpdated), true
+}
+
+// snapshot implements snapshotCollector interface.
+func (c *postgresPgvectorCollector) snapshot() (CachedStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]postgresPgvectorIndexSnapshot, 0, len(c.stats))
+	for _, stat := range c.stats {
+		stats = append(stats, postgresPgvectorIndexSnapshot{
+			Database: stat.database, Schema: stat.schema, Table: stat.table, Index: stat.index, Method: stat.method,
+			Sizebytes: stat.sizebytes, Lists: stat.lists,
+		})
+	}
+
+	return marshalCachedStats(c.lastUpdated, stats)
+}
+
+// restore implements snapshotCollector interface.
+func (c *postgresPgvectorCollector) restore(s CachedStats) error {
+	var stats []postgresPgvectorIndexSnapshot
+	err := json.Unmarshal(s.Stats, &stats)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats = make([]postgresPgvectorIndexStat, 0, len(stats))
+	for _, stat := range stats {
+		c.stats = append(c.stats, postgresPgvectorIndexStat{
+			database: stat.Database, schema: stat.Schema, table: stat.Table, index: stat.Index, method: stat.Method,
+			sizebytes: stat.Sizebytes, lists: stat.Lists,
+		})
+	}
+	c.lastUpdated = s.Updated
+
+	return nil
+}
+
+// queryPostgresPgvectorIndexesStats returns stats of pgvector indexes of databases.
+func queryPostgresPgvectorIndexesStats(config Config) ([]postgresPgvectorIndexStat, error) {
+	conn, err := config.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var stats []postgresPgvectorIndexStat
+
+	collect := func(conn *store.DB) {
+		res, err := conn.Query(postgresPgvectorIndexesQuery)
+		if err != nil {
+			log.Warnf("get pgvector indexes stats failed: %s; skip", err)
+			return
+		}
+
+		stats = append(stats, parsePostgresPgvectorIndexesStats(res)...)
+	}
+
+	if config.DatabasesRE == nil {
+		// service discovery case
+		collect(conn)
+		return stats, nil
+	}
+
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+
+		conn, err := config.acquireDatabaseConn(d)
+		if err != nil {
+			return nil, err
+		}
+		collect(conn)
+		conn.Close()
+	}
+
+	return stats, nil
+}
+
+// postgresPgvectorIndexStat represents stats of pgvector index.
+type postgresPgvectorIndexStat struct {
+	database  string
+	schema    string
+	table     string
+	index     string
+	method    string
+	sizebytes float64
+	lists     float64
+}
+
+// postgresPgvectorIndexSnapshot represents postgresPgvectorIndexStat persisted in snapshot of cached stats.
+type postgresPgvectorIndexSnapshot struct {
+	Database  string  `json:"database"`
+	Schema    string  `json:"schema"`
+	Table     string  `json:"table"`
+	Index     string  `json:"index"`
+	Method    string  `json:"method"`
+	Sizebytes float64 `json:"size_bytes"`
+	Lists     float64 `json:"lists"`
+}
+
+// parsePostgresPgvectorIndexesStats parses PGResult and returns structs with stats values.
+func parsePostgresPgvectorIndexesStats(r *model.PGResult) []postgresPgvectorIndexStat {
+	log.Debug("parse postgres pgvector indexes stats")
+
+	var stats []postgresPgvectorIndexStat
+
+	for _, row := range r.Rows {
+		var stat postgresPgvectorIndexStat
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				stat.database = row[i].String
+			case "schema":
+				stat.schema = row[i].String
+			case "table":
+				stat.table = row[i].String
+			case "index":
+				stat.index = row[i].String
+			case "method":
+				stat.method = row[i].String
+			case "size_bytes", "lists":
+				// Skip empty (NULL) values.
+				if !row[i].Valid {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				if string(colname.Name) == "size_bytes" {
+					stat.sizebytes = v
+				} else {
+					stat.lists = v
+				}
+			}
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresPgvectorCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_pgvector_index_size_bytes",
+			"postgres_pgvector_index_lists",
+		},
+		collector: NewPostgresPgvectorCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresPgvectorIndexesStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 7,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("table")}, {Name: []byte("index")},
+			{Name: []byte("method")}, {Name: []byte("size_bytes")}, {Name: []byte("lists")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "items", Valid: true}, {String: "items_embedding_ivfflat_idx", Valid: true},
+				{String: "ivfflat", Valid: true}, {String: "16384000", Valid: true}, {String: "1000", Valid: true},
+			},
+			{
+				{String: "testdb", Valid: true}, {String: "public", Valid: true}, {String: "items", Valid: true}, {String: "items_embedding_hnsw_idx", Valid: true},
+				{String: "hnsw", Valid: true}, {String: "32768000", Valid: true}, {String: "", Valid: false},
+			},
+		},
+	}
+
+	want := []postgresPgvectorIndexStat{
+		{database: "testdb", schema: "public", table: "items", index: "items_embedding_ivfflat_idx", method: "ivfflat", sizebytes: 16384000, lists: 1000},
+		{database: "testdb", schema: "public", table: "items", index: "items_embedding_hnsw_idx", method: "hnsw", sizebytes: 32768000},
+	}
+
+	assert.Equal(t, want, parsePostgresPgvectorIndexesStats(res))
+}
+
+func Test_postgresPgvectorCollector_cache(t *testing.T) {
+	c, err := NewPostgresPgvectorCollector(labels{}, model.CollectorSettings{})
+	require.NoError(t, err)
+	pc := c.(*postgresPgvectorCollector)
+
+	_, cached := pc.dataAge()
+	assert.False(t, cached)
+
+	// Fresh stats are reused without connecting to the service.
+	pc.stats = []postgresPgvectorIndexStat{{database: "testdb", schema: "public", table: "items", index: "items_embedding_idx", method: "ivfflat", sizebytes: 8192, lists: 100}}
+	pc.lastUpdated = time.Now().Add(-time.Minute)
+
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, c.Update(Config{}, ch))
+	assert.Len(t, ch, 2)
+
+	age, cached := pc.dataAge()
+	assert.True(t, cached)
+	assert.GreaterOrEqual(t, age, time.Minute)
+}
@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// postgresPublicationTablesQuery defines query for counting tables published by each publication.
+	postgresPublicationTablesQuery = "SELECT current_database() AS database, p.pubname AS publication, count(pt.tablename) AS tables " +
+		"FROM pg_publication p LEFT JOIN pg_publication_tables pt ON pt.pubname = p.pubname " +
+		"GROUP BY p.pubname"
+
+	// postgresPublicationReplicaIdentityQuery defines query for selecting published tables which replica identity does
+	// not allow to identify rows, hence updates and deletes of such tables fail or could not be replicated.
+	postgresPublicationReplicaIdentityQuery = "SELECT current_database() AS database, pt.pubname AS publication, " +
+		"pt.schemaname AS schema, pt.tablename AS table, " +
+		"CASE c.relreplident WHEN 'n' THEN 'nothing' ELSE 'default_without_pk' END AS replica_identity " +
+		"FROM pg_publication_tables pt " +
+		"JOIN pg_publication p ON p.pubname = pt.pubname " +
+		"JOIN pg_namespace n ON n.nspname = pt.schemaname " +
+		"JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = pt.tablename " +
+		"WHERE (p.pubupdate OR p.pubdelete) AND (c.relreplident = 'n' OR (c.relreplident = 'd' " +
+		"AND NOT EXISTS (SELECT 1 FROM pg_index i WHERE i.indrelid = c.oid AND i.indisprimary)))"
+)
+
+// postgresPublicationsCollector defines metric descriptors.
+type postgresPublicationsCollector struct {
+	tables          typedDesc
+	replicaIdentity typedDesc
+}
+
+// NewPostgresPublicationsCollector returns a new Collector exposing number of tables published by publications and
+// published tables with replica identity which breaks logical replication of updates and deletes. Sync state of
+// subscribed tables is exposed by postgres/subscription_rel collector.
+// For details see https://www.postgresql.org/docs/current/logical-replication-publication.html
+func NewPostgresPublicationsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresPublicationsCollector{
+		tables: newBuiltinTypedDesc(
+			descOpts{"postgres", "publication", "tables", "Number of tables published by the publication.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "publication"}, constLabels,
+			settings.Filters,
+		),
+		replicaIdentity: newBuiltinTypedDesc(
+			descOpts{"postgres", "publication", "replica_identity_issues", "Labeled information about published tables which replica identity does not allow to replicate updates and deletes.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "publication", "schema", "table", "replica_identity"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresPublicationsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.pgVersion.Numeric < PostgresV10 {
+		log.Debugln("[postgres publications collector]: publications are not available, required Postgres 10 or newer")
+		return nil
+	}
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if config.DatabasesRE == nil {
+		// service discovery case
+		c.collect(conn, ch)
+		return nil
+	}
+
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
+	if err != nil {
+		return err
+	}
+
+	// walk through all databases, connect to it and collect publications stats
+	for _, d := range databases {
+		// Skip database if not matched to allowed.
+		if !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+		conn, err := config.acquireDatabaseConn(d)
+		if err != nil {
+			return err
+		}
+		c.collect(conn, ch)
+		conn.Close()
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresPublicationsCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV10 {
+		return nil
+	}
+	return perDatabaseQueries(config, postgresPublicationTablesQuery, postgresPublicationReplicaIdentityQuery)
+}
+
+// collect collects publications stats of the database connected using passed connection.
+func (c *postgresPublicationsCollector) collect(conn *store.DB, ch chan<- prometheus.Metric) {
+	res, err := conn.Query(postgresPublicationTablesQuery)
+	if err != nil {
+		log.Warnf("get publications tables failed: %s; skip", err)
+	} else {
+		for _, p := range parsePostgresPublicationTables(res) {
+			ch <- c.tables.newConstMetric(p.tables, p.database, p.publication)
+		}
+	}
+
+	res, err = conn.Query(postgresPublicationReplicaIdentityQuery)
+	if err != nil {
+		log.Warnf("get publications replica identity issues failed: %s; skip", err)
+		return
+	}
+
+	for _, row := range res.Rows {
+		ch <- c.replicaIdentity.newConstMetric(1, row[0].String, row[1].String, row[2].String, row[3].String, row[4].String)
+	}
+}
+
+// postgresPublicationTables represents number of tables published by the publication.
+type postgresPublicationTables struct {
+	database    string
+	publication string
+	tables      float64
+}
+
+// parsePostgresPublicationTables parses PGResult and returns number of tables of each publication.
+func parsePostgresPublicationTables(r *model.PGResult) []postgresPublicationTables {
+	log.Debug("parse postgres publications stats")
+
+	var result []postgresPublicationTables
+
+	for _, row := range r.Rows {
+		var p postgresPublicationTables
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				p.database = row[i].String
+			case "publication":
+				p.publication = row[i].String
+			case "tables":
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+				p.tables = v
+			}
+		}
+		result = append(result, p)
+	}
+
+	return result
+}
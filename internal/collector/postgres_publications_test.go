@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresPublicationsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_publication_tables",
+			"postgres_publication_replica_identity_issues",
+		},
+		collector: NewPostgresPublicationsCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresPublicationTables(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("publication")}, {Name: []byte("tables")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "appdb", Valid: true}, {String: "pub_all", Valid: true}, {String: "12", Valid: true}},
+			{{String: "appdb", Valid: true}, {String: "pub_empty", Valid: true}, {String: "0", Valid: true}},
+		},
+	}
+
+	want := []postgresPublicationTables{
+		{database: "appdb", publication: "pub_all", tables: 12},
+		{database: "appdb", publication: "pub_empty", tables: 0},
+	}
+
+	assert.Equal(t, want, parsePostgresPublicationTables(res))
+}
@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresRecoveryPrefetchQuery defines query for querying recovery prefetch stats. Stats are collected only on standbys.
+const postgresRecoveryPrefetchQuery = "SELECT prefetch, hit, skip_init, skip_new, skip_fpw, skip_rep, " +
+	"wal_distance, block_distance, io_depth FROM pg_stat_recovery_prefetch WHERE pg_is_in_recovery()"
+
+type postgresRecoveryPrefetchCollector struct {
+	blocks        typedDesc
+	walDistance   typedDesc
+	blockDistance typedDesc
+	ioDepth       typedDesc
+}
+
+// NewPostgresRecoveryPrefetchCollector returns a new Collector exposing postgres WAL recovery prefetch stats.
+// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-RECOVERY-PREFETCH
+func NewPostgresRecoveryPrefetchCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresRecoveryPrefetchCollector{
+		blocks: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "blocks_total", "Total number of blocks referenced in WAL during recovery, by prefetch result.", 0},
+			prometheus.CounterValue,
+			[]string{"result"}, constLabels,
+			settings.Filters,
+		),
+		walDistance: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "wal_distance_bytes", "How far ahead the prefetcher is looking, in bytes.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		blockDistance: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "block_distance", "How many blocks ahead the prefetcher is looking.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		ioDepth: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery_prefetch", "io_depth", "How many prefetches have been initiated but are not yet known to have completed.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresRecoveryPrefetchCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.pgVersion.Numeric < PostgresV15 {
+		log.Debugln("[postgres recovery_prefetch collector]: pg_stat_recovery_prefetch view is not available, required Postgres 15 or newer")
+		return nil
+	}
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(postgresRecoveryPrefetchQuery)
+	if err != nil {
+		return err
+	}
+
+	// Empty result means service is not a standby.
+	if len(res.Rows) == 0 {
+		return nil
+	}
+
+	stat := parsePostgresRecoveryPrefetchStats(res)
+
+	ch <- c.blocks.newConstMetric(stat.prefetch, "prefetch")
+	ch <- c.blocks.newConstMetric(stat.hit, "hit")
+	ch <- c.blocks.newConstMetric(stat.skipInit, "skip_init")
+	ch <- c.blocks.newConstMetric(stat.skipNew, "skip_new")
+	ch <- c.blocks.newConstMetric(stat.skipFPW, "skip_fpw")
+	ch <- c.blocks.newConstMetric(stat.skipRep, "skip_rep")
+	ch <- c.walDistance.newConstMetric(stat.walDistance)
+	ch <- c.blockDistance.newConstMetric(stat.blockDistance)
+	ch <- c.ioDepth.newConstMetric(stat.ioDepth)
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresRecoveryPrefetchCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV15 {
+		return nil
+	}
+	return []string{postgresRecoveryPrefetchQuery}
+}
+
+// postgresRecoveryPrefetchStat represents recovery prefetch stats based on pg_stat_recovery_prefetch.
+type postgresRecoveryPrefetchStat struct {
+	prefetch      float64
+	hit           float64
+	skipInit      float64
+	skipNew       float64
+	skipFPW       float64
+	skipRep       float64
+	walDistance   float64
+	blockDistance float64
+	ioDepth       float64
+}
+
+// parsePostgresRecoveryPrefetchStats parses PGResult and returns struct with stats values.
+func parsePostgresRecoveryPrefetchStats(r *model.PGResult) postgresRecoveryPrefetchStat {
+	log.Debug("parse postgres recovery prefetch stats")
+
+	var stat postgresRecoveryPrefetchStat
+
+	for _, row := range r.Rows {
+		for i, colname := range r.Colnames {
+			// Skip empty (NULL) values.
+			if !row[i].Valid {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+				continue
+			}
+
+			switch string(colname.Name) {
+			case "prefetch":
+				stat.prefetch = v
+			case "hit":
+				stat.hit = v
+			case "skip_init":
+				stat.skipInit = v
+			case "skip_new":
+				stat.skipNew = v
+			case "skip_fpw":
+				stat.skipFPW = v
+			case "skip_rep":
+				stat.skipRep = v
+			case "wal_distance":
+				stat.walDistance = v
+			case "block_distance":
+				stat.blockDistance = v
+			case "io_depth":
+				stat.ioDepth = v
+			}
+		}
+	}
+
+	return stat
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRecoveryPrefetchCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_recovery_prefetch_blocks_total",
+			"postgres_recovery_prefetch_wal_distance_bytes",
+			"postgres_recovery_prefetch_block_distance",
+			"postgres_recovery_prefetch_io_depth",
+		},
+		collector: NewPostgresRecoveryPrefetchCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresRecoveryPrefetchStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 9,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("prefetch")}, {Name: []byte("hit")}, {Name: []byte("skip_init")}, {Name: []byte("skip_new")},
+			{Name: []byte("skip_fpw")}, {Name: []byte("skip_rep")}, {Name: []byte("wal_distance")},
+			{Name: []byte("block_distance")}, {Name: []byte("io_depth")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "1250", Valid: true}, {String: "48230", Valid: true}, {String: "12", Valid: true}, {String: "340", Valid: true},
+				{String: "5120", Valid: true}, {String: "7810", Valid: true}, {String: "65536", Valid: true},
+				{String: "24", Valid: true}, {String: "", Valid: false},
+			},
+		},
+	}
+
+	want := postgresRecoveryPrefetchStat{
+		prefetch: 1250, hit: 48230, skipInit: 12, skipNew: 340, skipFPW: 5120, skipRep: 7810,
+		walDistance: 65536, blockDistance: 24,
+	}
+
+	assert.Equal(t, want, parsePostgresRecoveryPrefetchStats(res))
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -99,7 +98,7 @@ func NewPostgresReplicationCollector(constLabels labels, settings model.Collecto
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresReplicationCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -147,6 +146,11 @@ func (c *postgresReplicationCollector) Update(config Config, ch chan<- prometheu
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresReplicationCollector) executedQueries(config Config) []string {
+	return []string{selectReplicationQuery(config.pgVersion)}
+}
+
 // postgresReplicationStat represents per-replica stats based on pg_stat_replication.
 type postgresReplicationStat struct {
 	pid             string
@@ -7,36 +7,47 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// Consumers of active slots are looked up in pg_stat_activity by PID of the process streaming from the slot. Each slot
+// has at most one consumer, hence consumer labels don't increase number of series. Consumers connected via Unix socket
+// have 'local' address.
 const (
 	// Query for Postgres version 9.6 and older.
-	postgresReplicationSlotQuery96 = "SELECT database, slot_name, slot_type, active, " +
-		"CASE WHEN pg_is_in_recovery() THEN pg_xlog_location_diff(pg_last_xlog_receive_location(), restart_lsn) " +
-		"ELSE pg_xlog_location_diff(pg_current_xlog_location(), restart_lsn) END AS since_restart_bytes " +
-		"FROM pg_replication_slots"
+	postgresReplicationSlotQuery96 = "SELECT s.database, s.slot_name, s.slot_type, s.active, " +
+		"COALESCE(a.application_name, '') AS application_name, " +
+		"CASE WHEN a.pid IS NULL THEN '' ELSE COALESCE(host(a.client_addr), 'local') END AS client_addr, " +
+		"CASE WHEN pg_is_in_recovery() THEN pg_xlog_location_diff(pg_last_xlog_receive_location(), s.restart_lsn) " +
+		"ELSE pg_xlog_location_diff(pg_current_xlog_location(), s.restart_lsn) END AS since_restart_bytes " +
+		"FROM pg_replication_slots s LEFT JOIN pg_stat_activity a ON a.pid = s.active_pid"
 
 	// Query for Postgres versions from 10 and newer.
-	postgresReplicationSlotQueryLatest = "SELECT database, slot_name, slot_type, active, " +
-		"CASE WHEN pg_is_in_recovery() THEN pg_wal_lsn_diff(pg_last_wal_receive_lsn(), restart_lsn) " +
-		"ELSE pg_wal_lsn_diff(pg_current_wal_lsn(), restart_lsn) END AS since_restart_bytes " +
-		"FROM pg_replication_slots"
+	postgresReplicationSlotQueryLatest = "SELECT s.database, s.slot_name, s.slot_type, s.active, " +
+		"COALESCE(a.application_name, '') AS application_name, " +
+		"CASE WHEN a.pid IS NULL THEN '' ELSE COALESCE(host(a.client_addr), 'local') END AS client_addr, " +
+		"CASE WHEN pg_is_in_recovery() THEN pg_wal_lsn_diff(pg_last_wal_receive_lsn(), s.restart_lsn) " +
+		"ELSE pg_wal_lsn_diff(pg_current_wal_lsn(), s.restart_lsn) END AS since_restart_bytes " +
+		"FROM pg_replication_slots s LEFT JOIN pg_stat_activity a ON a.pid = s.active_pid"
+
+	// postgresMaxSlotWalKeepSizeQuery returns max_slot_wal_keep_size in bytes, -1 means unlimited (since Postgres 13).
+	postgresMaxSlotWalKeepSizeQuery = "SELECT (CASE WHEN setting::bigint < 0 THEN -1 ELSE setting::bigint * 1024 * 1024 END)::float8 " +
+		"FROM pg_settings WHERE name = 'max_slot_wal_keep_size'"
 )
 
 type postgresReplicationSlotCollector struct {
 	restart typedDesc
 }
 
-// NewPostgresReplicationSlotsCollector returns a new Collector exposing postgres replication slots stats.
+// NewPostgresReplicationSlotsCollector returns a new Collector exposing postgres replication slots stats. Metrics of
+// active slots are labeled with application name and address of the consumer, e.g. Debezium connector.
 // For details see https://www.postgresql.org/docs/current/view-pg-replication-slots.html
 func NewPostgresReplicationSlotsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	return &postgresReplicationSlotCollector{
 		restart: newBuiltinTypedDesc(
 			descOpts{"postgres", "replication_slot", "wal_retain_bytes", "Number of WAL retained and required by consumers, in bytes.", 0},
 			prometheus.GaugeValue,
-			[]string{"database", "slot_name", "slot_type", "active"}, constLabels,
+			[]string{"database", "slot_name", "slot_type", "active", "application_name", "client_addr"}, constLabels,
 			settings.Filters,
 		),
 	}, nil
@@ -44,7 +55,7 @@ func NewPostgresReplicationSlotsCollector(constLabels labels, settings model.Col
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresReplicationSlotCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -59,18 +70,41 @@ func (c *postgresReplicationSlotCollector) Update(config Config, ch chan<- prome
 	stats := parsePostgresReplicationSlotStats(res, c.restart.labelNames)
 
 	for _, stat := range stats {
-		ch <- c.restart.newConstMetric(stat.retainedBytes, stat.database, stat.slotname, stat.slottype, stat.active)
+		ch <- c.restart.newConstMetric(stat.retainedBytes, stat.database, stat.slotname, stat.slottype, stat.active, stat.application, stat.clientaddr)
+		config.facts.publish(factSlotRetainedBytes, stat.retainedBytes, labels{"database": stat.database, "slot_name": stat.slotname, "slot_type": stat.slottype})
+	}
+
+	// Limit of retained WAL is used for computing derived metrics.
+	if config.pgVersion.Numeric >= PostgresV13 && len(stats) > 0 {
+		var keepSize float64
+		err := conn.QueryRow(postgresMaxSlotWalKeepSizeQuery).Scan(&keepSize)
+		if err != nil {
+			log.Warnf("get max_slot_wal_keep_size failed: %s; skip", err)
+		} else {
+			config.facts.publish(factMaxSlotWalKeepSizeBytes, keepSize, nil)
+		}
 	}
 
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresReplicationSlotCollector) executedQueries(config Config) []string {
+	query := selectReplicationSlotQuery(config.pgVersion.Numeric)
+	if config.pgVersion.Numeric < PostgresV13 {
+		return []string{query}
+	}
+	return []string{query, postgresMaxSlotWalKeepSizeQuery}
+}
+
 // postgresReplicationSlotStat represents per-slot stats based on pg_replication_slots.
 type postgresReplicationSlotStat struct {
 	database      string
 	slotname      string
 	slottype      string
 	active        string
+	application   string
+	clientaddr    string
 	retainedBytes float64
 }
 
@@ -94,6 +128,10 @@ func parsePostgresReplicationSlotStats(r *model.PGResult, labelNames []string) m
 				stat.slottype = row[i].String
 			case "active":
 				stat.active = row[i].String
+			case "application_name":
+				stat.application = row[i].String
+			case "client_addr":
+				stat.clientaddr = row[i].String
 			}
 		}
 
@@ -45,11 +45,39 @@ func Test_parsePostgresReplicationSlotStats(t *testing.T) {
 				"testdb/testslot/testtype": {slotname: "testslot", slottype: "testtype", database: "testdb", active: "t", retainedBytes: 25485425},
 			},
 		},
+		{
+			name: "output with consumers",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 7,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("slot_name")}, {Name: []byte("slot_type")}, {Name: []byte("database")}, {Name: []byte("active")},
+					{Name: []byte("application_name")}, {Name: []byte("client_addr")}, {Name: []byte("since_restart_bytes")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "debezium", Valid: true}, {String: "logical", Valid: true}, {String: "testdb", Valid: true}, {String: "t", Valid: true},
+						{String: "Debezium Streaming", Valid: true}, {String: "10.0.0.15", Valid: true}, {String: "1048576", Valid: true},
+					},
+					{
+						{String: "orphaned", Valid: true}, {String: "logical", Valid: true}, {String: "testdb", Valid: true}, {String: "f", Valid: true},
+						{String: "", Valid: true}, {String: "", Valid: true}, {String: "8388608", Valid: true},
+					},
+				},
+			},
+			want: map[string]postgresReplicationSlotStat{
+				"testdb/debezium/logical": {
+					slotname: "debezium", slottype: "logical", database: "testdb", active: "t",
+					application: "Debezium Streaming", clientaddr: "10.0.0.15", retainedBytes: 1048576,
+				},
+				"testdb/orphaned/logical": {slotname: "orphaned", slottype: "logical", database: "testdb", active: "f", retainedBytes: 8388608},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := parsePostgresReplicationSlotStats(tc.res, []string{"slot_name", "slot_type", "database", "active"})
+			got := parsePostgresReplicationSlotStats(tc.res, []string{"slot_name", "slot_type", "database", "active", "application_name", "client_addr"})
 			assert.EqualValues(t, tc.want, got)
 		})
 	}
@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresRolesQuery defines query for roles attributes and number of connections established by each role. Password
+// expiration time is returned only for roles with finite rolvaliduntil.
+const postgresRolesQuery = "SELECT r.rolname AS role, r.rolcanlogin AS login, r.rolsuper AS superuser, " +
+	"r.rolreplication AS replication, r.rolbypassrls AS bypassrls, r.rolconnlimit AS conn_limit, " +
+	"COALESCE(a.connections, 0) AS connections, " +
+	"CASE WHEN r.rolvaliduntil IS NULL OR r.rolvaliduntil = 'infinity' THEN NULL ELSE extract(epoch FROM r.rolvaliduntil) END AS valid_until " +
+	"FROM pg_roles r LEFT JOIN (SELECT usesysid, count(*) AS connections FROM pg_stat_activity GROUP BY usesysid) a ON a.usesysid = r.oid"
+
+// postgresRolesCollector defines metric descriptors.
+type postgresRolesCollector struct {
+	roles       typedDesc
+	connections typedDesc
+	connLimit   typedDesc
+	validUntil  typedDesc
+}
+
+// NewPostgresRolesCollector returns a new Collector exposing roles attributes, connections usage against
+// per-role connection limits and password expiration time.
+// For details see https://www.postgresql.org/docs/current/view-pg-roles.html
+func NewPostgresRolesCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresRolesCollector{
+		roles: newBuiltinTypedDesc(
+			descOpts{"postgres", "roles", "count", "Number of roles having attribute.", 0},
+			prometheus.GaugeValue,
+			[]string{"attribute"}, constLabels,
+			settings.Filters,
+		),
+		connections: newBuiltinTypedDesc(
+			descOpts{"postgres", "role", "connections_in_flight", "Number of connections currently established by role.", 0},
+			prometheus.GaugeValue,
+			[]string{"role"}, constLabels,
+			settings.Filters,
+		),
+		connLimit: newBuiltinTypedDesc(
+			descOpts{"postgres", "role", "connections_limit", "Maximum number of concurrent connections allowed for role.", 0},
+			prometheus.GaugeValue,
+			[]string{"role"}, constLabels,
+			settings.Filters,
+		),
+		validUntil: newBuiltinTypedDesc(
+			descOpts{"postgres", "role", "valid_until_seconds", "Time after which role's password is no longer valid, in unixtime.", 0},
+			prometheus.GaugeValue,
+			[]string{"role"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresRolesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(postgresRolesQuery)
+	if err != nil {
+		return err
+	}
+
+	roles := parsePostgresRoles(res)
+
+	counts := map[string]float64{"total": 0, "login": 0, "superuser": 0, "replication": 0, "bypassrls": 0}
+
+	for _, role := range roles {
+		counts["total"]++
+		if role.login {
+			counts["login"]++
+		}
+		if role.superuser {
+			counts["superuser"]++
+		}
+		if role.replication {
+			counts["replication"]++
+		}
+		if role.bypassrls {
+			counts["bypassrls"]++
+		}
+
+		// Roles without login privilege can't establish connections, skip them.
+		if !role.login {
+			continue
+		}
+
+		ch <- c.connections.newConstMetric(role.connections, role.name)
+
+		// Value of -1 means no limit.
+		if role.connLimit >= 0 {
+			ch <- c.connLimit.newConstMetric(role.connLimit, role.name)
+		}
+
+		if role.validUntil.Valid {
+			ch <- c.validUntil.newConstMetric(role.validUntil.Float64, role.name)
+		}
+	}
+
+	for attr, v := range counts {
+		ch <- c.roles.newConstMetric(v, attr)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresRolesCollector) executedQueries(_ Config) []string {
+	return []string{postgresRolesQuery}
+}
+
+// postgresRole represents role's attributes and connections usage.
+type postgresRole struct {
+	name        string
+	login       bool
+	superuser   bool
+	replication bool
+	bypassrls   bool
+	connLimit   float64
+	connections float64
+	validUntil  sql.NullFloat64
+}
+
+// parsePostgresRoles parses PGResult and returns roles.
+func parsePostgresRoles(r *model.PGResult) []postgresRole {
+	log.Debug("parse postgres roles")
+
+	var roles = make([]postgresRole, 0, r.Nrows)
+
+	for _, row := range r.Rows {
+		role := postgresRole{connLimit: -1}
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "role":
+				role.name = row[i].String
+			case "login":
+				role.login = row[i].String == "t" || row[i].String == "true"
+			case "superuser":
+				role.superuser = row[i].String == "t" || row[i].String == "true"
+			case "replication":
+				role.replication = row[i].String == "t" || row[i].String == "true"
+			case "bypassrls":
+				role.bypassrls = row[i].String == "t" || row[i].String == "true"
+			case "conn_limit", "connections", "valid_until":
+				// Skip empty (NULL) values.
+				if !row[i].Valid {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				switch string(colname.Name) {
+				case "conn_limit":
+					role.connLimit = v
+				case "connections":
+					role.connections = v
+				case "valid_until":
+					role.validUntil = sql.NullFloat64{Float64: v, Valid: true}
+				}
+			}
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles
+}
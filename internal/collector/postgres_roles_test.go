@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRolesCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_roles_count",
+			"postgres_role_connections_in_flight",
+		},
+		optional: []string{
+			"postgres_role_connections_limit",
+			"postgres_role_valid_until_seconds",
+		},
+		collector: NewPostgresRolesCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresRoles(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 8,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("role")}, {Name: []byte("login")}, {Name: []byte("superuser")}, {Name: []byte("replication")},
+			{Name: []byte("bypassrls")}, {Name: []byte("conn_limit")}, {Name: []byte("connections")}, {Name: []byte("valid_until")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "postgres", Valid: true}, {String: "t", Valid: true}, {String: "t", Valid: true}, {String: "t", Valid: true},
+				{String: "t", Valid: true}, {String: "-1", Valid: true}, {String: "3", Valid: true}, {},
+			},
+			{
+				{String: "app", Valid: true}, {String: "t", Valid: true}, {String: "f", Valid: true}, {String: "f", Valid: true},
+				{String: "f", Valid: true}, {String: "100", Valid: true}, {String: "42", Valid: true}, {String: "1767225600", Valid: true},
+			},
+			{
+				{String: "pg_monitor", Valid: true}, {String: "f", Valid: true}, {String: "f", Valid: true}, {String: "f", Valid: true},
+				{String: "f", Valid: true}, {String: "-1", Valid: true}, {String: "0", Valid: true}, {},
+			},
+		},
+	}
+
+	want := []postgresRole{
+		{name: "postgres", login: true, superuser: true, replication: true, bypassrls: true, connLimit: -1, connections: 3},
+		{name: "app", login: true, connLimit: 100, connections: 42, validUntil: sql.NullFloat64{Float64: 1767225600, Valid: true}},
+		{name: "pg_monitor", connLimit: -1},
+	}
+
+	assert.Equal(t, want, parsePostgresRoles(res))
+}
@@ -2,15 +2,68 @@
 package collector
 
 import (
+	"fmt"
+	"hash/fnv"
 	"strings"
 
-	"github.com/jackc/pgx/v4"
-
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/net/context"
+)
+
+const (
+	// postgresSchemaSystemCatalogSizeQuery defines query for size of system catalog.
+	postgresSchemaSystemCatalogSizeQuery = `SELECT sum(pg_total_relation_size(relname::regclass)) AS bytes FROM pg_stat_sys_tables WHERE schemaname = 'pg_catalog'`
+
+	// postgresSchemaNonPKTablesQuery defines query for tables without primary or unique keys.
+	postgresSchemaNonPKTablesQuery = "SELECT n.nspname AS schema, c.relname AS table " +
+		"FROM pg_class c JOIN pg_namespace n ON c.relnamespace = n.oid " +
+		"WHERE NOT EXISTS (SELECT 1 FROM pg_index i WHERE c.oid = i.indrelid AND (i.indisprimary OR i.indisunique)) " +
+		"AND c.relkind = 'r' AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')"
+
+	// postgresSchemaInvalidIndexesQuery defines query for invalid indexes.
+	postgresSchemaInvalidIndexesQuery = "SELECT c1.relnamespace::regnamespace::text AS schema, c2.relname AS table, c1.relname AS index, " +
+		"pg_relation_size(i.indexrelid) AS bytes " +
+		"FROM pg_index i JOIN pg_class c1 ON i.indexrelid = c1.oid JOIN pg_class c2 ON i.indrelid = c2.oid WHERE NOT i.indisvalid"
+
+	// postgresSchemaNonIndexedFKQuery defines query for foreign key constraints without indexes.
+	postgresSchemaNonIndexedFKQuery = "SELECT c.connamespace::regnamespace::text AS schema, s.relname AS table, " +
+		"string_agg(a.attname, ',' ORDER BY x.n) AS columns, c.conname AS constraint, " +
+		"c.confrelid::regclass::text AS referenced " +
+		"FROM pg_constraint c CROSS JOIN LATERAL unnest(c.conkey) WITH ORDINALITY AS x(attnum, n) " +
+		"JOIN pg_attribute a ON a.attnum = x.attnum AND a.attrelid = c.conrelid " +
+		"JOIN pg_class s ON c.conrelid = s.oid " +
+		"WHERE NOT EXISTS (SELECT 1 FROM pg_index i WHERE i.indrelid = c.conrelid AND (i.indkey::integer[])[0:cardinality(c.conkey)-1] @> c.conkey::integer[]) " +
+		"AND c.contype = 'f' " +
+		"GROUP BY c.connamespace,s.relname,c.conname,c.confrelid"
+
+	// postgresSchemaRedundantIndexesQuery defines query for redundant indexes.
+	postgresSchemaRedundantIndexesQuery = "WITH index_data AS (SELECT *, string_to_array(indkey::text,' ') AS key_array, array_length(string_to_array(indkey::text,' '),1) AS nkeys FROM pg_index) " +
+		"SELECT c1.relnamespace::regnamespace::text AS schema, c1.relname AS table, c2.relname AS index, " +
+		"pg_get_indexdef(i1.indexrelid) AS indexdef, pg_get_indexdef(i2.indexrelid) AS redundantdef, " +
+		"pg_relation_size(i2.indexrelid) AS bytes " +
+		"FROM index_data AS i1 JOIN index_data AS i2 ON i1.indrelid = i2.indrelid AND i1.indexrelid<>i2.indexrelid " +
+		"JOIN pg_class c1 ON i1.indrelid = c1.oid " +
+		"JOIN pg_class c2 ON i2.indexrelid = c2.oid " +
+		`WHERE (regexp_replace(i1.indpred, 'location \\d+', 'location', 'g') IS NOT DISTINCT FROM regexp_replace(i2.indpred, 'location \\d+', 'location', 'g')) ` +
+		`AND (regexp_replace(i1.indexprs, 'location \\d+', 'location', 'g') IS NOT DISTINCT FROM regexp_replace(i2.indexprs, 'location \\d+', 'location', 'g')) ` +
+		"AND ((i1.nkeys > i2.nkeys AND NOT i2.indisunique) OR (i1.nkeys = i2.nkeys AND ((i1.indisunique AND i2.indisunique AND (i1.indexrelid>i2.indexrelid)) " +
+		"OR (NOT i1.indisunique AND NOT i2.indisunique AND (i1.indexrelid>i2.indexrelid)) " +
+		"OR (i1.indisunique AND NOT i2.indisunique)))) AND i1.key_array[1:i2.nkeys]=i2.key_array"
+
+	// postgresSchemaSequencesQuery defines query for usage of sequences ranges.
+	postgresSchemaSequencesQuery = `SELECT schemaname AS schema, sequencename AS sequence, COALESCE(last_value, 0) / max_value::float AS ratio FROM pg_sequences`
+
+	// postgresSchemaFKDatatypeMismatchQuery defines query for foreign key constraints with different data types of columns.
+	postgresSchemaFKDatatypeMismatchQuery = "SELECT c1.relnamespace::regnamespace::text AS schema, c1.relname AS table, a1.attname||'::'||t1.typname AS column, " +
+		"c2.relnamespace::regnamespace::text AS refschema, c2.relname AS reftable, a2.attname||'::'||t2.typname AS refcolumn " +
+		"FROM pg_constraint JOIN pg_class c1 ON c1.oid = conrelid JOIN pg_class c2 ON c2.oid = confrelid " +
+		"JOIN pg_attribute a1 ON a1.attnum = conkey[1] AND a1.attrelid = conrelid " +
+		"JOIN pg_attribute a2 ON a2.attnum = confkey[1] AND a2.attrelid = confrelid " +
+		"JOIN pg_type t1 ON t1.oid = a1.atttypid " +
+		"JOIN pg_type t2 ON t2.oid = a2.atttypid " +
+		"WHERE a1.atttypid <> a2.atttypid AND contype = 'f'"
 )
 
 // postgresSchemaCollector defines metric descriptors and stats store.
@@ -20,6 +73,7 @@ type postgresSchemaCollector struct {
 	invalididx   typedDesc
 	nonidxfkey   typedDesc
 	redundantidx typedDesc
+	redundantsz  typedDesc
 	sequences    typedDesc
 	difftypefkey typedDesc
 }
@@ -58,6 +112,12 @@ func NewPostgresSchemasCollector(constLabels labels, settings model.CollectorSet
 			[]string{"database", "schema", "table", "index", "indexdef", "redundantdef"}, constLabels,
 			settings.Filters,
 		),
+		redundantsz: newBuiltinTypedDesc(
+			descOpts{"postgres", "index", "redundant_bytes", "Number of bytes occupied by index which is a duplicate or a prefix of another index.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "index"}, constLabels,
+			settings.Filters,
+		),
 		sequences: newBuiltinTypedDesc(
 			descOpts{"postgres", "schema", "sequence_exhaustion_ratio", "Sequences usage percentage accordingly to attached column, in percent.", 0},
 			prometheus.GaugeValue,
@@ -75,18 +135,20 @@ func NewPostgresSchemasCollector(constLabels labels, settings model.CollectorSet
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
+	limiter := newSchemaLabelsLimiter(config.SchemaLabelMaxLength, config.SchemaLabelHash, config.SchemaMaxSeries)
+
 	collect := func(conn *store.DB) {
 		// 1. get system catalog size in bytes.
 		collectSystemCatalogSize(conn, ch, c.syscatalog)
 
 		// 2. collect metrics related to tables with no primary/unique key constraints.
-		collectSchemaNonPKTables(conn, ch, c.nonpktables)
+		collectSchemaNonPKTables(conn, ch, c.nonpktables, limiter)
 
 		// Functions below uses queries with casting to regnamespace data type, which is introduced in Postgres 9.5.
 		if config.pgVersion.Numeric < PostgresV95 {
@@ -95,23 +157,23 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 		}
 
 		// 3. collect metrics related to invalid indexes.
-		collectSchemaInvalidIndexes(conn, ch, c.invalididx)
+		collectSchemaInvalidIndexes(conn, ch, c.invalididx, limiter)
 
 		// 4. collect metrics related to non indexed foreign key constraints.
-		collectSchemaNonIndexedFK(conn, ch, c.nonidxfkey)
+		collectSchemaNonIndexedFK(conn, ch, c.nonidxfkey, limiter)
 
 		// 5. collect metric related to redundant indexes.
-		collectSchemaRedundantIndexes(conn, ch, c.redundantidx)
+		collectSchemaRedundantIndexes(conn, ch, c.redundantidx, c.redundantsz, limiter)
 
 		// 6. collect metrics related to foreign key constraints with different data types.
-		collectSchemaFKDatatypeMismatch(conn, ch, c.difftypefkey)
+		collectSchemaFKDatatypeMismatch(conn, ch, c.difftypefkey, limiter)
 
 		// Function below uses queries pg_sequences which is introduced in Postgres 10.
 		if config.pgVersion.Numeric < PostgresV10 {
 			log.Debugln("[postgres schema collector]: some system views are not available, required Postgres 10 or newer")
 		} else {
 			// 7. collect metrics related to sequences (available since Postgres 10).
-			collectSchemaSequences(conn, ch, c.sequences)
+			collectSchemaSequences(conn, ch, c.sequences, limiter)
 		}
 	}
 
@@ -121,12 +183,7 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 		return nil
 	}
 
-	databases, err := listDatabases(conn)
-	if err != nil {
-		return err
-	}
-
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
 	if err != nil {
 		return err
 	}
@@ -137,18 +194,34 @@ func (c *postgresSchemaCollector) Update(config Config, ch chan<- prometheus.Met
 		if !config.DatabasesRE.MatchString(d) {
 			continue
 		}
-		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := config.acquireDatabaseConn(d)
 		if err != nil {
 			return err
 		}
-		defer conn.Close()
 		collect(conn)
+		conn.Close()
 	}
 
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresSchemaCollector) executedQueries(config Config) []string {
+	queries := []string{postgresSchemaSystemCatalogSizeQuery, postgresSchemaNonPKTablesQuery}
+	if config.pgVersion.Numeric >= PostgresV95 {
+		queries = append(queries,
+			postgresSchemaInvalidIndexesQuery,
+			postgresSchemaNonIndexedFKQuery,
+			postgresSchemaRedundantIndexesQuery,
+			postgresSchemaFKDatatypeMismatchQuery,
+		)
+	}
+	if config.pgVersion.Numeric >= PostgresV10 {
+		queries = append(queries, postgresSchemaSequencesQuery)
+	}
+	return perDatabaseQueries(config, queries...)
+}
+
 // collectSystemCatalogSize collects system catalog size metrics.
 func collectSystemCatalogSize(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
 	datname := conn.Conn().Config().Database
@@ -165,16 +238,15 @@ func collectSystemCatalogSize(conn *store.DB, ch chan<- prometheus.Metric, desc
 
 // getSystemCatalogSize returns size of system catalog in bytes.
 func getSystemCatalogSize(conn *store.DB) (float64, error) {
-	var query = `SELECT sum(pg_total_relation_size(relname::regclass)) AS bytes FROM pg_stat_sys_tables WHERE schemaname = 'pg_catalog'`
 	var size int64
-	if err := conn.Conn().QueryRow(context.Background(), query).Scan(&size); err != nil {
+	if err := conn.QueryRow(postgresSchemaSystemCatalogSizeQuery).Scan(&size); err != nil {
 		return 0, err
 	}
 	return float64(size), nil
 }
 
 // collectSchemaNonPKTables collects metrics related to non-PK tables.
-func collectSchemaNonPKTables(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaNonPKTables(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc, limiter *schemaLabelsLimiter) {
 	datname := conn.Conn().Config().Database
 	tables, err := getSchemaNonPKTables(conn)
 	if err != nil {
@@ -189,18 +261,16 @@ func collectSchemaNonPKTables(conn *store.DB, ch chan<- prometheus.Metric, desc
 			log.Warnf("incorrect table FQ name: %s; skip", t)
 			continue
 		}
-		ch <- desc.newConstMetric(1, datname, parts[0], parts[1])
+		if !limiter.allow(desc) {
+			break
+		}
+		ch <- desc.newConstMetric(1, datname, limiter.value(parts[0]), limiter.value(parts[1]))
 	}
 }
 
 // getSchemaNonPKTables searches tables with no PRIMARY or UNIQUE keys in the database and return its names.
 func getSchemaNonPKTables(conn *store.DB) ([]string, error) {
-	var query = "SELECT n.nspname AS schema, c.relname AS table " +
-		"FROM pg_class c JOIN pg_namespace n ON c.relnamespace = n.oid " +
-		"WHERE NOT EXISTS (SELECT 1 FROM pg_index i WHERE c.oid = i.indrelid AND (i.indisprimary OR i.indisunique)) " +
-		"AND c.relkind = 'r' AND n.nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')"
-
-	rows, err := conn.Conn().Query(context.Background(), query)
+	rows, err := conn.QueryRows(postgresSchemaNonPKTablesQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +295,7 @@ func getSchemaNonPKTables(conn *store.DB) ([]string, error) {
 }
 
 // collectSchemaInvalidIndexes collects metrics related to invalid indexes.
-func collectSchemaInvalidIndexes(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaInvalidIndexes(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc, limiter *schemaLabelsLimiter) {
 	database := conn.Conn().Config().Database
 	stats, err := getSchemaInvalidIndexes(conn)
 	if err != nil {
@@ -246,16 +316,16 @@ func collectSchemaInvalidIndexes(conn *store.DB, ch chan<- prometheus.Metric, de
 			continue
 		}
 
-		ch <- desc.newConstMetric(value, database, schema, table, index)
+		if !limiter.allow(desc) {
+			break
+		}
+		ch <- desc.newConstMetric(value, database, limiter.value(schema), limiter.value(table), limiter.value(index))
 	}
 }
 
 // getSchemaInvalidIndexes searches invalid indexes in the database and return its names if such indexes have been found.
 func getSchemaInvalidIndexes(conn *store.DB) (map[string]postgresGenericStat, error) {
-	var query = "SELECT c1.relnamespace::regnamespace::text AS schema, c2.relname AS table, c1.relname AS index, " +
-		"pg_relation_size(i.indexrelid) AS bytes " +
-		"FROM pg_index i JOIN pg_class c1 ON i.indexrelid = c1.oid JOIN pg_class c2 ON i.indrelid = c2.oid WHERE NOT i.indisvalid"
-	res, err := conn.Query(query)
+	res, err := conn.Query(postgresSchemaInvalidIndexesQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -264,7 +334,7 @@ func getSchemaInvalidIndexes(conn *store.DB) (map[string]postgresGenericStat, er
 }
 
 // collectSchemaNonIndexedFK collects metrics related to non indexed foreign key constraints.
-func collectSchemaNonIndexedFK(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaNonIndexedFK(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc, limiter *schemaLabelsLimiter) {
 	database := conn.Conn().Config().Database
 	stats, err := getSchemaNonIndexedFK(conn)
 	if err != nil {
@@ -286,23 +356,16 @@ func collectSchemaNonIndexedFK(conn *store.DB, ch chan<- prometheus.Metric, desc
 			continue
 		}
 
-		ch <- desc.newConstMetric(1, database, schema, table, columns, constraint, referenced)
+		if !limiter.allow(desc) {
+			break
+		}
+		ch <- desc.newConstMetric(1, database, limiter.value(schema), limiter.value(table), limiter.value(columns), limiter.value(constraint), limiter.value(referenced))
 	}
 }
 
 // getSchemaNonIndexedFK searches non indexes foreign key constraints and return its names.
 func getSchemaNonIndexedFK(conn *store.DB) (map[string]postgresGenericStat, error) {
-	var query = "SELECT c.connamespace::regnamespace::text AS schema, s.relname AS table, " +
-		"string_agg(a.attname, ',' ORDER BY x.n) AS columns, c.conname AS constraint, " +
-		"c.confrelid::regclass::text AS referenced " +
-		"FROM pg_constraint c CROSS JOIN LATERAL unnest(c.conkey) WITH ORDINALITY AS x(attnum, n) " +
-		"JOIN pg_attribute a ON a.attnum = x.attnum AND a.attrelid = c.conrelid " +
-		"JOIN pg_class s ON c.conrelid = s.oid " +
-		"WHERE NOT EXISTS (SELECT 1 FROM pg_index i WHERE i.indrelid = c.conrelid AND (i.indkey::integer[])[0:cardinality(c.conkey)-1] @> c.conkey::integer[]) " +
-		"AND c.contype = 'f' " +
-		"GROUP BY c.connamespace,s.relname,c.conname,c.confrelid"
-
-	res, err := conn.Query(query)
+	res, err := conn.Query(postgresSchemaNonIndexedFKQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -310,8 +373,9 @@ func getSchemaNonIndexedFK(conn *store.DB) (map[string]postgresGenericStat, erro
 	return parsePostgresGenericStats(res, []string{"schema", "table", "columns", "constraint", "referenced"}), nil
 }
 
-// collectSchemaRedundantIndexes collects metrics related to invalid indexes
-func collectSchemaRedundantIndexes(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+// collectSchemaRedundantIndexes collects metrics related to redundant indexes. Besides detailed metric with indexes
+// definitions, sizes of redundant indexes are sent with the same labels used by indexes collector.
+func collectSchemaRedundantIndexes(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc, sizeDesc typedDesc, limiter *schemaLabelsLimiter) {
 	database := conn.Conn().Config().Database
 	stats, err := getSchemaRedundantIndexes(conn)
 	if err != nil {
@@ -319,6 +383,9 @@ func collectSchemaRedundantIndexes(conn *store.DB, ch chan<- prometheus.Metric,
 		return
 	}
 
+	// Index could be redundant to several other indexes, send its size only once.
+	sizes := map[[3]string]float64{}
+
 	for k, s := range stats {
 		var (
 			schema       = s.labels["schema"]
@@ -334,26 +401,26 @@ func collectSchemaRedundantIndexes(conn *store.DB, ch chan<- prometheus.Metric,
 			continue
 		}
 
-		ch <- desc.newConstMetric(value, database, schema, table, index, indexdef, redundantdef)
+		schema, table, index = limiter.value(schema), limiter.value(table), limiter.value(index)
+		sizes[[3]string{schema, table, index}] = value
+
+		if !limiter.allow(desc) {
+			continue
+		}
+		ch <- desc.newConstMetric(value, database, schema, table, index, limiter.value(indexdef), limiter.value(redundantdef))
+	}
+
+	for k, v := range sizes {
+		if !limiter.allow(sizeDesc) {
+			break
+		}
+		ch <- sizeDesc.newConstMetric(v, database, k[0], k[1], k[2])
 	}
 }
 
 // getSchemaRedundantIndexes searches redundant indexes and returns its sizes
 func getSchemaRedundantIndexes(conn *store.DB) (map[string]postgresGenericStat, error) {
-	var query = "WITH index_data AS (SELECT *, string_to_array(indkey::text,' ') AS key_array, array_length(string_to_array(indkey::text,' '),1) AS nkeys FROM pg_index) " +
-		"SELECT c1.relnamespace::regnamespace::text AS schema, c1.relname AS table, c2.relname AS index, " +
-		"pg_get_indexdef(i1.indexrelid) AS indexdef, pg_get_indexdef(i2.indexrelid) AS redundantdef, " +
-		"pg_relation_size(i2.indexrelid) AS bytes " +
-		"FROM index_data AS i1 JOIN index_data AS i2 ON i1.indrelid = i2.indrelid AND i1.indexrelid<>i2.indexrelid " +
-		"JOIN pg_class c1 ON i1.indrelid = c1.oid " +
-		"JOIN pg_class c2 ON i2.indexrelid = c2.oid " +
-		`WHERE (regexp_replace(i1.indpred, 'location \\d+', 'location', 'g') IS NOT DISTINCT FROM regexp_replace(i2.indpred, 'location \\d+', 'location', 'g')) ` +
-		`AND (regexp_replace(i1.indexprs, 'location \\d+', 'location', 'g') IS NOT DISTINCT FROM regexp_replace(i2.indexprs, 'location \\d+', 'location', 'g')) ` +
-		"AND ((i1.nkeys > i2.nkeys AND NOT i2.indisunique) OR (i1.nkeys = i2.nkeys AND ((i1.indisunique AND i2.indisunique AND (i1.indexrelid>i2.indexrelid)) " +
-		"OR (NOT i1.indisunique AND NOT i2.indisunique AND (i1.indexrelid>i2.indexrelid)) " +
-		"OR (i1.indisunique AND NOT i2.indisunique)))) AND i1.key_array[1:i2.nkeys]=i2.key_array"
-
-	res, err := conn.Query(query)
+	res, err := conn.Query(postgresSchemaRedundantIndexesQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -362,7 +429,7 @@ func getSchemaRedundantIndexes(conn *store.DB) (map[string]postgresGenericStat,
 }
 
 // collectSchemaSequences collects metrics related to sequences attached to poor-typed columns.
-func collectSchemaSequences(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaSequences(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc, limiter *schemaLabelsLimiter) {
 	database := conn.Conn().Config().Database
 	stats, err := getSchemaSequences(conn)
 	if err != nil {
@@ -382,15 +449,16 @@ func collectSchemaSequences(conn *store.DB, ch chan<- prometheus.Metric, desc ty
 			continue
 		}
 
-		ch <- desc.newConstMetric(value, database, schema, sequence)
+		if !limiter.allow(desc) {
+			break
+		}
+		ch <- desc.newConstMetric(value, database, limiter.value(schema), limiter.value(sequence))
 	}
 }
 
 // getSchemaSequences searches sequences attached to the poor-typed columns with risk of exhaustion.
 func getSchemaSequences(conn *store.DB) (map[string]postgresGenericStat, error) {
-	var query = `SELECT schemaname AS schema, sequencename AS sequence, COALESCE(last_value, 0) / max_value::float AS ratio FROM pg_sequences`
-
-	res, err := conn.Query(query)
+	res, err := conn.Query(postgresSchemaSequencesQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -399,7 +467,7 @@ func getSchemaSequences(conn *store.DB) (map[string]postgresGenericStat, error)
 }
 
 // collectSchemaFKDatatypeMismatch collects metrics related to foreign key constraints with different data types.
-func collectSchemaFKDatatypeMismatch(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc) {
+func collectSchemaFKDatatypeMismatch(conn *store.DB, ch chan<- prometheus.Metric, desc typedDesc, limiter *schemaLabelsLimiter) {
 	database := conn.Conn().Config().Database
 	stats, err := getSchemaFKDatatypeMismatch(conn)
 	if err != nil {
@@ -422,25 +490,76 @@ func collectSchemaFKDatatypeMismatch(conn *store.DB, ch chan<- prometheus.Metric
 			continue
 		}
 
-		ch <- desc.newConstMetric(1, database, schema, table, column, refschema, reftable, refcolumn)
+		if !limiter.allow(desc) {
+			break
+		}
+		ch <- desc.newConstMetric(1, database, limiter.value(schema), limiter.value(table), limiter.value(column), limiter.value(refschema), limiter.value(reftable), limiter.value(refcolumn))
 	}
 }
 
 // getSchemaFKDatatypeMismatch searches foreign key constraints with different data types.
 func getSchemaFKDatatypeMismatch(conn *store.DB) (map[string]postgresGenericStat, error) {
-	var query = "SELECT c1.relnamespace::regnamespace::text AS schema, c1.relname AS table, a1.attname||'::'||t1.typname AS column, " +
-		"c2.relnamespace::regnamespace::text AS refschema, c2.relname AS reftable, a2.attname||'::'||t2.typname AS refcolumn " +
-		"FROM pg_constraint JOIN pg_class c1 ON c1.oid = conrelid JOIN pg_class c2 ON c2.oid = confrelid " +
-		"JOIN pg_attribute a1 ON a1.attnum = conkey[1] AND a1.attrelid = conrelid " +
-		"JOIN pg_attribute a2 ON a2.attnum = confkey[1] AND a2.attrelid = confrelid " +
-		"JOIN pg_type t1 ON t1.oid = a1.atttypid " +
-		"JOIN pg_type t2 ON t2.oid = a2.atttypid " +
-		"WHERE a1.atttypid <> a2.atttypid AND contype = 'f'"
-
-	res, err := conn.Query(query)
+	res, err := conn.Query(postgresSchemaFKDatatypeMismatchQuery)
 	if err != nil {
 		return nil, err
 	}
 
 	return parsePostgresGenericStats(res, []string{"schema", "table", "column", "refschema", "reftable", "refcolumn"}), nil
 }
+
+// schemaLabelsLimiter limits length of label values and number of series of schema metrics. Long label values such
+// as indexes definitions are truncated, and optionally suffixed with hash of the full value to keep series distinct.
+type schemaLabelsLimiter struct {
+	maxLength int
+	hash      bool
+	maxSeries int
+	series    map[*prometheus.Desc]int // number of series sent per metric
+	warned    map[*prometheus.Desc]bool
+}
+
+// newSchemaLabelsLimiter creates new schemaLabelsLimiter. Zero maxLength and maxSeries mean no limits.
+func newSchemaLabelsLimiter(maxLength int, hash bool, maxSeries int) *schemaLabelsLimiter {
+	return &schemaLabelsLimiter{
+		maxLength: maxLength,
+		hash:      hash,
+		maxSeries: maxSeries,
+		series:    map[*prometheus.Desc]int{},
+		warned:    map[*prometheus.Desc]bool{},
+	}
+}
+
+// value returns label value truncated to max length.
+func (l *schemaLabelsLimiter) value(v string) string {
+	runes := []rune(v)
+	if l.maxLength <= 0 || len(runes) <= l.maxLength {
+		return v
+	}
+
+	if !l.hash {
+		return string(runes[:l.maxLength])
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(v))
+	suffix := fmt.Sprintf("~%08x", h.Sum32())
+
+	return string(runes[:l.maxLength-len(suffix)]) + suffix
+}
+
+// allow returns true if one more series of the metric is allowed to be sent.
+func (l *schemaLabelsLimiter) allow(desc typedDesc) bool {
+	if l.maxSeries <= 0 {
+		return true
+	}
+
+	if l.series[desc.desc] >= l.maxSeries {
+		if !l.warned[desc.desc] {
+			log.Warnf("[postgres schema collector]: series limit %d exceeded for %s; skip", l.maxSeries, desc.desc)
+			l.warned[desc.desc] = true
+		}
+		return false
+	}
+
+	l.series[desc.desc]++
+	return true
+}
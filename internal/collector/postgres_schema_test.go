@@ -2,8 +2,10 @@ package collector
 
 import (
 	"context"
+	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -16,6 +18,7 @@ func TestPostgresSchemaCollector_Update(t *testing.T) {
 			"postgres_schema_invalid_indexes_bytes",
 			"postgres_schema_non_indexed_fkeys",
 			"postgres_schema_redundant_indexes_bytes",
+			"postgres_index_redundant_bytes",
 			"postgres_schema_sequence_exhaustion_ratio",
 			"postgres_schema_mistyped_fkeys",
 		},
@@ -109,3 +112,26 @@ func Test_getSchemaFKDatatypeMismatch(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(got))
 }
+
+func Test_schemaLabelsLimiter(t *testing.T) {
+	long := "CREATE INDEX t1_idx ON public.t1 USING btree (a, b, c)"
+
+	l := newSchemaLabelsLimiter(0, false, 0)
+	assert.Equal(t, long, l.value(long))
+
+	l = newSchemaLabelsLimiter(20, false, 0)
+	assert.Equal(t, "CREATE INDEX t1_idx ", l.value(long))
+	assert.Equal(t, "short", l.value("short"))
+
+	l = newSchemaLabelsLimiter(20, true, 0)
+	got := l.value(long)
+	assert.Len(t, got, 20)
+	assert.Equal(t, "CREATE INDE~", got[:12])
+	assert.NotEqual(t, got, l.value(long+" WHERE a > 0"))
+
+	desc := newBuiltinTypedDesc(descOpts{"postgres", "schema", "test", "Test.", 0}, prometheus.GaugeValue, nil, nil, filter.New())
+	l = newSchemaLabelsLimiter(0, false, 2)
+	assert.True(t, l.allow(desc))
+	assert.True(t, l.allow(desc))
+	assert.False(t, l.allow(desc))
+}
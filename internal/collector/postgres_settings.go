@@ -10,10 +10,19 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// postgresSettingsQuery defines query for settings. For complete list of displayable names of GUC's sources types
+	// check guc.c (see GucSource_Names[]).
+	postgresSettingsQuery = "SELECT name, setting, unit, vartype FROM pg_show_all_settings() " +
+		"WHERE source IN ('default','configuration file','override','environment variable','command line','global')"
+
+	// postgresSettingsFilesQuery defines query for locations of configuration files and data directory.
+	postgresSettingsFilesQuery = "SELECT name, setting FROM pg_show_all_settings() WHERE name IN ('config_file','hba_file','ident_file','data_directory')"
+)
+
 // postgresSettingsCollector defines metric descriptors and stats store.
 type postgresSettingsCollector struct {
 	settings typedDesc
@@ -42,16 +51,13 @@ func NewPostgresSettingsCollector(constLabels labels, settings model.CollectorSe
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	// For complete list of displayable names of GUC's sources types check guc.c (see GucSource_Names[]).
-	query := "SELECT name, setting, unit, vartype FROM pg_show_all_settings() " +
-		"WHERE source IN ('default','configuration file','override','environment variable','command line','global')"
-	res, err := conn.Query(query)
+	res, err := conn.Query(postgresSettingsQuery)
 	if err != nil {
 		return err
 	}
@@ -70,8 +76,7 @@ func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.M
 		return nil
 	}
 
-	query = `SELECT name, setting FROM pg_show_all_settings() WHERE name IN ('config_file','hba_file','ident_file','data_directory')`
-	res, err = conn.Query(query)
+	res, err = conn.Query(postgresSettingsFilesQuery)
 	if err != nil {
 		return err
 	}
@@ -85,6 +90,14 @@ func (c *postgresSettingsCollector) Update(config Config, ch chan<- prometheus.M
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresSettingsCollector) executedQueries(config Config) []string {
+	if !config.localService {
+		return []string{postgresSettingsQuery}
+	}
+	return []string{postgresSettingsQuery, postgresSettingsFilesQuery}
+}
+
 // postgresSetting is per-setting store for metrics related to postgres settings.
 type postgresSetting struct {
 	name    string  // pg_settings.name
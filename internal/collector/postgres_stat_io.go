@@ -6,7 +6,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -163,7 +162,7 @@ func (c *postgresStatIOCollector) Update(config Config, ch chan<- prometheus.Met
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -201,6 +200,14 @@ func (c *postgresStatIOCollector) Update(config Config, ch chan<- prometheus.Met
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatIOCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV16 {
+		return nil
+	}
+	return []string{selectStatIOQuery(config.pgVersion.Numeric)}
+}
+
 // postgresStatIO
 type postgresStatIO struct {
 	BackendType   string // a backend type like "autovacuum worker"
@@ -6,7 +6,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -14,8 +13,25 @@ const (
 	postgresStatSlruQuery = "SELECT name, COALESCE(blks_zeroed, 0) AS blks_zeroed, COALESCE(blks_hit, 0) AS blks_hit, " +
 		"COALESCE(blks_read, 0) AS blks_read, COALESCE(blks_written, 0) AS blks_written, COALESCE(blks_exists, 0) AS blks_exists, " +
 		"COALESCE(flushes, 0) AS flushes, COALESCE(truncates, 0) AS truncates FROM pg_stat_slru"
+
+	// postgresSlruBuffersQuery defines query for querying configured SLRU buffers (since Postgres 17).
+	postgresSlruBuffersQuery = "SELECT name, setting FROM pg_settings WHERE name IN ('commit_timestamp_buffers', " +
+		"'multixact_member_buffers', 'multixact_offset_buffers', 'notify_buffers', 'serializable_buffers', " +
+		"'subtransaction_buffers', 'transaction_buffers')"
 )
 
+// slruLegacyNames defines mapping of SLRU names used before Postgres 17 to names used in Postgres 17 and newer. Names
+// are normalized to make metrics consistent across Postgres versions.
+var slruLegacyNames = map[string]string{
+	"CommitTs":        "commit_timestamp",
+	"MultiXactMember": "multixact_member",
+	"MultiXactOffset": "multixact_offset",
+	"Notify":          "notify",
+	"Serial":          "serializable",
+	"Subtrans":        "subtransaction",
+	"Xact":            "transaction",
+}
+
 // postgresStatSlruCollector defines metric descriptors and stats store.
 type postgresStatSlruCollector struct {
 	blksZeroed  typedDesc
@@ -25,6 +41,8 @@ type postgresStatSlruCollector struct {
 	blksExists  typedDesc
 	flushes     typedDesc
 	truncates   typedDesc
+	hitRatio    typedDesc
+	buffers     typedDesc
 	labelNames  []string
 }
 
@@ -76,6 +94,18 @@ func NewPostgresStatSlruCollector(constLabels labels, settings model.CollectorSe
 			labelNames, constLabels,
 			settings.Filters,
 		),
+		hitRatio: newBuiltinTypedDesc(
+			descOpts{"postgres", "stat_slru", "hit_ratio", "Ratio of blocks found in this SLRU to all blocks requested, since stats reset.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		buffers: newBuiltinTypedDesc(
+			descOpts{"postgres", "stat_slru", "buffers_bytes", "Configured size of this SLRU cache, in bytes (since v17, auto-tuned sizes are not reported).", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
@@ -86,35 +116,63 @@ func (c *postgresStatSlruCollector) Update(config Config, ch chan<- prometheus.M
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
 	// Collecting pg_stat_slru since Postgres 13.
-	if config.pgVersion.Numeric >= PostgresV13 {
-		res, err := conn.Query(postgresStatSlruQuery)
-		if err != nil {
-			log.Warnf("get pg_stat_slru failed: %s; skip", err)
-		} else {
-			stats := parsePostgresStatSlru(res, c.labelNames)
-
-			for _, stat := range stats {
-				ch <- c.blksZeroed.newConstMetric(stat.BlksZeroed, stat.SlruName)
-				ch <- c.blksHit.newConstMetric(stat.BlksHit, stat.SlruName)
-				ch <- c.blksRead.newConstMetric(stat.BlksRead, stat.SlruName)
-				ch <- c.blksWritten.newConstMetric(stat.BlksWritten, stat.SlruName)
-				ch <- c.blksExists.newConstMetric(stat.BlksExists, stat.SlruName)
-				ch <- c.flushes.newConstMetric(stat.Flushes, stat.SlruName)
-				ch <- c.truncates.newConstMetric(stat.Truncates, stat.SlruName)
+	res, err := conn.Query(postgresStatSlruQuery)
+	if err != nil {
+		log.Warnf("get pg_stat_slru failed: %s; skip", err)
+	} else {
+		stats := parsePostgresStatSlru(res, c.labelNames)
+
+		for _, stat := range stats {
+			ch <- c.blksZeroed.newConstMetric(stat.BlksZeroed, stat.SlruName)
+			ch <- c.blksHit.newConstMetric(stat.BlksHit, stat.SlruName)
+			ch <- c.blksRead.newConstMetric(stat.BlksRead, stat.SlruName)
+			ch <- c.blksWritten.newConstMetric(stat.BlksWritten, stat.SlruName)
+			ch <- c.blksExists.newConstMetric(stat.BlksExists, stat.SlruName)
+			ch <- c.flushes.newConstMetric(stat.Flushes, stat.SlruName)
+			ch <- c.truncates.newConstMetric(stat.Truncates, stat.SlruName)
+
+			// Hit ratio makes sense only when SLRU has been accessed.
+			if total := stat.BlksHit + stat.BlksRead; total > 0 {
+				ch <- c.hitRatio.newConstMetric(stat.BlksHit/total, stat.SlruName)
 			}
 		}
 	}
 
+	// SLRU sizes are configurable since Postgres 17.
+	if config.pgVersion.Numeric >= PostgresV17 {
+		res, err := conn.Query(postgresSlruBuffersQuery)
+		if err != nil {
+			log.Warnf("get SLRU buffers settings failed: %s; skip", err)
+			return nil
+		}
+
+		for name, buffers := range parsePostgresSlruBuffers(res) {
+			ch <- c.buffers.newConstMetric(buffers*float64(config.blockSize), name)
+		}
+	}
+
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatSlruCollector) executedQueries(config Config) []string {
+	switch {
+	case config.pgVersion.Numeric < PostgresV13:
+		return nil
+	case config.pgVersion.Numeric < PostgresV17:
+		return []string{postgresStatSlruQuery}
+	default:
+		return []string{postgresStatSlruQuery, postgresSlruBuffersQuery}
+	}
+}
+
 // postgresStatSlru
 type postgresStatSlru struct {
 	SlruName    string // a name of SLRU-cache
@@ -139,7 +197,7 @@ func parsePostgresStatSlru(r *model.PGResult, labelNames []string) map[string]po
 		for i, colname := range r.Colnames {
 			switch string(colname.Name) {
 			case "name":
-				SlruName = row[i].String
+				SlruName = normalizeSlruName(row[i].String)
 			}
 		}
 
@@ -196,3 +254,44 @@ func parsePostgresStatSlru(r *model.PGResult, labelNames []string) map[string]po
 
 	return stats
 }
+
+// parsePostgresSlruBuffers parses PGResult with SLRU buffers settings and returns number of buffers per SLRU. Settings
+// with zero value (auto-tuned) are skipped.
+func parsePostgresSlruBuffers(r *model.PGResult) map[string]float64 {
+	log.Debug("parse postgres SLRU buffers settings")
+
+	var buffers = make(map[string]float64)
+
+	for _, row := range r.Rows {
+		var name, setting string
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "name":
+				name = strings.TrimSuffix(row[i].String, "_buffers")
+			case "setting":
+				setting = row[i].String
+			}
+		}
+
+		v, err := strconv.ParseFloat(setting, 64)
+		if err != nil {
+			log.Errorf("invalid input, parse '%s' failed: %s; skip", setting, err)
+			continue
+		}
+
+		if v > 0 {
+			buffers[name] = v
+		}
+	}
+
+	return buffers
+}
+
+// normalizeSlruName returns SLRU name used since Postgres 17 for passed legacy name.
+func normalizeSlruName(name string) string {
+	if n, ok := slruLegacyNames[name]; ok {
+		return n
+	}
+	return name
+}
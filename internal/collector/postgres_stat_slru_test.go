@@ -2,6 +2,7 @@ package collector
 
 import (
 	"database/sql"
+	"strconv"
 	"testing"
 
 	"github.com/cherts/pgscv/internal/model"
@@ -20,6 +21,10 @@ func TestPostgresStatSlruCollector_Update(t *testing.T) {
 			"postgres_stat_slru_flushes",
 			"postgres_stat_slru_truncates",
 		},
+		optional: []string{
+			"postgres_stat_slru_hit_ratio",
+			"postgres_stat_slru_buffers_bytes",
+		},
 		collector: NewPostgresStatSlruCollector,
 		service:   model.ServiceTypePostgresql,
 	}
@@ -56,6 +61,24 @@ func Test_parsePostgresStatSlru(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "legacy names, Postgres 16",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 3,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("name")}, {Name: []byte("blks_hit")}, {Name: []byte("blks_read")},
+				},
+				Rows: [][]sql.NullString{
+					{{String: "Subtrans", Valid: true}, {String: "100", Valid: true}, {String: "20", Valid: true}},
+					{{String: "other", Valid: true}, {String: "5", Valid: true}, {String: "0", Valid: true}},
+				},
+			},
+			want: map[string]postgresStatSlru{
+				"subtransaction": {SlruName: "subtransaction", BlksHit: 100, BlksRead: 20},
+				"other":          {SlruName: "other", BlksHit: 5},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -65,3 +88,53 @@ func Test_parsePostgresStatSlru(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePostgresSlruBuffers(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 2,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("name")}, {Name: []byte("setting")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "subtransaction_buffers", Valid: true}, {String: "1024", Valid: true}},
+			{{String: "multixact_offset_buffers", Valid: true}, {String: "16", Valid: true}},
+			{{String: "transaction_buffers", Valid: true}, {String: "0", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, map[string]float64{"subtransaction": 1024, "multixact_offset": 16}, parsePostgresSlruBuffers(res))
+}
+
+func Test_normalizeSlruName(t *testing.T) {
+	// Names of SLRUs reported by pg_stat_slru in different Postgres versions.
+	var testCases = []struct {
+		versions []int
+		names    []string
+	}{
+		{
+			versions: []int{PostgresV13, PostgresV14, PostgresV15, PostgresV16},
+			names:    []string{"CommitTs", "MultiXactMember", "MultiXactOffset", "Notify", "Serial", "Subtrans", "Xact", "other"},
+		},
+		{
+			versions: []int{PostgresV17, PostgresV18},
+			names: []string{"commit_timestamp", "multixact_member", "multixact_offset", "notify", "serializable",
+				"subtransaction", "transaction", "other"},
+		},
+	}
+
+	want := []string{"commit_timestamp", "multixact_member", "multixact_offset", "notify", "serializable",
+		"subtransaction", "transaction", "other"}
+
+	for _, tc := range testCases {
+		for _, v := range tc.versions {
+			t.Run(strconv.Itoa(v), func(t *testing.T) {
+				got := make([]string, 0, len(tc.names))
+				for _, name := range tc.names {
+					got = append(got, normalizeSlruName(name))
+				}
+				assert.Equal(t, want, got)
+			})
+		}
+	}
+}
@@ -1,12 +1,12 @@
 package collector
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -22,16 +22,40 @@ const (
 		") SELECT * FROM pgsa " +
 		"UNION " +
 		"SELECT * FROM pgsr;"
+
+	// postgresStatSslTLSQuery defines query for distribution of SSL connections by TLS version and cipher.
+	postgresStatSslTLSQuery = "SELECT COALESCE(version, 'unknown') AS version, COALESCE(cipher, 'unknown') AS cipher, " +
+		"count(*) AS conn_number FROM pg_stat_ssl WHERE ssl GROUP BY 1, 2"
+
+	// postgresStatSslEncryptionQuery11 defines query for client connections by encryption for versions from 9.5 to 11.
+	// Postgres 11 and older don't have pg_stat_gssapi view. Unix socket connections have client_port = -1.
+	postgresStatSslEncryptionQuery11 = "SELECT a.datname AS database, " +
+		"CASE WHEN a.client_port = -1 THEN 'local' WHEN s.ssl THEN 'ssl' ELSE 'none' END AS encryption, " +
+		"count(*) AS conn_number FROM pg_stat_activity a " +
+		"LEFT JOIN pg_stat_ssl s ON s.pid = a.pid " +
+		"WHERE a.client_port IS NOT NULL AND a.datname IS NOT NULL GROUP BY 1, 2"
+
+	// postgresStatSslEncryptionQueryLatest defines query for client connections by encryption for recent versions.
+	postgresStatSslEncryptionQueryLatest = "SELECT a.datname AS database, " +
+		"CASE WHEN a.client_port = -1 THEN 'local' WHEN s.ssl THEN 'ssl' WHEN g.encrypted THEN 'gssapi' ELSE 'none' END AS encryption, " +
+		"count(*) AS conn_number FROM pg_stat_activity a " +
+		"LEFT JOIN pg_stat_ssl s ON s.pid = a.pid " +
+		"LEFT JOIN pg_stat_gssapi g ON g.pid = a.pid " +
+		"WHERE a.client_port IS NOT NULL AND a.datname IS NOT NULL GROUP BY 1, 2"
 )
 
 // postgresStatSslCollector defines metric descriptors and stats store.
 type postgresStatSslCollector struct {
-	sslConnNumber typedDesc
-	labelNames    []string
+	sslConnNumber    typedDesc
+	tlsConnNumber    typedDesc
+	clientConnNumber typedDesc
+	labelNames       []string
 }
 
-// NewPostgresStatSslCollector returns a new Collector exposing postgres pg_stat_ssl stats.
-// For details see https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-SSL-VIEW
+// NewPostgresStatSslCollector returns a new Collector exposing postgres pg_stat_ssl and pg_stat_gssapi stats.
+// For details see:
+//  1. https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-SSL-VIEW
+//  2. https://www.postgresql.org/docs/current/monitoring-stats.html#MONITORING-PG-STAT-GSSAPI-VIEW
 func NewPostgresStatSslCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
 	var labelNames = []string{"database", "username"}
 
@@ -43,6 +67,18 @@ func NewPostgresStatSslCollector(constLabels labels, settings model.CollectorSet
 			labelNames, constLabels,
 			settings.Filters,
 		),
+		tlsConnNumber: newBuiltinTypedDesc(
+			descOpts{"postgres", "stat_ssl", "tls_conn_number", "Number of SSL connections by TLS version and cipher.", 0},
+			prometheus.GaugeValue,
+			[]string{"version", "cipher"}, constLabels,
+			settings.Filters,
+		),
+		clientConnNumber: newBuiltinTypedDesc(
+			descOpts{"postgres", "stat_ssl", "client_conn_number", "Number of client connections by encryption: ssl, gssapi, none (unencrypted network connection) or local (Unix socket).", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "encryption"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
@@ -53,7 +89,7 @@ func (c *postgresStatSslCollector) Update(config Config, ch chan<- prometheus.Me
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -71,11 +107,47 @@ func (c *postgresStatSslCollector) Update(config Config, ch chan<- prometheus.Me
 				ch <- c.sslConnNumber.newConstMetric(stat.ConnNumber, stat.Database, stat.Username)
 			}
 		}
+
+		res, err = conn.Query(postgresStatSslTLSQuery)
+		if err != nil {
+			log.Warnf("get pg_stat_ssl TLS versions failed: %s; skip", err)
+		} else {
+			for _, stat := range parsePostgresConnNumbers(res, []string{"version", "cipher"}) {
+				ch <- c.tlsConnNumber.newConstMetric(stat.ConnNumber, stat.Labels...)
+			}
+		}
+
+		res, err = conn.Query(selectStatSslEncryptionQuery(config.pgVersion.Numeric))
+		if err != nil {
+			log.Warnf("get client connections encryption failed: %s; skip", err)
+		} else {
+			for _, stat := range parsePostgresConnNumbers(res, []string{"database", "encryption"}) {
+				ch <- c.clientConnNumber.newConstMetric(stat.ConnNumber, stat.Labels...)
+			}
+		}
 	}
 
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatSslCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV95 {
+		return nil
+	}
+	return []string{postgresStatSslQueryLatest, postgresStatSslTLSQuery, selectStatSslEncryptionQuery(config.pgVersion.Numeric)}
+}
+
+// selectStatSslEncryptionQuery returns suitable client connections encryption query depending on passed version.
+func selectStatSslEncryptionQuery(version int) string {
+	switch {
+	case version < PostgresV12:
+		return postgresStatSslEncryptionQuery11
+	default:
+		return postgresStatSslEncryptionQueryLatest
+	}
+}
+
 // postgresStatSsl represents per-subscription stats based on pg_stat_ssl.
 type postgresStatSsl struct {
 	Database   string // a database
@@ -142,3 +214,48 @@ func parsePostgresStatSsl(r *model.PGResult, labelNames []string) map[string]pos
 
 	return stats
 }
+
+// postgresConnNumber represents number of connections with the same label values.
+type postgresConnNumber struct {
+	Labels     []string // label values in order of label names
+	ConnNumber float64
+}
+
+// parsePostgresConnNumbers parses PGResult with label columns and 'conn_number' column, and returns stats values.
+func parsePostgresConnNumbers(r *model.PGResult, labelNames []string) []postgresConnNumber {
+	log.Debug("parse postgres connections numbers")
+
+	var stats []postgresConnNumber
+
+	for _, row := range r.Rows {
+		stat := postgresConnNumber{Labels: make([]string, len(labelNames))}
+		var found bool
+
+		for i, colname := range r.Colnames {
+			name := string(colname.Name)
+			if idx := slices.Index(labelNames, name); idx >= 0 {
+				stat.Labels[idx] = row[i].String
+				continue
+			}
+
+			// Skip empty (NULL) values.
+			if name != "conn_number" || !row[i].Valid {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+				continue
+			}
+
+			stat.ConnNumber, found = v, true
+		}
+
+		if found {
+			stats = append(stats, stat)
+		}
+	}
+
+	return stats
+}
@@ -13,6 +13,7 @@ func TestPostgresStatSslCollector_Update(t *testing.T) {
 	var input = pipelineInput{
 		required: []string{
 			"postgres_stat_ssl_conn_number",
+			"postgres_stat_ssl_client_conn_number",
 		},
 		collector: NewPostgresStatSslCollector,
 		service:   model.ServiceTypePostgresql,
@@ -56,3 +57,30 @@ func Test_parsePostgresStatSsl(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePostgresConnNumbers(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("encryption")}, {Name: []byte("conn_number")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "testdb", Valid: true}, {String: "ssl", Valid: true}, {String: "10", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "none", Valid: true}, {String: "2", Valid: true}},
+			{{String: "testdb", Valid: true}, {String: "local", Valid: true}, {}},
+		},
+	}
+
+	want := []postgresConnNumber{
+		{Labels: []string{"testdb", "ssl"}, ConnNumber: 10},
+		{Labels: []string{"testdb", "none"}, ConnNumber: 2},
+	}
+
+	assert.Equal(t, want, parsePostgresConnNumbers(res, []string{"database", "encryption"}))
+}
+
+func Test_selectStatSslEncryptionQuery(t *testing.T) {
+	assert.Equal(t, postgresStatSslEncryptionQuery11, selectStatSslEncryptionQuery(PostgresV11))
+	assert.Equal(t, postgresStatSslEncryptionQueryLatest, selectStatSslEncryptionQuery(PostgresV12))
+}
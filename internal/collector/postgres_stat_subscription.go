@@ -5,7 +5,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -129,7 +128,7 @@ func (c *postgresStatSubscriptionCollector) Update(config Config, ch chan<- prom
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -193,6 +192,14 @@ func (c *postgresStatSubscriptionCollector) Update(config Config, ch chan<- prom
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatSubscriptionCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV10 {
+		return nil
+	}
+	return []string{selectSubscriptionQuery(config.pgVersion.Numeric)}
+}
+
 // postgresSubscriptionStat represents per-subscription stats based on pg_stat_subscription.
 type postgresSubscriptionStat struct {
 	SubID      string // a subscription id
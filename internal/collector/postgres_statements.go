@@ -2,9 +2,17 @@
 package collector
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
@@ -22,35 +30,10 @@ const (
 		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
 		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
 		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written " +
-		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid;"
+		"FROM %s p JOIN pg_database d ON d.oid=p.dbid;"
 
-	postgresStatementsQuery12TopK = "WITH stat AS (SELECT d.datname AS DATABASE, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
-		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_time, p.blk_read_time, p.blk_write_time, " +
-		"NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
-		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
-		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
-		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
-		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.calls DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.rows DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.total_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.blk_read_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.blk_read_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.blk_write_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.temp_blks_read DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.temp_blks_written DESC NULLS LAST) < $1) AS visible " +
-		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid = p.dbid) " +
-		"SELECT DATABASE, \"user\", queryid, query, calls, rows, total_time, blk_read_time, blk_write_time, shared_blks_hit, " +
-		"shared_blks_read, shared_blks_dirtied, shared_blks_written, local_blks_hit, local_blks_read, local_blks_dirtied, local_blks_written, " +
-		"temp_blks_read, temp_blks_written FROM stat WHERE visible UNION ALL SELECT DATABASE, 'all_users', NULL, " +
-		"'all_queries', NULLIF(SUM(COALESCE(calls, 0)), 0), NULLIF(SUM(COALESCE(ROWS, 0)), 0), NULLIF(SUM(COALESCE(total_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(blk_read_time, 0)), 0), NULLIF(SUM(COALESCE(blk_write_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_read, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_dirtied, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_written, 0)), 0), NULLIF(SUM(COALESCE(local_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(local_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(local_blks_dirtied, 0)), 0), NULLIF(SUM(COALESCE(local_blks_written, 0)), 0), NULLIF(SUM(COALESCE(temp_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(temp_blks_written, 0)), 0) FROM stat WHERE NOT visible GROUP BY DATABASE HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible)"
-
-	postgresStatementsQuery16 = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
+	// postgresStatementsQuery13 defines query for querying statements metrics for PG13.
+	postgresStatementsQuery13 = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
 		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.blk_read_time, p.blk_write_time, " +
 		"NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
 		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
@@ -58,91 +41,40 @@ const (
 		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
 		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
 		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes " +
-		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
+		"FROM %s p JOIN pg_database d ON d.oid=p.dbid"
 
-	postgresStatementsQuery16TopK = "WITH stat AS (SELECT d.datname AS DATABASE, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
-		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.blk_read_time, p.blk_write_time, " +
+	// postgresStatementsQuery16 defines query for querying statements metrics for PG14-PG16, calls and plans of top-level
+	// statements are selected separately (toplevel is available since PG14).
+	postgresStatementsQuery16 = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
+		"COALESCE(%s, '') AS query, p.calls, CASE WHEN p.toplevel THEN p.calls END AS toplevel_calls, p.plans, " +
+		"CASE WHEN p.toplevel THEN p.plans END AS toplevel_plans, p.rows, " +
+		"p.total_exec_time, p.total_plan_time, p.blk_read_time, p.blk_write_time, " +
 		"NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
 		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
 		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
 		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
 		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
-		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes, " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.calls DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.rows DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.total_exec_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.total_plan_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.blk_read_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.blk_write_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.temp_blks_read DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.temp_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.wal_records DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.wal_fpi DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.wal_bytes DESC NULLS LAST) < $1) AS visible FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid = p.dbid) " +
-		"SELECT DATABASE, \"user\", queryid, query, calls, rows, total_exec_time, total_plan_time, blk_read_time, blk_write_time, shared_blks_hit, " +
-		"shared_blks_read, shared_blks_dirtied, shared_blks_written, local_blks_hit, local_blks_read, local_blks_dirtied, local_blks_written, " +
-		"temp_blks_read, temp_blks_written, wal_records, wal_fpi, wal_bytes FROM stat WHERE visible UNION ALL SELECT DATABASE, 'all_users', NULL, " +
-		"'all_queries', NULLIF(SUM(COALESCE(calls, 0)), 0), NULLIF(SUM(COALESCE(ROWS, 0)), 0), NULLIF(SUM(COALESCE(total_exec_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(total_plan_time, 0)), 0), NULLIF(SUM(COALESCE(blk_read_time, 0)), 0), NULLIF(SUM(COALESCE(blk_write_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_read, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_dirtied, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_written, 0)), 0), NULLIF(SUM(COALESCE(local_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(local_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(local_blks_dirtied, 0)), 0), NULLIF(SUM(COALESCE(local_blks_written, 0)), 0), NULLIF(SUM(COALESCE(temp_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(temp_blks_written, 0)), 0), NULLIF(SUM(COALESCE(wal_records, 0)), 0), NULLIF(SUM(COALESCE(wal_fpi, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(wal_bytes, 0)), 0) FROM stat WHERE NOT visible GROUP BY DATABASE HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible)"
+		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes " +
+		"FROM %s p JOIN pg_database d ON d.oid=p.dbid"
 
 	postgresStatementsQuery17 = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
-		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.shared_blk_read_time AS blk_read_time, " +
+		"COALESCE(%s, '') AS query, p.calls, CASE WHEN p.toplevel THEN p.calls END AS toplevel_calls, p.plans, " +
+		"CASE WHEN p.toplevel THEN p.plans END AS toplevel_plans, p.rows, " +
+		"p.total_exec_time, p.total_plan_time, p.shared_blk_read_time AS blk_read_time, " +
 		"p.shared_blk_write_time AS blk_write_time, NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
 		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
 		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
 		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
 		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
 		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes " +
-		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
-
-	postgresStatementsQuery17TopK = "WITH stat AS (SELECT d.datname AS DATABASE, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
-		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.shared_blk_read_time AS blk_read_time, " +
-		"p.shared_blk_write_time AS blk_write_time, NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
-		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
-		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
-		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
-		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
-		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes, " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.calls DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.rows DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.total_exec_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.total_plan_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blk_read_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blk_write_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.temp_blks_read DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.temp_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.wal_records DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.wal_fpi DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.wal_bytes DESC NULLS LAST) < $1) AS visible FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid = p.dbid) " +
-		"SELECT DATABASE, \"user\", queryid, query, calls, rows, total_exec_time, total_plan_time, blk_read_time, blk_write_time, shared_blks_hit, " +
-		"shared_blks_read, shared_blks_dirtied, shared_blks_written, local_blks_hit, local_blks_read, local_blks_dirtied, local_blks_written, " +
-		"temp_blks_read, temp_blks_written, wal_records, wal_fpi, wal_bytes FROM stat WHERE visible UNION ALL SELECT DATABASE, 'all_users', NULL, " +
-		"'all_queries', NULLIF(SUM(COALESCE(calls, 0)), 0), NULLIF(SUM(COALESCE(ROWS, 0)), 0), NULLIF(SUM(COALESCE(total_exec_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(total_plan_time, 0)), 0), NULLIF(SUM(COALESCE(blk_read_time, 0)), 0), NULLIF(SUM(COALESCE(blk_write_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_read, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_dirtied, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_written, 0)), 0), NULLIF(SUM(COALESCE(local_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(local_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(local_blks_dirtied, 0)), 0), NULLIF(SUM(COALESCE(local_blks_written, 0)), 0), NULLIF(SUM(COALESCE(temp_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(temp_blks_written, 0)), 0), NULLIF(SUM(COALESCE(wal_records, 0)), 0), NULLIF(SUM(COALESCE(wal_fpi, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(wal_bytes, 0)), 0) FROM stat WHERE NOT visible GROUP BY DATABASE HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible)"
+		"FROM %s p JOIN pg_database d ON d.oid=p.dbid"
 
 	// postgresStatementsQueryLatest defines query for querying statements metrics.
 	// 1. use nullif(value, 0) to nullify zero values, NULL are skipped by stats method and metrics wil not be generated.
 	postgresStatementsQueryLatest = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
-		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.shared_blk_read_time AS blk_read_time, " +
-		"p.shared_blk_write_time AS blk_write_time, NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
-		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
-		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
-		"NULLIF(p.local_blks_dirtied, 0) AS local_blks_dirtied, NULLIF(p.local_blks_written, 0) AS local_blks_written, " +
-		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
-		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes, " +
-		"NULLIF(p.wal_buffers_full, 0) AS wal_buffers_full " +
-		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid=p.dbid"
-
-	postgresStatementsQueryLatestTopK = "WITH stat AS (SELECT d.datname AS DATABASE, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
-		"COALESCE(%s, '') AS query, p.calls, p.rows, p.total_exec_time, p.total_plan_time, p.shared_blk_read_time AS blk_read_time, " +
+		"COALESCE(%s, '') AS query, p.calls, CASE WHEN p.toplevel THEN p.calls END AS toplevel_calls, p.plans, " +
+		"CASE WHEN p.toplevel THEN p.plans END AS toplevel_plans, p.rows, " +
+		"p.total_exec_time, p.total_plan_time, p.shared_blk_read_time AS blk_read_time, " +
 		"p.shared_blk_write_time AS blk_write_time, NULLIF(p.shared_blks_hit, 0) AS shared_blks_hit, NULLIF(p.shared_blks_read, 0) AS shared_blks_read, " +
 		"NULLIF(p.shared_blks_dirtied, 0) AS shared_blks_dirtied, NULLIF(p.shared_blks_written, 0) AS shared_blks_written, " +
 		"NULLIF(p.local_blks_hit, 0) AS local_blks_hit, NULLIF(p.local_blks_read, 0) AS local_blks_read, " +
@@ -150,33 +82,42 @@ const (
 		"NULLIF(p.temp_blks_read, 0) AS temp_blks_read, NULLIF(p.temp_blks_written, 0) AS temp_blks_written, " +
 		"NULLIF(p.wal_records, 0) AS wal_records, NULLIF(p.wal_fpi, 0) AS wal_fpi, NULLIF(p.wal_bytes, 0) AS wal_bytes, " +
 		"NULLIF(p.wal_buffers_full, 0) AS wal_buffers_full, " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.calls DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.rows DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.total_exec_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.total_plan_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blk_read_time DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blk_write_time DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.shared_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.shared_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_hit DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_read DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.local_blks_dirtied DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.local_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.temp_blks_read DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.temp_blks_written DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.wal_records DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.wal_fpi DESC NULLS LAST) < $1) OR " +
-		"(ROW_NUMBER() OVER ( ORDER BY p.wal_bytes DESC NULLS LAST) < $1) OR (ROW_NUMBER() OVER ( ORDER BY p.wal_buffers_full DESC NULLS LAST) < $1) AS visible " +
-		"FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid = p.dbid) " +
-		"SELECT DATABASE, \"user\", queryid, query, calls, rows, total_exec_time, total_plan_time, blk_read_time, blk_write_time, shared_blks_hit, " +
-		"shared_blks_read, shared_blks_dirtied, shared_blks_written, local_blks_hit, local_blks_read, local_blks_dirtied, local_blks_written, " +
-		"temp_blks_read, temp_blks_written, wal_records, wal_fpi, wal_bytes, wal_buffers_full FROM stat WHERE visible UNION ALL SELECT DATABASE, 'all_users', NULL, " +
-		"'all_queries', NULLIF(SUM(COALESCE(calls, 0)), 0), NULLIF(SUM(COALESCE(ROWS, 0)), 0), NULLIF(SUM(COALESCE(total_exec_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(total_plan_time, 0)), 0), NULLIF(SUM(COALESCE(blk_read_time, 0)), 0), NULLIF(SUM(COALESCE(blk_write_time, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_read, 0)), 0), NULLIF(SUM(COALESCE(shared_blks_dirtied, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(shared_blks_written, 0)), 0), NULLIF(SUM(COALESCE(local_blks_hit, 0)), 0), NULLIF(SUM(COALESCE(local_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(local_blks_dirtied, 0)), 0), NULLIF(SUM(COALESCE(local_blks_written, 0)), 0), NULLIF(SUM(COALESCE(temp_blks_read, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(temp_blks_written, 0)), 0), NULLIF(SUM(COALESCE(wal_records, 0)), 0), NULLIF(SUM(COALESCE(wal_fpi, 0)), 0), " +
-		"NULLIF(SUM(COALESCE(wal_bytes, 0)), 0), NULLIF(SUM(COALESCE(wal_buffers_full, 0)), 0) FROM stat WHERE NOT visible " +
-		"GROUP BY DATABASE HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible)"
+		"NULLIF(p.parallel_workers_to_launch, 0) AS parallel_workers_to_launch, " +
+		"NULLIF(p.parallel_workers_launched, 0) AS parallel_workers_launched " +
+		"FROM %s p JOIN pg_database d ON d.oid=p.dbid"
+
+	// postgresStatementsPlanIDColumnQuery defines query for looking up column with plan identifier in pg_stat_statements.
+	postgresStatementsPlanIDColumnQuery = "SELECT attname FROM pg_attribute WHERE attrelid = '%s.pg_stat_statements'::regclass " +
+		"AND attname IN ('planid', 'plan_id') AND NOT attisdropped LIMIT 1"
+
+	// postgresStatementsEntriesQuery13 defines query for max allowed statements for PG13 and older. Number of tracked
+	// statements is taken from statements snapshot.
+	postgresStatementsEntriesQuery13 = "SELECT current_setting('pg_stat_statements.max')::float8 AS max_entries"
+
+	// postgresStatementsEntriesQueryLatest defines query for max allowed statements, and number of times least-executed
+	// statements have been deallocated since stats reset.
+	postgresStatementsEntriesQueryLatest = "SELECT current_setting('pg_stat_statements.max')::float8 AS max_entries, i.dealloc, " +
+		"EXTRACT(EPOCH FROM now() - i.stats_reset) AS stats_age_seconds " +
+		"FROM %s.pg_stat_statements_info i"
+
+	// statementsDeallocPercent defines percent of entries evicted by pg_stat_statements on each deallocation, but no
+	// less than statementsDeallocMin entries. See USAGE_DEALLOC_PERCENT in pg_stat_statements.c.
+	statementsDeallocPercent = 5
+	statementsDeallocMin     = 10
+
+	// postgresStatementsTracesQuery defines query for trace context of running statements passed by clients in
+	// application_name (since Postgres 14).
+	postgresStatementsTracesQuery = "SELECT datname AS database, usename AS \"user\", query_id AS queryid, application_name " +
+		"FROM pg_stat_activity WHERE query_id IS NOT NULL AND application_name ~ '[0-9a-f]{32}'"
 )
 
+// reTraceparent defines regexp for extracting trace id from traceparent value passed in application_name, e.g. by
+// sqlcommenter, see https://google.github.io/sqlcommenter/spec/ and https://www.w3.org/TR/trace-context/#traceparent-header.
+// Trace flags are optional, because application_name is truncated to 63 characters.
+var reTraceparent = regexp.MustCompile(`\b[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}\b`)
+
 // postgresStatementsCollector ...
 type postgresStatementsCollector struct {
-	query         typedDesc
 	calls         typedDesc
 	rows          typedDesc
 	times         typedDesc
@@ -193,126 +134,243 @@ type postgresStatementsCollector struct {
 	tempWritten   typedDesc
 	walRecords    typedDesc
 	walBuffers    typedDesc
+	topCalls      typedDesc
+	parallel      typedDesc
 	walAllBytes   typedDesc
 	walBytes      typedDesc
+	dbCalls       typedDesc
+	dbPlans       typedDesc
+	dbPlansRatio  typedDesc
+	rollupCalls   typedDesc
+	rollupTime    typedDesc
+	rollupWal     typedDesc
+	rollupTemp    typedDesc
+	entries       typedDesc
+	maxEntries    typedDesc
+	dealloc       typedDesc
+	retention     typedDesc
+	// planID defines statements are labeled with identifier of the query plan.
+	planID bool
+	// snapshot keeps top statements collected during the last update.
+	snapshot   TopQueriesSnapshot
+	snapshotMu sync.RWMutex
+}
+
+// defaultTopQueriesSnapshotSize defines number of statements kept in top queries snapshot when top-k is not enabled.
+const defaultTopQueriesSnapshotSize = 20
+
+// TopQuery defines statement with the most total time spent by it.
+type TopQuery struct {
+	Database         string  `json:"database"`
+	User             string  `json:"user"`
+	QueryID          string  `json:"queryid"`
+	Query            string  `json:"query"`
+	Calls            float64 `json:"calls"`
+	TotalTimeSeconds float64 `json:"total_time_seconds"`
+	MeanTimeSeconds  float64 `json:"mean_time_seconds"`
+}
+
+// TopQueriesSnapshot defines top statements collected by statements collector during the last scrape.
+type TopQueriesSnapshot struct {
+	Updated time.Time  `json:"updated"`
+	Queries []TopQuery `json:"queries"`
 }
 
 // NewPostgresStatementsCollector returns a new Collector exposing postgres statements stats.
 // For details see https://www.postgresql.org/docs/current/pgstatstatements.html
 func NewPostgresStatementsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	// Plan identifier label is added only when requested, so label sets of existing series are not changed.
+	statementLabels := []string{"user", "database", "queryid"}
+	if settings.PlanID {
+		statementLabels = append(statementLabels, "planid")
+	}
+
 	return &postgresStatementsCollector{
-		query: newBuiltinTypedDesc(
-			descOpts{"postgres", "statements", "query_info", "Labeled info about statements has been executed.", 0},
-			prometheus.GaugeValue,
-			[]string{"user", "database", "queryid", "query"}, constLabels,
-			settings.Filters,
-		),
+		planID: settings.PlanID,
 		calls: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "calls_total", "Total number of times statement has been executed.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		rows: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "rows_total", "Total number of rows retrieved or affected by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		times: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "time_seconds_total", "Time spent by the statement in each mode, in seconds.", .001},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid", "mode"}, constLabels,
+			append(slices.Clip(statementLabels), "mode"), constLabels,
 			settings.Filters,
 		),
 		allTimes: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "time_seconds_all_total", "Total time spent by the statement, in seconds.", .001},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		sharedHit: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_hit_total", "Total number of blocks have been found in shared buffers by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		sharedRead: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_read_bytes_total", "Total number of bytes read from disk or OS page cache by the statement when block not found in shared buffers.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		sharedDirtied: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_dirtied_total", "Total number of blocks have been dirtied in shared buffers by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		sharedWritten: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "shared_buffers_written_bytes_total", "Total number of bytes written from shared buffers to disk by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		localHit: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_hit_total", "Total number of blocks have been found in local buffers by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		localRead: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_read_bytes_total", "Total number of bytes read from disk or OS page cache by the statement when block not found in local buffers.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		localDirtied: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_dirtied_total", "Total number of blocks have been dirtied in local buffers by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		localWritten: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "local_buffers_written_bytes_total", "Total number of bytes written from local buffers to disk by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		tempRead: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "temp_read_bytes_total", "Total number of bytes read from temporary files by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		tempWritten: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "temp_written_bytes_total", "Total number of bytes written to temporary files by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		walRecords: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "wal_records_total", "Total number of WAL records generated by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		walBuffers: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "wal_buffers_full", "Total number of times the WAL buffers became full generated by the statement.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
+			settings.Filters,
+		),
+		topCalls: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "toplevel_calls_total", "Total number of times statement has been executed as top-level statement, calls of nested statements are not included.", 0},
+			prometheus.CounterValue,
+			statementLabels, constLabels,
+			settings.Filters,
+		),
+		parallel: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "parallel_workers_total", "Total number of parallel workers planned to be launched and actually launched by the statement.", 0},
+			prometheus.CounterValue,
+			append(slices.Clip(statementLabels), "workers"), constLabels,
 			settings.Filters,
 		),
 		walAllBytes: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "wal_bytes_all_total", "Total number of WAL generated by the statement, in bytes.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid"}, constLabels,
+			statementLabels, constLabels,
 			settings.Filters,
 		),
 		walBytes: newBuiltinTypedDesc(
 			descOpts{"postgres", "statements", "wal_bytes_total", "Total number of WAL bytes generated by the statement, by type.", 0},
 			prometheus.CounterValue,
-			[]string{"user", "database", "queryid", "wal"}, constLabels,
+			append(slices.Clip(statementLabels), "wal"), constLabels,
+			settings.Filters,
+		),
+		dbCalls: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "database_calls_total", "Total number of times statements have been executed in the database, by top-level flag.", 0},
+			prometheus.CounterValue,
+			[]string{"database", "toplevel"}, constLabels,
+			settings.Filters,
+		),
+		dbPlans: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "database_plans_total", "Total number of times statements have been planned in the database, by top-level flag.", 0},
+			prometheus.CounterValue,
+			[]string{"database", "toplevel"}, constLabels,
+			settings.Filters,
+		),
+		dbPlansRatio: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "database_plans_calls_ratio", "Ratio of statements plans to calls in the database, values close to 1 indicate prepared statements are not reused.", 0},
+			prometheus.GaugeValue,
+			[]string{"database"}, constLabels,
+			settings.Filters,
+		),
+		rollupCalls: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "rollup_calls_total", "Total number of times all statements have been executed, by database and user.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database"}, constLabels,
+			settings.Filters,
+		),
+		rollupTime: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "rollup_exec_time_seconds_total", "Total time spent executing all statements, by database and user, in seconds.", .001},
+			prometheus.CounterValue,
+			[]string{"user", "database"}, constLabels,
+			settings.Filters,
+		),
+		rollupWal: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "rollup_wal_bytes_total", "Total number of WAL bytes generated by all statements, by database and user.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database"}, constLabels,
+			settings.Filters,
+		),
+		rollupTemp: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "rollup_temp_bytes_total", "Total number of bytes read from and written to temporary files by all statements, by database and user.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database"}, constLabels,
+			settings.Filters,
+		),
+		entries: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "entries", "Number of statements tracked by pg_stat_statements.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		maxEntries: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "max_entries", "Max number of statements tracked by pg_stat_statements.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		dealloc: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "dealloc_total", "Total number of times least-executed statements have been deallocated because more distinct statements than max were observed.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		retention: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "retention_seconds", "Estimated time statements are kept before eviction, averaged since stats reset, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
 			settings.Filters,
 		),
 	}, nil
@@ -320,7 +378,6 @@ func NewPostgresStatementsCollector(constLabels labels, settings model.Collector
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	var err error
 	// nothing to do, pg_stat_statements not found in shared_preload_libraries
 	if !config.pgStatStatements {
 		return nil
@@ -328,112 +385,193 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 
 	// pg_stat_statements could be installed in any database. The database with
 	// installed pg_stat_statements is discovered during initial config and stored
-	// in configuration. Create the new connection config using default connection
-	// string, but replace database with installed pg_stat_statements.
+	// in configuration. Acquire connection to the database with installed pg_stat_statements.
 
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	conn, err := config.acquireDatabaseConn(config.pgStatStatementsDatabase)
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
+
+	// Query texts are not read when they are cached by statements_query collector.
+	texts := config.statementsTexts
+	noText := config.NoTrackMode || texts != nil
 
-	pgconfig.Database = config.pgStatStatementsDatabase
+	// get pg_stat_statements stats
+	query := selectStatementsQuery(config.pgVersion.Numeric, config.pgStatStatementsSchema, noText)
+	if c.planID {
+		query = withStatementsPlanID(query, queryStatementsPlanIDColumn(conn, config.pgStatStatementsSchema))
+	}
 
-	conn, err := store.NewWithConfig(pgconfig)
+	res, err := conn.Query(query)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
 
-	var res *model.PGResult
-	// get pg_stat_statements stats
+	// parse pg_stat_statements stats
+	stats := parsePostgresStatementsStats(res, []string{"user", "database", "queryid", "planid", "query"})
+
+	// Rollups, per-database aggregates and number of entries are computed from the same snapshot of pg_stat_statements
+	// before top-k is applied, so pg_stat_statements is scanned only once per scrape.
+	rollups := statementsRollups(stats)
+	databases := statementsDatabaseStats(stats)
+	entries := float64(len(res.Rows))
+
+	// Cached texts are used for top queries snapshot.
+	if texts != nil && !config.NoTrackMode {
+		for key, stat := range stats {
+			if query, ok := texts.lookup(statementKey(stat)); ok {
+				stat.query = query
+				stats[key] = stat
+			}
+		}
+	}
+
+	// Top-k is computed on pgSCV side, ranking using window functions is expensive on busy instances with many statements.
 	if config.CollectTopQuery > 0 {
-		res, err = conn.Query(selectStatementsQuery(config.pgVersion.Numeric, config.pgStatStatementsSchema, config.NoTrackMode, config.CollectTopQuery), config.CollectTopQuery)
-	} else {
-		res, err = conn.Query(selectStatementsQuery(config.pgVersion.Numeric, config.pgStatStatementsSchema, config.NoTrackMode, config.CollectTopQuery))
+		stats = selectTopStatements(stats, config.CollectTopQuery)
 	}
-	if err != nil {
-		return err
+
+	if texts != nil {
+		texts.setTop(stats, config.CollectTopQuery > 0)
 	}
 
-	// parse pg_stat_statements stats
-	stats := parsePostgresStatementsStats(res, []string{"user", "database", "queryid", "query"})
+	c.updateSnapshot(stats, config.CollectTopQuery, config.NoTrackMode)
 
 	blockSize := float64(config.blockSize)
 
-	for _, stat := range stats {
-		var query string
-		if config.NoTrackMode {
-			query = "/* query text hidden, no-track mode enabled */"
-		} else {
-			query = stat.query
-		}
+	var traces map[string]string
+	if config.pgVersion.Numeric >= PostgresV14 {
+		traces = queryStatementsTraces(conn)
+	}
 
+	for _, stat := range stats {
 		// Note: pg_stat_statements.total_exec_time (and .total_time) includes blk_read_time and blk_write_time implicitly.
 		// Remember that when creating metrics.
 
-		ch <- c.query.newConstMetric(1, stat.user, stat.database, stat.queryid, query)
+		// Exemplars allow to jump from latency panels directly to the offending query.
+		exemplar := statementExemplarLabels(stat, traces)
 
-		ch <- c.calls.newConstMetric(stat.calls, stat.user, stat.database, stat.queryid)
-		ch <- c.rows.newConstMetric(stat.rows, stat.user, stat.database, stat.queryid)
+		values := []string{stat.user, stat.database, stat.queryid}
+		if c.planID {
+			values = append(values, stat.planid)
+		}
+
+		ch <- c.calls.newConstMetricWithExemplar(stat.calls, exemplar, values...)
+		ch <- c.rows.newConstMetric(stat.rows, values...)
+
+		// Top-level flag is available since Postgres 14, nested calls are the difference between all and top-level calls.
+		if config.pgVersion.Numeric >= PostgresV14 {
+			ch <- c.topCalls.newConstMetric(stat.toplevelCalls, values...)
+		}
+
+		// Parallel workers are available since Postgres 18, launched workers less than planned means shortage of workers.
+		if stat.parallelPlanned > 0 {
+			ch <- c.parallel.newConstMetric(stat.parallelPlanned, append(slices.Clip(values), "planned")...)
+			ch <- c.parallel.newConstMetric(stat.parallelLaunched, append(slices.Clip(values), "launched")...)
+		}
 
 		// total = planning + execution; execution already includes io time.
-		ch <- c.allTimes.newConstMetric(stat.totalPlanTime+stat.totalExecTime, stat.user, stat.database, stat.queryid)
-		ch <- c.times.newConstMetric(stat.totalPlanTime, stat.user, stat.database, stat.queryid, "planning")
+		ch <- c.allTimes.newConstMetricWithExemplar(stat.totalPlanTime+stat.totalExecTime, exemplar, values...)
+		ch <- c.times.newConstMetric(stat.totalPlanTime, append(slices.Clip(values), "planning")...)
 
 		// execution time = execution - io times.
-		ch <- c.times.newConstMetric(stat.totalExecTime-(stat.blkReadTime+stat.blkWriteTime), stat.user, stat.database, stat.queryid, "executing")
+		ch <- c.times.newConstMetricWithExemplar(stat.totalExecTime-(stat.blkReadTime+stat.blkWriteTime), exemplar, append(slices.Clip(values), "executing")...)
 
 		// avoid metrics spamming and send metrics only if they greater than zero.
 		if stat.blkReadTime > 0 {
-			ch <- c.times.newConstMetric(stat.blkReadTime, stat.user, stat.database, stat.queryid, "ioread")
+			ch <- c.times.newConstMetric(stat.blkReadTime, append(slices.Clip(values), "ioread")...)
 		}
 		if stat.blkWriteTime > 0 {
-			ch <- c.times.newConstMetric(stat.blkWriteTime, stat.user, stat.database, stat.queryid, "iowrite")
+			ch <- c.times.newConstMetric(stat.blkWriteTime, append(slices.Clip(values), "iowrite")...)
 		}
 		if stat.sharedBlksHit > 0 {
-			ch <- c.sharedHit.newConstMetric(stat.sharedBlksHit, stat.user, stat.database, stat.queryid)
+			ch <- c.sharedHit.newConstMetric(stat.sharedBlksHit, values...)
 		}
 		if stat.sharedBlksRead > 0 {
-			ch <- c.sharedRead.newConstMetric(stat.sharedBlksRead*blockSize, stat.user, stat.database, stat.queryid)
+			ch <- c.sharedRead.newConstMetric(stat.sharedBlksRead*blockSize, values...)
 		}
 		if stat.sharedBlksDirtied > 0 {
-			ch <- c.sharedDirtied.newConstMetric(stat.sharedBlksDirtied, stat.user, stat.database, stat.queryid)
+			ch <- c.sharedDirtied.newConstMetric(stat.sharedBlksDirtied, values...)
 		}
 		if stat.sharedBlksWritten > 0 {
-			ch <- c.sharedWritten.newConstMetric(stat.sharedBlksWritten*blockSize, stat.user, stat.database, stat.queryid)
+			ch <- c.sharedWritten.newConstMetric(stat.sharedBlksWritten*blockSize, values...)
 		}
 		if stat.localBlksHit > 0 {
-			ch <- c.localHit.newConstMetric(stat.localBlksHit, stat.user, stat.database, stat.queryid)
+			ch <- c.localHit.newConstMetric(stat.localBlksHit, values...)
 		}
 		if stat.localBlksRead > 0 {
-			ch <- c.localRead.newConstMetric(stat.localBlksRead*blockSize, stat.user, stat.database, stat.queryid)
+			ch <- c.localRead.newConstMetric(stat.localBlksRead*blockSize, values...)
 		}
 		if stat.localBlksDirtied > 0 {
-			ch <- c.localDirtied.newConstMetric(stat.localBlksDirtied, stat.user, stat.database, stat.queryid)
+			ch <- c.localDirtied.newConstMetric(stat.localBlksDirtied, values...)
 		}
 		if stat.localBlksWritten > 0 {
-			ch <- c.localWritten.newConstMetric(stat.localBlksWritten*blockSize, stat.user, stat.database, stat.queryid)
+			ch <- c.localWritten.newConstMetric(stat.localBlksWritten*blockSize, values...)
 		}
 		if stat.tempBlksRead > 0 {
-			ch <- c.tempRead.newConstMetric(stat.tempBlksRead*blockSize, stat.user, stat.database, stat.queryid)
+			ch <- c.tempRead.newConstMetric(stat.tempBlksRead*blockSize, values...)
 		}
 		if stat.tempBlksWritten > 0 {
-			ch <- c.tempWritten.newConstMetric(stat.tempBlksWritten*blockSize, stat.user, stat.database, stat.queryid)
+			ch <- c.tempWritten.newConstMetric(stat.tempBlksWritten*blockSize, values...)
 		}
 		if stat.walRecords > 0 {
 			// WAL records
-			ch <- c.walRecords.newConstMetric(stat.walRecords, stat.user, stat.database, stat.queryid)
+			ch <- c.walRecords.newConstMetric(stat.walRecords, values...)
 
 			// WAL total bytes
-			ch <- c.walAllBytes.newConstMetric((stat.walFPI*blockSize)+stat.walBytes, stat.user, stat.database, stat.queryid)
+			ch <- c.walAllBytes.newConstMetricWithExemplar((stat.walFPI*blockSize)+stat.walBytes, exemplar, values...)
 
 			// WAL bytes by type (regular of fpi)
-			ch <- c.walBytes.newConstMetric(stat.walFPI*blockSize, stat.user, stat.database, stat.queryid, "fpi")
-			ch <- c.walBytes.newConstMetric(stat.walBytes, stat.user, stat.database, stat.queryid, "regular")
+			ch <- c.walBytes.newConstMetric(stat.walFPI*blockSize, append(slices.Clip(values), "fpi")...)
+			ch <- c.walBytes.newConstMetric(stat.walBytes, append(slices.Clip(values), "regular")...)
 
 			if config.pgVersion.Numeric >= PostgresV18 {
 				// WAL buffers
-				ch <- c.walBuffers.newConstMetric(stat.walBuffers, stat.user, stat.database, stat.queryid)
+				ch <- c.walBuffers.newConstMetric(stat.walBuffers, values...)
+			}
+		}
+	}
+
+	// Per-database/user rollups are collected regardless of top-k limit.
+	for _, stat := range rollups {
+		ch <- c.rollupCalls.newConstMetric(stat.calls, stat.user, stat.database)
+		ch <- c.rollupTime.newConstMetric(stat.execTime, stat.user, stat.database)
+		if config.pgVersion.Numeric >= PostgresV13 {
+			ch <- c.rollupWal.newConstMetric(stat.walBytes, stat.user, stat.database)
+		}
+		ch <- c.rollupTemp.newConstMetric(stat.tempBlks*blockSize, stat.user, stat.database)
+	}
+
+	// Entries utilization and eviction churn.
+	ch <- c.entries.newConstMetric(entries)
+	res, err = conn.Query(selectStatementsEntriesQuery(config.pgVersion.Numeric, config.pgStatStatementsSchema))
+	if err != nil {
+		log.Warnf("get statements entries failed: %s; skip", err)
+	} else {
+		stats := parsePostgresStatementsEntriesStats(res)
+		ch <- c.maxEntries.newConstMetric(stats["max_entries"])
+		if dealloc, ok := stats["dealloc"]; ok {
+			ch <- c.dealloc.newConstMetric(dealloc)
+			if retention := statementsRetentionSeconds(stats["max_entries"], dealloc, stats["stats_age_seconds"]); retention > 0 {
+				ch <- c.retention.newConstMetric(retention)
+			}
+		}
+	}
+
+	// Per-database aggregates are collected regardless of top-k limit, top-level flag is available since Postgres 14.
+	if config.pgVersion.Numeric >= PostgresV14 {
+		calls, plans := map[string]float64{}, map[string]float64{}
+		for _, stat := range databases {
+			ch <- c.dbCalls.newConstMetric(stat.calls, stat.database, stat.toplevel)
+			ch <- c.dbPlans.newConstMetric(stat.plans, stat.database, stat.toplevel)
+			calls[stat.database] += stat.calls
+			plans[stat.database] += stat.plans
+		}
+
+		for database, v := range calls {
+			if v > 0 {
+				ch <- c.dbPlansRatio.newConstMetric(plans[database]/v, database)
 			}
 		}
 	}
@@ -441,11 +579,251 @@ func (c *postgresStatementsCollector) Update(config Config, ch chan<- prometheus
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatementsCollector) executedQueries(config Config) []string {
+	if !config.pgStatStatements {
+		return nil
+	}
+
+	schema := config.pgStatStatementsSchema
+	query := selectStatementsQuery(config.pgVersion.Numeric, schema, config.NoTrackMode || config.statementsTexts != nil)
+
+	var queries []string
+	if c.planID {
+		queries = append(queries, fmt.Sprintf(postgresStatementsPlanIDColumnQuery, schema))
+		query = withStatementsPlanID(query, "p.planid::text")
+	}
+	queries = append(queries, query, selectStatementsEntriesQuery(config.pgVersion.Numeric, schema))
+	if config.pgVersion.Numeric >= PostgresV14 {
+		queries = append(queries, postgresStatementsTracesQuery)
+	}
+
+	return queries
+}
+
+// updateSnapshot keeps statements with the most total time as top queries snapshot. Statements aggregated beyond
+// top-k are not included.
+func (c *postgresStatementsCollector) updateSnapshot(stats map[string]postgresStatementStat, topK int, noTrackMode bool) {
+	if topK <= 0 {
+		topK = defaultTopQueriesSnapshotSize
+	}
+
+	queries := make([]TopQuery, 0, len(stats))
+	for _, stat := range stats {
+		if stat.queryid == "" {
+			continue
+		}
+
+		q := TopQuery{
+			Database:         stat.database,
+			User:             stat.user,
+			QueryID:          stat.queryid,
+			Query:            stat.query,
+			Calls:            stat.calls,
+			TotalTimeSeconds: (stat.totalPlanTime + stat.totalExecTime) * .001,
+		}
+		if noTrackMode {
+			q.Query = statementsQueryHidden
+		}
+		if q.Calls > 0 {
+			q.MeanTimeSeconds = q.TotalTimeSeconds / q.Calls
+		}
+
+		queries = append(queries, q)
+	}
+
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].TotalTimeSeconds != queries[j].TotalTimeSeconds {
+			return queries[i].TotalTimeSeconds > queries[j].TotalTimeSeconds
+		}
+		return queries[i].QueryID < queries[j].QueryID
+	})
+
+	c.snapshotMu.Lock()
+	c.snapshot = TopQueriesSnapshot{Updated: time.Now(), Queries: queries[:min(topK, len(queries))]}
+	c.snapshotMu.Unlock()
+}
+
+// topQueries returns top queries snapshot collected during the last update.
+func (c *postgresStatementsCollector) topQueries() TopQueriesSnapshot {
+	c.snapshotMu.RLock()
+	defer c.snapshotMu.RUnlock()
+	return c.snapshot
+}
+
+// postgresStatementsDatabaseStat represents per-database aggregated statements stats.
+type postgresStatementsDatabaseStat struct {
+	database string
+	toplevel string
+	calls    float64
+	plans    float64
+}
+
+// statementsDatabaseStats returns per-database aggregates of statements calls and plans broken down by top-level flag.
+// Nested statements are accounted only when they have been called or planned.
+func statementsDatabaseStats(stats map[string]postgresStatementStat) []postgresStatementsDatabaseStat {
+	toplevel, nested := map[string]postgresStatementsDatabaseStat{}, map[string]postgresStatementsDatabaseStat{}
+
+	for _, s := range stats {
+		t := toplevel[s.database]
+		t.calls += s.toplevelCalls
+		t.plans += s.toplevelPlans
+		toplevel[s.database] = t
+
+		n := nested[s.database]
+		n.calls += s.calls - s.toplevelCalls
+		n.plans += s.plans - s.toplevelPlans
+		nested[s.database] = n
+	}
+
+	var result []postgresStatementsDatabaseStat
+	for _, database := range slices.Sorted(maps.Keys(toplevel)) {
+		t := toplevel[database]
+		result = append(result, postgresStatementsDatabaseStat{database: database, toplevel: "true", calls: t.calls, plans: t.plans})
+
+		if n := nested[database]; n.calls > 0 || n.plans > 0 {
+			result = append(result, postgresStatementsDatabaseStat{database: database, toplevel: "false", calls: n.calls, plans: n.plans})
+		}
+	}
+
+	return result
+}
+
+// postgresStatementsRollupStat represents per-database/user rollup of statements stats.
+type postgresStatementsRollupStat struct {
+	database string
+	user     string
+	calls    float64
+	execTime float64
+	walBytes float64
+	tempBlks float64
+}
+
+// statementsRollups returns per-database/user rollups of statements stats.
+func statementsRollups(stats map[string]postgresStatementStat) []postgresStatementsRollupStat {
+	rollups := map[[2]string]postgresStatementsRollupStat{}
+
+	for _, s := range stats {
+		key := [2]string{s.database, s.user}
+		r := rollups[key]
+		r.database, r.user = s.database, s.user
+		r.calls += s.calls
+		r.execTime += s.totalExecTime
+		r.walBytes += s.walBytes
+		r.tempBlks += s.tempBlksRead + s.tempBlksWritten
+		rollups[key] = r
+	}
+
+	result := slices.Collect(maps.Values(rollups))
+	slices.SortFunc(result, func(a, b postgresStatementsRollupStat) int {
+		return cmp.Or(strings.Compare(a.database, b.database), strings.Compare(a.user, b.user))
+	})
+
+	return result
+}
+
+// selectStatementsEntriesQuery returns suitable statements entries query depending on passed version.
+func selectStatementsEntriesQuery(version int, schema string) string {
+	if version < PostgresV14 {
+		return postgresStatementsEntriesQuery13
+	}
+	return fmt.Sprintf(postgresStatementsEntriesQueryLatest, schema)
+}
+
+// parsePostgresStatementsEntriesStats parses PGResult and returns statements entries stats, NULL values are skipped.
+func parsePostgresStatementsEntriesStats(r *model.PGResult) map[string]float64 {
+	log.Debug("parse postgres statements entries stats")
+
+	stats := map[string]float64{}
+
+	for _, row := range r.Rows {
+		for i, colname := range r.Colnames {
+			// Skip empty (NULL) values.
+			if !row[i].Valid {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(row[i].String, 64)
+			if err != nil {
+				log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+				continue
+			}
+
+			stats[string(colname.Name)] = v
+		}
+	}
+
+	return stats
+}
+
+// statementsRetentionSeconds estimates how long statements are kept before eviction, based on number of deallocations
+// since stats reset. Each deallocation evicts a fixed share of max entries, hence the whole set of entries is replaced
+// after max / evicted-per-second seconds. Zero is returned when statements have not been evicted.
+func statementsRetentionSeconds(maxEntries, dealloc, age float64) float64 {
+	if maxEntries <= 0 || dealloc <= 0 || age <= 0 {
+		return 0
+	}
+
+	evicted := dealloc * max(statementsDeallocMin, maxEntries*statementsDeallocPercent/100)
+	return age * maxEntries / evicted
+}
+
+// statementExemplarLabels returns exemplar labels for passed statement. Exemplar contains queryid and trace_id (when
+// the statement is running by client passed traceparent in application_name). Aggregated statements have no queryid
+// and no exemplars.
+func statementExemplarLabels(stat postgresStatementStat, traces map[string]string) prometheus.Labels {
+	if stat.queryid == "" {
+		return nil
+	}
+
+	exemplar := prometheus.Labels{"queryid": stat.queryid}
+
+	if traceID, ok := traces[statementKey(stat)]; ok {
+		exemplar["trace_id"] = traceID
+	}
+
+	return exemplar
+}
+
+// queryStatementsTraces returns trace ids of running statements by database/user/queryid. Trace ids are taken from
+// application_name, query texts are never parsed because they are controlled by users. Traces are optional, hence
+// errors are logged and ignored.
+func queryStatementsTraces(conn *store.DB) map[string]string {
+	res, err := conn.Query(postgresStatementsTracesQuery)
+	if err != nil {
+		log.Warnf("get trace context of statements failed: %s; skip", err)
+		return nil
+	}
+
+	return parseStatementsTraces(res)
+}
+
+// parseStatementsTraces parses PGResult and returns trace ids of statements by database/user/queryid.
+func parseStatementsTraces(r *model.PGResult) map[string]string {
+	traces := map[string]string{}
+
+	for _, row := range r.Rows {
+		if len(row) != 4 {
+			continue
+		}
+
+		parts := reTraceparent.FindStringSubmatch(row[3].String)
+		if len(parts) != 2 {
+			continue
+		}
+
+		traces[strings.Join([]string{row[0].String, row[1].String, row[2].String}, "/")] = parts[1]
+	}
+
+	return traces
+}
+
 // postgresStatementStat represents stats values for single statement based on pg_stat_statements.
 type postgresStatementStat struct {
 	database          string
 	user              string
 	queryid           string
+	planid            string
 	query             string
 	calls             float64
 	rows              float64
@@ -467,6 +845,11 @@ type postgresStatementStat struct {
 	walFPI            float64
 	walBytes          float64
 	walBuffers        float64
+	toplevelCalls     float64
+	plans             float64
+	toplevelPlans     float64
+	parallelPlanned   float64
+	parallelLaunched  float64
 }
 
 // parsePostgresStatementsStats parses PGResult and return structs with stats values.
@@ -478,7 +861,7 @@ func parsePostgresStatementsStats(r *model.PGResult, labelNames []string) map[st
 	// process row by row - on every row construct 'statement' using database/user/queryHash trio. Next process other row's
 	// fields and collect stats for constructed 'statement'.
 	for _, row := range r.Rows {
-		var database, user, queryid, query string
+		var database, user, queryid, planid, query string
 
 		// collect label values
 		for i, colname := range r.Colnames {
@@ -489,17 +872,23 @@ func parsePostgresStatementsStats(r *model.PGResult, labelNames []string) map[st
 				user = row[i].String
 			case "queryid":
 				queryid = row[i].String
+			case "planid":
+				planid = row[i].String
 			case "query":
 				query = row[i].String
 			}
 		}
 
-		// Create a statement name consisting of trio database/user/queryHash
+		// Create a statement name consisting of trio database/user/queryHash, statements with different plans are
+		// distinguished by plan identifier.
 		statement := strings.Join([]string{database, user, queryid}, "/")
+		if planid != "" {
+			statement += "/" + planid
+		}
 
 		// Put stats with labels (but with no data values yet) into stats store.
 		if _, ok := stats[statement]; !ok {
-			stats[statement] = postgresStatementStat{database: database, user: user, queryid: queryid, query: query}
+			stats[statement] = postgresStatementStat{database: database, user: user, queryid: queryid, planid: planid, query: query}
 		}
 
 		// fetch data values from columns
@@ -565,6 +954,16 @@ func parsePostgresStatementsStats(r *model.PGResult, labelNames []string) map[st
 				s.walBytes += v
 			case "wal_buffers_full":
 				s.walBuffers += v
+			case "toplevel_calls":
+				s.toplevelCalls += v
+			case "plans":
+				s.plans += v
+			case "toplevel_plans":
+				s.toplevelPlans += v
+			case "parallel_workers_to_launch":
+				s.parallelPlanned += v
+			case "parallel_workers_launched":
+				s.parallelLaunched += v
 			default:
 				continue
 			}
@@ -576,32 +975,142 @@ func parsePostgresStatementsStats(r *model.PGResult, labelNames []string) map[st
 	return stats
 }
 
-// selectStatementsQuery returns suitable statements query depending on passed version.
-func selectStatementsQuery(version int, schema string, notrackmode bool, topK int) string {
-	var queryColumm string
-	if notrackmode {
-		queryColumm = "null"
+// statementKey returns key of the statement consisting of database/user/queryid trio, plan of the statement is not
+// accounted.
+func statementKey(stat postgresStatementStat) string {
+	return strings.Join([]string{stat.database, stat.user, stat.queryid}, "/")
+}
+
+// queryStatementsPlanIDColumn returns expression selecting plan identifier from pg_stat_statements. When installed
+// version of pg_stat_statements doesn't provide plan identifiers, NULL is returned and statements are labeled with
+// empty plan identifier.
+func queryStatementsPlanIDColumn(conn *store.DB, schema string) string {
+	var column string
+	err := conn.QueryRow(fmt.Sprintf(postgresStatementsPlanIDColumnQuery, schema)).Scan(&column)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Warnf("lookup plan identifier in pg_stat_statements failed: %s; skip", err)
+		}
+		return "NULL"
+	}
+
+	return "p." + column + "::text"
+}
+
+// withStatementsPlanID returns statements query which selects plan identifier using passed expression.
+func withStatementsPlanID(query string, column string) string {
+	return strings.Replace(query, "p.queryid, ", "p.queryid, "+column+" AS planid, ", 1)
+}
+
+// selectStatementsQuery returns suitable statements query depending on passed version. When query texts are not
+// required, they are not read from pg_stat_statements at all.
+func selectStatementsQuery(version int, schema string, noText bool) string {
+	var queryColumm, source string
+	if noText {
+		queryColumm, source = "null", schema+".pg_stat_statements(false)"
 	} else {
-		queryColumm = "p.query"
+		queryColumm, source = "p.query", schema+".pg_stat_statements"
 	}
 	if version < PostgresV13 {
-		if topK > 0 {
-			return fmt.Sprintf(postgresStatementsQuery12TopK, queryColumm, schema)
-		}
-		return fmt.Sprintf(postgresStatementsQuery12, queryColumm, schema)
-	} else if version > PostgresV12 && version < PostgresV17 {
-		if topK > 0 {
-			return fmt.Sprintf(postgresStatementsQuery16TopK, queryColumm, schema)
-		}
-		return fmt.Sprintf(postgresStatementsQuery16, queryColumm, schema)
+		return fmt.Sprintf(postgresStatementsQuery12, queryColumm, source)
+	} else if version < PostgresV14 {
+		return fmt.Sprintf(postgresStatementsQuery13, queryColumm, source)
+	} else if version > PostgresV13 && version < PostgresV17 {
+		return fmt.Sprintf(postgresStatementsQuery16, queryColumm, source)
 	} else if version > PostgresV16 && version < PostgresV18 {
-		if topK > 0 {
-			return fmt.Sprintf(postgresStatementsQuery17TopK, queryColumm, schema)
+		return fmt.Sprintf(postgresStatementsQuery17, queryColumm, source)
+	}
+	return fmt.Sprintf(postgresStatementsQueryLatest, queryColumm, source)
+}
+
+// statementRankValues defines stats used for ranking statements when top-k is enabled.
+var statementRankValues = []func(s postgresStatementStat) float64{
+	func(s postgresStatementStat) float64 { return s.calls },
+	func(s postgresStatementStat) float64 { return s.rows },
+	func(s postgresStatementStat) float64 { return s.totalExecTime },
+	func(s postgresStatementStat) float64 { return s.totalPlanTime },
+	func(s postgresStatementStat) float64 { return s.blkReadTime },
+	func(s postgresStatementStat) float64 { return s.blkWriteTime },
+	func(s postgresStatementStat) float64 { return s.sharedBlksHit },
+	func(s postgresStatementStat) float64 { return s.sharedBlksRead },
+	func(s postgresStatementStat) float64 { return s.sharedBlksDirtied },
+	func(s postgresStatementStat) float64 { return s.sharedBlksWritten },
+	func(s postgresStatementStat) float64 { return s.localBlksHit },
+	func(s postgresStatementStat) float64 { return s.localBlksRead },
+	func(s postgresStatementStat) float64 { return s.localBlksDirtied },
+	func(s postgresStatementStat) float64 { return s.localBlksWritten },
+	func(s postgresStatementStat) float64 { return s.tempBlksRead },
+	func(s postgresStatementStat) float64 { return s.tempBlksWritten },
+	func(s postgresStatementStat) float64 { return s.walRecords },
+	func(s postgresStatementStat) float64 { return s.walFPI },
+	func(s postgresStatementStat) float64 { return s.walBytes },
+	func(s postgresStatementStat) float64 { return s.walBuffers },
+}
+
+// selectTopStatements returns statements which are in top-k by any of ranked stats. Stats of other statements are
+// summed into per-database statements with 'all_users' user and 'all_queries' query.
+func selectTopStatements(stats map[string]postgresStatementStat, topK int) map[string]postgresStatementStat {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+
+	visible := make(map[string]bool, len(stats))
+	for _, value := range statementRankValues {
+		// Sort by key on equal values, so selected statements are the same across scrapes.
+		sort.Slice(keys, func(i, j int) bool {
+			vi, vj := value(stats[keys[i]]), value(stats[keys[j]])
+			if vi != vj {
+				return vi > vj
+			}
+			return keys[i] < keys[j]
+		})
+
+		for _, k := range keys[:min(topK, len(keys))] {
+			if value(stats[k]) > 0 {
+				visible[k] = true
+			}
 		}
-		return fmt.Sprintf(postgresStatementsQuery17, queryColumm, schema)
 	}
-	if topK > 0 {
-		return fmt.Sprintf(postgresStatementsQueryLatestTopK, queryColumm, schema)
+
+	top := make(map[string]postgresStatementStat, len(visible))
+	for k, s := range stats {
+		if visible[k] {
+			top[k] = s
+			continue
+		}
+
+		key := s.database + "/all_users/"
+		other, ok := top[key]
+		if !ok {
+			other = postgresStatementStat{database: s.database, user: "all_users", query: "all_queries"}
+		}
+
+		other.calls += s.calls
+		other.rows += s.rows
+		other.totalExecTime += s.totalExecTime
+		other.totalPlanTime += s.totalPlanTime
+		other.blkReadTime += s.blkReadTime
+		other.blkWriteTime += s.blkWriteTime
+		other.sharedBlksHit += s.sharedBlksHit
+		other.sharedBlksRead += s.sharedBlksRead
+		other.sharedBlksDirtied += s.sharedBlksDirtied
+		other.sharedBlksWritten += s.sharedBlksWritten
+		other.localBlksHit += s.localBlksHit
+		other.localBlksRead += s.localBlksRead
+		other.localBlksDirtied += s.localBlksDirtied
+		other.localBlksWritten += s.localBlksWritten
+		other.tempBlksRead += s.tempBlksRead
+		other.tempBlksWritten += s.tempBlksWritten
+		other.walRecords += s.walRecords
+		other.walFPI += s.walFPI
+		other.walBytes += s.walBytes
+		other.walBuffers += s.walBuffers
+		other.toplevelCalls += s.toplevelCalls
+		other.parallelPlanned += s.parallelPlanned
+		other.parallelLaunched += s.parallelLaunched
+		top[key] = other
 	}
-	return fmt.Sprintf(postgresStatementsQueryLatest, queryColumm, schema)
+
+	return top
 }
@@ -0,0 +1,321 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// postgresStatementsPlansTopQuery defines query for selecting top statements by total execution time.
+	postgresStatementsPlansTopQuery = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
+		"COALESCE(p.query, '') AS query FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid = p.dbid " +
+		"WHERE p.queryid IS NOT NULL ORDER BY p.%s DESC LIMIT %d"
+
+	// statementsPlansTop defines number of top statements which plans are explained.
+	statementsPlansTop = 3
+
+	// statementsPlansTimeout defines max time of planning a single statement.
+	statementsPlansTimeout = 5 * time.Second
+
+	// statementsPlansLockTimeout defines max time of waiting for locks of relations used by explained statement.
+	statementsPlansLockTimeout = time.Second
+)
+
+var (
+	// reExplainableStatement defines statements which could be explained, utility statements are never explained.
+	reExplainableStatement = regexp.MustCompile(`(?is)^\s*(SELECT|WITH|VALUES|TABLE|INSERT|UPDATE|DELETE|MERGE)\b`)
+	// reStatementParameter defines placeholders of normalized statements, e.g. $1.
+	reStatementParameter = regexp.MustCompile(`\$\d+`)
+)
+
+// statementPlan defines plan fingerprint of the statement.
+type statementPlan struct {
+	user        string
+	database    string
+	queryid     string
+	fingerprint string
+}
+
+// postgresStatementsPlansCollector defines metric descriptors and fingerprints of plans of top statements.
+type postgresStatementsPlansCollector struct {
+	mu         sync.Mutex
+	plan       typedDesc
+	changes    typedDesc
+	lastRun    typedDesc
+	explains   time.Time
+	plans      []statementPlan      // fingerprints of plans of the current top statements
+	previous   map[string]string    // the last fingerprints of explained statements, by database/user/queryid
+	counts     map[string]float64   // number of plan changes of explained statements, by database/user/queryid
+	statements map[string][3]string // user, database and queryid of statements with changed plans
+}
+
+// NewPostgresStatementsPlansCollector returns a new Collector exposing fingerprints of plans of the top statements by
+// total execution time. Plans are obtained using EXPLAIN (without ANALYZE) not more often than statements_explain_interval.
+// For details see https://www.postgresql.org/docs/current/sql-explain.html
+func NewPostgresStatementsPlansCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresStatementsPlansCollector{
+		previous:   map[string]string{},
+		counts:     map[string]float64{},
+		statements: map[string][3]string{},
+		plan: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "plan_info", "Labeled info about fingerprint of plan shape of the top statements.", 0},
+			prometheus.GaugeValue,
+			[]string{"user", "database", "queryid", "fingerprint"}, constLabels,
+			settings.Filters,
+		),
+		changes: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "plan_changes_total", "Total number of plan shape changes of the top statements noticed by pgSCV.", 0},
+			prometheus.CounterValue,
+			[]string{"user", "database", "queryid"}, constLabels,
+			settings.Filters,
+		),
+		lastRun: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "plan_last_explain_seconds", "Time when plans of the top statements have been explained, in unixtime.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresStatementsPlansCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	// Explaining is optional, query texts are not read in no-track mode.
+	if !config.pgStatStatements || config.StatementsExplainInterval <= 0 || config.NoTrackMode {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.explains.IsZero() || time.Since(c.explains) >= config.StatementsExplainInterval {
+		// Remember time before explaining, so failed attempts are not repeated until the next interval.
+		c.explains = time.Now()
+
+		plans, err := explainTopStatements(config)
+		if err != nil {
+			return err
+		}
+		c.update(plans)
+	}
+
+	for _, p := range c.plans {
+		ch <- c.plan.newConstMetric(1, p.user, p.database, p.queryid, p.fingerprint)
+	}
+	for key, value := range c.counts {
+		s := c.statements[key]
+		ch <- c.changes.newConstMetric(value, s[0], s[1], s[2])
+	}
+	if len(c.plans) > 0 {
+		ch <- c.lastRun.newConstMetric(float64(c.explains.Unix()))
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatementsPlansCollector) executedQueries(config Config) []string {
+	if !config.pgStatStatements || config.StatementsExplainInterval <= 0 || config.NoTrackMode {
+		return nil
+	}
+	// Explained statements are taken from pg_stat_statements, hence they are not known in advance.
+	return []string{selectStatementsPlansTopQuery(config.pgVersion.Numeric, config.pgStatStatementsSchema)}
+}
+
+// update replaces plans of top statements and accounts changes of plans of the statements explained before.
+// Statements which left the top are forgotten.
+func (c *postgresStatementsPlansCollector) update(plans []statementPlan) {
+	top := make(map[string]struct{}, len(plans))
+	for _, p := range plans {
+		key := p.database + "/" + p.user + "/" + p.queryid
+		top[key] = struct{}{}
+		if prev, ok := c.previous[key]; ok && prev != p.fingerprint {
+			c.counts[key]++
+			c.statements[key] = [3]string{p.user, p.database, p.queryid}
+		}
+		c.previous[key] = p.fingerprint
+	}
+
+	for key := range c.previous {
+		if _, ok := top[key]; !ok {
+			delete(c.previous, key)
+			delete(c.counts, key)
+			delete(c.statements, key)
+		}
+	}
+
+	c.plans = plans
+}
+
+// selectStatementsPlansTopQuery returns query for the top statements by total execution time depending on passed
+// version, from pg_stat_statements installed in passed schema.
+func selectStatementsPlansTopQuery(version int, schema string) string {
+	column := "total_exec_time"
+	if version < PostgresV13 {
+		column = "total_time"
+	}
+	return fmt.Sprintf(postgresStatementsPlansTopQuery, schema, column, statementsPlansTop)
+}
+
+// explainTopStatements explains the top statements by total execution time and returns fingerprints of their plans.
+func explainTopStatements(config Config) ([]statementPlan, error) {
+	conn, err := config.acquireDatabaseConn(config.pgStatStatementsDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := conn.Query(selectStatementsPlansTopQuery(config.pgVersion.Numeric, config.pgStatStatementsSchema))
+	conn.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Statements with parameters could be explained using generic plans only, available since Postgres 16.
+	generic := config.pgVersion.Numeric >= PostgresV16
+
+	var plans []statementPlan
+	for _, row := range res.Rows {
+		if len(row) != 4 {
+			return nil, fmt.Errorf("invalid input: wrong number of columns")
+		}
+
+		database, user, queryid, query := row[0].String, row[1].String, row[2].String, row[3].String
+		if !explainableStatement(query, generic) {
+			log.Debugf("[postgres statements plans collector]: statement %s is not explainable, skip", queryid)
+			continue
+		}
+
+		plan, err := explainStatement(config, database, user, query, generic)
+		if err != nil {
+			// Statements could refer to objects which are not accessible by pgSCV.
+			log.Warnf("[postgres statements plans collector]: explain statement %s in database %s failed: %s; skip", queryid, database, err)
+			continue
+		}
+
+		fingerprint, err := planFingerprint(plan)
+		if err != nil {
+			log.Warnf("[postgres statements plans collector]: parse plan of statement %s failed: %s; skip", queryid, err)
+			continue
+		}
+
+		plans = append(plans, statementPlan{user: user, database: database, queryid: queryid, fingerprint: fingerprint})
+	}
+
+	return plans, nil
+}
+
+// explainableStatement returns true if the statement could be explained. Utility statements, statements with several
+// commands and statements with parameters (when generic plans are not supported) are not explained.
+func explainableStatement(query string, generic bool) bool {
+	if !reExplainableStatement.MatchString(query) || strings.Contains(strings.TrimRight(query, "; \t\r\n"), ";") {
+		return false
+	}
+	return generic || !reStatementParameter.MatchString(query)
+}
+
+// explainStatement returns plan of the statement in JSON format. Statement is explained without ANALYZE within
+// read-only transaction with limited statement_timeout and lock_timeout, hence the statement is never executed.
+// Planning could evaluate functions (e.g. constant folding of immutable functions), hence statement is explained with
+// privileges of its own user, statements of users which role could not be set are not explained.
+func explainStatement(config Config, database, user, query string, generic bool) ([]byte, error) {
+	conn, err := config.acquireDatabaseConn(database)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), statementsPlansTimeout)
+	defer cancel()
+
+	tx, err := conn.Conn().BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	_, err = tx.Exec(ctx, "SET LOCAL statement_timeout = "+strconv.Itoa(int(statementsPlansTimeout.Milliseconds())))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, "SET LOCAL lock_timeout = "+strconv.Itoa(int(statementsPlansLockTimeout.Milliseconds())))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, "SET LOCAL ROLE "+pgx.Identifier{user}.Sanitize())
+	if err != nil {
+		return nil, fmt.Errorf("set role %s failed: %w", user, err)
+	}
+
+	options := "COSTS OFF, FORMAT JSON"
+	if generic {
+		options = "GENERIC_PLAN, " + options
+	}
+
+	var plan []byte
+	err = tx.QueryRow(ctx, "EXPLAIN ("+options+") "+strings.TrimRight(query, "; \t\r\n")).Scan(&plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// planNode defines node of plan in JSON format, only properties which define shape of the plan are used.
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	Strategy     string     `json:"Strategy"`
+	JoinType     string     `json:"Join Type"`
+	RelationName string     `json:"Relation Name"`
+	IndexName    string     `json:"Index Name"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// planFingerprint returns fingerprint of plan shape: types of nodes, join types and strategies, and names of scanned
+// relations and indexes. Costs and estimates are not taken into account.
+func planFingerprint(plan []byte) (string, error) {
+	var explain []struct {
+		Plan planNode `json:"Plan"`
+	}
+	err := json.Unmarshal(plan, &explain)
+	if err != nil {
+		return "", err
+	}
+	if len(explain) == 0 || explain[0].Plan.NodeType == "" {
+		return "", fmt.Errorf("empty plan")
+	}
+
+	var b strings.Builder
+	var walk func(n planNode)
+	walk = func(n planNode) {
+		b.WriteString(n.NodeType)
+		for _, s := range []string{n.Strategy, n.JoinType, n.RelationName, n.IndexName} {
+			b.WriteByte(',')
+			b.WriteString(s)
+		}
+		b.WriteByte('(')
+		for _, child := range n.Plans {
+			walk(child)
+		}
+		b.WriteByte(')')
+	}
+	walk(explain[0].Plan)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(b.String()))
+
+	return strconv.FormatUint(h.Sum64(), 16), nil
+}
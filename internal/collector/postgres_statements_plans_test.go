@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStatementsPlansCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_statements_plan_info",
+			"postgres_statements_plan_changes_total",
+			"postgres_statements_plan_last_explain_seconds",
+		},
+		collector: NewPostgresStatementsPlansCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_explainableStatement(t *testing.T) {
+	testcases := []struct {
+		query   string
+		generic bool
+		want    bool
+	}{
+		{query: "SELECT * FROM t WHERE id = 1", want: true},
+		{query: "  with x AS (SELECT 1) SELECT * FROM x;", want: true},
+		{query: "UPDATE t SET v = 1", want: true},
+		{query: "SELECT * FROM t WHERE id = $1", want: false},
+		{query: "SELECT * FROM t WHERE id = $1", generic: true, want: true},
+		{query: "VACUUM t", generic: true, want: false},
+		{query: "CREATE TABLE t (id int)", generic: true, want: false},
+		{query: "SELECT 1; DROP TABLE t", generic: true, want: false},
+		{query: "", generic: true, want: false},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, explainableStatement(tc.query, tc.generic), tc.query)
+	}
+}
+
+func Test_planFingerprint(t *testing.T) {
+	seqScan := `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t", "Startup Cost": 0.00, "Total Cost": 35.50}}]`
+	seqScanCosts := `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t", "Startup Cost": 0.00, "Total Cost": 99.50}}]`
+	indexScan := `[{"Plan": {"Node Type": "Index Scan", "Relation Name": "t", "Index Name": "t_pkey"}}]`
+	join := `[{"Plan": {"Node Type": "Hash Join", "Join Type": "Inner", "Plans": [
+		{"Node Type": "Seq Scan", "Relation Name": "t"}, {"Node Type": "Hash", "Plans": [{"Node Type": "Seq Scan", "Relation Name": "u"}]}
+	]}}]`
+
+	a, err := planFingerprint([]byte(seqScan))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, a)
+
+	// Costs don't affect fingerprint.
+	b, err := planFingerprint([]byte(seqScanCosts))
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	c, err := planFingerprint([]byte(indexScan))
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, c)
+
+	d, err := planFingerprint([]byte(join))
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, d)
+
+	_, err = planFingerprint([]byte(`[]`))
+	assert.Error(t, err)
+	_, err = planFingerprint([]byte(`invalid`))
+	assert.Error(t, err)
+}
+
+func Test_postgresStatementsPlansCollector_update(t *testing.T) {
+	c, err := NewPostgresStatementsPlansCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	pc := c.(*postgresStatementsPlansCollector)
+
+	pc.update([]statementPlan{{user: "u", database: "d", queryid: "1", fingerprint: "a"}, {user: "u", database: "d", queryid: "2", fingerprint: "b"}})
+	assert.Len(t, pc.plans, 2)
+	assert.Empty(t, pc.counts)
+
+	// Plan of the first statement changed, the second statement left the top.
+	pc.update([]statementPlan{{user: "u", database: "d", queryid: "1", fingerprint: "c"}})
+	assert.Len(t, pc.plans, 1)
+	assert.Equal(t, map[string]float64{"d/u/1": 1}, pc.counts)
+	assert.Equal(t, [3]string{"u", "d", "1"}, pc.statements["d/u/1"])
+	assert.NotContains(t, pc.previous, "d/u/2")
+
+	// The first statement left the top, the second statement returned to the top.
+	pc.update([]statementPlan{{user: "u", database: "d", queryid: "2", fingerprint: "b"}})
+	assert.Empty(t, pc.counts)
+	assert.Empty(t, pc.statements)
+	assert.Equal(t, map[string]string{"d/u/2": "b"}, pc.previous)
+}
@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// defaultStatementsQueryTTL defines default interval during which query texts of statements are reused between
+	// scrapes. Texts of statements rarely change, but reading them from pg_stat_statements is expensive.
+	defaultStatementsQueryTTL = 10 * time.Minute
+
+	// postgresStatementsTextsQuery defines query for reading query texts of statements.
+	postgresStatementsTextsQuery = "SELECT d.datname AS database, pg_get_userbyid(p.userid) AS \"user\", p.queryid, " +
+		"COALESCE(p.query, '') AS query FROM %s.pg_stat_statements p JOIN pg_database d ON d.oid = p.dbid"
+
+	// statementsQueryHidden defines query text exposed when no-track mode is enabled.
+	statementsQueryHidden = "/* query text hidden, no-track mode enabled */"
+)
+
+// statementsTexts keeps query texts of statements, shared by statements collectors of the service. Texts are refreshed
+// by postgres/statements_query collector and used by postgres/statements collector, hence texts are not read from
+// pg_stat_statements on every scrape.
+type statementsTexts struct {
+	mu      sync.RWMutex
+	texts   map[string]postgresStatementStat // statements with query texts, by database/user/queryid
+	updated time.Time
+	top     map[string]struct{} // statements in top-k during the last scrape, nil if top-k is disabled
+	topSeen bool                // true when statements collector has reported top-k at least once
+}
+
+// lookup returns query text of the statement with passed key.
+func (t *statementsTexts) lookup(key string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	stat, ok := t.texts[key]
+	return stat.query, ok
+}
+
+// update replaces query texts with passed ones.
+func (t *statementsTexts) update(texts map[string]postgresStatementStat) {
+	t.mu.Lock()
+	t.texts = texts
+	t.updated = time.Now()
+	t.mu.Unlock()
+}
+
+// lastUpdated returns time when query texts have been updated.
+func (t *statementsTexts) lastUpdated() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.updated
+}
+
+// exposed returns statements which query texts should be exposed. Unless all is true, only statements in top-k are
+// returned, no statements are returned until top-k is reported by statements collector.
+func (t *statementsTexts) exposed(all bool) []postgresStatementStat {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if !all && !t.topSeen {
+		return nil
+	}
+
+	stats := make([]postgresStatementStat, 0, len(t.texts))
+	for key, stat := range t.texts {
+		if !all && t.top != nil {
+			if _, ok := t.top[key]; !ok {
+				continue
+			}
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// setTop remembers statements in top-k, nil means all statements are exposed.
+func (t *statementsTexts) setTop(stats map[string]postgresStatementStat, enabled bool) {
+	var top map[string]struct{}
+	if enabled {
+		top = make(map[string]struct{}, len(stats))
+		for _, stat := range stats {
+			top[statementKey(stat)] = struct{}{}
+		}
+	}
+
+	t.mu.Lock()
+	t.top = top
+	t.topSeen = true
+	t.mu.Unlock()
+}
+
+// postgresStatementsQueryCollector defines metric descriptors and cache of query texts.
+type postgresStatementsQueryCollector struct {
+	mu    sync.Mutex
+	query typedDesc
+	texts *statementsTexts
+	// cached is true when texts have been reused during the last update.
+	cached bool
+}
+
+// NewPostgresStatementsQueryCollector returns a new Collector exposing query texts of statements. Texts are reused
+// during statements_query_ttl because they rarely change between scrapes.
+// For details see https://www.postgresql.org/docs/current/pgstatstatements.html
+func NewPostgresStatementsQueryCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresStatementsQueryCollector{
+		query: newBuiltinTypedDesc(
+			descOpts{"postgres", "statements", "query_info", "Labeled info about statements has been executed.", 0},
+			prometheus.GaugeValue,
+			[]string{"user", "database", "queryid", "query"}, constLabels,
+			settings.Filters,
+		),
+		texts: &statementsTexts{},
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresStatementsQueryCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	// nothing to do, pg_stat_statements not found in shared_preload_libraries
+	if !config.pgStatStatements {
+		return nil
+	}
+
+	ttl := config.StatementsQueryTTL
+	if ttl <= 0 {
+		ttl = defaultStatementsQueryTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cached = true
+	if updated := c.texts.lastUpdated(); config.bypassCache || updated.IsZero() || time.Since(updated) >= ttl {
+		c.cached = false
+		texts, err := queryPostgresStatementsTexts(config)
+		if err != nil {
+			return err
+		}
+		c.texts.update(texts)
+	}
+
+	// Only statements in top-k are exposed unless requested otherwise, other statements are aggregated by statements
+	// collector.
+	for _, stat := range c.texts.exposed(config.StatementsQueryAll) {
+		query := stat.query
+		if config.NoTrackMode {
+			query = statementsQueryHidden
+		}
+
+		ch <- c.query.newConstMetric(1, stat.user, stat.database, stat.queryid, query)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresStatementsQueryCollector) executedQueries(config Config) []string {
+	if !config.pgStatStatements {
+		return nil
+	}
+	return []string{selectStatementsTextsQuery(config.pgStatStatementsSchema, config.NoTrackMode)}
+}
+
+// dataAge implements cachedCollector interface.
+func (c *postgresStatementsQueryCollector) dataAge() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	updated := c.texts.lastUpdated()
+	if !c.cached || updated.IsZero() {
+		return 0, false
+	}
+	return time.Since(updated), true
+}
+
+// queryPostgresStatementsTexts reads query texts of statements from pg_stat_statements. Texts are not read in
+// no-track mode.
+func queryPostgresStatementsTexts(config Config) (map[string]postgresStatementStat, error) {
+	conn, err := config.acquireDatabaseConn(config.pgStatStatementsDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(selectStatementsTextsQuery(config.pgStatStatementsSchema, config.NoTrackMode))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePostgresStatementsStats(res, []string{"user", "database", "queryid", "query"}), nil
+}
+
+// selectStatementsTextsQuery returns query for texts of statements from pg_stat_statements installed in passed schema.
+// Texts are not read in no-track mode.
+func selectStatementsTextsQuery(schema string, noTrack bool) string {
+	query := fmt.Sprintf(postgresStatementsTextsQuery, schema)
+	if noTrack {
+		query = strings.Replace(query, "COALESCE(p.query, '')", "''", 1)
+	}
+	return query
+}
+
+// linkStatementsTexts returns query texts cache of statements_query collector, if the collector is enabled.
+func linkStatementsTexts(collectors map[string]Collector) *statementsTexts {
+	if c, ok := collectors["postgres/statements_query"].(*postgresStatementsQueryCollector); ok {
+		return c.texts
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStatementsQueryCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		// Texts are not exposed until top-k is reported by statements collector.
+		optional: []string{
+			"postgres_statements_query_info",
+		},
+		collector: NewPostgresStatementsQueryCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_statementsTexts(t *testing.T) {
+	texts := &statementsTexts{}
+	assert.True(t, texts.lastUpdated().IsZero())
+
+	texts.update(map[string]postgresStatementStat{
+		"testdb/testuser/1": {database: "testdb", user: "testuser", queryid: "1", query: "SELECT 1"},
+		"testdb/testuser/2": {database: "testdb", user: "testuser", queryid: "2", query: "SELECT 2"},
+	})
+	assert.False(t, texts.lastUpdated().IsZero())
+
+	query, ok := texts.lookup("testdb/testuser/1")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT 1", query)
+
+	_, ok = texts.lookup("testdb/testuser/3")
+	assert.False(t, ok)
+
+	// Until top-k is reported, texts are exposed only when all statements are requested.
+	assert.Empty(t, texts.exposed(false))
+	assert.Len(t, texts.exposed(true), 2)
+
+	texts.setTop(map[string]postgresStatementStat{"testdb/testuser/2": {database: "testdb", user: "testuser", queryid: "2"}}, true)
+	assert.Len(t, texts.exposed(true), 2)
+	assert.Equal(t, []postgresStatementStat{{database: "testdb", user: "testuser", queryid: "2", query: "SELECT 2"}}, texts.exposed(false))
+
+	// Without top-k all statements are exposed.
+	texts.setTop(map[string]postgresStatementStat{"testdb/testuser/2": {database: "testdb", user: "testuser", queryid: "2"}}, false)
+	assert.Len(t, texts.exposed(false), 2)
+}
+
+func Test_linkStatementsTexts(t *testing.T) {
+	assert.Nil(t, linkStatementsTexts(map[string]Collector{}))
+
+	c, err := NewPostgresStatementsQueryCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, c.(*postgresStatementsQueryCollector).texts, linkStatementsTexts(map[string]Collector{"postgres/statements_query": c}))
+}
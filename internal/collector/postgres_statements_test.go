@@ -3,17 +3,18 @@ package collector
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/jackc/pgproto3/v2"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestPostgresStatementsCollector_Update(t *testing.T) {
 	var input = pipelineInput{
 		required: []string{
-			"postgres_statements_query_info",
 			"postgres_statements_calls_total",
 			"postgres_statements_rows_total",
 			"postgres_statements_time_seconds_total",
@@ -34,6 +35,19 @@ func TestPostgresStatementsCollector_Update(t *testing.T) {
 			"postgres_statements_wal_bytes_all_total",
 			"postgres_statements_wal_bytes_total",
 			"postgres_statements_wal_buffers_full",
+			"postgres_statements_toplevel_calls_total",
+			"postgres_statements_parallel_workers_total",
+			"postgres_statements_database_calls_total",
+			"postgres_statements_database_plans_total",
+			"postgres_statements_database_plans_calls_ratio",
+			"postgres_statements_rollup_calls_total",
+			"postgres_statements_rollup_exec_time_seconds_total",
+			"postgres_statements_rollup_wal_bytes_total",
+			"postgres_statements_rollup_temp_bytes_total",
+			"postgres_statements_entries",
+			"postgres_statements_max_entries",
+			"postgres_statements_dealloc_total",
+			"postgres_statements_retention_seconds",
 		},
 		collector: NewPostgresStatementsCollector,
 		service:   model.ServiceTypePostgresql,
@@ -153,33 +167,268 @@ func Test_parsePostgresStatementsStats(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "plan identifiers",
+			res: &model.PGResult{
+				Nrows: 3,
+				Ncols: 7,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("queryid")}, {Name: []byte("planid")}, {Name: []byte("query")},
+					{Name: []byte("calls")}, {Name: []byte("rows")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "1", Valid: true}, {String: "101", Valid: true}, {String: "SELECT 1", Valid: true},
+						{String: "10", Valid: true}, {String: "20", Valid: true},
+					},
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "1", Valid: true}, {String: "102", Valid: true}, {String: "SELECT 1", Valid: true},
+						{String: "30", Valid: true}, {String: "40", Valid: true},
+					},
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "2", Valid: true}, {}, {String: "SELECT 2", Valid: true},
+						{String: "50", Valid: true}, {String: "60", Valid: true},
+					},
+				},
+			},
+			want: map[string]postgresStatementStat{
+				"testdb/testuser/1/101": {database: "testdb", user: "testuser", queryid: "1", planid: "101", query: "SELECT 1", calls: 10, rows: 20},
+				"testdb/testuser/1/102": {database: "testdb", user: "testuser", queryid: "1", planid: "102", query: "SELECT 1", calls: 30, rows: 40},
+				"testdb/testuser/2":     {database: "testdb", user: "testuser", queryid: "2", query: "SELECT 2", calls: 50, rows: 60},
+			},
+		},
+		{
+			name: "top-level and nested calls, parallel workers",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 8,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("queryid")}, {Name: []byte("query")},
+					{Name: []byte("calls")}, {Name: []byte("toplevel_calls")}, {Name: []byte("parallel_workers_to_launch")}, {Name: []byte("parallel_workers_launched")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "1", Valid: true}, {String: "SELECT 1", Valid: true},
+						{String: "10", Valid: true}, {String: "10", Valid: true}, {String: "8", Valid: true}, {String: "6", Valid: true},
+					},
+					{
+						{String: "testdb", Valid: true}, {String: "testuser", Valid: true}, {String: "1", Valid: true}, {String: "SELECT 1", Valid: true},
+						{String: "5", Valid: true}, {}, {String: "4", Valid: true}, {String: "4", Valid: true},
+					},
+				},
+			},
+			want: map[string]postgresStatementStat{
+				"testdb/testuser/1": {database: "testdb", user: "testuser", queryid: "1", query: "SELECT 1", calls: 15, toplevelCalls: 10, parallelPlanned: 12, parallelLaunched: 10},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := parsePostgresStatementsStats(tc.res, []string{"usename", "datname", "queryid", "query"})
+			got := parsePostgresStatementsStats(tc.res, []string{"usename", "datname", "queryid", "planid", "query"})
 			assert.EqualValues(t, tc.want, got)
 		})
 	}
 }
 
+func Test_statementsDatabaseStats(t *testing.T) {
+	stats := map[string]postgresStatementStat{
+		"testdb/testuser/1":  {database: "testdb", user: "testuser", queryid: "1", calls: 1000, toplevelCalls: 1000, plans: 120, toplevelPlans: 120},
+		"testdb/testuser/2":  {database: "testdb", user: "testuser", queryid: "2", calls: 500, plans: 500},
+		"testdb2/testuser/3": {database: "testdb2", user: "testuser", queryid: "3", calls: 10, toplevelCalls: 10},
+	}
+
+	want := []postgresStatementsDatabaseStat{
+		{database: "testdb", toplevel: "true", calls: 1000, plans: 120},
+		{database: "testdb", toplevel: "false", calls: 500, plans: 500},
+		{database: "testdb2", toplevel: "true", calls: 10},
+	}
+
+	assert.Equal(t, want, statementsDatabaseStats(stats))
+}
+
+func Test_statementsRollups(t *testing.T) {
+	stats := map[string]postgresStatementStat{
+		"testdb/testuser/1": {database: "testdb", user: "testuser", queryid: "1", calls: 600, totalExecTime: 2000.5, walBytes: 65536, tempBlksRead: 6},
+		"testdb/testuser/2": {database: "testdb", user: "testuser", queryid: "2", calls: 400, totalExecTime: 500, tempBlksWritten: 10},
+		"testdb/postgres/3": {database: "testdb", user: "postgres", queryid: "3", calls: 10, totalExecTime: 12},
+	}
+
+	want := []postgresStatementsRollupStat{
+		{database: "testdb", user: "postgres", calls: 10, execTime: 12},
+		{database: "testdb", user: "testuser", calls: 1000, execTime: 2500.5, walBytes: 65536, tempBlks: 16},
+	}
+
+	assert.Equal(t, want, statementsRollups(stats))
+}
+
+func Test_selectStatementsEntriesQuery(t *testing.T) {
+	assert.Equal(t, postgresStatementsEntriesQuery13, selectStatementsEntriesQuery(PostgresV13, "public"))
+	assert.Equal(t, fmt.Sprintf(postgresStatementsEntriesQueryLatest, "public"), selectStatementsEntriesQuery(PostgresV14, "public"))
+}
+
+func Test_parsePostgresStatementsEntriesStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 1,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("max_entries")}, {Name: []byte("dealloc")}, {Name: []byte("stats_age_seconds")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "5000", Valid: true}, {String: "12", Valid: true}, {}},
+		},
+	}
+
+	assert.Equal(t, map[string]float64{"max_entries": 5000, "dealloc": 12}, parsePostgresStatementsEntriesStats(res))
+}
+
+func Test_statementsRetentionSeconds(t *testing.T) {
+	// 5000 entries, 250 entries evicted per deallocation, 40 deallocations per hour replace 2 sets of entries per hour.
+	assert.Equal(t, float64(1800), statementsRetentionSeconds(5000, 40, 3600))
+	// Small max, at least 10 entries evicted per deallocation.
+	assert.Equal(t, float64(3600), statementsRetentionSeconds(100, 1, 360))
+	// No evictions.
+	assert.Equal(t, float64(0), statementsRetentionSeconds(5000, 0, 3600))
+	assert.Equal(t, float64(0), statementsRetentionSeconds(5000, 10, 0))
+}
+
 func Test_selectStatementsQuery(t *testing.T) {
 	testcases := []struct {
 		version int
 		want    string
-		topK    int
 	}{
-		{version: PostgresV12, want: fmt.Sprintf(postgresStatementsQuery12, "p.query", "example"), topK: 0},
-		{version: PostgresV12, want: fmt.Sprintf(postgresStatementsQuery12TopK, "p.query", "example"), topK: 100},
-		{version: PostgresV13, want: fmt.Sprintf(postgresStatementsQuery16, "p.query", "example"), topK: 0},
-		{version: PostgresV13, want: fmt.Sprintf(postgresStatementsQuery16TopK, "p.query", "example"), topK: 100},
-		{version: PostgresV17, want: fmt.Sprintf(postgresStatementsQuery17, "p.query", "example"), topK: 0},
-		{version: PostgresV17, want: fmt.Sprintf(postgresStatementsQuery17TopK, "p.query", "example"), topK: 100},
-		{version: PostgresV18, want: fmt.Sprintf(postgresStatementsQueryLatest, "p.query", "example"), topK: 0},
-		{version: PostgresV18, want: fmt.Sprintf(postgresStatementsQueryLatestTopK, "p.query", "example"), topK: 100},
+		{version: PostgresV12, want: fmt.Sprintf(postgresStatementsQuery12, "p.query", "example.pg_stat_statements")},
+		{version: PostgresV13, want: fmt.Sprintf(postgresStatementsQuery13, "p.query", "example.pg_stat_statements")},
+		{version: PostgresV14, want: fmt.Sprintf(postgresStatementsQuery16, "p.query", "example.pg_stat_statements")},
+		{version: PostgresV17, want: fmt.Sprintf(postgresStatementsQuery17, "p.query", "example.pg_stat_statements")},
+		{version: PostgresV18, want: fmt.Sprintf(postgresStatementsQueryLatest, "p.query", "example.pg_stat_statements")},
 	}
 
 	for _, tc := range testcases {
-		assert.Equal(t, tc.want, selectStatementsQuery(tc.version, "example", false, tc.topK))
+		assert.Equal(t, tc.want, selectStatementsQuery(tc.version, "example", false))
+	}
+	// Query texts are not read when they are not needed.
+	assert.Equal(t,
+		fmt.Sprintf(postgresStatementsQueryLatest, "null", "example.pg_stat_statements(false)"),
+		selectStatementsQuery(PostgresV18, "example", true),
+	)
+}
+
+func Test_withStatementsPlanID(t *testing.T) {
+	query := selectStatementsQuery(PostgresV18, "example", false)
+	got := withStatementsPlanID(query, "p.planid::text")
+	assert.Contains(t, got, "p.queryid, p.planid::text AS planid, COALESCE(p.query, '') AS query")
+	assert.Equal(t, 1, strings.Count(got, "AS planid"))
+
+	assert.Contains(t, withStatementsPlanID(query, "NULL"), "p.queryid, NULL AS planid, ")
+}
+
+func TestNewPostgresStatementsCollector_planID(t *testing.T) {
+	c, err := NewPostgresStatementsCollector(labels{}, model.CollectorSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user", "database", "queryid", "mode"}, c.(*postgresStatementsCollector).times.labelNames)
+
+	// Plan identifier label is added when requested.
+	c, err = NewPostgresStatementsCollector(labels{}, model.CollectorSettings{PlanID: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user", "database", "queryid", "planid"}, c.(*postgresStatementsCollector).calls.labelNames)
+	assert.Equal(t, []string{"user", "database", "queryid", "planid", "mode"}, c.(*postgresStatementsCollector).times.labelNames)
+	assert.Equal(t, []string{"user", "database", "queryid", "planid", "wal"}, c.(*postgresStatementsCollector).walBytes.labelNames)
+}
+
+func Test_selectTopStatements(t *testing.T) {
+	stats := map[string]postgresStatementStat{
+		"testdb/testuser/1":  {database: "testdb", user: "testuser", queryid: "1", query: "SELECT 1", calls: 100, rows: 100},
+		"testdb/testuser/2":  {database: "testdb", user: "testuser", queryid: "2", query: "SELECT 2", calls: 10, rows: 1000},
+		"testdb/testuser/3":  {database: "testdb", user: "testuser", queryid: "3", query: "SELECT 3", calls: 5, rows: 5, totalExecTime: 20},
+		"testdb/testuser/4":  {database: "testdb", user: "testuser", queryid: "4", query: "SELECT 4", calls: 1, rows: 1, totalExecTime: 10},
+		"otherdb/testuser/5": {database: "otherdb", user: "testuser", queryid: "5", query: "SELECT 5", calls: 2, rows: 2},
+	}
+
+	want := map[string]postgresStatementStat{
+		"testdb/testuser/1":  stats["testdb/testuser/1"],
+		"testdb/testuser/2":  stats["testdb/testuser/2"],
+		"testdb/testuser/3":  stats["testdb/testuser/3"],
+		"testdb/all_users/":  {database: "testdb", user: "all_users", query: "all_queries", calls: 1, rows: 1, totalExecTime: 10},
+		"otherdb/all_users/": {database: "otherdb", user: "all_users", query: "all_queries", calls: 2, rows: 2},
 	}
+
+	assert.Equal(t, want, selectTopStatements(stats, 1))
+	assert.Equal(t, stats, selectTopStatements(stats, 10))
+}
+
+func Test_postgresStatementsCollector_updateSnapshot(t *testing.T) {
+	stats := map[string]postgresStatementStat{
+		"testdb/testuser/1": {database: "testdb", user: "testuser", queryid: "1", query: "SELECT 1", calls: 100, totalExecTime: 1000},
+		"testdb/testuser/2": {database: "testdb", user: "testuser", queryid: "2", query: "SELECT 2", calls: 4, totalExecTime: 3000, totalPlanTime: 1000},
+		"testdb/all_users/": {database: "testdb", user: "all_users", query: "all_queries", calls: 1000, totalExecTime: 100000},
+	}
+
+	c := &postgresStatementsCollector{}
+	assert.True(t, c.topQueries().Updated.IsZero())
+
+	c.updateSnapshot(stats, 1, false)
+	got := c.topQueries()
+	assert.False(t, got.Updated.IsZero())
+	assert.Equal(t, []TopQuery{
+		{Database: "testdb", User: "testuser", QueryID: "2", Query: "SELECT 2", Calls: 4, TotalTimeSeconds: 4, MeanTimeSeconds: 1},
+	}, got.Queries)
+
+	// Aggregated statements are not included, texts are hidden in no-track mode.
+	c.updateSnapshot(stats, 0, true)
+	got = c.topQueries()
+	assert.Len(t, got.Queries, 2)
+	assert.Equal(t, "2", got.Queries[0].QueryID)
+	assert.Equal(t, "1", got.Queries[1].QueryID)
+	assert.Equal(t, "/* query text hidden, no-track mode enabled */", got.Queries[1].Query)
+}
+
+func Test_statementExemplarLabels(t *testing.T) {
+	traces := map[string]string{"db/user/123": "5bd66ef5095369c7b0d1f8f4bd33716a"}
+
+	testcases := []struct {
+		name string
+		stat postgresStatementStat
+		want prometheus.Labels
+	}{
+		{name: "aggregated", stat: postgresStatementStat{query: "all_queries"}, want: nil},
+		{name: "plain", stat: postgresStatementStat{database: "db", user: "user", queryid: "456", query: "SELECT 1"}, want: prometheus.Labels{"queryid": "456"}},
+		{
+			name: "traced",
+			stat: postgresStatementStat{database: "db", user: "user", queryid: "123", query: "SELECT 1"},
+			want: prometheus.Labels{"queryid": "123", "trace_id": "5bd66ef5095369c7b0d1f8f4bd33716a"},
+		},
+		{
+			name: "traceparent in query text is ignored",
+			stat: postgresStatementStat{database: "db", user: "user", queryid: "456", query: "SELECT 1 /*traceparent='00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01'*/"},
+			want: prometheus.Labels{"queryid": "456"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, statementExemplarLabels(tc.stat, traces))
+		})
+	}
+}
+
+func Test_parseStatementsTraces(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 4,
+		Ncols: 4,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("user")}, {Name: []byte("queryid")}, {Name: []byte("application_name")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "db", Valid: true}, {String: "user", Valid: true}, {String: "1", Valid: true}, {String: "traceparent=00-5bd66ef5095369c7b0d1f8f4bd33716a-c532cb4098ac3dd2-01", Valid: true}},
+			{{String: "db", Valid: true}, {String: "user", Valid: true}, {String: "2", Valid: true}, {String: "app 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331", Valid: true}},
+			{{String: "db", Valid: true}, {String: "user", Valid: true}, {String: "3", Valid: true}, {String: "app 00-0af7651916cd43dd8448eb211c80319c", Valid: true}},
+			{{String: "db", Valid: true}, {String: "user", Valid: true}, {String: "4", Valid: true}, {String: "psql", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"db/user/1": "5bd66ef5095369c7b0d1f8f4bd33716a",
+		"db/user/2": "0af7651916cd43dd8448eb211c80319c",
+	}, parseStatementsTraces(res))
 }
@@ -4,15 +4,20 @@ package collector
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/disk"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -20,19 +25,46 @@ const (
 		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - min(modification)), 0) AS max_age_seconds " +
 		"FROM pg_tablespace ts LEFT JOIN (SELECT spcname,(pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') ls ON ls.spcname = ts.spcname " +
 		"WHERE ts.spcname != 'pg_global' GROUP BY ts.spcname"
+
+	// postgresTempFilesInflightDatabaseQuery defines query for in-flight temp files grouped by tablespace and database.
+	// Temp files are named as pgsql_tmpPID.N, where PID is the process ID of the backend which created the file, hence
+	// database is found by joining pg_stat_activity. Files of finished backends are accounted with empty database.
+	postgresTempFilesInflightDatabaseQuery = "SELECT ls.spcname AS tablespace, COALESCE(a.datname, '') AS database, " +
+		"COUNT(ls.size) AS files_total, COALESCE(sum(ls.size), 0) AS bytes_total " +
+		"FROM (SELECT spcname,(pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') ls " +
+		"LEFT JOIN pg_stat_activity a ON a.pid = substring(ls.name FROM '^pgsql_tmp([0-9]+)')::int " +
+		"GROUP BY ls.spcname, a.datname"
+
+	// postgresTablespacesQuery defines query for locations and sizes of tablespaces.
+	postgresTablespacesQuery = "SELECT spcname, coalesce(nullif(pg_tablespace_location(oid), ''), current_setting('data_directory')) AS path, " +
+		"pg_tablespace_size(oid) AS size FROM pg_tablespace"
+
+	// postgresWaldirQuery defines query for location, size and number of files of WAL directory.
+	postgresWaldirQuery = "SELECT current_setting('data_directory')||'/pg_wal' AS path, COALESCE(sum(size), 0) AS bytes, COALESCE(count(name), 0) AS count FROM pg_ls_waldir()"
+
+	// postgresLogdirQuery defines query for location, size and number of files of log directory.
+	postgresLogdirQuery = "SELECT current_setting('log_directory') AS path, COALESCE(sum(size), 0) AS bytes, COALESCE(count(name), 0) AS count FROM pg_ls_logdir()"
+
+	// postgresTmpdirQuery defines query for size and number of temp files in all tablespaces.
+	postgresTmpdirQuery = "SELECT coalesce(sum(size), 0) AS bytes, coalesce(count(name), 0) AS count " +
+		"FROM (SELECT (pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') tablespaces"
 )
 
 type postgresStorageCollector struct {
 	tempFiles       typedDesc
 	tempBytes       typedDesc
 	tempFilesMaxAge typedDesc
+	tempFilesDB     typedDesc
+	tempBytesDB     typedDesc
 	datadirBytes    typedDesc
 	tblspcBytes     typedDesc
 	waldirBytes     typedDesc
 	waldirFiles     typedDesc
+	waldirFree      typedDesc
 	logdirBytes     typedDesc
 	logdirFiles     typedDesc
 	tmpfilesBytes   typedDesc
+	walker          *dirWalker
 }
 
 // NewPostgresStorageCollector returns a new Collector exposing various stats related to Postgres storage layer.
@@ -57,6 +89,18 @@ func NewPostgresStorageCollector(constLabels labels, settings model.CollectorSet
 			[]string{"tablespace"}, constLabels,
 			settings.Filters,
 		),
+		tempFilesDB: newBuiltinTypedDesc(
+			descOpts{"postgres", "temp_files", "database_in_flight", "Number of temporary files processed in flight, by database.", 0},
+			prometheus.GaugeValue,
+			[]string{"tablespace", "database"}, constLabels,
+			settings.Filters,
+		),
+		tempBytesDB: newBuiltinTypedDesc(
+			descOpts{"postgres", "temp_bytes", "database_in_flight", "Number of bytes occupied by temporary files processed in flight, by database.", 0},
+			prometheus.GaugeValue,
+			[]string{"tablespace", "database"}, constLabels,
+			settings.Filters,
+		),
 		datadirBytes: newBuiltinTypedDesc(
 			descOpts{"postgres", "data_directory", "bytes", "The size of Postgres server data directory, in bytes.", 0},
 			prometheus.GaugeValue,
@@ -81,6 +125,12 @@ func NewPostgresStorageCollector(constLabels labels, settings model.CollectorSet
 			[]string{"device", "mountpoint", "path"}, constLabels,
 			settings.Filters,
 		),
+		waldirFree: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_directory", "free_bytes", "Free space available on filesystem of Postgres server WAL directory, in bytes.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "path"}, constLabels,
+			settings.Filters,
+		),
 		logdirBytes: newBuiltinTypedDesc(
 			descOpts{"postgres", "log_directory", "bytes", "The size of Postgres server LOG directory, in bytes.", 0},
 			prometheus.GaugeValue,
@@ -99,6 +149,7 @@ func NewPostgresStorageCollector(constLabels labels, settings model.CollectorSet
 			[]string{"device", "mountpoint", "path"}, constLabels,
 			settings.Filters,
 		),
+		walker: newDirWalker(),
 	}, nil
 }
 
@@ -112,7 +163,12 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	// Without SQL access only directories found in data directory are accounted.
+	if config.sqlUnavailable {
+		return c.updateLocal(config, ch)
+	}
+
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -132,6 +188,18 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 				ch <- c.tempFilesMaxAge.newConstMetric(stat.tempmaxage, stat.tablespace)
 			}
 		}
+
+		res, err = conn.Query(postgresTempFilesInflightDatabaseQuery)
+		if err != nil {
+			log.Warnf("get in-flight temp files by databases failed: %s; skip", err)
+		} else {
+			stats := parsePostgresTempFileInflightDatabase(res)
+
+			for _, stat := range stats {
+				ch <- c.tempFilesDB.newConstMetric(stat.tempfiles, stat.tablespace, stat.database)
+				ch <- c.tempBytesDB.newConstMetric(stat.tempbytes, stat.tablespace, stat.database)
+			}
+		}
 	}
 
 	// Collecting metrics about directories requires direct access to filesystems, which is impossible for remote services.
@@ -164,11 +232,19 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 	}
 
 	// Collecting other server-directories stats (DATADIR and tablespaces, WALDIR, LOGDIR, TEMPDIR).
-	dirstats, tblspcStats, err := newPostgresDirStat(conn, config.dataDirectory, config.loggingCollector, config.pgVersion.Numeric)
+	cacheTTL := config.DirWalkCacheTTL
+	if config.bypassCache {
+		cacheTTL = 0
+	}
+	c.walker.configure(config.DirWalkRate, config.DirWalkTimeout, cacheTTL)
+	dirstats, tblspcStats, err := newPostgresDirStat(conn, c.walker, config.dataDirectory, config.loggingCollector, config.pgVersion.Numeric)
 	if err != nil {
 		return err
 	}
 
+	// Remember mountpoints of data and WAL directories for predicting filesystem exhaustion.
+	storageMountpoints.add(config.ConnString, dirstats.datadirMountpoint, dirstats.waldirMountpoint)
+
 	// Data directory
 	ch <- c.datadirBytes.newConstMetric(dirstats.datadirSizeBytes, dirstats.datadirDevice, dirstats.datadirMountpoint, dirstats.datadirPath)
 
@@ -180,6 +256,16 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 	ch <- c.waldirBytes.newConstMetric(dirstats.waldirSizeBytes, dirstats.waldirDevice, dirstats.waldirMountpoint, dirstats.waldirPath)
 	ch <- c.waldirFiles.newConstMetric(dirstats.waldirFilesCount, dirstats.waldirDevice, dirstats.waldirMountpoint, dirstats.waldirPath)
 
+	usage, err := disk.Usage(dirstats.waldirMountpoint)
+	if err != nil {
+		log.Warnf("get usage of %s failed: %s; skip", dirstats.waldirMountpoint, err)
+	} else {
+		ch <- c.waldirFree.newConstMetric(float64(usage.Free), dirstats.waldirDevice, dirstats.waldirMountpoint, dirstats.waldirPath)
+		config.facts.publish(factWalDirectoryFreeBytes, float64(usage.Free), labels{
+			"device": dirstats.waldirDevice, "mountpoint": dirstats.waldirMountpoint, "path": dirstats.waldirPath,
+		})
+	}
+
 	// Log directory (only if logging_collector is enabled).
 	if config.loggingCollector {
 		ch <- c.logdirBytes.newConstMetric(dirstats.logdirSizeBytes, dirstats.logdirDevice, dirstats.logdirMountpoint, dirstats.logdirPath)
@@ -194,9 +280,109 @@ func (c *postgresStorageCollector) Update(config Config, ch chan<- prometheus.Me
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresStorageCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV10 || config.sqlUnavailable {
+		return nil
+	}
+
+	var queries []string
+	if config.pgVersion.Numeric >= PostgresV12 {
+		queries = append(queries, postgresTempFilesInflightQuery, postgresTempFilesInflightDatabaseQuery)
+	}
+	if config.localService {
+		queries = append(queries, postgresTablespacesQuery)
+	}
+	queries = append(queries, postgresWaldirQuery)
+	if config.loggingCollector {
+		queries = append(queries, postgresLogdirQuery)
+	}
+	if config.pgVersion.Numeric >= PostgresV12 {
+		queries = append(queries, postgresTmpdirQuery)
+	}
+
+	return queries
+}
+
+// updateLocal collects sizes of data, WAL and log directories of the local service which is not accessible using SQL.
+// Directories are found in data directory, tablespaces and temp files are not accounted.
+func (c *postgresStorageCollector) updateLocal(config Config, ch chan<- prometheus.Metric) error {
+	mounts, err := getMountpoints()
+	if err != nil {
+		return fmt.Errorf("get mountpoints failed: %s", err)
+	}
+
+	cacheTTL := config.DirWalkCacheTTL
+	if config.bypassCache {
+		cacheTTL = 0
+	}
+	c.walker.configure(config.DirWalkRate, config.DirWalkTimeout, cacheTTL)
+
+	// Data directory
+	device, mountpoint, size, err := getDatadirStat(c.walker, config.dataDirectory, mounts)
+	if err != nil {
+		return err
+	}
+	ch <- c.datadirBytes.newConstMetric(float64(size), device, mountpoint, config.dataDirectory)
+
+	// WAL directory
+	waldir := filepath.Join(config.dataDirectory, "pg_wal")
+	size, count, err := getDirectoryFilesStat(waldir)
+	if err != nil {
+		return fmt.Errorf("get WAL directory size failed: %s", err)
+	}
+
+	waldirMountpoint, waldirDevice, err := findMountpoint(mounts, waldir)
+	if err != nil {
+		return fmt.Errorf("find WAL directory mountpoint failed: %s", err)
+	}
+	waldirDevice = truncateDeviceName(waldirDevice)
+
+	storageMountpoints.add(config.ConnString, mountpoint, waldirMountpoint)
+
+	ch <- c.waldirBytes.newConstMetric(float64(size), waldirDevice, waldirMountpoint, waldir)
+	ch <- c.waldirFiles.newConstMetric(float64(count), waldirDevice, waldirMountpoint, waldir)
+
+	// Log directory (only if logging_collector is enabled).
+	if !config.loggingCollector {
+		return nil
+	}
+
+	logfile, err := readCurrentLogfile(config.dataDirectory)
+	if err != nil {
+		log.Warnf("get current logfile failed: %s; skip", err)
+		return nil
+	}
+
+	logdir := filepath.Dir(logfile)
+	size, count, err = getDirectoryFilesStat(logdir)
+	if err != nil {
+		log.Warnf("get log directory size failed: %s; skip", err)
+		return nil
+	}
+
+	logdirMountpoint, logdirDevice, err := findMountpoint(mounts, logdir)
+	if err != nil {
+		log.Warnf("find log directory mountpoint failed: %s; skip", err)
+		return nil
+	}
+	logdirDevice = truncateDeviceName(logdirDevice)
+
+	ch <- c.logdirBytes.newConstMetric(float64(size), logdirDevice, logdirMountpoint, logdir)
+	ch <- c.logdirFiles.newConstMetric(float64(count), logdirDevice, logdirMountpoint, logdir)
+
+	return nil
+}
+
+// dataAge implements cachedCollector interface.
+func (c *postgresStorageCollector) dataAge() (time.Duration, bool) {
+	return c.walker.age()
+}
+
 // postgresTempfilesStat
 type postgresTempfilesStat struct {
 	tablespace string
+	database   string
 	tempfiles  float64
 	tempbytes  float64
 	tempmaxage float64
@@ -268,6 +454,47 @@ func parsePostgresTempFileInflght(r *model.PGResult) map[string]postgresTempfile
 	return stats
 }
 
+// parsePostgresTempFileInflightDatabase parses PGResult and returns in-flight temp files stats by tablespaces and databases.
+func parsePostgresTempFileInflightDatabase(r *model.PGResult) map[string]postgresTempfilesStat {
+	log.Debug("parse postgres temp files by databases stats")
+
+	var stats = make(map[string]postgresTempfilesStat)
+
+	for _, row := range r.Rows {
+		stat := postgresTempfilesStat{}
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "tablespace":
+				stat.tablespace = row[i].String
+			case "database":
+				stat.database = row[i].String
+			case "files_total", "bytes_total":
+				// Skip empty (NULL) values.
+				if !row[i].Valid {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				if string(colname.Name) == "files_total" {
+					stat.tempfiles = v
+				} else {
+					stat.tempbytes = v
+				}
+			}
+		}
+
+		stats[stat.tablespace+"/"+stat.database] = stat
+	}
+
+	return stats
+}
+
 // postgresDirStat represents stats about Postgres system directories
 type postgresDirStat struct {
 	datadirPath       string
@@ -321,7 +548,7 @@ func newPostgresStat(conn *store.DB, logcollector bool, version int) (*postgresD
 }
 
 // newPostgresDirStat returns sizes of Postgres server directories.
-func newPostgresDirStat(conn *store.DB, datadir string, logcollector bool, version int) (*postgresDirStat, []tablespaceStat, error) {
+func newPostgresDirStat(conn *store.DB, walker *dirWalker, datadir string, logcollector bool, version int) (*postgresDirStat, []tablespaceStat, error) {
 	// Get directories mountpoints.
 	mounts, err := getMountpoints()
 	if err != nil {
@@ -329,7 +556,7 @@ func newPostgresDirStat(conn *store.DB, datadir string, logcollector bool, versi
 	}
 
 	// Get DATADIR properties.
-	datadirDevice, datadirMount, datadirSize, err := getDatadirStat(datadir, mounts)
+	datadirDevice, datadirMount, datadirSize, err := getDatadirStat(walker, datadir, mounts)
 	if err != nil {
 		log.Errorln(err)
 	}
@@ -380,8 +607,8 @@ func newPostgresDirStat(conn *store.DB, datadir string, logcollector bool, versi
 }
 
 // getDatadirStat returns filesystem info related to DATADIR.
-func getDatadirStat(datadir string, mounts []mount) (string, string, int64, error) {
-	size, err := getDirectorySize(datadir)
+func getDatadirStat(walker *dirWalker, datadir string, mounts []mount) (string, string, int64, error) {
+	size, err := walker.size(datadir)
 	if err != nil {
 		return "", "", 0, fmt.Errorf("get data_directory size failed: %s; skip", err)
 	}
@@ -408,8 +635,8 @@ type tablespaceStat struct {
 
 // getTablespacesStat returns filesystem info related to WALDIR.
 func getTablespacesStat(conn *store.DB, mounts []mount) ([]tablespaceStat, error) {
-	rows, err := conn.Conn().
-		Query(context.Background(), "select spcname, coalesce(nullif(pg_tablespace_location(oid), ''), current_setting('data_directory')) as path, pg_tablespace_size(oid) as size from pg_tablespace")
+	rows, err := conn.
+		QueryRows(postgresTablespacesQuery)
 	if err != nil {
 		return nil, fmt.Errorf("get tablespaces stats failed: %s", err)
 	}
@@ -448,8 +675,8 @@ func getTablespacesStat(conn *store.DB, mounts []mount) ([]tablespaceStat, error
 func getWalStat(conn *store.DB) (string, int64, int64, error) {
 	var path string
 	var size, count int64
-	err := conn.Conn().
-		QueryRow(context.Background(), "SELECT current_setting('data_directory')||'/pg_wal' AS path, COALESCE(sum(size), 0) AS bytes, COALESCE(count(name), 0) AS count FROM pg_ls_waldir()").
+	err := conn.
+		QueryRow(postgresWaldirQuery).
 		Scan(&path, &size, &count)
 	if err != nil {
 		return "", 0, 0, fmt.Errorf("get WAL directory size failed: %s", err)
@@ -485,8 +712,8 @@ func getLogStat(conn *store.DB, logcollector bool) (string, int64, int64, error)
 
 	var size, count int64
 	var path string
-	err := conn.Conn().
-		QueryRow(context.Background(), "SELECT current_setting('log_directory') AS path, COALESCE(sum(size), 0) AS bytes, COALESCE(count(name), 0) AS count FROM pg_ls_logdir()").
+	err := conn.
+		QueryRow(postgresLogdirQuery).
 		Scan(&path, &size, &count)
 	if err != nil {
 		return "", 0, 0, fmt.Errorf("get log directory size failed: %s", err)
@@ -525,8 +752,8 @@ func getTempfilesStat(conn *store.DB, version int) (int64, int64, error) {
 	}
 
 	var size, count int64
-	err := conn.Conn().
-		QueryRow(context.Background(), "SELECT coalesce(sum(size), 0) AS bytes, coalesce(count(name), 0) AS count FROM (SELECT (pg_ls_tmpdir(oid)).* FROM pg_tablespace WHERE spcname != 'pg_global') tablespaces").
+	err := conn.
+		QueryRow(postgresTmpdirQuery).
 		Scan(&size, &count)
 	if err != nil {
 		return 0, 0, fmt.Errorf("get total size of temp files failed: %s", err)
@@ -535,8 +762,41 @@ func getTempfilesStat(conn *store.DB, version int) (int64, int64, error) {
 	return size, count, nil
 }
 
+// getDirectoryFilesStat returns total size and number of regular files in the directory, subdirectories are not
+// accounted, the same as pg_ls_waldir() and pg_ls_logdir() do.
+func getDirectoryFilesStat(path string) (int64, int64, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var size, count int64
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+
+		// Files might be removed during listing, e.g. recycled WAL segments.
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		size += info.Size()
+		count++
+	}
+
+	return size, count, nil
+}
+
 // getDirectorySize walk through directory tree, calculate sizes and return total size of the directory.
 func getDirectorySize(path string) (int64, error) {
+	return walkDirectorySize(context.Background(), path, nil)
+}
+
+// walkDirectorySize walk through directory tree and return total size of the directory. Walking is throttled by
+// limiter (if passed) and interrupted when context is done.
+func walkDirectorySize(ctx context.Context, path string, limiter *rate.Limiter) (int64, error) {
 	fi, err := os.Lstat(path)
 	if err != nil {
 		return 0, err
@@ -553,7 +813,7 @@ func getDirectorySize(path string) (int64, error) {
 
 	var size int64
 
-	err = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
 		// ignore ENOENT errors, they don't affect overall result.
 		if err != nil {
 			if strings.HasSuffix(err.Error(), "no such file or directory") {
@@ -561,14 +821,156 @@ func getDirectorySize(path string) (int64, error) {
 			}
 			return err
 		}
-		if !info.IsDir() {
-			size += info.Size()
+
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
 		}
-		return err
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		size += info.Size()
+		return nil
 	})
 	return size, err
 }
 
+// dirWalker calculates sizes of directories. On huge directories walking causes IO latency spikes, hence walking
+// could be throttled by number of files per second, and calculated sizes could be cached. Directories are walked in
+// background: walking which is not finished in timeout is continued during next scrapes, meanwhile the last complete
+// size is returned.
+type dirWalker struct {
+	rate    int           // max number of files visited per second, 0 means unlimited
+	timeout time.Duration // max duration of waiting for walking, 0 means unlimited
+	ttl     time.Duration // how long calculated sizes are reused, 0 means no caching
+	cache   map[string]dirWalkerSize
+	walks   map[string]*dirWalk // walks in progress, by path
+	// oldest defines time when the oldest cached size returned since the last configuring has been calculated.
+	oldest time.Time
+	mu     sync.Mutex
+}
+
+// dirWalkerSize defines the last complete size of directory.
+type dirWalkerSize struct {
+	size    int64
+	updated time.Time
+}
+
+// dirWalk defines walking of directory in progress, done is closed when walking is finished.
+type dirWalk struct {
+	done chan struct{}
+	size int64
+	err  error
+}
+
+// newDirWalker creates new dirWalker with no limits.
+func newDirWalker() *dirWalker {
+	return &dirWalker{cache: map[string]dirWalkerSize{}, walks: map[string]*dirWalk{}}
+}
+
+// configure sets walking limits.
+func (w *dirWalker) configure(rate int, timeout time.Duration, ttl time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.rate, w.timeout, w.ttl = rate, timeout, ttl
+	w.oldest = time.Time{}
+}
+
+// age returns age of the oldest cached size returned since the last configuring and true if any cached size has
+// been returned.
+func (w *dirWalker) age() (time.Duration, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.oldest.IsZero() {
+		return 0, false
+	}
+	return time.Since(w.oldest), true
+}
+
+// size returns size of the directory, cached size is returned when it is not expired yet. Otherwise, walking of the
+// directory is started (unless it is already in progress) and awaited during timeout. When walking is not finished in
+// timeout, the last complete size is returned and walking is continued in background.
+func (w *dirWalker) size(path string) (int64, error) {
+	w.mu.Lock()
+	if cached, ok := w.cache[path]; ok && w.ttl > 0 && time.Since(cached.updated) < w.ttl {
+		w.observe(cached)
+		w.mu.Unlock()
+		return cached.size, nil
+	}
+
+	walk, ok := w.walks[path]
+	if !ok {
+		walk = &dirWalk{done: make(chan struct{})}
+		w.walks[path] = walk
+		go w.walk(path, walk, w.rate)
+	}
+	timeout := w.timeout
+	w.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-walk.done:
+		return walk.size, walk.err
+	case <-expired:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		cached, ok := w.cache[path]
+		if !ok {
+			return 0, fmt.Errorf("walking %s is not finished in %s, continue in background", path, timeout)
+		}
+		w.observe(cached)
+		return cached.size, nil
+	}
+}
+
+// walk calculates size of the directory and remembers it as the last complete size.
+func (w *dirWalker) walk(path string, walk *dirWalk, limit int) {
+	var limiter *rate.Limiter
+	if limit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(limit), limit)
+	}
+
+	size, err := walkDirectorySize(context.Background(), path, limiter)
+
+	w.mu.Lock()
+	delete(w.walks, path)
+	if err == nil {
+		w.cache[path] = dirWalkerSize{size: size, updated: time.Now()}
+	}
+	w.mu.Unlock()
+
+	walk.size, walk.err = size, err
+	close(walk.done)
+}
+
+// observe accounts age of returned cached size, must be called with mutex held.
+func (w *dirWalker) observe(cached dirWalkerSize) {
+	if w.oldest.IsZero() || cached.updated.Before(w.oldest) {
+		w.oldest = cached.updated
+	}
+}
+
 // findMountpoint checks path in the list of passed mountpoints.
 func findMountpoint(mounts []mount, path string) (string, string, error) {
 	fi, err := os.Lstat(path)
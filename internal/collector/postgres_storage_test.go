@@ -7,7 +7,9 @@ import (
 	"github.com/cherts/pgscv/internal/store"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestPostgresStorageCollector_Update(t *testing.T) {
@@ -18,11 +20,15 @@ func TestPostgresStorageCollector_Update(t *testing.T) {
 	var input = pipelineInput{
 		required: []string{
 			"postgres_temp_files_in_flight", "postgres_temp_bytes_in_flight", "postgres_temp_files_max_age_seconds",
+			"postgres_temp_files_database_in_flight", "postgres_temp_bytes_database_in_flight",
 			"postgres_data_directory_bytes", "postgres_tablespace_directory_bytes",
 			"postgres_wal_directory_bytes", "postgres_wal_directory_files",
 			"postgres_log_directory_bytes", "postgres_log_directory_files",
 			"postgres_temp_files_all_bytes",
 		},
+		optional: []string{
+			"postgres_wal_directory_free_bytes",
+		},
 		collector: NewPostgresStorageCollector,
 		service:   model.ServiceTypePostgresql,
 	}
@@ -64,6 +70,42 @@ func Test_parsePostgresTempFileInflght(t *testing.T) {
 	}
 }
 
+func Test_parsePostgresTempFileInflightDatabase(t *testing.T) {
+	var testCases = []struct {
+		name string
+		res  *model.PGResult
+		want map[string]postgresTempfilesStat
+	}{
+		{
+			name: "normal output",
+			res: &model.PGResult{
+				Nrows: 3,
+				Ncols: 4,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("tablespace")}, {Name: []byte("database")}, {Name: []byte("files_total")}, {Name: []byte("bytes_total")},
+				},
+				Rows: [][]sql.NullString{
+					{{String: "pg_default", Valid: true}, {String: "db1", Valid: true}, {String: "4", Valid: true}, {String: "4096000", Valid: true}},
+					{{String: "pg_default", Valid: true}, {String: "db2", Valid: true}, {String: "1", Valid: true}, {String: "8192", Valid: true}},
+					{{String: "temp", Valid: true}, {String: "", Valid: true}, {String: "2", Valid: true}, {String: "", Valid: false}},
+				},
+			},
+			want: map[string]postgresTempfilesStat{
+				"pg_default/db1": {tablespace: "pg_default", database: "db1", tempfiles: 4, tempbytes: 4096000},
+				"pg_default/db2": {tablespace: "pg_default", database: "db2", tempfiles: 1, tempbytes: 8192},
+				"temp/":          {tablespace: "temp", database: "", tempfiles: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parsePostgresTempFileInflightDatabase(tc.res)
+			assert.EqualValues(t, tc.want, got)
+		})
+	}
+}
+
 func Test_getDatadirStat(t *testing.T) {
 	if uid := os.Geteuid(); uid != 0 {
 		t.Skipf("root privileges required, skip")
@@ -72,7 +114,7 @@ func Test_getDatadirStat(t *testing.T) {
 	mounts, err := getMountpoints()
 	assert.NoError(t, err)
 
-	s1, s2, i1, err := getDatadirStat("/tmp", mounts)
+	s1, s2, i1, err := getDatadirStat(newDirWalker(), "/tmp", mounts)
 	assert.NoError(t, err)
 	assert.NotEqual(t, "", s1)
 	assert.NotEqual(t, "", s2)
@@ -149,6 +191,75 @@ func Test_getDirectorySize(t *testing.T) {
 	assert.Equal(t, size, int64(0))
 }
 
+func Test_getDirectoryFilesStat(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "000000010000000000000001"), make([]byte, 100), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "000000010000000000000002"), make([]byte, 50), 0600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "archive_status"), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "archive_status", "000000010000000000000001.done"), make([]byte, 10), 0600))
+
+	// Files in subdirectories are not accounted.
+	size, count, err := getDirectoryFilesStat(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), size)
+	assert.Equal(t, int64(2), count)
+
+	_, _, err = getDirectoryFilesStat("unknown")
+	assert.Error(t, err)
+}
+
+func Test_dirWalker(t *testing.T) {
+	w := newDirWalker()
+	want, err := getDirectorySize("testdata")
+	assert.NoError(t, err)
+
+	w.configure(100000, time.Minute, time.Minute)
+	size, err := w.size("testdata")
+	assert.NoError(t, err)
+	assert.Equal(t, want, size)
+	_, cached := w.age()
+	assert.False(t, cached)
+
+	// Cached size is returned until TTL is expired.
+	w.cache["testdata"] = dirWalkerSize{size: 1, updated: time.Now().Add(-10 * time.Second)}
+	size, err = w.size("testdata")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), size)
+	age, cached := w.age()
+	assert.True(t, cached)
+	assert.GreaterOrEqual(t, age, 10*time.Second)
+
+	// Age of cached sizes is reset when walker is configured before next update.
+	w.configure(100000, time.Minute, time.Minute)
+	_, cached = w.age()
+	assert.False(t, cached)
+
+	// Error is returned when walking is not finished in timeout and there is no complete size.
+	w = newDirWalker()
+	w.walks["testdata"] = &dirWalk{done: make(chan struct{})}
+	w.configure(0, time.Millisecond, 0)
+	_, err = w.size("testdata")
+	assert.Error(t, err)
+
+	// The last complete size is returned while walking is in progress.
+	w.cache["testdata"] = dirWalkerSize{size: 2, updated: time.Now().Add(-time.Hour)}
+	size, err = w.size("testdata")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), size)
+	age, cached = w.age()
+	assert.True(t, cached)
+	assert.GreaterOrEqual(t, age, time.Hour)
+
+	// Finished walking updates the last complete size.
+	delete(w.walks, "testdata")
+	w.configure(0, time.Minute, 0)
+	size, err = w.size("testdata")
+	assert.NoError(t, err)
+	assert.Equal(t, want, size)
+	assert.Equal(t, want, w.cache["testdata"].size)
+	assert.Empty(t, w.walks)
+}
+
 func Test_findMountpoint(t *testing.T) {
 	mount, device, err := findMountpoint([]mount{{mountpoint: "/", device: "sda"}}, "/bin")
 	assert.NoError(t, err)
@@ -5,7 +5,6 @@ import (
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -50,7 +49,7 @@ func (c *postgresSubscriptionRelCollector) Update(config Config, ch chan<- prome
 		return nil
 	}
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -103,6 +102,14 @@ func (c *postgresSubscriptionRelCollector) Update(config Config, ch chan<- prome
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresSubscriptionRelCollector) executedQueries(config Config) []string {
+	if config.pgVersion.Numeric < PostgresV10 {
+		return nil
+	}
+	return []string{selectSubscriptionRelQuery(config.pgVersion.Numeric)}
+}
+
 // selectSubscriptionRelQuery returns suitable subscription_rel query depending on passed version.
 func selectSubscriptionRelQuery(version int) string {
 	switch {
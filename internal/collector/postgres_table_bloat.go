@@ -0,0 +1,291 @@
+package collector
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// defaultTableBloatTTL defines default interval during which sampled bloat of a table is reused.
+	defaultTableBloatTTL = 6 * time.Hour
+
+	// tableBloatBatch defines max number of tables sampled during single update. Sampling reads pages of tables which
+	// are not all-visible, hence tables are sampled gradually across scrapes to avoid IO storms.
+	tableBloatBatch = 5
+
+	// tableBloatMaxTop defines max number of the largest tables of each database sampled when table_bloat_top is set.
+	tableBloatMaxTop = 50
+
+	// postgresTableBloatTopQuery defines query for selecting the largest tables of the current database.
+	postgresTableBloatTopQuery = "SELECT n.nspname AS schema, c.relname AS table " +
+		"FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE c.relkind IN ('r', 'm') AND c.relpersistence = 'p' " +
+		"AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast' " +
+		"ORDER BY pg_table_size(c.oid) DESC LIMIT $1"
+
+	// postgresTableBloatQuery defines query for sampling bloat of the table using pgstattuple extension. Tables locked
+	// exclusively are not sampled to avoid waiting on locks.
+	postgresTableBloatQuery = "SELECT dead_tuple_percent, approx_free_percent " +
+		"FROM %s.pgstattuple_approx(format('%%I.%%I', $1::text, $2::text)::regclass) " +
+		"WHERE NOT EXISTS (SELECT 1 FROM pg_locks WHERE relation = format('%%I.%%I', $1::text, $2::text)::regclass AND mode = 'AccessExclusiveLock')"
+)
+
+// tableBloatSample represents bloat of the table sampled using pgstattuple_approx().
+type tableBloatSample struct {
+	database    string
+	schema      string
+	table       string
+	deadPercent float64
+	freePercent float64
+	sampled     time.Time
+}
+
+// postgresTableBloatCollector defines metric descriptors and sampled stats.
+type postgresTableBloatCollector struct {
+	dead typedDesc
+	free typedDesc
+	// samples keeps sampled tables, keyed by database/schema/table.
+	samples map[string]tableBloatSample
+	// cached is true when no tables have been sampled during the last update.
+	cached bool
+	mu     sync.Mutex
+}
+
+// NewPostgresTableBloatCollector returns a new Collector exposing bloat of selected tables sampled using
+// pgstattuple_approx(). Tables are selected using table_bloat_tables and table_bloat_top settings, collector does
+// nothing when none of them are set.
+// For details see https://www.postgresql.org/docs/current/pgstattuple.html
+func NewPostgresTableBloatCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresTableBloatCollector{
+		dead: newBuiltinTypedDesc(
+			descOpts{"postgres", "table_bloat", "dead_tuple_percent", "Percentage of the table occupied by dead tuples, sampled periodically.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table"}, constLabels,
+			settings.Filters,
+		),
+		free: newBuiltinTypedDesc(
+			descOpts{"postgres", "table_bloat", "free_percent", "Percentage of free space in the table, sampled periodically.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table"}, constLabels,
+			settings.Filters,
+		),
+		samples: map[string]tableBloatSample{},
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresTableBloatCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if len(config.TableBloatTables) == 0 && config.TableBloatTop <= 0 {
+		return nil
+	}
+
+	ttl := config.TableBloatTTL
+	if ttl <= 0 {
+		ttl = defaultTableBloatTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	databases := []string{conn.Conn().Config().Database}
+	if config.DatabasesRE != nil {
+		databases, err = listDatabases(conn, config.ExcludeDatabasesRE)
+		if err != nil {
+			return err
+		}
+	}
+
+	budget := tableBloatBatch
+	if config.bypassCache {
+		budget = len(config.TableBloatTables) + tableBloatMaxTop*len(databases)
+	}
+
+	c.cached = true
+	candidates := map[string]bool{}
+	for _, d := range databases {
+		if config.DatabasesRE != nil && !config.DatabasesRE.MatchString(d) {
+			continue
+		}
+		if config.TableBloatTop <= 0 && !slices.ContainsFunc(config.TableBloatTables, func(t string) bool { return strings.HasPrefix(t, d+".") }) {
+			continue
+		}
+
+		dbconn := conn
+		if config.DatabasesRE != nil {
+			dbconn, err = config.acquireDatabaseConn(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		budget = c.sampleDatabase(dbconn, d, config, ttl, budget, candidates)
+
+		if dbconn != conn {
+			dbconn.Close()
+		}
+	}
+
+	// Forget tables which are not selected anymore.
+	for key := range c.samples {
+		if !candidates[key] {
+			delete(c.samples, key)
+		}
+	}
+
+	for _, s := range c.samples {
+		ch <- c.dead.newConstMetric(s.deadPercent, s.database, s.schema, s.table)
+		ch <- c.free.newConstMetric(s.freePercent, s.database, s.schema, s.table)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresTableBloatCollector) executedQueries(config Config) []string {
+	if len(config.TableBloatTables) == 0 && config.TableBloatTop <= 0 {
+		return nil
+	}
+
+	queries := []string{postgresExtensionSchemaQuery}
+	if config.TableBloatTop > 0 {
+		queries = append(queries, postgresTableBloatTopQuery)
+	}
+	if schema, ok := config.extensionsSchemas["pgstattuple"]; ok {
+		queries = append(queries, fmt.Sprintf(postgresTableBloatQuery, schema))
+	}
+
+	return perDatabaseQueries(config, queries...)
+}
+
+// sampleDatabase samples bloat of selected tables of the database which samples are older than ttl, at most budget
+// tables are sampled. Keys of selected tables are put into candidates. Remaining budget is returned.
+func (c *postgresTableBloatCollector) sampleDatabase(conn *store.DB, database string, config Config, ttl time.Duration, budget int, candidates map[string]bool) int {
+	schema := extensionInstalledSchema(conn, "pgstattuple")
+	if schema == "" {
+		log.Debugf("[postgres table_bloat collector]: pgstattuple extension is not installed in database %s, skip", database)
+		return budget
+	}
+
+	tables := selectTableBloatTables(config.TableBloatTables, database)
+	if config.TableBloatTop > 0 {
+		res, err := conn.Query(postgresTableBloatTopQuery, min(config.TableBloatTop, tableBloatMaxTop))
+		if err != nil {
+			log.Warnf("get largest tables of database %s failed: %s; skip", database, err)
+		} else {
+			for _, row := range res.Rows {
+				t := [2]string{row[0].String, row[1].String}
+				if !slices.Contains(tables, t) {
+					tables = append(tables, t)
+				}
+			}
+		}
+	}
+
+	// Sample tables with the oldest samples first, never sampled tables go first.
+	slices.SortStableFunc(tables, func(a, b [2]string) int {
+		return c.samples[database+"/"+a[0]+"/"+a[1]].sampled.Compare(c.samples[database+"/"+b[0]+"/"+b[1]].sampled)
+	})
+
+	for _, t := range tables {
+		key := database + "/" + t[0] + "/" + t[1]
+		candidates[key] = true
+
+		if s, ok := c.samples[key]; budget <= 0 || (ok && time.Since(s.sampled) < ttl && !config.bypassCache) {
+			continue
+		}
+		budget--
+
+		res, err := conn.Query(fmt.Sprintf(postgresTableBloatQuery, schema), t[0], t[1])
+		if err != nil {
+			log.Warnf("sample bloat of table %s.%s.%s failed: %s; skip", database, t[0], t[1], err)
+			continue
+		}
+
+		s, ok := parseTableBloatSample(res)
+		if !ok {
+			continue
+		}
+		s.database, s.schema, s.table, s.sampled = database, t[0], t[1], time.Now()
+		c.samples[key] = s
+		c.cached = false
+	}
+
+	return budget
+}
+
+// dataAge implements cachedCollector interface, age of the oldest sample is returned.
+func (c *postgresTableBloatCollector) dataAge() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.cached || len(c.samples) == 0 {
+		return 0, false
+	}
+
+	var oldest time.Time
+	for _, s := range c.samples {
+		if oldest.IsZero() || s.sampled.Before(oldest) {
+			oldest = s.sampled
+		}
+	}
+	return time.Since(oldest), true
+}
+
+// selectTableBloatTables returns schemas and names of tables of the database from the list of tables specified in
+// 'database.schema.table' format.
+func selectTableBloatTables(tables []string, database string) [][2]string {
+	var result [][2]string
+	for _, t := range tables {
+		parts := strings.SplitN(t, ".", 3)
+		if len(parts) != 3 || parts[0] != database {
+			continue
+		}
+		result = append(result, [2]string{parts[1], parts[2]})
+	}
+	return result
+}
+
+// parseTableBloatSample parses PGResult of pgstattuple_approx() query. False is returned when table has not been
+// sampled, e.g. when it is locked.
+func parseTableBloatSample(r *model.PGResult) (tableBloatSample, bool) {
+	var s tableBloatSample
+	if len(r.Rows) == 0 {
+		return s, false
+	}
+
+	for i, colname := range r.Colnames {
+		if !r.Rows[0][i].Valid {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(r.Rows[0][i].String, 64)
+		if err != nil {
+			log.Errorf("invalid input, parse '%s' failed: %s; skip", r.Rows[0][i].String, err)
+			continue
+		}
+
+		switch string(colname.Name) {
+		case "dead_tuple_percent":
+			s.deadPercent = v
+		case "approx_free_percent":
+			s.freePercent = v
+		}
+	}
+
+	return s, true
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTableBloatCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"postgres_table_bloat_dead_tuple_percent",
+			"postgres_table_bloat_free_percent",
+		},
+		collector: NewPostgresTableBloatCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_selectTableBloatTables(t *testing.T) {
+	tables := []string{"appdb.public.orders", "appdb.sales.items", "otherdb.public.users", "invalid"}
+
+	assert.Equal(t, [][2]string{{"public", "orders"}, {"sales", "items"}}, selectTableBloatTables(tables, "appdb"))
+	assert.Equal(t, [][2]string{{"public", "users"}}, selectTableBloatTables(tables, "otherdb"))
+	assert.Nil(t, selectTableBloatTables(tables, "postgres"))
+}
+
+func Test_parseTableBloatSample(t *testing.T) {
+	res := &model.PGResult{
+		Nrows:    1,
+		Ncols:    2,
+		Colnames: []pgproto3.FieldDescription{{Name: []byte("dead_tuple_percent")}, {Name: []byte("approx_free_percent")}},
+		Rows: [][]sql.NullString{
+			{{String: "12.5", Valid: true}, {String: "30.25", Valid: true}},
+		},
+	}
+
+	s, ok := parseTableBloatSample(res)
+	assert.True(t, ok)
+	assert.Equal(t, 12.5, s.deadPercent)
+	assert.Equal(t, 30.25, s.freePercent)
+
+	// Locked tables are not sampled.
+	_, ok = parseTableBloatSample(&model.PGResult{Colnames: res.Colnames})
+	assert.False(t, ok)
+}
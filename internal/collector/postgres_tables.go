@@ -8,11 +8,14 @@ import (
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
-	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
+	// tableFillfactorColumn defines expression for getting table's fillfactor, when fillfactor storage parameter is
+	// not set, the default value is used.
+	tableFillfactorColumn = "COALESCE((SELECT option_value FROM pg_options_to_table(c.reloptions) WHERE option_name = 'fillfactor'), '100') AS fillfactor"
+
 	userTablesQuery = "SELECT current_database() AS database, s1.schemaname AS schema, s1.relname AS table, " +
 		"seq_scan, seq_tup_read, idx_scan, idx_tup_fetch, n_tup_ins, n_tup_upd, n_tup_del, n_tup_hot_upd, " +
 		"n_live_tup, n_dead_tup, n_mod_since_analyze, " +
@@ -22,7 +25,7 @@ const (
 		"EXTRACT(EPOCH FROM GREATEST(last_analyze, last_autoanalyze)) AS last_analyze_time, " +
 		"vacuum_count, autovacuum_count,  analyze_count, autoanalyze_count, heap_blks_read, heap_blks_hit, idx_blks_read, " +
 		"idx_blks_hit, toast_blks_read, toast_blks_hit, tidx_blks_read, tidx_blks_hit, " +
-		"pg_table_size(s1.relid) AS size_bytes, reltuples " +
+		"pg_table_size(s1.relid) AS size_bytes, reltuples, " + tableFillfactorColumn + " " +
 		"FROM pg_stat_user_tables s1 JOIN pg_statio_user_tables s2 USING (schemaname, relname) JOIN pg_class c ON s1.relid = c.oid " +
 		"WHERE NOT EXISTS (SELECT 1 FROM pg_locks WHERE relation = s1.relid AND mode = 'AccessExclusiveLock')"
 
@@ -34,7 +37,7 @@ const (
 		"EXTRACT(EPOCH FROM GREATEST(last_analyze, last_autoanalyze)) AS last_analyze_time, " +
 		"vacuum_count, autovacuum_count, analyze_count, autoanalyze_count, heap_blks_read, heap_blks_hit, idx_blks_read, " +
 		"idx_blks_hit, toast_blks_read, toast_blks_hit, tidx_blks_read, tidx_blks_hit, pg_table_size(s1.relid) AS size_bytes, " +
-		"reltuples, (row_number() OVER (ORDER BY seq_scan DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY seq_tup_read DESC NULLS LAST) < $1) OR " +
+		"reltuples, " + tableFillfactorColumn + ", (row_number() OVER (ORDER BY seq_scan DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY seq_tup_read DESC NULLS LAST) < $1) OR " +
 		"(row_number() OVER (ORDER BY idx_scan DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY idx_tup_fetch DESC NULLS LAST) < $1) OR " +
 		"(row_number() OVER (ORDER BY n_tup_ins DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY n_tup_upd DESC NULLS LAST) < $1) OR " +
 		"(row_number() OVER (ORDER BY n_tup_del DESC NULLS LAST) < $1) OR (row_number() OVER (ORDER BY n_tup_hot_upd DESC NULLS LAST) < $1) OR " +
@@ -49,7 +52,7 @@ const (
 		"SELECT current_database() AS database, schema, \"table\", seq_scan, seq_tup_read, idx_scan, idx_tup_fetch, n_tup_ins, n_tup_upd, n_tup_del, " +
 		"n_tup_hot_upd, n_live_tup, n_dead_tup, n_mod_since_analyze, last_vacuum_seconds, last_analyze_seconds, last_vacuum_time, last_analyze_time, " +
 		"vacuum_count, autovacuum_count, analyze_count, autoanalyze_count, heap_blks_read, heap_blks_hit, idx_blks_read, idx_blks_hit, toast_blks_read, " +
-		"toast_blks_hit, tidx_blks_read, tidx_blks_hit, size_bytes, reltuples FROM stat WHERE visible UNION ALL (SELECT current_database() AS database, " +
+		"toast_blks_hit, tidx_blks_read, tidx_blks_hit, size_bytes, reltuples, fillfactor FROM stat WHERE visible UNION ALL (SELECT current_database() AS database, " +
 		"'all_shemas', 'all_other_tables', NULLIF(SUM(COALESCE(seq_scan,0)),0), NULLIF(SUM(COALESCE(seq_tup_read,0)),0), NULLIF(SUM(COALESCE(idx_scan,0)),0), " +
 		"NULLIF(SUM(COALESCE(idx_tup_fetch,0)),0), NULLIF(SUM(COALESCE(n_tup_ins,0)),0), NULLIF(SUM(COALESCE(n_tup_upd,0)),0), " +
 		"NULLIF(SUM(COALESCE(n_tup_del,0)),0), NULLIF(SUM(COALESCE(n_tup_hot_upd,0)),0), NULLIF(SUM(COALESCE(n_live_tup,0)),0), " +
@@ -58,7 +61,7 @@ const (
 		"NULLIF(SUM(COALESCE(autoanalyze_count,0)),0), NULLIF(SUM(COALESCE(heap_blks_read,0)),0), NULLIF(SUM(COALESCE(heap_blks_hit,0)),0), " +
 		"NULLIF(SUM(COALESCE(idx_blks_read,0)),0), NULLIF(SUM(COALESCE(idx_blks_hit,0)),0), NULLIF(SUM(COALESCE(toast_blks_read,0)),0), " +
 		"NULLIF(SUM(COALESCE(toast_blks_hit,0)),0), NULLIF(SUM(COALESCE(tidx_blks_read,0)),0), NULLIF(SUM(COALESCE(tidx_blks_hit, 0)),0), " +
-		"NULLIF(SUM(COALESCE(size_bytes,0)),0), NULLIF(SUM(COALESCE(reltuples,0)),0) FROM stat " +
+		"NULLIF(SUM(COALESCE(size_bytes,0)),0), NULLIF(SUM(COALESCE(reltuples,0)),0), NULL FROM stat " +
 		"WHERE NOT visible HAVING EXISTS (SELECT 1 FROM stat WHERE NOT visible))"
 )
 
@@ -83,6 +86,9 @@ type postgresTablesCollector struct {
 	io                   typedDesc
 	sizes                typedDesc
 	reltuples            typedDesc
+	hotUpdateRatio       typedDesc
+	seqScanRatio         typedDesc
+	info                 typedDesc
 	labelNames           []string
 }
 
@@ -209,6 +215,24 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 			labels, constLabels,
 			settings.Filters,
 		),
+		hotUpdateRatio: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "hot_update_ratio", "Ratio of HOT updates to all updates of tuples (rows) in the table.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		seqScanRatio: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "seq_scan_ratio", "Ratio of sequential scans to all scans (sequential and index) initiated on the table.", 0},
+			prometheus.GaugeValue,
+			labels, constLabels,
+			settings.Filters,
+		),
+		info: newBuiltinTypedDesc(
+			descOpts{"postgres", "table", "info", "Labeled information about table's storage parameters.", 0},
+			prometheus.GaugeValue,
+			[]string{"database", "schema", "table", "fillfactor"}, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
@@ -216,7 +240,7 @@ func NewPostgresTablesCollector(constLabels labels, settings model.CollectorSett
 func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Metric) error {
 	var err error
 
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -308,6 +332,19 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 
 			ch <- c.sizes.newConstMetric(stat.sizebytes, stat.database, stat.schema, stat.table)
 			ch <- c.reltuples.newConstMetric(stat.reltuples, stat.database, stat.schema, stat.table)
+
+			// ratios -- avoid metrics spam produced by inactive tables, don't send metrics if there were no updates or scans.
+			if stat.updated > 0 {
+				ch <- c.hotUpdateRatio.newConstMetric(stat.hotUpdated/stat.updated, stat.database, stat.schema, stat.table)
+			}
+			if stat.seqscan+stat.idxscan > 0 {
+				ch <- c.seqScanRatio.newConstMetric(stat.seqscan/(stat.seqscan+stat.idxscan), stat.database, stat.schema, stat.table)
+			}
+
+			// fillfactor is not defined for aggregated 'all_other_tables' row.
+			if stat.fillfactor != "" {
+				ch <- c.info.newConstMetric(1, stat.database, stat.schema, stat.table, stat.fillfactor)
+			}
 		}
 		return nil
 	}
@@ -317,12 +354,7 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 		return err
 	}
 
-	databases, err := listDatabases(conn)
-	if err != nil {
-		return err
-	}
-
-	pgconfig, err := pgx.ParseConfig(config.ConnString)
+	databases, err := listDatabases(conn, config.ExcludeDatabasesRE)
 	if err != nil {
 		return err
 	}
@@ -333,13 +365,12 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 			continue
 		}
 
-		pgconfig.Database = d
-		conn, err := store.NewWithConfig(pgconfig)
+		conn, err := config.acquireDatabaseConn(d)
 		if err != nil {
 			return err
 		}
-		defer conn.Close()
 		err = collect(conn)
+		conn.Close()
 		if err != nil {
 			return err
 		}
@@ -348,11 +379,20 @@ func (c *postgresTablesCollector) Update(config Config, ch chan<- prometheus.Met
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresTablesCollector) executedQueries(config Config) []string {
+	if config.CollectTopTable > 0 {
+		return perDatabaseQueries(config, userTablesQueryTopK)
+	}
+	return perDatabaseQueries(config, userTablesQuery)
+}
+
 // postgresTableStat is per-table store for metrics related to how tables are accessed.
 type postgresTableStat struct {
 	database        string
 	schema          string
 	table           string
+	fillfactor      string
 	seqscan         float64
 	seqtupread      float64
 	idxscan         float64
@@ -402,6 +442,8 @@ func parsePostgresTableStats(r *model.PGResult, labelNames []string) map[string]
 				table.schema = row[i].String
 			case "table":
 				table.table = row[i].String
+			case "fillfactor":
+				table.fillfactor = row[i].String
 			}
 		}
 
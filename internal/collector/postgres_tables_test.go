@@ -29,9 +29,12 @@ func TestPostgresTablesCollector_Update(t *testing.T) {
 			"postgres_table_maintenance_total",
 			"postgres_table_size_bytes",
 			"postgres_table_tuples_total",
+			"postgres_table_info",
 		},
 		optional: []string{
 			"postgres_table_io_blocks_total",
+			"postgres_table_hot_update_ratio",
+			"postgres_table_seq_scan_ratio",
 		},
 		collector: NewPostgresTablesCollector,
 		service:   model.ServiceTypePostgresql,
@@ -50,7 +53,7 @@ func Test_parsePostgresTableStats(t *testing.T) {
 			name: "normal output",
 			res: &model.PGResult{
 				Nrows: 1,
-				Ncols: 32,
+				Ncols: 33,
 				Colnames: []pgproto3.FieldDescription{
 					{Name: []byte("database")}, {Name: []byte("schema")}, {Name: []byte("table")},
 					{Name: []byte("seq_scan")}, {Name: []byte("seq_tup_read")}, {Name: []byte("idx_scan")}, {Name: []byte("idx_tup_fetch")},
@@ -60,7 +63,7 @@ func Test_parsePostgresTableStats(t *testing.T) {
 					{Name: []byte("vacuum_count")}, {Name: []byte("autovacuum_count")}, {Name: []byte("analyze_count")}, {Name: []byte("autoanalyze_count")},
 					{Name: []byte("heap_blks_read")}, {Name: []byte("heap_blks_hit")}, {Name: []byte("idx_blks_read")}, {Name: []byte("idx_blks_hit")},
 					{Name: []byte("toast_blks_read")}, {Name: []byte("toast_blks_hit")}, {Name: []byte("tidx_blks_read")}, {Name: []byte("tidx_blks_hit")},
-					{Name: []byte("size_bytes")}, {Name: []byte("reltuples")},
+					{Name: []byte("size_bytes")}, {Name: []byte("reltuples")}, {Name: []byte("fillfactor")},
 				},
 				Rows: [][]sql.NullString{
 					{
@@ -72,13 +75,13 @@ func Test_parsePostgresTableStats(t *testing.T) {
 						{String: "910", Valid: true}, {String: "920", Valid: true}, {String: "930", Valid: true}, {String: "940", Valid: true},
 						{String: "4528", Valid: true}, {String: "5845", Valid: true}, {String: "458", Valid: true}, {String: "698", Valid: true},
 						{String: "125", Valid: true}, {String: "825", Valid: true}, {String: "699", Valid: true}, {String: "375", Valid: true},
-						{String: "458523", Valid: true}, {String: "50000", Valid: true},
+						{String: "458523", Valid: true}, {String: "50000", Valid: true}, {String: "90", Valid: true},
 					},
 				},
 			},
 			want: map[string]postgresTableStat{
 				"testdb/testschema/testrelname": {
-					database: "testdb", schema: "testschema", table: "testrelname",
+					database: "testdb", schema: "testschema", table: "testrelname", fillfactor: "90",
 					seqscan: 100, seqtupread: 1000, idxscan: 200, idxtupfetch: 2000,
 					inserted: 300, updated: 400, deleted: 500, hotUpdated: 150, live: 600, dead: 100, modified: 500,
 					lastvacuumAge: 700, lastanalyzeAge: 800, lastvacuumTime: 12345678, lastanalyzeTime: 87654321, vacuum: 910, autovacuum: 920, analyze: 930, autoanalyze: 940,
@@ -0,0 +1,251 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// postgresVacuumWorkersQuery16 defines query for querying activity of running vacuum workers for Postgres 16 and older.
+	// Relation names could be resolved only for current database, for other databases relation OID is used.
+	postgresVacuumWorkersQuery16 = "SELECT a.datname AS database, " +
+		"CASE WHEN a.datname = current_database() THEN p.relid::regclass::text ELSE p.relid::text END AS relation, " +
+		"p.phase, CASE WHEN a.query LIKE 'autovacuum:%(to prevent wraparound)' THEN 'wraparound' " +
+		"WHEN a.backend_type = 'autovacuum worker' THEN 'regular' ELSE 'user' END AS type, " +
+		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - coalesce(a.xact_start, a.query_start)), 0) AS duration_seconds, " +
+		"p.heap_blks_total, p.heap_blks_scanned, p.heap_blks_vacuumed, p.index_vacuum_count, p.num_dead_tuples AS dead_tuples " +
+		"FROM pg_stat_progress_vacuum p JOIN pg_stat_activity a ON a.pid = p.pid"
+
+	// postgresVacuumWorkersQueryLatest defines query for querying activity of running vacuum workers.
+	postgresVacuumWorkersQueryLatest = "SELECT a.datname AS database, " +
+		"CASE WHEN a.datname = current_database() THEN p.relid::regclass::text ELSE p.relid::text END AS relation, " +
+		"p.phase, CASE WHEN a.query LIKE 'autovacuum:%(to prevent wraparound)' THEN 'wraparound' " +
+		"WHEN a.backend_type = 'autovacuum worker' THEN 'regular' ELSE 'user' END AS type, " +
+		"COALESCE(EXTRACT(EPOCH FROM clock_timestamp() - coalesce(a.xact_start, a.query_start)), 0) AS duration_seconds, " +
+		"p.heap_blks_total, p.heap_blks_scanned, p.heap_blks_vacuumed, p.index_vacuum_count, p.num_dead_item_ids AS dead_tuples " +
+		"FROM pg_stat_progress_vacuum p JOIN pg_stat_activity a ON a.pid = p.pid"
+
+	// postgresVacuumCostSettingsQuery defines query for querying settings which define vacuum throttling.
+	postgresVacuumCostSettingsQuery = "SELECT name, setting FROM pg_settings WHERE name IN ('autovacuum_max_workers', " +
+		"'autovacuum_vacuum_cost_delay', 'autovacuum_vacuum_cost_limit', 'vacuum_cost_delay', 'vacuum_cost_limit', " +
+		"'vacuum_cost_page_dirty', 'vacuum_cost_page_hit', 'vacuum_cost_page_miss')"
+)
+
+// postgresVacuumCollector defines metric descriptors.
+type postgresVacuumCollector struct {
+	duration      typedDesc
+	heapTotal     typedDesc
+	heapScanned   typedDesc
+	heapVacuumed  typedDesc
+	indexVacuumed typedDesc
+	deadTuples    typedDesc
+	costSettings  typedDesc
+}
+
+// NewPostgresVacuumCollector returns a new Collector exposing activity of running vacuum workers and effective vacuum
+// cost settings. For details see https://www.postgresql.org/docs/current/progress-reporting.html#VACUUM-PROGRESS-REPORTING
+func NewPostgresVacuumCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	var labelNames = []string{"database", "relation", "phase", "type"}
+
+	return &postgresVacuumCollector{
+		duration: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum_worker", "duration_seconds", "Duration of running vacuum, in seconds.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		heapTotal: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum_worker", "heap_blks_total", "Total number of heap blocks in the relation being vacuumed.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		heapScanned: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum_worker", "heap_blks_scanned", "Number of heap blocks scanned by running vacuum.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		heapVacuumed: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum_worker", "heap_blks_vacuumed", "Number of heap blocks vacuumed by running vacuum.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		indexVacuumed: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum_worker", "index_vacuum_count", "Number of completed index vacuum cycles by running vacuum.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		deadTuples: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum_worker", "dead_tuples", "Number of dead tuples collected by running vacuum since the last index vacuum cycle.", 0},
+			prometheus.GaugeValue,
+			labelNames, constLabels,
+			settings.Filters,
+		),
+		costSettings: newBuiltinTypedDesc(
+			descOpts{"postgres", "vacuum", "cost_settings_info", "Labeled information about effective vacuum cost-based delay settings.", 0},
+			prometheus.GaugeValue,
+			[]string{"name", "setting"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
+func (c *postgresVacuumCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(selectVacuumWorkersQuery(config.pgVersion.Numeric))
+	if err != nil {
+		return err
+	}
+
+	for _, w := range parsePostgresVacuumWorkers(res) {
+		ch <- c.duration.newConstMetric(w.duration, w.database, w.relation, w.phase, w.vacuumType)
+		ch <- c.heapTotal.newConstMetric(w.heapBlksTotal, w.database, w.relation, w.phase, w.vacuumType)
+		ch <- c.heapScanned.newConstMetric(w.heapBlksScanned, w.database, w.relation, w.phase, w.vacuumType)
+		ch <- c.heapVacuumed.newConstMetric(w.heapBlksVacuumed, w.database, w.relation, w.phase, w.vacuumType)
+		ch <- c.indexVacuumed.newConstMetric(w.indexVacuumCount, w.database, w.relation, w.phase, w.vacuumType)
+		ch <- c.deadTuples.newConstMetric(w.deadTuples, w.database, w.relation, w.phase, w.vacuumType)
+	}
+
+	res, err = conn.Query(postgresVacuumCostSettingsQuery)
+	if err != nil {
+		log.Warnf("get vacuum cost settings failed: %s; skip", err)
+		return nil
+	}
+
+	for name, setting := range parsePostgresVacuumCostSettings(res) {
+		ch <- c.costSettings.newConstMetric(1, name, setting)
+	}
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresVacuumCollector) executedQueries(config Config) []string {
+	return []string{selectVacuumWorkersQuery(config.pgVersion.Numeric), postgresVacuumCostSettingsQuery}
+}
+
+// postgresVacuumWorker represents activity of running vacuum worker based on pg_stat_progress_vacuum.
+type postgresVacuumWorker struct {
+	database         string
+	relation         string
+	phase            string
+	vacuumType       string
+	duration         float64
+	heapBlksTotal    float64
+	heapBlksScanned  float64
+	heapBlksVacuumed float64
+	indexVacuumCount float64
+	deadTuples       float64
+}
+
+// parsePostgresVacuumWorkers parses PGResult and returns structs with running vacuum workers activity.
+func parsePostgresVacuumWorkers(r *model.PGResult) []postgresVacuumWorker {
+	log.Debug("parse postgres vacuum workers activity")
+
+	var workers []postgresVacuumWorker
+
+	for _, row := range r.Rows {
+		var w postgresVacuumWorker
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				w.database = row[i].String
+			case "relation":
+				w.relation = row[i].String
+			case "phase":
+				w.phase = row[i].String
+			case "type":
+				w.vacuumType = row[i].String
+			default:
+				// Skip empty (NULL) values.
+				if !row[i].Valid {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				switch string(colname.Name) {
+				case "duration_seconds":
+					w.duration = v
+				case "heap_blks_total":
+					w.heapBlksTotal = v
+				case "heap_blks_scanned":
+					w.heapBlksScanned = v
+				case "heap_blks_vacuumed":
+					w.heapBlksVacuumed = v
+				case "index_vacuum_count":
+					w.indexVacuumCount = v
+				case "dead_tuples":
+					w.deadTuples = v
+				}
+			}
+		}
+
+		workers = append(workers, w)
+	}
+
+	return workers
+}
+
+// parsePostgresVacuumCostSettings parses PGResult and returns vacuum cost settings. Autovacuum cost settings set
+// to -1 are replaced with values of regular vacuum settings, the same way Postgres does.
+func parsePostgresVacuumCostSettings(r *model.PGResult) map[string]string {
+	log.Debug("parse postgres vacuum cost settings")
+
+	var settings = make(map[string]string)
+
+	for _, row := range r.Rows {
+		var name, setting string
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "name":
+				name = row[i].String
+			case "setting":
+				setting = row[i].String
+			}
+		}
+
+		settings[name] = setting
+	}
+
+	for autovacuum, vacuum := range map[string]string{
+		"autovacuum_vacuum_cost_delay": "vacuum_cost_delay",
+		"autovacuum_vacuum_cost_limit": "vacuum_cost_limit",
+	} {
+		if settings[autovacuum] == "-1" {
+			if v, ok := settings[vacuum]; ok {
+				settings[autovacuum] = v
+			}
+		}
+	}
+
+	return settings
+}
+
+// selectVacuumWorkersQuery returns suitable vacuum workers query depending on passed version.
+func selectVacuumWorkersQuery(version int) string {
+	switch {
+	case version < PostgresV17:
+		return postgresVacuumWorkersQuery16
+	default:
+		return postgresVacuumWorkersQueryLatest
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresVacuumCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"postgres_vacuum_cost_settings_info",
+		},
+		optional: []string{
+			"postgres_vacuum_worker_duration_seconds",
+			"postgres_vacuum_worker_heap_blks_total",
+			"postgres_vacuum_worker_heap_blks_scanned",
+			"postgres_vacuum_worker_heap_blks_vacuumed",
+			"postgres_vacuum_worker_index_vacuum_count",
+			"postgres_vacuum_worker_dead_tuples",
+		},
+		collector: NewPostgresVacuumCollector,
+		service:   model.ServiceTypePostgresql,
+	}
+
+	pipeline(t, input)
+}
+
+func Test_parsePostgresVacuumWorkers(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 10,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("relation")}, {Name: []byte("phase")}, {Name: []byte("type")},
+			{Name: []byte("duration_seconds")}, {Name: []byte("heap_blks_total")}, {Name: []byte("heap_blks_scanned")},
+			{Name: []byte("heap_blks_vacuumed")}, {Name: []byte("index_vacuum_count")}, {Name: []byte("dead_tuples")},
+		},
+		Rows: [][]sql.NullString{
+			{
+				{String: "testdb", Valid: true}, {String: "public.orders", Valid: true}, {String: "vacuuming indexes", Valid: true},
+				{String: "regular", Valid: true}, {String: "125.5", Valid: true}, {String: "10000", Valid: true},
+				{String: "4500", Valid: true}, {String: "3000", Valid: true}, {String: "1", Valid: true}, {String: "75000", Valid: true},
+			},
+			{
+				{String: "otherdb", Valid: true}, {String: "16384", Valid: true}, {String: "scanning heap", Valid: true},
+				{String: "wraparound", Valid: true}, {String: "3600", Valid: true}, {String: "200", Valid: true},
+				{String: "10", Valid: true}, {String: "0", Valid: true}, {String: "0", Valid: true}, {String: "", Valid: false},
+			},
+		},
+	}
+
+	want := []postgresVacuumWorker{
+		{
+			database: "testdb", relation: "public.orders", phase: "vacuuming indexes", vacuumType: "regular", duration: 125.5,
+			heapBlksTotal: 10000, heapBlksScanned: 4500, heapBlksVacuumed: 3000, indexVacuumCount: 1, deadTuples: 75000,
+		},
+		{
+			database: "otherdb", relation: "16384", phase: "scanning heap", vacuumType: "wraparound", duration: 3600,
+			heapBlksTotal: 200, heapBlksScanned: 10,
+		},
+	}
+
+	assert.Equal(t, want, parsePostgresVacuumWorkers(res))
+}
+
+func Test_parsePostgresVacuumCostSettings(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 4,
+		Ncols: 2,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("name")}, {Name: []byte("setting")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "autovacuum_vacuum_cost_delay", Valid: true}, {String: "2", Valid: true}},
+			{{String: "autovacuum_vacuum_cost_limit", Valid: true}, {String: "-1", Valid: true}},
+			{{String: "vacuum_cost_delay", Valid: true}, {String: "0", Valid: true}},
+			{{String: "vacuum_cost_limit", Valid: true}, {String: "200", Valid: true}},
+		},
+	}
+
+	want := map[string]string{
+		"autovacuum_vacuum_cost_delay": "2",
+		"autovacuum_vacuum_cost_limit": "200",
+		"vacuum_cost_delay":            "0",
+		"vacuum_cost_limit":            "200",
+	}
+
+	assert.Equal(t, want, parsePostgresVacuumCostSettings(res))
+}
+
+func Test_selectVacuumWorkersQuery(t *testing.T) {
+	assert.Equal(t, postgresVacuumWorkersQuery16, selectVacuumWorkersQuery(PostgresV16))
+	assert.Equal(t, postgresVacuumWorkersQueryLatest, selectVacuumWorkersQuery(PostgresV17))
+}
@@ -2,11 +2,12 @@
 package collector
 
 import (
+	"database/sql"
+	"regexp"
 	"strconv"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
-	"github.com/cherts/pgscv/internal/store"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -23,20 +24,42 @@ const (
 		"(CASE pg_is_in_recovery() WHEN 'f' THEN FALSE::int ELSE pg_is_wal_replay_paused()::int END) AS recovery_paused, " +
 		"wal_records, wal_fpi, " +
 		"(CASE pg_is_in_recovery() WHEN 't' THEN pg_last_wal_receive_lsn() - '0/00000000' ELSE pg_current_wal_lsn() - '0/00000000' END) AS wal_written, " +
-		"wal_bytes, wal_buffers_full, wal_write, wal_sync, wal_write_time, wal_sync_time, extract('epoch' from stats_reset) as reset_time " +
+		"wal_bytes, wal_buffers_full, wal_write, wal_sync, wal_write_time, wal_sync_time, extract('epoch' from stats_reset) as reset_time, " +
+		"extract('epoch' from COALESCE(stats_reset, pg_postmaster_start_time())) AS stats_reset_unixtime " +
 		"FROM pg_stat_wal"
 
+	// Since Postgres 18 WAL write and sync stats have been moved from pg_stat_wal to pg_stat_io.
 	postgresWalQueryLatest = "SELECT pg_is_in_recovery()::int AS recovery, " +
 		"(CASE pg_is_in_recovery() WHEN 'f' THEN FALSE::int ELSE pg_is_wal_replay_paused()::int END) AS recovery_paused, " +
 		"wal_records, wal_fpi, " +
 		"(CASE pg_is_in_recovery() WHEN 't' THEN pg_last_wal_receive_lsn() - '0/00000000' ELSE pg_current_wal_lsn() - '0/00000000' END) AS wal_written, " +
-		"wal_bytes, wal_buffers_full, extract('epoch' from stats_reset) as reset_time " +
-		"FROM pg_stat_wal"
+		"wal_bytes, wal_buffers_full, io.wal_write, io.wal_sync, io.wal_write_time, io.wal_sync_time, extract('epoch' from stats_reset) as reset_time, " +
+		"extract('epoch' from COALESCE(stats_reset, pg_postmaster_start_time())) AS stats_reset_unixtime " +
+		"FROM pg_stat_wal, (SELECT sum(writes) AS wal_write, sum(fsyncs) AS wal_sync, sum(write_time) AS wal_write_time, sum(fsync_time) AS wal_sync_time " +
+		"FROM pg_stat_io WHERE object = 'wal') io"
+
+	// postgresRecoveryReplayQuery defines query for WAL replay stats, used on standbys only. Delay of WAL replay is
+	// available as a setting since Postgres 12, on older versions it is configured in recovery.conf and not visible.
+	postgresRecoveryReplayQuery = "SELECT extract(epoch FROM clock_timestamp() - pg_last_xact_replay_timestamp()) AS since_last_replay_seconds, " +
+		"(SELECT setting::float8 / 1000 FROM pg_settings WHERE name = 'recovery_min_apply_delay') AS min_apply_delay_seconds"
+
+	// postgresWalReceiverQuery defines query for WAL receiver stats, used on standbys only (Postgres 13 and newer).
+	postgresWalReceiverQuery = "SELECT status, COALESCE(slot_name, '') AS slot_name, COALESCE(sender_host, '') AS sender_host, " +
+		"COALESCE(sender_port::text, '') AS sender_port, COALESCE(conninfo, '') AS conninfo, " +
+		"flushed_lsn - '0/00000000' AS received_bytes, " +
+		"greatest(flushed_lsn - pg_last_wal_replay_lsn(), 0) AS replay_lag_bytes, " +
+		"extract(epoch FROM clock_timestamp() - last_msg_receipt_time) AS last_msg_age_seconds " +
+		"FROM pg_stat_wal_receiver"
 )
 
+// reConninfoSslmode defines regexp for extracting sslmode from WAL receiver conninfo.
+var reConninfoSslmode = regexp.MustCompile(`(?:^|\s)sslmode=(\S+)`)
+
 type postgresWalCollector struct {
 	recovery       typedDesc
 	recoveryPaused typedDesc
+	sinceReplay    typedDesc
+	applyDelay     typedDesc
 	records        typedDesc
 	fpi            typedDesc
 	bytes          typedDesc
@@ -47,6 +70,10 @@ type postgresWalCollector struct {
 	secondsAll     typedDesc
 	seconds        typedDesc
 	resetUnix      typedDesc
+	receiverInfo   typedDesc
+	receivedBytes  typedDesc
+	replayLag      typedDesc
+	lastMsgAge     typedDesc
 }
 
 // NewPostgresWalCollector returns a new Collector exposing postgres WAL stats.
@@ -65,6 +92,18 @@ func NewPostgresWalCollector(constLabels labels, settings model.CollectorSetting
 			nil, constLabels,
 			settings.Filters,
 		),
+		sinceReplay: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery", "since_last_replay_seconds", "Time elapsed since last transaction replayed by standby, grows when primary is idle, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		applyDelay: newBuiltinTypedDesc(
+			descOpts{"postgres", "recovery", "min_apply_delay_seconds", "Delay of applying WAL on standby configured by recovery_min_apply_delay, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 		records: newBuiltinTypedDesc(
 			descOpts{"postgres", "wal", "records_total", "Total number of WAL records generated (zero in case of standby).", 0},
 			prometheus.CounterValue,
@@ -125,12 +164,36 @@ func NewPostgresWalCollector(constLabels labels, settings model.CollectorSetting
 			nil, constLabels,
 			settings.Filters,
 		),
+		receiverInfo: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "info", "Labeled information about WAL receiver connection to upstream server.", 0},
+			prometheus.GaugeValue,
+			[]string{"status", "slot_name", "sender_host", "sender_port", "sslmode"}, constLabels,
+			settings.Filters,
+		),
+		receivedBytes: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "received_bytes_total", "Total amount of WAL received and flushed to disk by WAL receiver since cluster init, in bytes.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		replayLag: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "replay_lag_bytes", "Amount of WAL received by WAL receiver but not replayed yet, in bytes.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		lastMsgAge: newBuiltinTypedDesc(
+			descOpts{"postgres", "wal_receiver", "last_msg_age_seconds", "Time elapsed since last message received from upstream server, in seconds.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
 	}, nil
 }
 
 // Update method collects statistics, parse it and produces metrics that are sent to Prometheus.
 func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric) error {
-	conn, err := store.New(config.ConnString, config.ConnTimeout)
+	conn, err := config.connect()
 	if err != nil {
 		return err
 	}
@@ -144,6 +207,8 @@ func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric
 
 	stats := parsePostgresWalStats(res)
 
+	created := statsCreatedTime(stats["stats_reset_unixtime"])
+
 	for k, v := range stats {
 		switch k {
 		case "recovery":
@@ -151,25 +216,26 @@ func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric
 		case "recovery_paused":
 			ch <- c.recoveryPaused.newConstMetric(v)
 		case "wal_records":
-			ch <- c.records.newConstMetric(v)
+			ch <- c.records.newConstMetricWithCreated(v, created)
 		case "wal_fpi":
-			ch <- c.fpi.newConstMetric(v)
+			ch <- c.fpi.newConstMetricWithCreated(v, created)
 		case "wal_bytes":
-			ch <- c.bytes.newConstMetric(v)
+			ch <- c.bytes.newConstMetricWithCreated(v, created)
 		case "wal_written":
 			ch <- c.writtenBytes.newConstMetric(v)
+			config.facts.publish(factWalWrittenBytes, v, nil)
 		case "wal_buffers_full":
-			ch <- c.buffersFull.newConstMetric(v)
+			ch <- c.buffersFull.newConstMetricWithCreated(v, created)
 		case "wal_write":
-			ch <- c.writes.newConstMetric(v)
+			ch <- c.writes.newConstMetricWithCreated(v, created)
 		case "wal_sync":
-			ch <- c.syncs.newConstMetric(v)
+			ch <- c.syncs.newConstMetricWithCreated(v, created)
 		case "wal_write_time":
-			ch <- c.seconds.newConstMetric(v, "write")
+			ch <- c.seconds.newConstMetricWithCreated(v, created, "write")
 		case "wal_sync_time":
-			ch <- c.seconds.newConstMetric(v, "sync")
+			ch <- c.seconds.newConstMetricWithCreated(v, created, "sync")
 		case "wal_all_time":
-			ch <- c.secondsAll.newConstMetric(v)
+			ch <- c.secondsAll.newConstMetricWithCreated(v, created)
 		case "reset_time":
 			ch <- c.resetUnix.newConstMetric(v)
 		default:
@@ -177,9 +243,61 @@ func (c *postgresWalCollector) Update(config Config, ch chan<- prometheus.Metric
 		}
 	}
 
+	// WAL replay and WAL receiver are running on standbys only.
+	if stats["recovery"] != 1 {
+		return nil
+	}
+
+	res, err = conn.Query(postgresRecoveryReplayQuery)
+	if err != nil {
+		log.Warnf("get WAL replay stats failed: %s; skip", err)
+	} else {
+		replay := parsePostgresWalStats(res)
+		if v, ok := replay["since_last_replay_seconds"]; ok {
+			ch <- c.sinceReplay.newConstMetric(v)
+		}
+		if v, ok := replay["min_apply_delay_seconds"]; ok {
+			ch <- c.applyDelay.newConstMetric(v)
+		}
+	}
+
+	if config.pgVersion.Numeric < PostgresV13 {
+		return nil
+	}
+
+	res, err = conn.Query(postgresWalReceiverQuery)
+	if err != nil {
+		log.Warnf("get WAL receiver stats failed: %s; skip", err)
+		return nil
+	}
+
+	for _, stat := range parsePostgresWalReceiverStats(res) {
+		ch <- c.receiverInfo.newConstMetric(1, stat.status, stat.slotName, stat.senderHost, stat.senderPort, stat.sslmode)
+
+		if stat.receivedBytes.Valid {
+			ch <- c.receivedBytes.newConstMetric(stat.receivedBytes.Float64)
+		}
+		if stat.replayLag.Valid {
+			ch <- c.replayLag.newConstMetric(stat.replayLag.Float64)
+		}
+		if stat.lastMsgAge.Valid {
+			ch <- c.lastMsgAge.newConstMetric(stat.lastMsgAge.Float64)
+		}
+	}
+
 	return nil
 }
 
+// executedQueries implements queriesCollector interface.
+func (c *postgresWalCollector) executedQueries(config Config) []string {
+	// WAL replay and WAL receiver are queried on standbys only.
+	queries := []string{selectWalQuery(config.pgVersion.Numeric), postgresRecoveryReplayQuery}
+	if config.pgVersion.Numeric >= PostgresV13 {
+		queries = append(queries, postgresWalReceiverQuery)
+	}
+	return queries
+}
+
 // parsePostgresWalStats parses PGResult and returns struct with data values
 func parsePostgresWalStats(r *model.PGResult) map[string]float64 {
 	log.Debug("parse postgres WAL stats")
@@ -215,6 +333,77 @@ func parsePostgresWalStats(r *model.PGResult) map[string]float64 {
 	return stats
 }
 
+// postgresWalReceiverStat represents WAL receiver stats. Numeric values are not visible for unprivileged users.
+type postgresWalReceiverStat struct {
+	status        string
+	slotName      string
+	senderHost    string
+	senderPort    string
+	sslmode       string
+	receivedBytes sql.NullFloat64
+	replayLag     sql.NullFloat64
+	lastMsgAge    sql.NullFloat64
+}
+
+// parsePostgresWalReceiverStats parses PGResult and returns structs with WAL receiver stats.
+func parsePostgresWalReceiverStats(r *model.PGResult) []postgresWalReceiverStat {
+	log.Debug("parse postgres WAL receiver stats")
+
+	var stats []postgresWalReceiverStat
+
+	for _, row := range r.Rows {
+		var stat postgresWalReceiverStat
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "status":
+				stat.status = row[i].String
+			case "slot_name":
+				stat.slotName = row[i].String
+			case "sender_host":
+				stat.senderHost = row[i].String
+			case "sender_port":
+				stat.senderPort = row[i].String
+			case "conninfo":
+				stat.sslmode = parseConninfoSslmode(row[i].String)
+			case "received_bytes", "replay_lag_bytes", "last_msg_age_seconds":
+				// Skip empty (NULL) values.
+				if !row[i].Valid {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(row[i].String, 64)
+				if err != nil {
+					log.Errorf("invalid input, parse '%s' failed: %s; skip", row[i].String, err)
+					continue
+				}
+
+				value := sql.NullFloat64{Float64: v, Valid: true}
+				switch string(colname.Name) {
+				case "received_bytes":
+					stat.receivedBytes = value
+				case "replay_lag_bytes":
+					stat.replayLag = value
+				case "last_msg_age_seconds":
+					stat.lastMsgAge = value
+				}
+			}
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+// parseConninfoSslmode returns sslmode used in connection string, libpq's default is returned when it's not specified.
+func parseConninfoSslmode(conninfo string) string {
+	if m := reConninfoSslmode.FindStringSubmatch(conninfo); len(m) == 2 {
+		return m[1]
+	}
+	return "prefer"
+}
+
 // selectWalQuery returns suitable wal state query depending on passed version.
 func selectWalQuery(version int) string {
 	switch {
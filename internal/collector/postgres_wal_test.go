@@ -26,6 +26,12 @@ func TestPostgresWalCollector_Update(t *testing.T) {
 			"postgres_wal_sync_total",
 			"postgres_wal_seconds_total",
 			"postgres_wal_seconds_all_total",
+			"postgres_recovery_since_last_replay_seconds",
+			"postgres_recovery_min_apply_delay_seconds",
+			"postgres_wal_receiver_info",
+			"postgres_wal_receiver_received_bytes_total",
+			"postgres_wal_receiver_replay_lag_bytes",
+			"postgres_wal_receiver_last_msg_age_seconds",
 		},
 		collector: NewPostgresWalCollector,
 		service:   model.ServiceTypePostgresql,
@@ -103,6 +109,18 @@ func Test_parsePostgresWalStats(t *testing.T) {
 			},
 			want: map[string]float64{"recovery": 0, "recovery_paused": 0, "wal_written": 123456789},
 		},
+		{
+			name: "replay stats",
+			res: &model.PGResult{
+				Nrows: 1,
+				Ncols: 2,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("since_last_replay_seconds")}, {Name: []byte("min_apply_delay_seconds")},
+				},
+				Rows: [][]sql.NullString{{{String: "12.5", Valid: true}, {}}},
+			},
+			want: map[string]float64{"since_last_replay_seconds": 12.5},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -113,6 +131,52 @@ func Test_parsePostgresWalStats(t *testing.T) {
 	}
 }
 
+func Test_parsePostgresWalReceiverStats(t *testing.T) {
+	var testCases = []struct {
+		name string
+		res  *model.PGResult
+		want []postgresWalReceiverStat
+	}{
+		{
+			name: "normal output",
+			res: &model.PGResult{
+				Nrows: 2,
+				Ncols: 8,
+				Colnames: []pgproto3.FieldDescription{
+					{Name: []byte("status")}, {Name: []byte("slot_name")}, {Name: []byte("sender_host")}, {Name: []byte("sender_port")},
+					{Name: []byte("conninfo")}, {Name: []byte("received_bytes")}, {Name: []byte("replay_lag_bytes")}, {Name: []byte("last_msg_age_seconds")},
+				},
+				Rows: [][]sql.NullString{
+					{
+						{String: "streaming", Valid: true}, {String: "standby1", Valid: true}, {String: "10.0.0.1", Valid: true}, {String: "5432", Valid: true},
+						{String: "user=replica password=******** host=10.0.0.1 port=5432 sslmode=verify-full", Valid: true},
+						{String: "123456789", Valid: true}, {String: "4096", Valid: true}, {String: "0.512", Valid: true},
+					},
+					{
+						{String: "streaming", Valid: true}, {String: "", Valid: true}, {String: "", Valid: true}, {String: "", Valid: true},
+						{String: "", Valid: true}, {}, {}, {},
+					},
+				},
+			},
+			want: []postgresWalReceiverStat{
+				{
+					status: "streaming", slotName: "standby1", senderHost: "10.0.0.1", senderPort: "5432", sslmode: "verify-full",
+					receivedBytes: sql.NullFloat64{Float64: 123456789, Valid: true},
+					replayLag:     sql.NullFloat64{Float64: 4096, Valid: true},
+					lastMsgAge:    sql.NullFloat64{Float64: 0.512, Valid: true},
+				},
+				{status: "streaming", sslmode: "prefer"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, parsePostgresWalReceiverStats(tc.res))
+		})
+	}
+}
+
 func Test_selectWalQuery(t *testing.T) {
 	var testcases = []struct {
 		version int
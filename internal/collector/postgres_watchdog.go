@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresWatchdogQuery defines query which cancels pgSCV own queries running longer than passed number of seconds.
+// Own backends are recognized by user and application_name of the watchdog connection.
+const postgresWatchdogQuery = "SELECT pid, EXTRACT(EPOCH FROM clock_timestamp() - query_start) AS age_seconds, " +
+	"pg_cancel_backend(pid) AS cancelled FROM pg_stat_activity " +
+	"WHERE usename = current_user AND application_name = current_setting('application_name') " +
+	"AND pid <> pg_backend_pid() AND state = 'active' AND clock_timestamp() - query_start > make_interval(secs => $1)"
+
+// postgresWatchdogCollector defines metric descriptors and number of cancelled queries.
+type postgresWatchdogCollector struct {
+	cancelled typedDesc
+	total     float64
+	mu        sync.Mutex
+}
+
+// NewPostgresWatchdogCollector returns a new Collector which cancels pgSCV own queries running longer than configured
+// watchdog_max_query_age, e.g. queries of collectors stuck behind locks held by DDL.
+func NewPostgresWatchdogCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &postgresWatchdogCollector{
+		cancelled: newBuiltinTypedDesc(
+			descOpts{"pgscv", "watchdog", "cancelled_queries_total", "Total number of pgSCV queries cancelled due to exceeded max query age.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method cancels long-running pgSCV queries and sends metrics to Prometheus.
+func (c *postgresWatchdogCollector) Update(config Config, ch chan<- prometheus.Metric) error {
+	if config.WatchdogMaxQueryAge <= 0 {
+		return nil
+	}
+
+	conn, err := config.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(postgresWatchdogQuery, config.WatchdogMaxQueryAge.Seconds())
+	if err != nil {
+		return err
+	}
+
+	cancelled := parsePostgresWatchdogStats(res)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total += cancelled
+	ch <- c.cancelled.newConstMetric(c.total)
+
+	return nil
+}
+
+// executedQueries implements queriesCollector interface.
+func (c *postgresWatchdogCollector) executedQueries(config Config) []string {
+	if config.WatchdogMaxQueryAge <= 0 {
+		return nil
+	}
+	return []string{postgresWatchdogQuery}
+}
+
+// parsePostgresWatchdogStats parses PGResult and returns number of cancelled queries.
+func parsePostgresWatchdogStats(r *model.PGResult) float64 {
+	log.Debug("parse postgres watchdog stats")
+
+	var cancelled float64
+
+	for _, row := range r.Rows {
+		var pid, age, ok string
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "pid":
+				pid = row[i].String
+			case "age_seconds":
+				age = row[i].String
+			case "cancelled":
+				ok = row[i].String
+			}
+		}
+
+		if v, err := strconv.ParseBool(ok); err != nil || !v {
+			log.Warnf("[postgres watchdog collector]: cancel query of backend %s running %s seconds failed; skip", pid, age)
+			continue
+		}
+
+		log.Warnf("[postgres watchdog collector]: query of backend %s running %s seconds cancelled", pid, age)
+		cancelled++
+	}
+
+	return cancelled
+}
@@ -0,0 +1,28 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parsePostgresWatchdogStats(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 3,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("pid")}, {Name: []byte("age_seconds")}, {Name: []byte("cancelled")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "1234", Valid: true}, {String: "320.5", Valid: true}, {String: "true", Valid: true}},
+			{{String: "1235", Valid: true}, {String: "310.1", Valid: true}, {String: "false", Valid: true}},
+			{{String: "1236", Valid: true}, {String: "305.9", Valid: true}, {String: "true", Valid: true}},
+		},
+	}
+
+	assert.Equal(t, float64(2), parsePostgresWatchdogStats(res))
+	assert.Equal(t, float64(0), parsePostgresWatchdogStats(&model.PGResult{}))
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+)
+
+// PrivilegesSQL connects to Postgres, detects its version and installed extensions and returns statements which create
+// role for pgSCV and grant privileges required by Postgres collectors, except disabled ones. Comments are returned as
+// separate statements starting with '--'.
+func PrivilegesSQL(connString string, connTimeout int, role, password string, disabledCollectors []string) ([]string, error) {
+	config, err := newPostgresServiceConfig(connString, connTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	factories := Factories{}
+	factories.RegisterPostgresCollectors(disabledCollectors)
+
+	collectors := make([]string, 0, len(factories))
+	for name := range factories {
+		collectors = append(collectors, name)
+	}
+	slices.Sort(collectors)
+
+	return privilegesStatements(role, password, config.pgVersion, config.pgStatStatements, collectors), nil
+}
+
+// ApplyPrivileges connects to Postgres and executes passed statements, comments are skipped. Connection must have
+// enough privileges for creating roles and granting privileges, e.g. superuser.
+func ApplyPrivileges(connString string, connTimeout int, statements []string) error {
+	conn, err := store.New(connString, connTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, s := range statements {
+		if strings.HasPrefix(s, "--") {
+			continue
+		}
+		if _, err := conn.Conn().Exec(context.Background(), s); err != nil {
+			return fmt.Errorf("execute '%s' failed: %w", s, err)
+		}
+	}
+
+	return nil
+}
+
+// privilegesStatements returns statements creating role and granting privileges required by passed collectors on
+// Postgres of passed version.
+func privilegesStatements(role, password string, version PostgresVersion, pgStatStatements bool, collectors []string) []string {
+	ident := pgx.Identifier{role}.Sanitize()
+	literal := "'" + strings.ReplaceAll(role, "'", "''") + "'"
+
+	statements := []string{
+		fmt.Sprintf("-- pgSCV privileges for Postgres %s, enabled collectors: %d", version.Short, len(collectors)),
+		fmt.Sprintf("DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = %s) THEN "+
+			"CREATE ROLE %s WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE; END IF; END $$", literal, ident),
+	}
+
+	if password != "" {
+		statements = append(statements, fmt.Sprintf("ALTER ROLE %s WITH PASSWORD '%s'", ident, strings.ReplaceAll(password, "'", "''")))
+	}
+
+	if version.Numeric < PostgresV10 {
+		statements = append(statements, "-- pg_monitor role is not available before Postgres 10, activity and statements of other roles are visible to superusers only")
+	} else {
+		statements = append(statements, fmt.Sprintf("GRANT pg_monitor TO %s", ident))
+	}
+
+	if slices.Contains(collectors, "postgres/logs") && version.Numeric >= PostgresV10 {
+		statements = append(statements, fmt.Sprintf("GRANT EXECUTE ON FUNCTION pg_current_logfile() TO %s", ident))
+	}
+
+	// Directory listing functions are granted to pg_monitor, grant them explicitly to keep storage metrics working
+	// when pg_monitor membership is revoked.
+	if slices.Contains(collectors, "postgres/storage") && version.Numeric >= PostgresV10 {
+		statements = append(statements,
+			fmt.Sprintf("GRANT EXECUTE ON FUNCTION pg_ls_waldir() TO %s", ident),
+			fmt.Sprintf("GRANT EXECUTE ON FUNCTION pg_ls_logdir() TO %s", ident),
+		)
+		if version.Numeric >= PostgresV12 {
+			statements = append(statements, fmt.Sprintf("GRANT EXECUTE ON FUNCTION pg_ls_tmpdir(oid) TO %s", ident))
+		}
+	}
+
+	if slices.Contains(collectors, "postgres/statements") && !pgStatStatements {
+		statements = append(statements,
+			"-- pg_stat_statements is not available, it also must be added to shared_preload_libraries",
+			"CREATE EXTENSION IF NOT EXISTS pg_stat_statements",
+		)
+	}
+
+	return statements
+}
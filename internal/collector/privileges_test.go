@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_privilegesStatements(t *testing.T) {
+	all := []string{"postgres/activity", "postgres/logs", "postgres/statements", "postgres/storage"}
+
+	testcases := []struct {
+		name             string
+		role             string
+		password         string
+		version          PostgresVersion
+		pgStatStatements bool
+		collectors       []string
+		want             []string
+	}{
+		{
+			name: "pg16", role: "pgscv", password: "secret", version: PostgresVersion{Short: "16.4.0", Numeric: 160004},
+			pgStatStatements: true, collectors: all,
+			want: []string{
+				"-- pgSCV privileges for Postgres 16.4.0, enabled collectors: 4",
+				`DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'pgscv') THEN CREATE ROLE "pgscv" WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE; END IF; END $$`,
+				`ALTER ROLE "pgscv" WITH PASSWORD 'secret'`,
+				`GRANT pg_monitor TO "pgscv"`,
+				`GRANT EXECUTE ON FUNCTION pg_current_logfile() TO "pgscv"`,
+				`GRANT EXECUTE ON FUNCTION pg_ls_waldir() TO "pgscv"`,
+				`GRANT EXECUTE ON FUNCTION pg_ls_logdir() TO "pgscv"`,
+				`GRANT EXECUTE ON FUNCTION pg_ls_tmpdir(oid) TO "pgscv"`,
+			},
+		},
+		{
+			name: "pg11 without pg_stat_statements", role: "mon'itor", version: PostgresVersion{Short: "11.22.0", Numeric: 110022},
+			collectors: all,
+			want: []string{
+				"-- pgSCV privileges for Postgres 11.22.0, enabled collectors: 4",
+				`DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'mon''itor') THEN CREATE ROLE "mon'itor" WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE; END IF; END $$`,
+				`GRANT pg_monitor TO "mon'itor"`,
+				`GRANT EXECUTE ON FUNCTION pg_current_logfile() TO "mon'itor"`,
+				`GRANT EXECUTE ON FUNCTION pg_ls_waldir() TO "mon'itor"`,
+				`GRANT EXECUTE ON FUNCTION pg_ls_logdir() TO "mon'itor"`,
+				"-- pg_stat_statements is not available, it also must be added to shared_preload_libraries",
+				"CREATE EXTENSION IF NOT EXISTS pg_stat_statements",
+			},
+		},
+		{
+			name: "pg96 with disabled collectors", role: "pgscv", version: PostgresVersion{Short: "9.6.24", Numeric: 90624},
+			pgStatStatements: true, collectors: []string{"postgres/activity"},
+			want: []string{
+				"-- pgSCV privileges for Postgres 9.6.24, enabled collectors: 1",
+				`DO $$ BEGIN IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'pgscv') THEN CREATE ROLE "pgscv" WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE; END IF; END $$`,
+				"-- pg_monitor role is not available before Postgres 10, activity and statements of other roles are visible to superusers only",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, privilegesStatements(tc.role, tc.password, tc.version, tc.pgStatStatements, tc.collectors))
+		})
+	}
+}
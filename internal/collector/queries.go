@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/model"
+)
+
+// declaredExtensions defines extensions which schemas are substituted into queries of collectors.
+var declaredExtensions = []string{"pg_buffercache", "pgstattuple", "pageinspect"}
+
+// queriesCollector is implemented by collectors which execute SQL queries.
+type queriesCollector interface {
+	// executedQueries returns queries executed by collector during update with passed configuration.
+	executedQueries(config Config) []string
+}
+
+// DeclaredQueries connects to the service, detects its version and installed extensions, and returns queries executed
+// by collectors created using passed factories, grouped by collectors. Queries executed during configuration of Postgres
+// service and by session sampler are grouped under 'service' and 'postgres/session_history' keys, queries of leader
+// election are grouped under 'leader' key. Extensions are detected in the database of the connection string.
+func DeclaredQueries(factories Factories, config Config) (map[string][]string, error) {
+	switch config.ServiceType {
+	case model.ServiceTypePostgresql:
+		if err := config.FillPostgresServiceConfig(config.ConnTimeout); err != nil {
+			return nil, err
+		}
+
+		if !config.sqlUnavailable {
+			schemas, err := queryExtensionsSchemas(config, declaredExtensions)
+			if err != nil {
+				return nil, err
+			}
+			config.extensionsSchemas = schemas
+		}
+	case model.ServiceTypePgbouncer:
+		conn, err := config.connect()
+		if err != nil {
+			return nil, err
+		}
+		config.pgbouncerVersion, _, err = queryPgbouncerVersion(conn)
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	collectors := make(map[string]Collector, len(factories))
+	for name, factory := range factories {
+		c, err := factory(labels{}, config.Settings[name])
+		if err != nil {
+			return nil, err
+		}
+		collectors[name] = c
+	}
+
+	config.statementsTexts = linkStatementsTexts(collectors)
+
+	return declaredQueries(collectors, config), nil
+}
+
+// declaredQueries returns queries executed by passed collectors with passed configuration, grouped by collectors.
+func declaredQueries(collectors map[string]Collector, config Config) map[string][]string {
+	result := make(map[string][]string, len(collectors))
+	for name, c := range collectors {
+		if qc, ok := c.(queriesCollector); ok {
+			result[name] = qc.executedQueries(config)
+		}
+	}
+
+	if config.ServiceType == model.ServiceTypePostgresql {
+		result["service"] = []string{
+			postgresRolConnLimitQuery,
+			postgresSettingQuery,
+			postgresFullVersionQuery,
+			postgresAwsAuroraQuery,
+			postgresCitusQuery,
+			postgresExtensionSchemaQuery,
+			postgresDatabasesListQuery,
+		}
+		if config.SessionSamplingInterval > 0 {
+			result["postgres/session_history"] = []string{postgresSessionSampleQuery}
+		}
+	}
+
+	if config.LeaderElector != nil {
+		result["leader"] = []string{leaderLockQuery, leaderAliveQuery}
+	}
+
+	return result
+}
+
+// queryExtensionsSchemas returns schemas of passed extensions installed in the database of the connection string.
+// Extensions which are not installed are omitted.
+func queryExtensionsSchemas(config Config, extensions []string) (map[string]string, error) {
+	conn, err := config.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	schemas := make(map[string]string, len(extensions))
+	for _, name := range extensions {
+		if schema := extensionInstalledSchema(conn, name); schema != "" {
+			schemas[name] = schema
+		}
+	}
+
+	return schemas, nil
+}
+
+// perDatabaseQueries returns passed queries, preceded by the query listing databases when queries are executed in
+// every database matched to configured databases.
+func perDatabaseQueries(config Config, queries ...string) []string {
+	if config.DatabasesRE == nil {
+		return queries
+	}
+	return append([]string{postgresDatabasesListQuery}, queries...)
+}
+
+// queryOrOverride returns overriding query if it is specified, or built-in query otherwise.
+func queryOrOverride(override, builtin string) string {
+	if override != "" {
+		return override
+	}
+	return builtin
+}
+
+// descSetsQueries returns queries of user-defined metrics sorted by subsystems names. When some metrics are collected
+// from multiple databases, the query listing databases goes first.
+func descSetsQueries(descSets []typedDescSet) []string {
+	sets := slices.SortedFunc(slices.Values(descSets), func(a, b typedDescSet) int {
+		return strings.Compare(a.subsystem, b.subsystem)
+	})
+
+	var queries []string
+	if needMultipleUpdate(sets) {
+		queries = append(queries, postgresDatabasesListQuery)
+	}
+	for _, s := range sets {
+		if s.query != "" {
+			queries = append(queries, s.query)
+		}
+	}
+
+	return queries
+}
+
+// sortedQueries returns values of passed queries sorted by keys.
+func sortedQueries(queries map[string]string) []string {
+	result := make([]string, 0, len(queries))
+	for _, k := range slices.Sorted(maps.Keys(queries)) {
+		result = append(result, queries[k])
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeclaredQueries(t *testing.T) {
+	// System collectors don't execute queries.
+	f := Factories{}
+	f.RegisterSystemCollectors(nil)
+	got, err := DeclaredQueries(f, Config{ServiceType: model.ServiceTypeSystem})
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	f = Factories{}
+	f.RegisterPostgresCollectors(nil)
+	got, err = DeclaredQueries(f, Config{ServiceType: model.ServiceTypePostgresql, ConnString: store.TestPostgresConnStr})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, got["postgres/activity"])
+	assert.Contains(t, got["service"], postgresSettingQuery)
+
+	_, err = DeclaredQueries(f, Config{ServiceType: model.ServiceTypePostgresql, ConnString: "invalid"})
+	assert.Error(t, err)
+}
+
+func Test_declaredQueries(t *testing.T) {
+	f := Factories{}
+	f.RegisterPostgresCollectors(nil)
+
+	settings := model.CollectorsSettings{
+		"postgres/locks": {Query: "SELECT 'custom' AS locks"},
+		"postgres/custom": {Subsystems: model.Subsystems{
+			"b": {Query: "SELECT 2"},
+			"a": {Query: "SELECT 1", Databases: ".+"},
+		}},
+	}
+
+	collectors := make(map[string]Collector, len(f))
+	for name, factory := range f {
+		c, err := factory(labels{}, settings[name])
+		assert.NoError(t, err)
+		collectors[name] = c
+	}
+
+	config := Config{
+		ServiceType: model.ServiceTypePostgresql,
+		DatabasesRE: regexp.MustCompile(".+"),
+		postgresServiceConfig: postgresServiceConfig{
+			localService:           true,
+			pgVersion:              PostgresVersion{Numeric: PostgresV18},
+			loggingCollector:       true,
+			logDestination:         "stderr,csvlog",
+			pgStatStatements:       true,
+			pgStatStatementsSchema: "ext",
+		},
+		extensionsSchemas: map[string]string{"pg_buffercache": "ext"},
+		statementsTexts:   linkStatementsTexts(collectors),
+	}
+
+	got := declaredQueries(collectors, config)
+
+	assert.Equal(t, []string{postgresPreparedXactQuery, postgresStartTimeQuery}, got["postgres/activity"][1:])
+	assert.Equal(t, []string{"SELECT 'custom' AS locks"}, got["postgres/locks"])
+	assert.Equal(t, []string{postgresDatabasesListQuery, "SELECT 1", "SELECT 2"}, got["postgres/custom"])
+	assert.Equal(t, []string{postgresDatabasesListQuery, userTablesQuery}, got["postgres/tables"])
+	assert.Equal(t, []string{postgresCurrentLogfileQuery}, got["postgres/logs"])
+	assert.Equal(t, []string{selectStatIOQuery(PostgresV18)}, got["postgres/stat_io"])
+	assert.Contains(t, got["postgres/buffercache"], fmt.Sprintf(postgresBuffercacheQuery, "ext"))
+	assert.Contains(t, got["service"], postgresSettingQuery)
+	assert.NotContains(t, got, "leader")
+
+	// Query texts are read by statements_query collector.
+	assert.Contains(t, got["postgres/statements"][0], "pg_stat_statements(false)")
+	assert.NotContains(t, got["postgres/statements_query"][0], "pg_stat_statements(false)")
+
+	// Optional collectors don't execute queries until enabled.
+	assert.Empty(t, got["postgres/watchdog"])
+	assert.Empty(t, got["postgres/table_bloat"])
+	assert.Empty(t, got["postgres/statements_plans"])
+
+	// Queries depend on detected version and extensions.
+	config.pgVersion = PostgresVersion{Numeric: PostgresV12}
+	config.pgStatStatements = false
+	config.extensionsSchemas = nil
+	config.logDestination = "csvlog"
+
+	got = declaredQueries(collectors, config)
+	assert.Empty(t, got["postgres/stat_io"])
+	assert.Empty(t, got["postgres/statements"])
+	assert.Empty(t, got["postgres/logs"])
+	assert.Equal(t, []string{postgresExtensionSchemaQuery}, got["postgres/buffercache"])
+	assert.Equal(t, []string{selectActivityQuery(PostgresV12), postgresPreparedXactQuery, postgresStartTimeQuery}, got["postgres/activity"])
+}
+
+func Test_queriesCollector(t *testing.T) {
+	// Collectors which don't execute queries.
+	noQueries := []string{"postgres/pgscv", "postgres/probe", "pgbouncer/pgscv", "pgbouncer/probe"}
+
+	f := Factories{}
+	f.RegisterPostgresCollectors(nil)
+	f.RegisterPgbouncerCollectors(nil)
+
+	for name, factory := range f {
+		c, err := factory(labels{}, model.CollectorSettings{})
+		assert.NoError(t, err)
+
+		_, ok := c.(queriesCollector)
+		if slices.Contains(noQueries, name) {
+			assert.False(t, ok, "collector %s doesn't execute queries", name)
+			continue
+		}
+		assert.True(t, ok, "queries of collector %s are not declared", name)
+	}
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryErrorsKey defines a set of labels which errors of queries are accounted by.
+type queryErrorsKey struct {
+	collector string
+	class     store.ErrorClass
+}
+
+// queryErrors accumulates errors and retries of queries executed by collectors of the service.
+type queryErrors struct {
+	mu      sync.Mutex
+	errors  map[queryErrorsKey]float64
+	retries map[string]float64
+	total   typedDesc
+	retried typedDesc
+}
+
+// newQueryErrors creates new queryErrors.
+func newQueryErrors(constLabels labels) *queryErrors {
+	return &queryErrors{
+		errors:  map[queryErrorsKey]float64{},
+		retries: map[string]float64{},
+		total: newBuiltinTypedDesc(
+			descOpts{"pgscv", "query", "errors_total", "Total number of failed queries executed by collectors, by error class.", 0},
+			prometheus.CounterValue,
+			[]string{"collector", "class"}, constLabels,
+			filter.New(),
+		),
+		retried: newBuiltinTypedDesc(
+			descOpts{"pgscv", "query", "retries_total", "Total number of retries of queries failed with transient errors.", 0},
+			prometheus.CounterValue,
+			[]string{"collector"}, constLabels,
+			filter.New(),
+		),
+	}
+}
+
+// observer returns function which accounts errors of queries executed by passed collector.
+func (e *queryErrors) observer(collector string) store.ErrorObserver {
+	if e == nil {
+		return nil
+	}
+
+	return func(class store.ErrorClass, retry bool) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		e.errors[queryErrorsKey{collector: collector, class: class}]++
+		if retry {
+			e.retries[collector]++
+		}
+	}
+}
+
+// send sends accumulated errors and retries of queries into channel.
+func (e *queryErrors) send(ch chan<- prometheus.Metric) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, v := range e.errors {
+		ch <- e.total.newConstMetric(v, key.collector, string(key.class))
+	}
+	for collector, v := range e.retries {
+		ch <- e.retried.newConstMetric(v, collector)
+	}
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_queryErrors(t *testing.T) {
+	e := newQueryErrors(labels{"service_id": "test"})
+
+	observe := e.observer("postgres/activity")
+	observe(store.ErrorClassConnection, true)
+	observe(store.ErrorClassConnection, false)
+	e.observer("postgres/locks")(store.ErrorClassPermission, false)
+
+	ch := make(chan prometheus.Metric, 10)
+	e.send(ch)
+	close(ch)
+	assert.Len(t, ch, 3)
+
+	got := map[string]float64{}
+	for m := range ch {
+		var metric dto.Metric
+		assert.NoError(t, m.Write(&metric))
+
+		var key string
+		for _, l := range metric.GetLabel() {
+			if l.GetName() != "service_id" {
+				key += l.GetName() + "=" + l.GetValue() + ","
+			}
+		}
+		got[key] = metric.GetCounter().GetValue()
+	}
+
+	assert.Equal(t, map[string]float64{
+		"class=connection,collector=postgres/activity,": 2,
+		"class=permission,collector=postgres/locks,":    1,
+		"collector=postgres/activity,":                  1,
+	}, got)
+
+	// Nothing is accounted when errors are not defined.
+	var empty *queryErrors
+	assert.Nil(t, empty.observer("postgres/activity"))
+	ch = make(chan prometheus.Metric, 10)
+	empty.send(ch)
+	assert.Len(t, ch, 0)
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+)
+
+// overridableQueries defines collectors which built-in query could be overridden in collector settings, and columns
+// which must be returned by the overriding query.
+var overridableQueries = map[string][]string{
+	"postgres/activity":  {"user", "database", "state", "active_seconds", "waiting_seconds", "query"},
+	"postgres/archiver":  {"archived_count", "failed_count", "since_last_archive_seconds", "lag_files"},
+	"postgres/conflicts": {"database", "confl_tablespace", "confl_lock", "confl_snapshot", "confl_bufferpin", "confl_deadlock"},
+	"postgres/locks": {
+		"access_share_lock", "row_share_lock", "row_exclusive_lock", "share_update_exclusive_lock", "share_lock",
+		"share_row_exclusive_lock", "exclusive_lock", "access_exclusive_lock", "not_granted", "total",
+	},
+}
+
+// ValidateQueryOverride checks the collector allows overriding its built-in query, and the query mentions all columns
+// required by the collector.
+func ValidateQueryOverride(collector, query string) error {
+	columns, ok := overridableQueries[collector]
+	if !ok {
+		names := make([]string, 0, len(overridableQueries))
+		for name := range overridableQueries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("collector '%s' doesn't support query override, supported: %s", collector, strings.Join(names, ", "))
+	}
+
+	if !regexp.MustCompile(`(?i)^\s*(SELECT|WITH)\s`).MatchString(query) {
+		return fmt.Errorf("query of collector '%s' must be a SELECT statement", collector)
+	}
+
+	for _, column := range columns {
+		if !regexp.MustCompile(`(?i)\b` + column + `\b`).MatchString(query) {
+			return fmt.Errorf("query of collector '%s' doesn't return required column '%s'", collector, column)
+		}
+	}
+
+	return nil
+}
+
+// queryWithOverride executes overriding query if it is specified, or built-in query otherwise. Result of overriding
+// query is checked to contain all columns required by the collector.
+func queryWithOverride(conn *store.DB, collector, override, builtin string) (*model.PGResult, error) {
+	if override == "" {
+		return conn.Query(builtin)
+	}
+
+	res, err := conn.Query(override)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkQueryColumns(collector, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// checkQueryColumns checks the query result contains all columns required by the collector.
+func checkQueryColumns(collector string, res *model.PGResult) error {
+	colnames := map[string]bool{}
+	for _, colname := range res.Colnames {
+		colnames[string(colname.Name)] = true
+	}
+
+	for _, column := range overridableQueries[collector] {
+		if !colnames[column] {
+			return fmt.Errorf("query of collector '%s' doesn't return required column '%s'", collector, column)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateQueryOverride(t *testing.T) {
+	testcases := []struct {
+		valid     bool
+		collector string
+		query     string
+	}{
+		{valid: true, collector: "postgres/conflicts", query: postgresDatabaseConflictsQueryLatest},
+		{valid: true, collector: "postgres/locks", query: locksQuery},
+		{valid: true, collector: "postgres/activity", query: "with a as (select * from pg_stat_activity) " + postgresActivityQueryLatest},
+		{valid: false, collector: "postgres/tables", query: "SELECT 1"},
+		{valid: false, collector: "postgres/archiver", query: "DELETE FROM pg_stat_archiver"},
+		{valid: false, collector: "postgres/archiver", query: "SELECT archived_count, failed_count FROM pg_stat_archiver"},
+	}
+
+	for _, tc := range testcases {
+		if tc.valid {
+			assert.NoError(t, ValidateQueryOverride(tc.collector, tc.query))
+		} else {
+			assert.Error(t, ValidateQueryOverride(tc.collector, tc.query))
+		}
+	}
+}
+
+func Test_checkQueryColumns(t *testing.T) {
+	res := &model.PGResult{
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("archived_count")}, {Name: []byte("failed_count")},
+			{Name: []byte("since_last_archive_seconds")}, {Name: []byte("lag_files")},
+		},
+	}
+	assert.NoError(t, checkQueryColumns("postgres/archiver", res))
+
+	res.Colnames = res.Colnames[:3]
+	assert.Error(t, checkQueryColumns("postgres/archiver", res))
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryStatsKey defines a set of labels which durations of queries are accounted by.
+type queryStatsKey struct {
+	collector string
+	hash      string
+}
+
+// queryStat defines accumulated durations of query.
+type queryStat struct {
+	count uint64
+	sum   float64
+}
+
+// queryStats accumulates durations of queries executed by collectors of the service.
+type queryStats struct {
+	mu       sync.Mutex
+	stats    map[queryStatsKey]queryStat
+	duration typedDesc
+}
+
+// newQueryStats creates new queryStats.
+func newQueryStats(constLabels labels) *queryStats {
+	return &queryStats{
+		stats: map[queryStatsKey]queryStat{},
+		duration: newBuiltinTypedDesc(
+			descOpts{"pgscv", "query", "duration_seconds", "Duration of queries executed by collectors, in seconds.", 0},
+			prometheus.UntypedValue,
+			[]string{"collector", "query_hash"}, constLabels,
+			filter.New(),
+		),
+	}
+}
+
+// observer returns function which accounts durations of queries executed by passed collector.
+func (s *queryStats) observer(collector string) store.QueryObserver {
+	if s == nil {
+		return nil
+	}
+
+	return func(query string, duration time.Duration) {
+		key := queryStatsKey{collector: collector, hash: queryHash(query)}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		stat := s.stats[key]
+		stat.count++
+		stat.sum += duration.Seconds()
+		s.stats[key] = stat
+	}
+}
+
+// send sends accumulated durations of queries into channel.
+func (s *queryStats) send(ch chan<- prometheus.Metric) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, stat := range s.stats {
+		m, err := prometheus.NewConstSummary(s.duration.desc, stat.count, stat.sum, nil, key.collector, key.hash)
+		if err != nil {
+			log.Warnf("create query duration metric failed: %s; skip", err)
+			continue
+		}
+		ch <- m
+	}
+}
+
+// queryHash returns short hash of the query text used for distinguishing queries in metrics.
+func queryHash(query string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(query))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
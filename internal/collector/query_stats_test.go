@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_queryStats(t *testing.T) {
+	s := newQueryStats(labels{"service_id": "test"})
+
+	observe := s.observer("postgres/activity")
+	observe("SELECT 1", 100*time.Millisecond)
+	observe("SELECT 1", 300*time.Millisecond)
+	s.observer("postgres/locks")("SELECT 2", time.Second)
+
+	ch := make(chan prometheus.Metric, 10)
+	s.send(ch)
+	close(ch)
+	assert.Len(t, ch, 2)
+
+	for m := range ch {
+		var metric dto.Metric
+		assert.NoError(t, m.Write(&metric))
+
+		got := map[string]string{}
+		for _, l := range metric.GetLabel() {
+			got[l.GetName()] = l.GetValue()
+		}
+
+		switch got["collector"] {
+		case "postgres/activity":
+			assert.Equal(t, queryHash("SELECT 1"), got["query_hash"])
+			assert.Equal(t, uint64(2), metric.GetSummary().GetSampleCount())
+			assert.InDelta(t, 0.4, metric.GetSummary().GetSampleSum(), 0.0001)
+		case "postgres/locks":
+			assert.Equal(t, queryHash("SELECT 2"), got["query_hash"])
+			assert.Equal(t, uint64(1), metric.GetSummary().GetSampleCount())
+			assert.Equal(t, 1.0, metric.GetSummary().GetSampleSum())
+		default:
+			t.Errorf("unexpected collector %s", got["collector"])
+		}
+	}
+
+	// Nothing is accounted when stats are not defined.
+	var empty *queryStats
+	assert.Nil(t, empty.observer("postgres/activity"))
+	ch = make(chan prometheus.Metric, 10)
+	empty.send(ch)
+	assert.Len(t, ch, 0)
+}
+
+func Test_queryHash(t *testing.T) {
+	assert.Len(t, queryHash("SELECT 1"), 8)
+	assert.Equal(t, queryHash("SELECT 1"), queryHash("SELECT 1"))
+	assert.NotEqual(t, queryHash("SELECT 1"), queryHash("SELECT 2"))
+}
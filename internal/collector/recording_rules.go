@@ -0,0 +1,685 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Recording rules define metrics computed at scrape time using expressions over metrics collected from the same
+// service, e.g. cache hit ratio of databases:
+//
+//	recording_rules:
+//	  - name: postgres_database_blocks_hit_ratio
+//	    expr: postgres_database_blocks_total{access="hit"} / sum by (database) (postgres_database_blocks_total)
+//
+// Expressions support numbers, metrics with optional label matchers (=, !=), arithmetic operators (+, -, *, /),
+// parentheses and sum() aggregation with optional 'by (labels)' clause. Labels used in equality matchers are removed
+// from selected series, so series selected by different matchers of the same metric could be combined. Series of
+// both operands are matched by identical label sets. Series which values are not finite (e.g. division by zero) are
+// skipped. Rules could refer to metrics computed by preceding rules.
+
+// reRuleName defines regexp of valid names of rules, the same as names of metrics.
+var reRuleName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// ValidateRecordingRule checks name and expression of recording rule.
+func ValidateRecordingRule(rule model.RecordingRule) error {
+	_, err := parseRecordingRule(rule)
+	return err
+}
+
+// recordingRule is a parsed recording rule.
+type recordingRule struct {
+	name string
+	help string
+	expr ruleExpr
+}
+
+// parseRecordingRule parses expression of recording rule.
+func parseRecordingRule(rule model.RecordingRule) (recordingRule, error) {
+	if !reRuleName.MatchString(rule.Name) {
+		return recordingRule{}, fmt.Errorf("invalid name '%s', allowed letters, digits, underscores and colons", rule.Name)
+	}
+
+	// Families with the same name but different help or labels break the scrape. Self-metrics of pgSCV are not listed
+	// in catalog, hence their prefix is reserved.
+	if IsBuiltinMetric(rule.Name) || strings.HasPrefix(rule.Name, "pgscv_") {
+		return recordingRule{}, fmt.Errorf("invalid name '%s', the name is used by builtin metrics", rule.Name)
+	}
+
+	expr, err := parseRuleExpr(rule.Expr)
+	if err != nil {
+		return recordingRule{}, fmt.Errorf("invalid expression of '%s': %w", rule.Name, err)
+	}
+
+	help := rule.Help
+	if help == "" {
+		help = "Recording rule: " + rule.Expr
+	}
+
+	return recordingRule{name: rule.Name, help: help, expr: expr}, nil
+}
+
+// recordingRules defines parsed recording rules of the service.
+type recordingRules struct {
+	rules       []recordingRule
+	constLabels labels
+	inputs      map[string]struct{} // names of metrics referenced by rules
+}
+
+// newRecordingRules parses passed rules, nil is returned when there are no valid rules.
+func newRecordingRules(rules model.RecordingRules, constLabels labels) *recordingRules {
+	r := &recordingRules{constLabels: constLabels, inputs: map[string]struct{}{}}
+	for _, rule := range rules {
+		parsed, err := parseRecordingRule(rule)
+		if err != nil {
+			log.Errorf("recording rule skipped: %s", err)
+			continue
+		}
+		parsed.expr.names(r.inputs)
+		r.rules = append(r.rules, parsed)
+	}
+
+	if len(r.rules) == 0 {
+		return nil
+	}
+	return r
+}
+
+// newScrape returns storage of series used by rules during single scrape.
+func (r *recordingRules) newScrape() *ruleSeries {
+	if r == nil {
+		return nil
+	}
+	return &ruleSeries{rules: r, names: map[*prometheus.Desc]string{}, series: map[string][]ruleSample{}}
+}
+
+// ruleSample is a single series used in expressions.
+type ruleSample struct {
+	labels labels
+	value  float64
+}
+
+// ruleSeries keeps series referenced by recording rules during single scrape.
+type ruleSeries struct {
+	rules  *recordingRules
+	names  map[*prometheus.Desc]string // names of referenced metrics, empty for other metrics
+	series map[string][]ruleSample
+}
+
+// observe remembers metric if it is referenced by rules.
+func (s *ruleSeries) observe(m prometheus.Metric) {
+	if s == nil {
+		return
+	}
+
+	desc := m.Desc()
+	name, ok := s.names[desc]
+	if !ok {
+		if parts := reDescFqName.FindStringSubmatch(desc.String()); len(parts) == 2 {
+			if _, found := s.rules.inputs[parts[1]]; found {
+				name = parts[1]
+			}
+		}
+		s.names[desc] = name
+	}
+	if name == "" {
+		return
+	}
+
+	pb := &dto.Metric{}
+	if err := m.Write(pb); err != nil {
+		return
+	}
+
+	var value float64
+	switch {
+	case pb.Gauge != nil:
+		value = pb.Gauge.GetValue()
+	case pb.Counter != nil:
+		value = pb.Counter.GetValue()
+	case pb.Untyped != nil:
+		value = pb.Untyped.GetValue()
+	default:
+		return
+	}
+
+	// Service labels are the same for all series, they are not used in matching.
+	l := labels{}
+	for _, lp := range pb.GetLabel() {
+		if _, ok := s.rules.constLabels[lp.GetName()]; !ok {
+			l[lp.GetName()] = lp.GetValue()
+		}
+	}
+
+	s.series[name] = append(s.series[name], ruleSample{labels: l, value: value})
+}
+
+// send evaluates rules and sends computed metrics.
+func (s *ruleSeries) send(ch chan<- prometheus.Metric) {
+	if s == nil {
+		return
+	}
+
+	for _, rule := range s.rules.rules {
+		samples := rule.expr.eval(s.series).samples()
+		if len(samples) == 0 {
+			continue
+		}
+
+		// Series of rule could have different labels, missing labels are exposed with empty values.
+		var names []string
+		for _, sample := range samples {
+			for k := range sample.labels {
+				if !slices.Contains(names, k) {
+					names = append(names, k)
+				}
+			}
+		}
+		sort.Strings(names)
+
+		desc := prometheus.NewDesc(rule.name, rule.help, names, prometheus.Labels(s.rules.constLabels))
+		for _, sample := range samples {
+			values := make([]string, len(names))
+			for i, k := range names {
+				values[i] = sample.labels[k]
+			}
+
+			m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, sample.value, values...)
+			if err != nil {
+				log.Errorf("create const metric failed: %s; skip. Failed metric descriptor: '%s'", err, desc.String())
+				continue
+			}
+			ch <- m
+		}
+
+		// Computed series are available for following rules.
+		if _, ok := s.rules.inputs[rule.name]; ok {
+			s.series[rule.name] = samples
+		}
+	}
+}
+
+// ruleValue is a result of expression evaluation, either scalar or vector of series.
+type ruleValue struct {
+	scalar bool
+	value  float64
+	vector []ruleSample
+}
+
+// samples returns series of the value, scalar is represented as a single series without labels.
+func (v ruleValue) samples() []ruleSample {
+	if !v.scalar {
+		return v.vector
+	}
+	if math.IsNaN(v.value) || math.IsInf(v.value, 0) {
+		return nil
+	}
+	return []ruleSample{{labels: labels{}, value: v.value}}
+}
+
+// ruleExpr is a node of parsed expression.
+type ruleExpr interface {
+	// eval computes value of the expression using passed series.
+	eval(series map[string][]ruleSample) ruleValue
+	// names adds names of referenced metrics to passed set.
+	names(dst map[string]struct{})
+}
+
+// ruleNumber is a numeric literal.
+type ruleNumber float64
+
+func (e ruleNumber) eval(map[string][]ruleSample) ruleValue {
+	return ruleValue{scalar: true, value: float64(e)}
+}
+
+func (e ruleNumber) names(map[string]struct{}) {}
+
+// ruleMatcher defines label matcher of selector.
+type ruleMatcher struct {
+	name   string
+	value  string
+	negate bool
+}
+
+// ruleSelector selects series of metric.
+type ruleSelector struct {
+	metric   string
+	matchers []ruleMatcher
+}
+
+func (e ruleSelector) eval(series map[string][]ruleSample) ruleValue {
+	var vector []ruleSample
+	for _, s := range series[e.metric] {
+		matched := true
+		for _, m := range e.matchers {
+			if (s.labels[m.name] == m.value) == m.negate {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		l := labels{}
+		for k, v := range s.labels {
+			if !slices.ContainsFunc(e.matchers, func(m ruleMatcher) bool { return m.name == k && !m.negate }) {
+				l[k] = v
+			}
+		}
+		vector = append(vector, ruleSample{labels: l, value: s.value})
+	}
+	return ruleValue{vector: vector}
+}
+
+func (e ruleSelector) names(dst map[string]struct{}) {
+	dst[e.metric] = struct{}{}
+}
+
+// ruleSum aggregates series by labels.
+type ruleSum struct {
+	by   []string
+	expr ruleExpr
+}
+
+func (e ruleSum) eval(series map[string][]ruleSample) ruleValue {
+	v := e.expr.eval(series)
+	if v.scalar {
+		return v
+	}
+
+	var vector []ruleSample
+	groups := map[string]int{}
+	for _, s := range v.vector {
+		l := labels{}
+		for _, k := range e.by {
+			if value, ok := s.labels[k]; ok {
+				l[k] = value
+			}
+		}
+
+		key := ruleSignature(l)
+		if i, ok := groups[key]; ok {
+			vector[i].value += s.value
+			continue
+		}
+		groups[key] = len(vector)
+		vector = append(vector, ruleSample{labels: l, value: s.value})
+	}
+	return ruleValue{vector: vector}
+}
+
+func (e ruleSum) names(dst map[string]struct{}) {
+	e.expr.names(dst)
+}
+
+// ruleBinary is an arithmetic operation.
+type ruleBinary struct {
+	op  byte
+	lhs ruleExpr
+	rhs ruleExpr
+}
+
+func (e ruleBinary) eval(series map[string][]ruleSample) ruleValue {
+	lhs, rhs := e.lhs.eval(series), e.rhs.eval(series)
+
+	switch {
+	case lhs.scalar && rhs.scalar:
+		return ruleValue{scalar: true, value: applyRuleOp(e.op, lhs.value, rhs.value)}
+	case rhs.scalar:
+		return ruleValue{vector: mapRuleSamples(lhs.vector, func(v float64) float64 { return applyRuleOp(e.op, v, rhs.value) })}
+	case lhs.scalar:
+		return ruleValue{vector: mapRuleSamples(rhs.vector, func(v float64) float64 { return applyRuleOp(e.op, lhs.value, v) })}
+	}
+
+	right := make(map[string]float64, len(rhs.vector))
+	for _, s := range rhs.vector {
+		right[ruleSignature(s.labels)] = s.value
+	}
+
+	var vector []ruleSample
+	for _, s := range lhs.vector {
+		v, ok := right[ruleSignature(s.labels)]
+		if !ok {
+			continue
+		}
+		if value := applyRuleOp(e.op, s.value, v); !math.IsNaN(value) && !math.IsInf(value, 0) {
+			vector = append(vector, ruleSample{labels: s.labels, value: value})
+		}
+	}
+	return ruleValue{vector: vector}
+}
+
+func (e ruleBinary) names(dst map[string]struct{}) {
+	e.lhs.names(dst)
+	e.rhs.names(dst)
+}
+
+// applyRuleOp returns result of arithmetic operation.
+func applyRuleOp(op byte, a, b float64) float64 {
+	switch op {
+	case '+':
+		return a + b
+	case '-':
+		return a - b
+	case '*':
+		return a * b
+	default:
+		return a / b
+	}
+}
+
+// mapRuleSamples applies function to values of series, series with not finite results are skipped.
+func mapRuleSamples(samples []ruleSample, fn func(float64) float64) []ruleSample {
+	var vector []ruleSample
+	for _, s := range samples {
+		if value := fn(s.value); !math.IsNaN(value) && !math.IsInf(value, 0) {
+			vector = append(vector, ruleSample{labels: s.labels, value: value})
+		}
+	}
+	return vector
+}
+
+// ruleSignature returns string uniquely identifying label set.
+func ruleSignature(l labels) string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0xff)
+		b.WriteString(l[k])
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// ruleToken is a lexical token of expression.
+type ruleToken struct {
+	kind  byte // 'i' - identifier, 'n' - number, 's' - string, other - punctuation
+	value string
+}
+
+// lexRuleExpr splits expression into tokens.
+func lexRuleExpr(s string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '!' && i+1 < len(s) && s[i+1] == '=':
+			tokens = append(tokens, ruleToken{kind: '!'})
+			i += 2
+		case strings.IndexByte("+-*/(){},=", c) >= 0:
+			tokens = append(tokens, ruleToken{kind: c})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			value, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %w", i, err)
+			}
+			tokens = append(tokens, ruleToken{kind: 's', value: value})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.' || s[j] == 'e' || s[j] == 'E' ||
+				(s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, ruleToken{kind: 'n', value: s[i:j]})
+			i = j
+		case c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(s) && (s[j] == '_' || s[j] == ':' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, ruleToken{kind: 'i', value: s[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+// ruleParser implements recursive descent parser of expressions.
+type ruleParser struct {
+	tokens []ruleToken
+	pos    int
+}
+
+// parseRuleExpr parses expression.
+func parseRuleExpr(s string) (ruleExpr, error) {
+	tokens, err := lexRuleExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &ruleParser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token '%s'", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// String returns text representation of token used in error messages.
+func (t ruleToken) String() string {
+	if t.kind == 'i' || t.kind == 'n' || t.kind == 's' {
+		return t.value
+	}
+	if t.kind == '!' {
+		return "!="
+	}
+	return string(t.kind)
+}
+
+// peek returns kind of current token, zero is returned at the end of expression.
+func (p *ruleParser) peek() byte {
+	if p.pos >= len(p.tokens) {
+		return 0
+	}
+	return p.tokens[p.pos].kind
+}
+
+// expect consumes token of specified kind.
+func (p *ruleParser) expect(kind byte) (ruleToken, error) {
+	if p.peek() != kind {
+		if p.pos >= len(p.tokens) {
+			return ruleToken{}, fmt.Errorf("unexpected end of expression, expected '%s'", ruleToken{kind: kind})
+		}
+		return ruleToken{}, fmt.Errorf("unexpected token '%s', expected '%s'", p.tokens[p.pos], ruleToken{kind: kind})
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+// parseExpr parses additive expression.
+func (p *ruleParser) parseExpr() (ruleExpr, error) {
+	lhs, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '+' || p.peek() == '-' {
+		op := p.tokens[p.pos].kind
+		p.pos++
+		rhs, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		lhs = ruleBinary{op: op, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+// parseTerm parses multiplicative expression.
+func (p *ruleParser) parseTerm() (ruleExpr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '*' || p.peek() == '/' {
+		op := p.tokens[p.pos].kind
+		p.pos++
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = ruleBinary{op: op, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+// parseUnary parses negation and primary expressions.
+func (p *ruleParser) parseUnary() (ruleExpr, error) {
+	if p.peek() == '-' {
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return ruleBinary{op: '-', lhs: ruleNumber(0), rhs: expr}, nil
+	}
+
+	switch p.peek() {
+	case 'n':
+		t := p.tokens[p.pos]
+		p.pos++
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", t.value)
+		}
+		return ruleNumber(value), nil
+	case '(':
+		p.pos++
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case 'i':
+		t := p.tokens[p.pos]
+		p.pos++
+		if t.value == "sum" && (p.peek() == '(' || p.peek() == 'i' && p.tokens[p.pos].value == "by") {
+			return p.parseSum()
+		}
+		return p.parseSelector(t.value)
+	case 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected token '%s'", p.tokens[p.pos])
+	}
+}
+
+// parseSum parses arguments of sum aggregation.
+func (p *ruleParser) parseSum() (ruleExpr, error) {
+	var by []string
+	if p.peek() == 'i' {
+		p.pos++
+		if _, err := p.expect('('); err != nil {
+			return nil, err
+		}
+		for p.peek() != ')' {
+			t, err := p.expect('i')
+			if err != nil {
+				return nil, err
+			}
+			by = append(by, t.value)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if _, err := p.expect(')'); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.expect('('); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return ruleSum{by: by, expr: expr}, nil
+}
+
+// parseSelector parses label matchers of metric.
+func (p *ruleParser) parseSelector(metric string) (ruleExpr, error) {
+	sel := ruleSelector{metric: metric}
+	if p.peek() != '{' {
+		return sel, nil
+	}
+	p.pos++
+
+	for p.peek() != '}' {
+		name, err := p.expect('i')
+		if err != nil {
+			return nil, err
+		}
+
+		m := ruleMatcher{name: name.value}
+		switch p.peek() {
+		case '=':
+		case '!':
+			m.negate = true
+		default:
+			return nil, fmt.Errorf("expected '=' or '!=' after label '%s'", name.value)
+		}
+		p.pos++
+
+		value, err := p.expect('s')
+		if err != nil {
+			return nil, err
+		}
+		m.value = value.value
+		sel.matchers = append(sel.matchers, m)
+
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if _, err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRecordingRule(t *testing.T) {
+	testcases := []struct {
+		valid bool
+		rule  model.RecordingRule
+	}{
+		{valid: true, rule: model.RecordingRule{Name: "example", Expr: "1"}},
+		{valid: true, rule: model.RecordingRule{Name: "example:ratio", Expr: `a{x="1"} / (a{x="1"} + a{x!="1"}) * 100`}},
+		{valid: true, rule: model.RecordingRule{Name: "example", Expr: "sum by (database, user) (a) - -sum(b)"}},
+		{valid: true, rule: model.RecordingRule{Name: "example", Expr: "sum by () (a)"}},
+		{valid: false, rule: model.RecordingRule{Name: "", Expr: "1"}},
+		{valid: false, rule: model.RecordingRule{Name: "invalid-name", Expr: "1"}},
+		{valid: false, rule: model.RecordingRule{Name: "postgres_up", Expr: "1"}},
+		{valid: false, rule: model.RecordingRule{Name: "pgscv_series_dropped_total", Expr: "1"}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: ""}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: "a +"}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: "(a"}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: "a b"}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: `a{x=1}`}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: `a{x="1}`}},
+		{valid: false, rule: model.RecordingRule{Name: "example", Expr: "rate(a[5m])"}},
+	}
+
+	for _, tc := range testcases {
+		err := ValidateRecordingRule(tc.rule)
+		if tc.valid {
+			assert.NoError(t, err, tc.rule.Expr)
+		} else {
+			assert.Error(t, err, tc.rule.Expr)
+		}
+	}
+}
+
+func Test_recordingRules(t *testing.T) {
+	assert.Nil(t, newRecordingRules(nil, labels{}))
+	assert.Nil(t, newRecordingRules(model.RecordingRules{{Name: "example", Expr: "a +"}}, labels{}))
+
+	var nilRules *recordingRules
+	assert.Nil(t, nilRules.newScrape())
+
+	r := newRecordingRules(model.RecordingRules{
+		{Name: "test_blocks_hit_ratio", Expr: `test_blocks_total{access="hit"} / sum by (database) (test_blocks_total)`},
+		{Name: "test_blocks_hit_percent", Expr: `test_blocks_hit_ratio * 100`, Help: "Percent of hits."},
+		{Name: "test_blocks_all", Expr: `sum(test_blocks_total)`},
+		{Name: "test_unknown", Expr: `test_unknown_total + 1`},
+		{Name: "invalid", Expr: `a +`},
+	}, labels{"service_id": "svc"})
+	require.NotNil(t, r)
+	assert.Len(t, r.rules, 4)
+
+	desc := prometheus.NewDesc("test_blocks_total", "Test.", []string{"database", "access"}, prometheus.Labels{"service_id": "svc"})
+	other := prometheus.NewDesc("test_other", "Test.", nil, prometheus.Labels{"service_id": "svc"})
+
+	s := r.newScrape()
+	s.observe(prometheus.MustNewConstMetric(desc, prometheus.CounterValue, 75, "db1", "hit"))
+	s.observe(prometheus.MustNewConstMetric(desc, prometheus.CounterValue, 25, "db1", "read"))
+	s.observe(prometheus.MustNewConstMetric(desc, prometheus.CounterValue, 0, "db2", "hit"))
+	s.observe(prometheus.MustNewConstMetric(desc, prometheus.CounterValue, 0, "db2", "read"))
+	s.observe(prometheus.MustNewConstMetric(other, prometheus.GaugeValue, 1))
+	assert.Len(t, s.series["test_blocks_total"], 4)
+	assert.Len(t, s.series, 1)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		s.send(ch)
+		close(ch)
+	}()
+
+	got := map[string]float64{}
+	for m := range ch {
+		pb := &dto.Metric{}
+		require.NoError(t, m.Write(pb))
+		key := reDescFqName.FindStringSubmatch(m.Desc().String())[1]
+		for _, lp := range pb.GetLabel() {
+			key += "," + lp.GetName() + "=" + lp.GetValue()
+		}
+		got[key] = pb.GetGauge().GetValue()
+	}
+
+	// Division by zero of db2 is skipped.
+	assert.Equal(t, map[string]float64{
+		"test_blocks_hit_ratio,database=db1,service_id=svc":   0.75,
+		"test_blocks_hit_percent,database=db1,service_id=svc": 75,
+		"test_blocks_all,service_id=svc":                      100,
+	}, got)
+}
+
+func Test_ruleExpr_eval(t *testing.T) {
+	series := map[string][]ruleSample{
+		"a": {
+			{labels: labels{"x": "1", "y": "a"}, value: 1},
+			{labels: labels{"x": "2", "y": "a"}, value: 2},
+			{labels: labels{"x": "3", "y": "b"}, value: 3},
+		},
+	}
+
+	testcases := []struct {
+		expr string
+		want ruleValue
+	}{
+		{expr: "1 + 2 * 3", want: ruleValue{scalar: true, value: 7}},
+		{expr: "(1 + 2) * 3", want: ruleValue{scalar: true, value: 9}},
+		{expr: "-2 - 1e1", want: ruleValue{scalar: true, value: -12}},
+		{expr: `a{x="1"}`, want: ruleValue{vector: []ruleSample{{labels: labels{"y": "a"}, value: 1}}}},
+		{expr: `a{y!="a"} * 2`, want: ruleValue{vector: []ruleSample{{labels: labels{"x": "3", "y": "b"}, value: 6}}}},
+		{expr: `sum by (y) (a)`, want: ruleValue{vector: []ruleSample{{labels: labels{"y": "a"}, value: 3}, {labels: labels{"y": "b"}, value: 3}}}},
+		{expr: `sum(a)`, want: ruleValue{vector: []ruleSample{{labels: labels{}, value: 6}}}},
+		{expr: `a{x="1"} + a{x="2"}`, want: ruleValue{vector: []ruleSample{{labels: labels{"y": "a"}, value: 3}}}},
+		{expr: `a{x="1"} / 0`},
+		{expr: `unknown`},
+	}
+
+	for _, tc := range testcases {
+		expr, err := parseRuleExpr(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.want, expr.eval(series), tc.expr)
+	}
+}
@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ScrapeScheduler limits number of services collected concurrently across all scrapes. Services waiting for a slot
+// are queued and served in order of arrival, hence busy services can't starve others at scrape time.
+type ScrapeScheduler struct {
+	mu      sync.Mutex
+	limit   int // 0 means no limit
+	running int
+	queue   []chan struct{}
+	waited  float64 // total time spent by services in queue, in seconds
+
+	queueDepth typedDesc
+	inFlight   typedDesc
+	waitTotal  typedDesc
+}
+
+// NewScrapeScheduler creates new scheduler which allows up to limit services collected at the same time.
+func NewScrapeScheduler(limit int) *ScrapeScheduler {
+	return &ScrapeScheduler{
+		limit: limit,
+		queueDepth: newBuiltinTypedDesc(
+			descOpts{"pgscv", "scrape", "queue_depth", "Number of services waiting for collecting.", 0},
+			prometheus.GaugeValue,
+			nil, nil,
+			filter.New(),
+		),
+		inFlight: newBuiltinTypedDesc(
+			descOpts{"pgscv", "scrape", "in_flight", "Number of services being collected.", 0},
+			prometheus.GaugeValue,
+			nil, nil,
+			filter.New(),
+		),
+		waitTotal: newBuiltinTypedDesc(
+			descOpts{"pgscv", "scrape", "queue_wait_seconds_total", "Total time spent by services waiting for collecting, in seconds.", 0},
+			prometheus.CounterValue,
+			nil, nil,
+			filter.New(),
+		),
+	}
+}
+
+// SetLimit updates number of services allowed to be collected at the same time, 0 means no limit.
+func (s *ScrapeScheduler) SetLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+	s.wakeup()
+}
+
+// acquire blocks until service is allowed to be collected.
+func (s *ScrapeScheduler) acquire() {
+	start := time.Now()
+
+	s.mu.Lock()
+	if len(s.queue) == 0 && (s.limit <= 0 || s.running < s.limit) {
+		s.running++
+		s.mu.Unlock()
+		return
+	}
+
+	ready := make(chan struct{})
+	s.queue = append(s.queue, ready)
+	s.mu.Unlock()
+
+	<-ready
+
+	s.mu.Lock()
+	s.waited += time.Since(start).Seconds()
+	s.mu.Unlock()
+}
+
+// release frees slot of collected service and passes it to the next service in the queue.
+func (s *ScrapeScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	s.wakeup()
+}
+
+// wakeup passes free slots to queued services. Must be called with lock held.
+func (s *ScrapeScheduler) wakeup() {
+	for len(s.queue) > 0 && (s.limit <= 0 || s.running < s.limit) {
+		s.running++
+		close(s.queue[0])
+		s.queue = s.queue[1:]
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (s *ScrapeScheduler) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.queueDepth.desc
+	ch <- s.inFlight.desc
+	ch <- s.waitTotal.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (s *ScrapeScheduler) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch <- s.queueDepth.newConstMetric(float64(len(s.queue)))
+	ch <- s.inFlight.newConstMetric(float64(s.running))
+	ch <- s.waitTotal.newConstMetric(s.waited)
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeScheduler(t *testing.T) {
+	s := NewScrapeScheduler(1)
+
+	// First service takes the only slot.
+	s.acquire()
+
+	// Others are queued and served in order of arrival.
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for i := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.acquire()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			s.release()
+		}()
+
+		// Wait until service is queued.
+		assert.Eventually(t, func() bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return len(s.queue) == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	assert.Equal(t, 3, testutil.CollectAndCount(s, "pgscv_scrape_queue_depth", "pgscv_scrape_in_flight", "pgscv_scrape_queue_wait_seconds_total"))
+	assert.Equal(t, float64(3), gaugeValue(t, s, "pgscv_scrape_queue_depth"))
+	assert.Equal(t, float64(1), gaugeValue(t, s, "pgscv_scrape_in_flight"))
+
+	s.release()
+	wg.Wait()
+
+	assert.Equal(t, []int{0, 1, 2}, order)
+	assert.Equal(t, float64(0), gaugeValue(t, s, "pgscv_scrape_queue_depth"))
+	assert.Equal(t, float64(0), gaugeValue(t, s, "pgscv_scrape_in_flight"))
+}
+
+func TestScrapeScheduler_SetLimit(t *testing.T) {
+	s := NewScrapeScheduler(1)
+	s.acquire()
+
+	done := make(chan struct{})
+	go func() {
+		s.acquire()
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.queue) == 1
+	}, time.Second, time.Millisecond)
+
+	// Removing limit wakes up queued services.
+	s.SetLimit(0)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("queued service has not been woken up")
+	}
+
+	s.release()
+	s.release()
+}
+
+// gaugeValue returns value of single metric with specified name exposed by collector.
+func gaugeValue(t *testing.T, c prometheus.Collector, name string) float64 {
+	reg := prometheus.NewPedanticRegistry()
+	assert.NoError(t, reg.Register(c))
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == name {
+			m := f.GetMetric()[0]
+			if m.GetGauge() != nil {
+				return m.GetGauge().GetValue()
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
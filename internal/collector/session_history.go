@@ -0,0 +1,315 @@
+package collector
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// postgresSessionSampleQuery defines query for sampling active sessions. Query ID is taken using to_jsonb(), because
+// query_id column is available since Postgres 14.
+const postgresSessionSampleQuery = "SELECT datname AS database, coalesce(wait_event_type, 'CPU') AS wait_class, " +
+	"coalesce(to_jsonb(a)->>'query_id', '') AS queryid FROM pg_stat_activity a " +
+	"WHERE state = 'active' AND backend_type = 'client backend' AND pid <> pg_backend_pid()"
+
+// DefaultSessionHistoryMaxQueries defines default number of queries with the most active sessions exposed by session
+// history metrics.
+const DefaultSessionHistoryMaxQueries = 20
+
+// sessionHistoryWindows defines windows which active sessions are averaged over.
+var sessionHistoryWindows = []struct {
+	name     string
+	duration time.Duration
+}{
+	{name: "1m", duration: time.Minute},
+	{name: "5m", duration: 5 * time.Minute},
+}
+
+// activeSession defines a set of properties which active sessions are accounted by.
+type activeSession struct {
+	database  string
+	waitClass string
+	queryid   string
+}
+
+// sessionSample defines active sessions observed at particular moment.
+type sessionSample struct {
+	ts       time.Time
+	sessions []activeSession
+}
+
+// sessionHistory is the ring buffer of active sessions samples.
+type sessionHistory struct {
+	mu      sync.Mutex
+	samples []sessionSample
+	next    int // position of the next sample
+	count   int // number of samples stored
+}
+
+// newSessionHistory creates new sessionHistory which keeps samples for the longest window.
+func newSessionHistory(interval time.Duration) *sessionHistory {
+	size := int(sessionHistoryWindows[len(sessionHistoryWindows)-1].duration/interval) + 1
+	return &sessionHistory{samples: make([]sessionSample, size)}
+}
+
+// add puts sample into the history, the oldest sample is overwritten when history is full.
+func (h *sessionHistory) add(s sessionSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.count < len(h.samples) {
+		h.count++
+	}
+}
+
+// sessionHistoryAverages defines average number of active sessions by wait class, database and query.
+type sessionHistoryAverages struct {
+	waitClasses map[string]float64
+	databases   map[string]float64
+	queries     map[string]float64
+}
+
+// average returns average number of active sessions observed in samples taken during window before now. Returns
+// false when there are no such samples.
+func (h *sessionHistory) average(now time.Time, window time.Duration) (sessionHistoryAverages, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	avg := sessionHistoryAverages{
+		waitClasses: map[string]float64{},
+		databases:   map[string]float64{},
+		queries:     map[string]float64{},
+	}
+
+	var n float64
+	for i := 0; i < h.count; i++ {
+		s := h.samples[i]
+		if now.Sub(s.ts) >= window {
+			continue
+		}
+
+		n++
+		for _, session := range s.sessions {
+			avg.waitClasses[session.waitClass]++
+			avg.databases[session.database]++
+			if session.queryid != "" && session.queryid != "0" {
+				avg.queries[session.queryid]++
+			}
+		}
+	}
+
+	if n == 0 {
+		return avg, false
+	}
+
+	for _, m := range []map[string]float64{avg.waitClasses, avg.databases, avg.queries} {
+		for k := range m {
+			m[k] /= n
+		}
+	}
+
+	return avg, true
+}
+
+// sessionSampler periodically samples active sessions of the service and exposes averaged "active session history".
+type sessionSampler struct {
+	interval    time.Duration
+	connString  string
+	connTimeout int
+	settings    map[string]string
+	maxQueries  int
+	history     *sessionHistory
+	// paused returns true when the service should not be sampled, e.g. it is silenced or the instance is standby.
+	paused func() bool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	waitClasses typedDesc
+	databases   typedDesc
+	queries     typedDesc
+}
+
+// newSessionSampler creates new sessionSampler, returns nil if sampling is disabled or not supported by the service.
+// Sampling is skipped while passed paused function returns true.
+func newSessionSampler(config Config, constLabels labels, paused func() bool) *sessionSampler {
+	if config.SessionSamplingInterval <= 0 || config.ServiceType != model.ServiceTypePostgresql {
+		return nil
+	}
+
+	newDesc := func(name, help, label string) typedDesc {
+		return newBuiltinTypedDesc(
+			descOpts{"postgres", "session_history", name, help, 0},
+			prometheus.GaugeValue,
+			[]string{"window", label}, constLabels,
+			filter.New(),
+		)
+	}
+
+	return &sessionSampler{
+		interval:    config.SessionSamplingInterval,
+		connString:  config.ConnString,
+		connTimeout: config.ConnTimeout,
+		settings:    config.SessionSettings,
+		maxQueries:  config.SessionHistoryMaxQueries,
+		history:     newSessionHistory(config.SessionSamplingInterval),
+		paused:      paused,
+		waitClasses: newDesc("wait_class_active_sessions", "Average number of active sessions by wait class over the window.", "wait_class"),
+		databases:   newDesc("database_active_sessions", "Average number of active sessions by database over the window.", "database"),
+		queries:     newDesc("query_active_sessions", "Average number of active sessions by query ID over the window.", "queryid"),
+	}
+}
+
+// start runs sampling in background until stop is called.
+func (s *sessionSampler) start() {
+	if s == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Go(func() {
+		s.run(ctx)
+	})
+}
+
+// stop stops sampling and waits until background goroutine is finished.
+func (s *sessionSampler) stop() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+}
+
+// run samples active sessions every interval. Connection is kept open between samples and reopened after failures,
+// connection is closed while sampling is paused.
+func (s *sessionSampler) run(ctx context.Context) {
+	var conn *store.DB
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if s.paused != nil && s.paused() {
+				if conn != nil {
+					conn.Close()
+					conn = nil
+				}
+				continue
+			}
+
+			if conn == nil {
+				var err error
+				conn, err = store.NewWithSettings(s.connString, s.connTimeout, s.settings)
+				if err != nil {
+					log.Warnf("session history: connect failed: %s; skip", err)
+					continue
+				}
+			}
+
+			res, err := conn.Query(postgresSessionSampleQuery)
+			if err != nil {
+				log.Warnf("session history: sampling active sessions failed: %s; skip", err)
+				conn.Close()
+				conn = nil
+				continue
+			}
+
+			s.history.add(sessionSample{ts: now, sessions: parseActiveSessions(res)})
+		}
+	}
+}
+
+// send sends averaged active sessions into channel.
+func (s *sessionSampler) send(ch chan<- prometheus.Metric) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, w := range sessionHistoryWindows {
+		avg, ok := s.history.average(now, w.duration)
+		if !ok {
+			continue
+		}
+
+		for k, v := range avg.waitClasses {
+			ch <- s.waitClasses.newConstMetric(v, w.name, k)
+		}
+		for k, v := range avg.databases {
+			ch <- s.databases.newConstMetric(v, w.name, k)
+		}
+		for _, k := range topQueries(avg.queries, s.maxQueries) {
+			ch <- s.queries.newConstMetric(avg.queries[k], w.name, k)
+		}
+	}
+}
+
+// topQueries returns IDs of limit queries with the most active sessions, all queries are returned when limit is 0.
+func topQueries(queries map[string]float64, limit int) []string {
+	ids := make([]string, 0, len(queries))
+	for k := range queries {
+		ids = append(ids, k)
+	}
+
+	if limit <= 0 || len(ids) <= limit {
+		return ids
+	}
+
+	slices.SortFunc(ids, func(a, b string) int {
+		if queries[a] != queries[b] {
+			if queries[a] > queries[b] {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	return ids[:limit]
+}
+
+// parseActiveSessions parses PGResult and returns active sessions.
+func parseActiveSessions(r *model.PGResult) []activeSession {
+	var sessions = make([]activeSession, 0, len(r.Rows))
+
+	for _, row := range r.Rows {
+		var session activeSession
+
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "database":
+				session.database = row[i].String
+			case "wait_class":
+				session.waitClass = row[i].String
+			case "queryid":
+				session.queryid = row[i].String
+			}
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sessionHistory(t *testing.T) {
+	h := newSessionHistory(time.Minute)
+	assert.Len(t, h.samples, 6)
+
+	now := time.Now()
+
+	_, ok := h.average(now, time.Minute)
+	assert.False(t, ok)
+
+	// Old samples are overwritten when history is full.
+	for i := 10; i > 0; i-- {
+		h.add(sessionSample{
+			ts:       now.Add(-time.Duration(i) * time.Minute),
+			sessions: []activeSession{{database: "old", waitClass: "IO"}},
+		})
+	}
+	h.add(sessionSample{
+		ts: now.Add(-30 * time.Second),
+		sessions: []activeSession{
+			{database: "testdb", waitClass: "CPU", queryid: "123"},
+			{database: "testdb", waitClass: "Lock", queryid: "123"},
+			{database: "otherdb", waitClass: "CPU", queryid: "0"},
+		},
+	})
+	h.add(sessionSample{
+		ts:       now,
+		sessions: []activeSession{{database: "testdb", waitClass: "CPU", queryid: "456"}},
+	})
+	assert.Equal(t, 6, h.count)
+
+	avg, ok := h.average(now, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]float64{"CPU": 1.5, "Lock": 0.5}, avg.waitClasses)
+	assert.Equal(t, map[string]float64{"testdb": 1.5, "otherdb": 0.5}, avg.databases)
+	assert.Equal(t, map[string]float64{"123": 1, "456": 0.5}, avg.queries)
+
+	// Samples taken 1-4 minutes ago are still kept for 5m window.
+	avg, ok = h.average(now, 5*time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]float64{"CPU": 0.5, "Lock": 1.0 / 6, "IO": 4.0 / 6}, avg.waitClasses)
+}
+
+func Test_sessionSampler(t *testing.T) {
+	// Sampling is disabled.
+	s := newSessionSampler(Config{ServiceType: model.ServiceTypePostgresql}, labels{"service_id": "test"}, nil)
+	assert.Nil(t, s)
+	s.start()
+	s.stop()
+
+	ch := make(chan prometheus.Metric, 10)
+	s.send(ch)
+	assert.Len(t, ch, 0)
+
+	// Sampling is not supported by Pgbouncer.
+	s = newSessionSampler(Config{ServiceType: model.ServiceTypePgbouncer, SessionSamplingInterval: time.Second}, labels{"service_id": "test"}, nil)
+	assert.Nil(t, s)
+
+	s = newSessionSampler(Config{
+		ServiceType:              model.ServiceTypePostgresql,
+		ConnString:               "host=127.0.0.1 port=1 user=pgscv dbname=postgres",
+		ConnTimeout:              1,
+		SessionSamplingInterval:  time.Hour,
+		SessionHistoryMaxQueries: 1,
+	}, labels{"service_id": "test"}, nil)
+	assert.NotNil(t, s)
+
+	s.history.add(sessionSample{ts: time.Now(), sessions: []activeSession{
+		{database: "testdb", waitClass: "CPU", queryid: "123"},
+		{database: "testdb", waitClass: "CPU", queryid: "456"},
+	}})
+	s.send(ch)
+	close(ch)
+	// 3 metrics for each of 2 windows, queries are limited.
+	assert.Len(t, ch, 6)
+
+	s.start()
+	s.stop()
+
+	// Service is not sampled while sampling is paused.
+	var paused atomic.Int32
+	s = newSessionSampler(Config{
+		ServiceType:             model.ServiceTypePostgresql,
+		ConnString:              "host=127.0.0.1 port=1 user=pgscv dbname=postgres",
+		ConnTimeout:             1,
+		SessionSamplingInterval: 10 * time.Millisecond,
+	}, labels{"service_id": "test"}, func() bool { paused.Add(1); return true })
+
+	s.start()
+	assert.Eventually(t, func() bool { return paused.Load() >= 2 }, time.Second, 10*time.Millisecond)
+	s.stop()
+	assert.Equal(t, 0, s.history.count)
+}
+
+func Test_topQueries(t *testing.T) {
+	queries := map[string]float64{"1": 0.5, "2": 2, "3": 1, "4": 1}
+
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, topQueries(queries, 0))
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, topQueries(queries, 10))
+	assert.Equal(t, []string{"2", "3"}, topQueries(queries, 2))
+}
+
+func Test_parseActiveSessions(t *testing.T) {
+	res := &model.PGResult{
+		Nrows: 2,
+		Ncols: 3,
+		Colnames: []pgproto3.FieldDescription{
+			{Name: []byte("database")}, {Name: []byte("wait_class")}, {Name: []byte("queryid")},
+		},
+		Rows: [][]sql.NullString{
+			{{String: "testdb", Valid: true}, {String: "CPU", Valid: true}, {String: "-4251875520347463101", Valid: true}},
+			{{String: "otherdb", Valid: true}, {String: "IO", Valid: true}, {String: "", Valid: true}},
+		},
+	}
+
+	want := []activeSession{
+		{database: "testdb", waitClass: "CPU", queryid: "-4251875520347463101"},
+		{database: "otherdb", waitClass: "IO"},
+	}
+
+	assert.Equal(t, want, parseActiveSessions(res))
+}
@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// silenceAllCollectors defines name used when whole service is silenced.
+const silenceAllCollectors = "all"
+
+// silences keeps expiration times of silenced collectors of the service. Silenced collectors are not executed
+// during scrapes, e.g. during planned maintenance.
+type silences struct {
+	store map[string]time.Time
+	mu    sync.Mutex
+	desc  typedDesc
+}
+
+// newSilences creates new silences.
+func newSilences(constLabels labels) *silences {
+	return &silences{
+		store: map[string]time.Time{},
+		desc: newBuiltinTypedDesc(
+			descOpts{"pgscv", "service", "silenced", "Number of seconds left until silence of the service's collector expires, 'all' means whole service is silenced.", 0},
+			prometheus.GaugeValue,
+			[]string{"collector"}, constLabels,
+			filter.New(),
+		),
+	}
+}
+
+// add silences passed collectors for specified duration, when no collectors passed, whole service is silenced.
+func (s *silences) add(collectors []string, d time.Duration) {
+	if len(collectors) == 0 {
+		collectors = []string{silenceAllCollectors}
+	}
+
+	until := time.Now().Add(d)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range collectors {
+		s.store[c] = until
+	}
+}
+
+// active returns silenced collectors and seconds left until their silences expire. Expired silences are removed.
+func (s *silences) active() map[string]float64 {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := map[string]float64{}
+	for c, until := range s.store {
+		if !until.After(now) {
+			delete(s.store, c)
+			continue
+		}
+		res[c] = until.Sub(now).Seconds()
+	}
+
+	return res
+}
+
+// serviceSilenced returns true if whole service is silenced.
+func (s *silences) serviceSilenced() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.store[silenceAllCollectors].After(time.Now())
+}
+
+// send sends metrics about active silences into channel.
+func (s *silences) send(active map[string]float64, ch chan<- prometheus.Metric) {
+	for c, v := range active {
+		ch <- s.desc.newConstMetric(v, c)
+	}
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_silences(t *testing.T) {
+	s := newSilences(labels{"service_id": "test"})
+	assert.Empty(t, s.active())
+
+	s.add([]string{"postgres/activity"}, time.Hour)
+	s.add([]string{"postgres/tables"}, -time.Second)
+	assert.False(t, s.serviceSilenced())
+	s.add(nil, time.Minute)
+	assert.True(t, s.serviceSilenced())
+
+	active := s.active()
+	assert.Len(t, active, 2)
+	assert.Greater(t, active["postgres/activity"], float64(3500))
+	assert.Greater(t, active[silenceAllCollectors], float64(50))
+
+	// Expired silences are removed.
+	assert.NotContains(t, s.store, "postgres/tables")
+
+	ch := make(chan prometheus.Metric, 2)
+	s.send(active, ch)
+	close(ch)
+	assert.Len(t, ch, 2)
+}
+
+func TestPgscvCollector_Silence(t *testing.T) {
+	c := PgscvCollector{
+		Collectors: map[string]Collector{
+			"postgres/activity": newSeriesCollector("activity", 5),
+			"postgres/tables":   newSeriesCollector("tables", 10),
+		},
+		dropped:  newDroppedSeries(labels{"service_id": "test"}),
+		silences: newSilences(labels{"service_id": "test"}),
+	}
+
+	assert.Error(t, c.Silence([]string{"postgres/unknown"}, time.Minute))
+	assert.NoError(t, c.Silence([]string{"postgres/tables"}, time.Minute))
+
+	// Silenced collector is skipped, silence metric is sent instead.
+	ch := make(chan prometheus.Metric, 20)
+	c.Collect(ch)
+	close(ch)
+	assert.Len(t, ch, 6)
+
+	// Whole service is silenced, only silence metrics are sent.
+	assert.NoError(t, c.Silence(nil, time.Minute))
+	ch = make(chan prometheus.Metric, 20)
+	c.Collect(ch)
+	close(ch)
+	assert.Len(t, ch, 2)
+}
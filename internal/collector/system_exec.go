@@ -0,0 +1,211 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	cmodel "github.com/prometheus/common/model"
+)
+
+const (
+	// defaultExecInterval defines default interval of commands execution.
+	defaultExecInterval = time.Minute
+	// defaultExecTimeout defines default max duration of command execution.
+	defaultExecTimeout = 10 * time.Second
+)
+
+// execCollector defines metric descriptors and commands state.
+type execCollector struct {
+	success     typedDesc
+	duration    typedDesc
+	constLabels labels
+	filters     filter.Filters
+	commands    []*execCommand
+}
+
+// execCommand defines a command and results of its last execution.
+type execCommand struct {
+	model.Command
+	families map[string]*dto.MetricFamily
+	success  float64
+	duration float64
+	lastRun  time.Time
+	mu       sync.Mutex
+}
+
+// NewExecCollector returns a new Collector which executes local commands configured in collector settings and exposes
+// metrics printed by commands in Prometheus text format. Output of command is reused until the next execution.
+func NewExecCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	commands := make([]*execCommand, 0, len(settings.Commands))
+	for _, cmd := range settings.Commands {
+		if cmd.Interval <= 0 {
+			cmd.Interval = defaultExecInterval
+		}
+		if cmd.Timeout <= 0 {
+			cmd.Timeout = defaultExecTimeout
+		}
+		commands = append(commands, &execCommand{Command: cmd})
+	}
+
+	return &execCollector{
+		success: newBuiltinTypedDesc(
+			descOpts{"pgscv", "exec", "command_success", "Whether the last execution of the command succeeded (1) or failed (0).", 0},
+			prometheus.GaugeValue,
+			[]string{"command"}, constLabels,
+			settings.Filters,
+		),
+		duration: newBuiltinTypedDesc(
+			descOpts{"pgscv", "exec", "command_duration_seconds", "Duration of the last execution of the command, in seconds.", 0},
+			prometheus.GaugeValue,
+			[]string{"command"}, constLabels,
+			settings.Filters,
+		),
+		constLabels: constLabels,
+		filters:     settings.Filters,
+		commands:    commands,
+	}, nil
+}
+
+// Update method executes commands which are due, and sends metrics printed by commands to Prometheus.
+func (c *execCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	for _, cmd := range c.commands {
+		cmd.mu.Lock()
+
+		if cmd.lastRun.IsZero() || time.Since(cmd.lastRun) >= cmd.Interval {
+			cmd.run()
+		}
+
+		ch <- c.success.newConstMetric(cmd.success, cmd.Name)
+		ch <- c.duration.newConstMetric(cmd.duration, cmd.Name)
+
+		for _, mf := range cmd.families {
+			for _, m := range mf.GetMetric() {
+				if metric := c.newMetric(mf, m); metric != nil {
+					ch <- metric
+				}
+			}
+		}
+
+		cmd.mu.Unlock()
+	}
+
+	return nil
+}
+
+// run executes command and parses its output. Metrics of the previous execution are dropped if execution failed.
+func (cmd *execCommand) run() {
+	start := time.Now()
+	families, err := cmd.execute()
+	cmd.duration = time.Since(start).Seconds()
+	cmd.lastRun = start
+
+	if err != nil {
+		log.Warnf("[system exec collector]: command %s failed: %s", cmd.Name, err)
+		cmd.families = nil
+		cmd.success = 0
+		return
+	}
+
+	cmd.families = families
+	cmd.success = 1
+}
+
+// execute runs command with configured timeout and environment, and parses its output in Prometheus text format.
+func (cmd *execCommand) execute() (map[string]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cmd.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	// #nosec G204
+	command := exec.CommandContext(ctx, cmd.Command.Command, cmd.Args...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	// Don't wait for output of child processes which survived killed command.
+	command.WaitDelay = time.Second
+	command.Env = os.Environ()
+	for k, v := range cmd.Env {
+		command.Env = append(command.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	if err := command.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timeout %s exceeded", cmd.Timeout)
+		}
+		return nil, fmt.Errorf("%s: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return parseExecOutput(&stdout)
+}
+
+// parseExecOutput parses metrics in Prometheus text format.
+func parseExecOutput(in *bytes.Buffer) (map[string]*dto.MetricFamily, error) {
+	parser := expfmt.NewTextParser(cmodel.UTF8Validation)
+	return parser.TextToMetricFamilies(in)
+}
+
+// newMetric creates metric from parsed metric family, service labels are attached to metric. Metrics which labels are
+// matched by collector filters are skipped.
+func (c *execCollector) newMetric(mf *dto.MetricFamily, m *dto.Metric) prometheus.Metric {
+	labelNames := make([]string, 0, len(m.GetLabel()))
+	labelValues := make([]string, 0, len(m.GetLabel()))
+
+	pairs := m.GetLabel()
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].GetName() < pairs[j].GetName() })
+
+	for _, l := range pairs {
+		re := c.filters[l.GetName()]
+		if !re.Pass(l.GetValue()) {
+			return nil
+		}
+		labelNames = append(labelNames, l.GetName())
+		labelValues = append(labelValues, l.GetValue())
+	}
+
+	desc := prometheus.NewDesc(mf.GetName(), mf.GetHelp(), labelNames, prometheus.Labels(c.constLabels))
+
+	var (
+		metric prometheus.Metric
+		err    error
+	)
+
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		metric, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), labelValues...)
+	case dto.MetricType_GAUGE:
+		metric, err = prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+	case dto.MetricType_SUMMARY:
+		quantiles := map[float64]float64{}
+		for _, q := range m.GetSummary().GetQuantile() {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		metric, err = prometheus.NewConstSummary(desc, m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum(), quantiles, labelValues...)
+	case dto.MetricType_HISTOGRAM:
+		buckets := map[float64]uint64{}
+		for _, b := range m.GetHistogram().GetBucket() {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		metric, err = prometheus.NewConstHistogram(desc, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum(), buckets, labelValues...)
+	default:
+		metric, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), labelValues...)
+	}
+
+	if err != nil {
+		log.Errorf("create metric %s failed: %s; skip", mf.GetName(), err)
+		return nil
+	}
+
+	return metric
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecCollector_Update(t *testing.T) {
+	settings := model.CollectorSettings{
+		Commands: model.Commands{
+			{Name: "ok", Command: "/bin/sh", Args: []string{"-c", "echo \"# TYPE example_total counter\nexample_total{kind=\\\"$KIND\\\"} 42\""}, Env: map[string]string{"KIND": "test"}},
+			{Name: "failed", Command: "/bin/sh", Args: []string{"-c", "exit 1"}},
+			{Name: "timeout", Command: "/bin/sh", Args: []string{"-c", "sleep 5"}, Timeout: 100 * time.Millisecond},
+		},
+	}
+
+	c, err := NewExecCollector(labels{"service_id": "system:0"}, settings)
+	assert.NoError(t, err)
+
+	ch := make(chan prometheus.Metric, 100)
+	assert.NoError(t, c.Update(Config{}, ch))
+	close(ch)
+
+	var names []string
+	for m := range ch {
+		names = append(names, m.Desc().String())
+	}
+
+	// Two metrics about execution per command and one metric printed by successful command.
+	assert.Len(t, names, 7)
+	assert.Contains(t, names[2], `fqName: "example_total"`)
+
+	cmd := c.(*execCollector).commands
+	assert.Equal(t, float64(1), cmd[0].success)
+	assert.Equal(t, float64(0), cmd[1].success)
+	assert.Equal(t, float64(0), cmd[2].success)
+	assert.Equal(t, defaultExecInterval, cmd[0].Interval)
+}
+
+func Test_parseExecOutput(t *testing.T) {
+	in := bytes.NewBufferString("# HELP example_seconds Example.\n# TYPE example_seconds gauge\nexample_seconds{a=\"1\"} 1.5\nexample_seconds{a=\"2\"} 2\n")
+
+	families, err := parseExecOutput(in)
+	assert.NoError(t, err)
+	assert.Len(t, families, 1)
+	assert.Len(t, families["example_seconds"].GetMetric(), 2)
+
+	_, err = parseExecOutput(bytes.NewBufferString("invalid output {"))
+	assert.Error(t, err)
+}
+
+func Test_execCollector_newMetric(t *testing.T) {
+	families, err := parseExecOutput(bytes.NewBufferString("# TYPE example histogram\nexample_bucket{le=\"1\"} 1\nexample_bucket{le=\"+Inf\"} 2\nexample_sum 3\nexample_count 2\n"))
+	assert.NoError(t, err)
+
+	c, err := NewExecCollector(labels{"service_id": "system:0"}, model.CollectorSettings{})
+	assert.NoError(t, err)
+
+	mf := families["example"]
+	assert.NotNil(t, c.(*execCollector).newMetric(mf, mf.GetMetric()[0]))
+}
@@ -0,0 +1,519 @@
+//go:build !linux
+
+// Package collector is a pgSCV collectors
+package collector
+
+import (
+	"fmt"
+
+	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// System collectors for non-Linux platforms (FreeBSD, macOS). Procfs and sysfs are not available there, hence stats
+// are requested using gopsutil. Metrics names and labels are the same as produced by Linux collectors.
+
+type filesystemCollector struct {
+	bytes      typedDesc
+	bytesTotal typedDesc
+	files      typedDesc
+	filesTotal typedDesc
+}
+
+// NewFilesystemCollector returns a new Collector exposing filesystem stats.
+func NewFilesystemCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+
+	// Define default filters (if no already present) to avoid collecting metrics about exotic filesystems.
+	if _, ok := settings.Filters["fstype"]; !ok {
+		if settings.Filters == nil {
+			settings.Filters = filter.New()
+		}
+
+		settings.Filters.Add("fstype", filter.Filter{Include: `^(ufs|zfs|apfs|hfs)$`})
+		err := settings.Filters.Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &filesystemCollector{
+		bytes: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "bytes", "Number of bytes of filesystem by usage.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype", "usage"}, constLabels,
+			settings.Filters,
+		),
+		bytesTotal: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "bytes_total", "Total number of bytes of filesystem capacity.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
+		files: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "files", "Number of files (inodes) of filesystem by usage.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype", "usage"}, constLabels,
+			settings.Filters,
+		),
+		filesTotal: newBuiltinTypedDesc(
+			descOpts{"node", "filesystem", "files_total", "Total number of files (inodes) of filesystem capacity.", 0},
+			prometheus.GaugeValue,
+			[]string{"device", "mountpoint", "fstype"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects filesystem usage statistics.
+func (c *filesystemCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return fmt.Errorf("get filesystem stats failed: %s", err)
+	}
+
+	for _, p := range partitions {
+		usage, err := disk.Usage(p.Mountpoint)
+		if err != nil {
+			log.Warnf("get usage of %s failed: %s; skip", p.Mountpoint, err)
+			continue
+		}
+
+		// Truncate device paths to device names, e.g /dev/ada0p2 -> ada0p2
+		device := truncateDeviceName(p.Device)
+
+		// bytes; free = avail + reserved; total = used + free
+		ch <- c.bytesTotal.newConstMetric(float64(usage.Total), device, p.Mountpoint, p.Fstype)
+		ch <- c.bytes.newConstMetric(float64(usage.Free), device, p.Mountpoint, p.Fstype, "avail")
+		ch <- c.bytes.newConstMetric(float64(usage.Total-usage.Used-usage.Free), device, p.Mountpoint, p.Fstype, "reserved")
+		ch <- c.bytes.newConstMetric(float64(usage.Used), device, p.Mountpoint, p.Fstype, "used")
+		// files (inodes)
+		ch <- c.filesTotal.newConstMetric(float64(usage.InodesTotal), device, p.Mountpoint, p.Fstype)
+		ch <- c.files.newConstMetric(float64(usage.InodesFree), device, p.Mountpoint, p.Fstype, "free")
+		ch <- c.files.newConstMetric(float64(usage.InodesUsed), device, p.Mountpoint, p.Fstype, "used")
+	}
+
+	return nil
+}
+
+type diskstatsCollector struct {
+	completed      typedDesc
+	completedAll   typedDesc
+	merged         typedDesc
+	mergedAll      typedDesc
+	bytes          typedDesc
+	bytesAll       typedDesc
+	times          typedDesc
+	timesAll       typedDesc
+	ionow          typedDesc
+	iotime         typedDesc
+	iotimeweighted typedDesc
+}
+
+// NewDiskstatsCollector returns a new Collector exposing disk device stats.
+func NewDiskstatsCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+
+	// Define default filters (if no already present) to avoid collecting metrics about virtual devices and device partitions.
+	if _, ok := settings.Filters["device"]; !ok {
+		if settings.Filters == nil {
+			settings.Filters = filter.New()
+		}
+
+		settings.Filters.Add("device", filter.Filter{Exclude: `^(md|cd|pass)\d+$|(p|s)\d+$`})
+		err := settings.Filters.Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diskLabelNames := []string{"device", "type"}
+
+	return &diskstatsCollector{
+		completed: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "completed_total", "The total number of IO requests completed successfully of each type.", 0},
+			prometheus.CounterValue,
+			diskLabelNames, constLabels,
+			settings.Filters,
+		),
+		completedAll: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "completed_all_total", "The total number of IO requests completed successfully.", 0},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		merged: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "merged_total", "The total number of merged IO requests of each type.", 0},
+			prometheus.CounterValue,
+			diskLabelNames, constLabels,
+			settings.Filters,
+		),
+		mergedAll: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "merged_all_total", "The total number of merged IO requests.", 0},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		bytes: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "bytes_total", "The total number of bytes processed by IO requests of each type.", 0},
+			prometheus.CounterValue,
+			diskLabelNames, constLabels,
+			settings.Filters,
+		),
+		bytesAll: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "bytes_all_total", "The total number of bytes processed by IO requests.", 0},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		times: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "time_seconds_total", "The total number of seconds spent on all requests of each type.", .001},
+			prometheus.CounterValue,
+			diskLabelNames, constLabels,
+			settings.Filters,
+		),
+		timesAll: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "time_seconds_all_total", "The total number of seconds spent on all requests.", .001},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		ionow: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "io_now", "The number of I/Os currently in progress.", 0},
+			prometheus.GaugeValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		iotime: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "io_time_seconds_total", "Total seconds spent doing I/Os.", .001},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+		iotimeweighted: newBuiltinTypedDesc(
+			descOpts{"node", "disk", "io_time_weighted_seconds_total", "The weighted number of seconds spent doing I/Os.", .001},
+			prometheus.CounterValue,
+			[]string{"device"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects disk devices statistics.
+func (c *diskstatsCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	stats, err := disk.IOCounters()
+	if err != nil {
+		return fmt.Errorf("get diskstats failed: %s", err)
+	}
+
+	for dev, s := range stats {
+		ch <- c.completed.newConstMetric(float64(s.ReadCount), dev, "read")
+		ch <- c.merged.newConstMetric(float64(s.MergedReadCount), dev, "read")
+		ch <- c.bytes.newConstMetric(float64(s.ReadBytes), dev, "read")
+		ch <- c.times.newConstMetric(float64(s.ReadTime), dev, "read")
+		ch <- c.completed.newConstMetric(float64(s.WriteCount), dev, "write")
+		ch <- c.merged.newConstMetric(float64(s.MergedWriteCount), dev, "write")
+		ch <- c.bytes.newConstMetric(float64(s.WriteBytes), dev, "write")
+		ch <- c.times.newConstMetric(float64(s.WriteTime), dev, "write")
+		ch <- c.ionow.newConstMetric(float64(s.IopsInProgress), dev)
+		ch <- c.iotime.newConstMetric(float64(s.IoTime), dev)
+		ch <- c.iotimeweighted.newConstMetric(float64(s.WeightedIO), dev)
+
+		ch <- c.completedAll.newConstMetric(float64(s.ReadCount+s.WriteCount), dev)
+		ch <- c.mergedAll.newConstMetric(float64(s.MergedReadCount+s.MergedWriteCount), dev)
+		ch <- c.bytesAll.newConstMetric(float64(s.ReadBytes+s.WriteBytes), dev)
+		ch <- c.timesAll.newConstMetric(float64(s.ReadTime+s.WriteTime), dev)
+	}
+
+	return nil
+}
+
+type netdevCollector struct {
+	bytes   typedDesc
+	packets typedDesc
+	events  typedDesc
+}
+
+// NewNetdevCollector returns a new Collector exposing network interfaces stats.
+func NewNetdevCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+
+	// Define default filters (if no already present) to avoid collecting metrics about virtual interfaces.
+	if _, ok := settings.Filters["device"]; !ok {
+		if settings.Filters == nil {
+			settings.Filters = filter.New()
+		}
+
+		settings.Filters.Add("device", filter.Filter{Exclude: `^(lo|pflog|enc|bridge|utun)\d*$`})
+		err := settings.Filters.Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &netdevCollector{
+		bytes: newBuiltinTypedDesc(
+			descOpts{"node", "network", "bytes_total", "Total number of bytes processed by network device, by each direction.", 0},
+			prometheus.CounterValue,
+			[]string{"device", "type"}, constLabels,
+			settings.Filters,
+		),
+		packets: newBuiltinTypedDesc(
+			descOpts{"node", "network", "packets_total", "Total number of packets processed by network device, by each direction.", 0},
+			prometheus.CounterValue,
+			[]string{"device", "type"}, constLabels,
+			settings.Filters,
+		),
+		events: newBuiltinTypedDesc(
+			descOpts{"node", "network", "events_total", "Total number of events occurred on network device, by each type and direction.", 0},
+			prometheus.CounterValue,
+			[]string{"device", "type", "event"}, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update method collects network interfaces statistics
+func (c *netdevCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	stats, err := net.IOCounters(true)
+	if err != nil {
+		return fmt.Errorf("get network devices stats failed: %s", err)
+	}
+
+	for _, s := range stats {
+		// recv
+		ch <- c.bytes.newConstMetric(float64(s.BytesRecv), s.Name, "recv")
+		ch <- c.packets.newConstMetric(float64(s.PacketsRecv), s.Name, "recv")
+		ch <- c.events.newConstMetric(float64(s.Errin), s.Name, "recv", "errs")
+		ch <- c.events.newConstMetric(float64(s.Dropin), s.Name, "recv", "drop")
+		ch <- c.events.newConstMetric(float64(s.Fifoin), s.Name, "recv", "fifo")
+
+		// sent
+		ch <- c.bytes.newConstMetric(float64(s.BytesSent), s.Name, "sent")
+		ch <- c.packets.newConstMetric(float64(s.PacketsSent), s.Name, "sent")
+		ch <- c.events.newConstMetric(float64(s.Errout), s.Name, "sent", "errs")
+		ch <- c.events.newConstMetric(float64(s.Dropout), s.Name, "sent", "drop")
+		ch <- c.events.newConstMetric(float64(s.Fifoout), s.Name, "sent", "fifo")
+	}
+
+	return nil
+}
+
+type loadaverageCollector struct {
+	load1  typedDesc
+	load5  typedDesc
+	load15 typedDesc
+}
+
+// NewLoadAverageCollector returns a new Collector exposing load average statistics.
+func NewLoadAverageCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &loadaverageCollector{
+		load1: newBuiltinTypedDesc(
+			descOpts{"node", "", "load1", "1m load average.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		load5: newBuiltinTypedDesc(
+			descOpts{"node", "", "load5", "5m load average.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		load15: newBuiltinTypedDesc(
+			descOpts{"node", "", "load15", "15m load average.", 0},
+			prometheus.GaugeValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes load average related metrics.
+func (c *loadaverageCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	stats, err := load.Avg()
+	if err != nil {
+		return fmt.Errorf("get load average stats failed: %s", err)
+	}
+
+	ch <- c.load1.newConstMetric(stats.Load1)
+	ch <- c.load5.newConstMetric(stats.Load5)
+	ch <- c.load15.newConstMetric(stats.Load15)
+
+	return nil
+}
+
+type meminfoCollector struct {
+	constLabels   labels
+	subsysFilters filter.Filters
+}
+
+// NewMeminfoCollector returns a new Collector exposing memory stats.
+func NewMeminfoCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &meminfoCollector{
+		constLabels:   constLabels,
+		subsysFilters: settings.Filters,
+	}, nil
+}
+
+// Update method collects memory statistics.
+func (c *meminfoCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return fmt.Errorf("get virtual memory stats failed: %s", err)
+	}
+
+	swap, err := mem.SwapMemory()
+	if err != nil {
+		return fmt.Errorf("get swap memory stats failed: %s", err)
+	}
+
+	// Use the same fields names as in /proc/meminfo, where it is possible.
+	meminfo := map[string]float64{
+		"MemTotal":     float64(vm.Total),
+		"MemFree":      float64(vm.Free),
+		"MemAvailable": float64(vm.Available),
+		"MemUsed":      float64(vm.Used),
+		"Buffers":      float64(vm.Buffers),
+		"Cached":       float64(vm.Cached),
+		"Active":       float64(vm.Active),
+		"Inactive":     float64(vm.Inactive),
+		"Wired":        float64(vm.Wired),
+		"Laundry":      float64(vm.Laundry),
+		"SwapTotal":    float64(swap.Total),
+		"SwapFree":     float64(swap.Free),
+		"SwapUsed":     float64(swap.Used),
+	}
+
+	for param, value := range meminfo {
+		desc := newBuiltinTypedDesc(
+			descOpts{"node", "memory", param, fmt.Sprintf("Memory information field %s.", param), 0},
+			prometheus.GaugeValue,
+			nil, c.constLabels,
+			c.subsysFilters,
+		)
+
+		ch <- desc.newConstMetric(value)
+	}
+
+	return nil
+}
+
+type cpuCollector struct {
+	cpu      typedDesc
+	cpuAll   typedDesc
+	uptime   typedDesc
+	idletime typedDesc
+}
+
+// NewCPUCollector returns a new Collector exposing kernel/system statistics.
+func NewCPUCollector(constLabels labels, settings model.CollectorSettings) (Collector, error) {
+	return &cpuCollector{
+		cpu: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "seconds_total", "Seconds the CPUs spent in each mode.", 0},
+			prometheus.CounterValue,
+			[]string{"mode"}, constLabels,
+			settings.Filters,
+		),
+		cpuAll: newBuiltinTypedDesc(
+			descOpts{"node", "cpu", "seconds_all_total", "Seconds the CPUs spent in all modes.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		uptime: newBuiltinTypedDesc(
+			descOpts{"node", "uptime", "up_seconds_total", "Total number of seconds the system has been up.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+		idletime: newBuiltinTypedDesc(
+			descOpts{"node", "uptime", "idle_seconds_total", "Total number of seconds all cores have spent idle.", 0},
+			prometheus.CounterValue,
+			nil, constLabels,
+			settings.Filters,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes cpu related metrics.
+func (c *cpuCollector) Update(_ Config, ch chan<- prometheus.Metric) error {
+	times, err := cpu.Times(false)
+	if err != nil {
+		return fmt.Errorf("collect cpu usage stats failed: %s; skip", err)
+	}
+
+	if len(times) == 0 {
+		return fmt.Errorf("collect cpu usage stats failed: no stats returned; skip")
+	}
+
+	uptime, err := host.Uptime()
+	if err != nil {
+		return fmt.Errorf("collect uptime stats failed: %s; skip", err)
+	}
+
+	// Collected time represents summary time spent by ALL cpu cores.
+	stat := times[0]
+	ch <- c.cpu.newConstMetric(stat.User, "user")
+	ch <- c.cpu.newConstMetric(stat.Nice, "nice")
+	ch <- c.cpu.newConstMetric(stat.System, "system")
+	ch <- c.cpu.newConstMetric(stat.Idle, "idle")
+	ch <- c.cpu.newConstMetric(stat.Irq, "irq")
+
+	ch <- c.cpuAll.newConstMetric(stat.User + stat.Nice + stat.System + stat.Idle + stat.Irq)
+
+	ch <- c.uptime.newConstMetric(float64(uptime))
+	ch <- c.idletime.newConstMetric(stat.Idle)
+
+	return nil
+}
+
+// unsupportedCollector is used for collectors which are not supported on the current platform.
+type unsupportedCollector struct {
+	name string
+}
+
+// NewSysconfigCollector returns a new Collector which does nothing on non-Linux platforms.
+func NewSysconfigCollector(_ labels, _ model.CollectorSettings) (Collector, error) {
+	return &unsupportedCollector{name: "system/sysconfig"}, nil
+}
+
+// NewCgroupCollector returns a new Collector which does nothing on non-Linux platforms.
+func NewCgroupCollector(_ labels, _ model.CollectorSettings) (Collector, error) {
+	return &unsupportedCollector{name: "system/cgroup"}, nil
+}
+
+// NewClockCollector returns a new Collector which does nothing on non-Linux platforms.
+func NewClockCollector(_ labels, _ model.CollectorSettings) (Collector, error) {
+	return &unsupportedCollector{name: "system/clock"}, nil
+}
+
+// NewSystemdCollector returns a new Collector which does nothing on non-Linux platforms.
+func NewSystemdCollector(_ labels, _ model.CollectorSettings) (Collector, error) {
+	return &unsupportedCollector{name: "system/systemd"}, nil
+}
+
+// NewSysInfoCollector returns a new Collector which does nothing on non-Linux platforms.
+func NewSysInfoCollector(_ labels, _ model.CollectorSettings) (Collector, error) {
+	return &unsupportedCollector{name: "system/sysinfo"}, nil
+}
+
+// Update method does nothing, collector is not supported on the current platform.
+func (c *unsupportedCollector) Update(_ Config, _ chan<- prometheus.Metric) error {
+	log.Debugf("[%s collector]: not supported on this platform, skip", c.name)
+	return nil
+}
+
+// defaultProcRoot defines default mountpoint of procfs, procfs is not used on non-Linux platforms.
+const defaultProcRoot = ""
+
+// findPostmasterPIDs returns PIDs of Postgres postmaster processes, local postmasters are not discovered on non-Linux
+// platforms.
+func findPostmasterPIDs(string) ([]int, error) {
+	return nil, nil
+}
@@ -0,0 +1,80 @@
+//go:build !linux
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/model"
+)
+
+func TestFilesystemCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_filesystem_bytes",
+			"node_filesystem_bytes_total",
+			"node_filesystem_files",
+			"node_filesystem_files_total",
+		},
+		collector: NewFilesystemCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestDiskstatsCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_disk_completed_total", "node_disk_completed_all_total",
+			"node_disk_merged_total", "node_disk_merged_all_total",
+			"node_disk_bytes_total", "node_disk_bytes_all_total",
+			"node_disk_time_seconds_total", "node_disk_time_seconds_all_total",
+			"node_disk_io_now", "node_disk_io_time_seconds_total", "node_disk_io_time_weighted_seconds_total",
+		},
+		collector: NewDiskstatsCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestNetdevCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		optional: []string{
+			"node_network_bytes_total",
+			"node_network_packets_total",
+			"node_network_events_total",
+		},
+		collector: NewNetdevCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestLoadAverageCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required:  []string{"node_load1", "node_load5", "node_load15"},
+		collector: NewLoadAverageCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestCPUCollector_Update(t *testing.T) {
+	var input = pipelineInput{
+		required: []string{
+			"node_cpu_seconds_total",
+			"node_cpu_seconds_all_total",
+			"node_uptime_up_seconds_total",
+			"node_uptime_idle_seconds_total",
+		},
+		collector: NewCPUCollector,
+	}
+
+	pipeline(t, input)
+}
+
+func TestUnsupportedCollector_Update(t *testing.T) {
+	for _, fn := range []func(labels, model.CollectorSettings) (Collector, error){NewSysconfigCollector, NewSysInfoCollector} {
+		pipeline(t, pipelineInput{collector: fn})
+	}
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// warmUp defines staggered first execution of heavy collectors of the service. Heavy collectors are not executed until
+// their start time, start times are spread over the warm-up window, so heavy queries of services created at the same
+// time (at startup or when discovery adds a batch of services) are not executed simultaneously.
+type warmUp struct {
+	starts map[string]time.Time
+}
+
+// newWarmUp creates warm-up of heavy collectors of the service, nil is returned when warm-up is disabled. Start offset
+// of each collector is derived from service ID and collector name, hence collectors of different services are spread
+// over the window and offsets don't change when service is recreated.
+func newWarmUp(serviceID string, collectors map[string]Collector, window time.Duration, now time.Time) *warmUp {
+	if window <= 0 {
+		return nil
+	}
+
+	w := &warmUp{starts: map[string]time.Time{}}
+	for name := range collectors {
+		if !stringsContains(standbyDisabledCollectors, name) {
+			continue
+		}
+
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(serviceID + "/" + name))
+		w.starts[name] = now.Add(time.Duration(h.Sum64() % uint64(window)))
+	}
+
+	return w
+}
+
+// pending returns true if start time of the collector has not come yet.
+func (w *warmUp) pending(name string, now time.Time) bool {
+	if w == nil {
+		return false
+	}
+
+	start, ok := w.starts[name]
+	return ok && now.Before(start)
+}
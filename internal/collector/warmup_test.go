@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newWarmUp(t *testing.T) {
+	collectors := map[string]Collector{
+		"postgres/activity":   nil,
+		"postgres/tables":     nil,
+		"postgres/statements": nil,
+	}
+	now := time.Now()
+
+	// Warm-up is disabled.
+	assert.Nil(t, newWarmUp("test", collectors, 0, now))
+	assert.False(t, (*warmUp)(nil).pending("postgres/tables", now))
+
+	w := newWarmUp("test", collectors, time.Minute, now)
+	assert.NotNil(t, w)
+
+	// Only heavy collectors are staggered within the window.
+	assert.Len(t, w.starts, 2)
+	for _, start := range w.starts {
+		assert.False(t, start.Before(now))
+		assert.True(t, start.Before(now.Add(time.Minute)))
+	}
+
+	assert.False(t, w.pending("postgres/activity", now))
+	assert.False(t, w.pending("postgres/tables", now.Add(time.Minute)))
+	assert.False(t, w.pending("postgres/statements", now.Add(time.Minute)))
+
+	// Offsets are stable for the same service and differ between services.
+	assert.Equal(t, w.starts, newWarmUp("test", collectors, time.Minute, now).starts)
+	assert.NotEqual(t, w.starts, newWarmUp("test2", collectors, time.Minute, now).starts)
+}
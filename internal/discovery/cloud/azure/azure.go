@@ -0,0 +1,175 @@
+// Package azure implements getter for Azure Database for PostgreSQL flexible servers
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/cherts/pgscv/discovery/log"
+)
+
+const (
+	// apiVersion defines version of Microsoft.DBforPostgreSQL REST API.
+	apiVersion = "2022-12-01"
+	// scope defines scope of tokens for requesting Azure Resource Manager API.
+	scope = "https://management.azure.com/.default"
+	// requestTimeout defines timeout of requests to Azure API.
+	requestTimeout = 30 * time.Second
+)
+
+// Credentials defines service principal used for requesting Azure API. When client secret is not specified,
+// DefaultAzureCredential is used (AZURE_* environment variables, workload identity, managed identity of the host or
+// Azure CLI), ClientID without client secret selects user-assigned managed identity.
+type Credentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// Server struct
+type Server struct {
+	Name            string
+	ResourceGroup   string
+	Location        string
+	FQDN            string
+	State           string
+	Version         string
+	ReplicationRole string
+	Tags            map[string]string
+}
+
+// Client implements requests to Azure Resource Manager API, tokens are requested and cached by the credential.
+type Client struct {
+	cred          azcore.TokenCredential
+	client        *http.Client
+	managementURL string
+}
+
+// NewClient returns client of Azure Resource Manager API.
+func NewClient(creds Credentials) (*Client, error) {
+	var cred azcore.TokenCredential
+	var err error
+	switch {
+	case creds.ClientSecret != "":
+		log.Debug("[Azure SD] Using client secret credential...")
+		cred, err = azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret, nil)
+	case creds.ClientID != "":
+		log.Debug("[Azure SD] Using user-assigned managed identity credential...")
+		cred, err = azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ClientID(creds.ClientID)})
+	default:
+		log.Debug("[Azure SD] Using default Azure credential...")
+		cred, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create credential failed: %w", err)
+	}
+
+	return newClient(cred), nil
+}
+
+// newClient returns client of Azure Resource Manager API authorized by passed credential.
+func newClient(cred azcore.TokenCredential) *Client {
+	return &Client{
+		cred:          cred,
+		client:        &http.Client{Timeout: requestTimeout},
+		managementURL: "https://management.azure.com",
+	}
+}
+
+// do sends request and decodes JSON response into v.
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.client.Do(req) // #nosec G704
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response: %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	return json.Unmarshal(content, v)
+}
+
+// serverList implements response of flexible servers list request.
+type serverList struct {
+	Value []struct {
+		ID         string            `json:"id"`
+		Name       string            `json:"name"`
+		Location   string            `json:"location"`
+		Tags       map[string]string `json:"tags"`
+		Properties struct {
+			FullyQualifiedDomainName string `json:"fullyQualifiedDomainName"`
+			State                    string `json:"state"`
+			Version                  string `json:"version"`
+			ReplicationRole          string `json:"replicationRole"`
+		} `json:"properties"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// ListFlexibleServers returns flexible servers of the subscription.
+func (c *Client) ListFlexibleServers(ctx context.Context, subscriptionID string) ([]Server, error) {
+	token, err := c.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		return nil, fmt.Errorf("request token failed: %w", err)
+	}
+
+	var servers []Server
+	next := c.managementURL + "/subscriptions/" + url.PathEscape(subscriptionID) +
+		"/providers/Microsoft.DBforPostgreSQL/flexibleServers?api-version=" + apiVersion
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+
+		var list serverList
+		err = c.do(req, &list)
+		if err != nil {
+			return nil, fmt.Errorf("list flexible servers failed: %w", err)
+		}
+
+		for _, s := range list.Value {
+			servers = append(servers, Server{
+				Name:            s.Name,
+				ResourceGroup:   resourceGroup(s.ID),
+				Location:        s.Location,
+				FQDN:            s.Properties.FullyQualifiedDomainName,
+				State:           s.Properties.State,
+				Version:         s.Properties.Version,
+				ReplicationRole: s.Properties.ReplicationRole,
+				Tags:            s.Tags,
+			})
+		}
+		next = list.NextLink
+	}
+
+	return servers, nil
+}
+
+// resourceGroup returns name of resource group from resource ID.
+func resourceGroup(id string) string {
+	parts := strings.Split(id, "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if strings.EqualFold(parts[i], "resourceGroups") {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
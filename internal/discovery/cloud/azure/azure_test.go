@@ -0,0 +1,103 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCredential implements azcore.TokenCredential returning static token.
+type testCredential struct {
+	token string
+}
+
+// GetToken implements azcore.TokenCredential interface.
+func (c testCredential) GetToken(_ context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if c.token == "" {
+		return azcore.AccessToken{}, errors.New("no token")
+	}
+	if !slices.Equal(opts.Scopes, []string{scope}) {
+		return azcore.AccessToken{}, fmt.Errorf("invalid scopes %v", opts.Scopes)
+	}
+	return azcore.AccessToken{Token: c.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func newTestServer() *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subscriptions/sub/providers/Microsoft.DBforPostgreSQL/flexibleServers":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("page") == "" {
+				_, _ = rw.Write([]byte(`{"value": [{"id": "/subscriptions/sub/resourceGroups/rg1/providers/Microsoft.DBforPostgreSQL/flexibleServers/pg1", "name": "pg1", "location": "westeurope", "tags": {"env": "prod"}, "properties": {"fullyQualifiedDomainName": "pg1.postgres.database.azure.com", "state": "Ready", "version": "16", "replicationRole": "Primary"}}], "nextLink": "` + ts.URL + r.URL.Path + `?page=2"}`))
+				return
+			}
+			_, _ = rw.Write([]byte(`{"value": [{"id": "/subscriptions/sub/resourceGroups/rg2/providers/Microsoft.DBforPostgreSQL/flexibleServers/pg2", "name": "pg2", "location": "westeurope", "properties": {"fullyQualifiedDomainName": "pg2.postgres.database.azure.com", "state": "Stopped", "version": "15"}}]}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts
+}
+
+func newTestClient(ts *httptest.Server, token string) *Client {
+	c := newClient(testCredential{token: token})
+	c.managementURL = ts.URL
+	return c
+}
+
+func TestClient_ListFlexibleServers(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	servers, err := newTestClient(ts, "token").ListFlexibleServers(context.Background(), "sub")
+	require.NoError(t, err)
+	assert.Equal(t, []Server{
+		{Name: "pg1", ResourceGroup: "rg1", Location: "westeurope", FQDN: "pg1.postgres.database.azure.com", State: "Ready", Version: "16", ReplicationRole: "Primary", Tags: map[string]string{"env": "prod"}},
+		{Name: "pg2", ResourceGroup: "rg2", Location: "westeurope", FQDN: "pg2.postgres.database.azure.com", State: "Stopped", Version: "15"},
+	}, servers)
+
+	// Test errors
+	_, err = newTestClient(ts, "").ListFlexibleServers(context.Background(), "sub")
+	assert.Error(t, err)
+
+	_, err = newTestClient(ts, "invalid").ListFlexibleServers(context.Background(), "sub")
+	assert.Error(t, err)
+
+	_, err = newTestClient(ts, "token").ListFlexibleServers(context.Background(), "invalid")
+	assert.Error(t, err)
+}
+
+func TestNewClient(t *testing.T) {
+	for _, creds := range []Credentials{
+		{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"},
+		{ClientID: "identity"},
+		{},
+	} {
+		c, err := NewClient(creds)
+		assert.NoError(t, err)
+		assert.NotNil(t, c.cred)
+	}
+
+	// Test errors
+	_, err := NewClient(Credentials{TenantID: "invalid tenant", ClientID: "client", ClientSecret: "secret"})
+	assert.Error(t, err)
+}
+
+func Test_resourceGroup(t *testing.T) {
+	assert.Equal(t, "rg1", resourceGroup("/subscriptions/sub/resourceGroups/rg1/providers/Microsoft.DBforPostgreSQL/flexibleServers/pg1"))
+	assert.Equal(t, "", resourceGroup("invalid"))
+}
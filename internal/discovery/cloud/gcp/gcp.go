@@ -0,0 +1,186 @@
+// Package gcp implements getter for GCP Cloud SQL for PostgreSQL instances
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cherts/pgscv/discovery/log"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// scope defines OAuth2 scope required for listing Cloud SQL instances.
+	scope = "https://www.googleapis.com/auth/sqlservice.admin"
+	// requestTimeout defines timeout of requests to GCP API.
+	requestTimeout = 30 * time.Second
+)
+
+// Instance struct
+type Instance struct {
+	Name            string
+	Region          string
+	DatabaseVersion string
+	State           string
+	InstanceType    string
+	IPAddresses     map[string]string // IP addresses keyed by type: PRIMARY, PRIVATE or OUTGOING
+	Labels          map[string]string
+}
+
+// Client implements requests to Cloud SQL Admin API, requests are authorized by token source of credentials.
+type Client struct {
+	client *http.Client
+	apiURL string
+}
+
+// NewClient returns client of Cloud SQL Admin API. Credentials are loaded from passed file of service account key or
+// authorized user, when file is not specified Application Default Credentials are used (GOOGLE_APPLICATION_CREDENTIALS
+// environment variable, gcloud credentials or service account of the instance).
+func NewClient(credentialsPath string) (*Client, error) {
+	ctx := context.Background()
+
+	if credentialsPath == "" {
+		log.Debug("[GCP SD] Looking for application default credentials...")
+		creds, err := google.FindDefaultCredentials(ctx, scope)
+		if err != nil {
+			return nil, fmt.Errorf("find default credentials failed: %w", err)
+		}
+		return newClient(creds.TokenSource), nil
+	}
+
+	log.Debugf("[GCP SD] Loading credentials from path '%s'", credentialsPath)
+	data, err := os.ReadFile(filepath.Clean(credentialsPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Type google.CredentialsType `json:"type"`
+	}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("parse credentials failed: %w", err)
+	}
+
+	switch file.Type {
+	case google.ServiceAccount, google.AuthorizedUser:
+	default:
+		return nil, fmt.Errorf("unsupported credentials type '%s', allowed 'service_account' or 'authorized_user'", file.Type)
+	}
+
+	creds, err := google.CredentialsFromJSONWithType(ctx, data, file.Type, scope)
+	if err != nil {
+		return nil, fmt.Errorf("parse credentials failed: %w", err)
+	}
+
+	return newClient(creds.TokenSource), nil
+}
+
+// newClient returns client of Cloud SQL Admin API authorized by passed token source.
+func newClient(ts oauth2.TokenSource) *Client {
+	client := oauth2.NewClient(context.Background(), ts)
+	client.Timeout = requestTimeout
+
+	return &Client{
+		client: client,
+		apiURL: "https://sqladmin.googleapis.com",
+	}
+}
+
+// do sends request and decodes JSON response into v.
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.client.Do(req) // #nosec G704
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response: %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	return json.Unmarshal(content, v)
+}
+
+// instanceList implements response of instances list request.
+type instanceList struct {
+	Items []struct {
+		Name            string `json:"name"`
+		Region          string `json:"region"`
+		DatabaseVersion string `json:"databaseVersion"`
+		State           string `json:"state"`
+		InstanceType    string `json:"instanceType"`
+		IPAddresses     []struct {
+			Type      string `json:"type"`
+			IPAddress string `json:"ipAddress"`
+		} `json:"ipAddresses"`
+		Settings struct {
+			UserLabels map[string]string `json:"userLabels"`
+		} `json:"settings"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// ListInstances returns PostgreSQL instances of the project.
+func (c *Client) ListInstances(ctx context.Context, project string) ([]Instance, error) {
+	var instances []Instance
+	pageToken := ""
+	for {
+		uri := c.apiURL + "/v1/projects/" + url.PathEscape(project) + "/instances"
+		if pageToken != "" {
+			uri += "?pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var list instanceList
+		err = c.do(req, &list)
+		if err != nil {
+			return nil, fmt.Errorf("list instances failed: %w", err)
+		}
+
+		for _, item := range list.Items {
+			if !strings.HasPrefix(item.DatabaseVersion, "POSTGRES") {
+				continue
+			}
+
+			inst := Instance{
+				Name:            item.Name,
+				Region:          item.Region,
+				DatabaseVersion: item.DatabaseVersion,
+				State:           item.State,
+				InstanceType:    item.InstanceType,
+				IPAddresses:     make(map[string]string, len(item.IPAddresses)),
+				Labels:          item.Settings.UserLabels,
+			}
+			for _, addr := range item.IPAddresses {
+				inst.IPAddresses[addr.Type] = addr.IPAddress
+			}
+			instances = append(instances, inst)
+		}
+
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,103 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+const testInstances = `{"items": [
+	{"name": "pg1", "region": "europe-west1", "databaseVersion": "POSTGRES_16", "state": "RUNNABLE", "instanceType": "CLOUD_SQL_INSTANCE", "ipAddresses": [{"type": "PRIMARY", "ipAddress": "34.0.0.1"}, {"type": "PRIVATE", "ipAddress": "10.0.0.1"}], "settings": {"userLabels": {"env": "prod"}}},
+	{"name": "mysql1", "region": "europe-west1", "databaseVersion": "MYSQL_8_0", "state": "RUNNABLE"}
+], "nextPageToken": "next"}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			rw.Header().Set("Content-Type", "application/json")
+			_, _ = rw.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3599}`))
+		case "/v1/projects/project/instances":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("pageToken") == "" {
+				_, _ = rw.Write([]byte(testInstances))
+				return
+			}
+			_, _ = rw.Write([]byte(`{"items": [{"name": "pg2", "region": "us-east1", "databaseVersion": "POSTGRES_15", "state": "STOPPED"}]}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func writeCredentials(t *testing.T, creds map[string]string) string {
+	data, err := json.Marshal(creds)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestClient_ListInstances(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	saClient, err := NewClient(writeCredentials(t, map[string]string{"type": "service_account", "client_email": "pgscv@project.iam.gserviceaccount.com", "private_key": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), "token_uri": ts.URL + "/token"}))
+	require.NoError(t, err)
+
+	for _, c := range []*Client{
+		saClient,
+		newClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})),
+	} {
+		c.apiURL = ts.URL
+
+		instances, err := c.ListInstances(context.Background(), "project")
+		require.NoError(t, err)
+		assert.Equal(t, []Instance{
+			{Name: "pg1", Region: "europe-west1", DatabaseVersion: "POSTGRES_16", State: "RUNNABLE", InstanceType: "CLOUD_SQL_INSTANCE", IPAddresses: map[string]string{"PRIMARY": "34.0.0.1", "PRIVATE": "10.0.0.1"}, Labels: map[string]string{"env": "prod"}},
+			{Name: "pg2", Region: "us-east1", DatabaseVersion: "POSTGRES_15", State: "STOPPED", IPAddresses: map[string]string{}},
+		}, instances)
+	}
+
+	// Test errors
+	c := newClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "invalid"}))
+	c.apiURL = ts.URL
+	_, err = c.ListInstances(context.Background(), "project")
+	assert.Error(t, err)
+}
+
+func TestNewClient(t *testing.T) {
+	// Test errors
+	_, err := NewClient(writeCredentials(t, map[string]string{"type": "external_account"}))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0600))
+	_, err = NewClient(path)
+	assert.Error(t, err)
+
+	_, err = NewClient(filepath.Join(t.TempDir(), "invalid"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/discovery/cloud/azure"
+	"gopkg.in/yaml.v2"
+)
+
+// AzureConfig defines subscription where Azure Database for PostgreSQL flexible servers are discovered and service
+// principal used for requesting Azure API. When client secret is not specified, client_id selects user-assigned managed
+// identity, otherwise DefaultAzureCredential is used (AZURE_* environment variables, workload identity, managed identity
+// of the host or Azure CLI).
+type AzureConfig struct {
+	SubscriptionID      string `json:"subscription_id" yaml:"subscription_id"`
+	TenantID            string `json:"tenant_id" yaml:"tenant_id"`
+	ClientID            string `json:"client_id" yaml:"client_id"`
+	ClientSecret        string `json:"client_secret" yaml:"client_secret"` // #nosec G117
+	ClientSecretFromEnv string `json:"client_secret_from_env" yaml:"client_secret_from_env"`
+	CloudConnConfig     `yaml:",inline"`
+}
+
+// AzureDiscovery is the discoverer of Azure Database for PostgreSQL flexible servers.
+type AzureDiscovery struct {
+	*cloudDiscovery
+}
+
+// NewAzureDiscovery return pointer initialized AzureDiscovery structure
+func NewAzureDiscovery() *AzureDiscovery {
+	return &AzureDiscovery{cloudDiscovery: newCloudDiscovery("Azure")}
+}
+
+// Init implementation Init method of Discovery interface
+func (ad *AzureDiscovery) Init(cfg discovery.Config) error {
+	log.Debug("[Azure SD] Init discovery config...")
+	c, err := ensureConfigAzure(cfg)
+	if err != nil {
+		log.Errorf("[Azure SD] Failed to init discovery config, error: %v", err)
+		return err
+	}
+
+	conns := make([]CloudConnConfig, 0, len(c))
+	listers := make([]cloudLister, 0, len(c))
+	for _, ac := range c {
+		client, err := azure.NewClient(azure.Credentials{TenantID: ac.TenantID, ClientID: ac.ClientID, ClientSecret: ac.ClientSecret})
+		if err != nil {
+			log.Errorf("[Azure SD] Failed to create Azure API client, error: %v", err)
+			return err
+		}
+		conns = append(conns, ac.CloudConnConfig)
+		listers = append(listers, newAzureLister(client, ac.SubscriptionID))
+	}
+	ad.init(conns, listers)
+	return nil
+}
+
+// newAzureLister returns lister of ready flexible servers of the subscription.
+func newAzureLister(client *azure.Client, subscriptionID string) cloudLister {
+	return func(ctx context.Context) ([]cloudServer, error) {
+		servers, err := client.ListFlexibleServers(ctx, subscriptionID)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]cloudServer, 0, len(servers))
+		for _, s := range servers {
+			if s.State != "Ready" || s.FQDN == "" {
+				log.Debugf("[Azure SD] Server '%s' is in state '%s', skip", s.Name, s.State)
+				continue
+			}
+			result = append(result, cloudServer{
+				Name:   s.Name,
+				Host:   s.FQDN,
+				Labels: s.Tags,
+				ConstLabels: map[string]string{
+					"provider":             discovery.AzureFlexibleServer,
+					"azure_server":         s.Name,
+					"azure_resource_group": s.ResourceGroup,
+				},
+			})
+		}
+		return result, nil
+	}
+}
+
+func ensureConfigAzure(config discovery.Config) ([]AzureConfig, error) {
+	c := &[]AzureConfig{}
+	o, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(o, c)
+	if err != nil {
+		return nil, err
+	}
+	for i, ac := range *c {
+		if ac.SubscriptionID == "" {
+			return nil, fmt.Errorf("subscription_id is not specified")
+		}
+		if ac.ClientSecretFromEnv != "" {
+			(*c)[i].ClientSecret = os.Getenv(ac.ClientSecretFromEnv)
+		}
+		if (*c)[i].ClientSecret != "" && (ac.TenantID == "" || ac.ClientID == "") {
+			return nil, fmt.Errorf("tenant_id and client_id must be specified with client secret")
+		}
+		err = ensureCloudConnConfig(&(*c)[i].CloudConnConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return *c, nil
+}
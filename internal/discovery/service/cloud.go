@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/model"
+)
+
+const (
+	// defaultCloudRefreshInterval defines default interval of polling cloud API, in minutes.
+	defaultCloudRefreshInterval = 5
+	// defaultCloudDatabase defines database used for connecting to discovered servers.
+	defaultCloudDatabase = "postgres"
+	// defaultCloudSSLMode defines sslmode used for connecting to discovered servers.
+	defaultCloudSSLMode = "require"
+)
+
+// CloudFilter defines which servers are discovered. Name and ExcludeName are regexps matched against server name,
+// Labels must be all present in server tags (labels) with the same values.
+type CloudFilter struct {
+	Name        string            `json:"name" yaml:"name"`
+	ExcludeName *string           `json:"exclude_name" yaml:"exclude_name"`
+	Labels      map[string]string `json:"labels" yaml:"labels"`
+	nameRE      *regexp.Regexp
+	excludeRE   *regexp.Regexp
+}
+
+// CloudConnConfig defines options common for discovery of managed PostgreSQL servers in public clouds: credentials
+// of connecting to discovered servers, filters, refresh interval (in minutes) and target labels.
+type CloudConnConfig struct {
+	User            string        `json:"user" yaml:"user"`
+	Password        string        `json:"password" yaml:"password"` // #nosec G117
+	PasswordFromEnv string        `json:"password_from_env" yaml:"password_from_env"`
+	Database        string        `json:"database" yaml:"database"`
+	SSLMode         string        `json:"sslmode" yaml:"sslmode"`
+	RefreshInterval int           `json:"refresh_interval" yaml:"refresh_interval"`
+	Filters         []CloudFilter `json:"filters" yaml:"filters"`
+	TargetLabels    *[]Label      `json:"target_labels" yaml:"target_labels"`
+}
+
+// cloudServer defines discovered server used for creating service.
+type cloudServer struct {
+	Name        string
+	Host        string
+	Labels      map[string]string // tags (labels) of the server used in filters
+	ConstLabels map[string]string
+}
+
+// cloudLister lists servers of a single config item.
+type cloudLister func(ctx context.Context) ([]cloudServer, error)
+
+// cloudDiscovery implements polling of cloud API, it is shared by discoverers of managed PostgreSQL servers.
+type cloudDiscovery struct {
+	*pollingDiscovery
+	conns   []CloudConnConfig
+	listers []cloudLister
+}
+
+// newCloudDiscovery return pointer initialized cloudDiscovery structure
+func newCloudDiscovery(name string) *cloudDiscovery {
+	return &cloudDiscovery{pollingDiscovery: newPollingDiscovery(name)}
+}
+
+// init sets config items and theirs listers.
+func (cd *cloudDiscovery) init(conns []CloudConnConfig, listers []cloudLister) {
+	cd.conns = conns
+	cd.listers = listers
+	cd.initServices(len(conns))
+}
+
+// Start implementation Start method of Discovery interface
+func (cd *cloudDiscovery) Start(ctx context.Context, errCh chan<- error) error {
+	interval := defaultCloudRefreshInterval
+	for _, c := range cd.conns {
+		if c.RefreshInterval > 0 && c.RefreshInterval < interval {
+			interval = c.RefreshInterval
+		}
+	}
+
+	cd.poll(ctx, errCh, time.Duration(interval)*time.Minute, cd.refresh)
+	return nil
+}
+
+// refresh requests cloud API and updates discovered services. Services are kept as is when API is unavailable.
+func (cd *cloudDiscovery) refresh(ctx context.Context) {
+	for i, list := range cd.listers {
+		servers, err := list(ctx)
+		if err != nil {
+			log.Errorf("[%s SD] Failed to get servers list, error: %s", cd.name, err.Error())
+			continue
+		}
+
+		cd.setServices(i, newCloudServices(cd.conns[i], servers))
+	}
+}
+
+// newCloudServices creates services of servers matched by filters.
+func newCloudServices(c CloudConnConfig, servers []cloudServer) map[string]discovery.Service {
+	targetLabels := make(map[string]string)
+	if c.TargetLabels != nil {
+		for _, item := range *c.TargetLabels {
+			targetLabels[item.Name] = item.Value
+		}
+	}
+
+	services := make(map[string]discovery.Service, len(servers))
+	for _, s := range servers {
+		if !matchCloudFilters(c.Filters, s) {
+			continue
+		}
+
+		dsn := url.URL{
+			Scheme:   "postgresql",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     net.JoinHostPort(s.Host, "5432"),
+			Path:     "/" + c.Database,
+			RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+		}
+
+		serviceID := model.ServiceTypePostgresql + ":" + dsn.Host
+		services[serviceID] = discovery.Service{
+			ServiceType:  model.ServiceTypePostgresql,
+			DSN:          dsn.String(),
+			ConstLabels:  maps.Clone(s.ConstLabels),
+			TargetLabels: maps.Clone(targetLabels),
+		}
+	}
+
+	return services
+}
+
+// matchCloudFilters returns true if server is matched by any filter, all servers are matched when there are no filters.
+func matchCloudFilters(filters []CloudFilter, s cloudServer) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	for _, f := range filters {
+		if f.excludeRE != nil && f.excludeRE.MatchString(s.Name) {
+			continue
+		}
+		if !f.nameRE.MatchString(s.Name) {
+			continue
+		}
+
+		matched := true
+		for k, v := range f.Labels {
+			if value, ok := s.Labels[k]; !ok || value != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureCloudConnConfig validates common options and sets defaults.
+func ensureCloudConnConfig(c *CloudConnConfig) error {
+	if c.User == "" {
+		return fmt.Errorf("user is not specified")
+	}
+	if c.PasswordFromEnv != "" {
+		c.Password = os.Getenv(c.PasswordFromEnv)
+	}
+	if c.Database == "" {
+		c.Database = defaultCloudDatabase
+	}
+	if c.SSLMode == "" {
+		c.SSLMode = defaultCloudSSLMode
+	}
+
+	var err error
+	for i, f := range c.Filters {
+		if f.Name == "" {
+			f.Name = ".*"
+		}
+		c.Filters[i].nameRE, err = regexp.Compile(f.Name)
+		if err != nil {
+			return fmt.Errorf("invalid filter name '%s': %w", f.Name, err)
+		}
+		if f.ExcludeName != nil {
+			c.Filters[i].excludeRE, err = regexp.Compile(*f.ExcludeName)
+			if err != nil {
+				return fmt.Errorf("invalid filter exclude_name '%s': %w", *f.ExcludeName, err)
+			}
+		}
+	}
+	return nil
+}
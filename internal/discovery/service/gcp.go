@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/discovery/cloud/gcp"
+	"gopkg.in/yaml.v2"
+)
+
+// GCPConfig defines project where Cloud SQL for PostgreSQL instances are discovered and credentials used for
+// requesting Cloud SQL Admin API. When credentials_file is not specified, Application Default Credentials are used
+// (GOOGLE_APPLICATION_CREDENTIALS environment variable, gcloud credentials or service account of the instance). IPType
+// selects address used for connecting to instances: PRIMARY (public) or PRIVATE.
+type GCPConfig struct {
+	Project         string `json:"project" yaml:"project"`
+	CredentialsFile string `json:"credentials_file" yaml:"credentials_file"`
+	IPType          string `json:"ip_type" yaml:"ip_type"`
+	CloudConnConfig `yaml:",inline"`
+}
+
+// GCPDiscovery is the discoverer of GCP Cloud SQL for PostgreSQL instances.
+type GCPDiscovery struct {
+	*cloudDiscovery
+}
+
+// NewGCPDiscovery return pointer initialized GCPDiscovery structure
+func NewGCPDiscovery() *GCPDiscovery {
+	return &GCPDiscovery{cloudDiscovery: newCloudDiscovery("GCP")}
+}
+
+// Init implementation Init method of Discovery interface
+func (gd *GCPDiscovery) Init(cfg discovery.Config) error {
+	log.Debug("[GCP SD] Init discovery config...")
+	c, err := ensureConfigGCP(cfg)
+	if err != nil {
+		log.Errorf("[GCP SD] Failed to init discovery config, error: %v", err)
+		return err
+	}
+
+	conns := make([]CloudConnConfig, 0, len(c))
+	listers := make([]cloudLister, 0, len(c))
+	for _, gc := range c {
+		client, err := gcp.NewClient(gc.CredentialsFile)
+		if err != nil {
+			log.Errorf("[GCP SD] Failed to create Cloud SQL client, error: %v", err)
+			return err
+		}
+		conns = append(conns, gc.CloudConnConfig)
+		listers = append(listers, newGCPLister(client, gc.Project, gc.IPType))
+	}
+	gd.init(conns, listers)
+	return nil
+}
+
+// newGCPLister returns lister of running instances of the project.
+func newGCPLister(client *gcp.Client, project, ipType string) cloudLister {
+	return func(ctx context.Context) ([]cloudServer, error) {
+		instances, err := client.ListInstances(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]cloudServer, 0, len(instances))
+		for _, inst := range instances {
+			if inst.State != "RUNNABLE" {
+				log.Debugf("[GCP SD] Instance '%s' is in state '%s', skip", inst.Name, inst.State)
+				continue
+			}
+			host, ok := inst.IPAddresses[ipType]
+			if !ok {
+				log.Debugf("[GCP SD] Instance '%s' has no %s address, skip", inst.Name, ipType)
+				continue
+			}
+			result = append(result, cloudServer{
+				Name:   inst.Name,
+				Host:   host,
+				Labels: inst.Labels,
+				ConstLabels: map[string]string{
+					"provider":          discovery.GCPCloudSQL,
+					"cloudsql_instance": inst.Name,
+					"cloudsql_region":   inst.Region,
+				},
+			})
+		}
+		return result, nil
+	}
+}
+
+func ensureConfigGCP(config discovery.Config) ([]GCPConfig, error) {
+	c := &[]GCPConfig{}
+	o, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(o, c)
+	if err != nil {
+		return nil, err
+	}
+	for i, gc := range *c {
+		if gc.Project == "" {
+			return nil, fmt.Errorf("project is not specified")
+		}
+		switch gc.IPType {
+		case "":
+			(*c)[i].IPType = "PRIMARY"
+		case "PRIMARY", "PRIVATE":
+		default:
+			return nil, fmt.Errorf("invalid ip_type '%s', allowed 'PRIMARY' or 'PRIVATE'", gc.IPType)
+		}
+		err = ensureCloudConnConfig(&(*c)[i].CloudConnConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return *c, nil
+}
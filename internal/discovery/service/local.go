@@ -0,0 +1,372 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/model"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// defaultLocalRefreshInterval defines default interval of scanning local processes, in seconds.
+	defaultLocalRefreshInterval = 60
+	// defaultProcRoot defines default mountpoint of procfs.
+	defaultProcRoot = "/proc"
+	// tcpListenState defines state of listening sockets in /proc/net/tcp.
+	tcpListenState = "0A"
+)
+
+// LocalConfig defines discovery of Postgres, Pgbouncer and Patroni services running on the local host. Processes are
+// found by scanning /proc, ports are taken from listening TCP sockets of the processes. Postgres and Pgbouncer services
+// are connected using credentials specified in 'defaults'. ServiceTypes limits types of discovered services, all types
+// are discovered by default.
+type LocalConfig struct {
+	RefreshInterval int      `json:"refresh_interval" yaml:"refresh_interval"`
+	ServiceTypes    []string `json:"service_types" yaml:"service_types"`
+	TargetLabels    *[]Label `json:"target_labels" yaml:"target_labels"`
+}
+
+// localProcess defines properties of discovered local process.
+type localProcess struct {
+	pid         int
+	serviceType string
+	datadir     string
+	endpoints   []localEndpoint
+}
+
+// localEndpoint defines listening TCP socket of local process.
+type localEndpoint struct {
+	host string
+	port int
+}
+
+// LocalDiscovery is the discoverer of services running on the local host.
+type LocalDiscovery struct {
+	*pollingDiscovery
+	config   LocalConfig
+	procRoot string
+	defaults map[string]string
+}
+
+// NewLocalDiscovery return pointer initialized LocalDiscovery structure
+func NewLocalDiscovery() *LocalDiscovery {
+	ld := &LocalDiscovery{
+		pollingDiscovery: newPollingDiscovery("Local"),
+		procRoot:         defaultProcRoot,
+		defaults:         map[string]string{},
+	}
+	ld.initServices(1)
+	return ld
+}
+
+// Init implementation Init method of Discovery interface
+func (ld *LocalDiscovery) Init(cfg discovery.Config) error {
+	log.Debug("[Local SD] Init discovery config...")
+	c, err := ensureConfigLocal(cfg)
+	if err != nil {
+		log.Errorf("[Local SD] Failed to init discovery config, error: %v", err)
+		return err
+	}
+	ld.config = c
+	return nil
+}
+
+// SetDefaults sets default credentials used for connecting to discovered services, e.g. postgres_username,
+// postgres_password, postgres_dbname and the same for pgbouncer. Must be called before Start.
+func (ld *LocalDiscovery) SetDefaults(defaults map[string]string) {
+	ld.Lock()
+	defer ld.Unlock()
+	ld.defaults = maps.Clone(defaults)
+}
+
+// Start implementation Start method of Discovery interface
+func (ld *LocalDiscovery) Start(ctx context.Context, errCh chan<- error) error {
+	interval := defaultLocalRefreshInterval
+	if ld.config.RefreshInterval > 0 {
+		interval = ld.config.RefreshInterval
+	}
+
+	ld.poll(ctx, errCh, time.Duration(interval)*time.Second, ld.refresh)
+	return nil
+}
+
+// refresh scans local processes and updates discovered services. Services are kept as is if scan failed.
+func (ld *LocalDiscovery) refresh(context.Context) {
+	processes, err := scanLocalProcesses(ld.procRoot)
+	if err != nil {
+		log.Errorf("[Local SD] Failed to scan local processes, error: %s", err.Error())
+		return
+	}
+
+	ld.RLock()
+	services := newLocalServices(ld.config, ld.defaults, processes)
+	ld.RUnlock()
+
+	ld.setServices(0, services)
+}
+
+// newLocalServices creates services of discovered processes. Postgres and Pgbouncer DSNs are made using defaults.
+func newLocalServices(c LocalConfig, defaults map[string]string, processes []localProcess) map[string]discovery.Service {
+	targetLabels := make(map[string]string)
+	if c.TargetLabels != nil {
+		for _, item := range *c.TargetLabels {
+			targetLabels[item.Name] = item.Value
+		}
+	}
+
+	services := make(map[string]discovery.Service)
+	for _, p := range processes {
+		if len(c.ServiceTypes) > 0 && !slices.Contains(c.ServiceTypes, p.serviceType) {
+			continue
+		}
+
+		// Postgres and Pgbouncer listen the same port on several addresses, one endpoint is enough. Patroni might
+		// listen several ports, REST API listens the first one.
+		e := p.endpoints[0]
+		address := net.JoinHostPort(e.host, strconv.Itoa(e.port))
+
+		var dsn string
+		switch p.serviceType {
+		case model.ServiceTypePostgresql, model.ServiceTypePgbouncer:
+			u := url.URL{Scheme: "postgres", Host: address, Path: "/" + defaults[p.serviceType+"_dbname"]}
+			if password, ok := defaults[p.serviceType+"_password"]; ok && password != "" {
+				u.User = url.UserPassword(defaults[p.serviceType+"_username"], password)
+			} else {
+				u.User = url.User(defaults[p.serviceType+"_username"])
+			}
+			dsn = u.String()
+		case model.ServiceTypePatroni:
+			dsn = "http://" + address
+		}
+
+		log.Debugf("[Local SD] Found %s service (pid %d, data directory '%s') listening on %s", p.serviceType, p.pid, p.datadir, address)
+
+		services[p.serviceType+":"+address] = discovery.Service{
+			ServiceType:  p.serviceType,
+			DSN:          dsn,
+			ConstLabels:  map[string]string{"provider": discovery.Local},
+			TargetLabels: maps.Clone(targetLabels),
+		}
+	}
+
+	return services
+}
+
+// scanLocalProcesses scans procfs and returns Postgres, Pgbouncer and Patroni processes which listen TCP sockets.
+func scanLocalProcesses(procRoot string) ([]localProcess, error) {
+	sockets := map[string]localEndpoint{}
+	for _, name := range []string{"tcp", "tcp6"} {
+		f, err := os.Open(filepath.Join(procRoot, "net", name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		s, err := parseListenSockets(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(sockets, s)
+	}
+
+	dirs, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []localProcess
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes might finish during scan, skip them.
+		cmdline, err := os.ReadFile(filepath.Join(procRoot, d.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		p, ok := parseLocalProcessCmdline(cmdline)
+		if !ok {
+			continue
+		}
+		p.pid = pid
+
+		p.endpoints = processEndpoints(filepath.Join(procRoot, d.Name(), "fd"), sockets)
+		if len(p.endpoints) == 0 {
+			log.Debugf("[Local SD] No listening sockets found for %s process (pid %d), skip", p.serviceType, pid)
+			continue
+		}
+
+		processes = append(processes, p)
+	}
+
+	return processes, nil
+}
+
+// parseLocalProcessCmdline parses process command line and returns process if it is a Postgres postmaster,
+// Pgbouncer or Patroni process.
+func parseLocalProcessCmdline(cmdline []byte) (localProcess, bool) {
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	if len(args) == 0 || args[0] == "" {
+		return localProcess{}, false
+	}
+
+	switch filepath.Base(args[0]) {
+	case "postgres", "postmaster":
+		p := localProcess{serviceType: model.ServiceTypePostgresql}
+		for i, arg := range args[1:] {
+			switch {
+			case arg == "-D" && i+2 < len(args):
+				p.datadir = args[i+2]
+			case strings.HasPrefix(arg, "-D"):
+				p.datadir = strings.TrimPrefix(arg, "-D")
+			case strings.HasPrefix(arg, "--data-directory="):
+				p.datadir = strings.TrimPrefix(arg, "--data-directory=")
+			}
+		}
+		return p, true
+	case "pgbouncer":
+		return localProcess{serviceType: model.ServiceTypePgbouncer}, true
+	}
+
+	// Patroni is started directly or by Python interpreter.
+	for _, arg := range args[:min(len(args), 2)] {
+		if filepath.Base(arg) == "patroni" {
+			return localProcess{serviceType: model.ServiceTypePatroni}, true
+		}
+	}
+
+	return localProcess{}, false
+}
+
+// processEndpoints returns endpoints of listening sockets opened by process with passed fd directory.
+func processEndpoints(fdDir string, sockets map[string]localEndpoint) []localEndpoint {
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil
+	}
+
+	var endpoints []localEndpoint
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+
+		if e, ok := sockets[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]; ok {
+			endpoints = append(endpoints, e)
+		}
+	}
+
+	// Prefer lower ports and IPv4 addresses.
+	slices.SortFunc(endpoints, func(a, b localEndpoint) int {
+		if a.port != b.port {
+			return a.port - b.port
+		}
+		return strings.Count(a.host, ":") - strings.Count(b.host, ":")
+	})
+
+	return endpoints
+}
+
+// parseListenSockets parses /proc/net/tcp (or tcp6) and returns endpoints of listening sockets mapped to inodes.
+// Sockets listening all addresses are reachable through loopback.
+func parseListenSockets(r io.Reader) (map[string]localEndpoint, error) {
+	sockets := map[string]localEndpoint{}
+	scanner := bufio.NewScanner(r)
+
+	// Skip header.
+	scanner.Scan()
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("invalid input: '%s'", scanner.Text())
+		}
+
+		if fields[3] != tcpListenState {
+			continue
+		}
+
+		host, port, err := parseSocketAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		sockets[fields[9]] = localEndpoint{host: host, port: port}
+	}
+
+	return sockets, scanner.Err()
+}
+
+// parseSocketAddress parses hex-encoded address in format of /proc/net/tcp, e.g. 0100007F:1538.
+func parseSocketAddress(s string) (string, int, error) {
+	addr, portStr, ok := strings.Cut(s, ":")
+	if !ok || (len(addr) != 8 && len(addr) != 32) {
+		return "", 0, fmt.Errorf("invalid socket address '%s'", s)
+	}
+
+	port, err := strconv.ParseUint(portStr, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid socket address '%s': %s", s, err)
+	}
+
+	// Address is stored as 32-bit words in host (little-endian) byte order.
+	ip := make(net.IP, len(addr)/2)
+	for i := 0; i < len(addr); i += 8 {
+		word, err := strconv.ParseUint(addr[i:i+8], 16, 32)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid socket address '%s': %s", s, err)
+		}
+		ip[i/2], ip[i/2+1], ip[i/2+2], ip[i/2+3] = byte(word), byte(word>>8), byte(word>>16), byte(word>>24)
+	}
+
+	if ip.IsUnspecified() {
+		if len(ip) == net.IPv4len {
+			ip = net.IPv4(127, 0, 0, 1)
+		} else {
+			ip = net.IPv6loopback
+		}
+	}
+
+	return ip.String(), int(port), nil
+}
+
+func ensureConfigLocal(config discovery.Config) (LocalConfig, error) {
+	c := LocalConfig{}
+	o, err := yaml.Marshal(config)
+	if err != nil {
+		return c, err
+	}
+	err = yaml.Unmarshal(o, &c)
+	if err != nil {
+		return c, err
+	}
+	for _, t := range c.ServiceTypes {
+		switch t {
+		case model.ServiceTypePostgresql, model.ServiceTypePgbouncer, model.ServiceTypePatroni:
+		default:
+			return c, fmt.Errorf("invalid service type '%s', allowed '%s', '%s' or '%s'", t, model.ServiceTypePostgresql, model.ServiceTypePgbouncer, model.ServiceTypePatroni)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/model"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// defaultOperatorRefreshInterval defines default interval of polling Kubernetes API, in seconds.
+	defaultOperatorRefreshInterval = 60
+	// operatorRequestTimeout defines timeout of requests to Kubernetes API.
+	operatorRequestTimeout = 10 * time.Second
+
+	// serviceAccountDir defines directory with credentials of the pod service account.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// operatorClusterLabel and operatorRoleLabel define labels put on services of PostgresCluster by Crunchy Data
+	// PGO v5 and Percona Operator for PostgreSQL v2.
+	operatorClusterLabel = "postgres-operator.crunchydata.com/cluster"
+	operatorRoleLabel    = "postgres-operator.crunchydata.com/role"
+
+	// Roles of services of PostgresCluster.
+	operatorRolePrimary   = "primary"
+	operatorRoleReplica   = "replica"
+	operatorRolePgbouncer = "pgbouncer"
+)
+
+// OperatorConfig defines how to find PostgresCluster services managed by Crunchy Data PGO v5 or Percona Operator for
+// PostgreSQL v2 and how to connect to them. Kubernetes API is requested using the pod service account unless
+// api_server, token_file and ca_file are specified. DSNTemplate and PgbouncerDSNTemplate are text/template strings
+// executed with Host, Port, Namespace, Cluster and Role fields; pgbouncer services are discovered only when
+// PgbouncerDSNTemplate is specified. Replicas are discovered as separate services using endpoints of replicas service.
+type OperatorConfig struct {
+	APIServer            string   `json:"api_server" yaml:"api_server"`
+	TokenFile            string   `json:"token_file" yaml:"token_file"`
+	CAFile               string   `json:"ca_file" yaml:"ca_file"`
+	Namespaces           []string `json:"namespaces" yaml:"namespaces"`
+	DSNTemplate          string   `json:"dsn_template" yaml:"dsn_template"`
+	PgbouncerDSNTemplate string   `json:"pgbouncer_dsn_template" yaml:"pgbouncer_dsn_template"`
+	RefreshInterval      int      `json:"refresh_interval" yaml:"refresh_interval"`
+	TargetLabels         *[]Label `json:"target_labels" yaml:"target_labels"`
+	template             *template.Template
+	pgbouncerTemplate    *template.Template
+	client               *http.Client
+}
+
+// operatorEndpoint defines network endpoint of discovered service, fields are available in DSN templates.
+type operatorEndpoint struct {
+	Host      string
+	Port      int
+	Namespace string
+	Cluster   string
+	Role      string
+}
+
+// k8sObjectMeta implements metadata of Kubernetes objects.
+type k8sObjectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// k8sServicePort implements port of Kubernetes service and endpoints.
+type k8sServicePort struct {
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+// k8sServiceList implements response of Kubernetes API listing services.
+type k8sServiceList struct {
+	Items []struct {
+		Metadata k8sObjectMeta `json:"metadata"`
+		Spec     struct {
+			Ports []k8sServicePort `json:"ports"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// k8sEndpoints implements response of Kubernetes API reading endpoints of the service.
+type k8sEndpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []k8sServicePort `json:"ports"`
+	} `json:"subsets"`
+}
+
+// OperatorDiscovery is the discoverer of PostgresCluster services managed by Postgres operators on Kubernetes.
+type OperatorDiscovery struct {
+	*pollingDiscovery
+	config []OperatorConfig
+}
+
+// NewOperatorDiscovery return pointer initialized OperatorDiscovery structure
+func NewOperatorDiscovery() *OperatorDiscovery {
+	return &OperatorDiscovery{pollingDiscovery: newPollingDiscovery("Operator")}
+}
+
+// Init implementation Init method of Discovery interface
+func (od *OperatorDiscovery) Init(cfg discovery.Config) error {
+	log.Debug("[Operator SD] Init discovery config...")
+	c, err := ensureConfigOperator(cfg)
+	if err != nil {
+		log.Errorf("[Operator SD] Failed to init discovery config, error: %v", err)
+		return err
+	}
+	od.config = c
+	od.initServices(len(c))
+	return nil
+}
+
+// Start implementation Start method of Discovery interface
+func (od *OperatorDiscovery) Start(ctx context.Context, errCh chan<- error) error {
+	interval := defaultOperatorRefreshInterval
+	for _, c := range od.config {
+		if c.RefreshInterval > 0 && c.RefreshInterval < interval {
+			interval = c.RefreshInterval
+		}
+	}
+
+	od.poll(ctx, errCh, time.Duration(interval)*time.Second, od.refresh)
+	return nil
+}
+
+// refresh requests Kubernetes API and updates discovered services. Services are kept as is when API is unavailable.
+func (od *OperatorDiscovery) refresh(ctx context.Context) {
+	for i, c := range od.config {
+		endpoints, err := discoverOperatorEndpoints(ctx, c)
+		if err != nil {
+			log.Errorf("[Operator SD] Failed to discover services, error: %s", err.Error())
+			continue
+		}
+
+		services, err := newOperatorServices(c, endpoints)
+		if err != nil {
+			log.Errorf("[Operator SD] Failed to create services, error: %s", err.Error())
+			continue
+		}
+
+		od.setServices(i, services)
+	}
+}
+
+// discoverOperatorEndpoints lists services of PostgresClusters and returns endpoints of primaries, replicas and
+// pgbouncers. Primary and pgbouncer are connected through services, replicas are connected directly using addresses
+// of replicas service endpoints.
+func discoverOperatorEndpoints(ctx context.Context, c OperatorConfig) ([]operatorEndpoint, error) {
+	namespaces := c.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var endpoints []operatorEndpoint
+	for _, ns := range namespaces {
+		path := "/api/v1/services"
+		if ns != "" {
+			path = "/api/v1/namespaces/" + url.PathEscape(ns) + "/services"
+		}
+
+		var list k8sServiceList
+		err := requestKubernetesAPI(ctx, c, path+"?labelSelector="+url.QueryEscape(operatorRoleLabel+","+operatorClusterLabel), &list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, svc := range list.Items {
+			role := svc.Metadata.Labels[operatorRoleLabel]
+			e := operatorEndpoint{
+				Host:      svc.Metadata.Name + "." + svc.Metadata.Namespace + ".svc",
+				Port:      selectOperatorPort(svc.Spec.Ports, role),
+				Namespace: svc.Metadata.Namespace,
+				Cluster:   svc.Metadata.Labels[operatorClusterLabel],
+				Role:      role,
+			}
+
+			switch role {
+			case operatorRolePrimary:
+				endpoints = append(endpoints, e)
+			case operatorRolePgbouncer:
+				if c.pgbouncerTemplate != nil {
+					endpoints = append(endpoints, e)
+				}
+			case operatorRoleReplica:
+				var eps k8sEndpoints
+				err := requestKubernetesAPI(ctx, c, "/api/v1/namespaces/"+url.PathEscape(e.Namespace)+"/endpoints/"+url.PathEscape(svc.Metadata.Name), &eps)
+				if err != nil {
+					log.Errorf("[Operator SD] Failed to get endpoints of %s/%s, error: %s; skip", e.Namespace, svc.Metadata.Name, err)
+					continue
+				}
+				for _, subset := range eps.Subsets {
+					port := selectOperatorPort(subset.Ports, role)
+					for _, addr := range subset.Addresses {
+						endpoints = append(endpoints, operatorEndpoint{Host: addr.IP, Port: port, Namespace: e.Namespace, Cluster: e.Cluster, Role: role})
+					}
+				}
+			}
+		}
+	}
+
+	return endpoints, nil
+}
+
+// selectOperatorPort returns port named after the service role ('postgres' or 'pgbouncer'), the first port is used
+// when there is no such port.
+func selectOperatorPort(ports []k8sServicePort, role string) int {
+	name := "postgres"
+	if role == operatorRolePgbouncer {
+		name = "pgbouncer"
+	}
+
+	for _, p := range ports {
+		if p.Name == name {
+			return p.Port
+		}
+	}
+	if len(ports) > 0 {
+		return ports[0].Port
+	}
+	return 5432
+}
+
+// requestKubernetesAPI requests Kubernetes API using bearer token and decodes JSON response into v.
+func requestKubernetesAPI(ctx context.Context, c OperatorConfig, path string, v any) error {
+	token, err := os.ReadFile(filepath.Clean(c.TokenFile))
+	if err != nil {
+		return fmt.Errorf("read token failed: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, operatorRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.APIServer+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req) // #nosec G704
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed: %s", path, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, v)
+}
+
+// newOperatorServices creates services of endpoints using DSN templates.
+func newOperatorServices(c OperatorConfig, endpoints []operatorEndpoint) (map[string]discovery.Service, error) {
+	targetLabels := make(map[string]string)
+	if c.TargetLabels != nil {
+		for _, item := range *c.TargetLabels {
+			targetLabels[item.Name] = item.Value
+		}
+	}
+
+	services := make(map[string]discovery.Service, len(endpoints))
+	for _, e := range endpoints {
+		serviceType, tmpl := model.ServiceTypePostgresql, c.template
+		if e.Role == operatorRolePgbouncer {
+			serviceType, tmpl = model.ServiceTypePgbouncer, c.pgbouncerTemplate
+		}
+
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, e)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceID := serviceType + ":" + net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+		services[serviceID] = discovery.Service{
+			ServiceType: serviceType,
+			DSN:         buf.String(),
+			ConstLabels: map[string]string{
+				"provider":      discovery.PostgresOperator,
+				"k8s_namespace": e.Namespace,
+				"pg_cluster":    e.Cluster,
+				"pg_role":       e.Role,
+			},
+			TargetLabels: maps.Clone(targetLabels),
+		}
+	}
+
+	return services, nil
+}
+
+// newKubernetesClient creates HTTP client for Kubernetes API, server certificate is verified using CA file.
+func newKubernetesClient(caFile string) (*http.Client, error) {
+	ca, err := os.ReadFile(filepath.Clean(caFile))
+	if err != nil {
+		return nil, fmt.Errorf("read CA file failed: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+	}, nil
+}
+
+func ensureConfigOperator(config discovery.Config) ([]OperatorConfig, error) {
+	c := &[]OperatorConfig{}
+	o, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(o, c)
+	if err != nil {
+		return nil, err
+	}
+	for i, oc := range *c {
+		if oc.DSNTemplate == "" {
+			return nil, fmt.Errorf("dsn_template is not specified")
+		}
+		(*c)[i].template, err = template.New("dsn").Parse(oc.DSNTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dsn_template: %w", err)
+		}
+		if oc.PgbouncerDSNTemplate != "" {
+			(*c)[i].pgbouncerTemplate, err = template.New("pgbouncer_dsn").Parse(oc.PgbouncerDSNTemplate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pgbouncer_dsn_template: %w", err)
+			}
+		}
+
+		// Use credentials of the pod service account by default.
+		if oc.APIServer == "" {
+			host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+			if host == "" {
+				return nil, fmt.Errorf("api_server is not specified and pgSCV is not running in Kubernetes")
+			}
+			(*c)[i].APIServer = "https://" + net.JoinHostPort(host, port)
+		}
+		(*c)[i].APIServer = strings.TrimSuffix((*c)[i].APIServer, "/")
+		if oc.TokenFile == "" {
+			(*c)[i].TokenFile = serviceAccountDir + "/token"
+		}
+		if oc.CAFile == "" {
+			(*c)[i].CAFile = serviceAccountDir + "/ca.crt"
+		}
+
+		if strings.HasPrefix((*c)[i].APIServer, "https://") {
+			(*c)[i].client, err = newKubernetesClient((*c)[i].CAFile)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			(*c)[i].client = &http.Client{}
+		}
+	}
+	return *c, nil
+}
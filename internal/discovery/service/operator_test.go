@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	operatorTestServices = `{"items": [
+		{"metadata": {"name": "hippo-primary", "namespace": "db", "labels": {"postgres-operator.crunchydata.com/cluster": "hippo", "postgres-operator.crunchydata.com/role": "primary"}}, "spec": {"ports": [{"name": "postgres", "port": 5432}]}},
+		{"metadata": {"name": "hippo-replicas", "namespace": "db", "labels": {"postgres-operator.crunchydata.com/cluster": "hippo", "postgres-operator.crunchydata.com/role": "replica"}}, "spec": {"ports": [{"name": "postgres", "port": 5432}]}},
+		{"metadata": {"name": "hippo-pgbouncer", "namespace": "db", "labels": {"postgres-operator.crunchydata.com/cluster": "hippo", "postgres-operator.crunchydata.com/role": "pgbouncer"}}, "spec": {"ports": [{"name": "pgbouncer", "port": 5433}]}}
+	]}`
+	operatorTestEndpoints = `{"subsets": [{"addresses": [{"ip": "10.0.0.2"}, {"ip": "10.0.0.3"}], "ports": [{"name": "postgres", "port": 5432}]}]}`
+)
+
+func newOperatorTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/namespaces/db/services":
+			assert.Equal(t, "postgres-operator.crunchydata.com/role,postgres-operator.crunchydata.com/cluster", r.URL.Query().Get("labelSelector"))
+			_, _ = rw.Write([]byte(operatorTestServices))
+		case "/api/v1/namespaces/db/endpoints/hippo-replicas":
+			_, _ = rw.Write([]byte(operatorTestEndpoints))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newOperatorTestConfig(t *testing.T, apiServer string, pgbouncer bool) OperatorConfig {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token\n"), 0600))
+
+	cfg := map[string]any{
+		"api_server":   apiServer,
+		"token_file":   tokenFile,
+		"namespaces":   []string{"db"},
+		"dsn_template": "postgres://pgscv@{{ .Host }}:{{ .Port }}/postgres",
+	}
+	if pgbouncer {
+		cfg["pgbouncer_dsn_template"] = "postgres://pgscv@{{ .Host }}:{{ .Port }}/pgbouncer"
+	}
+
+	c, err := ensureConfigOperator([]map[string]any{cfg})
+	require.NoError(t, err)
+	require.Len(t, c, 1)
+	return c[0]
+}
+
+func Test_discoverOperatorEndpoints(t *testing.T) {
+	ts := newOperatorTestServer(t)
+	defer ts.Close()
+
+	endpoints, err := discoverOperatorEndpoints(context.Background(), newOperatorTestConfig(t, ts.URL, true))
+	require.NoError(t, err)
+	assert.Equal(t, []operatorEndpoint{
+		{Host: "hippo-primary.db.svc", Port: 5432, Namespace: "db", Cluster: "hippo", Role: "primary"},
+		{Host: "10.0.0.2", Port: 5432, Namespace: "db", Cluster: "hippo", Role: "replica"},
+		{Host: "10.0.0.3", Port: 5432, Namespace: "db", Cluster: "hippo", Role: "replica"},
+		{Host: "hippo-pgbouncer.db.svc", Port: 5433, Namespace: "db", Cluster: "hippo", Role: "pgbouncer"},
+	}, endpoints)
+
+	// Pgbouncer services are not discovered without template.
+	endpoints, err = discoverOperatorEndpoints(context.Background(), newOperatorTestConfig(t, ts.URL, false))
+	require.NoError(t, err)
+	assert.Len(t, endpoints, 3)
+
+	// Test errors
+	c := newOperatorTestConfig(t, ts.URL, false)
+	c.TokenFile = filepath.Join(t.TempDir(), "invalid")
+	_, err = discoverOperatorEndpoints(context.Background(), c)
+	assert.Error(t, err)
+}
+
+func Test_newOperatorServices(t *testing.T) {
+	c := newOperatorTestConfig(t, "http://127.0.0.1:8080", true)
+
+	services, err := newOperatorServices(c, []operatorEndpoint{
+		{Host: "hippo-primary.db.svc", Port: 5432, Namespace: "db", Cluster: "hippo", Role: "primary"},
+		{Host: "hippo-pgbouncer.db.svc", Port: 5433, Namespace: "db", Cluster: "hippo", Role: "pgbouncer"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]discovery.Service{
+		"postgres:hippo-primary.db.svc:5432": {
+			ServiceType:  "postgres",
+			DSN:          "postgres://pgscv@hippo-primary.db.svc:5432/postgres",
+			ConstLabels:  map[string]string{"provider": "postgres-operator", "k8s_namespace": "db", "pg_cluster": "hippo", "pg_role": "primary"},
+			TargetLabels: map[string]string{},
+		},
+		"pgbouncer:hippo-pgbouncer.db.svc:5433": {
+			ServiceType:  "pgbouncer",
+			DSN:          "postgres://pgscv@hippo-pgbouncer.db.svc:5433/pgbouncer",
+			ConstLabels:  map[string]string{"provider": "postgres-operator", "k8s_namespace": "db", "pg_cluster": "hippo", "pg_role": "pgbouncer"},
+			TargetLabels: map[string]string{},
+		},
+	}, services)
+}
+
+func Test_ensureConfigOperator(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	// dsn_template is required
+	_, err := ensureConfigOperator([]map[string]any{{"api_server": "http://127.0.0.1:8080"}})
+	assert.Error(t, err)
+
+	// invalid template
+	_, err = ensureConfigOperator([]map[string]any{{"api_server": "http://127.0.0.1:8080", "dsn_template": "{{ .Host"}})
+	assert.Error(t, err)
+
+	// API server is unknown outside of Kubernetes
+	_, err = ensureConfigOperator([]map[string]any{{"dsn_template": "postgres://{{ .Host }}"}})
+	assert.Error(t, err)
+
+	// CA file of the service account is missing
+	_, err = ensureConfigOperator([]map[string]any{{"api_server": "https://127.0.0.1:6443", "ca_file": "/nonexistent", "dsn_template": "postgres://{{ .Host }}"}})
+	assert.Error(t, err)
+}
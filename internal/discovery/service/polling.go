@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/discovery/mapops"
+)
+
+// pollingSubscriber defines subscriber and services which have been already passed to it.
+type pollingSubscriber struct {
+	AddService     discovery.AddServiceFunc
+	RemoveService  discovery.RemoveServiceFunc
+	syncedServices map[string]discovery.Service
+}
+
+// pollingDiscovery implements passing of services discovered by periodic polling to subscribers, it is embedded by
+// discoverers which poll local processes, Kubernetes API, primaries or cloud APIs.
+type pollingDiscovery struct {
+	sync.RWMutex
+	name        string                         // name used in log messages
+	services    []map[string]discovery.Service // discovered services per config item
+	subscribers map[string]pollingSubscriber
+}
+
+// newPollingDiscovery return pointer initialized pollingDiscovery structure
+func newPollingDiscovery(name string) *pollingDiscovery {
+	return &pollingDiscovery{name: name, subscribers: make(map[string]pollingSubscriber)}
+}
+
+// initServices allocates discovered services for passed number of config items.
+func (pd *pollingDiscovery) initServices(n int) {
+	pd.Lock()
+	defer pd.Unlock()
+	pd.services = make([]map[string]discovery.Service, n)
+}
+
+// setServices replaces services discovered by config item with passed index.
+func (pd *pollingDiscovery) setServices(i int, services map[string]discovery.Service) {
+	pd.Lock()
+	defer pd.Unlock()
+	pd.services[i] = services
+}
+
+// Subscribe implementation Subscribe method of Discovery interface
+func (pd *pollingDiscovery) Subscribe(subscriberID string, addService discovery.AddServiceFunc, removeService discovery.RemoveServiceFunc) error {
+	pd.Lock()
+	defer pd.Unlock()
+	log.Debugf("[%s SD] Init subscribe '%s'", pd.name, subscriberID)
+
+	s := pollingSubscriber{AddService: addService, RemoveService: removeService, syncedServices: pd.discovered()}
+	pd.subscribers[subscriberID] = s
+
+	if len(s.syncedServices) > 0 {
+		err := addService(maps.Clone(s.syncedServices))
+		if err != nil {
+			log.Errorf("[%s SD] Error adding synced services: %v", pd.name, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe implementation Unsubscribe method of Discovery interface
+func (pd *pollingDiscovery) Unsubscribe(subscriberID string) error {
+	pd.Lock()
+	defer pd.Unlock()
+	s, ok := pd.subscribers[subscriberID]
+	if !ok {
+		return nil
+	}
+	svc := make([]string, 0, len(s.syncedServices))
+	for k := range s.syncedServices {
+		svc = append(svc, k)
+	}
+	delete(pd.subscribers, subscriberID)
+	return s.RemoveService(svc)
+}
+
+// poll calls refresh and passes changes of discovered services to subscribers every interval until context is done.
+func (pd *pollingDiscovery) poll(ctx context.Context, errCh chan<- error, interval time.Duration, refresh func(ctx context.Context)) {
+	for {
+		refresh(ctx)
+
+		err := pd.Sync()
+		if err != nil {
+			log.Errorf("[%s SD] Failed to sync, error: %s", pd.name, err.Error())
+			errCh <- err
+		}
+		select {
+		case <-ctx.Done():
+			log.Debugf("[%s SD] Context done.", pd.name)
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Sync passes changes of discovered services to subscribers.
+func (pd *pollingDiscovery) Sync() error {
+	pd.Lock()
+	defer pd.Unlock()
+	log.Debugf("[%s SD] Sync...", pd.name)
+
+	services := pd.discovered()
+
+	for _, subscriber := range pd.subscribers {
+		removeSvc := make([]string, 0)
+		appendSvc := make(map[string]discovery.Service)
+		for _, v := range mapops.FullJoin(services, subscriber.syncedServices) {
+			if v.Left == nil {
+				removeSvc = append(removeSvc, *v.Right)
+				delete(subscriber.syncedServices, *v.Right)
+			}
+			if v.Right == nil {
+				appendSvc[*v.Left] = services[*v.Left]
+				subscriber.syncedServices[*v.Left] = services[*v.Left]
+			}
+		}
+		if len(removeSvc) > 0 {
+			log.Debugf("[%s SD] Removing '%d' services from subscriber.", pd.name, len(removeSvc))
+			err := subscriber.RemoveService(removeSvc)
+			if err != nil {
+				return err
+			}
+		}
+		if len(appendSvc) > 0 {
+			log.Debugf("[%s SD] Appending '%d' services to subscriber.", pd.name, len(appendSvc))
+			err := subscriber.AddService(appendSvc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// discovered returns services discovered by all config items. Must be called with lock held.
+func (pd *pollingDiscovery) discovered() map[string]discovery.Service {
+	services := make(map[string]discovery.Service)
+	for _, s := range pd.services {
+		maps.Copy(services, s)
+	}
+	return services
+}
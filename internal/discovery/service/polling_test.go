@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pollingDiscovery(t *testing.T) {
+	pd := newPollingDiscovery("Test")
+	pd.initServices(2)
+
+	added := make(map[string]discovery.Service)
+	var removed []string
+	require.NoError(t, pd.Subscribe("test",
+		func(services map[string]discovery.Service) error {
+			for k, v := range services {
+				added[k] = v
+			}
+			return nil
+		},
+		func(serviceIds []string) error {
+			removed = append(removed, serviceIds...)
+			return nil
+		},
+	))
+	assert.Empty(t, added)
+
+	// Services of all config items are passed to subscribers.
+	pd.setServices(0, map[string]discovery.Service{"postgres:a:5432": {DSN: "a"}})
+	pd.setServices(1, map[string]discovery.Service{"postgres:b:5432": {DSN: "b"}})
+	require.NoError(t, pd.Sync())
+	assert.Len(t, added, 2)
+
+	pd.setServices(1, map[string]discovery.Service{})
+	require.NoError(t, pd.Sync())
+	assert.Equal(t, []string{"postgres:b:5432"}, removed)
+
+	// Polling stops when context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	pd.poll(ctx, make(chan error, 1), time.Hour, func(context.Context) {
+		calls++
+		cancel()
+	})
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, pd.Unsubscribe("test"))
+	assert.Equal(t, []string{"postgres:b:5432", "postgres:a:5432"}, removed)
+	require.NoError(t, pd.Unsubscribe("unknown"))
+}
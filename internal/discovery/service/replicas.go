@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/discovery/log"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/jackc/pgx/v4"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// defaultReplicasRefreshInterval defines default interval of polling primaries, in seconds.
+	defaultReplicasRefreshInterval = 60
+	// replicasConnTimeout defines timeout of connecting to primaries, in seconds.
+	replicasConnTimeout = 5
+
+	// replicasQuery returns network endpoints of standbys connected to the service.
+	replicasQuery = "SELECT application_name, COALESCE(NULLIF(client_hostname, ''), host(client_addr)) AS host " +
+		"FROM pg_stat_replication WHERE client_addr IS NOT NULL"
+	// upstreamQuery returns network endpoint of the upstream server the service is replicating from (Postgres 11+).
+	upstreamQuery = "SELECT sender_host AS host, sender_port AS port FROM pg_stat_wal_receiver WHERE sender_host IS NOT NULL"
+)
+
+// ReplicasConfig defines the primary which standbys should be discovered and how to connect to them. DSNTemplate is
+// a text/template string executed with Host, Port, ApplicationName and Password fields; Port is unknown for standbys,
+// so port of the primary is used. Password is taken from the primary DSN.
+type ReplicasConfig struct {
+	PrimaryDSN       string   `json:"primary_dsn" yaml:"primary_dsn"`
+	DSNTemplate      string   `json:"dsn_template" yaml:"dsn_template"`
+	RefreshInterval  int      `json:"refresh_interval" yaml:"refresh_interval"`
+	DiscoverUpstream bool     `json:"discover_upstream" yaml:"discover_upstream"`
+	TargetLabels     *[]Label `json:"target_labels" yaml:"target_labels"`
+	template         *template.Template
+}
+
+// replicaEndpoint defines network endpoint of discovered replica, fields are available in DSN template.
+type replicaEndpoint struct {
+	Host            string
+	Port            uint16
+	ApplicationName string
+	Password        string
+}
+
+// ReplicasDiscovery is the discoverer of standbys connected to the configured primaries.
+type ReplicasDiscovery struct {
+	*pollingDiscovery
+	config []ReplicasConfig
+}
+
+// NewReplicasDiscovery return pointer initialized ReplicasDiscovery structure
+func NewReplicasDiscovery() *ReplicasDiscovery {
+	return &ReplicasDiscovery{pollingDiscovery: newPollingDiscovery("Replicas")}
+}
+
+// Init implementation Init method of Discovery interface
+func (rd *ReplicasDiscovery) Init(cfg discovery.Config) error {
+	log.Debug("[Replicas SD] Init discovery config...")
+	c, err := ensureConfigReplicas(cfg)
+	if err != nil {
+		log.Errorf("[Replicas SD] Failed to init discovery config, error: %v", err)
+		return err
+	}
+	rd.config = c
+	rd.initServices(len(c))
+	return nil
+}
+
+// Start implementation Start method of Discovery interface
+func (rd *ReplicasDiscovery) Start(ctx context.Context, errCh chan<- error) error {
+	interval := defaultReplicasRefreshInterval
+	for _, c := range rd.config {
+		if c.RefreshInterval > 0 && c.RefreshInterval < interval {
+			interval = c.RefreshInterval
+		}
+	}
+
+	rd.poll(ctx, errCh, time.Duration(interval)*time.Second, rd.refresh)
+	return nil
+}
+
+// refresh polls primaries and updates discovered services. Services of unavailable primaries are kept as is.
+func (rd *ReplicasDiscovery) refresh(context.Context) {
+	for i, c := range rd.config {
+		services, err := discoverReplicas(c)
+		if err != nil {
+			log.Errorf("[Replicas SD] Failed to discover replicas, error: %s", err.Error())
+			continue
+		}
+
+		rd.setServices(i, services)
+	}
+}
+
+// discoverReplicas connects to the primary and returns services of its standbys (and upstream if requested).
+func discoverReplicas(c ReplicasConfig) (map[string]discovery.Service, error) {
+	pgconfig, err := pgx.ParseConfig(c.PrimaryDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := store.New(c.PrimaryDSN, replicasConnTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res, err := conn.Query(replicasQuery)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := parseReplicaEndpoints(res, pgconfig.Port)
+
+	if c.DiscoverUpstream {
+		res, err := conn.Query(upstreamQuery)
+		if err != nil {
+			log.Errorf("[Replicas SD] Failed to get upstream of %s:%d, error: %s; skip", pgconfig.Host, pgconfig.Port, err)
+		} else {
+			endpoints = append(endpoints, parseReplicaEndpoints(res, pgconfig.Port)...)
+		}
+	}
+
+	return newReplicaServices(c, net.JoinHostPort(pgconfig.Host, strconv.Itoa(int(pgconfig.Port))), pgconfig.Password, endpoints)
+}
+
+// parseReplicaEndpoints parses PGResult and returns endpoints, defaultPort is used when port is not returned.
+func parseReplicaEndpoints(r *model.PGResult, defaultPort uint16) []replicaEndpoint {
+	endpoints := make([]replicaEndpoint, 0, r.Nrows)
+
+	for _, row := range r.Rows {
+		e := replicaEndpoint{Port: defaultPort}
+		for i, colname := range r.Colnames {
+			switch string(colname.Name) {
+			case "host":
+				e.Host = row[i].String
+			case "application_name":
+				e.ApplicationName = row[i].String
+			case "port":
+				if v, err := strconv.ParseUint(row[i].String, 10, 16); err == nil && v > 0 {
+					e.Port = uint16(v)
+				}
+			}
+		}
+
+		// Skip connections through UNIX sockets.
+		if e.Host == "" || strings.HasPrefix(e.Host, "/") {
+			continue
+		}
+
+		endpoints = append(endpoints, e)
+	}
+
+	return endpoints
+}
+
+// newReplicaServices creates services of endpoints using DSN template.
+func newReplicaServices(c ReplicasConfig, primary string, password string, endpoints []replicaEndpoint) (map[string]discovery.Service, error) {
+	targetLabels := make(map[string]string)
+	if c.TargetLabels != nil {
+		for _, item := range *c.TargetLabels {
+			targetLabels[item.Name] = item.Value
+		}
+	}
+
+	services := make(map[string]discovery.Service, len(endpoints))
+	for _, e := range endpoints {
+		e.Password = password
+
+		var buf bytes.Buffer
+		err := c.template.Execute(&buf, e)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceID := "postgres:" + net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port)))
+		services[serviceID] = discovery.Service{
+			DSN:          buf.String(),
+			ConstLabels:  map[string]string{"provider": discovery.Replicas, "discovered_from": primary},
+			TargetLabels: maps.Clone(targetLabels),
+		}
+	}
+
+	return services, nil
+}
+
+func ensureConfigReplicas(config discovery.Config) ([]ReplicasConfig, error) {
+	c := &[]ReplicasConfig{}
+	o, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(o, c)
+	if err != nil {
+		return nil, err
+	}
+	for i, rc := range *c {
+		if rc.PrimaryDSN == "" {
+			return nil, fmt.Errorf("primary_dsn is not specified")
+		}
+		if rc.DSNTemplate == "" {
+			return nil, fmt.Errorf("dsn_template is not specified")
+		}
+		(*c)[i].template, err = template.New("dsn").Parse(rc.DSNTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dsn_template: %w", err)
+		}
+	}
+	return *c, nil
+}
@@ -3,7 +3,12 @@ package http
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -21,7 +26,9 @@ const (
 
 // Client defines local wrapper on standard http.Client.
 type Client struct {
-	client *http.Client
+	client   *http.Client
+	username string
+	password string
 }
 
 // ClientConfig defines initial configuration when creating Client.
@@ -29,6 +36,72 @@ type ClientConfig struct {
 	Timeout time.Duration
 }
 
+// ClientAuthConfig defines authentication settings used by Client for connecting to HTTP services.
+type ClientAuthConfig struct {
+	Username string `yaml:"username"` // username used for basic authentication
+	Password string `yaml:"password"` // #nosec G117 password used for basic authentication
+	CAfile   string `yaml:"cafile"`   // path to CA certificate file used for verifying server certificate
+	Certfile string `yaml:"certfile"` // path to client certificate file
+	Keyfile  string `yaml:"keyfile"`  // path to client key file
+}
+
+// Validate checks authentication settings of ClientAuthConfig.
+func (cfg ClientAuthConfig) Validate() error {
+	if cfg.Password != "" && cfg.Username == "" {
+		return fmt.Errorf("password specified but username is not")
+	}
+	if (cfg.Certfile == "") != (cfg.Keyfile == "") {
+		return fmt.Errorf("certfile and keyfile must be specified together")
+	}
+	return nil
+}
+
+// NewClientWithAuth creates new HTTP client for connecting to service with passed base URL using authentication
+// settings. Server certificate is not verified if CA certificate is not specified.
+func NewClientWithAuth(cfg ClientConfig, baseurl string, auth ClientAuthConfig) (*Client, error) {
+	cl := NewClient(cfg)
+	cl.username, cl.password = auth.Username, auth.Password
+
+	if !strings.HasPrefix(baseurl, "https://") {
+		return cl, nil
+	}
+
+	if auth.CAfile == "" && auth.Certfile == "" {
+		cl.EnableTLSInsecure()
+		return cl, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if auth.CAfile == "" {
+		tlsConfig.InsecureSkipVerify = true // #nosec G402
+	} else {
+		ca, err := os.ReadFile(filepath.Clean(auth.CAfile))
+		if err != nil {
+			return nil, fmt.Errorf("read CA file failed: %s", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificates found in %s", auth.CAfile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if auth.Certfile != "" {
+		cert, err := tls.LoadX509KeyPair(auth.Certfile, auth.Keyfile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate failed: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	t := cl.client.Transport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	cl.client.Transport = t
+
+	return cl, nil
+}
+
 // NewClient creates new HTTP client.
 func NewClient(cfg ClientConfig) *Client {
 	const defaultTimeout = time.Second
@@ -58,8 +131,19 @@ func (cl *Client) EnableTLSInsecure() {
 }
 
 // Get wraps a standard http.Get method which issues a GET to the specified URL.
+// Credentials for basic authentication are added if specified.
 func (cl *Client) Get(url string) (*http.Response, error) {
-	return cl.client.Get(url)
+	if cl.username == "" {
+		return cl.client.Get(url)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(cl.username, cl.password)
+
+	return cl.client.Do(req) // #nosec G704
 }
 
 // Do wraps a standard http.Do method which sends an HTTP request and returns an HTTP response.
@@ -1,8 +1,12 @@
 package http
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 )
 
@@ -40,3 +44,76 @@ func TestClient_Do(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 }
+
+func TestClientAuthConfig_Validate(t *testing.T) {
+	assert.NoError(t, ClientAuthConfig{}.Validate())
+	assert.NoError(t, ClientAuthConfig{Username: "user", Password: "pass", CAfile: "ca.crt", Certfile: "c.crt", Keyfile: "c.key"}.Validate())
+	assert.Error(t, ClientAuthConfig{Password: "pass"}.Validate())
+	assert.Error(t, ClientAuthConfig{Certfile: "c.crt"}.Validate())
+	assert.Error(t, ClientAuthConfig{Keyfile: "c.key"}.Validate())
+}
+
+func TestNewClientWithAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, nil, nil, "ca")
+	writeTestCertificate(t, filepath.Join(dir, "ca.crt"), "", ca, nil)
+	server, serverKey := newTestCertificate(t, ca, caKey, "127.0.0.1")
+	client, clientKey := newTestCertificate(t, ca, caKey, "pgscv")
+	writeTestCertificate(t, filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), client, clientKey)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "pgscv" || pass != "secret" {
+			rw.WriteHeader(StatusUnauthorized)
+			return
+		}
+		rw.WriteHeader(StatusOK)
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ts.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	auth := ClientAuthConfig{
+		Username: "pgscv", Password: "secret",
+		CAfile: filepath.Join(dir, "ca.crt"), Certfile: filepath.Join(dir, "client.crt"), Keyfile: filepath.Join(dir, "client.key"),
+	}
+
+	cl, err := NewClientWithAuth(ClientConfig{}, ts.URL, auth)
+	assert.NoError(t, err)
+	resp, err := cl.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	// wrong password
+	cl, err = NewClientWithAuth(ClientConfig{}, ts.URL, ClientAuthConfig{Username: "pgscv", Password: "invalid", CAfile: auth.CAfile, Certfile: auth.Certfile, Keyfile: auth.Keyfile})
+	assert.NoError(t, err)
+	resp, err = cl.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusUnauthorized, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	// no client certificate
+	cl, err = NewClientWithAuth(ClientConfig{}, ts.URL, ClientAuthConfig{Username: "pgscv", Password: "secret", CAfile: auth.CAfile})
+	assert.NoError(t, err)
+	_, err = cl.Get(ts.URL)
+	assert.Error(t, err)
+
+	// plain HTTP, TLS settings are not applied
+	cl, err = NewClientWithAuth(ClientConfig{}, "http://127.0.0.1", ClientAuthConfig{CAfile: "/invalid"})
+	assert.NoError(t, err)
+	assert.Nil(t, cl.client.Transport.(*http.Transport).TLSClientConfig)
+
+	// invalid files
+	_, err = NewClientWithAuth(ClientConfig{}, ts.URL, ClientAuthConfig{CAfile: "/invalid"})
+	assert.Error(t, err)
+	_, err = NewClientWithAuth(ClientConfig{}, ts.URL, ClientAuthConfig{Certfile: "/invalid", Keyfile: "/invalid"})
+	assert.Error(t, err)
+}
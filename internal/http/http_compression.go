@@ -0,0 +1,118 @@
+package http
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+
+	// MaxCompressionLevel defines the highest compression level could be forced through configuration.
+	MaxCompressionLevel = 9
+)
+
+// compressResponseWriter passes response body through compressor.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	writer io.Writer
+}
+
+// Write writes compressed data to the connection.
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	return w.writer.Write(b)
+}
+
+// compressHandler wraps handler and compresses response using encoding negotiated with client through Accept-Encoding
+// header. Response body is streamed through compressor directly into connection (using chunked transfer encoding)
+// without buffering the whole payload. Level 0 means default level of the negotiated encoding.
+func compressHandler(level int, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next(w, r)
+			return
+		}
+
+		cw, err := newCompressWriter(w, encoding, level)
+		if err != nil {
+			httpLog.Warnf("create %s compressor failed: %s; send uncompressed response", encoding, err)
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", encoding)
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Del("Content-Length")
+
+		next(&compressResponseWriter{ResponseWriter: w, writer: cw}, r)
+
+		err = cw.Close()
+		if err != nil {
+			httpLog.Warnf("close %s compressor failed: %s", encoding, err)
+		}
+	}
+}
+
+// newCompressWriter creates compressor with requested encoding and level.
+func newCompressWriter(w io.Writer, encoding string, level int) (io.WriteCloser, error) {
+	switch encoding {
+	case encodingZstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level > 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	case encodingGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	default:
+		return nil, fmt.Errorf("unsupported encoding '%s'", encoding)
+	}
+}
+
+// negotiateEncoding parses Accept-Encoding header and returns the most preferred supported encoding, zstd is preferred
+// over gzip when client accepts both with the same weight. Empty string returned if no supported encodings accepted.
+func negotiateEncoding(header string) string {
+	var (
+		encoding string
+		weight   float64
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if name != encodingGzip && name != encodingZstd {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		if q > weight || (q == weight && name == encodingZstd) {
+			encoding, weight = name, q
+		}
+	}
+
+	return encoding
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_negotiateEncoding(t *testing.T) {
+	testcases := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "identity", want: ""},
+		{header: "deflate, br", want: ""},
+		{header: "gzip", want: "gzip"},
+		{header: "GZIP", want: "gzip"},
+		{header: "gzip, zstd", want: "zstd"},
+		{header: "zstd;q=0.5, gzip", want: "gzip"},
+		{header: "gzip;q=0, zstd;q=0", want: ""},
+		{header: "gzip;q=invalid", want: ""},
+		{header: "gzip; q=0.8, deflate", want: "gzip"},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, negotiateEncoding(tc.header), tc.header)
+	}
+}
+
+func Test_compressHandler(t *testing.T) {
+	payload := strings.Repeat("postgres_up{service_id=\"test\"} 1\n", 1000)
+	raw := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(payload))
+	}
+	handler := compressHandler(0, raw)
+
+	testcases := []struct {
+		encoding string
+		reader   func(r io.Reader) (io.Reader, error)
+	}{
+		{encoding: "", reader: func(r io.Reader) (io.Reader, error) { return r, nil }},
+		{encoding: "gzip", reader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{encoding: "zstd", reader: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	}
+
+	for _, tc := range testcases {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", tc.encoding)
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+		assert.Equal(t, tc.encoding, rec.Header().Get("Content-Encoding"))
+
+		r, err := tc.reader(rec.Body)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, string(body))
+	}
+
+	// Forced compression level.
+	for _, level := range []int{1, MaxCompressionLevel} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		compressHandler(level, raw)(rec, req)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+		r, err := gzip.NewReader(rec.Body)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, string(body))
+	}
+}
+
+func Test_compressHandler_streaming(t *testing.T) {
+	var part strings.Builder
+	for i := 0; i < 20000; i++ {
+		_, _ = fmt.Fprintf(&part, "postgres_table_seq_scan_total{relname=\"t%d\"} %d\n", i, i*7)
+	}
+
+	// Handler sends the first part and waits until client receives it, so the test hangs if the whole response is
+	// buffered before sending.
+	proceed := make(chan struct{})
+	srv := httptest.NewServer(compressHandler(0, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(part.String()))
+		<-proceed
+		_, _ = w.Write([]byte(part.String()))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, int64(-1), resp.ContentLength)
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+	r, err := gzip.NewReader(resp.Body)
+	assert.NoError(t, err)
+
+	first := make([]byte, 4096)
+	done := make(chan error)
+	go func() {
+		_, err := io.ReadFull(r, first)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		close(proceed)
+		t.Fatal("response is not streamed")
+	}
+	close(proceed)
+
+	rest, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, part.String()+part.String(), string(first)+string(rest))
+}
+
+func Test_compressHandler_level(t *testing.T) {
+	raw := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("postgres_up{service_id=\"test\"} 1\n", 1000)))
+	}
+
+	// Gzip header holds flag of used compression: 4 for the fastest compression, 2 for the best compression.
+	testcases := []struct {
+		level int
+		xfl   byte
+	}{
+		{level: 0, xfl: 0},
+		{level: 1, xfl: 4},
+		{level: MaxCompressionLevel, xfl: 2},
+	}
+
+	for _, tc := range testcases {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		compressHandler(tc.level, raw)(rec, req)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, tc.xfl, rec.Body.Bytes()[8])
+	}
+}
@@ -2,13 +2,20 @@
 package http
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"os"
+	"slices"
 	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 )
 
+// httpLog is the logger of http module.
+var httpLog = log.Module("http")
+
 // AuthConfig defines configuration settings for authentication.
 type AuthConfig struct {
 	EnableAuth bool   // flag tells about authentication should be enabled
@@ -17,6 +24,10 @@ type AuthConfig struct {
 	EnableTLS  bool   // flag tells about TLS should be enabled
 	Keyfile    string `yaml:"keyfile"`  // path to key file
 	Certfile   string `yaml:"certfile"` // path to certificate file
+	// ClientCAfile defines path to CA certificate file, when specified clients must present certificate signed by the CA.
+	ClientCAfile string `yaml:"client_cafile"`
+	// AllowedClients defines list of allowed clients, client certificate CN or SAN must match one of the list.
+	AllowedClients []string `yaml:"allowed_clients"`
 }
 
 // Validate check authentication options of AuthConfig and returns toggle flags.
@@ -31,6 +42,14 @@ func (cfg AuthConfig) Validate() (bool, bool, error) {
 		return false, false, fmt.Errorf("TLS settings invalid")
 	}
 
+	if cfg.ClientCAfile != "" && cfg.Keyfile == "" {
+		return false, false, fmt.Errorf("TLS client authentication requires keyfile and certfile")
+	}
+
+	if len(cfg.AllowedClients) > 0 && cfg.ClientCAfile == "" {
+		return false, false, fmt.Errorf("allowed clients requires client CA file")
+	}
+
 	if cfg.Username != "" && cfg.Password != "" {
 		enableAuth = true
 	}
@@ -44,7 +63,8 @@ func (cfg AuthConfig) Validate() (bool, bool, error) {
 
 // ServerConfig defines HTTP server configuration.
 type ServerConfig struct {
-	Addr string
+	Addr             string
+	CompressionLevel int // compression level of /metrics responses, 0 means default level of negotiated encoding
 	AuthConfig
 }
 
@@ -54,26 +74,61 @@ type Server struct {
 	server *http.Server
 }
 
-// NewServer creates new HTTP server instance.
+// NewServer creates new HTTP server instance. The silence, top queries and collect handlers are optional and
+// registered only if passed.
 func NewServer(cfg ServerConfig,
 	handlerMetrics func(http.ResponseWriter, *http.Request),
 	targetsMetrics func(http.ResponseWriter, *http.Request),
 	flushServiceConfig func(http.ResponseWriter, *http.Request),
+	silence func(http.ResponseWriter, *http.Request),
+	topQueries func(http.ResponseWriter, *http.Request),
+	collect func(http.ResponseWriter, *http.Request),
+	assets func(http.ResponseWriter, *http.Request),
 ) *Server {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/", handleRoot())
+	handlerMetrics = compressHandler(cfg.CompressionLevel, handlerMetrics)
 	if cfg.EnableAuth {
 		mux.HandleFunc("/metrics", basicAuth(cfg.AuthConfig, handlerMetrics))
 	} else {
 		mux.HandleFunc("/metrics", handlerMetrics)
 	}
 	mux.HandleFunc("/targets", targetsMetrics)
+	mux.HandleFunc("/targets/", targetsMetrics)
 	if cfg.EnableAuth {
 		mux.HandleFunc("/flush-services-config", basicAuth(cfg.AuthConfig, flushServiceConfig))
 	} else {
 		mux.HandleFunc("/flush-services-config", flushServiceConfig)
 	}
+	if silence != nil {
+		if cfg.EnableAuth {
+			mux.HandleFunc("/silence", basicAuth(cfg.AuthConfig, silence))
+		} else {
+			mux.HandleFunc("/silence", silence)
+		}
+	}
+	if topQueries != nil {
+		if cfg.EnableAuth {
+			mux.HandleFunc("/top-queries", basicAuth(cfg.AuthConfig, topQueries))
+		} else {
+			mux.HandleFunc("/top-queries", topQueries)
+		}
+	}
+	if collect != nil {
+		if cfg.EnableAuth {
+			mux.HandleFunc("/collect", basicAuth(cfg.AuthConfig, collect))
+		} else {
+			mux.HandleFunc("/collect", collect)
+		}
+	}
+	if assets != nil {
+		if cfg.EnableAuth {
+			mux.HandleFunc("/assets/", basicAuth(cfg.AuthConfig, assets))
+		} else {
+			mux.HandleFunc("/assets/", assets)
+		}
+	}
 
 	return &Server{
 		config: cfg,
@@ -90,11 +145,19 @@ func NewServer(cfg ServerConfig,
 // Serve method starts listening and serving requests.
 func (s *Server) Serve() error {
 	if s.config.EnableTLS {
-		log.Infof("listen on https://%s", s.server.Addr)
+		if s.config.ClientCAfile != "" {
+			tlsConfig, err := newClientAuthTLSConfig(s.config.AuthConfig)
+			if err != nil {
+				return err
+			}
+			s.server.TLSConfig = tlsConfig
+		}
+
+		httpLog.Infof("listen on https://%s", s.server.Addr)
 		return s.server.ListenAndServeTLS(s.config.Certfile, s.config.Keyfile)
 	}
 
-	log.Infof("listen on http://%s", s.server.Addr)
+	httpLog.Infof("listen on http://%s", s.server.Addr)
 	return s.server.ListenAndServe()
 }
 
@@ -105,8 +168,9 @@ func handleRoot() http.HandlerFunc {
 <body>
 pgSCV / PostgreSQL metrics collector, for more info visit <a href="https://github.com/cherts/pgscv">Github</a> page.
 <p><a href="/metrics">Metrics</a> (add ?target=service_id, to get metrics for one service)</p>
-<p><a href="/targets">Targets</a></p>
+<p><a href="/targets">Targets</a> (add /service_type, e.g. /targets/postgres, to get Prometheus HTTP SD targets of services of the type)</p>
 <p><a href="/flush-services-config">Reload service config</a></p>
+<p><a href="/assets/dashboards">Grafana dashboard</a> and <a href="/assets/alerts">Prometheus alerting rules</a> for enabled collectors</p>
 </body>
 </html>
 `
@@ -114,7 +178,7 @@ pgSCV / PostgreSQL metrics collector, for more info visit <a href="https://githu
 	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		_, err := w.Write([]byte(htmlTemplate))
 		if err != nil {
-			log.Warnln("response write failed: ", err)
+			httpLog.Warnf("response write failed: %s", err)
 		}
 	})
 }
@@ -133,3 +197,55 @@ func basicAuth(cfg AuthConfig, next http.HandlerFunc) http.HandlerFunc {
 		http.Error(w, "Unauthorized", StatusUnauthorized)
 	})
 }
+
+// newClientAuthTLSConfig creates TLS config which requires clients to present certificate signed by configured CA.
+// When allowed clients are specified, CN or SAN of client certificate must match one of them.
+func newClientAuthTLSConfig(cfg AuthConfig) (*tls.Config, error) {
+	content, err := os.ReadFile(cfg.ClientCAfile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAfile)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}
+
+	if len(cfg.AllowedClients) > 0 {
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 || !clientAllowed(state.PeerCertificates[0], cfg.AllowedClients) {
+				return fmt.Errorf("client certificate is not allowed")
+			}
+			return nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// clientAllowed returns true if CN or any SAN of the certificate is in the allowed list.
+func clientAllowed(cert *x509.Certificate, allowed []string) bool {
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+
+	for _, name := range names {
+		if name != "" && slices.Contains(allowed, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,9 +1,20 @@
 package http
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -25,6 +36,9 @@ func TestAuthConfig_Validate(t *testing.T) {
 		{valid: false, cfg: AuthConfig{Username: "", Password: "pass"}},
 		{valid: false, cfg: AuthConfig{Keyfile: "key", Certfile: ""}},
 		{valid: false, cfg: AuthConfig{Keyfile: "", Certfile: "cert"}},
+		{valid: true, cfg: AuthConfig{Keyfile: "key", Certfile: "cert", ClientCAfile: "ca", AllowedClients: []string{"client"}}, wantAuth: false, wantTLS: true},
+		{valid: false, cfg: AuthConfig{ClientCAfile: "ca"}},
+		{valid: false, cfg: AuthConfig{Keyfile: "key", Certfile: "cert", AllowedClients: []string{"client"}}},
 	}
 
 	for _, tc := range testcases {
@@ -41,7 +55,7 @@ func TestAuthConfig_Validate(t *testing.T) {
 
 func TestServer_Serve_HTTP(t *testing.T) {
 	addr := "127.0.0.1:17890"
-	srv := NewServer(ServerConfig{Addr: addr}, getDummyHandler(), getDummyHandler(), getDummyHandler())
+	srv := NewServer(ServerConfig{Addr: addr}, getDummyHandler(), getDummyHandler(), getDummyHandler(), nil, nil, nil, nil)
 
 	var wg sync.WaitGroup
 	wg.Go(func() {
@@ -61,6 +75,30 @@ func TestServer_Serve_HTTP(t *testing.T) {
 	}
 }
 
+func TestNewServer_topQueries(t *testing.T) {
+	cfg := ServerConfig{AuthConfig: AuthConfig{EnableAuth: true, Username: "user", Password: "pass"}}
+	srv := NewServer(cfg, getDummyHandler(), getDummyHandler(), getDummyHandler(), nil, getDummyHandler(), nil, nil)
+
+	// Unauthenticated requests are rejected.
+	res := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/top-queries?service_id=test", nil))
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/top-queries?service_id=test", nil)
+	req.SetBasicAuth("user", "pass")
+	res = httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// Endpoint is not registered when handler is not passed, request is served by root handler.
+	srv = NewServer(cfg, getDummyHandler(), getDummyHandler(), getDummyHandler(), nil, nil, nil, nil)
+	req = httptest.NewRequest(http.MethodGet, "/top-queries?service_id=test", nil)
+	req.SetBasicAuth("user", "pass")
+	res = httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(res, req)
+	assert.Contains(t, res.Body.String(), "PostgreSQL metrics collector")
+}
+
 func getDummyHandler() func(w http.ResponseWriter, r *http.Request) {
 	return func(http.ResponseWriter, *http.Request) {
 	}
@@ -72,7 +110,7 @@ func TestServer_Serve_HTTPS(t *testing.T) {
 		EnableTLS: true,
 		Keyfile:   "./testdata/example.key",
 		Certfile:  "./testdata/example.crt",
-	}}, getDummyHandler(), getDummyHandler(), getDummyHandler())
+	}}, getDummyHandler(), getDummyHandler(), getDummyHandler(), nil, nil, nil, nil)
 
 	var wg sync.WaitGroup
 	wg.Go(func() {
@@ -141,3 +179,99 @@ func Test_basicAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_Serve_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, nil, nil, "ca")
+	writeTestCertificate(t, filepath.Join(dir, "ca.crt"), "", ca, nil)
+	server, serverKey := newTestCertificate(t, ca, caKey, "127.0.0.1")
+	writeTestCertificate(t, filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), server, serverKey)
+
+	addr := "127.0.0.1:17892"
+	srv := NewServer(ServerConfig{Addr: addr, AuthConfig: AuthConfig{
+		EnableTLS:      true,
+		Keyfile:        filepath.Join(dir, "server.key"),
+		Certfile:       filepath.Join(dir, "server.crt"),
+		ClientCAfile:   filepath.Join(dir, "ca.crt"),
+		AllowedClients: []string{"prometheus"},
+	}}, getDummyHandler(), getDummyHandler(), getDummyHandler(), nil, nil, nil, nil)
+
+	go func() { _ = srv.Serve() }()
+	time.Sleep(100 * time.Millisecond)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	newClient := func(certs []tls.Certificate) *http.Client {
+		return &http.Client{Timeout: time.Second, Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool, Certificates: certs},
+		}}
+	}
+
+	allowed, allowedKey := newTestCertificate(t, ca, caKey, "prometheus")
+	denied, deniedKey := newTestCertificate(t, ca, caKey, "intruder")
+
+	resp, err := newClient([]tls.Certificate{{Certificate: [][]byte{allowed.Raw}, PrivateKey: allowedKey}}).Get("https://" + addr + "/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	_, err = newClient([]tls.Certificate{{Certificate: [][]byte{denied.Raw}, PrivateKey: deniedKey}}).Get("https://" + addr + "/metrics")
+	assert.Error(t, err)
+
+	_, err = newClient(nil).Get("https://" + addr + "/metrics")
+	assert.Error(t, err)
+}
+
+func Test_clientAllowed(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "prometheus"}, DNSNames: []string{"prometheus.example.org"}}
+
+	assert.True(t, clientAllowed(cert, []string{"prometheus"}))
+	assert.True(t, clientAllowed(cert, []string{"prometheus.example.org"}))
+	assert.False(t, clientAllowed(cert, []string{"intruder"}))
+	assert.False(t, clientAllowed(&x509.Certificate{}, []string{""}))
+}
+
+// newTestCertificate creates certificate signed by parent, or self-signed CA certificate if parent is nil.
+func newTestCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, cn string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if ip := net.ParseIP(cn); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	}
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return cert, key
+}
+
+// writeTestCertificate writes certificate and optionally its key into files in PEM format.
+func writeTestCertificate(t *testing.T, certfile, keyfile string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	assert.NoError(t, os.WriteFile(certfile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600))
+
+	if keyfile != "" {
+		der, err := x509.MarshalECPrivateKey(key)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(keyfile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+	}
+}
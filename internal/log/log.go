@@ -3,7 +3,10 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 )
@@ -11,25 +14,125 @@ import (
 // Logger is the global logger with predefined settings
 var Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
 
+// output defines destination of log messages.
+var output io.Writer = os.Stdout
+
+var (
+	// levelsMu protects default and per-module logging levels.
+	levelsMu sync.RWMutex
+	// defaultLevel defines logging level of messages which are not attached to any module.
+	defaultLevel = zerolog.InfoLevel
+	// moduleLevels defines logging levels of modules which differ from default level.
+	moduleLevels = map[string]zerolog.Level{}
+	// moduleLoggers caches loggers of modules, the cache is reset when settings are changed.
+	moduleLoggers = map[string]*zerolog.Logger{}
+)
+
 // KV is a simple key-value store
 type KV map[string]string
 
 // SetLevel sets logging level
 func SetLevel(level string) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	lvl, ok := parseLevel(level)
+	if !ok {
+		lvl = zerolog.InfoLevel
+	}
+	defaultLevel = lvl
+	applyLevels()
+}
+
+// SetModuleLevels sets logging levels of particular modules, levels are specified as comma-separated list of
+// module=level pairs, e.g. 'collector=warn,store=debug'.
+func SetModuleLevels(spec string) error {
+	levels := map[string]zerolog.Level{}
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		module, level, found := strings.Cut(pair, "=")
+		if !found || module == "" {
+			return fmt.Errorf("invalid module log level '%s', must be in module=level format", pair)
+		}
+
+		lvl, ok := parseLevel(level)
+		if !ok {
+			return fmt.Errorf("invalid log level '%s' of module '%s', allowed: debug, info, warn, error", level, module)
+		}
+		levels[module] = lvl
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	moduleLevels = levels
+	applyLevels()
+	return nil
+}
+
+// SetOutput sets destination of log messages, stdout is used by default. Takes effect when SetFormat is called.
+func SetOutput(w io.Writer) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	output = w
+}
+
+// SetFormat sets format of log messages, allowed 'json' (default) and 'console'. Should be called before any other
+// logger settings, because logger is recreated.
+func SetFormat(format string) error {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	switch format {
+	case "", "json":
+		Logger = zerolog.New(output).With().Timestamp().Logger()
+	case "console":
+		Logger = zerolog.New(zerolog.ConsoleWriter{Out: output, NoColor: true}).With().Timestamp().Logger()
+	default:
+		return fmt.Errorf("invalid log format '%s', allowed: json, console", format)
+	}
+
+	applyLevels()
+	return nil
+}
+
+// parseLevel parses level name.
+func parseLevel(level string) (zerolog.Level, bool) {
 	switch level {
 	case "debug":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		return zerolog.DebugLevel, true
 	case "info":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return zerolog.InfoLevel, true
 	case "warn":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+		return zerolog.WarnLevel, true
 	case "error":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+		return zerolog.ErrorLevel, true
 	default:
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return zerolog.NoLevel, false
 	}
 }
 
+// applyLevels applies default and per-module levels to loggers. Global level is set to the most verbose level, hence
+// messages are filtered by levels of particular loggers. Must be called with levelsMu held.
+func applyLevels() {
+	global := defaultLevel
+	for _, lvl := range moduleLevels {
+		if lvl < global {
+			global = lvl
+		}
+	}
+
+	zerolog.SetGlobalLevel(global)
+	Logger = Logger.Level(defaultLevel)
+	moduleLoggers = map[string]*zerolog.Logger{}
+}
+
 // New create logger
 func New() zerolog.Logger {
 	var logger = Logger
@@ -38,7 +141,11 @@ func New() zerolog.Logger {
 
 // SetApplication appends application name string to log messages
 func SetApplication(app string) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
 	Logger = Logger.With().Str("service", app).Logger()
+	moduleLoggers = map[string]*zerolog.Logger{}
 }
 
 // Debug prints message with DEBUG severity
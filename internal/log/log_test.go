@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureLogger replaces global logger with logger writing into buffer.
+func captureLogger(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	orig := Logger
+
+	levelsMu.Lock()
+	Logger = zerolog.New(buf)
+	levelsMu.Unlock()
+
+	t.Cleanup(func() {
+		Logger = orig
+		SetLevel("info")
+		assert.NoError(t, SetModuleLevels(""))
+	})
+
+	return buf
+}
+
+func TestSetModuleLevels(t *testing.T) {
+	buf := captureLogger(t)
+
+	SetLevel("warn")
+	assert.NoError(t, SetModuleLevels("collector=debug, store=error"))
+
+	Infof("default info")
+	Module("collector").Debugf("collector debug")
+	Module("store").Warnf("store warn")
+	Module("http").Warnf("http warn")
+
+	out := buf.String()
+	assert.NotContains(t, out, "default info")
+	assert.Contains(t, out, "collector debug")
+	assert.NotContains(t, out, "store warn")
+	assert.Contains(t, out, "http warn")
+
+	for _, spec := range []string{"collector", "=debug", "collector=verbose"} {
+		assert.Error(t, SetModuleLevels(spec))
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	orig := Logger
+	defer func() { Logger = orig }()
+
+	assert.NoError(t, SetFormat("json"))
+	assert.NoError(t, SetFormat("console"))
+	assert.Error(t, SetFormat("xml"))
+}
+
+func TestSetOutput(t *testing.T) {
+	orig := Logger
+	defer func() {
+		Logger = orig
+		SetOutput(os.Stdout)
+	}()
+
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	assert.NoError(t, SetFormat("json"))
+
+	Logger.Error().Msg("test message")
+	assert.Contains(t, buf.String(), "test message")
+}
+
+func TestModuleLogger_ErrorfLimited(t *testing.T) {
+	buf := captureLogger(t)
+	SetDedupInterval(time.Hour)
+	defer SetDedupInterval(defaultDedupInterval)
+
+	l := Module("test")
+	for range 3 {
+		l.ErrorfLimited("key", "permission denied for %s", "pg_stat_statements")
+	}
+	l.ErrorfLimited("key", "another error")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+
+	var msg map[string]any
+	assert.NoError(t, json.Unmarshal(lines[0], &msg))
+	assert.Equal(t, "permission denied for pg_stat_statements", msg["message"])
+	assert.Equal(t, "test", msg["module"])
+
+	ch := make(chan prometheus.Metric, 10)
+	SuppressedCollector.Collect(ch)
+	close(ch)
+	assert.Len(t, ch, 1)
+}
+
+func Test_dedup_allow(t *testing.T) {
+	d := &dedup{interval: time.Minute, entries: map[string]*dedupEntry{}}
+	now := time.Now()
+
+	ok, n := d.allow("msg", now)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), n)
+
+	ok, _ = d.allow("msg", now.Add(time.Second))
+	assert.False(t, ok)
+	ok, _ = d.allow("msg", now.Add(2*time.Second))
+	assert.False(t, ok)
+
+	// After interval message is sent again with number of suppressed repeats.
+	ok, n = d.allow("msg", now.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), n)
+
+	// Disabled suppression.
+	d.interval = 0
+	ok, _ = d.allow("msg", now.Add(time.Minute))
+	assert.True(t, ok)
+}
@@ -0,0 +1,206 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// defaultDedupInterval defines default interval during which repeating messages are suppressed.
+const defaultDedupInterval = 5 * time.Minute
+
+// dedupMaxEntries defines number of tracked messages after which stale entries are purged.
+const dedupMaxEntries = 1000
+
+// ModuleLogger is the logger attached to particular module, module's messages are logged accordingly to module's level.
+type ModuleLogger struct {
+	name string
+}
+
+// Module returns logger of the module.
+func Module(name string) ModuleLogger {
+	return ModuleLogger{name: name}
+}
+
+// logger returns underlying logger of the module configured with module's level.
+func (m ModuleLogger) logger() *zerolog.Logger {
+	levelsMu.RLock()
+	l, ok := moduleLoggers[m.name]
+	levelsMu.RUnlock()
+	if ok {
+		return l
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	lvl, ok := moduleLevels[m.name]
+	if !ok {
+		lvl = defaultLevel
+	}
+
+	logger := Logger.Level(lvl).With().Str("module", m.name).Logger()
+	moduleLoggers[m.name] = &logger
+	return &logger
+}
+
+// Debugf prints formatted message with DEBUG severity
+func (m ModuleLogger) Debugf(format string, v ...any) {
+	m.logger().Debug().Msgf(format, v...)
+}
+
+// Infof prints formatted message with INFO severity
+func (m ModuleLogger) Infof(format string, v ...any) {
+	m.logger().Info().Msgf(format, v...)
+}
+
+// Warnf prints formatted message with WARNING severity
+func (m ModuleLogger) Warnf(format string, v ...any) {
+	m.logger().Warn().Msgf(format, v...)
+}
+
+// Errorf prints formatted message with ERROR severity
+func (m ModuleLogger) Errorf(format string, v ...any) {
+	m.logger().Error().Msgf(format, v...)
+}
+
+// WarnfLimited prints formatted message with WARNING severity, repeating messages with the same key are suppressed.
+func (m ModuleLogger) WarnfLimited(key string, format string, v ...any) {
+	m.limited(m.logger().Warn(), key, format, v...)
+}
+
+// ErrorfLimited prints formatted message with ERROR severity, repeating messages with the same key are suppressed.
+func (m ModuleLogger) ErrorfLimited(key string, format string, v ...any) {
+	m.limited(m.logger().Error(), key, format, v...)
+}
+
+// limited sends event if the same message has not been sent during dedup interval. Number of messages suppressed since
+// last sending is attached to the event.
+func (m ModuleLogger) limited(e *zerolog.Event, key string, format string, v ...any) {
+	// Event is nil when level is disabled.
+	if e == nil {
+		return
+	}
+
+	msg := fmt.Sprintf(format, v...)
+
+	ok, suppressed := messages.allow(key+"\x00"+msg, time.Now())
+	if !ok {
+		suppressedMessages.inc(m.name)
+		e.Discard()
+		return
+	}
+
+	if suppressed > 0 {
+		e = e.Uint64("suppressed", suppressed)
+	}
+	e.Msg(msg)
+}
+
+// dedup tracks recently sent messages.
+type dedup struct {
+	mu       sync.Mutex
+	interval time.Duration
+	entries  map[string]*dedupEntry
+}
+
+// dedupEntry defines the time when message has been sent and number of its repeats suppressed since then.
+type dedupEntry struct {
+	last       time.Time
+	suppressed uint64
+}
+
+// messages defines global store of recently sent messages.
+var messages = &dedup{interval: defaultDedupInterval, entries: map[string]*dedupEntry{}}
+
+// SetDedupInterval sets interval during which repeating messages are suppressed, zero disables suppression.
+func SetDedupInterval(interval time.Duration) {
+	messages.mu.Lock()
+	defer messages.mu.Unlock()
+
+	messages.interval = interval
+	messages.entries = map[string]*dedupEntry{}
+}
+
+// allow returns true if message should be sent and number of its repeats suppressed since it was sent last time.
+func (d *dedup) allow(key string, now time.Time) (bool, uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.interval <= 0 {
+		return true, 0
+	}
+
+	e, ok := d.entries[key]
+	if ok && now.Sub(e.last) < d.interval {
+		e.suppressed++
+		return false, 0
+	}
+
+	if !ok {
+		if len(d.entries) >= dedupMaxEntries {
+			d.purge(now)
+		}
+		e = &dedupEntry{}
+		d.entries[key] = e
+	}
+
+	suppressed := e.suppressed
+	e.last, e.suppressed = now, 0
+
+	return true, suppressed
+}
+
+// purge removes entries which are not tracked anymore. Must be called with mutex held.
+func (d *dedup) purge(now time.Time) {
+	for k, e := range d.entries {
+		if now.Sub(e.last) >= d.interval {
+			delete(d.entries, k)
+		}
+	}
+}
+
+// suppressedCounter counts suppressed messages per module.
+type suppressedCounter struct {
+	mu    sync.Mutex
+	store map[string]float64
+	desc  *prometheus.Desc
+}
+
+// suppressedMessages defines global counter of suppressed messages.
+var suppressedMessages = &suppressedCounter{
+	store: map[string]float64{},
+	desc: prometheus.NewDesc(
+		"pgscv_log_messages_suppressed_total",
+		"Total number of repeating log messages suppressed, by module.",
+		[]string{"module"}, nil,
+	),
+}
+
+// SuppressedCollector is the collector exposing number of suppressed log messages.
+var SuppressedCollector prometheus.Collector = suppressedMessages
+
+// inc increments number of messages suppressed in module.
+func (c *suppressedCounter) inc(module string) {
+	c.mu.Lock()
+	c.store[module]++
+	c.mu.Unlock()
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *suppressedCounter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *suppressedCounter) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for module, v := range c.store {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, v, module)
+	}
+}
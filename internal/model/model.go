@@ -4,8 +4,10 @@ package model
 import (
 	"database/sql"
 	"regexp"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
+	"github.com/cherts/pgscv/internal/http"
 	"github.com/jackc/pgproto3/v2"
 )
 
@@ -52,6 +54,13 @@ type PGResult struct {
 //              labeledValues:                                  <- UserMetric.LabeledValues
 //                extra: [ l2, l3 ]
 //              description: v1 description
+//
+// Custom metrics of Patroni services (patroni/custom) are requested from REST API, subsystems define 'endpoint' and
+// 'path' (JSONPath expression selecting objects used as rows) instead of 'query', e.g. endpoint: /cluster and
+// path: $.members[*].
+//
+// Custom metrics of arbitrary HTTP endpoints (http/custom) are requested from 'url' of subsystems, values are extracted
+// using either JSONPath expression ('path') or regular expression with named groups ('regex').
 
 // CollectorsSettings unions all collectors settings in one place.
 type CollectorsSettings map[string]CollectorSettings
@@ -62,6 +71,33 @@ type CollectorSettings struct {
 	Filters filter.Filters `yaml:"filters"`
 	// Subsystems defines subsystem with user-defined metrics.
 	Subsystems Subsystems `yaml:"subsystems"`
+	// Commands defines local commands which print metrics in Prometheus text format.
+	Commands Commands `yaml:"commands"`
+	// Units defines patterns of systemd units which state is monitored.
+	Units []string `yaml:"units"`
+	// Query defines a SQL statement overriding built-in query of the collector.
+	Query string `yaml:"query"`
+	// PlanID defines statements metrics are labeled with identifier of the query plan, where it is available.
+	PlanID bool `yaml:"plan_id"`
+}
+
+// Commands unions all commands in one place.
+type Commands []Command
+
+// Command defines a local command executed periodically, output of the command is parsed as metrics.
+type Command struct {
+	// Name defines unique name of the command.
+	Name string `yaml:"name"`
+	// Command defines path to executable.
+	Command string `yaml:"command"`
+	// Args defines arguments passed to the command.
+	Args []string `yaml:"args"`
+	// Env defines additional environment variables passed to the command.
+	Env map[string]string `yaml:"env"`
+	// Interval defines how often the command is executed, output is reused between executions.
+	Interval time.Duration `yaml:"interval"`
+	// Timeout defines max duration of the command execution.
+	Timeout time.Duration `yaml:"timeout"`
 }
 
 // Subsystems unions all subsystems in one place.
@@ -75,6 +111,21 @@ type MetricsSubsystem struct {
 	DatabasesRE *regexp.Regexp
 	// Query defines a SQL statement used for getting label/values for metrics.
 	Query string `yaml:"query"`
+	// Endpoint defines path of REST API endpoint used for getting label/values for metrics, Patroni only.
+	Endpoint string `yaml:"endpoint"`
+	// Path defines JSONPath expression selecting objects of endpoint response used as rows of label/values, Patroni
+	// and HTTP only. Nested fields of objects are flattened using underscore, e.g. tags.nofailover becomes
+	// tags_nofailover.
+	Path string `yaml:"path"`
+	// URL defines address requested for getting label/values for metrics, HTTP only.
+	URL string `yaml:"url"`
+	// Auth defines credentials and TLS settings used for requesting URL, HTTP only.
+	Auth http.ClientAuthConfig `yaml:"auth"`
+	// Timeout defines max duration of URL request, HTTP only.
+	Timeout time.Duration `yaml:"timeout"`
+	// Regex defines regular expression with named groups applied to response instead of Path, each match is used as
+	// a row of label/values keyed by names of groups, HTTP only.
+	Regex string `yaml:"regex"`
 	// Metrics defines a list of labels and metrics should be extracted from Query result.
 	Metrics Metrics `yaml:"metrics"`
 }
@@ -91,3 +142,17 @@ type UserMetric struct {
 	LabeledValues map[string][]string `yaml:"labeled_values,omitempty"`
 	Description   string              `yaml:"description"`
 }
+
+// RecordingRules unions all recording rules in one place.
+type RecordingRules []RecordingRule
+
+// RecordingRule defines a metric computed at scrape time using expression over metrics collected from the same
+// service, e.g. postgres_database_blocks_total{access="hit"} / sum by (database) (postgres_database_blocks_total).
+type RecordingRule struct {
+	// Name defines name of computed metric.
+	Name string `yaml:"name"`
+	// Expr defines expression used for computing metric.
+	Expr string `yaml:"expr"`
+	// Help defines description of computed metric, expression is used when description is not specified.
+	Help string `yaml:"help"`
+}
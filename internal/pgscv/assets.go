@@ -0,0 +1,253 @@
+package pgscv
+
+import (
+	"encoding/json"
+	"fmt"
+	net_http "net/http"
+	"slices"
+	"strings"
+
+	"github.com/cherts/pgscv/internal/collector"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/cherts/pgscv/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+// grafanaDashboard defines Grafana dashboard model, only fields required for importing dashboard are defined.
+type grafanaDashboard struct {
+	Title         string            `json:"title"`
+	UID           string            `json:"uid"`
+	Tags          []string          `json:"tags"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Refresh       string            `json:"refresh"`
+	Time          grafanaTimeRange  `json:"time"`
+	Templating    grafanaTemplating `json:"templating"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+type grafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name       string             `json:"name"`
+	Label      string             `json:"label"`
+	Type       string             `json:"type"`
+	Query      string             `json:"query"`
+	Datasource *grafanaDatasource `json:"datasource,omitempty"`
+	Refresh    int                `json:"refresh,omitempty"`
+	Multi      bool               `json:"multi,omitempty"`
+	IncludeAll bool               `json:"includeAll,omitempty"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Datasource  *grafanaDatasource `json:"datasource,omitempty"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	Targets     []grafanaTarget    `json:"targets,omitempty"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+// newGrafanaDashboard returns dashboard with row of panels for each passed collector, a panel per metric family.
+// Rates are shown for counters, values are shown for other metrics.
+func newGrafanaDashboard(infos []collector.CollectorInfo, renamer metricsRenamer) grafanaDashboard {
+	const panelHeight, panelWidth = 8, 12
+
+	datasource := &grafanaDatasource{Type: "prometheus", UID: "${datasource}"}
+	dashboard := grafanaDashboard{
+		Title:         "pgSCV",
+		UID:           "pgscv",
+		Tags:          []string{"pgscv"},
+		SchemaVersion: 39,
+		Refresh:       "1m",
+		Time:          grafanaTimeRange{From: "now-3h", To: "now"},
+		Templating: grafanaTemplating{List: []grafanaVariable{
+			{Name: "datasource", Label: "Datasource", Type: "datasource", Query: "prometheus"},
+			{
+				Name: "service_id", Label: "Service", Type: "query", Query: "label_values(service_id)",
+				Datasource: datasource, Refresh: 2, Multi: true, IncludeAll: true,
+			},
+		}},
+		Panels: []grafanaPanel{},
+	}
+
+	var id, y int
+	for _, info := range infos {
+		id++
+		dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+			ID: id, Type: "row", Title: info.Name, GridPos: grafanaGridPos{H: 1, W: 24, X: 0, Y: y},
+		})
+		y++
+
+		for i, m := range info.Metrics {
+			name := renamer.rename(m.Name)
+			expr := fmt.Sprintf(`%s{service_id=~"$service_id"}`, name)
+			if m.Type == prometheus.CounterValue {
+				expr = fmt.Sprintf("rate(%s[$__rate_interval])", expr)
+			}
+
+			id++
+			dashboard.Panels = append(dashboard.Panels, grafanaPanel{
+				ID:          id,
+				Type:        "timeseries",
+				Title:       name,
+				Description: m.Help,
+				Datasource:  datasource,
+				GridPos:     grafanaGridPos{H: panelHeight, W: panelWidth, X: (i % 2) * panelWidth, Y: y + (i/2)*panelHeight},
+				Targets:     []grafanaTarget{{Expr: expr, LegendFormat: "{{service_id}}", RefID: "A"}},
+			})
+		}
+		y += (len(info.Metrics) + 1) / 2 * panelHeight
+	}
+
+	return dashboard
+}
+
+// alertRuleTemplate defines alerting rule generated when its metric is produced by enabled collectors.
+type alertRuleTemplate struct {
+	metric   string
+	alert    string
+	expr     string // expression format, metric name is substituted
+	duration string
+	severity string
+	summary  string
+}
+
+// alertRuleTemplates defines alerting rules based on metrics of builtin collectors.
+var alertRuleTemplates = []alertRuleTemplate{
+	{metric: "postgres_up", alert: "PostgresDown", expr: "%s == 0", duration: "1m", severity: "critical",
+		summary: "Postgres service {{ $labels.service_id }} is down"},
+	{metric: "pgbouncer_up", alert: "PgbouncerDown", expr: "%s == 0", duration: "1m", severity: "critical",
+		summary: "Pgbouncer service {{ $labels.service_id }} is down"},
+	{metric: "patroni_up", alert: "PatroniDown", expr: "%s == 0", duration: "1m", severity: "critical",
+		summary: "Patroni service {{ $labels.service_id }} is down"},
+	{metric: "postgres_replication_lag_all_seconds", alert: "PostgresReplicationLag", expr: "%s > 300", duration: "5m", severity: "warning",
+		summary: "Replication lag of {{ $labels.client_addr }} on {{ $labels.service_id }} is more than 5 minutes"},
+	{metric: "postgres_xacts_left_before_wraparound", alert: "PostgresWraparoundRisk", expr: "%s < 200000000", duration: "15m", severity: "warning",
+		summary: "Database {{ $labels.database }} on {{ $labels.service_id }} is approaching transaction ID wraparound"},
+	{metric: "postgres_database_deadlocks_total", alert: "PostgresDeadlocks", expr: "increase(%s[5m]) > 0", duration: "0m", severity: "warning",
+		summary: "Deadlocks detected in database {{ $labels.database }} on {{ $labels.service_id }}"},
+	{metric: "postgres_database_checksum_failures_total", alert: "PostgresChecksumFailures", expr: "increase(%s[5m]) > 0", duration: "0m", severity: "critical",
+		summary: "Data checksum failures detected in database {{ $labels.database }} on {{ $labels.service_id }}"},
+	{metric: "pgbouncer_pool_max_wait_seconds", alert: "PgbouncerClientsWaiting", expr: "%s > 1", duration: "5m", severity: "warning",
+		summary: "Clients wait for server connections in pool {{ $labels.database }} on {{ $labels.service_id }}"},
+}
+
+// alertRules defines file with Prometheus alerting rules.
+type alertRules struct {
+	Groups []alertRuleGroup `yaml:"groups"`
+}
+
+type alertRuleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []alertRule `yaml:"rules"`
+}
+
+type alertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// newAlertRules returns alerting rules which metrics are produced by passed collectors.
+func newAlertRules(infos []collector.CollectorInfo, renamer metricsRenamer) alertRules {
+	var metrics []string
+	for _, info := range infos {
+		for _, m := range info.Metrics {
+			metrics = append(metrics, m.Name)
+		}
+	}
+
+	group := alertRuleGroup{Name: "pgscv", Rules: []alertRule{}}
+	for _, t := range alertRuleTemplates {
+		if !slices.Contains(metrics, t.metric) {
+			continue
+		}
+		group.Rules = append(group.Rules, alertRule{
+			Alert:       t.alert,
+			Expr:        fmt.Sprintf(t.expr, renamer.rename(t.metric)),
+			For:         t.duration,
+			Labels:      map[string]string{"severity": t.severity},
+			Annotations: map[string]string{"summary": t.summary},
+		})
+	}
+
+	return alertRules{Groups: []alertRuleGroup{group}}
+}
+
+// enabledCollectorsInfo returns catalog entries of passed collectors.
+func enabledCollectorsInfo(infos []collector.CollectorInfo, enabled []string) []collector.CollectorInfo {
+	var result []collector.CollectorInfo
+	for _, info := range infos {
+		if slices.Contains(enabled, info.Name) {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// getAssetsHandler return http handler function to /assets/ endpoints. Grafana dashboard and Prometheus alerting rules
+// matching collectors enabled for registered services are served at /assets/dashboards and /assets/alerts.
+func getAssetsHandler(repository *service.Repository, renamer metricsRenamer) func(w net_http.ResponseWriter, r *net_http.Request) {
+	return func(w net_http.ResponseWriter, r *net_http.Request) {
+		if r.Method != net_http.MethodGet {
+			net_http.Error(w, "Method not allowed", net_http.StatusMethodNotAllowed)
+			return
+		}
+
+		asset := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/assets/"), "/")
+		if asset != "dashboards" && asset != "alerts" {
+			net_http.NotFound(w, r)
+			return
+		}
+
+		infos := enabledCollectorsInfo(collector.ListCollectors(), repository.EnabledCollectors())
+
+		var data []byte
+		var err error
+		if asset == "dashboards" {
+			w.Header().Set("Content-Type", "application/json")
+			data, err = json.MarshalIndent(newGrafanaDashboard(infos, renamer), "", "  ")
+		} else {
+			w.Header().Set("Content-Type", "application/yaml")
+			data, err = yaml.Marshal(newAlertRules(infos, renamer))
+		}
+		if err != nil {
+			net_http.Error(w, err.Error(), net_http.StatusInternalServerError)
+			return
+		}
+
+		_, err = w.Write(data)
+		if err != nil {
+			log.Error(err.Error())
+		}
+	}
+}
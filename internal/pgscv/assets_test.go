@@ -0,0 +1,95 @@
+package pgscv
+
+import (
+	"encoding/json"
+	net_http "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cherts/pgscv/internal/collector"
+	"github.com/cherts/pgscv/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+var testCollectorsInfo = []collector.CollectorInfo{
+	{Name: "postgres/activity", Metrics: []collector.MetricInfo{
+		{Name: "postgres_up", Help: "State of Postgres service.", Type: prometheus.GaugeValue},
+	}},
+	{Name: "postgres/databases", Metrics: []collector.MetricInfo{
+		{Name: "postgres_database_deadlocks_total", Help: "Total number of deadlocks.", Type: prometheus.CounterValue},
+		{Name: "postgres_database_size_bytes", Help: "Total size of the database.", Type: prometheus.GaugeValue},
+		{Name: "postgres_xacts_left_before_wraparound", Help: "Transactions left before wraparound.", Type: prometheus.GaugeValue},
+	}},
+}
+
+func Test_newGrafanaDashboard(t *testing.T) {
+	d := newGrafanaDashboard(testCollectorsInfo, metricsRenamer{prefix: "x_", namespaces: map[string]string{"postgres": "pg"}})
+
+	// Two rows and four metric panels.
+	assert.Len(t, d.Panels, 6)
+	assert.Equal(t, "row", d.Panels[0].Type)
+	assert.Equal(t, "postgres/activity", d.Panels[0].Title)
+	assert.Equal(t, `x_pg_up{service_id=~"$service_id"}`, d.Panels[1].Targets[0].Expr)
+	assert.Equal(t, "row", d.Panels[2].Type)
+	assert.Equal(t, 9, d.Panels[2].GridPos.Y)
+	assert.Equal(t, `rate(x_pg_database_deadlocks_total{service_id=~"$service_id"}[$__rate_interval])`, d.Panels[3].Targets[0].Expr)
+	assert.Equal(t, "Total number of deadlocks.", d.Panels[3].Description)
+	assert.Equal(t, 12, d.Panels[4].GridPos.X)
+	assert.Equal(t, 0, d.Panels[5].GridPos.X)
+	assert.Equal(t, 18, d.Panels[5].GridPos.Y)
+}
+
+func Test_newAlertRules(t *testing.T) {
+	rules := newAlertRules(testCollectorsInfo, metricsRenamer{namespaces: map[string]string{"postgres": "pg"}})
+	assert.Len(t, rules.Groups, 1)
+
+	var got []string
+	for _, r := range rules.Groups[0].Rules {
+		got = append(got, r.Alert+": "+r.Expr)
+	}
+	assert.Equal(t, []string{
+		"PostgresDown: pg_up == 0",
+		"PostgresWraparoundRisk: pg_xacts_left_before_wraparound < 200000000",
+		"PostgresDeadlocks: increase(pg_database_deadlocks_total[5m]) > 0",
+	}, got)
+
+	// No collectors, no rules.
+	assert.Empty(t, newAlertRules(nil, metricsRenamer{}).Groups[0].Rules)
+}
+
+func Test_getAssetsHandler(t *testing.T) {
+	repo := service.NewRepository()
+	repo.Services["postgres:5432"] = service.Service{ServiceID: "postgres:5432", Collector: &silencedCollector{}}
+	handler := getAssetsHandler(repo, metricsRenamer{})
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(net_http.MethodGet, "/assets/dashboards", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var d grafanaDashboard
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&d))
+	assert.Equal(t, "postgres/activity", d.Panels[0].Title)
+	for _, p := range d.Panels {
+		assert.NotEqual(t, "postgres/databases", p.Title)
+	}
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(net_http.MethodGet, "/assets/alerts", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+
+	var rules alertRules
+	assert.NoError(t, yaml.Unmarshal(res.Body.Bytes(), &rules))
+	assert.Len(t, rules.Groups[0].Rules, 1)
+	assert.Equal(t, "PostgresDown", rules.Groups[0].Rules[0].Alert)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(net_http.MethodGet, "/assets/unknown", nil))
+	assert.Equal(t, net_http.StatusNotFound, res.Code)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(net_http.MethodPost, "/assets/alerts", nil))
+	assert.Equal(t, net_http.StatusMethodNotAllowed, res.Code)
+}
@@ -2,21 +2,28 @@
 package pgscv
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	sd "github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/internal/collector"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/service"
+	"github.com/cherts/pgscv/internal/store"
+	"github.com/cherts/pgscv/plugin"
 	"github.com/jackc/pgx/v4"
 	"gopkg.in/yaml.v2"
 )
@@ -28,6 +35,12 @@ const (
 	defaultPgbouncerUsername      = "pgscv"
 	defaultPgbouncerDbname        = "pgbouncer"
 	defaultThrottlingInterval int = 0 // seconds
+
+	// defaultChecksumsVerifyRate defines max rate of reading data files during checksums verification, bytes per second.
+	defaultChecksumsVerifyRate = 10 * 1024 * 1024
+
+	// minStatementsExplainInterval defines min interval of explaining plans of the top statements.
+	minStatementsExplainInterval = time.Minute
 )
 
 // Config defines application's configuration.
@@ -40,10 +53,13 @@ type Config struct {
 	CollectorsSettings    			model.CollectorsSettings `yaml:"collectors"`         // Collectors settings propagated from main YAML configuration
 	Databases             			string                   `yaml:"databases"`          // Regular expression string specifies databases from which metrics should be collected
 	DatabasesRE           			*regexp.Regexp           // Regular expression object compiled from Databases
+	ExcludeDatabases      			string                   `yaml:"exclude_databases"`  // Regular expression string specifies databases excluded from per-database metrics collection
+	ExcludeDatabasesRE    			*regexp.Regexp           // Regular expression object compiled from ExcludeDatabases
 	AuthConfig            			http.AuthConfig          `yaml:"authentication"`       // TLS and Basic auth configuration
 	CollectTopTable       			int                      `yaml:"collect_top_table"`    // Limit elements on Table collector
 	CollectTopIndex       			int                      `yaml:"collect_top_index"`    // Limit elements on Indexes collector
 	CollectTopQuery       			int                      `yaml:"collect_top_query"`    // Limit elements on Statements collector
+	CollectTopApplication 			int                      `yaml:"collect_top_application"` // Limit applications in activity breakdown
 	SkipConnErrorMode     			bool                     `yaml:"skip_conn_error_mode"` // Skipping connection errors and creating a Service instance.
 	DiscoveryConfig       			*any                     `yaml:"discovery"`
 	DiscoveryServices     			*map[string]sd.Discovery
@@ -52,6 +68,48 @@ type Config struct {
 	ThrottlingInterval    			*int   			`yaml:"throttling_interval"`
 	ConcurrencyLimit      			*int   			`yaml:"concurrency_limit"`
 	RefreshServiceConfigInterval	time.Duration 	`yaml:"refresh_service_config_interval"`
+	CompressionLevel      			int    			`yaml:"compression_level"` // Force compression level of /metrics responses
+	MaxSeriesPerCollector 			int    			`yaml:"max_series_per_collector"` // Limit series exposed by single collector
+	MaxPayloadBytes       			int    			`yaml:"max_payload_bytes"`        // Limit estimated size of series exposed by single service
+	MetricsAllowlist      			[]string		`yaml:"metrics_allowlist"`        // Metric families exposed by services, all other metrics are dropped
+	RecordingRules        			model.RecordingRules	`yaml:"recording_rules"`   // Metrics computed at scrape time using expressions over metrics of the service
+	ChecksumsVerifyInterval			time.Duration	`yaml:"checksums_verify_interval"` // Interval of data files checksums verification
+	ChecksumsVerifyRate   			int    			`yaml:"checksums_verify_rate"`     // Max rate of reading data files during checksums verification, bytes per second
+	EnableSilenceAPI      			bool   			`yaml:"enable_silence_api"`        // Enable /silence endpoint for muting services during maintenance
+	MaxConcurrentScrapes  			int    			`yaml:"max_concurrent_scrapes"`    // Limit services collected concurrently
+	CacheSnapshotFile     			string 			`yaml:"cache_snapshot_file"`       // File where stats cached by collectors are persisted across restarts
+	ClusterIdentity       			string 			`yaml:"cluster_identity"`          // Use stable cluster identity in service labels ('label' or 'replace')
+	ClusterNameLabel      			bool   			`yaml:"cluster_name_label"`        // Add 'cluster' label with cluster_name to metrics of Postgres services
+	ExtraLabels           			map[string]string	`yaml:"extra_labels"`          // Labels added to metrics of all services
+	BuffercacheTTL        			time.Duration	`yaml:"buffercache_ttl"`           // Interval during which pg_buffercache stats are reused
+	StatementsQueryTTL    			time.Duration	`yaml:"statements_query_ttl"`      // Interval during which query texts of statements are reused
+	StatementsQueryAll    			bool			`yaml:"statements_query_all"`      // Expose query texts for all statements, not only for statements in top-k
+	StatementsExplainInterval		time.Duration	`yaml:"statements_explain_interval"` // Interval of explaining plans of the top statements
+	WarmUpWindow          			time.Duration	`yaml:"warmup_window"`             // Window over which first execution of heavy collectors is staggered
+	TableBloatTables      			[]string		`yaml:"table_bloat_tables"`        // Tables sampled using pgstattuple_approx, in 'database.schema.table' format
+	TableBloatTop         			int				`yaml:"table_bloat_top"`           // Number of the largest tables of each database sampled using pgstattuple_approx
+	TableBloatTTL         			time.Duration	`yaml:"table_bloat_ttl"`           // Interval during which sampled bloat of tables is reused
+	IndexProbesTop        			int				`yaml:"index_probes_top"`          // Number of the largest GIN and BRIN indexes of each database probed
+	IndexProbesTTL        			time.Duration	`yaml:"index_probes_ttl"`          // Interval during which probed stats of indexes are reused
+	DDLTracking           			bool   			`yaml:"ddl_tracking"`              // Compare catalog snapshots between scrapes for tracking DDL activity
+	ProbeICMP             			bool   			`yaml:"probe_icmp"`                // Probe service endpoints using ICMP echo in addition to TCP connect
+	WatchdogMaxQueryAge   			time.Duration	`yaml:"watchdog_max_query_age"`    // Cancel pgSCV own queries running longer than this
+	SchemaLabelMaxLength  			int				`yaml:"schema_label_max_length"`   // Max length of label values of schema metrics
+	SchemaLabelHash       			bool			`yaml:"schema_label_hash"`         // Suffix truncated label values of schema metrics with hash
+	SchemaMaxSeries       			int				`yaml:"schema_max_series"`         // Max number of series of each schema metric
+	MetricPrefix          			string			`yaml:"metric_prefix"`             // Prefix added to names of all metrics
+	MetricNamespaces      			map[string]string	`yaml:"metric_namespaces"`      // Namespaces of metrics replaced with new ones, e.g. postgres: pg
+	DirWalkRate           			int				`yaml:"dir_walk_rate"`             // Max number of files visited per second when calculating directories sizes
+	DirWalkTimeout        			time.Duration	`yaml:"dir_walk_timeout"`          // Max duration of calculating directory size
+	DirWalkCacheTTL       			time.Duration	`yaml:"dir_walk_cache_ttl"`        // How long calculated directories sizes are reused
+	SessionSamplingInterval			time.Duration	`yaml:"session_sampling_interval"` // Interval of sampling active sessions for session history metrics
+	SessionHistoryMaxQueries		int				`yaml:"session_history_max_queries"` // Max number of queries exposed by session history metrics
+	ShardIndex            			int				`yaml:"shard_index"`               // Index of shard of services monitored by the instance
+	ShardCount            			int				`yaml:"shard_count"`               // Total number of shards services are split across instances
+	LeaderElectionConninfo			string			`yaml:"leader_election_conninfo"`  // Postgres holding advisory lock used for electing the leader among paired instances
+	LeaderElectionLockID  			int64			`yaml:"leader_election_lock_id"`   // Key of advisory lock used for electing the leader
+	Include               			includePatterns	`yaml:"include"`                   // Glob patterns of configuration fragments merged into configuration
+	Plugins               			[]string		`yaml:"plugins"`                   // Paths to Go plugins implementing additional service types
 }
 
 // NewConfig creates new config based on config file or return default config if config file is not specified.
@@ -73,6 +131,10 @@ func NewConfig(configFilePath string) (*Config, error) {
 		if err != nil {
 			return nil, err
 		}
+		err = includeConfigFragments(configFromFile, configRealPath)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Get configuration from environment variables
@@ -106,7 +168,7 @@ func NewConfig(configFilePath string) (*Config, error) {
 			}
 		}
 		// Set AuthConfig settings
-		if configFromEnv.AuthConfig != (http.AuthConfig{}) {
+		if !reflect.DeepEqual(configFromEnv.AuthConfig, http.AuthConfig{}) {
 			configFromFile.AuthConfig = configFromEnv.AuthConfig
 		}
 
@@ -119,6 +181,9 @@ func NewConfig(configFilePath string) (*Config, error) {
 		if configFromEnv.CollectTopQuery > 0 {
 			configFromFile.CollectTopQuery = configFromEnv.CollectTopQuery
 		}
+		if configFromEnv.CollectTopApplication > 0 {
+			configFromFile.CollectTopApplication = configFromEnv.CollectTopApplication
+		}
 		if configFromEnv.SkipConnErrorMode {
 			configFromFile.SkipConnErrorMode = configFromEnv.SkipConnErrorMode
 		}
@@ -137,12 +202,243 @@ func NewConfig(configFilePath string) (*Config, error) {
 		if configFromEnv.RefreshServiceConfigInterval > 0 {
 			configFromFile.RefreshServiceConfigInterval = configFromEnv.RefreshServiceConfigInterval
 		}
+		if configFromEnv.CompressionLevel > 0 {
+			configFromFile.CompressionLevel = configFromEnv.CompressionLevel
+		}
+		if configFromEnv.MaxSeriesPerCollector > 0 {
+			configFromFile.MaxSeriesPerCollector = configFromEnv.MaxSeriesPerCollector
+		}
+		if configFromEnv.MaxPayloadBytes > 0 {
+			configFromFile.MaxPayloadBytes = configFromEnv.MaxPayloadBytes
+		}
+		if configFromEnv.ChecksumsVerifyInterval > 0 {
+			configFromFile.ChecksumsVerifyInterval = configFromEnv.ChecksumsVerifyInterval
+		}
+		if configFromEnv.ChecksumsVerifyRate > 0 {
+			configFromFile.ChecksumsVerifyRate = configFromEnv.ChecksumsVerifyRate
+		}
+		if configFromEnv.EnableSilenceAPI {
+			configFromFile.EnableSilenceAPI = configFromEnv.EnableSilenceAPI
+		}
+		if configFromEnv.MaxConcurrentScrapes > 0 {
+			configFromFile.MaxConcurrentScrapes = configFromEnv.MaxConcurrentScrapes
+		}
+		if configFromEnv.CacheSnapshotFile != "" {
+			configFromFile.CacheSnapshotFile = configFromEnv.CacheSnapshotFile
+		}
+		if configFromEnv.ClusterIdentity != "" {
+			configFromFile.ClusterIdentity = configFromEnv.ClusterIdentity
+		}
+		if configFromEnv.ClusterNameLabel {
+			configFromFile.ClusterNameLabel = configFromEnv.ClusterNameLabel
+		}
+		if configFromEnv.ExcludeDatabases != "" {
+			configFromFile.ExcludeDatabases = configFromEnv.ExcludeDatabases
+		}
+		if len(configFromEnv.Plugins) > 0 {
+			configFromFile.Plugins = configFromEnv.Plugins
+		}
+		if configFromEnv.DirWalkRate > 0 {
+			configFromFile.DirWalkRate = configFromEnv.DirWalkRate
+		}
+		if configFromEnv.DirWalkTimeout > 0 {
+			configFromFile.DirWalkTimeout = configFromEnv.DirWalkTimeout
+		}
+		if configFromEnv.DirWalkCacheTTL > 0 {
+			configFromFile.DirWalkCacheTTL = configFromEnv.DirWalkCacheTTL
+		}
+		if configFromEnv.SessionSamplingInterval > 0 {
+			configFromFile.SessionSamplingInterval = configFromEnv.SessionSamplingInterval
+		}
+		if configFromEnv.SessionHistoryMaxQueries > 0 {
+			configFromFile.SessionHistoryMaxQueries = configFromEnv.SessionHistoryMaxQueries
+		}
+		if configFromEnv.ShardIndex > 0 {
+			configFromFile.ShardIndex = configFromEnv.ShardIndex
+		}
+		if configFromEnv.ShardCount > 0 {
+			configFromFile.ShardCount = configFromEnv.ShardCount
+		}
+		if configFromEnv.LeaderElectionConninfo != "" {
+			configFromFile.LeaderElectionConninfo = configFromEnv.LeaderElectionConninfo
+		}
+		if configFromEnv.LeaderElectionLockID != 0 {
+			configFromFile.LeaderElectionLockID = configFromEnv.LeaderElectionLockID
+		}
+		if configFromEnv.MetricPrefix != "" {
+			configFromFile.MetricPrefix = configFromEnv.MetricPrefix
+		}
+		if len(configFromEnv.MetricsAllowlist) > 0 {
+			configFromFile.MetricsAllowlist = configFromEnv.MetricsAllowlist
+		}
+		if len(configFromEnv.MetricNamespaces) > 0 {
+			configFromFile.MetricNamespaces = configFromEnv.MetricNamespaces
+		}
+		if configFromEnv.SchemaLabelMaxLength > 0 {
+			configFromFile.SchemaLabelMaxLength = configFromEnv.SchemaLabelMaxLength
+		}
+		if configFromEnv.SchemaLabelHash {
+			configFromFile.SchemaLabelHash = configFromEnv.SchemaLabelHash
+		}
+		if configFromEnv.SchemaMaxSeries > 0 {
+			configFromFile.SchemaMaxSeries = configFromEnv.SchemaMaxSeries
+		}
+		if configFromEnv.WatchdogMaxQueryAge > 0 {
+			configFromFile.WatchdogMaxQueryAge = configFromEnv.WatchdogMaxQueryAge
+		}
+		if configFromEnv.DDLTracking {
+			configFromFile.DDLTracking = configFromEnv.DDLTracking
+		}
+		if configFromEnv.ProbeICMP {
+			configFromFile.ProbeICMP = configFromEnv.ProbeICMP
+		}
+		if configFromEnv.BuffercacheTTL > 0 {
+			configFromFile.BuffercacheTTL = configFromEnv.BuffercacheTTL
+		}
+		if configFromEnv.StatementsQueryTTL > 0 {
+			configFromFile.StatementsQueryTTL = configFromEnv.StatementsQueryTTL
+		}
+		if configFromEnv.StatementsQueryAll {
+			configFromFile.StatementsQueryAll = configFromEnv.StatementsQueryAll
+		}
+		if configFromEnv.StatementsExplainInterval > 0 {
+			configFromFile.StatementsExplainInterval = configFromEnv.StatementsExplainInterval
+		}
+		if configFromEnv.WarmUpWindow > 0 {
+			configFromFile.WarmUpWindow = configFromEnv.WarmUpWindow
+		}
+		if len(configFromEnv.TableBloatTables) > 0 {
+			configFromFile.TableBloatTables = configFromEnv.TableBloatTables
+		}
+		if configFromEnv.TableBloatTop > 0 {
+			configFromFile.TableBloatTop = configFromEnv.TableBloatTop
+		}
+		if configFromEnv.TableBloatTTL > 0 {
+			configFromFile.TableBloatTTL = configFromEnv.TableBloatTTL
+		}
+		if configFromEnv.IndexProbesTop > 0 {
+			configFromFile.IndexProbesTop = configFromEnv.IndexProbesTop
+		}
+		if configFromEnv.IndexProbesTTL > 0 {
+			configFromFile.IndexProbesTTL = configFromEnv.IndexProbesTTL
+		}
+		if len(configFromEnv.ExtraLabels) > 0 {
+			if configFromFile.ExtraLabels == nil {
+				configFromFile.ExtraLabels = map[string]string{}
+			}
+			maps.Copy(configFromFile.ExtraLabels, configFromEnv.ExtraLabels)
+		}
 		return configFromFile, nil
 	}
 
 	return configFromEnv, nil
 }
 
+// includePatterns defines glob patterns of configuration fragments, single pattern or list of patterns is accepted.
+type includePatterns []string
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (p *includePatterns) UnmarshalYAML(unmarshal func(any) error) error {
+	var pattern string
+	if err := unmarshal(&pattern); err == nil {
+		*p = includePatterns{pattern}
+		return nil
+	}
+
+	var patterns []string
+	if err := unmarshal(&patterns); err != nil {
+		return err
+	}
+	*p = patterns
+	return nil
+}
+
+// configFragment defines settings which could be specified in configuration fragments.
+type configFragment struct {
+	ServicesConnsSettings service.ConnsSettings    `yaml:"services"`
+	Defaults              map[string]string        `yaml:"defaults"`
+	CollectorsSettings    model.CollectorsSettings `yaml:"collectors"`
+	ExtraLabels           map[string]string        `yaml:"extra_labels"`
+}
+
+// includeConfigFragments merges configuration fragments matched by include patterns into config. Fragments are merged in
+// order of patterns, files matched by the same pattern are merged in lexical order. Relative patterns are resolved
+// against directory of the main configuration file. Settings defined in more than one file are rejected.
+func includeConfigFragments(config *Config, configPath string) error {
+	origins := map[string]string{}
+
+	for _, pattern := range config.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(configPath), pattern)
+		}
+
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern '%s': %w", pattern, err)
+		}
+		if len(files) == 0 {
+			log.Debugf("no configuration fragments found by pattern %s", pattern)
+			continue
+		}
+		slices.Sort(files)
+
+		for _, file := range files {
+			log.Infoln("read configuration fragment from ", file)
+			content, err := os.ReadFile(filepath.Clean(file))
+			if err != nil {
+				return err
+			}
+
+			var fragment configFragment
+			err = yaml.UnmarshalStrict(content, &fragment)
+			if err != nil {
+				return fmt.Errorf("parse configuration fragment %s failed: %w", file, err)
+			}
+
+			err = mergeConfigFragmentSection(&config.ServicesConnsSettings, fragment.ServicesConnsSettings, "service", file, configPath, origins)
+			if err != nil {
+				return err
+			}
+			err = mergeConfigFragmentSection(&config.Defaults, fragment.Defaults, "default", file, configPath, origins)
+			if err != nil {
+				return err
+			}
+			err = mergeConfigFragmentSection(&config.CollectorsSettings, fragment.CollectorsSettings, "collector", file, configPath, origins)
+			if err != nil {
+				return err
+			}
+			err = mergeConfigFragmentSection(&config.ExtraLabels, fragment.ExtraLabels, "extra label", file, configPath, origins)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeConfigFragmentSection merges section of configuration fragment into the same section of configuration. Keys
+// which are already defined in the main configuration file or another fragment are rejected.
+func mergeConfigFragmentSection[M ~map[string]V, V any](dest *M, src M, section, file, configPath string, origins map[string]string) error {
+	for key, value := range src {
+		if _, ok := (*dest)[key]; ok {
+			origin, ok := origins[section+"/"+key]
+			if !ok {
+				origin = configPath
+			}
+			return fmt.Errorf("%s '%s' defined in %s is already defined in %s", section, key, file, origin)
+		}
+
+		if *dest == nil {
+			*dest = M{}
+		}
+		(*dest)[key] = value
+		origins[section+"/"+key] = file
+	}
+
+	return nil
+}
+
 // Merge CollectorsSettings
 func mergeCollectorsSettings(dest, src model.CollectorsSettings) model.CollectorsSettings {
 	if dest == nil {
@@ -206,6 +502,11 @@ func (c *Config) Validate() error {
 		log.Infoln("skipping connection errors is enabled.")
 	}
 
+	// Go plugins require cgo, release builds are made without cgo.
+	if len(c.Plugins) > 0 && !plugin.LoadSupported {
+		return fmt.Errorf("invalid plugins: loading Go plugins is not supported by this build of pgSCV (built without cgo)")
+	}
+
 	// setup defaults
 	if c.Defaults == nil {
 		c.Defaults = map[string]string{}
@@ -239,10 +540,33 @@ func (c *Config) Validate() error {
 					return fmt.Errorf("empty service_type for %s", k)
 				}
 
-				_, err := pgx.ParseConfig(s.Conninfo)
-				if err != nil {
+				// Connection strings of plugin service types have service-specific format, address is defined by base URL.
+				if _, ok := plugin.Lookup(s.ServiceType); ok {
+					if u, err := url.Parse(s.BaseURL); err != nil || u.Host == "" {
+						return fmt.Errorf("invalid baseurl for %s: base URL with host is required by %s services", k, s.ServiceType)
+					}
+				} else if _, err := pgx.ParseConfig(s.Conninfo); err != nil {
 					return fmt.Errorf("invalid conninfo for %s: %s", k, err)
 				}
+
+				if len(s.SessionSettings) > 0 && s.ServiceType != model.ServiceTypePostgresql {
+					return fmt.Errorf("invalid session_settings for %s: supported only by %s services", k, model.ServiceTypePostgresql)
+				}
+				if s.HTTPAuth != (http.ClientAuthConfig{}) && s.ServiceType != model.ServiceTypePatroni {
+					return fmt.Errorf("invalid username, password or TLS settings for %s: supported only by %s services", k, model.ServiceTypePatroni)
+				}
+				if err := s.HTTPAuth.Validate(); err != nil {
+					return fmt.Errorf("invalid settings for %s: %s", k, err)
+				}
+				reSetting := regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+				for name := range s.SessionSettings {
+					if !reSetting.MatchString(name) {
+						return fmt.Errorf("invalid session_settings for %s: invalid setting name '%s'", k, name)
+					}
+				}
+				if name := invalidMetricName(s.MetricsAllowlist); name != "" {
+					return fmt.Errorf("invalid metrics_allowlist for %s: invalid metric name '%s'", k, name)
+				}
 			}
 		}
 	}
@@ -255,6 +579,15 @@ func (c *Config) Validate() error {
 	c.DatabasesRE = re
 	log.Infoln("option 'databases' is deprecated and removed in next major release.")
 
+	// Create 'exclude_databases' regexp object, excluded databases are not visited by collectors.
+	if c.ExcludeDatabases != "" {
+		c.ExcludeDatabasesRE, err = regexp.Compile(c.ExcludeDatabases)
+		if err != nil {
+			return fmt.Errorf("invalid setting 'exclude_databases' or env PGSCV_EXCLUDE_DATABASES: %s", err)
+		}
+		log.Infof("option exclude_databases is enabled (skip databases matching '%s')", c.ExcludeDatabases)
+	}
+
 	// Validate collector settings.
 	err = validateCollectorSettings(c.CollectorsSettings)
 	if err != nil {
@@ -268,6 +601,9 @@ func (c *Config) Validate() error {
 	}
 	c.AuthConfig.EnableAuth = enableAuth
 	c.AuthConfig.EnableTLS = enableTLS
+	if c.AuthConfig.ClientCAfile != "" {
+		log.Infoln("TLS client authentication is enabled")
+	}
 
 	if c.CollectTopQuery < 0 || c.CollectTopQuery > 1000 {
 		return fmt.Errorf("invalid setting 'collect_top_query' or env PGSCV_COLLECT_TOP_QUERY (value '%d'), allowed 0 to 1000", c.CollectTopQuery)
@@ -278,6 +614,9 @@ func (c *Config) Validate() error {
 	if c.CollectTopIndex < 0 || c.CollectTopIndex > 1000 {
 		return fmt.Errorf("invalid setting 'collect_top_index' or env PGSCV_COLLECT_TOP_INDEX (value '%d'), allowed 0 to 1000", c.CollectTopIndex)
 	}
+	if c.CollectTopApplication < 0 || c.CollectTopApplication > 1000 {
+		return fmt.Errorf("invalid setting 'collect_top_application' or env PGSCV_COLLECT_TOP_APPLICATION (value '%d'), allowed 0 to 1000", c.CollectTopApplication)
+	}
 
 	if c.CollectTopQuery > 0 {
 		log.Infof("option collect_top_query is enabled (limited top-%d queries)", c.CollectTopQuery)
@@ -288,9 +627,227 @@ func (c *Config) Validate() error {
 	if c.CollectTopIndex > 0 {
 		log.Infof("option collect_top_table is enabled (limited top-%d indexes)", c.CollectTopIndex)
 	}
+	if c.CollectTopApplication > 0 {
+		log.Infof("option collect_top_application is enabled (limited top-%d applications)", c.CollectTopApplication)
+	}
 	if c.ConcurrencyLimit != nil {
 		log.Infof("option concurrency_limit is enabled (limited %d concurrency collectors)", *c.ConcurrencyLimit)
 	}
+	if c.CompressionLevel < 0 || c.CompressionLevel > http.MaxCompressionLevel {
+		return fmt.Errorf("invalid setting 'compression_level' or env PGSCV_COMPRESSION_LEVEL (value '%d'), allowed 0 to %d", c.CompressionLevel, http.MaxCompressionLevel)
+	}
+	if c.CompressionLevel > 0 {
+		log.Infof("option compression_level is enabled (set %d compression level)", c.CompressionLevel)
+	}
+	if c.MaxSeriesPerCollector < 0 {
+		return fmt.Errorf("invalid setting 'max_series_per_collector' or env PGSCV_MAX_SERIES_PER_COLLECTOR (value '%d'), allowed 0 and above", c.MaxSeriesPerCollector)
+	}
+	if c.MaxPayloadBytes < 0 {
+		return fmt.Errorf("invalid setting 'max_payload_bytes' or env PGSCV_MAX_PAYLOAD_BYTES (value '%d'), allowed 0 and above", c.MaxPayloadBytes)
+	}
+	if c.MaxSeriesPerCollector > 0 {
+		log.Infof("option max_series_per_collector is enabled (limited %d series per collector)", c.MaxSeriesPerCollector)
+	}
+	if c.MaxPayloadBytes > 0 {
+		log.Infof("option max_payload_bytes is enabled (limited %d bytes per service)", c.MaxPayloadBytes)
+	}
+	if c.ChecksumsVerifyInterval < 0 {
+		return fmt.Errorf("invalid setting 'checksums_verify_interval' or env PGSCV_CHECKSUMS_VERIFY_INTERVAL (value '%s'), allowed positive durations", c.ChecksumsVerifyInterval)
+	}
+	if c.ChecksumsVerifyRate < 0 {
+		return fmt.Errorf("invalid setting 'checksums_verify_rate' or env PGSCV_CHECKSUMS_VERIFY_RATE (value '%d'), allowed positive numbers", c.ChecksumsVerifyRate)
+	}
+	if c.ChecksumsVerifyInterval > 0 {
+		if c.ChecksumsVerifyRate == 0 {
+			c.ChecksumsVerifyRate = defaultChecksumsVerifyRate
+		}
+		log.Infof("option checksums_verify_interval is enabled (verify data files checksums every %s, read %d bytes per second)", c.ChecksumsVerifyInterval, c.ChecksumsVerifyRate)
+	}
+	if c.BuffercacheTTL < 0 {
+		return fmt.Errorf("invalid setting 'buffercache_ttl' or env PGSCV_BUFFERCACHE_TTL (value '%s'), allowed positive durations", c.BuffercacheTTL)
+	}
+	if c.BuffercacheTTL > 0 {
+		log.Infof("option buffercache_ttl is enabled (reuse pg_buffercache stats during %s)", c.BuffercacheTTL)
+	}
+	if c.StatementsQueryTTL < 0 {
+		return fmt.Errorf("invalid setting 'statements_query_ttl' or env PGSCV_STATEMENTS_QUERY_TTL (value '%s'), allowed positive durations", c.StatementsQueryTTL)
+	}
+	if c.StatementsQueryTTL > 0 {
+		log.Infof("option statements_query_ttl is enabled (reuse query texts of statements during %s)", c.StatementsQueryTTL)
+	}
+	if c.StatementsQueryAll {
+		log.Infoln("option statements_query_all is enabled (expose query texts for all statements)")
+	}
+	if c.StatementsExplainInterval != 0 && c.StatementsExplainInterval < minStatementsExplainInterval {
+		return fmt.Errorf("invalid setting 'statements_explain_interval' or env PGSCV_STATEMENTS_EXPLAIN_INTERVAL (value '%s'), allowed 0 or durations not less than %s", c.StatementsExplainInterval, minStatementsExplainInterval)
+	}
+	if c.StatementsExplainInterval > 0 {
+		log.Infof("option statements_explain_interval is enabled (explain plans of the top statements every %s)", c.StatementsExplainInterval)
+	}
+	if c.WarmUpWindow < 0 {
+		return fmt.Errorf("invalid setting 'warmup_window' or env PGSCV_WARMUP_WINDOW (value '%s'), allowed positive durations", c.WarmUpWindow)
+	}
+	if c.WarmUpWindow > 0 {
+		log.Infof("option warmup_window is enabled (stagger first execution of heavy collectors over %s)", c.WarmUpWindow)
+	}
+	for _, t := range c.TableBloatTables {
+		if parts := strings.Split(t, "."); len(parts) != 3 || slices.Contains(parts, "") {
+			return fmt.Errorf("invalid setting 'table_bloat_tables' or env PGSCV_TABLE_BLOAT_TABLES (value '%s'), allowed tables in 'database.schema.table' format", t)
+		}
+	}
+	if c.TableBloatTop < 0 {
+		return fmt.Errorf("invalid setting 'table_bloat_top' or env PGSCV_TABLE_BLOAT_TOP (value '%d'), allowed 0 and above", c.TableBloatTop)
+	}
+	if c.TableBloatTTL < 0 {
+		return fmt.Errorf("invalid setting 'table_bloat_ttl' or env PGSCV_TABLE_BLOAT_TTL (value '%s'), allowed positive durations", c.TableBloatTTL)
+	}
+	if len(c.TableBloatTables) > 0 || c.TableBloatTop > 0 {
+		log.Infof("option table_bloat is enabled (sample bloat of %d selected tables and %d largest tables of each database)", len(c.TableBloatTables), c.TableBloatTop)
+	}
+	if c.IndexProbesTop < 0 {
+		return fmt.Errorf("invalid setting 'index_probes_top' or env PGSCV_INDEX_PROBES_TOP (value '%d'), allowed 0 and above", c.IndexProbesTop)
+	}
+	if c.IndexProbesTTL < 0 {
+		return fmt.Errorf("invalid setting 'index_probes_ttl' or env PGSCV_INDEX_PROBES_TTL (value '%s'), allowed positive durations", c.IndexProbesTTL)
+	}
+	if c.IndexProbesTop > 0 {
+		log.Infof("option index_probes is enabled (probe %d largest GIN and BRIN indexes of each database)", c.IndexProbesTop)
+	}
+	if c.DirWalkRate < 0 {
+		return fmt.Errorf("invalid setting 'dir_walk_rate' or env PGSCV_DIR_WALK_RATE (value '%d'), allowed positive numbers", c.DirWalkRate)
+	}
+	if c.DirWalkTimeout < 0 {
+		return fmt.Errorf("invalid setting 'dir_walk_timeout' or env PGSCV_DIR_WALK_TIMEOUT (value '%s'), allowed positive durations", c.DirWalkTimeout)
+	}
+	if c.DirWalkCacheTTL < 0 {
+		return fmt.Errorf("invalid setting 'dir_walk_cache_ttl' or env PGSCV_DIR_WALK_CACHE_TTL (value '%s'), allowed positive durations", c.DirWalkCacheTTL)
+	}
+	if c.DirWalkRate > 0 || c.DirWalkTimeout > 0 || c.DirWalkCacheTTL > 0 {
+		log.Infof("options dir_walk_* are enabled (rate %d files/s, timeout %s, cache TTL %s)", c.DirWalkRate, c.DirWalkTimeout, c.DirWalkCacheTTL)
+	}
+	if c.SessionSamplingInterval < 0 || (c.SessionSamplingInterval > 0 && c.SessionSamplingInterval < 100*time.Millisecond) {
+		return fmt.Errorf("invalid setting 'session_sampling_interval' or env PGSCV_SESSION_SAMPLING_INTERVAL (value '%s'), allowed 100ms and above", c.SessionSamplingInterval)
+	}
+	if c.SessionHistoryMaxQueries < 0 {
+		return fmt.Errorf("invalid setting 'session_history_max_queries' or env PGSCV_SESSION_HISTORY_MAX_QUERIES (value '%d'), allowed positive numbers", c.SessionHistoryMaxQueries)
+	}
+	if c.SessionSamplingInterval > 0 {
+		if c.SessionHistoryMaxQueries == 0 {
+			c.SessionHistoryMaxQueries = collector.DefaultSessionHistoryMaxQueries
+		}
+		log.Infof("option session_sampling_interval is enabled (sample active sessions every %s, expose top %d queries)", c.SessionSamplingInterval, c.SessionHistoryMaxQueries)
+	}
+	if c.MetricPrefix != "" && !regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`).MatchString(c.MetricPrefix) {
+		return fmt.Errorf("invalid setting 'metric_prefix' or env PGSCV_METRIC_PREFIX (value '%s'), allowed letters, digits, underscores and colons", c.MetricPrefix)
+	}
+	for from, to := range c.MetricNamespaces {
+		switch from {
+		case model.ServiceTypePostgresql, model.ServiceTypePgbouncer, model.ServiceTypePatroni:
+		default:
+			if _, ok := plugin.Lookup(from); ok {
+				break
+			}
+			return fmt.Errorf("invalid setting 'metric_namespaces' or env PGSCV_METRIC_NAMESPACES (namespace '%s'), allowed '%s', '%s' or '%s'", from, model.ServiceTypePostgresql, model.ServiceTypePgbouncer, model.ServiceTypePatroni)
+		}
+		if !regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`).MatchString(to) {
+			return fmt.Errorf("invalid setting 'metric_namespaces' or env PGSCV_METRIC_NAMESPACES (value '%s'), allowed letters, digits, underscores and colons", to)
+		}
+	}
+	if c.MetricPrefix != "" || len(c.MetricNamespaces) > 0 {
+		log.Infoln("option metric_prefix or metric_namespaces is enabled, metrics will be renamed")
+	}
+	if name := invalidMetricName(c.MetricsAllowlist); name != "" {
+		return fmt.Errorf("invalid setting 'metrics_allowlist' or env PGSCV_METRICS_ALLOWLIST (value '%s'), allowed letters, digits, underscores and colons", name)
+	}
+	if len(c.MetricsAllowlist) > 0 {
+		log.Infof("option metrics_allowlist is enabled (only %d metric families are exposed)", len(c.MetricsAllowlist))
+	}
+	for _, rule := range c.RecordingRules {
+		if err := collector.ValidateRecordingRule(rule); err != nil {
+			return fmt.Errorf("invalid setting 'recording_rules': %w", err)
+		}
+	}
+	if c.SchemaLabelMaxLength < 0 {
+		return fmt.Errorf("invalid setting 'schema_label_max_length' or env PGSCV_SCHEMA_LABEL_MAX_LENGTH (value '%d'), allowed positive numbers", c.SchemaLabelMaxLength)
+	}
+	if c.SchemaLabelHash && c.SchemaLabelMaxLength < 16 {
+		return fmt.Errorf("invalid setting 'schema_label_hash' or env PGSCV_SCHEMA_LABEL_HASH, requires 'schema_label_max_length' 16 or more")
+	}
+	if c.SchemaMaxSeries < 0 {
+		return fmt.Errorf("invalid setting 'schema_max_series' or env PGSCV_SCHEMA_MAX_SERIES (value '%d'), allowed positive numbers", c.SchemaMaxSeries)
+	}
+	if c.SchemaLabelMaxLength > 0 {
+		log.Infof("option schema_label_max_length is enabled (label values of schema metrics limited to %d characters)", c.SchemaLabelMaxLength)
+	}
+	if c.SchemaMaxSeries > 0 {
+		log.Infof("option schema_max_series is enabled (limited %d series of each schema metric)", c.SchemaMaxSeries)
+	}
+	if c.WatchdogMaxQueryAge < 0 {
+		return fmt.Errorf("invalid setting 'watchdog_max_query_age' or env PGSCV_WATCHDOG_MAX_QUERY_AGE (value '%s'), allowed positive durations", c.WatchdogMaxQueryAge)
+	}
+	if c.WatchdogMaxQueryAge > 0 {
+		log.Infof("option watchdog_max_query_age is enabled (cancel pgSCV queries running longer than %s)", c.WatchdogMaxQueryAge)
+	}
+	if c.DDLTracking {
+		log.Infoln("option ddl_tracking is enabled (catalog snapshots are compared between scrapes)")
+	}
+	if c.ProbeICMP {
+		log.Infoln("option probe_icmp is enabled (service endpoints are probed using ICMP echo)")
+	}
+	if c.EnableSilenceAPI {
+		log.Infoln("option enable_silence_api is enabled (services could be silenced via /silence endpoint)")
+	}
+	if c.MaxConcurrentScrapes < 0 {
+		return fmt.Errorf("invalid setting 'max_concurrent_scrapes' or env PGSCV_MAX_CONCURRENT_SCRAPES (value '%d'), allowed 0 and above", c.MaxConcurrentScrapes)
+	}
+	if c.MaxConcurrentScrapes > 0 {
+		log.Infof("option max_concurrent_scrapes is enabled (limited %d services collected concurrently)", c.MaxConcurrentScrapes)
+	}
+	if c.ShardCount < 0 {
+		return fmt.Errorf("invalid setting 'shard_count' or env PGSCV_SHARD_COUNT (value '%d'), allowed 0 and above", c.ShardCount)
+	}
+	if c.ShardIndex < 0 || (c.ShardCount > 0 && c.ShardIndex >= c.ShardCount) || (c.ShardCount == 0 && c.ShardIndex > 0) {
+		return fmt.Errorf("invalid setting 'shard_index' or env PGSCV_SHARD_INDEX (value '%d'), allowed 0 to shard_count-1", c.ShardIndex)
+	}
+	if c.ShardCount > 1 {
+		log.Infof("option shard_count is enabled (monitored shard %d of %d)", c.ShardIndex, c.ShardCount)
+	}
+	if c.LeaderElectionConninfo != "" {
+		if _, err := pgx.ParseConfig(c.LeaderElectionConninfo); err != nil {
+			return fmt.Errorf("invalid setting 'leader_election_conninfo' or env PGSCV_LEADER_ELECTION_CONNINFO: %w", err)
+		}
+		if c.LeaderElectionLockID == 0 {
+			c.LeaderElectionLockID = collector.DefaultLeaderLockID
+		}
+		log.Infof("option leader_election_conninfo is enabled (heavy collectors are executed by the leader holding advisory lock %d)", c.LeaderElectionLockID)
+	} else if c.LeaderElectionLockID != 0 {
+		return fmt.Errorf("invalid setting 'leader_election_lock_id' or env PGSCV_LEADER_ELECTION_LOCK_ID (value '%d'), requires 'leader_election_conninfo'", c.LeaderElectionLockID)
+	}
+	switch c.ClusterIdentity {
+	case "":
+	case collector.ClusterIdentityLabel, collector.ClusterIdentityReplace:
+		log.Infof("option cluster_identity is enabled (%s service labels with Patroni scope or cluster_name)", c.ClusterIdentity)
+	default:
+		return fmt.Errorf("invalid setting 'cluster_identity' or env PGSCV_CLUSTER_IDENTITY (value '%s'), allowed '%s' or '%s'", c.ClusterIdentity, collector.ClusterIdentityLabel, collector.ClusterIdentityReplace)
+	}
+	if c.ClusterNameLabel {
+		if c.ClusterIdentity != "" {
+			return fmt.Errorf("invalid setting 'cluster_name_label' or env PGSCV_CLUSTER_NAME_LABEL, 'cluster' label is already managed by 'cluster_identity'")
+		}
+		log.Infoln("option cluster_name_label is enabled (add 'cluster' label with cluster_name to metrics of Postgres services)")
+	}
+	reLabel := regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	for name := range c.ExtraLabels {
+		if !reLabel.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid setting 'extra_labels' or env PGSCV_EXTRA_LABELS (label name '%s'), allowed only letters, digits and underscores, without '__' prefix", name)
+		}
+		if collector.IsVariableLabel(name) {
+			return fmt.Errorf("invalid setting 'extra_labels' or env PGSCV_EXTRA_LABELS (label name '%s'), label is used by metrics of collectors", name)
+		}
+	}
+	if len(c.ExtraLabels) > 0 {
+		log.Infof("option extra_labels is enabled (%d labels added to all services)", len(c.ExtraLabels))
+	}
 	if c.ConnTimeout > 0 {
 		log.Infof("option conn_timeout is enabled (set %d seconds timeout)", c.ConnTimeout)
 	}
@@ -308,6 +865,59 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// CheckConnections connects to services defined in configuration and returns errors of failed connections. Services
+// found by discovery are not checked.
+func (c *Config) CheckConnections() error {
+	var errs []error
+
+	for _, id := range slices.Sorted(maps.Keys(c.ServicesConnsSettings)) {
+		err := checkServiceConnection(c.ServicesConnsSettings[id], c.ConnTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service '%s': %w", id, err))
+			continue
+		}
+		log.Infof("service '%s': connection succeeded", id)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkServiceConnection connects to the service using passed connection settings.
+func checkServiceConnection(cs service.ConnSetting, connTimeout int) error {
+	switch cs.ServiceType {
+	case model.ServiceTypePostgresql, model.ServiceTypePgbouncer:
+		db, err := store.New(cs.Conninfo, connTimeout)
+		if err != nil {
+			return err
+		}
+		db.Close()
+	case model.ServiceTypePatroni:
+		timeout := time.Second
+		if connTimeout > 0 {
+			timeout = time.Duration(connTimeout) * time.Second
+		}
+
+		client, err := http.NewClientWithAuth(http.ClientConfig{Timeout: timeout}, cs.BaseURL, cs.HTTPAuth)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Get(cs.BaseURL + "/liveness")
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			return fmt.Errorf("liveness check failed, response status '%s'", resp.Status)
+		}
+	default:
+		return service.CheckPluginService(cs, connTimeout)
+	}
+
+	return nil
+}
+
 // validateCollectorSettings validates collectors settings passed from main YAML configuration.
 func validateCollectorSettings(cs model.CollectorsSettings) error {
 	if len(cs) == 0 {
@@ -325,6 +935,14 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 			return err
 		}
 
+		// Validate overriding query.
+		if settings.Query != "" {
+			err := collector.ValidateQueryOverride(csName, settings.Query)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Validate subsystems level
 		for ssName, subsys := range settings.Subsystems {
 			re2 := regexp.MustCompilePOSIX(`^[a-zA-Z0-9_]+$`)
@@ -339,9 +957,61 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 				return fmt.Errorf("databases invalid regular expression specified: %s", err)
 			}
 
-			// Query must be specified if any metrics.
-			if len(subsys.Metrics) > 0 && subsys.Query == "" {
-				return fmt.Errorf("query is not specified for subsystem '%s' metrics", ssName)
+			switch csName {
+			case "patroni/custom":
+				// Patroni metrics are requested from REST API endpoint instead of query.
+				if subsys.Query != "" || subsys.Databases != "" || subsys.URL != "" || subsys.Regex != "" {
+					return fmt.Errorf("query, databases, url and regex are not supported for subsystem '%s' of %s", ssName, csName)
+				}
+				if len(subsys.Metrics) > 0 && subsys.Endpoint == "" {
+					return fmt.Errorf("endpoint is not specified for subsystem '%s' metrics", ssName)
+				}
+				if subsys.Endpoint != "" && !strings.HasPrefix(subsys.Endpoint, "/") {
+					return fmt.Errorf("endpoint of subsystem '%s' must start with '/'", ssName)
+				}
+				if err := collector.ValidateJSONPath(subsys.Path); err != nil {
+					return err
+				}
+			case "http/custom":
+				// Metrics are extracted from response of URL using either JSONPath or regular expression.
+				if subsys.Query != "" || subsys.Databases != "" || subsys.Endpoint != "" {
+					return fmt.Errorf("query, databases and endpoint are not supported for subsystem '%s' of %s", ssName, csName)
+				}
+				if u, err := url.Parse(subsys.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid url specified for subsystem '%s', allowed http:// or https:// URL", ssName)
+				}
+				if subsys.Path != "" && subsys.Regex != "" {
+					return fmt.Errorf("path and regex cannot be used together for subsystem '%s'", ssName)
+				}
+				if err := collector.ValidateJSONPath(subsys.Path); err != nil {
+					return err
+				}
+				if _, err := regexp.Compile(subsys.Regex); err != nil {
+					return fmt.Errorf("regex invalid regular expression specified: %s", err)
+				}
+				if subsys.Timeout < 0 {
+					return fmt.Errorf("invalid timeout for subsystem '%s', allowed positive duration", ssName)
+				}
+				if err := subsys.Auth.Validate(); err != nil {
+					return fmt.Errorf("invalid auth for subsystem '%s': %s", ssName, err)
+				}
+			default:
+				if subsys.Endpoint != "" || subsys.Path != "" {
+					return fmt.Errorf("endpoint and path are supported by patroni/custom and http/custom only, subsystem '%s'", ssName)
+				}
+				if subsys.URL != "" || subsys.Regex != "" {
+					return fmt.Errorf("url and regex are supported by http/custom only, subsystem '%s'", ssName)
+				}
+
+				// Pgbouncer has the only database - admin console.
+				if csName == "pgbouncer/custom" && subsys.Databases != "" {
+					return fmt.Errorf("databases are not supported for subsystem '%s' of %s", ssName, csName)
+				}
+
+				// Query must be specified if any metrics.
+				if len(subsys.Metrics) > 0 && subsys.Query == "" {
+					return fmt.Errorf("query is not specified for subsystem '%s' metrics", ssName)
+				}
 			}
 
 			// Validate metrics level
@@ -370,6 +1040,27 @@ func validateCollectorSettings(cs model.CollectorsSettings) error {
 				}
 			}
 		}
+
+		// Validate commands level
+		reCommand := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+		commands := map[string]bool{}
+
+		for _, cmd := range settings.Commands {
+			if !reCommand.MatchString(cmd.Name) {
+				return fmt.Errorf("invalid command name '%s'", cmd.Name)
+			}
+			if commands[cmd.Name] {
+				return fmt.Errorf("duplicate command name '%s'", cmd.Name)
+			}
+			commands[cmd.Name] = true
+
+			if cmd.Command == "" {
+				return fmt.Errorf("command is not specified for '%s'", cmd.Name)
+			}
+			if cmd.Interval < 0 || cmd.Timeout < 0 {
+				return fmt.Errorf("invalid interval or timeout for command '%s', allowed positive durations", cmd.Name)
+			}
+		}
 	}
 
 	return nil
@@ -444,6 +1135,10 @@ func newConfigFromEnv() (*Config, error) {
 			config.AuthConfig.Keyfile = value
 		case "PGSCV_AUTH_CERTFILE":
 			config.AuthConfig.Certfile = value
+		case "PGSCV_AUTH_CLIENT_CAFILE":
+			config.AuthConfig.ClientCAfile = value
+		case "PGSCV_AUTH_ALLOWED_CLIENTS":
+			config.AuthConfig.AllowedClients = strings.Split(value, ",")
 		case "PGSCV_COLLECT_TOP_QUERY":
 			collectTopQuery, err := strconv.Atoi(value)
 			if err != nil {
@@ -462,6 +1157,12 @@ func newConfigFromEnv() (*Config, error) {
 				return nil, fmt.Errorf("invalid setting PGSCV_COLLECT_TOP_INDEX, value '%s', allowed only digits", value)
 			}
 			config.CollectTopIndex = collectTopIndex
+		case "PGSCV_COLLECT_TOP_APPLICATION":
+			collectTopApplication, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_COLLECT_TOP_APPLICATION, value '%s', allowed only digits", value)
+			}
+			config.CollectTopApplication = collectTopApplication
 		case "PGSCV_SKIP_CONN_ERROR_MODE":
 			config.SkipConnErrorMode = toBool(value)
 		case "PGSCV_CONN_TIMEOUT":
@@ -490,11 +1191,214 @@ func newConfigFromEnv() (*Config, error) {
 				return nil, fmt.Errorf("invalid setting PGSCV_REFRESH_SERVICE_CONFIG_INTERVAL, value '%s', error: %w", value, err)
 			}
 			config.RefreshServiceConfigInterval = duration
+		case "PGSCV_COMPRESSION_LEVEL":
+			compressionLevel, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_COMPRESSION_LEVEL, value '%s', allowed only digits", value)
+			}
+			config.CompressionLevel = compressionLevel
+		case "PGSCV_MAX_SERIES_PER_COLLECTOR":
+			maxSeries, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_MAX_SERIES_PER_COLLECTOR, value '%s', allowed only digits", value)
+			}
+			config.MaxSeriesPerCollector = maxSeries
+		case "PGSCV_MAX_PAYLOAD_BYTES":
+			maxPayload, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_MAX_PAYLOAD_BYTES, value '%s', allowed only digits", value)
+			}
+			config.MaxPayloadBytes = maxPayload
+		case "PGSCV_CHECKSUMS_VERIFY_INTERVAL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_CHECKSUMS_VERIFY_INTERVAL, value '%s', error: %w", value, err)
+			}
+			config.ChecksumsVerifyInterval = duration
+		case "PGSCV_CHECKSUMS_VERIFY_RATE":
+			verifyRate, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_CHECKSUMS_VERIFY_RATE, value '%s', allowed only digits", value)
+			}
+			config.ChecksumsVerifyRate = verifyRate
+		case "PGSCV_BUFFERCACHE_TTL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_BUFFERCACHE_TTL, value '%s', error: %w", value, err)
+			}
+			config.BuffercacheTTL = duration
+		case "PGSCV_STATEMENTS_QUERY_TTL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_STATEMENTS_QUERY_TTL, value '%s', error: %w", value, err)
+			}
+			config.StatementsQueryTTL = duration
+		case "PGSCV_STATEMENTS_QUERY_ALL":
+			config.StatementsQueryAll = toBool(value)
+		case "PGSCV_STATEMENTS_EXPLAIN_INTERVAL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_STATEMENTS_EXPLAIN_INTERVAL, value '%s', error: %w", value, err)
+			}
+			config.StatementsExplainInterval = duration
+		case "PGSCV_WARMUP_WINDOW":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_WARMUP_WINDOW, value '%s', error: %w", value, err)
+			}
+			config.WarmUpWindow = duration
+		case "PGSCV_TABLE_BLOAT_TABLES":
+			config.TableBloatTables = strings.Split(strings.ReplaceAll(value, " ", ""), ",")
+		case "PGSCV_TABLE_BLOAT_TOP":
+			top, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_TABLE_BLOAT_TOP, value '%s', allowed only digits", value)
+			}
+			config.TableBloatTop = top
+		case "PGSCV_TABLE_BLOAT_TTL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_TABLE_BLOAT_TTL, value '%s', error: %w", value, err)
+			}
+			config.TableBloatTTL = duration
+		case "PGSCV_INDEX_PROBES_TOP":
+			top, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_INDEX_PROBES_TOP, value '%s', allowed only digits", value)
+			}
+			config.IndexProbesTop = top
+		case "PGSCV_INDEX_PROBES_TTL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_INDEX_PROBES_TTL, value '%s', error: %w", value, err)
+			}
+			config.IndexProbesTTL = duration
+		case "PGSCV_WATCHDOG_MAX_QUERY_AGE":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_WATCHDOG_MAX_QUERY_AGE, value '%s', error: %w", value, err)
+			}
+			config.WatchdogMaxQueryAge = duration
+		case "PGSCV_DDL_TRACKING":
+			config.DDLTracking = toBool(value)
+		case "PGSCV_PROBE_ICMP":
+			config.ProbeICMP = toBool(value)
+		case "PGSCV_SCHEMA_LABEL_MAX_LENGTH":
+			maxLength, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_SCHEMA_LABEL_MAX_LENGTH, value '%s', allowed only digits", value)
+			}
+			config.SchemaLabelMaxLength = maxLength
+		case "PGSCV_DIR_WALK_RATE":
+			walkRate, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_DIR_WALK_RATE, value '%s', allowed only digits", value)
+			}
+			config.DirWalkRate = walkRate
+		case "PGSCV_DIR_WALK_TIMEOUT":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_DIR_WALK_TIMEOUT, value '%s', error: %w", value, err)
+			}
+			config.DirWalkTimeout = duration
+		case "PGSCV_DIR_WALK_CACHE_TTL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_DIR_WALK_CACHE_TTL, value '%s', error: %w", value, err)
+			}
+			config.DirWalkCacheTTL = duration
+		case "PGSCV_SESSION_SAMPLING_INTERVAL":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_SESSION_SAMPLING_INTERVAL, value '%s', error: %w", value, err)
+			}
+			config.SessionSamplingInterval = duration
+		case "PGSCV_SESSION_HISTORY_MAX_QUERIES":
+			maxQueries, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_SESSION_HISTORY_MAX_QUERIES, value '%s', allowed only digits", value)
+			}
+			config.SessionHistoryMaxQueries = maxQueries
+		case "PGSCV_METRIC_PREFIX":
+			config.MetricPrefix = value
+		case "PGSCV_METRICS_ALLOWLIST":
+			config.MetricsAllowlist = strings.Split(strings.ReplaceAll(value, " ", ""), ",")
+		case "PGSCV_METRIC_NAMESPACES":
+			namespaces, err := parseExtraLabels(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_METRIC_NAMESPACES, value '%s', error: %w", value, err)
+			}
+			config.MetricNamespaces = namespaces
+		case "PGSCV_SCHEMA_LABEL_HASH":
+			config.SchemaLabelHash = toBool(value)
+		case "PGSCV_SCHEMA_MAX_SERIES":
+			maxSeries, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_SCHEMA_MAX_SERIES, value '%s', allowed only digits", value)
+			}
+			config.SchemaMaxSeries = maxSeries
+		case "PGSCV_ENABLE_SILENCE_API":
+			config.EnableSilenceAPI = toBool(value)
+		case "PGSCV_MAX_CONCURRENT_SCRAPES":
+			maxScrapes, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_MAX_CONCURRENT_SCRAPES, value '%s', allowed only digits", value)
+			}
+			config.MaxConcurrentScrapes = maxScrapes
+		case "PGSCV_CACHE_SNAPSHOT_FILE":
+			config.CacheSnapshotFile = value
+		case "PGSCV_SHARD_INDEX":
+			shardIndex, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_SHARD_INDEX, value '%s', allowed only digits", value)
+			}
+			config.ShardIndex = shardIndex
+		case "PGSCV_SHARD_COUNT":
+			shardCount, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_SHARD_COUNT, value '%s', allowed only digits", value)
+			}
+			config.ShardCount = shardCount
+		case "PGSCV_LEADER_ELECTION_CONNINFO":
+			config.LeaderElectionConninfo = value
+		case "PGSCV_LEADER_ELECTION_LOCK_ID":
+			lockID, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_LEADER_ELECTION_LOCK_ID, value '%s', allowed only digits", value)
+			}
+			config.LeaderElectionLockID = lockID
+		case "PGSCV_CLUSTER_IDENTITY":
+			config.ClusterIdentity = value
+		case "PGSCV_CLUSTER_NAME_LABEL":
+			config.ClusterNameLabel = toBool(value)
+		case "PGSCV_EXCLUDE_DATABASES":
+			config.ExcludeDatabases = value
+		case "PGSCV_PLUGINS":
+			config.Plugins = strings.Split(value, ",")
+		case "PGSCV_EXTRA_LABELS":
+			extraLabels, err := parseExtraLabels(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid setting PGSCV_EXTRA_LABELS, value '%s': %s", value, err)
+			}
+			config.ExtraLabels = extraLabels
 		}
 	}
 	return config, nil
 }
 
+// parseExtraLabels parses labels defined as comma-separated list of name=value pairs.
+func parseExtraLabels(s string) (map[string]string, error) {
+	extraLabels := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid label '%s', expected name=value", pair)
+		}
+		extraLabels[name] = value
+	}
+	return extraLabels, nil
+}
+
 // toBool string to bool
 func toBool(s string) bool {
 	switch s {
@@ -515,3 +1419,15 @@ func newDatabasesRegexp(s string) (*regexp.Regexp, error) {
 
 	return regexp.Compile(s)
 }
+
+// invalidMetricName returns the first name which is not valid metric name, empty string is returned when all names
+// are valid.
+func invalidMetricName(names []string) string {
+	re := regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	for _, name := range names {
+		if !re.MatchString(name) {
+			return name
+		}
+	}
+	return ""
+}
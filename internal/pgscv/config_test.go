@@ -4,11 +4,14 @@ package pgscv
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cherts/pgscv/internal/filter"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/service"
+	"github.com/cherts/pgscv/plugin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -260,6 +263,33 @@ func TestNewConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "valid: with included fragments",
+			valid: true,
+			file:  "testdata/pgscv-include-example.yaml",
+			want: &Config{
+				ListenAddress: "127.0.0.1:8080",
+				Include:       includePatterns{"include.d/*.yaml"},
+				Defaults:      map[string]string{"postgres_username": "testuser"},
+				ExtraLabels:   map[string]string{"datacenter": "dc1"},
+				ServicesConnsSettings: service.ConnsSettings{
+					"postgres:5432": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 port=5432 dbname=pgscv_fixtures user=pgscv"},
+					"postgres:5433": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 port=5433 dbname=pgscv_fixtures user=pgscv"},
+					"cluster1":      {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=10.0.0.1 port=5432 dbname=pgscv_fixtures user=pgscv"},
+					"cluster2":      {ServiceType: model.ServiceTypePgbouncer, Conninfo: "host=10.0.0.2 port=6432 dbname=pgbouncer user=pgscv"},
+				},
+			},
+		},
+		{
+			name:  "invalid: service defined in many fragments",
+			valid: false,
+			file:  "testdata/pgscv-include-collision-example.yaml",
+		},
+		{
+			name:  "invalid: unsupported setting in fragment",
+			valid: false,
+			file:  "testdata/pgscv-include-invalid-example.yaml",
+		},
 		{
 			name:  "valid: authentication",
 			valid: true,
@@ -353,6 +383,400 @@ func TestConfig_Validate(t *testing.T) {
 			valid: false,
 			in:    &Config{ListenAddress: "127.0.0.1:8080", AuthConfig: http.AuthConfig{Keyfile: "example.key"}},
 		},
+		{
+			name:  "valid config: compression level",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", CompressionLevel: 5},
+		},
+		{
+			name:  "invalid config: compression level",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", CompressionLevel: 10},
+		},
+		{
+			name:  "valid config: scrape limits",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxSeriesPerCollector: 10000, MaxPayloadBytes: 52428800},
+		},
+		{
+			name:  "invalid config: max series per collector",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxSeriesPerCollector: -1},
+		},
+		{
+			name:  "invalid config: max payload bytes",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxPayloadBytes: -1},
+		},
+		{
+			name:  "valid config: max concurrent scrapes",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxConcurrentScrapes: 20},
+		},
+		{
+			name:  "invalid config: max concurrent scrapes",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MaxConcurrentScrapes: -1},
+		},
+		{
+			name:  "valid config: leader election",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", LeaderElectionConninfo: "host=127.0.0.1 dbname=postgres"},
+		},
+		{
+			name:  "invalid config: leader election lock id without conninfo",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", LeaderElectionLockID: 100},
+		},
+		{
+			name:  "invalid config: leader election conninfo",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", LeaderElectionConninfo: "invalid"},
+		},
+		{
+			name:  "valid config: sharding",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ShardIndex: 2, ShardCount: 3},
+		},
+		{
+			name:  "invalid config: shard index out of range",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ShardIndex: 3, ShardCount: 3},
+		},
+		{
+			name:  "invalid config: shard index without shard count",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ShardIndex: 1},
+		},
+		{
+			name:  "valid config: cluster identity",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ClusterIdentity: "replace"},
+		},
+		{
+			name:  "invalid config: cluster identity",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ClusterIdentity: "invalid"},
+		},
+		{
+			name:  "valid config: cluster name label",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ClusterNameLabel: true},
+		},
+		{
+			name:  "invalid config: cluster name label with cluster identity",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ClusterNameLabel: true, ClusterIdentity: "label"},
+		},
+		{
+			name:  "valid config: exclude databases",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExcludeDatabases: "^(rdsadmin|test_.+)$"},
+		},
+		{
+			name:  "invalid config: exclude databases",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExcludeDatabases: "["},
+		},
+		{
+			name:  "valid config: buffercache ttl",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", BuffercacheTTL: 10 * time.Minute},
+		},
+		{
+			name:  "invalid config: buffercache ttl",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", BuffercacheTTL: -time.Minute},
+		},
+		{
+			name:  "valid config: table bloat",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", TableBloatTables: []string{"appdb.public.orders"}, TableBloatTop: 10, TableBloatTTL: time.Hour},
+		},
+		{
+			name:  "invalid config: table bloat tables",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", TableBloatTables: []string{"public.orders"}},
+		},
+		{
+			name:  "invalid config: table bloat top",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", TableBloatTop: -1},
+		},
+		{
+			name:  "invalid config: table bloat ttl",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", TableBloatTTL: -time.Hour},
+		},
+		{
+			name:  "valid config: index probes",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", IndexProbesTop: 10, IndexProbesTTL: time.Hour},
+		},
+		{
+			name:  "invalid config: index probes top",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", IndexProbesTop: -1},
+		},
+		{
+			name:  "invalid config: index probes ttl",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", IndexProbesTTL: -time.Hour},
+		},
+		{
+			name:  "valid config: statements query ttl",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", StatementsQueryTTL: 10 * time.Minute, StatementsQueryAll: true},
+		},
+		{
+			name:  "invalid config: statements query ttl",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", StatementsQueryTTL: -time.Minute},
+		},
+		{
+			name:  "valid config: statements explain interval",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", StatementsExplainInterval: time.Hour},
+		},
+		{
+			name:  "invalid config: statements explain interval",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", StatementsExplainInterval: 10 * time.Second},
+		},
+		{
+			name:  "valid config: warm-up window",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", WarmUpWindow: 2 * time.Minute},
+		},
+		{
+			name:  "invalid config: warm-up window",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", WarmUpWindow: -time.Minute},
+		},
+		{
+			name:  "valid config: directory walking limits",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", DirWalkRate: 1000, DirWalkTimeout: time.Minute, DirWalkCacheTTL: time.Hour},
+		},
+		{
+			name:  "invalid config: negative directory walking rate",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", DirWalkRate: -1},
+		},
+		{
+			name:  "invalid config: negative checksums verify rate",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ChecksumsVerifyInterval: time.Hour, ChecksumsVerifyRate: -1},
+		},
+		{
+			name:  "valid config: session sampling",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SessionSamplingInterval: time.Second},
+		},
+		{
+			name:  "invalid config: too short session sampling interval",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SessionSamplingInterval: time.Millisecond},
+		},
+		{
+			name:  "invalid config: negative session history max queries",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SessionSamplingInterval: time.Second, SessionHistoryMaxQueries: -1},
+		},
+		{
+			name:  "valid config: metric prefix and namespaces",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MetricPrefix: "pgscv_", MetricNamespaces: map[string]string{"postgres": "pg"}},
+		},
+		{
+			name:  "invalid config: metric prefix",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MetricPrefix: "pg-"},
+		},
+		{
+			name:  "valid config: metrics allowlist",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MetricsAllowlist: []string{"postgres_up", "postgres_database_size_bytes"}},
+		},
+		{
+			name:  "invalid config: metrics allowlist",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MetricsAllowlist: []string{"postgres_*"}},
+		},
+		{
+			name:  "valid config: recording rules",
+			valid: true,
+			in: &Config{ListenAddress: "127.0.0.1:8080", RecordingRules: model.RecordingRules{
+				{Name: "postgres_database_blocks_hit_ratio", Expr: `postgres_database_blocks_total{access="hit"} / sum by (database) (postgres_database_blocks_total)`},
+			}},
+		},
+		{
+			name:  "invalid config: recording rules",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", RecordingRules: model.RecordingRules{
+				{Name: "postgres_database_blocks_hit_ratio", Expr: `rate(postgres_database_blocks_total[5m])`},
+			}},
+		},
+		{
+			name:  "invalid config: unknown metric namespace",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MetricNamespaces: map[string]string{"system": "node"}},
+		},
+		{
+			name:  "valid config: schema labels limits",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SchemaLabelMaxLength: 128, SchemaLabelHash: true, SchemaMaxSeries: 100},
+		},
+		{
+			name:  "invalid config: schema label hash without max length",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SchemaLabelHash: true},
+		},
+		{
+			name:  "invalid config: negative schema max series",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", SchemaMaxSeries: -1},
+		},
+		{
+			name:  "valid config: watchdog max query age",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", WatchdogMaxQueryAge: time.Minute},
+		},
+		{
+			name:  "invalid config: watchdog max query age",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", WatchdogMaxQueryAge: -time.Minute},
+		},
+		{
+			name:  "valid config: extra labels",
+			valid: true,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExtraLabels: map[string]string{"environment": "prod", "region": "eu"}},
+		},
+		{
+			name:  "invalid config: extra labels meta label",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExtraLabels: map[string]string{"__scrape_timeout__": "10s"}},
+		},
+		{
+			name:  "invalid config: extra labels invalid name",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExtraLabels: map[string]string{"1-region": "eu"}},
+		},
+		{
+			name:  "invalid config: extra labels clash with collectors labels",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", ExtraLabels: map[string]string{"database": "shop"}},
+		},
+		{
+			name:  "valid config: session settings",
+			valid: true,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", SessionSettings: map[string]string{"statement_timeout": "10s", "lock_timeout": "1s"}},
+			}},
+		},
+		{
+			name:  "invalid config: session settings for pgbouncer",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePgbouncer, Conninfo: "host=127.0.0.1", SessionSettings: map[string]string{"statement_timeout": "10s"}},
+			}},
+		},
+		{
+			name:  "invalid config: session settings invalid name",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", SessionSettings: map[string]string{"statement_timeout; DROP": "10s"}},
+			}},
+		},
+		{
+			name:  "invalid config: service metrics allowlist",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", MetricsAllowlist: []string{"postgres up"}},
+			}},
+		},
+		{
+			name:  "valid config: patroni auth",
+			valid: true,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePatroni, BaseURL: "https://127.0.0.1:8008", HTTPAuth: http.ClientAuthConfig{
+					Username: "pgscv", Password: "secret", CAfile: "ca.crt", Certfile: "client.crt", Keyfile: "client.key",
+				}},
+			}},
+		},
+		{
+			name:  "invalid config: patroni auth for postgres",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1", HTTPAuth: http.ClientAuthConfig{Username: "pgscv"}},
+			}},
+		},
+		{
+			name:  "invalid config: patroni certfile without keyfile",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"test": {ServiceType: model.ServiceTypePatroni, BaseURL: "https://127.0.0.1:8008", HTTPAuth: http.ClientAuthConfig{Certfile: "client.crt"}},
+			}},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.in.Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_checksumsVerifyRate(t *testing.T) {
+	// Reading rate is limited by default when verification is enabled.
+	c := &Config{ListenAddress: "127.0.0.1:8080", ChecksumsVerifyInterval: time.Hour}
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, defaultChecksumsVerifyRate, c.ChecksumsVerifyRate)
+
+	c = &Config{ListenAddress: "127.0.0.1:8080", ChecksumsVerifyInterval: time.Hour, ChecksumsVerifyRate: 1048576}
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, 1048576, c.ChecksumsVerifyRate)
+}
+
+func TestConfig_Validate_plugin(t *testing.T) {
+	assert.NoError(t, plugin.Register(plugin.ServiceType{Name: "test_proxy", Collectors: map[string]plugin.CollectorFactory{
+		"health": func(prometheus.Labels) (plugin.Collector, error) { return nil, nil },
+	}}))
+
+	var testcases = []struct {
+		name  string
+		valid bool
+		in    *Config
+	}{
+		{
+			name:  "valid config: plugin service",
+			valid: true,
+			in: &Config{ListenAddress: "127.0.0.1:8080", MetricNamespaces: map[string]string{"test_proxy": "proxy"}, ServicesConnsSettings: service.ConnsSettings{
+				"proxy": {ServiceType: "test_proxy", BaseURL: "tcp://127.0.0.1:6033", Conninfo: "user:password@tcp(127.0.0.1:6033)/"},
+			}},
+		},
+		{
+			name:  "invalid config: plugin service without base url",
+			valid: false,
+			in: &Config{ListenAddress: "127.0.0.1:8080", ServicesConnsSettings: service.ConnsSettings{
+				"proxy": {ServiceType: "test_proxy", Conninfo: "user:password@tcp(127.0.0.1:6033)/"},
+			}},
+		},
+		{
+			name:  "invalid config: unknown metric namespace",
+			valid: false,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", MetricNamespaces: map[string]string{"test_unknown": "unknown"}},
+		},
+		{
+			name:  "plugins are accepted only by builds supporting Go plugins",
+			valid: plugin.LoadSupported,
+			in:    &Config{ListenAddress: "127.0.0.1:8080", Plugins: []string{"/usr/lib/pgscv/proxy.so"}},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -406,8 +830,126 @@ func Test_validateCollectorSettings(t *testing.T) {
 				},
 			},
 		},
+		{
+			valid: true, // Overriding query returns required columns
+			settings: map[string]model.CollectorSettings{
+				"postgres/archiver": {
+					Query: "SELECT archived_count, failed_count, EXTRACT(EPOCH FROM now() - last_archived_time) AS since_last_archive_seconds, " +
+						"0 AS lag_files FROM pg_stat_archiver WHERE archived_count > 0",
+				},
+			},
+		},
+		{
+			valid: true, // Custom metrics of pgbouncer and patroni
+			settings: map[string]model.CollectorSettings{
+				"pgbouncer/custom": {
+					Subsystems: map[string]model.MetricsSubsystem{
+						"lists": {
+							Query: "SHOW LISTS",
+							Metrics: model.Metrics{
+								{ShortName: "items", Usage: "GAUGE", Value: "items", Labels: []string{"list"}, Description: "description"},
+							},
+						},
+					},
+				},
+				"patroni/custom": {
+					Subsystems: map[string]model.MetricsSubsystem{
+						"member": {
+							Endpoint: "/cluster",
+							Path:     "$.members[*]",
+							Metrics: model.Metrics{
+								{ShortName: "timeline", Usage: "GAUGE", Value: "timeline", Labels: []string{"name", "role"}, Description: "description"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			valid: false, // Patroni metrics without endpoint
+			settings: map[string]model.CollectorSettings{
+				"patroni/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {
+					Query:   "SELECT 1 AS value1",
+					Metrics: model.Metrics{{ShortName: "v1", Usage: "GAUGE", Value: "value1", Description: "description"}},
+				}}},
+			},
+		},
+		{
+			valid: false, // Invalid JSONPath
+			settings: map[string]model.CollectorSettings{
+				"patroni/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {Endpoint: "/cluster", Path: "members[*]"}}},
+			},
+		},
+		{
+			valid: false, // Databases are not supported by pgbouncer
+			settings: map[string]model.CollectorSettings{
+				"pgbouncer/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {Databases: "pgbouncer", Query: "SHOW LISTS"}}},
+			},
+		},
+		{
+			valid: true, // Custom metrics of HTTP endpoints
+			settings: map[string]model.CollectorSettings{
+				"http/custom": {
+					Subsystems: map[string]model.MetricsSubsystem{
+						"sidecar": {
+							URL:  "http://127.0.0.1:8080/stats",
+							Path: "$.instances[*]",
+							Metrics: model.Metrics{
+								{ShortName: "connections", Usage: "GAUGE", Value: "connections", Labels: []string{"name"}, Description: "description"},
+							},
+						},
+						"haproxy": {
+							URL:   "https://127.0.0.1:8404/stats;csv",
+							Regex: `(?m)^(?P<proxy>[^#,]+),(?P<server>[^,]+),\d*,\d*,(?P<current>\d+),`,
+							Auth:  http.ClientAuthConfig{Username: "stats", Password: "secret"},
+							Metrics: model.Metrics{
+								{ShortName: "sessions", Usage: "GAUGE", Value: "current", Labels: []string{"proxy", "server"}, Description: "description"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			valid: false, // Invalid URL
+			settings: map[string]model.CollectorSettings{
+				"http/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {URL: "127.0.0.1:8080/stats"}}},
+			},
+		},
+		{
+			valid: false, // Path and regex together
+			settings: map[string]model.CollectorSettings{
+				"http/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {URL: "http://127.0.0.1/stats", Path: "$", Regex: "(?P<v>\\d+)"}}},
+			},
+		},
+		{
+			valid: false, // Invalid regex
+			settings: map[string]model.CollectorSettings{
+				"http/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {URL: "http://127.0.0.1/stats", Regex: "("}}},
+			},
+		},
+		{
+			valid: false, // URL is supported by http/custom only
+			settings: map[string]model.CollectorSettings{
+				"postgres/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {URL: "http://127.0.0.1/stats", Query: "SELECT 1"}}},
+			},
+		},
+		{
+			valid: false, // Endpoint is supported by patroni only
+			settings: map[string]model.CollectorSettings{
+				"postgres/custom": {Subsystems: map[string]model.MetricsSubsystem{"example": {Endpoint: "/cluster"}}},
+			},
+		},
 		// invalid collectors names
 		{valid: false, settings: map[string]model.CollectorSettings{"invalid": {}}},
+		{
+			valid:    false, // Collector doesn't support query override
+			settings: map[string]model.CollectorSettings{"postgres/tables": {Query: "SELECT 1"}},
+		},
+		{
+			valid:    false, // Overriding query doesn't return required columns
+			settings: map[string]model.CollectorSettings{"postgres/archiver": {Query: "SELECT archived_count FROM pg_stat_archiver"}},
+		},
 		{valid: false, settings: map[string]model.CollectorSettings{"invalid/": {}}},
 		{valid: false, settings: map[string]model.CollectorSettings{"/invalid": {}}},
 		{valid: false, settings: map[string]model.CollectorSettings{"example/inva:lid": {}}},
@@ -527,6 +1069,44 @@ func Test_validateCollectorSettings(t *testing.T) {
 				},
 			},
 		},
+		{
+			valid: true, // Valid commands
+			settings: map[string]model.CollectorSettings{
+				"system/exec": {
+					Commands: model.Commands{
+						{Name: "raid", Command: "/usr/local/bin/check_raid", Interval: time.Minute, Timeout: 5 * time.Second},
+						{Name: "backups", Command: "/usr/local/bin/check_backups", Env: map[string]string{"PGHOST": "/tmp"}},
+					},
+				},
+			},
+		},
+		{
+			valid: false, // Duplicate command names
+			settings: map[string]model.CollectorSettings{
+				"system/exec": {
+					Commands: model.Commands{
+						{Name: "raid", Command: "/usr/local/bin/check_raid"},
+						{Name: "raid", Command: "/usr/local/bin/check_raid2"},
+					},
+				},
+			},
+		},
+		{
+			valid: false, // Command is not specified
+			settings: map[string]model.CollectorSettings{
+				"system/exec": {
+					Commands: model.Commands{{Name: "raid"}},
+				},
+			},
+		},
+		{
+			valid: false, // Invalid timeout
+			settings: map[string]model.CollectorSettings{
+				"system/exec": {
+					Commands: model.Commands{{Name: "raid", Command: "/usr/local/bin/check_raid", Timeout: -time.Second}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -569,6 +1149,8 @@ func Test_newConfigFromEnv(t *testing.T) {
 				"PGSCV_AUTH_PASSWORD":        "pass",
 				"PGSCV_AUTH_KEYFILE":         "keyfile.key",
 				"PGSCV_AUTH_CERTFILE":        "certfile.cert",
+				"PGSCV_AUTH_CLIENT_CAFILE":   "ca.cert",
+				"PGSCV_AUTH_ALLOWED_CLIENTS": "client1,client2",
 				"PGSCV_SKIP_CONN_ERROR_MODE": "yes",
 			},
 			want: &Config{
@@ -585,10 +1167,12 @@ func Test_newConfigFromEnv(t *testing.T) {
 					"EXAMPLE3":  {ServiceType: model.ServiceTypePatroni, BaseURL: "example_url"},
 				},
 				AuthConfig: http.AuthConfig{
-					Username: "user",
-					Password: "pass",
-					Keyfile:  "keyfile.key",
-					Certfile: "certfile.cert",
+					Username:       "user",
+					Password:       "pass",
+					Keyfile:        "keyfile.key",
+					Certfile:       "certfile.cert",
+					ClientCAfile:   "ca.cert",
+					AllowedClients: []string{"client1", "client2"},
 				},
 				Defaults:          map[string]string{},
 				SkipConnErrorMode: true,
@@ -606,6 +1190,23 @@ func Test_newConfigFromEnv(t *testing.T) {
 			valid:   false, // Invalid patroni URL key
 			envvars: map[string]string{"PATRONI_URL_": "example_dsn"},
 		},
+		{
+			valid:   false, // Invalid compression level
+			envvars: map[string]string{"PGSCV_COMPRESSION_LEVEL": "max"},
+		},
+		{
+			valid:   true, // Extra labels
+			envvars: map[string]string{"PGSCV_EXTRA_LABELS": "environment=prod, region=eu"},
+			want: &Config{
+				Defaults:              map[string]string{},
+				ServicesConnsSettings: map[string]service.ConnSetting{},
+				ExtraLabels:           map[string]string{"environment": "prod", "region": "eu"},
+			},
+		},
+		{
+			valid:   false, // Invalid extra labels
+			envvars: map[string]string{"PGSCV_EXTRA_LABELS": "environment"},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -648,3 +1249,28 @@ func Test_newDatabasesRegexp(t *testing.T) {
 		}
 	}
 }
+
+func TestConfig_CheckConnections(t *testing.T) {
+	okSrv := http.TestServer(t, 200, "")
+	defer okSrv.Close()
+	failSrv := http.TestServer(t, 503, "")
+	defer failSrv.Close()
+
+	config := &Config{ConnTimeout: 1, ServicesConnsSettings: service.ConnsSettings{
+		"patroni:8008": {ServiceType: model.ServiceTypePatroni, BaseURL: okSrv.URL},
+	}}
+	assert.NoError(t, config.CheckConnections())
+
+	config.ServicesConnsSettings = service.ConnsSettings{
+		"patroni:8008":  {ServiceType: model.ServiceTypePatroni, BaseURL: okSrv.URL},
+		"patroni:8009":  {ServiceType: model.ServiceTypePatroni, BaseURL: failSrv.URL},
+		"postgres:1":    {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 port=1 user=pgscv dbname=postgres"},
+		"unknown:12345": {ServiceType: "unknown"},
+	}
+	err := config.CheckConnections()
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "patroni:8008")
+	assert.Contains(t, err.Error(), "patroni:8009")
+	assert.Contains(t, err.Error(), "postgres:1")
+	assert.Contains(t, err.Error(), "unknown:12345")
+}
@@ -6,19 +6,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	net_http "net/http"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cherts/pgscv/discovery"
+	"github.com/cherts/pgscv/internal/collector"
 	sd "github.com/cherts/pgscv/internal/discovery/service"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/service"
+	"github.com/cherts/pgscv/plugin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 	"golang.org/x/time/rate"
 )
 
@@ -26,6 +35,9 @@ const pgSCVSubscriber = "pgscv_subscriber"
 
 const tooManyRequests = 429
 
+// leaderElectionInterval defines how often leadership is acquired by standby or checked by leader.
+const leaderElectionInterval = 5 * time.Second
+
 type target struct {
 	Targets []string          `json:"targets"`
 	Labels  map[string]string `json:"labels,omitempty"`
@@ -35,33 +47,58 @@ type target struct {
 func Start(ctx context.Context, config *Config) error {
 	log.Debug("start application")
 
+	// Counter of suppressed log messages is process-wide, register it once.
+	err := prometheus.Register(log.SuppressedCollector)
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return err
+	}
+
 	serviceRepo := service.NewRepository()
 
-	serviceConfig := service.Config{
-		NoTrackMode:        config.NoTrackMode,
-		ConnDefaults:       config.Defaults,
-		ConnsSettings:      config.ServicesConnsSettings,
-		DatabasesRE:        config.DatabasesRE,
-		DisabledCollectors: config.DisableCollectors,
-		CollectorsSettings: config.CollectorsSettings,
-		CollectTopTable:    config.CollectTopTable,
-		CollectTopIndex:    config.CollectTopIndex,
-		CollectTopQuery:    config.CollectTopQuery,
-		SkipConnErrorMode:  config.SkipConnErrorMode,
-		ConnTimeout:        config.ConnTimeout,
-		ThrottlingInterval: config.ThrottlingInterval,
-		ConcurrencyLimit:   config.ConcurrencyLimit,
+	err = prometheus.Register(serviceRepo.ScrapeScheduler())
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return err
 	}
 
+	err = prometheus.Register(serviceRepo.Janitor())
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return err
+	}
+
+	err = prometheus.Register(serviceRepo.Shard())
+	if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return err
+	}
+
+	serviceConfig := newServiceConfig(config)
+
 	if len(config.ServicesConnsSettings) == 0 && config.DiscoveryServices == nil {
 		return errors.New("no services defined")
 	}
 
+	// Leader is elected before services setup, so heavy collectors are not executed by standby.
+	var leader *collector.LeaderElector
+	if config.LeaderElectionConninfo != "" {
+		leader = serviceRepo.EnableLeaderElection(config.LeaderElectionConninfo, config.LeaderElectionLockID)
+		err = prometheus.Register(leader)
+		if err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			return err
+		}
+	}
+
 	// fulfill service repo using passed services
 	serviceRepo.AddServicesFromConfig(serviceConfig)
 
+	// Stats cached by collectors before restart are restored when services are set up.
+	if config.CacheSnapshotFile != "" {
+		err = serviceRepo.LoadCacheSnapshot(config.CacheSnapshotFile)
+		if err != nil {
+			log.Warnf("load cached stats from %s failed: %s; skip", config.CacheSnapshotFile, err)
+		}
+	}
+
 	// setup exporters for all services
-	err := serviceRepo.SetupServices(serviceConfig)
+	err = serviceRepo.SetupServices(serviceConfig)
 	if err != nil {
 		return err
 	}
@@ -73,6 +110,10 @@ func Start(ctx context.Context, config *Config) error {
 	defer close(errCh)
 	if config.DiscoveryServices != nil {
 		for _, ds := range *config.DiscoveryServices {
+			// Local discovery connects to discovered services using default credentials.
+			if ld, ok := ds.(*sd.LocalDiscovery); ok {
+				ld.SetDefaults(config.Defaults)
+			}
 			wg.Go(func() {
 				err := ds.Start(ctx, errCh)
 				if err != nil {
@@ -81,7 +122,19 @@ func Start(ctx context.Context, config *Config) error {
 			})
 			switch dt := ds.(type) {
 			case *sd.YandexDiscovery:
-				err := subscribeYandex(&ds, config, serviceRepo)
+				err := subscribeDiscovery(&ds, config, serviceRepo, config.DisableCollectors)
+				if err != nil {
+					cancel()
+					return err
+				}
+			case *sd.ReplicasDiscovery:
+				err := subscribeDiscovery(&ds, config, serviceRepo, slices.Concat(replicaDisabledCollectors, config.DisableCollectors))
+				if err != nil {
+					cancel()
+					return err
+				}
+			case *sd.LocalDiscovery:
+				err := subscribeDiscovery(&ds, config, serviceRepo, config.DisableCollectors)
 				if err != nil {
 					cancel()
 					return err
@@ -93,6 +146,18 @@ func Start(ctx context.Context, config *Config) error {
 		}
 	}
 
+	// Start leader election, leadership is released when application is stopped.
+	if leader != nil {
+		wg.Go(func() {
+			leader.Run(ctx, leaderElectionInterval)
+		})
+	}
+
+	// Start janitor which releases data left after services removed by discovery.
+	wg.Go(func() {
+		serviceRepo.RunJanitor(ctx, service.JanitorInterval)
+	})
+
 	// Start HTTP metrics listener.
 	wg.Go(func() {
 		if err := runHTTPListener(ctx, config, serviceRepo); err != nil {
@@ -122,40 +187,287 @@ func Start(ctx context.Context, config *Config) error {
 			log.Info("exit signaled, stop application")
 			cancel()
 			wg.Wait()
+			saveCacheSnapshot(config, serviceRepo)
 			return nil
 		case e := <-errCh:
 			cancel()
 			wg.Wait()
+			saveCacheSnapshot(config, serviceRepo)
 			return e
 		}
 	}
 }
 
-func subscribeYandex(ds *discovery.Discovery, config *Config, serviceRepo *service.Repository) error {
+// saveCacheSnapshot persists stats cached by collectors of services, if enabled, so they are reused after restart.
+func saveCacheSnapshot(config *Config, repository *service.Repository) {
+	if config.CacheSnapshotFile == "" {
+		return
+	}
+
+	err := repository.SaveCacheSnapshot(config.CacheSnapshotFile)
+	if err != nil {
+		log.Warnf("save cached stats to %s failed: %s; skip", config.CacheSnapshotFile, err)
+	}
+}
+
+// newServiceConfig creates configuration of services defined in application's configuration.
+func newServiceConfig(config *Config) service.Config {
+	return service.Config{
+		NoTrackMode:               config.NoTrackMode,
+		ConnDefaults:              config.Defaults,
+		ConnsSettings:             config.ServicesConnsSettings,
+		DatabasesRE:               config.DatabasesRE,
+		ExcludeDatabasesRE:        config.ExcludeDatabasesRE,
+		DisabledCollectors:        config.DisableCollectors,
+		CollectorsSettings:        config.CollectorsSettings,
+		CollectTopTable:           config.CollectTopTable,
+		CollectTopIndex:           config.CollectTopIndex,
+		CollectTopQuery:           config.CollectTopQuery,
+		CollectTopApplication:     config.CollectTopApplication,
+		SkipConnErrorMode:         config.SkipConnErrorMode,
+		ConnTimeout:               config.ConnTimeout,
+		ThrottlingInterval:        config.ThrottlingInterval,
+		ConcurrencyLimit:          config.ConcurrencyLimit,
+		MaxSeriesPerCollector:     config.MaxSeriesPerCollector,
+		MaxPayloadBytes:           config.MaxPayloadBytes,
+		MetricsAllowlist:          config.MetricsAllowlist,
+		RecordingRules:            config.RecordingRules,
+		ChecksumsVerifyInterval:   config.ChecksumsVerifyInterval,
+		ChecksumsVerifyRate:       config.ChecksumsVerifyRate,
+		MaxConcurrentScrapes:      config.MaxConcurrentScrapes,
+		ClusterIdentity:           config.ClusterIdentity,
+		ClusterNameLabel:          config.ClusterNameLabel,
+		ExtraLabels:               config.ExtraLabels,
+		BuffercacheTTL:            config.BuffercacheTTL,
+		StatementsQueryTTL:        config.StatementsQueryTTL,
+		StatementsQueryAll:        config.StatementsQueryAll,
+		StatementsExplainInterval: config.StatementsExplainInterval,
+		WarmUpWindow:              config.WarmUpWindow,
+		TableBloatTables:          config.TableBloatTables,
+		TableBloatTop:             config.TableBloatTop,
+		TableBloatTTL:             config.TableBloatTTL,
+		IndexProbesTop:            config.IndexProbesTop,
+		IndexProbesTTL:            config.IndexProbesTTL,
+		DDLTracking:               config.DDLTracking,
+		ProbeICMP:                 config.ProbeICMP,
+		WatchdogMaxQueryAge:       config.WatchdogMaxQueryAge,
+		SchemaLabelMaxLength:      config.SchemaLabelMaxLength,
+		SchemaLabelHash:           config.SchemaLabelHash,
+		SchemaMaxSeries:           config.SchemaMaxSeries,
+		DirWalkRate:               config.DirWalkRate,
+		DirWalkTimeout:            config.DirWalkTimeout,
+		DirWalkCacheTTL:           config.DirWalkCacheTTL,
+		SessionSamplingInterval:   config.SessionSamplingInterval,
+		SessionHistoryMaxQueries:  config.SessionHistoryMaxQueries,
+		ShardIndex:                config.ShardIndex,
+		ShardCount:                config.ShardCount,
+	}
+}
+
+// CollectOnce performs single collection of services defined in configuration and writes collected metrics in text
+// exposition format. When service IDs are passed, only these services are collected. Services found by discovery are
+// not collected, metrics of pgSCV itself are not written.
+func CollectOnce(config *Config, serviceIDs []string, w io.Writer) error {
+	serviceRepo, err := setupServices(config, serviceIDs)
+	if err != nil {
+		return err
+	}
+	defer removeServices(serviceRepo)
+
+	if len(serviceIDs) == 0 {
+		serviceIDs = serviceRepo.GetServiceIDs()
+		slices.Sort(serviceIDs)
+	}
+
+	registry, err := serviceRepo.NewServicesRegistry(serviceIDs)
+	if err != nil {
+		return err
+	}
+
+	renamer := metricsRenamer{prefix: config.MetricPrefix, namespaces: config.MetricNamespaces}
+	families, err := renamer.gatherer(registry).Gather()
+	if err != nil {
+		return err
+	}
+
+	for _, mf := range families {
+		_, err = expfmt.MetricFamilyToText(w, mf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PrintQueries writes queries executed by collectors enabled for the service, grouped by collectors. The service is
+// connected for detecting its version and installed extensions, queries are selected by collectors for detected ones.
+func PrintQueries(config *Config, serviceID string, w io.Writer) error {
+	serviceRepo := service.NewRepository()
+	if config.LeaderElectionConninfo != "" {
+		serviceRepo.EnableLeaderElection(config.LeaderElectionConninfo, config.LeaderElectionLockID)
+	}
+
+	queries, err := serviceRepo.DeclaredQueries(newServiceConfig(config), serviceID)
+	if err != nil {
+		return err
+	}
+
+	return writeQueries(w, queries)
+}
+
+// writeQueries writes queries of collectors sorted by collectors names, collectors without queries are skipped.
+func writeQueries(w io.Writer, queries map[string][]string) error {
+	names := slices.Sorted(maps.Keys(queries))
+	for _, name := range names {
+		if len(queries[name]) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "-- %s\n", name); err != nil {
+			return err
+		}
+		for _, q := range queries[name] {
+			if _, err := fmt.Fprintf(w, "%s;\n\n", strings.TrimRight(strings.TrimSpace(q), ";")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setupServices creates repository with services defined in configuration and sets them up. When service IDs are
+// passed, only these services are added. Services must be removed by caller using removeServices().
+func setupServices(config *Config, serviceIDs []string) (*service.Repository, error) {
+	serviceConfig := newServiceConfig(config)
+
+	if len(serviceIDs) > 0 {
+		cs := service.ConnsSettings{}
+		for _, id := range serviceIDs {
+			if s, ok := config.ServicesConnsSettings[id]; ok {
+				cs[id] = s
+			}
+		}
+		serviceConfig.ConnsSettings = cs
+	}
+
+	serviceRepo := service.NewRepository()
+	serviceRepo.AddServicesFromConfig(serviceConfig)
+
+	if err := serviceRepo.SetupServices(serviceConfig); err != nil {
+		removeServices(serviceRepo)
+		return nil, err
+	}
+
+	return serviceRepo, nil
+}
+
+// removeServices removes all services from the repository and closes their collectors.
+func removeServices(serviceRepo *service.Repository) {
+	for _, id := range serviceRepo.GetServiceIDs() {
+		serviceRepo.RemoveService(id)
+	}
+}
+
+// replicaDisabledCollectors defines collectors disabled for replicas discovered from pg_stat_replication. Per-database
+// statistics of replicas duplicate the primary ones, hence only lightweight collectors are enabled.
+var replicaDisabledCollectors = []string{
+	"postgres/tables",
+	"postgres/indexes",
+	"postgres/functions",
+	"postgres/schemas",
+	"postgres/statements",
+	"postgres/statements_query",
+	"postgres/statements_plans",
+	"postgres/extensions",
+	"postgres/checksums",
+	"postgres/ddl",
+	"postgres/objects",
+	"postgres/pgvector",
+}
+
+// subscribeDiscovery subscribes to discovery service and registers/unregisters discovered services in repository.
+func subscribeDiscovery(ds *discovery.Discovery, config *Config, serviceRepo *service.Repository, disabledCollectors []string) error {
 	err := (*ds).Subscribe(pgSCVSubscriber,
 		// addService
 		func(services map[string]discovery.Service) error {
 			constLabels := make(map[string]*map[string]string)
 			targetLabels := make(map[string]*map[string]string)
 			serviceDiscoveryConfig := service.Config{
-				NoTrackMode:        config.NoTrackMode,
-				ConnDefaults:       config.Defaults,
-				DisabledCollectors: config.DisableCollectors,
-				CollectorsSettings: config.CollectorsSettings,
-				CollectTopTable:    config.CollectTopTable,
-				CollectTopIndex:    config.CollectTopIndex,
-				CollectTopQuery:    config.CollectTopQuery,
-				SkipConnErrorMode:  config.SkipConnErrorMode,
-				ConstLabels:        &constLabels,
-				TargetLabels:       &targetLabels,
-				ConnTimeout:        config.ConnTimeout,
-				ConcurrencyLimit:   config.ConcurrencyLimit,
+				NoTrackMode:               config.NoTrackMode,
+				ConnDefaults:              config.Defaults,
+				ExcludeDatabasesRE:        config.ExcludeDatabasesRE,
+				DisabledCollectors:        disabledCollectors,
+				CollectorsSettings:        config.CollectorsSettings,
+				CollectTopTable:           config.CollectTopTable,
+				CollectTopIndex:           config.CollectTopIndex,
+				CollectTopQuery:           config.CollectTopQuery,
+				CollectTopApplication:     config.CollectTopApplication,
+				SkipConnErrorMode:         config.SkipConnErrorMode,
+				ConstLabels:               &constLabels,
+				TargetLabels:              &targetLabels,
+				ConnTimeout:               config.ConnTimeout,
+				ConcurrencyLimit:          config.ConcurrencyLimit,
+				MaxSeriesPerCollector:     config.MaxSeriesPerCollector,
+				MaxPayloadBytes:           config.MaxPayloadBytes,
+				MetricsAllowlist:          config.MetricsAllowlist,
+				RecordingRules:            config.RecordingRules,
+				ChecksumsVerifyInterval:   config.ChecksumsVerifyInterval,
+				ChecksumsVerifyRate:       config.ChecksumsVerifyRate,
+				MaxConcurrentScrapes:      config.MaxConcurrentScrapes,
+				ClusterIdentity:           config.ClusterIdentity,
+				ClusterNameLabel:          config.ClusterNameLabel,
+				ExtraLabels:               config.ExtraLabels,
+				BuffercacheTTL:            config.BuffercacheTTL,
+				StatementsQueryTTL:        config.StatementsQueryTTL,
+				StatementsQueryAll:        config.StatementsQueryAll,
+				StatementsExplainInterval: config.StatementsExplainInterval,
+				WarmUpWindow:              config.WarmUpWindow,
+				TableBloatTables:          config.TableBloatTables,
+				TableBloatTop:             config.TableBloatTop,
+				TableBloatTTL:             config.TableBloatTTL,
+				IndexProbesTop:            config.IndexProbesTop,
+				IndexProbesTTL:            config.IndexProbesTTL,
+				DDLTracking:               config.DDLTracking,
+				ProbeICMP:                 config.ProbeICMP,
+				WatchdogMaxQueryAge:       config.WatchdogMaxQueryAge,
+				SchemaLabelMaxLength:      config.SchemaLabelMaxLength,
+				SchemaLabelHash:           config.SchemaLabelHash,
+				SchemaMaxSeries:           config.SchemaMaxSeries,
+				DirWalkRate:               config.DirWalkRate,
+				DirWalkTimeout:            config.DirWalkTimeout,
+				DirWalkCacheTTL:           config.DirWalkCacheTTL,
+				SessionSamplingInterval:   config.SessionSamplingInterval,
+				SessionHistoryMaxQueries:  config.SessionHistoryMaxQueries,
+				ShardIndex:                config.ShardIndex,
+				ShardCount:                config.ShardCount,
 			}
 			var cs = make(service.ConnsSettings, len(services))
 			for serviceID, svc := range services {
-				cs[serviceID] = service.ConnSetting{
-					ServiceType: model.ServiceTypePostgresql,
-					Conninfo:    svc.DSN,
+				switch svc.ServiceType {
+				case model.ServiceTypePatroni:
+					cs[serviceID] = service.ConnSetting{
+						ServiceType: model.ServiceTypePatroni,
+						BaseURL:     svc.DSN,
+					}
+				case model.ServiceTypePgbouncer:
+					cs[serviceID] = service.ConnSetting{
+						ServiceType: model.ServiceTypePgbouncer,
+						Conninfo:    svc.DSN,
+					}
+				default:
+					if _, ok := plugin.Lookup(svc.ServiceType); ok {
+						cs[serviceID] = service.ConnSetting{
+							ServiceType: svc.ServiceType,
+							BaseURL:     svc.DSN,
+						}
+						break
+					}
+					cs[serviceID] = service.ConnSetting{
+						ServiceType: model.ServiceTypePostgresql,
+						Conninfo:    svc.DSN,
+					}
 				}
 				constLabels[serviceID] = &svc.ConstLabels
 				targetLabels[serviceID] = &svc.TargetLabels
@@ -180,8 +492,60 @@ func subscribeYandex(ds *discovery.Discovery, config *Config, serviceRepo *servi
 	return err
 }
 
+// metricsHandlerOpts defines options of /metrics endpoint handler. OpenMetrics format is used when requested by scraper
+// via Accept header (required for exemplars and created timestamps), otherwise classic text format is used.
+// Compression is disabled here because it is negotiated by HTTP server (see http.ServerConfig.CompressionLevel).
+var metricsHandlerOpts = promhttp.HandlerOpts{
+	EnableOpenMetrics:                   true,
+	EnableOpenMetricsTextCreatedSamples: true,
+	DisableCompression:                  true,
+}
+
+// runtimeMetricsPrefixes defines prefixes of metrics describing pgSCV process itself, such metrics are not renamed.
+var runtimeMetricsPrefixes = []string{"go_", "process_", "promhttp_"}
+
+// metricsRenamer defines rules of renaming metrics exposed by pgSCV.
+type metricsRenamer struct {
+	prefix     string            // prefix added to names of all metrics
+	namespaces map[string]string // namespaces replaced in names of metrics, e.g. postgres -> pg
+}
+
+// rename returns new name of the metric accordingly to renaming rules.
+func (m metricsRenamer) rename(name string) string {
+	for _, p := range runtimeMetricsPrefixes {
+		if strings.HasPrefix(name, p) {
+			return name
+		}
+	}
+
+	for from, to := range m.namespaces {
+		if rest, ok := strings.CutPrefix(name, from+"_"); ok {
+			name = to + "_" + rest
+			break
+		}
+	}
+
+	return m.prefix + name
+}
+
+// gatherer wraps passed gatherer and renames gathered metrics. Gatherer is returned as is when no renaming required.
+func (m metricsRenamer) gatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if m.prefix == "" && len(m.namespaces) == 0 {
+		return g
+	}
+
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		for _, mf := range families {
+			name := m.rename(mf.GetName())
+			mf.Name = &name
+		}
+		return families, err
+	})
+}
+
 // getMetricsHandler return http handler function to /metrics endpoint
-func getMetricsHandler(repository *service.Repository, throttlingInterval *int, newLimiterFunc func() *rate.Limiter) func(w net_http.ResponseWriter, r *net_http.Request) {
+func getMetricsHandler(repository *service.Repository, throttlingInterval *int, newLimiterFunc func() *rate.Limiter, renamer metricsRenamer) func(w net_http.ResponseWriter, r *net_http.Request) {
 	limiters := make(map[string]*rate.Limiter)
 
 	throttle := struct {
@@ -193,6 +557,15 @@ func getMetricsHandler(repository *service.Repository, throttlingInterval *int,
 
 	return func(w net_http.ResponseWriter, r *net_http.Request) {
 		target := r.URL.Query().Get("target")
+		tag := r.URL.Query().Get("tag")
+		if target != "" && tag != "" {
+			net_http.Error(w, "target and tag could not be used together", http.StatusBadRequest)
+			return
+		}
+		// Scrapes of tagged services are limited separately from scrapes of exact services.
+		if tag != "" {
+			target = "tag/" + tag
+		}
 		if newLimiterFunc != nil {
 			if limiter, ok := limiters[target]; ok {
 				if !limiter.Allow() {
@@ -221,7 +594,17 @@ func getMetricsHandler(repository *service.Repository, throttlingInterval *int,
 		}
 		if target == "" {
 			h := promhttp.InstrumentMetricHandler(
-				prometheus.DefaultRegisterer, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}),
+				prometheus.DefaultRegisterer, promhttp.HandlerFor(renamer.gatherer(prometheus.DefaultGatherer), metricsHandlerOpts),
+			)
+			h.ServeHTTP(w, r)
+		} else if tag != "" {
+			registry := repository.GetTagRegistry(tag)
+			if registry == nil {
+				net_http.Error(w, fmt.Sprintf("no services tagged with %s", tag), http.StatusNotFound)
+				return
+			}
+			h := promhttp.InstrumentMetricHandler(
+				registry, promhttp.HandlerFor(renamer.gatherer(registry), metricsHandlerOpts),
 			)
 			h.ServeHTTP(w, r)
 		} else {
@@ -231,7 +614,7 @@ func getMetricsHandler(repository *service.Repository, throttlingInterval *int,
 				return
 			}
 			h := promhttp.InstrumentMetricHandler(
-				registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+				registry, promhttp.HandlerFor(renamer.gatherer(registry), metricsHandlerOpts),
 			)
 			h.ServeHTTP(w, r)
 		}
@@ -267,9 +650,153 @@ func getFlushHandler(repository *service.Repository, limiter *rate.Limiter) func
 	}
 }
 
-// getTargetsHandler return http handler function to /targets endpoint
+// silenceRequest defines request body of /silence endpoint.
+type silenceRequest struct {
+	ServiceID  string   `json:"service_id"`
+	Collectors []string `json:"collectors"` // empty list means whole service
+	Duration   string   `json:"duration"`
+}
+
+// getSilenceHandler return http handler function to /silence endpoint
+func getSilenceHandler(repository *service.Repository) func(w net_http.ResponseWriter, r *net_http.Request) {
+	return func(w net_http.ResponseWriter, r *net_http.Request) {
+		if r.Method != net_http.MethodPost {
+			net_http.Error(w, "Method not allowed", net_http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req silenceRequest
+		err := json.NewDecoder(net_http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req)
+		if err != nil {
+			net_http.Error(w, fmt.Sprintf("invalid request: %s", err), net_http.StatusBadRequest)
+			return
+		}
+
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			net_http.Error(w, fmt.Sprintf("invalid duration '%s', allowed positive durations", req.Duration), net_http.StatusBadRequest)
+			return
+		}
+
+		err = repository.SilenceService(req.ServiceID, req.Collectors, duration)
+		if err != nil {
+			net_http.Error(w, err.Error(), net_http.StatusBadRequest)
+			return
+		}
+
+		log.Infof("service [%s] silenced for %s, collectors: %v", req.ServiceID, duration, req.Collectors)
+
+		jsonData, err := json.Marshal(struct {
+			Status string
+		}{Status: "OK"},
+		)
+		if err != nil {
+			log.Error(err.Error())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_, err = w.Write(jsonData)
+		if err != nil {
+			log.Error(err.Error())
+		}
+	}
+}
+
+// getTopQueriesHandler return http handler function to /top-queries endpoint
+func getTopQueriesHandler(repository *service.Repository) func(w net_http.ResponseWriter, r *net_http.Request) {
+	return func(w net_http.ResponseWriter, r *net_http.Request) {
+		if r.Method != net_http.MethodGet {
+			net_http.Error(w, "Method not allowed", net_http.StatusMethodNotAllowed)
+			return
+		}
+
+		serviceID := r.URL.Query().Get("service_id")
+		if serviceID == "" {
+			net_http.Error(w, "service_id is required", net_http.StatusBadRequest)
+			return
+		}
+
+		snapshot, err := repository.TopQueries(serviceID)
+		if err != nil {
+			net_http.Error(w, err.Error(), net_http.StatusNotFound)
+			return
+		}
+
+		jsonData, err := json.Marshal(snapshot)
+		if err != nil {
+			net_http.Error(w, err.Error(), net_http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_, err = w.Write(jsonData)
+		if err != nil {
+			log.Error(err.Error())
+		}
+	}
+}
+
+// collectedMetrics defines metrics produced by on-demand run of collector. It is the unchecked collector, because
+// descriptors are not known in advance.
+type collectedMetrics []prometheus.Metric
+
+// Describe implements the prometheus.Collector interface.
+func (m collectedMetrics) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements the prometheus.Collector interface.
+func (m collectedMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range m {
+		ch <- metric
+	}
+}
+
+// getCollectHandler return http handler function to /collect endpoint. The endpoint runs single collector of the
+// service bypassing its cached stats, e.g. /collect?service_id=postgres:5432&collector=postgres/schemas.
+func getCollectHandler(repository *service.Repository, limiter *rate.Limiter, renamer metricsRenamer) func(w net_http.ResponseWriter, r *net_http.Request) {
+	return func(w net_http.ResponseWriter, r *net_http.Request) {
+		if limiter != nil {
+			if !limiter.Allow() {
+				net_http.Error(w, "Too many requests /collect", tooManyRequests)
+				return
+			}
+		}
+
+		serviceID, name := r.URL.Query().Get("service_id"), r.URL.Query().Get("collector")
+		if serviceID == "" || name == "" {
+			net_http.Error(w, "service_id and collector are required", net_http.StatusBadRequest)
+			return
+		}
+
+		start := time.Now()
+		metrics, err := repository.CollectOne(serviceID, name)
+		if err != nil {
+			net_http.Error(w, err.Error(), net_http.StatusInternalServerError)
+			return
+		}
+
+		log.Infof("collector %s of service [%s] executed on demand in %s, %d series", name, serviceID, time.Since(start), len(metrics))
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collectedMetrics(metrics))
+		promhttp.HandlerFor(renamer.gatherer(registry), metricsHandlerOpts).ServeHTTP(w, r)
+	}
+}
+
+// getTargetsHandler return http handler function to /targets endpoint. Requests to /targets/<service type> are handled
+// as Prometheus HTTP service discovery of services of the type, see getServiceTypeTargets.
 func getTargetsHandler(repository *service.Repository, urlPrefix string, enableTLS bool) func(w net_http.ResponseWriter, r *net_http.Request) {
 	return func(w net_http.ResponseWriter, r *net_http.Request) {
+		if serviceType, ok := strings.CutPrefix(r.URL.Path, "/targets/"); ok {
+			if !slices.Contains(service.ServiceTypes(), serviceType) {
+				net_http.Error(w, fmt.Sprintf("unknown service type '%s'", serviceType), net_http.StatusNotFound)
+				return
+			}
+			writeTargets(w, getServiceTypeTargets(repository, serviceType, targetsBaseURL(r, urlPrefix, enableTLS)))
+			return
+		}
+
 		var url string
 		if urlPrefix != "" {
 			url = strings.Trim(urlPrefix, "/")
@@ -310,16 +837,81 @@ func getTargetsHandler(repository *service.Repository, urlPrefix string, enableT
 			result = append(result, *target)
 		}
 
-		jsonData, err := json.Marshal(result)
-		if err != nil {
-			net_http.Error(w, err.Error(), net_http.StatusInternalServerError)
-			return
+		writeTargets(w, result)
+	}
+}
+
+// getServiceTypeTargets returns targets of services of passed type in Prometheus HTTP service discovery format. Each
+// service is returned as a separate target group with address of pgSCV, and labels pointing Prometheus to the metrics
+// of the service: __scheme__, __metrics_path__ and __param_target. Target labels of the service are added to the group,
+// hence discovery config could specify scrape hints, e.g. __scrape_interval__ and __scrape_timeout__.
+func getServiceTypeTargets(repository *service.Repository, serviceType string, baseURL *url.URL) []target {
+	repository.RLock()
+	defer repository.RUnlock()
+
+	result := []target{}
+
+	for _, svc := range repository.Services {
+		if svc.ConnSettings.ServiceType != serviceType {
+			continue
 		}
-		w.Header().Set("Content-Type", "application/json")
-		_, err = w.Write(jsonData)
-		if err != nil {
-			log.Error(err.Error())
+
+		labels := map[string]string{
+			"__scheme__":       baseURL.Scheme,
+			"__metrics_path__": strings.TrimSuffix(baseURL.Path, "/") + "/metrics",
+			"__param_target":   svc.ServiceID,
+			"service_id":       svc.ServiceID,
+			"service_type":     serviceType,
+		}
+		if svc.TargetLabels != nil {
+			for k, v := range *svc.TargetLabels {
+				labels[k] = v
+			}
 		}
+
+		result = append(result, target{Targets: []string{baseURL.Host}, Labels: labels})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Labels["service_id"] < result[j].Labels["service_id"] })
+
+	return result
+}
+
+// targetsBaseURL returns URL of pgSCV used in targets. URL prefix is used if specified, otherwise the request host.
+func targetsBaseURL(r *net_http.Request, urlPrefix string, enableTLS bool) *url.URL {
+	scheme := "http"
+	if enableTLS {
+		scheme = "https"
+	}
+
+	if urlPrefix == "" {
+		return &url.URL{Scheme: scheme, Host: r.Host}
+	}
+
+	if !strings.Contains(urlPrefix, "://") {
+		urlPrefix = scheme + "://" + urlPrefix
+	}
+
+	u, err := url.Parse(urlPrefix)
+	if err != nil || u.Host == "" {
+		log.Warnf("invalid url_prefix '%s', use request host", urlPrefix)
+		return &url.URL{Scheme: scheme, Host: r.Host}
+	}
+
+	return u
+}
+
+// writeTargets writes targets in JSON format to the response.
+func writeTargets(w net_http.ResponseWriter, targets []target) {
+	jsonData, err := json.Marshal(targets)
+	if err != nil {
+		net_http.Error(w, err.Error(), net_http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonData)
+	if err != nil {
+		log.Error(err.Error())
 	}
 }
 
@@ -328,20 +920,41 @@ const (
 	metricsBurst = 20
 	flushRPS     = 1
 	flushBurst   = 1
+	collectRPS   = 1
+	collectBurst = 1
 )
 
 // runHTTPListener start HTTP listener accordingly to passed configuration.
 func runHTTPListener(ctx context.Context, config *Config, repository *service.Repository) error {
 	sCfg := http.ServerConfig{
-		Addr:       config.ListenAddress,
-		AuthConfig: config.AuthConfig,
+		Addr:             config.ListenAddress,
+		CompressionLevel: config.CompressionLevel,
+		AuthConfig:       config.AuthConfig,
 	}
+	var silenceHandler func(w net_http.ResponseWriter, r *net_http.Request)
+	if config.EnableSilenceAPI {
+		silenceHandler = getSilenceHandler(repository)
+	}
+
+	// Top queries expose texts of queries, hence they are available only to authenticated clients.
+	var topQueriesHandler func(w net_http.ResponseWriter, r *net_http.Request)
+	if config.AuthConfig.EnableAuth || config.AuthConfig.ClientCAfile != "" {
+		topQueriesHandler = getTopQueriesHandler(repository)
+	} else {
+		log.Info("authentication is not configured, /top-queries endpoint is disabled")
+	}
+
 	srv := http.NewServer(sCfg,
 		getMetricsHandler(repository, config.ThrottlingInterval, func() *rate.Limiter {
 			return rate.NewLimiter(rate.Every(time.Duration(metricsRPS)*time.Second), metricsBurst)
-		}),
+		}, metricsRenamer{prefix: config.MetricPrefix, namespaces: config.MetricNamespaces}),
 		getTargetsHandler(repository, config.URLPrefix, config.AuthConfig.EnableTLS),
 		getFlushHandler(repository, rate.NewLimiter(rate.Every(time.Duration(flushRPS)*time.Second), flushBurst)),
+		silenceHandler,
+		topQueriesHandler,
+		getCollectHandler(repository, rate.NewLimiter(rate.Every(time.Duration(collectRPS)*time.Second), collectBurst),
+			metricsRenamer{prefix: config.MetricPrefix, namespaces: config.MetricNamespaces}),
+		getAssetsHandler(repository, metricsRenamer{prefix: config.MetricPrefix, namespaces: config.MetricNamespaces}),
 	)
 
 	errCh := make(chan error)
@@ -2,17 +2,85 @@ package pgscv
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cherts/pgscv/internal/collector"
 	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/service"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/cherts/pgscv/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
 	"io"
+	net_http "net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 )
 
+func TestCollectOnce(t *testing.T) {
+	srv := http.TestServer(t, http.StatusOK, "")
+	defer srv.Close()
+
+	config := &Config{
+		ServicesConnsSettings: map[string]service.ConnSetting{
+			"patroni:8008": {ServiceType: model.ServiceTypePatroni, BaseURL: srv.URL},
+		},
+	}
+
+	var buf strings.Builder
+	assert.NoError(t, CollectOnce(config, []string{"system:0"}, &buf))
+	assert.Contains(t, buf.String(), `service_id="system:0"`)
+	assert.NotContains(t, buf.String(), `service_id="patroni:8008"`)
+	assert.NotContains(t, buf.String(), "go_goroutines")
+
+	// Unknown service.
+	assert.Error(t, CollectOnce(config, []string{"unknown"}, io.Discard))
+}
+
+func TestPrintQueries(t *testing.T) {
+	config := &Config{}
+
+	// System service doesn't execute queries.
+	var buf strings.Builder
+	assert.NoError(t, PrintQueries(config, "system:0", &buf))
+	assert.Equal(t, "", buf.String())
+
+	// Unknown service.
+	assert.Error(t, PrintQueries(config, "unknown", io.Discard))
+
+	// Service is connected for detecting version and extensions.
+	config = &Config{
+		ServicesConnsSettings: service.ConnsSettings{
+			"postgres:1": {ServiceType: model.ServiceTypePostgresql, Conninfo: "host=127.0.0.1 port=1 user=pgscv dbname=postgres"},
+			"postgres:2": {ServiceType: model.ServiceTypePostgresql, Conninfo: store.TestPostgresConnStr},
+		},
+		LeaderElectionConninfo: store.TestPostgresConnStr,
+	}
+	assert.Error(t, PrintQueries(config, "postgres:1", io.Discard))
+
+	buf.Reset()
+	assert.NoError(t, PrintQueries(config, "postgres:2", &buf))
+	assert.Contains(t, buf.String(), "-- postgres/activity\n")
+	assert.Contains(t, buf.String(), "-- service\n")
+	assert.Contains(t, buf.String(), "-- leader\n")
+}
+
+func Test_writeQueries(t *testing.T) {
+	var buf strings.Builder
+	assert.NoError(t, writeQueries(&buf, map[string][]string{
+		"postgres/tables":   {"SELECT 2;", "  SELECT 3\n"},
+		"postgres/activity": {"SELECT 1"},
+		"system/cpu":        nil,
+	}))
+	assert.Equal(t, "-- postgres/activity\nSELECT 1;\n\n-- postgres/tables\nSELECT 2;\n\nSELECT 3;\n\n", buf.String())
+}
+
 func TestStart(t *testing.T) {
 	writeSrv := http.TestServer(t, http.StatusOK, "")
 	defer writeSrv.Close()
@@ -69,6 +137,291 @@ func Test_runHTTPListener(t *testing.T) {
 	assert.Contains(t, string(body), "promhttp_metric_handler_requests_in_flight")
 	assert.NoError(t, resp.Body.Close())
 
+	// Make request to '/metrics' with OpenMetrics negotiation and assert response.
+	req, err := net_http.NewRequest(net_http.MethodGet, "http://127.0.0.1:5003/metrics", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	resp, err = cl.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/openmetrics-text")
+
+	body, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "# EOF")
+	assert.Contains(t, string(body), "promhttp_metric_handler_requests_created")
+	assert.NoError(t, resp.Body.Close())
+
+	// Make request to '/metrics' with compression negotiation and assert response.
+	for _, encoding := range []string{"gzip", "zstd"} {
+		req, err = net_http.NewRequest(net_http.MethodGet, "http://127.0.0.1:5003/metrics", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Accept-Encoding", encoding)
+		resp, err = cl.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+		assert.Equal(t, encoding, resp.Header.Get("Content-Encoding"))
+		assert.NoError(t, resp.Body.Close())
+	}
+
 	// Waiting for listener goroutine.
 	wg.Wait()
 }
+
+// silencedCollector is a fake collector which records passed silences, returns predefined top queries and runs only
+// 'postgres/activity' collector on demand.
+type silencedCollector struct {
+	collectors []string
+	duration   time.Duration
+	topQueries collector.TopQueriesSnapshot
+}
+
+func (c *silencedCollector) Describe(chan<- *prometheus.Desc) {}
+func (c *silencedCollector) Collect(chan<- prometheus.Metric) {}
+func (c *silencedCollector) FlushServiceConfig()              {}
+func (c *silencedCollector) Close()                           {}
+func (c *silencedCollector) Silence(collectors []string, d time.Duration) error {
+	c.collectors, c.duration = collectors, d
+	return nil
+}
+func (c *silencedCollector) CacheSnapshot() collector.CacheSnapshot {
+	return nil
+}
+func (c *silencedCollector) TopQueries() (collector.TopQueriesSnapshot, error) {
+	return c.topQueries, nil
+}
+func (c *silencedCollector) EnabledCollectors() []string {
+	return []string{"postgres/activity"}
+}
+func (c *silencedCollector) CollectOne(name string, ch chan<- prometheus.Metric) error {
+	if name != "postgres/activity" {
+		return fmt.Errorf("collector %s is not enabled", name)
+	}
+	desc := prometheus.NewDesc("postgres_up", "State of Postgres service: 0 is down, 1 is up.", nil, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+	return nil
+}
+
+func Test_getSilenceHandler(t *testing.T) {
+	c := &silencedCollector{}
+	repo := service.NewRepository()
+	repo.Services["postgres:5432"] = service.Service{ServiceID: "postgres:5432", Collector: c}
+
+	testcases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{name: "valid", method: net_http.MethodPost, body: `{"service_id": "postgres:5432", "collectors": ["postgres/activity"], "duration": "30m"}`, status: http.StatusOK},
+		{name: "wrong method", method: net_http.MethodGet, body: "", status: net_http.StatusMethodNotAllowed},
+		{name: "invalid json", method: net_http.MethodPost, body: `{"service_id": `, status: net_http.StatusBadRequest},
+		{name: "invalid duration", method: net_http.MethodPost, body: `{"service_id": "postgres:5432", "duration": "-1m"}`, status: net_http.StatusBadRequest},
+		{name: "unknown service", method: net_http.MethodPost, body: `{"service_id": "postgres:5433", "duration": "1m"}`, status: net_http.StatusBadRequest},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			getSilenceHandler(repo)(res, httptest.NewRequest(tc.method, "/silence", strings.NewReader(tc.body)))
+			assert.Equal(t, tc.status, res.Code)
+		})
+	}
+
+	assert.Equal(t, []string{"postgres/activity"}, c.collectors)
+	assert.Equal(t, 30*time.Minute, c.duration)
+}
+
+func Test_getTopQueriesHandler(t *testing.T) {
+	c := &silencedCollector{topQueries: collector.TopQueriesSnapshot{
+		Updated: time.Now(),
+		Queries: []collector.TopQuery{
+			{Database: "testdb", User: "testuser", QueryID: "123", Query: "SELECT 1", Calls: 10, TotalTimeSeconds: 2, MeanTimeSeconds: 0.2},
+		},
+	}}
+	repo := service.NewRepository()
+	repo.Services["postgres:5432"] = service.Service{ServiceID: "postgres:5432", Collector: c}
+
+	testcases := []struct {
+		name   string
+		method string
+		url    string
+		status int
+	}{
+		{name: "valid", method: net_http.MethodGet, url: "/top-queries?service_id=postgres:5432", status: net_http.StatusOK},
+		{name: "wrong method", method: net_http.MethodPost, url: "/top-queries?service_id=postgres:5432", status: net_http.StatusMethodNotAllowed},
+		{name: "no service", method: net_http.MethodGet, url: "/top-queries", status: net_http.StatusBadRequest},
+		{name: "unknown service", method: net_http.MethodGet, url: "/top-queries?service_id=postgres:5433", status: net_http.StatusNotFound},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			getTopQueriesHandler(repo)(res, httptest.NewRequest(tc.method, tc.url, nil))
+			assert.Equal(t, tc.status, res.Code)
+
+			if tc.status == net_http.StatusOK {
+				var got collector.TopQueriesSnapshot
+				assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+				assert.Equal(t, c.topQueries.Queries, got.Queries)
+			}
+		})
+	}
+}
+
+func Test_getCollectHandler(t *testing.T) {
+	repo := service.NewRepository()
+	repo.Services["postgres:5432"] = service.Service{ServiceID: "postgres:5432", Collector: &silencedCollector{}}
+
+	testcases := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{name: "valid", url: "/collect?service_id=postgres:5432&collector=postgres/activity", status: net_http.StatusOK},
+		{name: "no collector", url: "/collect?service_id=postgres:5432", status: net_http.StatusBadRequest},
+		{name: "unknown collector", url: "/collect?service_id=postgres:5432&collector=postgres/schemas", status: net_http.StatusInternalServerError},
+		{name: "unknown service", url: "/collect?service_id=postgres:5433&collector=postgres/activity", status: net_http.StatusInternalServerError},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			getCollectHandler(repo, nil, metricsRenamer{})(res, httptest.NewRequest(net_http.MethodGet, tc.url, nil))
+			assert.Equal(t, tc.status, res.Code)
+
+			if tc.status == net_http.StatusOK {
+				assert.Contains(t, res.Body.String(), "postgres_up 1")
+			}
+		})
+	}
+
+	// Requests are rate-limited.
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	handler := getCollectHandler(repo, limiter, metricsRenamer{})
+	for _, want := range []int{net_http.StatusOK, tooManyRequests} {
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(net_http.MethodGet, "/collect?service_id=postgres:5432&collector=postgres/activity", nil))
+		assert.Equal(t, want, res.Code)
+	}
+}
+
+// createdCollector is the collector producing counter with created timestamp.
+type createdCollector struct {
+	desc    *prometheus.Desc
+	created time.Time
+}
+
+func (c createdCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c createdCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.desc, prometheus.CounterValue, 10, c.created, "db1")
+}
+
+func Test_metricsHandlerOpts_created(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(createdCollector{
+		desc:    prometheus.NewDesc("postgres_database_xact_commits_total", "Test counter.", []string{"database"}, nil),
+		created: time.Unix(1700000000, 0),
+	})
+	handler := promhttp.HandlerFor(metricsRenamer{namespaces: map[string]string{"postgres": "pg"}}.gatherer(registry), metricsHandlerOpts)
+
+	// Created timestamps are exposed in OpenMetrics format.
+	req := httptest.NewRequest(net_http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `pg_database_xact_commits_total{database="db1"} 10`)
+	assert.Contains(t, res.Body.String(), `pg_database_xact_commits_created{database="db1"} 1.7e+09`)
+
+	// Classic text format has no created timestamps.
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(net_http.MethodGet, "/metrics", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `pg_database_xact_commits_total{database="db1"} 10`)
+	assert.NotContains(t, res.Body.String(), "_created")
+}
+
+func Test_getMetricsHandler_tag(t *testing.T) {
+	repo := service.NewRepository()
+	handler := getMetricsHandler(repo, nil, nil, metricsRenamer{})
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(net_http.MethodGet, "/metrics?tag=payments", nil))
+	assert.Equal(t, net_http.StatusNotFound, res.Code)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(net_http.MethodGet, "/metrics?tag=payments&target=postgres:5432", nil))
+	assert.Equal(t, net_http.StatusBadRequest, res.Code)
+}
+
+func Test_getTargetsHandler_serviceType(t *testing.T) {
+	repo := service.NewRepository()
+	repo.Services["postgres:5432"] = service.Service{
+		ServiceID:    "postgres:5432",
+		ConnSettings: service.ConnSetting{ServiceType: model.ServiceTypePostgresql},
+		TargetLabels: &map[string]string{"env": "prod", "__scrape_interval__": "30s"},
+	}
+	repo.Services["pgbouncer:6432"] = service.Service{
+		ServiceID:    "pgbouncer:6432",
+		ConnSettings: service.ConnSetting{ServiceType: model.ServiceTypePgbouncer},
+	}
+
+	res := httptest.NewRecorder()
+	getTargetsHandler(repo, "https://pgscv.example.org/monitoring", false)(res, httptest.NewRequest(net_http.MethodGet, "/targets/postgres", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.JSONEq(t, `[{"targets": ["pgscv.example.org"], "labels": {
+		"__scheme__": "https", "__metrics_path__": "/monitoring/metrics", "__param_target": "postgres:5432",
+		"service_id": "postgres:5432", "service_type": "postgres", "env": "prod", "__scrape_interval__": "30s"}}]`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	getTargetsHandler(repo, "", true)(res, httptest.NewRequest(net_http.MethodGet, "/targets/pgbouncer", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.JSONEq(t, `[{"targets": ["example.com"], "labels": {
+		"__scheme__": "https", "__metrics_path__": "/metrics", "__param_target": "pgbouncer:6432",
+		"service_id": "pgbouncer:6432", "service_type": "pgbouncer"}}]`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	getTargetsHandler(repo, "", false)(res, httptest.NewRequest(net_http.MethodGet, "/targets/patroni", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.JSONEq(t, `[]`, res.Body.String())
+
+	// Service types registered by plugins are discovered too.
+	assert.NoError(t, plugin.Register(plugin.ServiceType{Name: "test_targets", Collectors: map[string]plugin.CollectorFactory{
+		"health": func(prometheus.Labels) (plugin.Collector, error) { return nil, nil },
+	}}))
+	repo.Services["proxy:6033"] = service.Service{
+		ServiceID:    "proxy:6033",
+		ConnSettings: service.ConnSetting{ServiceType: "test_targets"},
+	}
+
+	res = httptest.NewRecorder()
+	getTargetsHandler(repo, "", false)(res, httptest.NewRequest(net_http.MethodGet, "/targets/test_targets", nil))
+	assert.Equal(t, net_http.StatusOK, res.Code)
+	assert.JSONEq(t, `[{"targets": ["example.com"], "labels": {
+		"__scheme__": "http", "__metrics_path__": "/metrics", "__param_target": "proxy:6033",
+		"service_id": "proxy:6033", "service_type": "test_targets"}}]`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	getTargetsHandler(repo, "", false)(res, httptest.NewRequest(net_http.MethodGet, "/targets/unknown", nil))
+	assert.Equal(t, net_http.StatusNotFound, res.Code)
+}
+
+func Test_metricsRenamer(t *testing.T) {
+	r := metricsRenamer{}
+	assert.Equal(t, "postgres_up", r.rename("postgres_up"))
+
+	r = metricsRenamer{prefix: "pgscv_", namespaces: map[string]string{"postgres": "pg"}}
+	assert.Equal(t, "pgscv_pg_up", r.rename("postgres_up"))
+	assert.Equal(t, "pgscv_pgbouncer_up", r.rename("pgbouncer_up"))
+	assert.Equal(t, "go_goroutines", r.rename("go_goroutines"))
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "postgres_up", Help: "Test."}))
+
+	families, err := r.gatherer(registry).Gather()
+	assert.NoError(t, err)
+	assert.Len(t, families, 1)
+	assert.Equal(t, "pgscv_pg_up", families[0].GetName())
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cherts/pgscv/internal/collector"
+	"github.com/cherts/pgscv/internal/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+// cacheSnapshotOpenTimeout defines how long to wait for the lock of the snapshot file held by another process.
+const cacheSnapshotOpenTimeout = time.Second
+
+// LoadCacheSnapshot reads stats cached by collectors of services before the last shutdown. Stats are restored when
+// collectors of services are set up, so the first scrapes after restart don't repeat heavy work. Missing file is
+// not an error, e.g. on the first start.
+func (repo *Repository) LoadCacheSnapshot(path string) error {
+	if _, err := os.Stat(filepath.Clean(path)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	db, err := bolt.Open(filepath.Clean(path), 0600, &bolt.Options{Timeout: cacheSnapshotOpenTimeout, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	snapshots := map[string]collector.CacheSnapshot{}
+	err = db.View(func(tx *bolt.Tx) error {
+		// Services are stored in separate buckets, stats of collectors are stored by names of collectors.
+		return tx.ForEach(func(serviceID []byte, b *bolt.Bucket) error {
+			snapshot := collector.CacheSnapshot{}
+			err := b.ForEach(func(name, value []byte) error {
+				var s collector.CachedStats
+				if err := json.Unmarshal(value, &s); err != nil {
+					return err
+				}
+				snapshot[string(name)] = s
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			snapshots[string(serviceID)] = snapshot
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.Lock()
+	repo.snapshots = snapshots
+	repo.Unlock()
+
+	log.Infof("cached stats of %d services loaded from %s", len(snapshots), path)
+
+	return nil
+}
+
+// SaveCacheSnapshot writes stats cached by collectors of all services into the file. Stats saved before are replaced
+// in a single transaction, so interrupted write doesn't corrupt the snapshot.
+func (repo *Repository) SaveCacheSnapshot(path string) error {
+	snapshots := map[string]collector.CacheSnapshot{}
+
+	repo.RLock()
+	for id, s := range repo.Services {
+		if s.Collector == nil {
+			continue
+		}
+		if snapshot := s.Collector.CacheSnapshot(); len(snapshot) > 0 {
+			snapshots[id] = snapshot
+		}
+	}
+	repo.RUnlock()
+
+	db, err := bolt.Open(filepath.Clean(path), 0600, &bolt.Options{Timeout: cacheSnapshotOpenTimeout})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// Remove services saved before, they could be removed from configuration or discovery.
+		var names [][]byte
+		err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, name)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+
+		for id, snapshot := range snapshots {
+			b, err := tx.CreateBucket([]byte(id))
+			if err != nil {
+				return err
+			}
+			for name, s := range snapshot {
+				value, err := json.Marshal(s)
+				if err != nil {
+					return err
+				}
+				if err := b.Put([]byte(name), value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("cached stats of %d services saved to %s", len(snapshots), path)
+
+	return nil
+}
+
+// takeCacheSnapshot returns stats cached by collectors of the service before the last shutdown. Stats are returned
+// once, services set up again (e.g. rediscovered) start with empty cache.
+func (repo *Repository) takeCacheSnapshot(serviceID string) collector.CacheSnapshot {
+	repo.Lock()
+	defer repo.Unlock()
+
+	snapshot := repo.snapshots[serviceID]
+	delete(repo.snapshots, serviceID)
+
+	return snapshot
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cherts/pgscv/internal/collector"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CacheSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	// Missing file is not an error.
+	r := NewRepository()
+	assert.NoError(t, r.LoadCacheSnapshot(path))
+	assert.Nil(t, r.takeCacheSnapshot("test"))
+
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := collector.CacheSnapshot{
+		"postgres/checksums": {Updated: updated, Stats: []byte(`{"duration":12.5,"databases":{"db1":{"pages":100,"failures":1}}}`)},
+	}
+
+	newCollector := func() *collector.PgscvCollector {
+		c, err := collector.NewPgscvCollector("test", collector.Factories{"postgres/checksums": collector.NewPostgresChecksumsCollector}, collector.Config{
+			ServiceType: model.ServiceTypePostgresql,
+			ConnString:  "host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures",
+		})
+		require.NoError(t, err)
+		return c
+	}
+
+	c := newCollector()
+	c.RestoreCache(want)
+	r.addService(Service{ServiceID: "test", Collector: c})
+	require.NoError(t, r.SaveCacheSnapshot(path))
+
+	// Services which are not monitored anymore are removed from snapshot.
+	r.RemoveService("test")
+	other := newCollector()
+	other.RestoreCache(want)
+	r.addService(Service{ServiceID: "other", Collector: other})
+	require.NoError(t, r.SaveCacheSnapshot(path))
+
+	loaded := NewRepository()
+	require.NoError(t, loaded.LoadCacheSnapshot(path))
+	assert.Nil(t, loaded.takeCacheSnapshot("test"))
+
+	// Snapshot is taken once.
+	got := loaded.takeCacheSnapshot("other")
+	require.Contains(t, got, "postgres/checksums")
+	assert.True(t, updated.Equal(got["postgres/checksums"].Updated))
+	assert.JSONEq(t, string(want["postgres/checksums"].Stats), string(got["postgres/checksums"].Stats))
+	assert.Nil(t, loaded.takeCacheSnapshot("other"))
+
+	// Invalid file is reported.
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0600))
+	assert.Error(t, NewRepository().LoadCacheSnapshot(path))
+}
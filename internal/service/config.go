@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cherts/pgscv/internal/http"
 	"github.com/cherts/pgscv/internal/model"
 )
 
@@ -25,6 +26,14 @@ type ConnSetting struct {
 	BaseURL string `yaml:"baseurl"`
 	//TargetLabels array of labels for /targets endpoint
 	TargetLabels *[]Label `yaml:"target_labels"`
+	// Tags defines tags of the service, metrics of services with the same tag are exposed via /metrics?tag=<tag>.
+	Tags []string `yaml:"tags"`
+	// SessionSettings defines session settings (GUCs) applied to connections used by collectors, Postgres only.
+	SessionSettings map[string]string `yaml:"session_settings"`
+	// MetricsAllowlist defines metric families exposed by the service, overrides global metrics_allowlist.
+	MetricsAllowlist []string `yaml:"metrics_allowlist"`
+	// HTTPAuth defines credentials and TLS settings for connecting to HTTP services, Patroni only.
+	HTTPAuth http.ClientAuthConfig `yaml:",inline"`
 }
 
 // ConnsSettings defines a set of all connection settings of exact services.
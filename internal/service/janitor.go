@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cherts/pgscv/internal/collector"
+	"github.com/cherts/pgscv/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// JanitorInterval defines how often orphaned registries and cached data of removed services are looked for.
+const JanitorInterval = 5 * time.Minute
+
+// janitor counts registries and cached data released after services have been removed from the repo.
+type janitor struct {
+	mu       sync.Mutex
+	released map[string]float64 // number of released objects by kind
+	desc     *prometheus.Desc
+}
+
+// newJanitor creates new janitor.
+func newJanitor() *janitor {
+	return &janitor{
+		released: map[string]float64{"registry": 0, "cache": 0},
+		desc: prometheus.NewDesc(
+			"pgscv_janitor_released_total",
+			"Total number of registries and cache entries released after services have been removed, by kind.",
+			[]string{"kind"}, nil,
+		),
+	}
+}
+
+// add accounts released objects of passed kind.
+func (j *janitor) add(kind string, n int) {
+	if n == 0 {
+		return
+	}
+
+	j.mu.Lock()
+	j.released[kind] += float64(n)
+	j.mu.Unlock()
+}
+
+// Describe implements the prometheus.Collector interface.
+func (j *janitor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- j.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (j *janitor) Collect(ch chan<- prometheus.Metric) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for kind, v := range j.released {
+		ch <- prometheus.MustNewConstMetric(j.desc, prometheus.CounterValue, v, kind)
+	}
+}
+
+// Janitor returns collector exposing number of registries and cache entries released after services removal.
+func (repo *Repository) Janitor() prometheus.Collector {
+	return repo.janitor
+}
+
+// RunJanitor periodically releases registries and cached data left after removed services, until context is done.
+func (repo *Repository) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			registries, caches := repo.reapOrphans()
+			if registries > 0 || caches > 0 {
+				log.Infof("janitor: released %d orphaned registries and %d cache entries", registries, caches)
+			}
+		}
+	}
+}
+
+// reapOrphans releases registries and cached data of services which are not registered in the repo. Returns
+// number of released registries and cache entries.
+func (repo *Repository) reapOrphans() (int, int) {
+	repo.Lock()
+	var registries int
+	for id := range repo.Registries {
+		if _, ok := repo.Services[id]; !ok {
+			delete(repo.Registries, id)
+			registries++
+		}
+	}
+
+	active := make(map[string]struct{}, len(repo.Services))
+	for _, s := range repo.Services {
+		active[s.ConnSettings.Conninfo] = struct{}{}
+	}
+	repo.Unlock()
+
+	caches := collector.ReapServiceCaches(func(connString string) bool {
+		_, ok := active[connString]
+		return ok
+	})
+
+	repo.janitor.add("registry", registries)
+	repo.janitor.add("cache", caches)
+
+	return registries, caches
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_RemoveService(t *testing.T) {
+	r := NewRepository()
+	s := TestPostgresService()
+	r.addService(s)
+	r.addRegistry(s.ServiceID, prometheus.NewRegistry())
+
+	r.RemoveService(s.ServiceID)
+	assert.Equal(t, 0, r.totalServices())
+	assert.NotContains(t, r.Registries, s.ServiceID)
+	assert.Equal(t, float64(1), r.janitor.released["registry"])
+
+	// Removing unknown service is a noop.
+	r.RemoveService(s.ServiceID)
+	assert.Equal(t, float64(1), r.janitor.released["registry"])
+}
+
+func TestRepository_reapOrphans(t *testing.T) {
+	r := NewRepository()
+	s := TestPostgresService()
+	r.addService(s)
+	r.addRegistry(s.ServiceID, prometheus.NewRegistry())
+	r.addRegistry("orphan", prometheus.NewRegistry())
+
+	registries, _ := r.reapOrphans()
+	assert.Equal(t, 1, registries)
+	assert.Contains(t, r.Registries, s.ServiceID)
+	assert.NotContains(t, r.Registries, "orphan")
+	assert.Equal(t, float64(1), r.janitor.released["registry"])
+
+	registries, _ = r.reapOrphans()
+	assert.Equal(t, 0, registries)
+}
+
+func Test_janitor(t *testing.T) {
+	j := newJanitor()
+	j.add("registry", 2)
+	j.add("cache", 0)
+
+	ch := make(chan prometheus.Metric, 10)
+	j.Collect(ch)
+	close(ch)
+	assert.Len(t, ch, 2)
+}
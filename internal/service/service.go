@@ -2,9 +2,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"regexp"
-	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/cherts/pgscv/internal/store"
+	"github.com/cherts/pgscv/plugin"
 	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -25,6 +27,12 @@ import (
 type PgSCVCollector interface {
 	Collector
 	FlushServiceConfig()
+	Silence(collectors []string, d time.Duration) error
+	CacheSnapshot() collector.CacheSnapshot
+	TopQueries() (collector.TopQueriesSnapshot, error)
+	CollectOne(name string, ch chan<- prometheus.Metric) error
+	EnabledCollectors() []string
+	Close()
 }
 
 // Service struct describes service - the target from which should be collected metrics.
@@ -46,6 +54,13 @@ type Service struct {
 
 const system0ServiceID = "system:0"
 
+const (
+	// serviceRetryMinInterval defines initial interval between attempts to configure unavailable service.
+	serviceRetryMinInterval = 5 * time.Second
+	// serviceRetryMaxInterval defines max interval between attempts to configure unavailable service.
+	serviceRetryMaxInterval = 5 * time.Minute
+)
+
 // Config defines service's configuration.
 type Config struct {
 	RuntimeMode   int
@@ -53,7 +68,9 @@ type Config struct {
 	ConnDefaults  map[string]string `yaml:"defaults"` // Defaults
 	ConnsSettings ConnsSettings
 	// DatabasesRE defines regexp with databases from which builtin metrics should be collected.
-	DatabasesRE        *regexp.Regexp
+	DatabasesRE *regexp.Regexp
+	// ExcludeDatabasesRE defines regexp with databases excluded from metrics collection.
+	ExcludeDatabasesRE *regexp.Regexp
 	DisabledCollectors []string
 	// CollectorsSettings defines all collector settings propagated from main YAML configuration.
 	CollectorsSettings model.CollectorsSettings
@@ -66,6 +83,76 @@ type Config struct {
 	ConnTimeout        int  // in seconds
 	ThrottlingInterval *int // in seconds, default 25
 	ConcurrencyLimit   *int
+	// CollectTopApplication defines number of applications exposed in activity breakdown, 0 means default number.
+	CollectTopApplication int
+	// MaxSeriesPerCollector defines max number of series exposed by single collector, 0 means no limit.
+	MaxSeriesPerCollector int
+	// MaxPayloadBytes defines max estimated size of series exposed by service during single scrape, 0 means no limit.
+	MaxPayloadBytes int
+	// MetricsAllowlist defines metric families exposed by services, could be overridden by settings of the service.
+	MetricsAllowlist []string
+	// RecordingRules defines metrics computed at scrape time using expressions over metrics of the service.
+	RecordingRules model.RecordingRules
+	// ChecksumsVerifyInterval defines interval of data files checksums verification, 0 means verification disabled.
+	ChecksumsVerifyInterval time.Duration
+	// ChecksumsVerifyRate defines max rate of reading data files during checksums verification, bytes per second.
+	ChecksumsVerifyRate int
+	// MaxConcurrentScrapes defines max number of services collected concurrently, 0 means no limit.
+	MaxConcurrentScrapes int
+	// ClusterIdentity defines how stable cluster identity is reflected in service labels.
+	ClusterIdentity string
+	// ClusterNameLabel defines adding 'cluster' label with value of cluster_name to metrics of Postgres services.
+	ClusterNameLabel bool
+	// ExtraLabels defines labels added to metrics of all services.
+	ExtraLabels map[string]string
+	// BuffercacheTTL defines interval during which pg_buffercache stats are reused, 0 means default interval.
+	BuffercacheTTL time.Duration
+	// StatementsQueryTTL defines interval during which query texts of statements are reused, 0 means default interval.
+	StatementsQueryTTL time.Duration
+	// StatementsQueryAll defines query texts are exposed for all statements, not only for statements in top-k.
+	StatementsQueryAll bool
+	// StatementsExplainInterval defines interval of explaining plans of the top statements, 0 means explaining disabled.
+	StatementsExplainInterval time.Duration
+	// WarmUpWindow defines window over which first execution of heavy collectors is staggered, 0 means disabled.
+	WarmUpWindow time.Duration
+	// TableBloatTables defines tables sampled using pgstattuple_approx(), in 'database.schema.table' format.
+	TableBloatTables []string
+	// TableBloatTop defines number of the largest tables of each database sampled using pgstattuple_approx().
+	TableBloatTop int
+	// TableBloatTTL defines interval during which sampled bloat of tables is reused, 0 means default interval.
+	TableBloatTTL time.Duration
+	// IndexProbesTop defines number of the largest GIN and BRIN indexes of each database probed using pgstattuple and
+	// pageinspect functions, 0 means probes disabled.
+	IndexProbesTop int
+	// IndexProbesTTL defines interval during which probed stats of indexes are reused, 0 means default interval.
+	IndexProbesTTL time.Duration
+	// DDLTracking defines whether catalog snapshots are compared between scrapes for tracking DDL activity.
+	DDLTracking bool
+	// ProbeICMP defines whether service endpoints are probed using ICMP echo in addition to TCP connect.
+	ProbeICMP bool
+	// WatchdogMaxQueryAge defines max age of pgSCV own queries, older queries are cancelled. 0 means watchdog disabled.
+	WatchdogMaxQueryAge time.Duration
+	// SchemaLabelMaxLength defines max length of label values of schema metrics. 0 means unlimited.
+	SchemaLabelMaxLength int
+	// SchemaLabelHash defines truncated label values of schema metrics should be suffixed with hash of the full value.
+	SchemaLabelHash bool
+	// SchemaMaxSeries defines max number of series of each schema metric. 0 means unlimited.
+	SchemaMaxSeries int
+	// DirWalkRate defines max number of files visited per second when calculating directories sizes. 0 means unlimited.
+	DirWalkRate int
+	// DirWalkTimeout defines max duration of waiting for calculating directory size, the last calculated size is used when
+	// calculating is not finished in time. 0 means unlimited.
+	DirWalkTimeout time.Duration
+	// DirWalkCacheTTL defines how long calculated directories sizes are reused. 0 means no caching.
+	DirWalkCacheTTL time.Duration
+	// SessionSamplingInterval defines interval of sampling active sessions for session history metrics. 0 means disabled.
+	SessionSamplingInterval time.Duration
+	// SessionHistoryMaxQueries defines max number of queries exposed by session history metrics. 0 means unlimited.
+	SessionHistoryMaxQueries int
+	// ShardIndex defines index of shard of services monitored by the instance, from 0 to ShardCount-1.
+	ShardIndex int
+	// ShardCount defines total number of shards services are split to. 0 or 1 means sharding disabled.
+	ShardCount int
 }
 
 // Collector is an interface for prometheus.Collector.
@@ -79,13 +166,29 @@ type Repository struct {
 	sync.RWMutex                    // protect concurrent access
 	Services     map[string]Service // service repo store
 	Registries   map[string]*prometheus.Registry
+	// scheduler limits number of services collected concurrently, shared by all services in the repo.
+	scheduler *collector.ScrapeScheduler
+	// snapshots defines stats cached by collectors of services before the last shutdown, by service IDs.
+	snapshots map[string]collector.CacheSnapshot
+	// janitor accounts registries and cached data released after services removal.
+	janitor *janitor
+	// shard defines part of services monitored by the instance when services are sharded across many instances.
+	shard *shard
+	// leader elects the leader among paired instances monitoring the same services, nil if election is disabled.
+	leader *collector.LeaderElector
+	// tagRegistries defines registries with collectors of services tagged with the same tag.
+	tagRegistries map[string]*prometheus.Registry
 }
 
 // NewRepository creates new services repository.
 func NewRepository() *Repository {
 	return &Repository{
-		Services:   make(map[string]Service),
-		Registries: make(map[string]*prometheus.Registry),
+		Services:      make(map[string]Service),
+		Registries:    make(map[string]*prometheus.Registry),
+		tagRegistries: make(map[string]*prometheus.Registry),
+		scheduler:     collector.NewScrapeScheduler(0),
+		janitor:       newJanitor(),
+		shard:         newShard(),
 	}
 }
 
@@ -116,6 +219,19 @@ func (repo *Repository) addRegistry(serviceID string, r *prometheus.Registry) {
 	repo.Unlock()
 }
 
+// ScrapeScheduler returns scheduler which limits number of services collected concurrently.
+func (repo *Repository) ScrapeScheduler() *collector.ScrapeScheduler {
+	return repo.scheduler
+}
+
+// EnableLeaderElection enables election of the leader among paired instances monitoring the same services, heavy
+// collectors are executed by the leader only. Must be called before services setup. Returns elector which should be run
+// for keeping leadership status up to date.
+func (repo *Repository) EnableLeaderElection(connString string, lockID int64) *collector.LeaderElector {
+	repo.leader = collector.NewLeaderElector(connString, lockID)
+	return repo.leader
+}
+
 // GetRegistry returns registry with specified serviceID
 func (repo *Repository) GetRegistry(serviceID string) *prometheus.Registry {
 	repo.RLock()
@@ -127,6 +243,26 @@ func (repo *Repository) GetRegistry(serviceID string) *prometheus.Registry {
 	return r
 }
 
+// NewServicesRegistry returns new registry with collectors of passed services. Unlike registries used for exposing
+// metrics of services, it doesn't contain metrics of pgSCV itself.
+func (repo *Repository) NewServicesRegistry(serviceIDs []string) (*prometheus.Registry, error) {
+	registry := prometheus.NewRegistry()
+
+	for _, id := range serviceIDs {
+		s := repo.getService(id)
+		if s.Collector == nil {
+			return nil, fmt.Errorf("service '%s' is not available", id)
+		}
+
+		err := registry.Register(s.Collector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
 // getService returns the service from repo with specified ID.
 func (repo *Repository) getService(id string) Service {
 	repo.RLock()
@@ -135,16 +271,35 @@ func (repo *Repository) getService(id string) Service {
 	return s
 }
 
-// RemoveService remove service from repo and unregister prometheus collector
+// RemoveService remove service from repo, unregister prometheus collector and release its registry, connections and
+// cached data.
 func (repo *Repository) RemoveService(id string) {
+	repo.shard.forget(id)
+
 	repo.Lock()
-	defer repo.Unlock()
-	if s, ok := repo.Services[id]; ok {
-		if s.Collector != nil {
-			prometheus.Unregister(s.Collector)
-		}
-		delete(repo.Services, id)
+	s, ok := repo.Services[id]
+	if !ok {
+		repo.Unlock()
+		return
+	}
+	delete(repo.Services, id)
+
+	var registries int
+	if _, ok := repo.Registries[id]; ok {
+		delete(repo.Registries, id)
+		registries++
 	}
+	repo.removeTagged(s)
+	repo.Unlock()
+
+	// Closing collector waits for its background routines, do it without holding the lock.
+	if s.Collector != nil {
+		prometheus.Unregister(s.Collector)
+		s.Collector.Close()
+	}
+
+	repo.janitor.add("registry", registries)
+	repo.janitor.add("cache", collector.ReleaseServiceCaches(s.ConnSettings.Conninfo))
 }
 
 // totalServices returns the number of services registered in the repo.
@@ -155,6 +310,31 @@ func (repo *Repository) totalServices() int {
 	return size
 }
 
+// lookupService returns ID of the service of passed type which address matches passed function. When there are
+// several matching services, the first one in order of IDs is returned.
+func (repo *Repository) lookupService(serviceType string, match func(host string, port uint16) bool) string {
+	ids := repo.GetServiceIDs()
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		s := repo.getService(id)
+		if s.ConnSettings.ServiceType != serviceType {
+			continue
+		}
+
+		pgconfig, err := pgx.ParseConfig(s.ConnSettings.Conninfo)
+		if err != nil {
+			continue
+		}
+
+		if match(pgconfig.Host, pgconfig.Port) {
+			return id
+		}
+	}
+
+	return ""
+}
+
 // GetServiceIDs returns slice of services' IDs in the repo.
 func (repo *Repository) GetServiceIDs() []string {
 	var serviceIDs = make([]string, 0, repo.totalServices())
@@ -174,6 +354,8 @@ func (repo *Repository) serviceExists(serviceID string) bool {
 func (repo *Repository) addServicesFromConfig(config Config) {
 	log.Debug("config: add services from configuration")
 
+	repo.shard.configure(config.ShardIndex, config.ShardCount)
+
 	if !repo.serviceExists(system0ServiceID) {
 		// Always add system service.
 		repo.addService(Service{ServiceID: system0ServiceID, ConnSettings: ConnSetting{ServiceType: model.ServiceTypeSystem}})
@@ -191,12 +373,30 @@ func (repo *Repository) addServicesFromConfig(config Config) {
 	// Check all passed connection settings and try to connect using them. Create a 'Service' instance
 	// in the repo.
 	for k, cs := range config.ConnsSettings {
+		// Services owned by other instances are not added to the repo.
+		if !repo.shard.owns(k) {
+			log.Debugf("service [%s] is owned by another shard, skip", k)
+			wg.Done()
+			continue
+		}
+
 		go func() {
 			defer wg.Done()
 			var msg string
 
 			if cs.ServiceType == model.ServiceTypePatroni {
-				err := attemptRequest(cs.BaseURL)
+				err := attemptRequest(cs.BaseURL, cs.HTTPAuth)
+				if err != nil {
+					if config.SkipConnErrorMode {
+						log.Warnf("%s: %s", cs.BaseURL, err)
+					} else {
+						log.Warnf("%s: %s, skip", cs.BaseURL, err)
+						return
+					}
+				}
+				msg = fmt.Sprintf("service [%s] available through: %s", k, cs.BaseURL)
+			} else if _, ok := plugin.Lookup(cs.ServiceType); ok {
+				err := CheckPluginService(cs, config.ConnTimeout)
 				if err != nil {
 					if config.SkipConnErrorMode {
 						log.Warnf("%s: %s", cs.BaseURL, err)
@@ -265,6 +465,7 @@ func (repo *Repository) addServicesFromConfig(config Config) {
 // setupServices attaches metrics exporters to the services in the repo.
 func (repo *Repository) setupServices(config Config) error {
 	log.Debug("config: setting up services")
+	repo.scheduler.SetLimit(config.MaxConcurrentScrapes)
 	sids := repo.GetServiceIDs()
 	wg := sync.WaitGroup{}
 	wg.Add(len(sids))
@@ -276,42 +477,33 @@ func (repo *Repository) setupServices(config Config) error {
 			defer wg.Done()
 			var service = repo.getService(id)
 			if service.Collector == nil {
-				factories := collector.Factories{}
-				collectorConfig := collector.Config{
-					NoTrackMode:      config.NoTrackMode,
-					ServiceType:      service.ConnSettings.ServiceType,
-					ConnString:       service.ConnSettings.Conninfo,
-					Settings:         config.CollectorsSettings,
-					DatabasesRE:      config.DatabasesRE,
-					CollectTopTable:  config.CollectTopTable,
-					CollectTopIndex:  config.CollectTopIndex,
-					CollectTopQuery:  config.CollectTopQuery,
-					ConnTimeout:      config.ConnTimeout,
-					ConcurrencyLimit: config.ConcurrencyLimit,
+				// Postgres might listen Unix socket in directory other than default one, use the directory of postmaster.
+				if service.ConnSettings.ServiceType == model.ServiceTypePostgresql {
+					service.ConnSettings.Conninfo = collector.ResolveSocketDirectory(service.ConnSettings.Conninfo)
 				}
-				if config.ConstLabels != nil && (*config.ConstLabels)[id] != nil {
-					collectorConfig.ConstLabels = (*config.ConstLabels)[id]
-				}
-				if config.TargetLabels != nil && (*config.TargetLabels)[id] != nil {
-					collectorConfig.TargetLabels = (*config.TargetLabels)[id]
+
+				factories := collector.Factories{}
+				collectorConfig := newCollectorConfig(config, service)
+				collectorConfig.ScrapeScheduler = repo.scheduler
+				collectorConfig.LeaderElector = repo.leader
+				collectorConfig.ServiceLookup = repo.lookupService
+
+				if !registerFactories(factories, service.ConnSettings.ServiceType, config.DisabledCollectors) {
+					return
 				}
 
 				switch service.ConnSettings.ServiceType {
-				case model.ServiceTypeSystem:
-					factories.RegisterSystemCollectors(config.DisabledCollectors)
+				case model.ServiceTypeSystem, model.ServiceTypePgbouncer:
 				case model.ServiceTypePostgresql:
-					factories.RegisterPostgresCollectors(config.DisabledCollectors)
 					err := collectorConfig.FillPostgresServiceConfig(config.ConnTimeout)
 					if err != nil {
 						log.Errorf("update service config failed: %s", err.Error())
 					}
-				case model.ServiceTypePgbouncer:
-					factories.RegisterPgbouncerCollectors(config.DisabledCollectors)
 				case model.ServiceTypePatroni:
-					factories.RegisterPatroniCollectors(config.DisabledCollectors)
 					collectorConfig.BaseURL = service.ConnSettings.BaseURL
+					collectorConfig.BaseURLAuth = service.ConnSettings.HTTPAuth
 				default:
-					return
+					collectorConfig.BaseURL = service.ConnSettings.BaseURL
 				}
 
 				mc, err := collector.NewPgscvCollector(service.ServiceID, factories, collectorConfig)
@@ -322,6 +514,11 @@ func (repo *Repository) setupServices(config Config) error {
 				}
 				service.Collector = mc
 
+				// Stats cached by collectors before restart are reused as if they have been collected by this instance.
+				if mc != nil {
+					mc.RestoreCache(repo.takeCacheSnapshot(service.ServiceID))
+				}
+
 				// Register collector.
 				prometheus.MustRegister(service.Collector)
 
@@ -330,8 +527,21 @@ func (repo *Repository) setupServices(config Config) error {
 				registry := prometheus.NewRegistry()
 				registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 				registry.MustRegister(collectors.NewGoCollector())
+				registry.MustRegister(log.SuppressedCollector)
+				registry.MustRegister(repo.scheduler)
+				registry.MustRegister(repo.janitor)
+				if repo.leader != nil {
+					registry.MustRegister(repo.leader)
+				}
 				registry.MustRegister(service.Collector)
 				repo.addRegistry(service.ServiceID, registry)
+				repo.addTagged(service)
+
+				// Service was unavailable, keep trying to complete its configuration in background.
+				if err == nil && config.SkipConnErrorMode && !mc.ServiceConfigured() {
+					go repo.waitServiceConfig(service.ServiceID, mc, serviceRetryMinInterval, serviceRetryMaxInterval, time.After)
+				}
+
 				log.Debugf("service configured [%s]", id)
 			}
 		}()
@@ -340,6 +550,103 @@ func (repo *Repository) setupServices(config Config) error {
 	return retErr
 }
 
+// newCollectorConfig returns configuration of collectors of the service.
+func newCollectorConfig(config Config, service Service) collector.Config {
+	collectorConfig := collector.Config{
+		NoTrackMode:               config.NoTrackMode,
+		ServiceType:               service.ConnSettings.ServiceType,
+		ConnString:                service.ConnSettings.Conninfo,
+		Settings:                  config.CollectorsSettings,
+		DatabasesRE:               config.DatabasesRE,
+		ExcludeDatabasesRE:        config.ExcludeDatabasesRE,
+		CollectTopTable:           config.CollectTopTable,
+		CollectTopIndex:           config.CollectTopIndex,
+		CollectTopQuery:           config.CollectTopQuery,
+		CollectTopApplication:     config.CollectTopApplication,
+		ConnTimeout:               config.ConnTimeout,
+		ConcurrencyLimit:          config.ConcurrencyLimit,
+		MaxSeriesPerCollector:     config.MaxSeriesPerCollector,
+		MaxPayloadBytes:           config.MaxPayloadBytes,
+		MetricsAllowlist:          config.MetricsAllowlist,
+		RecordingRules:            config.RecordingRules,
+		ChecksumsVerifyInterval:   config.ChecksumsVerifyInterval,
+		ChecksumsVerifyRate:       config.ChecksumsVerifyRate,
+		ClusterIdentity:           config.ClusterIdentity,
+		ClusterNameLabel:          config.ClusterNameLabel,
+		ExtraLabels:               config.ExtraLabels,
+		SessionSettings:           service.ConnSettings.SessionSettings,
+		BuffercacheTTL:            config.BuffercacheTTL,
+		TableBloatTables:          config.TableBloatTables,
+		TableBloatTop:             config.TableBloatTop,
+		TableBloatTTL:             config.TableBloatTTL,
+		IndexProbesTop:            config.IndexProbesTop,
+		IndexProbesTTL:            config.IndexProbesTTL,
+		StatementsQueryTTL:        config.StatementsQueryTTL,
+		StatementsQueryAll:        config.StatementsQueryAll,
+		StatementsExplainInterval: config.StatementsExplainInterval,
+		WarmUpWindow:              config.WarmUpWindow,
+		DDLTracking:               config.DDLTracking,
+		ProbeICMP:                 config.ProbeICMP,
+		WatchdogMaxQueryAge:       config.WatchdogMaxQueryAge,
+		SchemaLabelMaxLength:      config.SchemaLabelMaxLength,
+		SchemaLabelHash:           config.SchemaLabelHash,
+		SchemaMaxSeries:           config.SchemaMaxSeries,
+		DirWalkRate:               config.DirWalkRate,
+		DirWalkTimeout:            config.DirWalkTimeout,
+		DirWalkCacheTTL:           config.DirWalkCacheTTL,
+		SessionSamplingInterval:   config.SessionSamplingInterval,
+		SessionHistoryMaxQueries:  config.SessionHistoryMaxQueries,
+	}
+	if config.ConstLabels != nil && (*config.ConstLabels)[service.ServiceID] != nil {
+		collectorConfig.ConstLabels = (*config.ConstLabels)[service.ServiceID]
+	}
+	if config.TargetLabels != nil && (*config.TargetLabels)[service.ServiceID] != nil {
+		collectorConfig.TargetLabels = (*config.TargetLabels)[service.ServiceID]
+	} else if service.TargetLabels != nil {
+		collectorConfig.TargetLabels = service.TargetLabels
+	}
+	if len(service.ConnSettings.MetricsAllowlist) > 0 {
+		collectorConfig.MetricsAllowlist = service.ConnSettings.MetricsAllowlist
+	}
+
+	return collectorConfig
+}
+
+// waitServiceConfig periodically attempts to complete configuration of the service which was unavailable during
+// setup, intervals between attempts are increased exponentially up to maxInterval. Attempts are stopped when service
+// configuration is completed, or service is removed from the repo. Waiting between attempts is done using passed
+// after function, e.g. time.After.
+func (repo *Repository) waitServiceConfig(serviceID string, c *collector.PgscvCollector, minInterval, maxInterval time.Duration, after func(time.Duration) <-chan time.Time) {
+	interval := minInterval
+
+	for {
+		<-after(interval)
+
+		repo.RLock()
+		s, ok := repo.Services[serviceID]
+		repo.RUnlock()
+
+		if !ok || s.Collector != c {
+			log.Debugf("service [%s] removed, stop configuration attempts", serviceID)
+			return
+		}
+
+		// Service might be configured during scrape.
+		if c.ServiceConfigured() {
+			return
+		}
+
+		err := c.FillServiceConfig()
+		if err == nil {
+			log.Infof("service [%s] became available, configuration completed", serviceID)
+			return
+		}
+
+		interval = min(interval*2, maxInterval)
+		log.Debugf("service [%s] is still unavailable: %s; next attempt in %s", serviceID, err, interval)
+	}
+}
+
 // FlushServiceConfig postgresql services config flush
 func (repo *Repository) FlushServiceConfig() {
 	repo.RLock()
@@ -349,15 +656,179 @@ func (repo *Repository) FlushServiceConfig() {
 	}
 }
 
-// attemptRequest tries to make a real HTTP request using passed URL string.
-func attemptRequest(baseurl string) error {
+// SilenceService mutes collectors of the service for specified duration, when no collectors passed, whole service is muted.
+func (repo *Repository) SilenceService(serviceID string, collectors []string, d time.Duration) error {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	s, ok := repo.Services[serviceID]
+	if !ok {
+		return fmt.Errorf("service %s not registered", serviceID)
+	}
+	if s.Collector == nil {
+		return fmt.Errorf("service %s has no collector", serviceID)
+	}
+
+	return s.Collector.Silence(collectors, d)
+}
+
+// TopQueries returns snapshot of top statements of the service collected during the last scrape.
+func (repo *Repository) TopQueries(serviceID string) (collector.TopQueriesSnapshot, error) {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	s, ok := repo.Services[serviceID]
+	if !ok {
+		return collector.TopQueriesSnapshot{}, fmt.Errorf("service %s not registered", serviceID)
+	}
+	if s.Collector == nil {
+		return collector.TopQueriesSnapshot{}, fmt.Errorf("service %s has no collector", serviceID)
+	}
+
+	return s.Collector.TopQueries()
+}
+
+// CollectOne runs collector of the service bypassing its cached stats and returns produced metrics.
+func (repo *Repository) CollectOne(serviceID string, name string) ([]prometheus.Metric, error) {
+	repo.RLock()
+	s, ok := repo.Services[serviceID]
+	repo.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("service %s not registered", serviceID)
+	}
+	if s.Collector == nil {
+		return nil, fmt.Errorf("service %s has no collector", serviceID)
+	}
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range ch {
+			if m != nil {
+				metrics = append(metrics, m)
+			}
+		}
+		done <- metrics
+	}()
+
+	err := s.Collector.CollectOne(name, ch)
+	close(ch)
+	metrics := <-done
+
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// DeclaredQueries returns queries executed by collectors of the service defined in configuration, grouped by
+// collectors. The service is connected for detecting its version and installed extensions, but it is not added to the
+// repo.
+func (repo *Repository) DeclaredQueries(config Config, serviceID string) (map[string][]string, error) {
+	s := Service{ServiceID: serviceID, ConnSettings: ConnSetting{ServiceType: model.ServiceTypeSystem}}
+	if serviceID != system0ServiceID {
+		cs, ok := config.ConnsSettings[serviceID]
+		if !ok {
+			return nil, fmt.Errorf("service %s is not defined in configuration", serviceID)
+		}
+		s.ConnSettings = cs
+	}
+
+	factories := collector.Factories{}
+	if !registerFactories(factories, s.ConnSettings.ServiceType, config.DisabledCollectors) {
+		return nil, fmt.Errorf("unknown type %s of service %s", s.ConnSettings.ServiceType, serviceID)
+	}
+
+	collectorConfig := newCollectorConfig(config, s)
+	collectorConfig.LeaderElector = repo.leader
+	collectorConfig.ServiceLookup = repo.lookupService
+
+	return collector.DeclaredQueries(factories, collectorConfig)
+}
+
+// builtinFactories defines functions registering collectors of builtin service types.
+var builtinFactories = map[string]func(collector.Factories, []string){
+	model.ServiceTypeSystem:     collector.Factories.RegisterSystemCollectors,
+	model.ServiceTypePostgresql: collector.Factories.RegisterPostgresCollectors,
+	model.ServiceTypePgbouncer:  collector.Factories.RegisterPgbouncerCollectors,
+	model.ServiceTypePatroni:    collector.Factories.RegisterPatroniCollectors,
+}
+
+// registerFactories registers collectors of the service type, false is returned if the service type is unknown.
+func registerFactories(factories collector.Factories, serviceType string, disabled []string) bool {
+	if register, ok := builtinFactories[serviceType]; ok {
+		register(factories, disabled)
+		return true
+	}
+
+	t, ok := plugin.Lookup(serviceType)
+	if !ok {
+		return false
+	}
+	factories.RegisterPluginCollectors(t, disabled)
+
+	return true
+}
+
+// ServiceTypes returns sorted names of service types which collectors could be registered, both builtin service types
+// and service types registered by plugins.
+func ServiceTypes() []string {
+	return slices.Sorted(slices.Values(append(slices.Collect(maps.Keys(builtinFactories)), plugin.Names()...)))
+}
+
+// EnabledCollectors returns sorted names of collectors enabled for at least one registered service.
+func (repo *Repository) EnabledCollectors() []string {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	var names []string
+	for _, s := range repo.Services {
+		if s.Collector == nil {
+			continue
+		}
+		for _, name := range s.Collector.EnabledCollectors() {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// CheckPluginService checks availability of the service of plugin service type, if service type defines the check.
+func CheckPluginService(cs ConnSetting, connTimeout int) error {
+	t, ok := plugin.Lookup(cs.ServiceType)
+	if !ok {
+		return fmt.Errorf("unknown service type '%s'", cs.ServiceType)
+	}
+	if t.Check == nil {
+		return nil
+	}
+
+	timeout := time.Second
+	if connTimeout > 0 {
+		timeout = time.Duration(connTimeout) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.Check(ctx, plugin.Service{BaseURL: cs.BaseURL, Conninfo: cs.Conninfo})
+}
+
+// attemptRequest tries to make a real HTTP request using passed URL string and authentication settings.
+func attemptRequest(baseurl string, auth http.ClientAuthConfig) error {
 	url := baseurl + "/health"
 	log.Debugln("making test http request: ", url)
 
-	var client = http.NewClient(http.ClientConfig{Timeout: time.Second})
-
-	if strings.HasPrefix(url, "https://") {
-		client.EnableTLSInsecure()
+	client, err := http.NewClientWithAuth(http.ClientConfig{Timeout: time.Second}, baseurl, auth)
+	if err != nil {
+		return err
 	}
 
 	resp, err := client.Get(url) // #nosec G107
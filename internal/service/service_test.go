@@ -2,7 +2,9 @@ package service
 
 import (
 	"testing"
+	"time"
 
+	"github.com/cherts/pgscv/internal/collector"
 	"github.com/cherts/pgscv/internal/model"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
@@ -51,6 +53,21 @@ func TestRepository_getServiceIDs(t *testing.T) {
 	}
 }
 
+func TestRepository_lookupService(t *testing.T) {
+	r := NewRepository()
+	r.addService(TestSystemService())
+	r.addService(TestPostgresService())
+	r.addService(TestPgbouncerService())
+
+	match := func(port uint16) func(string, uint16) bool {
+		return func(h string, p uint16) bool { return h == "127.0.0.1" && p == port }
+	}
+
+	assert.Equal(t, "postgres:5432", r.lookupService(model.ServiceTypePostgresql, match(5432)))
+	assert.Equal(t, "", r.lookupService(model.ServiceTypePostgresql, match(6432)))
+	assert.Equal(t, "pgbouncer:6432", r.lookupService(model.ServiceTypePgbouncer, match(6432)))
+}
+
 func TestRepository_addServicesFromConfig(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -135,3 +152,43 @@ func TestRepository_setupServices(t *testing.T) {
 		prometheus.Unregister(s.Collector)
 	}
 }
+
+func TestRepository_waitServiceConfig(t *testing.T) {
+	c, err := collector.NewPgscvCollector("test", collector.Factories{}, collector.Config{
+		ServiceType: model.ServiceTypePostgresql,
+		ConnString:  "host=127.0.0.1 port=1 user=pgscv dbname=pgscv_fixtures connect_timeout=1",
+	})
+	assert.NoError(t, err)
+	assert.False(t, c.ServiceConfigured())
+
+	r := NewRepository()
+	r.addService(Service{ServiceID: "test", Collector: c})
+
+	// Fake clock fires immediately and records intervals between attempts, service is removed on the fifth wait.
+	var intervals []time.Duration
+	after := func(d time.Duration) <-chan time.Time {
+		intervals = append(intervals, d)
+		if len(intervals) == 5 {
+			r.RemoveService("test")
+		}
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}
+
+	// Service is unavailable, attempts are continued with growing intervals until service is removed.
+	r.waitServiceConfig("test", c, 10*time.Millisecond, 40*time.Millisecond, after)
+	assert.Equal(t, []time.Duration{
+		10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond,
+	}, intervals)
+}
+
+func TestServiceTypes(t *testing.T) {
+	types := ServiceTypes()
+	for _, name := range []string{model.ServiceTypeSystem, model.ServiceTypePostgresql, model.ServiceTypePgbouncer, model.ServiceTypePatroni} {
+		assert.Contains(t, types, name)
+	}
+	assert.True(t, slices.IsSorted(types))
+	assert.True(t, registerFactories(collector.Factories{}, model.ServiceTypePgbouncer, nil))
+	assert.False(t, registerFactories(collector.Factories{}, "unknown", nil))
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// shard defines part of services monitored by the pgSCV instance when services are split across many instances sharing
+// the same configuration and discovery. Services are assigned to shards by hash of service ID.
+type shard struct {
+	mu      sync.Mutex
+	index   int                 // index of the shard owned by the instance, from 0 to count-1
+	count   int                 // total number of shards, 0 or 1 means sharding is disabled
+	skipped map[string]struct{} // IDs of services owned by other shards
+	info    *prometheus.Desc
+	total   *prometheus.Desc
+}
+
+// newShard creates new shard with sharding disabled.
+func newShard() *shard {
+	return &shard{
+		skipped: map[string]struct{}{},
+		info: prometheus.NewDesc(
+			"pgscv_shard_info",
+			"Labeled information about shard of services owned by the pgSCV instance.",
+			[]string{"shard_index", "shard_count"}, nil,
+		),
+		total: prometheus.NewDesc(
+			"pgscv_shard_services",
+			"Number of services known to the pgSCV instance, by ownership.",
+			[]string{"ownership"}, nil,
+		),
+	}
+}
+
+// configure sets index of owned shard and total number of shards.
+func (s *shard) configure(index, count int) {
+	s.mu.Lock()
+	s.index, s.count = index, count
+	s.mu.Unlock()
+}
+
+// owns returns true if the service with passed ID belongs to the owned shard. Services owned by other shards are
+// remembered for exposing ownership metrics. System service is local to the host, hence always owned.
+func (s *shard) owns(serviceID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count <= 1 || serviceID == system0ServiceID || shardOf(serviceID, s.count) == s.index {
+		delete(s.skipped, serviceID)
+		return true
+	}
+
+	s.skipped[serviceID] = struct{}{}
+	return false
+}
+
+// Shard returns collector exposing sharding settings and number of owned and skipped services.
+func (repo *Repository) Shard() prometheus.Collector {
+	return &shardCollector{shard: repo.shard, owned: repo.totalServices}
+}
+
+// forget removes passed service from remembered services owned by other shards.
+func (s *shard) forget(serviceID string) {
+	s.mu.Lock()
+	delete(s.skipped, serviceID)
+	s.mu.Unlock()
+}
+
+// shardOf returns index of the shard which the service with passed ID belongs to.
+func shardOf(serviceID string, count int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(serviceID))
+	return int(h.Sum32() % uint32(count)) // #nosec G115
+}
+
+// shardCollector exposes ownership metrics of the shard.
+type shardCollector struct {
+	shard *shard
+	owned func() int
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *shardCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.shard.info
+	ch <- c.shard.total
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *shardCollector) Collect(ch chan<- prometheus.Metric) {
+	owned := c.owned()
+
+	c.shard.mu.Lock()
+	defer c.shard.mu.Unlock()
+
+	index, count := c.shard.index, c.shard.count
+	if count < 1 {
+		count = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.shard.info, prometheus.GaugeValue, 1, strconv.Itoa(index), strconv.Itoa(count))
+	ch <- prometheus.MustNewConstMetric(c.shard.total, prometheus.GaugeValue, float64(owned), "owned")
+	ch <- prometheus.MustNewConstMetric(c.shard.total, prometheus.GaugeValue, float64(len(c.shard.skipped)), "skipped")
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shard_owns(t *testing.T) {
+	s := newShard()
+
+	// Sharding is disabled by default, all services are owned.
+	assert.True(t, s.owns("postgres:5432"))
+
+	// Each service is owned by exactly one of shards.
+	owners := map[int]int{}
+	for i := range 100 {
+		id := fmt.Sprintf("postgres:%d", 5432+i)
+
+		var owned int
+		for index := range 3 {
+			s.configure(index, 3)
+			if s.owns(id) {
+				owned++
+				owners[index]++
+			}
+		}
+		assert.Equal(t, 1, owned, id)
+	}
+	assert.Len(t, owners, 3)
+
+	// System service is always owned.
+	for index := range 3 {
+		s.configure(index, 3)
+		assert.True(t, s.owns(system0ServiceID))
+	}
+}
+
+func TestRepository_Shard(t *testing.T) {
+	r := NewRepository()
+	r.addService(TestPostgresService())
+	r.shard.configure(0, 2)
+
+	// Find service owned by another shard.
+	var skipped string
+	for i := 0; skipped == ""; i++ {
+		if id := fmt.Sprintf("postgres:%d", i); shardOf(id, 2) == 1 {
+			skipped = id
+		}
+	}
+	assert.False(t, r.shard.owns(skipped))
+
+	got := collectShardMetrics(t, r.Shard())
+	assert.Equal(t, map[string]float64{"info:0/2": 1, "owned": 1, "skipped": 1}, got)
+
+	// Removed services are forgotten.
+	r.RemoveService(skipped)
+	got = collectShardMetrics(t, r.Shard())
+	assert.Equal(t, float64(0), got["skipped"])
+}
+
+func collectShardMetrics(t *testing.T, c prometheus.Collector) map[string]float64 {
+	ch := make(chan prometheus.Metric, 10)
+	c.Collect(ch)
+	close(ch)
+
+	got := map[string]float64{}
+	for m := range ch {
+		var metric dto.Metric
+		assert.NoError(t, m.Write(&metric))
+
+		l := map[string]string{}
+		for _, lp := range metric.GetLabel() {
+			l[lp.GetName()] = lp.GetValue()
+		}
+
+		if ownership, ok := l["ownership"]; ok {
+			got[ownership] = metric.GetGauge().GetValue()
+		} else {
+			got["info:"+l["shard_index"]+"/"+l["shard_count"]] = metric.GetGauge().GetValue()
+		}
+	}
+
+	return got
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// addTagged registers collector of the service in registries of all tags the service is tagged with. Registries are
+// created when tag is seen for the first time.
+func (repo *Repository) addTagged(s Service) {
+	if s.Collector == nil {
+		return
+	}
+
+	repo.Lock()
+	defer repo.Unlock()
+
+	for _, tag := range s.ConnSettings.Tags {
+		registry, ok := repo.tagRegistries[tag]
+		if !ok {
+			registry = prometheus.NewRegistry()
+			repo.tagRegistries[tag] = registry
+		}
+
+		// Collector is already registered when services are set up again.
+		_ = registry.Register(s.Collector)
+	}
+}
+
+// removeTagged unregisters collector of the removed service from registries of its tags. Registries of tags which
+// are not used by remaining services are released. Must be called with lock held.
+func (repo *Repository) removeTagged(s Service) {
+	for _, tag := range s.ConnSettings.Tags {
+		registry, ok := repo.tagRegistries[tag]
+		if !ok {
+			continue
+		}
+
+		if s.Collector != nil {
+			registry.Unregister(s.Collector)
+		}
+
+		used := false
+		for _, other := range repo.Services {
+			if slices.Contains(other.ConnSettings.Tags, tag) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			delete(repo.tagRegistries, tag)
+		}
+	}
+}
+
+// GetTagRegistry returns registry with collectors of all services tagged with passed tag.
+func (repo *Repository) GetTagRegistry(tag string) *prometheus.Registry {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	return repo.tagRegistries[tag]
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/cherts/pgscv/internal/collector"
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_tags(t *testing.T) {
+	r := NewRepository()
+
+	for id, tags := range map[string][]string{
+		"postgres:5432": {"payments"},
+		"postgres:5433": {"payments", "billing"},
+		"postgres:5434": nil,
+	} {
+		c, err := collector.NewPgscvCollector(id, collector.Factories{}, collector.Config{ServiceType: model.ServiceTypeSystem})
+		assert.NoError(t, err)
+
+		s := Service{ServiceID: id, ConnSettings: ConnSetting{ServiceType: model.ServiceTypePostgresql, Tags: tags}, Collector: c}
+		r.addService(s)
+		r.addTagged(s)
+
+		// Adding service again doesn't fail.
+		r.addTagged(s)
+	}
+
+	assert.NotNil(t, r.GetTagRegistry("payments"))
+	assert.NotNil(t, r.GetTagRegistry("billing"))
+	assert.Nil(t, r.GetTagRegistry("unknown"))
+
+	_, err := r.GetTagRegistry("payments").Gather()
+	assert.NoError(t, err)
+
+	// Registry is released when the last tagged service is removed.
+	r.RemoveService("postgres:5433")
+	assert.NotNil(t, r.GetTagRegistry("payments"))
+	assert.Nil(t, r.GetTagRegistry("billing"))
+
+	r.RemoveService("postgres:5432")
+	assert.Nil(t, r.GetTagRegistry("payments"))
+}
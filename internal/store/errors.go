@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgconn"
+)
+
+// ErrorClass defines class of errors occurred during query execution.
+type ErrorClass string
+
+// Classes of query execution errors.
+const (
+	ErrorClassConnection ErrorClass = "connection" // connection is lost or could not be established
+	ErrorClassPermission ErrorClass = "permission" // lack of privileges or authentication failure
+	ErrorClassTimeout    ErrorClass = "timeout"    // statement or lock timeout, canceled query
+	ErrorClassSyntax     ErrorClass = "syntax"     // invalid query, e.g. missing relation or function
+	ErrorClassOther      ErrorClass = "other"      // all other errors
+)
+
+// ErrorClasses defines all classes of query execution errors.
+var ErrorClasses = []ErrorClass{ErrorClassConnection, ErrorClassPermission, ErrorClassTimeout, ErrorClassSyntax, ErrorClassOther}
+
+// QueryError is the error occurred during query execution, wrapped with its class.
+type QueryError struct {
+	Class ErrorClass
+	Err   error
+}
+
+// Error implements error interface.
+func (e *QueryError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns original error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorObserver is the function which is called with class of the error occurred during query execution and flag
+// whether the query is going to be retried.
+type ErrorObserver func(class ErrorClass, retry bool)
+
+// ClassifyError returns class of passed error. Postgres errors are classified by SQLSTATE codes.
+// For details see https://www.postgresql.org/docs/current/errcodes-appendix.html
+func ClassifyError(err error) ErrorClass {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"), pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03":
+			// connection_exception, admin_shutdown, crash_shutdown, cannot_connect_now
+			return ErrorClassConnection
+		case pgErr.Code == "42501", strings.HasPrefix(pgErr.Code, "28"):
+			// insufficient_privilege, invalid_authorization_specification
+			return ErrorClassPermission
+		case pgErr.Code == "57014", pgErr.Code == "55P03":
+			// query_canceled (e.g. due to statement_timeout), lock_not_available
+			return ErrorClassTimeout
+		case strings.HasPrefix(pgErr.Code, "42"):
+			// syntax_error_or_access_rule_violation
+			return ErrorClassSyntax
+		default:
+			return ErrorClassOther
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr), pgconn.SafeToRetry(err):
+		return ErrorClassConnection
+	}
+
+	// pgx doesn't export errors of closed connections.
+	if strings.Contains(err.Error(), "conn closed") {
+		return ErrorClassConnection
+	}
+
+	return ErrorClassOther
+}
+
+// isTransientError returns true if query failed with passed error could succeed being retried. Lost connections are
+// retried after reconnect, serialization failures and deadlocks are retried on the same connection.
+func isTransientError(err error, class ErrorClass) bool {
+	if class == ErrorClassConnection {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure, deadlock_detected, lock_not_available
+		return pgErr.Code == "40001" || pgErr.Code == "40P01" || pgErr.Code == "55P03"
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	testcases := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{err: &pgconn.PgError{Code: "08006"}, want: ErrorClassConnection},
+		{err: &pgconn.PgError{Code: "57P01"}, want: ErrorClassConnection},
+		{err: &pgconn.PgError{Code: "42501"}, want: ErrorClassPermission},
+		{err: &pgconn.PgError{Code: "28P01"}, want: ErrorClassPermission},
+		{err: &pgconn.PgError{Code: "57014"}, want: ErrorClassTimeout},
+		{err: &pgconn.PgError{Code: "55P03"}, want: ErrorClassTimeout},
+		{err: &pgconn.PgError{Code: "42601"}, want: ErrorClassSyntax},
+		{err: &pgconn.PgError{Code: "42P01"}, want: ErrorClassSyntax},
+		{err: &pgconn.PgError{Code: "22012"}, want: ErrorClassOther},
+		{err: fmt.Errorf("query failed: %w", &pgconn.PgError{Code: "42883"}), want: ErrorClassSyntax},
+		{err: context.DeadlineExceeded, want: ErrorClassTimeout},
+		{err: io.ErrUnexpectedEOF, want: ErrorClassConnection},
+		{err: errors.New("conn closed"), want: ErrorClassConnection},
+		{err: errors.New("unknown"), want: ErrorClassOther},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.want, ClassifyError(tc.err), tc.err.Error())
+	}
+}
+
+func Test_isTransientError(t *testing.T) {
+	assert.True(t, isTransientError(io.EOF, ErrorClassConnection))
+	assert.True(t, isTransientError(&pgconn.PgError{Code: "40P01"}, ErrorClassOther))
+	assert.True(t, isTransientError(&pgconn.PgError{Code: "55P03"}, ErrorClassTimeout))
+	assert.False(t, isTransientError(&pgconn.PgError{Code: "57014"}, ErrorClassTimeout))
+	assert.False(t, isTransientError(&pgconn.PgError{Code: "42501"}, ErrorClassPermission))
+}
+
+func TestQueryError(t *testing.T) {
+	pgErr := &pgconn.PgError{Code: "42501", Message: "permission denied for table test"}
+	err := error(&QueryError{Class: ErrorClassPermission, Err: pgErr})
+
+	assert.Equal(t, pgErr.Error(), err.Error())
+
+	var target *pgconn.PgError
+	assert.True(t, errors.As(err, &target))
+	assert.Equal(t, "42501", target.Code)
+}
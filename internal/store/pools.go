@@ -0,0 +1,222 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Pools manages per-database connection pools of single service. Total number of connections acquired across all
+// pools is limited by maxConns, so connection limits of the service (or pooler in front of it) are respected. Pools
+// which have not been used longer than idle timeout are closed.
+type Pools struct {
+	config      *pgx.ConnConfig // base connection config, database is replaced when pool is created
+	maxConns    int32
+	idleTimeout time.Duration
+	sem         chan struct{}
+	pools       map[string]*pool
+	counters    poolsCounters
+	mu          sync.Mutex
+}
+
+// poolsCounters defines cumulative counters of acquiring connections from pools. Counters of closed pools are kept.
+type poolsCounters struct {
+	acquireCount         int64
+	acquireDuration      time.Duration
+	canceledAcquireCount int64
+	newConnsCount        int64 // connections created by closed pools
+}
+
+// PoolsStat represents statistics of connection pools of single service.
+type PoolsStat struct {
+	Pools                int           // number of open per-database pools
+	MaxConns             int32         // max number of connections acquired across all pools
+	TotalConns           int32         // number of connections currently open
+	IdleConns            int32         // number of idle connections
+	AcquiredConns        int32         // number of connections currently acquired
+	AcquireCount         int64         // cumulative number of successful acquires
+	AcquireDuration      time.Duration // total time spent waiting for connections, including waiting for max connections limit
+	CanceledAcquireCount int64         // cumulative number of failed acquires, e.g. due to timeout
+	NewConnsCount        int64         // cumulative number of created connections
+}
+
+// pool is the per-database connection pool.
+type pool struct {
+	pool     *pgxpool.Pool
+	lastUsed time.Time
+}
+
+// NewPools creates new per-database pools manager for service with passed DSN. Passed session settings are applied
+// to all connections of the pools.
+func NewPools(connString string, connTimeout int, settings map[string]string, maxConns int, idleTimeout time.Duration) (*Pools, error) {
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	if connTimeout > 0 {
+		config.ConnectTimeout = time.Duration(connTimeout) * time.Second
+	}
+
+	SetSessionSettings(config, settings)
+
+	if maxConns < 1 {
+		maxConns = 1
+	}
+
+	return &Pools{
+		config:      config,
+		maxConns:    int32(maxConns), // #nosec G115
+		idleTimeout: idleTimeout,
+		sem:         make(chan struct{}, maxConns),
+		pools:       map[string]*pool{},
+	}, nil
+}
+
+// Acquire acquires connection to the database from its pool. Waiting for the max connections limit and for the
+// connection is bounded by passed context, e.g. by deadline of the scrape. Connection must be returned using
+// DB.Close() method.
+func (p *Pools) Acquire(ctx context.Context, database string) (*DB, error) {
+	start := time.Now()
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		p.countAcquire(start, false)
+		return nil, ctx.Err()
+	}
+
+	pl, err := p.get(database)
+	if err != nil {
+		<-p.sem
+		p.countAcquire(start, false)
+		return nil, err
+	}
+
+	if p.config.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.ConnectTimeout)
+		defer cancel()
+	}
+
+	c, err := pl.Acquire(ctx)
+	if err != nil {
+		<-p.sem
+		p.countAcquire(start, false)
+		return nil, err
+	}
+	p.countAcquire(start, true)
+
+	return &DB{conn: c.Conn(), release: func() {
+		c.Release()
+		<-p.sem
+	}}, nil
+}
+
+// countAcquire accounts acquire attempt started at passed time.
+func (p *Pools) countAcquire(start time.Time, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.counters.acquireDuration += time.Since(start)
+	if ok {
+		p.counters.acquireCount++
+	} else {
+		p.counters.canceledAcquireCount++
+	}
+}
+
+// Stat returns statistics of all pools.
+func (p *Pools) Stat() PoolsStat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat := PoolsStat{
+		Pools:                len(p.pools),
+		MaxConns:             p.maxConns,
+		AcquireCount:         p.counters.acquireCount,
+		AcquireDuration:      p.counters.acquireDuration,
+		CanceledAcquireCount: p.counters.canceledAcquireCount,
+		NewConnsCount:        p.counters.newConnsCount,
+	}
+
+	for _, pl := range p.pools {
+		s := pl.pool.Stat()
+		stat.TotalConns += s.TotalConns()
+		stat.IdleConns += s.IdleConns()
+		stat.AcquiredConns += s.AcquiredConns()
+		stat.NewConnsCount += s.NewConnsCount()
+	}
+
+	return stat
+}
+
+// Close closes all pools.
+func (p *Pools) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for database, pl := range p.pools {
+		p.counters.newConnsCount += pl.pool.Stat().NewConnsCount()
+		pl.pool.Close()
+		delete(p.pools, database)
+	}
+}
+
+// get returns pool for the database, pool is created if not exists. Expired pools are closed.
+func (p *Pools) get(database string) (*pgxpool.Pool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.expire(now)
+
+	if pl, ok := p.pools[database]; ok {
+		pl.lastUsed = now
+		return pl.pool, nil
+	}
+
+	config, err := pgxpool.ParseConfig("")
+	if err != nil {
+		return nil, err
+	}
+
+	config.ConnConfig = p.config.Copy()
+	config.ConnConfig.Database = database
+	prepareConfig(config.ConnConfig)
+	config.MaxConns = p.maxConns
+	config.MinConns = 0
+	config.MaxConnIdleTime = p.idleTimeout
+	config.LazyConnect = true
+
+	pl, err := pgxpool.ConnectConfig(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	storeLog.Debugf("connection pool for database %s created", database)
+	p.pools[database] = &pool{pool: pl, lastUsed: now}
+
+	return pl, nil
+}
+
+// expire closes pools which have not been used longer than idle timeout.
+func (p *Pools) expire(now time.Time) {
+	if p.idleTimeout <= 0 {
+		return
+	}
+
+	for database, pl := range p.pools {
+		if now.Sub(pl.lastUsed) < p.idleTimeout {
+			continue
+		}
+
+		storeLog.Debugf("connection pool for database %s expired", database)
+		p.counters.newConnsCount += pl.pool.Stat().NewConnsCount()
+		delete(p.pools, database)
+
+		// Close waits until all acquired connections are released, don't block callers.
+		go pl.pool.Close()
+	}
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPools(t *testing.T) {
+	p, err := NewPools(TestPostgresConnStr, 5, nil, 2, time.Minute)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+	assert.Equal(t, int32(2), p.maxConns)
+	assert.Equal(t, 5*time.Second, p.config.ConnectTimeout)
+
+	p, err = NewPools(TestPostgresConnStr, 0, nil, 0, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), p.maxConns)
+
+	p, err = NewPools("invalid_string", 0, nil, 1, time.Minute)
+	assert.Error(t, err)
+	assert.Nil(t, p)
+}
+
+func TestPools_Acquire(t *testing.T) {
+	p, err := NewPools(TestPostgresConnStr, 0, nil, 2, time.Minute)
+	require.NoError(t, err)
+	defer p.Close()
+
+	db, err := p.Acquire(context.Background(), "pgscv_fixtures")
+	require.NoError(t, err)
+	assert.Equal(t, "pgscv_fixtures", db.Conn().Config().Database)
+
+	_, err = db.Query("SELECT 1")
+	assert.NoError(t, err)
+	db.Close()
+
+	db, err = p.Acquire(context.Background(), "postgres")
+	require.NoError(t, err)
+	db.Close()
+	assert.Len(t, p.pools, 2)
+
+	_, err = p.Acquire(context.Background(), "__invalid__")
+	assert.Error(t, err)
+
+	// Semaphore is released after failed attempts and all connections could be acquired.
+	db1, err := p.Acquire(context.Background(), "pgscv_fixtures")
+	require.NoError(t, err)
+	db2, err := p.Acquire(context.Background(), "pgscv_fixtures")
+	require.NoError(t, err)
+	db1.Close()
+	db2.Close()
+}
+
+func TestPools_Acquire_deadline(t *testing.T) {
+	p, err := NewPools(TestPostgresConnStr, 0, nil, 1, time.Minute)
+	require.NoError(t, err)
+	defer p.Close()
+
+	// All connections are acquired, waiting for the limit is bounded by context.
+	p.sem <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = p.Acquire(ctx, "postgres")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int64(1), p.Stat().CanceledAcquireCount)
+	assert.Len(t, p.pools, 0)
+}
+
+func TestPools_expire(t *testing.T) {
+	p, err := NewPools(TestPostgresConnStr, 0, nil, 1, time.Minute)
+	assert.NoError(t, err)
+
+	_, err = p.get("pgscv_fixtures")
+	assert.NoError(t, err)
+	assert.Len(t, p.pools, 1)
+
+	p.mu.Lock()
+	p.expire(time.Now().Add(time.Minute))
+	p.mu.Unlock()
+	assert.Len(t, p.pools, 0)
+}
+
+func TestPools_Stat(t *testing.T) {
+	p, err := NewPools("host=127.0.0.1 port=1 user=pgscv dbname=postgres", 1, nil, 3, time.Minute)
+	assert.NoError(t, err)
+	defer p.Close()
+
+	assert.Equal(t, PoolsStat{MaxConns: 3}, p.Stat())
+
+	// Failed attempts are accounted as canceled.
+	_, err = p.Acquire(context.Background(), "postgres")
+	assert.Error(t, err)
+
+	stat := p.Stat()
+	assert.Equal(t, 1, stat.Pools)
+	assert.Equal(t, int64(0), stat.AcquireCount)
+	assert.Equal(t, int64(1), stat.CanceledAcquireCount)
+	assert.Greater(t, stat.AcquireDuration, time.Duration(0))
+	assert.Equal(t, int32(0), stat.AcquiredConns)
+
+	// Counters are kept when pools are closed.
+	p.Close()
+	stat = p.Stat()
+	assert.Equal(t, 0, stat.Pools)
+	assert.Equal(t, int64(1), stat.CanceledAcquireCount)
+}
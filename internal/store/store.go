@@ -5,14 +5,19 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/cherts/pgscv/internal/log"
 	"github.com/cherts/pgscv/internal/model"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 )
 
 const (
+	// ApplicationName defines application_name used by pgSCV connections when it is not specified in DSN.
+	ApplicationName = "pgscv"
+
 	// Data types supported by parser of query results.
 	dataTypeBool uint32 = 16
 	// dataTypeChar uint32 = 18 is not supported - its conversion to sql.NullString lead to panic 'pgx' driver.
@@ -28,13 +33,28 @@ const (
 	dataTypeBpchar  uint32 = 1042
 	dataTypeVarchar uint32 = 1043
 	dataTypeNumeric uint32 = 1700
+
+	// queryMaxAttempts defines max number of attempts to execute query failed with transient error.
+	queryMaxAttempts = 3
+	// queryRetryBackoff defines delay before the first retry of failed query, delay is doubled before each next retry.
+	queryRetryBackoff = 100 * time.Millisecond
 )
 
+// storeLog is the logger of store module.
+var storeLog = log.Module("store")
+
 // DB is the database representation
 type DB struct {
-	conn *pgx.Conn // database connection object
+	conn          *pgx.Conn     // database connection object
+	release       func()        // returns connection to the pool, nil for standalone connections
+	observer      QueryObserver // notified about executed queries, could be nil
+	errorObserver ErrorObserver // notified about failed queries, could be nil
+	deadline      time.Time     // queries are not retried after the deadline, zero means no deadline
 }
 
+// QueryObserver is the function which is called with query text and its execution duration after query is executed.
+type QueryObserver func(query string, duration time.Duration)
+
 // New creates new connection to Postgres/Pgbouncer using passed DSN
 func New(connString string, connTimeout int) (*DB, error) {
 	config, err := pgx.ParseConfig(connString)
@@ -48,23 +68,80 @@ func New(connString string, connTimeout int) (*DB, error) {
 	return NewWithConfig(config)
 }
 
+// NewWithSettings creates new connection to Postgres using passed DSN, passed session settings are applied to the
+// connection right after it is established.
+func NewWithSettings(connString string, connTimeout int, settings map[string]string) (*DB, error) {
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	if connTimeout > 0 {
+		config.ConnectTimeout = time.Duration(connTimeout) * time.Second
+	}
+
+	SetSessionSettings(config, settings)
+
+	return NewWithConfig(config)
+}
+
 // NewWithConfig creates new connection to Postgres/Pgbouncer using passed Config.
 func NewWithConfig(config *pgx.ConnConfig) (*DB, error) {
+	prepareConfig(config)
+
+	conn, err := pgx.ConnectConfig(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{conn: conn}, nil
+}
+
+// prepareConfig sets connection options required by pgSCV.
+func prepareConfig(config *pgx.ConnConfig) {
 	// Enable simple protocol for compatibility with Pgbouncer.
 	config.PreferSimpleProtocol = true
 
+	// Keep application_name specified in DSN (or PGAPPNAME), pgSCV connections are distinguished using it. Like libpq,
+	// fallback_application_name is used when application_name is not specified, pgSCV default is used otherwise.
+	appName := config.RuntimeParams["application_name"]
+	if appName == "" {
+		appName = config.RuntimeParams["fallback_application_name"]
+	}
+	if appName == "" {
+		appName = ApplicationName
+	}
+
 	// Using simple protocol requires explicit options to be set.
 	config.RuntimeParams = map[string]string{
 		"standard_conforming_strings": "on",
 		"client_encoding":             "UTF8",
+		"application_name":            appName,
 	}
+}
 
-	conn, err := pgx.ConnectConfig(context.Background(), config)
-	if err != nil {
-		return nil, err
+// SetSessionSettings sets hook which applies session settings (GUCs) to connections after they are established. This
+// allows to limit monitoring queries using statement_timeout, lock_timeout, etc. Settings are not applied through
+// startup parameters because they are overwritten by prepareConfig and not supported by Pgbouncer.
+func SetSessionSettings(config *pgx.ConnConfig, settings map[string]string) {
+	if len(settings) == 0 {
+		return
 	}
 
-	return &DB{conn: conn}, nil
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	config.AfterConnect = func(ctx context.Context, conn *pgconn.PgConn) error {
+		for _, name := range names {
+			res := conn.ExecParams(ctx, "SELECT pg_catalog.set_config($1, $2, false)", [][]byte{[]byte(name), []byte(settings[name])}, nil, nil, nil)
+			if _, err := res.Close(); err != nil {
+				return fmt.Errorf("set session setting %s failed: %w", name, err)
+			}
+		}
+		return nil
+	}
 }
 
 /* public db methods */
@@ -74,16 +151,115 @@ func (db *DB) Query(query string, args ...any) (*model.PGResult, error) {
 	return db.query(query, args...)
 }
 
+// QueryRow executes passed query expected to return at most one row, query is reported to the query observer. Unlike
+// Query(), failed queries are not retried.
+func (db *DB) QueryRow(query string, args ...any) pgx.Row {
+	if db.observer != nil {
+		start := time.Now()
+		defer func() { db.observer(query, time.Since(start)) }()
+	}
+
+	return db.conn.QueryRow(context.Background(), query, args...)
+}
+
+// QueryRows executes passed query and returns rows which must be closed by caller, query is reported to the query
+// observer. It is intended for reading large results row by row, failed queries are not retried.
+func (db *DB) QueryRows(query string, args ...any) (pgx.Rows, error) {
+	if db.observer != nil {
+		start := time.Now()
+		defer func() { db.observer(query, time.Since(start)) }()
+	}
+
+	return db.conn.Query(context.Background(), query, args...)
+}
+
 // Close is wrapper on private close() method.
 func (db *DB) Close() { db.close() }
 
 // Conn provides access to public methods of *pgx.Conn struct
 func (db *DB) Conn() *pgx.Conn { return db.conn }
 
+// SetQueryObserver sets observer notified about queries executed using Query(), QueryRow() and QueryRows() methods.
+func (db *DB) SetQueryObserver(observer QueryObserver) { db.observer = observer }
+
+// SetErrorObserver sets observer notified about errors of queries executed using Query() method.
+func (db *DB) SetErrorObserver(observer ErrorObserver) { db.errorObserver = observer }
+
+// SetDeadline sets time after which failed queries are not retried, e.g. when scrape should be finished.
+func (db *DB) SetDeadline(deadline time.Time) { db.deadline = deadline }
+
 /* private db methods */
 
-// Query method executes passed query and wraps result into model.PGResult struct.
+// Query method executes passed query and wraps result into model.PGResult struct. Queries failed with transient
+// errors are retried with backoff until the deadline. Returned errors are wrapped into QueryError with error class.
 func (db *DB) query(query string, args ...any) (*model.PGResult, error) {
+	if db.observer != nil {
+		start := time.Now()
+		defer func() { db.observer(query, time.Since(start)) }()
+	}
+
+	backoff := queryRetryBackoff
+	for attempt := 1; ; attempt++ {
+		res, err := db.execute(query, args...)
+		if err == nil {
+			return res, nil
+		}
+
+		class := ClassifyError(err)
+		retry := attempt < queryMaxAttempts && isTransientError(err, class) && db.canRetry(backoff)
+
+		if db.errorObserver != nil {
+			db.errorObserver(class, retry)
+		}
+
+		if !retry {
+			return nil, &QueryError{Class: class, Err: err}
+		}
+
+		storeLog.Debugf("query failed with %s error: %s; retry in %s", class, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+
+		if err := db.reconnect(); err != nil {
+			return nil, &QueryError{Class: ClassifyError(err), Err: err}
+		}
+	}
+}
+
+// canRetry returns true if query could be retried after passed delay without exceeding the deadline. Queries on
+// closed pooled connections are not retried, because connection could not be re-established in place.
+func (db *DB) canRetry(delay time.Duration) bool {
+	if db.release != nil && db.conn.IsClosed() {
+		return false
+	}
+
+	return db.deadline.IsZero() || time.Now().Add(delay).Before(db.deadline)
+}
+
+// reconnect re-establishes standalone connection if it has been closed.
+func (db *DB) reconnect() error {
+	if !db.conn.IsClosed() {
+		return nil
+	}
+
+	ctx := context.Background()
+	if !db.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, db.deadline)
+		defer cancel()
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, db.conn.Config())
+	if err != nil {
+		return err
+	}
+
+	db.conn = conn
+	return nil
+}
+
+// execute executes passed query and wraps result into model.PGResult struct.
+func (db *DB) execute(query string, args ...any) (*model.PGResult, error) {
 	rows, err := db.Conn().Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
@@ -122,7 +298,7 @@ func (db *DB) query(query string, args ...any) (*model.PGResult, error) {
 
 		err = rows.Scan(pointers...)
 		if err != nil {
-			log.Warnf("skip collecting stats: %s", err)
+			storeLog.Warnf("skip collecting stats: %s", err)
 			continue
 		}
 		rowsStore = append(rowsStore, values)
@@ -139,11 +315,16 @@ func (db *DB) query(query string, args ...any) (*model.PGResult, error) {
 	}, nil
 }
 
-// Close method closes database connections gracefully.
+// Close method closes database connections gracefully, pooled connections are returned to the pool.
 func (db *DB) close() {
+	if db.release != nil {
+		db.release()
+		return
+	}
+
 	err := db.Conn().Close(context.Background())
 	if err != nil {
-		log.Warnf("failed to close database connection: %s; ignore", err)
+		storeLog.Warnf("failed to close database connection: %s; ignore", err)
 	}
 }
 
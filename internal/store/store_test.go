@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/cherts/pgscv/internal/model"
@@ -33,6 +34,48 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func Test_prepareConfig(t *testing.T) {
+	for dsn, want := range map[string]string{
+		"host=127.0.0.1 dbname=pgscv_fixtures":                                                         ApplicationName,
+		"host=127.0.0.1 dbname=pgscv_fixtures application_name=monitoring":                             "monitoring",
+		"host=127.0.0.1 dbname=pgscv_fixtures fallback_application_name=fallback":                      "fallback",
+		"host=127.0.0.1 dbname=pgscv_fixtures application_name=monitoring fallback_application_name=x": "monitoring",
+	} {
+		config, err := pgx.ParseConfig(dsn)
+		assert.NoError(t, err)
+
+		prepareConfig(config)
+		assert.Equal(t, want, config.RuntimeParams["application_name"], dsn)
+		assert.NotContains(t, config.RuntimeParams, "fallback_application_name")
+	}
+}
+
+func TestNewWithSettings(t *testing.T) {
+	db, err := NewWithSettings(TestPostgresConnStr, 0, map[string]string{"statement_timeout": "10s", "application_name": "pgscv_test"})
+	assert.NoError(t, err)
+	assert.NotNil(t, db)
+
+	var setting string
+	assert.NoError(t, db.Conn().QueryRow(context.Background(), "SELECT current_setting('statement_timeout')").Scan(&setting))
+	assert.Equal(t, "10s", setting)
+	db.Close()
+
+	db, err = NewWithSettings(TestPostgresConnStr, 0, map[string]string{"invalid_setting": "1"})
+	assert.Error(t, err)
+	assert.Nil(t, db)
+}
+
+func TestSetSessionSettings(t *testing.T) {
+	config, err := pgx.ParseConfig(TestPostgresConnStr)
+	assert.NoError(t, err)
+
+	SetSessionSettings(config, nil)
+	assert.Nil(t, config.AfterConnect)
+
+	SetSessionSettings(config, map[string]string{"lock_timeout": "1s"})
+	assert.NotNil(t, config.AfterConnect)
+}
+
 func TestNewWithConfig(t *testing.T) {
 	var testcases = []struct {
 		valid    bool
@@ -0,0 +1,39 @@
+//go:build cgo && (linux || darwin || freebsd)
+
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+)
+
+// LoadSupported defines loading of Go plugins is supported by the build. Go plugins require cgo and are supported on
+// Linux, macOS and FreeBSD only.
+const LoadSupported = true
+
+// Load opens Go plugins and registers service types exported by them as Symbol variable. Plugins must be built with
+// the same Go version and versions of dependencies as pgSCV.
+func Load(paths []string) error {
+	for _, path := range paths {
+		p, err := goplugin.Open(path)
+		if err != nil {
+			return fmt.Errorf("open plugin %s failed: %w", path, err)
+		}
+
+		sym, err := p.Lookup(Symbol)
+		if err != nil {
+			return fmt.Errorf("lookup plugin %s failed: %w", path, err)
+		}
+
+		t, ok := sym.(*ServiceType)
+		if !ok {
+			return fmt.Errorf("invalid plugin %s: %s must be of plugin.ServiceType type", path, Symbol)
+		}
+
+		if err := Register(*t); err != nil {
+			return fmt.Errorf("register plugin %s failed: %w", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build !cgo || !(linux || darwin || freebsd)
+
+package plugin
+
+import "errors"
+
+// LoadSupported defines loading of Go plugins is supported by the build. Go plugins require cgo and are supported on
+// Linux, macOS and FreeBSD only.
+const LoadSupported = false
+
+// errLoadUnsupported is returned when plugins are requested but the build can't load them.
+var errLoadUnsupported = errors.New("loading Go plugins is not supported by this build of pgSCV (built without cgo), " +
+	"rebuild with CGO_ENABLED=1 or register service types using Register in a custom build")
+
+// Load opens Go plugins and registers service types exported by them as Symbol variable. The build doesn't support
+// Go plugins, so error is returned when any plugin is passed.
+func Load(paths []string) error {
+	if len(paths) > 0 {
+		return errLoadUnsupported
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !cgo || !(linux || darwin || freebsd)
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_unsupported(t *testing.T) {
+	assert.NoError(t, Load(nil))
+	assert.ErrorIs(t, Load([]string{"testdata/plugin.so"}), errLoadUnsupported)
+}
@@ -0,0 +1,113 @@
+// Package plugin defines API for implementing additional service types monitored by pgSCV, e.g. MySQL-compatible
+// proxies or etcd. Service types are registered using Register, either in custom builds of pgSCV or by Go plugins
+// loaded using 'plugins' setting. Services of registered types are defined in 'services' setting (or discovered) like
+// builtin ones, and their metrics are exposed with the same service labels and limits.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/cherts/pgscv/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Symbol defines name of the variable of ServiceType type exported by Go plugins.
+const Symbol = "ServiceType"
+
+// Service defines service of plugin service type.
+type Service struct {
+	// BaseURL defines address of the service, as specified in 'baseurl' service setting, e.g. http://127.0.0.1:2379.
+	BaseURL string
+	// Conninfo defines connection string in service-specific format, as specified in 'conninfo' service setting.
+	Conninfo string
+}
+
+// Collector is the interface a collector of plugin service type has to implement.
+type Collector interface {
+	// Update collects metrics of the service and sends them to the channel. Metrics must be created using descriptors
+	// with const labels passed to the CollectorFactory.
+	Update(ctx context.Context, service Service, ch chan<- prometheus.Metric) error
+}
+
+// CollectorFactory creates collector, constLabels defines labels of the service (service_id, host, port, etc.) which
+// must be added to all metrics produced by collector.
+type CollectorFactory func(constLabels prometheus.Labels) (Collector, error)
+
+// ServiceType defines service type implemented by plugin.
+type ServiceType struct {
+	// Name defines name of the service type used in 'service_type' service setting and as a prefix of collectors
+	// names. Name should be used as a namespace of metrics.
+	Name string
+	// Check checks the service is available, it is used when services are registered and by 'check-config --connect'.
+	// Nil means services are always considered available.
+	Check func(ctx context.Context, service Service) error
+	// Collectors defines collectors keyed by names. Collectors are registered as '<service type>/<name>' and could be
+	// disabled using 'disable_collectors' setting like builtin ones.
+	Collectors map[string]CollectorFactory
+}
+
+var (
+	mu           sync.RWMutex
+	serviceTypes = map[string]ServiceType{}
+)
+
+// reName defines allowed names of service types and collectors.
+var reName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// builtinServiceTypes defines names of service types which can't be registered by plugins.
+var builtinServiceTypes = []string{
+	model.ServiceTypeSystem, model.ServiceTypePostgresql, model.ServiceTypePgbouncer, model.ServiceTypePatroni,
+}
+
+// Register registers service type. Service types must have unique names, which don't conflict with builtin ones.
+func Register(t ServiceType) error {
+	if !reName.MatchString(t.Name) {
+		return fmt.Errorf("invalid service type name '%s', allowed lowercase letters, digits and underscores", t.Name)
+	}
+	if slices.Contains(builtinServiceTypes, t.Name) {
+		return fmt.Errorf("service type '%s' is builtin", t.Name)
+	}
+	if len(t.Collectors) == 0 {
+		return fmt.Errorf("no collectors defined for service type '%s'", t.Name)
+	}
+	for name, factory := range t.Collectors {
+		if !reName.MatchString(name) {
+			return fmt.Errorf("invalid collector name '%s/%s', allowed lowercase letters, digits and underscores", t.Name, name)
+		}
+		if factory == nil {
+			return fmt.Errorf("collector '%s/%s' has no factory", t.Name, name)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := serviceTypes[t.Name]; ok {
+		return fmt.Errorf("service type '%s' is already registered", t.Name)
+	}
+	serviceTypes[t.Name] = t
+
+	return nil
+}
+
+// Lookup returns registered service type with passed name.
+func Lookup(name string) (ServiceType, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := serviceTypes[name]
+	return t, ok
+}
+
+// Names returns sorted names of registered service types.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(serviceTypes))
+}
@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCollector struct{}
+
+func (testCollector) Update(context.Context, Service, chan<- prometheus.Metric) error { return nil }
+
+func newTestCollector(prometheus.Labels) (Collector, error) { return testCollector{}, nil }
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(serviceTypes, "test_etcd")
+	})
+
+	collectors := map[string]CollectorFactory{"health": newTestCollector}
+
+	assert.NoError(t, Register(ServiceType{Name: "test_etcd", Collectors: collectors}))
+	assert.Error(t, Register(ServiceType{Name: "test_etcd", Collectors: collectors}))
+
+	got, ok := Lookup("test_etcd")
+	assert.True(t, ok)
+	assert.Equal(t, "test_etcd", got.Name)
+	assert.Contains(t, Names(), "test_etcd")
+
+	_, ok = Lookup("unknown")
+	assert.False(t, ok)
+
+	for _, st := range []ServiceType{
+		{Name: "", Collectors: collectors},
+		{Name: "Etcd", Collectors: collectors},
+		{Name: "postgres", Collectors: collectors},
+		{Name: "patroni", Collectors: collectors},
+		{Name: "test_empty"},
+		{Name: "test_invalid", Collectors: map[string]CollectorFactory{"raft/stats": newTestCollector}},
+		{Name: "test_nil", Collectors: map[string]CollectorFactory{"health": nil}},
+	} {
+		assert.Error(t, Register(st), st.Name)
+	}
+	assert.Equal(t, []string{"test_etcd"}, Names())
+}
+
+func TestLoad(t *testing.T) {
+	assert.NoError(t, Load(nil))
+	assert.Error(t, Load([]string{"testdata/unknown.so"}))
+}